@@ -0,0 +1,74 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"hub-api-gateway/internal/proxy"
+	"hub-api-gateway/internal/router"
+	"hub-api-gateway/internal/sdkgen"
+)
+
+// languages maps -lang values to their renderers
+var languages = map[string]func(api *sdkgen.API, source string) []byte{
+	"typescript": sdkgen.TypeScript,
+	"openapi":    sdkgen.OpenAPI,
+}
+
+// gensdk generates a typed client for the gateway from its route table and
+// the backends' proto descriptors, so frontends don't hand-write fetch
+// wrappers that drift from the gateway.
+//
+// Usage:
+//
+//	gensdk -routes config/routes.yaml -descriptors protos.pb -out web/src/gateway.ts
+//	gensdk -lang openapi -out openapi.json
+func main() {
+	routesPath := flag.String("routes", "config/routes.yaml", "route table to generate the client from")
+	descriptorSets := flag.String("descriptors", os.Getenv("PROTO_DESCRIPTOR_SETS"), "comma-separated descriptor sets for services not linked into the gateway (default: $PROTO_DESCRIPTOR_SETS)")
+	lang := flag.String("lang", "typescript", "client language (typescript, openapi)")
+	out := flag.String("out", "gateway-client.ts", "output path for the generated client")
+	flag.Parse()
+
+	render, ok := languages[*lang]
+	if !ok {
+		fail("Unsupported language %q", *lang)
+	}
+
+	serviceRouter, err := router.NewServiceRouter(*routesPath)
+	if err != nil {
+		fail("%v", err)
+	}
+
+	descriptors := proxy.NewDescriptorRegistry()
+	for _, path := range strings.Split(*descriptorSets, ",") {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+		if err := descriptors.LoadDescriptorSet(path); err != nil {
+			fail("%v", err)
+		}
+	}
+
+	api, err := sdkgen.Build(serviceRouter.GetRoutes(), descriptors.FindMethod)
+	if err != nil {
+		fail("%v", err)
+	}
+	for _, skipped := range api.Skipped {
+		fmt.Fprintf(os.Stderr, "⚠️  Skipped %s\n", skipped)
+	}
+
+	if err := os.WriteFile(*out, render(api, filepath.Base(*routesPath)), 0o644); err != nil {
+		fail("Failed to write %s: %v", *out, err)
+	}
+	fmt.Printf("✅ Wrote %s (%d operations, %d types)\n", filepath.Clean(*out), len(api.Operations), len(api.Messages)+len(api.Enums))
+}
+
+// fail prints an error and exits
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "❌ "+format+"\n", args...)
+	os.Exit(2)
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"hub-api-gateway/internal/recording"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// replay re-sends traffic recorded by the gateway (RECORDING_ENABLED) to a
+// target environment and reports responses that differ from the recorded
+// ones, e.g. after moving a route from the monolith to a microservice.
+//
+// Usage:
+//
+//	replay -file recordings.jsonl -target https://staging.hub.com -token $TOKEN
+//	replay -redis localhost:6379 -target http://localhost:8080 -routes get-order -ignore updated_at,request_id
+func main() {
+	file := flag.String("file", "recordings.jsonl", "recording file to replay")
+	redisAddr := flag.String("redis", "", "replay the recordings kept in redis at this address instead of a file")
+	redisKey := flag.String("redis-key", "recordings", "redis list holding the recordings")
+	target := flag.String("target", "", "base URL of the environment to replay against")
+	token := flag.String("token", os.Getenv("REPLAY_TOKEN"), "bearer token sent with every request (default: $REPLAY_TOKEN)")
+	routes := flag.String("routes", "", "comma-separated routes to replay (default: all)")
+	ignore := flag.String("ignore", "", "comma-separated response fields not compared, e.g. timestamps")
+	timeout := flag.Duration("timeout", 10*time.Second, "timeout of each replayed request")
+	flag.Parse()
+
+	if *target == "" {
+		fail("-target is required")
+	}
+
+	var store recording.Store = recording.NewFileStore(*file)
+	source := *file
+	if *redisAddr != "" {
+		client := redis.NewClient(&redis.Options{Addr: *redisAddr, Password: os.Getenv("REDIS_PASSWORD")})
+		defer client.Close()
+		store = recording.NewRedisStore(client, *redisKey, 0)
+		source = *redisAddr + "/" + *redisKey
+	}
+
+	exchanges, err := store.Load(context.Background())
+	if err != nil {
+		fail("Failed to load recordings: %v", err)
+	}
+
+	selected := make(map[string]bool)
+	for _, name := range splitList(*routes) {
+		selected[name] = true
+	}
+	header := http.Header{}
+	if *token != "" {
+		header.Set("Authorization", "Bearer "+*token)
+	}
+	ignored := splitList(*ignore)
+
+	client := &http.Client{Timeout: *timeout}
+	var replayed, diverged, failed int
+	for _, exchange := range exchanges {
+		if len(selected) > 0 && !selected[exchange.Route] {
+			continue
+		}
+		replayed++
+
+		status, body, err := recording.Replay(context.Background(), client, *target, exchange, header)
+		if err != nil {
+			failed++
+			fmt.Printf("❌ %s %s %s (%s): %v\n", exchange.ID, exchange.Method, exchange.Path, exchange.Route, err)
+			continue
+		}
+		if diffs := recording.Compare(exchange, status, body, ignored); len(diffs) > 0 {
+			diverged++
+			fmt.Printf("⚠️  %s %s %s (%s) diverged:\n", exchange.ID, exchange.Method, exchange.Path, exchange.Route)
+			for _, diff := range diffs {
+				fmt.Printf("     %s\n", diff)
+			}
+		}
+	}
+
+	fmt.Printf("Replayed %d of %d recorded requests from %s against %s\n", replayed, len(exchanges), source, *target)
+	fmt.Printf("  Matched: %d, diverged: %d, failed: %d\n", replayed-diverged-failed, diverged, failed)
+	if diverged > 0 || failed > 0 {
+		os.Exit(1)
+	}
+	fmt.Println("✅ All replayed responses matched")
+}
+
+// splitList parses a comma-separated flag
+func splitList(raw string) []string {
+	var values []string
+	for _, value := range strings.Split(raw, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// fail prints an error and exits
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "❌ "+format+"\n", args...)
+	os.Exit(2)
+}
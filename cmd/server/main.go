@@ -2,103 +2,730 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"reflect"
+	"strings"
 	"syscall"
 	"time"
 
+	"hub-api-gateway/internal/admin"
+	"hub-api-gateway/internal/audit"
 	"hub-api-gateway/internal/auth"
+	"hub-api-gateway/internal/bulkhead"
+	"hub-api-gateway/internal/cache"
 	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/configbundle"
+	"hub-api-gateway/internal/connlimit"
+	"hub-api-gateway/internal/controlplane"
+	"hub-api-gateway/internal/discovery"
+	"hub-api-gateway/internal/drain"
+	"hub-api-gateway/internal/egress"
+	"hub-api-gateway/internal/errorlog"
+	"hub-api-gateway/internal/errtemplate"
+	"hub-api-gateway/internal/features"
+	"hub-api-gateway/internal/health"
+	"hub-api-gateway/internal/hooks"
+	"hub-api-gateway/internal/httperr"
+	"hub-api-gateway/internal/i18n"
+	"hub-api-gateway/internal/loadshed"
+	"hub-api-gateway/internal/logging"
 	"hub-api-gateway/internal/metrics"
 	"hub-api-gateway/internal/middleware"
+	"hub-api-gateway/internal/problem"
 	"hub-api-gateway/internal/proxy"
+	"hub-api-gateway/internal/recording"
 	"hub-api-gateway/internal/router"
+	"hub-api-gateway/internal/sdkgen"
+	"hub-api-gateway/internal/secrets"
+	"hub-api-gateway/internal/status"
+	"hub-api-gateway/internal/stream"
+	"hub-api-gateway/internal/tlscert"
+	"hub-api-gateway/internal/trace"
+	"hub-api-gateway/internal/usage"
+	"hub-api-gateway/internal/watchdog"
 
 	"github.com/gorilla/mux"
 	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
 )
 
 const version = "1.0.0"
 
 func main() {
-	log.Printf("🚀 Hub API Gateway v%s starting...", version)
+	devMode := flag.Bool("dev", false, "serve fake responses generated from proto descriptors when a backend is unreachable")
+	devSeed := flag.Int64("dev-seed", 1, "seed for dev mode fake data (same seed, same data)")
+	configFile := flag.String("config", os.Getenv(config.FileEnv), "YAML config file; environment variables override its settings (default: $CONFIG_FILE)")
+	validateConfig := flag.Bool("validate-config", false, "check the configuration and routes, print a JSON report and exit (1 when invalid)")
+	flag.Parse()
+
+	if *configFile != "" {
+		os.Setenv(config.FileEnv, *configFile)
+	}
+	if *validateConfig {
+		os.Exit(validateConfiguration())
+	}
+
+	slog.Info("hub API gateway starting", "version", version)
 
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("❌ Failed to load configuration: %v", err)
+		logging.Fatal("failed to load configuration", "error", err)
+	}
+	logging.Setup(cfg.Logging.Level, cfg.Logging.Format)
+
+	// Centrally managed settings override local ones, so reload with the bundle applied
+	var (
+		configBundle *configbundle.Bundle
+		bundleSource *configbundle.Source
+		bundleKey    []byte
+	)
+	if cfg.Bundle.Location != "" {
+		bundleKey, err = configbundle.LoadKey(cfg.Bundle.Key, cfg.Bundle.KeyFile)
+		if err != nil {
+			logging.Fatal("invalid config bundle key", "error", err)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.Bundle.FetchTimeout)
+		configBundle, bundleSource, err = configbundle.Load(ctx, cfg.Bundle.Location, bundleKey, cfg.Bundle.FetchTimeout)
+		cancel()
+		if err != nil {
+			logging.Fatal("failed to load config bundle", "error", err)
+		}
+
+		if err := configBundle.ApplySettings(); err != nil {
+			logging.Fatal("failed to apply config bundle", "error", err)
+		}
+		if cfg, err = config.Load(); err != nil {
+			logging.Fatal("failed to load configuration from bundle", "error", err)
+		}
+		logging.Setup(cfg.Logging.Level, cfg.Logging.Format)
+		slog.Info("loaded config bundle", "version", configBundle.Version, "settings", len(configBundle.Settings), "routes", len(configBundle.Routes))
+	}
+
+	// Secrets kept in Vault or AWS Secrets Manager override local settings, so reload with them applied
+	var secretStore *secrets.Store
+	if cfg.Secrets.Provider != "" {
+		secretStore = secrets.NewStore(newSecretsProvider(cfg.Secrets), cfg.Secrets.Refs, cfg.Secrets.FetchTimeout)
+		if err := secretStore.Load(context.Background()); err != nil {
+			logging.Fatal("failed to fetch secrets", "provider", cfg.Secrets.Provider, "error", err)
+		}
+		if err := secretStore.Apply(); err != nil {
+			logging.Fatal("failed to apply secrets", "error", err)
+		}
+		if cfg, err = config.Load(); err != nil {
+			logging.Fatal("failed to load configuration with secrets", "error", err)
+		}
+		slog.Info("loaded secrets", "provider", cfg.Secrets.Provider, "secrets", len(cfg.Secrets.Refs))
 	}
 
 	// Initialize Redis client (optional, for caching)
 	var redisClient *redis.Client
 	if cfg.Auth.CacheEnabled {
-		redisClient = redis.NewClient(&redis.Options{
+		redisOptions := &redis.Options{
 			Addr:     fmt.Sprintf("%s:%s", cfg.Redis.Host, cfg.Redis.Port),
 			Password: cfg.Redis.Password,
 			DB:       cfg.Redis.DB,
-		})
+		}
+		// New connections authenticate with the current password, so a rotated one applies as the pool turns over
+		if secretStore != nil && secretStore.Has("REDIS_PASSWORD") {
+			redisOptions.CredentialsProvider = func() (string, string) {
+				return "", secretStore.Get("REDIS_PASSWORD")
+			}
+			secretStore.OnRotate([]string{"REDIS_PASSWORD"}, func() error {
+				return checkRedisPassword(*redisOptions, secretStore.Get("REDIS_PASSWORD"))
+			})
+		}
+		redisClient = redis.NewClient(redisOptions)
 
 		// Test Redis connectivity
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
 		if err := redisClient.Ping(ctx).Err(); err != nil {
-			log.Printf("⚠️  Warning: Redis connection failed (continuing without cache): %v", err)
+			slog.Warn("redis connection failed, continuing without cache", "error", err)
 			redisClient = nil
 		} else {
-			log.Println("✅ Connected to Redis for token caching")
+			slog.Info("connected to redis for token caching")
 		}
 	} else {
-		log.Println("ℹ️  Redis caching disabled")
+		slog.Info("redis caching disabled")
 	}
 
 	if redisClient != nil {
 		defer redisClient.Close()
 	}
 
+	// Render gateway errors as RFC 7807 problems when enabled
+	problem.Configure(cfg.Errors.ProblemJSON, cfg.Errors.DocsBaseURL)
+
+	// Replace gateway error bodies with configured templates (optional)
+	if cfg.Errors.Templates != "" {
+		templates, err := errtemplate.Load(cfg.Errors.Templates)
+		if err != nil {
+			logging.Fatal("failed to load error templates", "file", cfg.Errors.Templates, "error", err)
+		}
+		errtemplate.Configure(templates)
+		slog.Info("error templates loaded", "file", cfg.Errors.Templates)
+	}
+
+	// Translate gateway error messages by Accept-Language (optional)
+	if cfg.Errors.Locales != "" {
+		catalogs, err := i18n.Load(cfg.Errors.Locales, cfg.Errors.DefaultLocale)
+		if err != nil {
+			logging.Fatal("failed to load message catalogs", "dir", cfg.Errors.Locales, "error", err)
+		}
+		i18n.Configure(catalogs)
+		slog.Info("message catalogs loaded", "dir", cfg.Errors.Locales, "locales", catalogs.Locales())
+	}
+
+	// Initialize tamper-evident audit log (optional)
+	var auditLogger *audit.Logger
+	if cfg.Audit.Enabled {
+		signer, err := audit.NewSigner(cfg.Audit.SigningKeys, cfg.Audit.ActiveKeyID)
+		if err != nil {
+			logging.Fatal("failed to initialize audit signer", "error", err)
+		}
+		sink, last, err := newAuditSink(cfg.Audit)
+		if err != nil {
+			logging.Fatal("failed to open audit log", "sink", cfg.Audit.Sink, "error", err)
+		}
+		auditLogger = audit.NewLogger(sink, signer, last)
+		defer auditLogger.Close()
+		slog.Info("audit logging enabled", "sink", cfg.Audit.Sink, "key", cfg.Audit.ActiveKeyID)
+	}
+
+	// Resolve discovery://name service addresses, following endpoint changes
+	var serviceDiscovery *discovery.Builder
+	if cfg.Discovery.Provider != "" {
+		resolver, err := discovery.New(cfg.Discovery)
+		if err != nil {
+			logging.Fatal("failed to set up service discovery", "error", err)
+		}
+		serviceDiscovery = discovery.Register(resolver)
+		slog.Info("service discovery enabled", "provider", cfg.Discovery.Provider)
+	}
+
+	// Refuse to start if any backend address falls outside the egress allowlist
+	egressPolicy, err := egress.NewPolicy(cfg.Egress.Allowlist)
+	if err != nil {
+		logging.Fatal("invalid egress allowlist", "error", err)
+	}
+	if egressPolicy.Enabled() {
+		for name, service := range cfg.Services {
+			if err := egressPolicy.CheckTarget(context.Background(), service.Address); err != nil {
+				logging.Fatal("service violates egress policy", "service", name, "error", err)
+			}
+		}
+		slog.Info("egress policy enforced", "services", len(cfg.Services))
+	}
+
+	// Minimum backend contract versions must be comparable
+	for name, service := range cfg.Services {
+		if service.MinVersion == "" {
+			continue
+		}
+		if _, err := proxy.ParseVersion(service.MinVersion); err != nil {
+			logging.Fatal("invalid minimum backend version", "service", name, "error", err)
+		}
+		slog.Info("backend version pinned", "service", name, "min_version", service.MinVersion)
+	}
+
 	// Initialize User Service gRPC client
-	userClient, err := auth.NewUserServiceClient(cfg)
+	userClient, err := auth.NewUserServiceClient(cfg, egressPolicy)
 	if err != nil {
-		log.Fatalf("❌ Failed to create User Service client: %v", err)
+		logging.Fatal("failed to create user service client", "error", err)
 	}
 	defer userClient.Close()
 
 	// Test User Service connectivity
 	if err := userClient.Ping(context.Background()); err != nil {
-		log.Printf("⚠️  Warning: User Service connectivity check failed: %v", err)
+		slog.Warn("user service connectivity check failed", "error", err)
 	}
 
 	// Initialize metrics collector
 	metricsCollector := metrics.NewMetrics()
-	log.Println("✅ Metrics collector initialized")
+	slog.Info("metrics collector initialized")
+
+	// Initialize authentication providers and middleware
+	authProviders := auth.NewProviderRegistryFromConfig(cfg, userClient)
+	if jwtProvider, err := authProviders.Get(auth.ProviderJWT); err == nil {
+		rotateWithJWTSecret(secretStore, cfg.Secrets.RotationGrace, jwtProvider.(*auth.JWTProvider))
+	}
 
-	// Initialize authentication middleware
-	authMiddleware := middleware.NewAuthMiddleware(userClient, redisClient, cfg, metricsCollector)
+	// Reconnect tickets are validated locally so reconnect storms skip the User Service
+	var ticketIssuer *auth.TicketIssuer
+	if cfg.Auth.ReconnectTicketsEnabled {
+		ticketIssuer = auth.NewTicketIssuer(cfg.Auth.ReconnectTicketSecret, cfg.Auth.ReconnectTicketTTL, metricsCollector)
+		authProviders.Register(ticketIssuer)
+		authProviders.SetDefault(auth.CredentialTicket, auth.ProviderTicket)
+		slog.Info("reconnect tickets enabled", "ttl", cfg.Auth.ReconnectTicketTTL.String())
+	}
+	authMiddleware := middleware.NewAuthMiddleware(authProviders, redisClient, cfg, metricsCollector)
+
+	// Keep JWKS signing keys fresh so local validation never waits on a fetch
+	providersCtx, stopProviders := context.WithCancel(context.Background())
+	defer stopProviders()
+	go authProviders.Run(providersCtx)
+
+	// Concurrent session limits for (high-risk) accounts
+	var sessionLimiter *auth.SessionLimiter
+	if cfg.Auth.MaxSessions > 0 {
+		var sessionStore auth.SessionStore
+		if redisClient != nil {
+			sessionStore = auth.NewRedisSessionStore(redisClient)
+		} else {
+			slog.Warn("redis unavailable, session limits are enforced per gateway instance")
+			sessionStore = auth.NewMemorySessionStore()
+		}
+		sessionLimiter = auth.NewSessionLimiter(sessionStore, cfg.Auth.MaxSessions, cfg.Auth.SessionLimitMode, cfg.Auth.SessionLimitUsers)
+		authMiddleware.SetSessionLimiter(sessionLimiter)
+		slog.Info("session limit enabled", "max", cfg.Auth.MaxSessions, "mode", cfg.Auth.SessionLimitMode)
+	}
+
+	// Session cookies for the web app, accepted alongside bearer tokens
+	var webSessions *auth.WebSessions
+	if cfg.Auth.SessionCookies {
+		var webSessionStore auth.WebSessionStore
+		if redisClient != nil {
+			webSessionStore = auth.NewRedisWebSessionStore(redisClient)
+		} else {
+			slog.Warn("redis unavailable, session cookies only work on the gateway instance that issued them")
+			webSessionStore = auth.NewMemoryWebSessionStore()
+		}
+		webSessions = auth.NewWebSessions(webSessionStore, cfg.Auth.SessionCookieSameSite)
+		authMiddleware.SetWebSessions(webSessions)
+		slog.Info("session cookies enabled", "same_site", cfg.Auth.SessionCookieSameSite)
+	}
 
 	// Load route configuration
-	serviceRouter, err := router.NewServiceRouter("config/routes.yaml")
+	var serviceRouter *router.ServiceRouter
+	if configBundle != nil && configBundle.Routes != nil {
+		serviceRouter, err = router.NewServiceRouterFromRoutes(configBundle.Routes)
+	} else {
+		serviceRouter, err = router.NewServiceRouter("config/routes.yaml")
+	}
 	if err != nil {
-		log.Fatalf("❌ Failed to load routes: %v", err)
+		logging.Fatal("failed to load routes", "error", err)
 	}
+	descriptors, err := loadDescriptors(cfg.Proxy.DescriptorSets)
+	if err != nil {
+		logging.Fatal("failed to load descriptor set", "error", err)
+	}
+	cors := addRouteChecks(serviceRouter, cfg, egressPolicy, descriptors)
+	if errs := serviceRouter.CheckRoutes(serviceRouter.GetRoutes()); len(errs) > 0 {
+		for _, err := range errs {
+			slog.Error("invalid route", "error", err)
+		}
+		logging.Fatal("route table rejected", "problems", len(errs))
+	}
+	serviceRouter.EnableMatchCache(cfg.Server.RouteCacheSize, metricsCollector.RecordRouteCacheLookup)
 
 	// List all configured routes
 	serviceRouter.ListRoutes()
 
+	// Register route tags for tag-level metric aggregation (and refresh on reload)
+	registerRouteTags := func(routes []router.Route) {
+		tags := make(map[string]map[string]string, len(routes))
+		for _, route := range routes {
+			tags[route.Name] = route.Tags
+		}
+		metricsCollector.SetAllRouteTags(tags)
+	}
+	registerRouteTags(serviceRouter.GetRoutes())
+	serviceRouter.OnRoutesChanged(registerRouteTags)
+
+	// Extensions linked into the binary register their lifecycle hooks from init
+	extensions := hooks.Default()
+	serviceRouter.OnRoutesChanged(extensions.ConfigReloaded)
+
+	// Refresh the config bundle; route tables apply live, settings need a restart
+	if bundleSource != nil && cfg.Bundle.RefreshInterval > 0 {
+		appliedSettings := configBundle.Settings
+		watcher := configbundle.NewWatcher(bundleSource, bundleKey, cfg.Bundle.RefreshInterval, configBundle, func(bundle *configbundle.Bundle) error {
+			if !reflect.DeepEqual(bundle.Settings, appliedSettings) {
+				slog.Warn("config bundle changes gateway settings, restart to apply them", "version", bundle.Version)
+			}
+			if bundle.Routes == nil {
+				return nil
+			}
+			return serviceRouter.ReplaceRoutes(bundle.Routes)
+		})
+		bundleCtx, stopBundleWatcher := context.WithCancel(context.Background())
+		defer stopBundleWatcher()
+		go watcher.Run(bundleCtx)
+	}
+
 	// Initialize service registry for gRPC connections
 	serviceRegistry := proxy.NewServiceRegistry(cfg)
+	serviceRegistry.SetEgressPolicy(egressPolicy)
 	defer serviceRegistry.Close()
 
+	// Dial every backend before serving, so unreachable ones show up now
+	// rather than on the first user request
+	if cfg.Proxy.WarmUpEnabled {
+		warmUpCtx, cancelWarmUp := context.WithTimeout(context.Background(), cfg.Proxy.WarmUpTimeout)
+		for service, err := range serviceRegistry.WarmUp(warmUpCtx) {
+			slog.Warn("backend unreachable at startup", "service", service, "error", err)
+		}
+		cancelWarmUp()
+	}
+
+	// Probe backends with grpc.health.v1 and eject those failing the probes
+	healthProbeCtx, stopHealthProbes := context.WithCancel(context.Background())
+	defer stopHealthProbes()
+	go serviceRegistry.RunHealthProbes(healthProbeCtx)
+
+	// Keep the most recent gateway errors for on-call inspection
+	recentErrors := errorlog.NewBuffer(cfg.Admin.RecentErrorsSize)
+
+	// Sync routes and egress policy from the central control plane
+	var controlPlane *controlplane.Client
+	if cfg.ControlPlane.URL != "" {
+		controlPlane = controlplane.NewClient(controlplane.Options{
+			URL:          cfg.ControlPlane.URL,
+			Token:        cfg.ControlPlane.Token,
+			InstanceID:   cfg.ControlPlane.InstanceID,
+			PollInterval: cfg.ControlPlane.PollInterval,
+			LongPollWait: cfg.ControlPlane.LongPollWait,
+			Timeout:      cfg.ControlPlane.Timeout,
+		}, newControlPlaneApplier(cfg, serviceRouter, serviceRegistry))
+		controlPlane.OnSync(metricsCollector.RecordConfigUpdate)
+
+		// Start from the latest config when the control plane is reachable
+		ctx, cancel := context.WithTimeout(context.Background(), cfg.ControlPlane.Timeout)
+		if err := controlPlane.Sync(ctx); err != nil {
+			slog.Warn("initial control plane sync failed, continuing with local routes", "error", err)
+		}
+		cancel()
+
+		controlPlaneCtx, stopControlPlane := context.WithCancel(context.Background())
+		defer stopControlPlane()
+		go controlPlane.Run(controlPlaneCtx)
+		slog.Info("control plane sync enabled", "url", cfg.ControlPlane.URL)
+	}
+
+	// Gateway-signed tokens backends verify instead of the client's
+	var internalTokens *auth.InternalTokenIssuer
+	if cfg.InternalTokens.Enabled {
+		internalTokens, err = auth.NewInternalTokenIssuer(cfg.InternalTokens.KeyFile, cfg.InternalTokens.Issuer, cfg.InternalTokens.TTL)
+		if err != nil {
+			logging.Fatal("failed to load internal token key", "error", err)
+		}
+	}
+
+	// Breaker state changes are logged and exported, so on-call sees trips as they happen
+	serviceRegistry.OnCircuitStateChange(func(name string, from, to proxy.CircuitState) {
+		attrs := []any{"breaker", name, "from", from.String(), "to", to.String()}
+		if to == proxy.StateOpen {
+			slog.Warn("circuit breaker opened", attrs...)
+		} else {
+			slog.Info("circuit breaker state changed", attrs...)
+		}
+		metricsCollector.RecordCircuitBreakerState(name, from.String(), to.String())
+	})
+
 	// Initialize proxy handler
-	proxyHandler := proxy.NewProxyHandler(serviceRegistry, metricsCollector)
+	proxyHandler := proxy.NewProxyHandler(serviceRegistry, metricsCollector, recentErrors)
+	proxyHandler.SetDescriptors(descriptors)
+	if *devMode {
+		proxyHandler.EnableDevMode(*devSeed)
+		slog.Info("dev mode enabled, unreachable backends answer with fake data", "seed", *devSeed)
+	}
+	if cfg.Server.MockMode {
+		proxyHandler.EnableMockMode()
+		slog.Warn("mock mode enabled, routes with a mock don't call their backends", "environment", cfg.Server.Environment)
+	}
+	proxyHandler.EnableMaintenanceSnapshots(cfg.Maintenance.SnapshotCacheSize)
+
+	// Chaos testing: routes' faults, overridable through /admin/faults
+	var faultInjector *proxy.FaultInjector
+	if cfg.Faults.Enabled {
+		faultInjector = proxy.NewFaultInjector()
+		faultInjector.OnInject(func(string) { metricsCollector.RecordFaultInjected() })
+		proxyHandler.EnableFaultInjection(faultInjector)
+		slog.Warn("fault injection enabled, routes' faults will fail backend calls", "environment", cfg.Server.Environment)
+	}
+	if internalTokens != nil {
+		proxyHandler.EnableInternalTokens(internalTokens)
+	}
+
+	// Response cache for routes with a cache policy; shared via Redis when available
+	if cfg.Cache.Enabled {
+		var responseStore cache.Store = cache.NewMemoryStore(cfg.Cache.LocalSize)
+		if redisClient != nil {
+			responseStore = cache.NewTieredStore(cfg.Cache.LocalSize, cache.NewRedisStore(redisClient))
+		}
+		proxyHandler.EnableResponseCache(responseStore)
+	}
+	proxyHandler.EnableLongPolling(cfg.LongPoll.MaxWait, cfg.LongPoll.Interval)
+	proxyHandler.EnableStreaming(cfg.Stream.BufferSize, stream.Policy(cfg.Stream.SlowConsumerPolicy), cfg.Stream.HeartbeatInterval)
+	proxyHandler.EnableWebSockets(proxy.WebSocketOptions{
+		PingInterval:    cfg.WebSocket.PingInterval,
+		PongTimeout:     cfg.WebSocket.PongTimeout,
+		WriteTimeout:    cfg.WebSocket.WriteTimeout,
+		MaxMessageBytes: int64(cfg.WebSocket.MaxMessageBytes),
+		AllowedOrigins:  cfg.WebSocket.AllowedOrigins,
+	})
+	proxyHandler.SetMirrorLimit(cfg.Proxy.MirrorMaxInFlight)
+	proxyHandler.OnBackendError(extensions.BackendError)
+	if err := proxyHandler.SetGRPCErrorStatus(cfg.Proxy.GRPCErrorStatus); err != nil {
+		logging.Fatal("invalid GRPC_ERROR_STATUS", "error", err)
+	}
+	if cfg.Proxy.RetryEnabled {
+		proxyHandler.SetRetryPolicy(proxy.RetryPolicy{
+			BaseDelay:   cfg.Proxy.RetryBaseDelay,
+			MaxDelay:    cfg.Proxy.RetryMaxDelay,
+			BudgetRatio: cfg.Proxy.RetryBudgetRatio,
+			BudgetBurst: cfg.Proxy.RetryBudgetBurst,
+		})
+	}
+
+	// Cancel requests stuck far past their timeout and dump goroutines
+	var requestWatchdog *watchdog.Watchdog
+	if cfg.Watchdog.Enabled {
+		requestWatchdog = watchdog.New(watchdog.Options{
+			Interval:     cfg.Watchdog.Interval,
+			DumpInterval: cfg.Watchdog.DumpInterval,
+			DumpMaxBytes: cfg.Watchdog.DumpMaxBytes,
+		})
+		requestWatchdog.OnStuck(metricsCollector.RecordStuckRequest)
+		watchdogCtx, stopWatchdog := context.WithCancel(context.Background())
+		defer stopWatchdog()
+		go requestWatchdog.Run(watchdogCtx)
+	}
+
+	// Shed low priority traffic before the gateway runs out of memory
+	var loadShedder *loadshed.Shedder
+	if cfg.LoadShed.Enabled {
+		thresholds := loadshed.Thresholds{
+			HeapBytes:  uint64(cfg.LoadShed.HeapMB) << 20,
+			Goroutines: cfg.LoadShed.Goroutines,
+			GCPause:    cfg.LoadShed.GCPause,
+		}
+		if thresholds.HeapBytes == 0 {
+			thresholds.HeapBytes = loadshed.MemoryLimitThreshold()
+		}
+		loadShedder = loadshed.New(thresholds, cfg.LoadShed.Interval)
+		loadShedder.OnChange(func(level int, pressure float64, signals loadshed.Signals) {
+			attrs := []any{"level", level, "pressure", fmt.Sprintf("%.2f", pressure), "heap_mb", signals.HeapBytes >> 20,
+				"goroutines", signals.Goroutines, "gc_pause", signals.GCPause.String()}
+			if level > 0 {
+				slog.Warn("gateway degraded, shedding low priority traffic", attrs...)
+			} else {
+				slog.Info("gateway recovered, load shedding stopped", attrs...)
+			}
+			metricsCollector.SetLoadShedLevel(level)
+			extensions.Degraded(level)
+		})
+		loadShedder.OnShed(func(proxy.Priority) { metricsCollector.RecordLoadShed() })
+		loadShedCtx, stopLoadShed := context.WithCancel(context.Background())
+		defer stopLoadShed()
+		go loadShedder.Run(loadShedCtx)
+	}
+
+	// Cap requests in flight per backend service and per client
+	var requestBulkhead *bulkhead.Bulkhead
+	if cfg.Bulkhead.Enabled {
+		limits := bulkhead.Limits{
+			Service:  cfg.Bulkhead.ServiceLimit,
+			Services: make(map[string]int),
+			Client:   cfg.Bulkhead.ClientLimit,
+		}
+		for name, service := range cfg.Services {
+			limits.Services[name] = service.MaxConcurrent
+		}
+		requestBulkhead = bulkhead.New(limits, cfg.Bulkhead.QueueTimeout)
+		requestBulkhead.TrustForwardedFor(cfg.RateLimit.TrustForwardedFor)
+		requestBulkhead.OnReject(func(string, string) { metricsCollector.RecordBulkheadRejected() })
+	}
+
+	// Learn how much concurrency each service takes from its latency, and shed
+	// low priority routes first as a service degrades
+	var adaptiveLimiter *loadshed.AdaptiveLimiter
+	if cfg.AdaptiveLimit.Enabled {
+		adaptiveLimiter = loadshed.NewAdaptiveLimiter(loadshed.AdaptiveOptions{
+			InitialLimit: cfg.AdaptiveLimit.InitialLimit,
+			MinLimit:     cfg.AdaptiveLimit.MinLimit,
+			MaxLimit:     cfg.AdaptiveLimit.MaxLimit,
+			Tolerance:    cfg.AdaptiveLimit.Tolerance,
+		})
+		adaptiveLimiter.OnChange(metricsCollector.SetAdaptiveLimit)
+		adaptiveLimiter.OnShed(func(string, proxy.Priority) { metricsCollector.RecordAdaptiveShed() })
+	}
+
+	// Record sanitized exchanges of routes with record: true for replays
+	var trafficRecorder *recording.Recorder
+	if cfg.Recording.Enabled {
+		var store recording.Store = recording.NewFileStore(cfg.Recording.Path)
+		if cfg.Recording.Store == "redis" {
+			if redisClient != nil {
+				store = recording.NewRedisStore(redisClient, cfg.Recording.RedisKey, cfg.Recording.MaxEntries)
+			} else {
+				slog.Warn("redis unavailable, recording to file", "path", cfg.Recording.Path)
+			}
+		}
+		trafficRecorder = recording.NewRecorder(store, cfg.Recording.Percent, cfg.Recording.MaxBodyBytes, cfg.Recording.RedactFields)
+		slog.Info("traffic recording enabled", "store", cfg.Recording.Store, "percent", cfg.Recording.Percent)
+	}
+
+	// Track when each route was last matched to find dead routes
+	var routeUsage *usage.Tracker
+	if cfg.RouteUsage.Enabled {
+		routeUsage = usage.NewTracker(redisClient)
+		if err := routeUsage.Load(context.Background()); err != nil {
+			slog.Warn("failed to load route usage, tracking from now", "error", err)
+		}
+		usageCtx, stopUsage := context.WithCancel(context.Background())
+		defer func() {
+			stopUsage()
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := routeUsage.Flush(flushCtx); err != nil {
+				slog.Warn("failed to flush route usage", "error", err)
+			}
+		}()
+		go routeUsage.Run(usageCtx, cfg.RouteUsage.FlushInterval)
+		if cfg.RouteUsage.ReportInterval > 0 {
+			go routeUsage.RunReports(usageCtx, cfg.RouteUsage.ReportInterval, cfg.RouteUsage.UnusedAfter, serviceRouter.GetRoutes)
+		}
+	}
+
+	// Initialize per-user feature flag evaluation (optional)
+	var flagEvaluator *features.Evaluator
+	if cfg.Features.Enabled {
+		var flagProvider features.Provider
+		switch cfg.Features.Provider {
+		case "launchdarkly":
+			flagProvider = features.NewLaunchDarklyProvider(cfg.Features.LaunchDarklyURL, cfg.Features.LaunchDarklySDKKey, cfg.Features.Timeout)
+		case "redis":
+			if redisClient != nil {
+				flagProvider = features.NewRedisProvider(redisClient)
+			} else {
+				slog.Warn("redis unavailable, feature flag evaluation disabled")
+			}
+		}
+		if flagProvider != nil {
+			flagEvaluator = features.NewEvaluator(flagProvider, cfg.Features.Flags, cfg.Features.CacheTTL, cfg.Features.Timeout)
+			slog.Info("feature flags enabled", "provider", flagProvider.Name(), "flags", cfg.Features.Flags)
+		}
+	}
+
+	// Anti-replay guard for routes with replay_protection; nonces are shared via Redis when available
+	var nonceStore middleware.NonceStore = middleware.NewMemoryNonceStore()
+	if redisClient != nil {
+		nonceStore = middleware.NewRedisNonceStore(redisClient)
+	}
+	replayGuard := middleware.NewReplayGuard(cfg.Replay.MaxAge, nonceStore)
+
+	// Compression of large JSON responses
+	var compressor *middleware.Compressor
+	if cfg.Compression.Enabled {
+		compressor = middleware.NewCompressor(cfg.Compression.MinSize, cfg.Compression.Level)
+	}
+
+	// Idempotency-Key replay for mutating requests; responses are shared via Redis when available
+	var idempotencyGuard *middleware.IdempotencyGuard
+	if cfg.Idempotency.Enabled {
+		var idempotencyStore middleware.IdempotencyStore = middleware.NewMemoryIdempotencyStore()
+		if redisClient != nil {
+			idempotencyStore = middleware.NewRedisIdempotencyStore(redisClient)
+		}
+		idempotencyGuard = middleware.NewIdempotencyGuard(idempotencyStore, cfg.Idempotency.Window, cfg.Idempotency.LockTTL)
+	}
+
+	// Per-user and per-IP rate limiting; buckets are shared across replicas via Redis when available
+	var rateLimiter *middleware.RateLimiter
+	if cfg.RateLimit.Enabled {
+		var rateLimitStore middleware.RateLimitStore = middleware.NewMemoryRateLimitStore()
+		if redisClient != nil {
+			rateLimitStore = middleware.NewRedisRateLimitStore(redisClient)
+			slog.Info("rate limiting enabled, shared via redis")
+		} else {
+			slog.Warn("redis unavailable, rate limits are enforced per gateway instance")
+		}
+		rateLimiter = middleware.NewRateLimiter(rateLimitStore,
+			middleware.RateLimit{Requests: cfg.RateLimit.PerUserLimit, Per: cfg.RateLimit.Window, Burst: cfg.RateLimit.PerUserBurst},
+			middleware.RateLimit{Requests: cfg.RateLimit.PerIPLimit, Per: cfg.RateLimit.Window, Burst: cfg.RateLimit.PerIPBurst},
+			metricsCollector)
+		rateLimiter.SetPreAuthLimit(middleware.RateLimit{Requests: cfg.RateLimit.PreAuthPerIP, Per: cfg.RateLimit.Window, Burst: cfg.RateLimit.PreAuthBurst})
+		rateLimiter.TrustForwardedFor(cfg.RateLimit.TrustForwardedFor)
+		if err := rateLimiter.SetExemptions(middleware.RateLimitExemptions{
+			Principals:   cfg.RateLimit.ExemptPrincipals,
+			Networks:     cfg.RateLimit.ExemptIPs,
+			BypassTokens: cfg.RateLimit.BypassTokens,
+		}); err != nil {
+			logging.Fatal("invalid rate limit exemptions", "error", err)
+		}
+	}
+
+	// Tenant of each request, from its token, subdomain or header
+	var tenantResolver *middleware.TenantResolver
+	if cfg.Tenancy.Enabled {
+		tenantResolver = middleware.NewTenantResolver(middleware.TenantOptions{
+			Sources:    cfg.Tenancy.Sources,
+			Header:     cfg.Tenancy.Header,
+			BaseDomain: cfg.Tenancy.BaseDomain,
+			Allowed:    cfg.Tenancy.Allowed,
+			Default:    cfg.Tenancy.Default,
+			Required:   cfg.Tenancy.Required,
+		})
+	}
 
 	// Create HTTP router
 	muxRouter := mux.NewRouter()
+	muxRouter.Use(middleware.RequestDeadline(cfg.Server.MaxRequestDuration))
+	muxRouter.Use(middleware.BodyLimit(cfg.Server.MaxBodySize))
 
-	// Health check endpoint
-	muxRouter.HandleFunc("/health", healthCheckHandler).Methods("GET")
+	// On-demand request traces for support investigations
+	var traceStore trace.Store
+	if cfg.Tracing.Token != "" {
+		if redisClient != nil {
+			traceStore = trace.NewRedisStore(redisClient, cfg.Tracing.TTL)
+		} else {
+			traceStore = trace.NewMemoryStore(cfg.Tracing.MaxTraces, cfg.Tracing.TTL)
+		}
+		muxRouter.Use(trace.NewRecorder(cfg.Tracing.Token, traceStore).Middleware)
+		slog.Info("request tracing enabled", "header", trace.Header, "retention", cfg.Tracing.TTL.String())
+	}
+
+	// Health checks: liveness, readiness and the full report with the config version
+	healthChecker := newHealthChecker(redisClient, serviceRouter, serviceRegistry, cfg)
+
+	// Track in-flight requests so shutdown can drain them; readiness fails once draining starts
+	shutdown := drain.NewTracker()
+	healthChecker.Register("shutdown", func(ctx context.Context) error {
+		if shutdown.Draining() {
+			return errors.New("gateway is shutting down")
+		}
+		return nil
+	})
+	muxRouter.HandleFunc("/health", newHealthCheckHandler(healthChecker, controlPlane)).Methods("GET")
+	muxRouter.HandleFunc("/health/live", healthChecker.HandleLive).Methods("GET")
+	muxRouter.HandleFunc("/health/ready", healthChecker.HandleReady).Methods("GET")
+
+	// Public status rollup for the customer-facing status page
+	if cfg.Status.Enabled {
+		statusHandler := status.NewHandler(cfg.Status, serviceRegistry, metricsCollector)
+		muxRouter.HandleFunc("/status", statusHandler.Handle).Methods("GET")
+	}
+
+	// OpenAPI document and Swagger UI for the live route table
+	if cfg.Docs.Enabled {
+		docsHandler := sdkgen.NewHandler(serviceRouter.GetRoutes, descriptors.FindMethod, cfg.Docs.SwaggerUIURL, "/openapi.json")
+		muxRouter.HandleFunc("/openapi.json", docsHandler.HandleSpec).Methods("GET")
+		muxRouter.HandleFunc("/docs", docsHandler.HandleDocs).Methods("GET")
+	}
 
 	// Metrics endpoints
 	metricsHandler := metrics.NewHandler(metricsCollector)
@@ -106,88 +733,822 @@ func main() {
 	muxRouter.HandleFunc("/metrics/json", metricsHandler.HandleJSON).Methods("GET")
 	muxRouter.HandleFunc("/metrics/summary", metricsHandler.HandleSummary).Methods("GET")
 
+	// Admin API (route import/export, operational controls)
+	if cfg.Admin.Enabled {
+		adminHandler := admin.NewHandler(cfg, admin.Dependencies{
+			Version:  version,
+			Router:   serviceRouter,
+			Registry: serviceRegistry,
+			Metrics:  metricsCollector,
+			Errors:   recentErrors,
+			Audit:    auditLogger,
+			Traces:   traceStore,
+			Usage:    routeUsage,
+
+			Discovery: serviceDiscovery,
+			Faults:    faultInjector,
+		})
+		adminHandler.RegisterRoutes(muxRouter)
+		slog.Info("admin API enabled", "path", "/admin")
+	}
+
 	// Login endpoint (special case - handled directly)
-	loginHandler := auth.NewLoginHandler(userClient)
-	muxRouter.HandleFunc("/api/v1/auth/login", loginHandler.Handle).Methods("POST", "OPTIONS")
+	loginHandler := auth.NewLoginHandler(userClient, auditLogger)
+	if sessionLimiter != nil {
+		loginHandler.SetSessionLimiter(sessionLimiter)
+	}
+	if webSessions != nil {
+		loginHandler.SetWebSessions(webSessions)
+		muxRouter.HandleFunc("/api/v1/auth/logout", loginHandler.HandleLogout).Methods("POST")
+	}
+	if cfg.Auth.LoginGuardEnabled {
+		var attemptStore auth.LoginAttemptStore
+		if redisClient != nil {
+			attemptStore = auth.NewRedisLoginAttemptStore(redisClient)
+		} else {
+			slog.Warn("redis unavailable, failed logins are counted per gateway instance")
+			attemptStore = auth.NewMemoryLoginAttemptStore()
+		}
+		loginGuard := auth.NewLoginGuard(attemptStore, auth.LoginGuardPolicy{
+			FreeAttempts:       cfg.Auth.LoginFreeAttempts,
+			BackoffBase:        cfg.Auth.LoginBackoffBase,
+			BackoffMax:         cfg.Auth.LoginBackoffMax,
+			LockoutThreshold:   cfg.Auth.LoginLockoutThreshold,
+			IPLockoutThreshold: cfg.Auth.LoginIPLockoutThreshold,
+			LockoutDuration:    cfg.Auth.LoginLockoutDuration,
+			Window:             cfg.Auth.LoginFailureWindow,
+			StuffingThreshold:  cfg.Auth.LoginStuffingThreshold,
+		}, metricsCollector)
+		loginGuard.TrustForwardedFor(cfg.RateLimit.TrustForwardedFor)
+		loginHandler.SetLoginGuard(loginGuard)
+
+		// Flagged logins must solve a challenge before reaching the User Service
+		if cfg.Auth.LoginChallengeProvider != "" {
+			verifier, err := auth.NewSiteVerifier(cfg.Auth.LoginChallengeProvider, cfg.Auth.LoginChallengeSecret,
+				cfg.Auth.LoginChallengeSiteKey, cfg.Auth.LoginChallengeVerifyURL, cfg.Auth.LoginChallengeMinScore,
+				cfg.Auth.LoginChallengeTimeout)
+			if err != nil {
+				logging.Fatal("failed to configure login challenge", "error", err)
+			}
+			loginHandler.SetChallengeVerifier(verifier)
+			slog.Info("login challenge enabled", "provider", cfg.Auth.LoginChallengeProvider)
+		}
+	}
+	var loginEndpoint http.Handler = http.HandlerFunc(loginHandler.Handle)
+	if rateLimiter != nil {
+		loginEndpoint = rateLimiter.Middleware(nil, loginEndpoint)
+	}
+	muxRouter.Handle("/api/v1/auth/login", loginEndpoint).Methods("POST")
+
+	// Browser logins through an external OIDC identity provider, rate limited like login
+	if cfg.Auth.OIDCClientID != "" {
+		oidcLogin := auth.NewOIDCLoginHandler(auth.OIDCLoginConfig{
+			Issuer:            cfg.Auth.OIDCIssuer,
+			ClientID:          cfg.Auth.OIDCClientID,
+			ClientSecret:      cfg.Auth.OIDCClientSecret,
+			RedirectURL:       cfg.Auth.OIDCRedirectURL,
+			Scopes:            cfg.Auth.OIDCScopes,
+			PostLoginRedirect: cfg.Auth.OIDCPostLoginRedirect,
+			TokenSecret:       cfg.Auth.JWTSecret,
+			TokenIssuer:       cfg.Auth.JWTIssuer,
+		}, loginHandler)
+		rotateWithJWTSecret(secretStore, cfg.Secrets.RotationGrace, oidcLogin)
+		for path, handle := range map[string]http.HandlerFunc{
+			"/api/v1/auth/oidc/login":    oidcLogin.HandleLogin,
+			"/api/v1/auth/oidc/callback": oidcLogin.HandleCallback,
+		} {
+			var endpoint http.Handler = handle
+			if rateLimiter != nil {
+				endpoint = rateLimiter.Middleware(nil, endpoint)
+			}
+			muxRouter.Handle(path, endpoint).Methods("GET")
+		}
+		slog.Info("oidc login enabled", "issuer", cfg.Auth.OIDCIssuer)
+	}
+
+	// Refresh endpoint, rate limited like login
+	if cfg.Auth.RefreshTokensEnabled {
+		var refreshStore auth.RefreshStore = auth.NewMemoryRefreshStore()
+		if redisClient != nil {
+			refreshStore = auth.NewRedisRefreshStore(redisClient)
+		} else {
+			slog.Warn("redis unavailable, refresh tokens only work on the gateway instance that issued them")
+		}
+		refreshTokens := auth.NewRefreshTokens(refreshStore, cfg.Auth.JWTSecret, cfg.Auth.JWTIssuer, cfg.Auth.RefreshTokenTTL)
+		rotateWithJWTSecret(secretStore, cfg.Secrets.RotationGrace, refreshTokens)
+		loginHandler.EnableRefreshTokens(refreshTokens, cfg.Auth.RefreshTokenCookie)
+
+		var refreshEndpoint http.Handler = http.HandlerFunc(loginHandler.HandleRefresh)
+		if rateLimiter != nil {
+			refreshEndpoint = rateLimiter.Middleware(nil, refreshEndpoint)
+		}
+		muxRouter.Handle("/api/v1/auth/refresh", refreshEndpoint).Methods("POST")
+		slog.Info("refresh tokens enabled", "ttl", cfg.Auth.RefreshTokenTTL.String(), "cookie", cfg.Auth.RefreshTokenCookie)
+	}
+
+	// Reconnect ticket endpoint, authenticated by a bearer token or session but never a ticket
+	if ticketIssuer != nil {
+		ticketHandler := auth.NewTicketHandler(ticketIssuer, middleware.PrincipalFromRequest)
+		muxRouter.Handle("/api/v1/auth/reconnect-ticket", authMiddleware.Middleware(http.HandlerFunc(ticketHandler.Handle))).Methods("POST")
+	}
+
+	// Public key backends verify internal tokens with
+	if internalTokens != nil {
+		muxRouter.HandleFunc("/.well-known/jwks.json", internalTokens.HandleJWKS).Methods("GET")
+	}
 
 	// Dynamic route handler for all other routes
 	muxRouter.PathPrefix("/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Find matching route
-		route, err := serviceRouter.FindRoute(r.URL.Path, r.Method)
+		routingStart := time.Now()
+		match, err := serviceRouter.MatchRequest(r)
+		metricsCollector.RecordStage(metrics.StageRouting, time.Since(routingStart))
+		requestTrace := trace.FromContext(r.Context())
 		if err != nil {
-			log.Printf("⚠️  No route found for %s %s", r.Method, r.URL.Path)
-			http.Error(w, `{"error": "Route not found", "code": "ROUTE_NOT_FOUND"}`, http.StatusNotFound)
+			requestTrace.Record(metrics.StageRouting, "no route matched", time.Since(routingStart), map[string]string{"error": err.Error()})
+			sendRoutingError(w, r, err)
 			return
 		}
+		route := match.Route
+		requestTrace.SetRoute(route.Name)
+		if routeUsage != nil {
+			routeUsage.Record(route.Name)
+		}
+		requestTrace.Record(metrics.StageRouting, "matched route", time.Since(routingStart), map[string]string{
+			"service": route.Service,
+			"method":  route.GRPCService + "/" + route.GRPCMethod,
+		})
 
-		// Check authentication requirement
-		if route.RequiresAuth() {
-			// Apply auth middleware
-			authMiddleware.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				// Forward to proxy handler
-				proxyHandler.HandleRequest(w, r, route)
-			})).ServeHTTP(w, r)
-		} else {
-			// Public route - forward directly
+		// Extensions may reject the request before it goes any further
+		if err := extensions.RouteMatched(r, route); err != nil {
+			sendRejection(w, r, err)
+			return
+		}
+		r = r.WithContext(router.WithPathVars(r.Context(), match.PathVars))
+
+		// Without flag evaluation, routes behind a feature flag stay dark
+		if route.FeatureFlag != nil && flagEvaluator == nil {
+			sendRoutingError(w, r, nil)
+			return
+		}
+
+		// Build the per-request pipeline, innermost stage first
+		var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Forward to proxy handler
 			proxyHandler.HandleRequest(w, r, route)
+		})
+
+		// Record what the client sent and got, for replays against another environment
+		if trafficRecorder != nil {
+			handler = trafficRecorder.Middleware(route, handler)
+		}
+
+		// Routes dark-launched behind a flag only exist for users it is on for
+		if route.FeatureFlag != nil {
+			handler = flagEvaluator.Gate(route, handler)
+		}
+
+		// Evaluate feature flags for the authenticated user
+		if flagEvaluator != nil {
+			handler = flagEvaluator.Middleware(handler)
+		}
+
+		// Replay the stored response to retries with the same Idempotency-Key
+		if idempotencyGuard != nil {
+			handler = idempotencyGuard.Middleware(route, handler)
+		}
+
+		// Reject stale or replayed requests on sensitive routes
+		if route.ReplayProtection {
+			handler = replayGuard.Middleware(handler)
+		}
+
+		// Shed low priority routes of services whose latency degrades
+		if adaptiveLimiter != nil {
+			handler = adaptiveLimiter.Middleware(route, handler)
+		}
+
+		// Cap concurrent requests per service and per client
+		if requestBulkhead != nil {
+			handler = requestBulkhead.Middleware(route, handler)
+		}
+
+		// Rate limit per user once authenticated, and by the route's rate_limit
+		if rateLimiter != nil {
+			handler = rateLimiter.Middleware(route, handler)
+		}
+
+		// Resolve the tenant that rate limits, caches and backends are scoped to
+		if tenantResolver != nil {
+			handler = tenantResolver.Middleware(handler)
+		}
+
+		// Enforce required roles and scopes once the user is known
+		handler = middleware.Authorize(route, handler)
+
+		if route.RevocationCheck {
+			handler = authMiddleware.RevocationCheckMiddleware(route.AuthProvider, handler)
+		} else if route.RequiresAuth() && route.Stream == router.StreamWebSocket {
+			handler = authMiddleware.WebSocketMiddlewareFor(route.AuthProvider, handler)
+		} else if route.RequiresAuth() {
+			handler = authMiddleware.MiddlewareFor(route.AuthProvider, handler)
+		}
+
+		// Record requests to sensitive routes, rejected credentials and authorization failures included
+		handler = middleware.Audit(auditLogger, route, handler)
+
+		// Guard against floods per IP before authentication, so credential floods never reach the auth providers
+		if rateLimiter != nil {
+			handler = rateLimiter.IPMiddleware(handler)
 		}
+
+		// Shed the route's priority class while the gateway is under pressure
+		if loadShedder != nil {
+			handler = loadShedder.Middleware(route, handler)
+		}
+
+		// Routes may allow larger (uploads) or smaller bodies than MAX_BODY_SIZE
+		bodyLimit := cfg.Server.MaxBodySize
+		if route.MaxBodySize > 0 {
+			bodyLimit = route.MaxBodySize
+		}
+		handler = middleware.LimitBody(bodyLimit, handler)
+
+		// Compress large JSON responses for clients that accept it
+		if compressor != nil {
+			handler = compressor.Middleware(route, handler)
+		}
+
+		// Latency by status code covers rejections by every stage above
+		handler = metricsCollector.Instrument(route.Name, route.Service, handler)
+
+		// Cancel the request if it is still running long after its timeout
+		if requestWatchdog != nil {
+			limit := time.Duration(cfg.Watchdog.TimeoutMultiplier * float64(proxyHandler.MaxUpstreamDuration(route)))
+			handler = requestWatchdog.Middleware(route.Name, limit, handler)
+		}
+
+		handler.ServeHTTP(w, r)
 	})
 
+	// CORS wraps the router so preflights are answered for every route
+	var publicHandler http.Handler = muxRouter
+	if cors != nil {
+		cors.SetRouteResolver(func(path, method string) *router.Route {
+			route, _ := serviceRouter.FindRoute(path, method)
+			return route
+		})
+		publicHandler = cors.Handler(muxRouter)
+	}
+
+	// Request IDs are assigned first so every log line and backend call carries one
+	requestIDs, err := logging.NewRequestIDs(cfg.Logging.TrustRequestID, cfg.Logging.RequestIDTrustedIPs)
+	if err != nil {
+		logging.Fatal("invalid request ID configuration", "error", err)
+	}
+	publicHandler = requestIDs.Middleware(publicHandler)
+	publicHandler = shutdown.Middleware(publicHandler)
+
 	// Create HTTP server
 	addr := fmt.Sprintf(":%s", cfg.Server.Port)
 	server := &http.Server{
-		Addr:           addr,
-		Handler:        muxRouter,
-		ReadTimeout:    cfg.Server.Timeout,
-		WriteTimeout:   cfg.Server.Timeout,
-		IdleTimeout:    120 * time.Second,
-		MaxHeaderBytes: 1 << 20, // 1MB
+		Addr:              addr,
+		Handler:           publicHandler,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.Timeout,
+		WriteTimeout:      cfg.Server.Timeout,
+		IdleTimeout:       120 * time.Second,
+		MaxHeaderBytes:    1 << 20, // 1MB
+		ErrorLog:          logging.StdLogger(slog.LevelWarn),
+	}
+
+	// Terminate TLS with a certificate reloaded whenever its files, or its secrets, change
+	scheme := "http"
+	if cfg.TLS.Enabled {
+		tlsConfig, certificates, err := newTLSConfig(cfg.TLS)
+		if err != nil {
+			logging.Fatal("failed to configure TLS", "error", err)
+		}
+		server.TLSConfig = tlsConfig
+		scheme = "https"
+
+		if cfg.TLS.CertPEM != "" {
+			if secretStore != nil {
+				secretStore.OnRotate([]string{"TLS_CERT_PEM", "TLS_KEY_PEM"}, func() error {
+					certPEM, keyPEM := cfg.TLS.CertPEM, cfg.TLS.KeyPEM
+					if secretStore.Has("TLS_CERT_PEM") {
+						certPEM = secretStore.Get("TLS_CERT_PEM")
+					}
+					if secretStore.Has("TLS_KEY_PEM") {
+						keyPEM = secretStore.Get("TLS_KEY_PEM")
+					}
+					_, err := certificates.SetPEM([]byte(certPEM), []byte(keyPEM))
+					return err
+				})
+			}
+			slog.Info("TLS enabled", "cert_source", "pem", "min_version", cfg.TLS.MinVersion)
+		} else {
+			certCtx, stopCertReload := context.WithCancel(context.Background())
+			defer stopCertReload()
+			go certificates.Run(certCtx, cfg.TLS.ReloadInterval)
+			slog.Info("TLS enabled", "cert_file", cfg.TLS.CertFile, "min_version", cfg.TLS.MinVersion)
+		}
 	}
 
+	// Fetch the secrets again periodically; JWT, Redis and TLS secrets rotate live, others need a restart
+	if secretStore != nil && cfg.Secrets.RefreshInterval > 0 {
+		secretsCtx, stopSecretRefresh := context.WithCancel(context.Background())
+		defer stopSecretRefresh()
+		go secretStore.Run(secretsCtx, cfg.Secrets.RefreshInterval)
+	}
+
+	// Start extensions before accepting traffic
+	if names := extensions.Names(); len(names) > 0 {
+		startupCtx, cancelStartup := context.WithTimeout(context.Background(), 30*time.Second)
+		err := extensions.Startup(startupCtx)
+		cancelStartup()
+		if err != nil {
+			logging.Fatal("failed to start extensions", "error", err)
+		}
+		slog.Info("extensions started", "extensions", names)
+	}
+
+	// Bound concurrent connections in total and per client IP
+	listener, err := drain.Listen(addr, cfg.Server.ReusePort)
+	if err != nil {
+		logging.Fatal("failed to listen", "address", addr, "error", err)
+	}
+	limitedListener := connlimit.NewListener(listener, cfg.Server.MaxConnections, cfg.Server.MaxConnsPerIP)
+	limitedListener.OnDrop(metricsCollector.RecordConnectionRejected)
+
 	// Start server in a goroutine
 	go func() {
-		log.Println("✅ Gateway initialized successfully")
-		log.Printf("📡 Listening on http://localhost%s", addr)
-		log.Printf("📊 Health check: http://localhost%s/health", addr)
-		log.Printf("📈 Metrics: http://localhost%s/metrics", addr)
-		log.Printf("🔐 Login: http://localhost%s/api/v1/auth/login", addr)
-		log.Println("")
-		log.Println("Gateway is ready to accept requests! 🎉")
+		slog.Info("gateway ready to accept requests",
+			"address", scheme+"://localhost"+addr,
+			"health", "/health",
+			"liveness", "/health/live",
+			"readiness", "/health/ready",
+			"metrics", "/metrics",
+			"login", "/api/v1/auth/login")
 
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("❌ Server failed: %v", err)
+		serve := server.Serve
+		if cfg.TLS.Enabled {
+			serve = func(l net.Listener) error { return server.ServeTLS(l, "", "") }
+		}
+		if err := serve(limitedListener); err != nil && err != http.ErrServerClosed {
+			logging.Fatal("server failed", "error", err)
 		}
 	}()
 
+	// Plain HTTP listener that only redirects to HTTPS
+	var redirectServer *http.Server
+	if cfg.TLS.Enabled && cfg.TLS.RedirectEnabled {
+		redirectServer = &http.Server{
+			Addr:              fmt.Sprintf(":%s", cfg.TLS.RedirectPort),
+			Handler:           tlscert.RedirectHandler(cfg.Server.Port),
+			ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+			IdleTimeout:       120 * time.Second,
+			ErrorLog:          logging.StdLogger(slog.LevelWarn),
+		}
+		go func() {
+			slog.Info("HTTP to HTTPS redirect listener started", "address", redirectServer.Addr)
+			if err := redirectServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				logging.Fatal("redirect listener failed", "error", err)
+			}
+		}()
+	}
+
+	// Internal mTLS listener for service-to-service calls (auth_provider: mtls)
+	var internalServer *http.Server
+	if cfg.InternalListener.Enabled {
+		// Token introspection is only reachable by mTLS-authenticated services
+		introspectionHandler := auth.NewIntrospectionHandler(authMiddleware.AuthenticatePrincipal, middleware.PrincipalFromRequest)
+		internalRouter := http.NewServeMux()
+		internalRouter.Handle("POST /internal/auth/introspect",
+			authMiddleware.MiddlewareFor(auth.ProviderMTLS, http.HandlerFunc(introspectionHandler.Handle)))
+		internalRouter.Handle("/", muxRouter)
+
+		// Same request IDs and shutdown draining as the public listener
+		internalServer, err = newInternalServer(cfg, shutdown.Middleware(requestIDs.Middleware(internalRouter)))
+		if err != nil {
+			logging.Fatal("failed to configure internal mTLS listener", "error", err)
+		}
+
+		go func() {
+			slog.Info("internal mTLS listener started", "address", "https://localhost"+internalServer.Addr)
+			if err := internalServer.ListenAndServeTLS(cfg.InternalListener.CertFile, cfg.InternalListener.KeyFile); err != nil && err != http.ErrServerClosed {
+				logging.Fatal("internal listener failed", "error", err)
+			}
+		}()
+	}
+
+	// Native gRPC listener for internal clients (no JSON transcoding)
+	var passthroughServer *grpc.Server
+	if cfg.GRPCPassthrough.Enabled {
+		passthrough := proxy.NewPassthrough(serviceRegistry, metricsCollector, cfg.GRPCPassthrough.Services)
+		passthrough.SetAuthenticator(authMiddleware.ValidateToken, cfg.GRPCPassthrough.AuthRequired)
+		if internalTokens != nil {
+			passthrough.EnableInternalTokens(internalTokens)
+		}
+		passthrough.SetRoutes(serviceRouter.GetRoutes())
+		serviceRouter.OnRoutesChanged(passthrough.SetRoutes)
+		passthroughServer = passthrough.NewServer()
+
+		passthroughAddr := fmt.Sprintf(":%s", cfg.GRPCPassthrough.Port)
+		passthroughListener, err := net.Listen("tcp", passthroughAddr)
+		if err != nil {
+			logging.Fatal("failed to listen", "address", passthroughAddr, "error", err)
+		}
+		go func() {
+			slog.Info("gRPC passthrough listener started", "address", passthroughAddr)
+			if err := passthroughServer.Serve(passthroughListener); err != nil {
+				logging.Fatal("gRPC passthrough listener failed", "error", err)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
-	log.Println("\n👋 Shutting down gracefully...")
+	slog.Info("shutting down gracefully", "in_flight", shutdown.InFlight())
+
+	// Keep serving while load balancers see readiness fail, closing each
+	// connection after its current response
+	shutdown.Start()
+	if cfg.Server.ShutdownDrainDelay > 0 {
+		slog.Info("draining before closing the listener", "delay", cfg.Server.ShutdownDrainDelay.String())
+		time.Sleep(cfg.Server.ShutdownDrainDelay)
+	}
 
 	// Create shutdown context with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
-	// Shutdown server
+	// Stop accepting connections (HTTP/2 clients get a GOAWAY) and wait for
+	// in-flight requests, including streams
 	if err := server.Shutdown(ctx); err != nil {
-		log.Printf("❌ Server forced to shutdown: %v", err)
+		slog.Error("server forced to shutdown", "error", err)
 	}
 
-	log.Println("✅ Gateway stopped")
+	if redirectServer != nil {
+		if err := redirectServer.Shutdown(ctx); err != nil {
+			slog.Error("redirect listener forced to shutdown", "error", err)
+		}
+	}
+
+	if internalServer != nil {
+		if err := internalServer.Shutdown(ctx); err != nil {
+			slog.Error("internal listener forced to shutdown", "error", err)
+		}
+	}
+
+	if passthroughServer != nil {
+		stopped := make(chan struct{})
+		go func() {
+			passthroughServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			slog.Error("gRPC passthrough listener forced to shutdown")
+			passthroughServer.Stop()
+		}
+	}
+
+	// Shutdown doesn't wait for hijacked connections such as WebSockets
+	if dropped := shutdown.Wait(ctx); dropped > 0 {
+		metricsCollector.RecordShutdownDropped(dropped)
+		slog.Warn("requests still in flight at the shutdown deadline", "dropped", dropped)
+	}
+
+	extensions.Shutdown(ctx)
+
+	// Backend gRPC connections are closed by the deferred registry Close, after the drain
+	slog.Info("gateway stopped")
+}
+
+// newAuditSink opens the configured audit sink and returns the last record
+// of its chain. Syslog and Kafka can't be read back, so their chain position
+// is kept in the local state file.
+func newAuditSink(cfg config.AuditConfig) (audit.Sink, *audit.Record, error) {
+	var sink audit.Sink
+	var err error
+	switch cfg.Sink {
+	case "syslog":
+		sink, err = audit.NewSyslogSink(cfg.SyslogNetwork, cfg.SyslogAddress, cfg.SyslogTag)
+	case "kafka":
+		sink, err = audit.NewKafkaSink(cfg.KafkaRESTURL, cfg.KafkaTopic, cfg.KafkaKey, cfg.KafkaTimeout, cfg.KafkaQueue)
+	default:
+		return audit.NewFileSink(cfg.FilePath)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return audit.NewCheckpointSink(sink, cfg.StatePath)
+}
+
+// newSecretsProvider creates the client of the configured secrets manager
+func newSecretsProvider(cfg config.SecretsConfig) secrets.Provider {
+	if cfg.Provider == "aws" {
+		return secrets.NewAWSSecretsManager(cfg.AWSRegion, cfg.AWSEndpoint, secrets.AWSCredentials{
+			AccessKeyID:     cfg.AWSAccessKeyID,
+			SecretAccessKey: cfg.AWSSecretAccessKey,
+			SessionToken:    cfg.AWSSessionToken,
+		}, cfg.FetchTimeout)
+	}
+	return secrets.NewVault(cfg.VaultAddress, cfg.VaultToken, cfg.VaultNamespace, cfg.FetchTimeout)
+}
+
+// rotateWithJWTSecret rotates signer's secret whenever JWT_SECRET changes in
+// the secrets manager, accepting tokens signed with the old one for grace
+func rotateWithJWTSecret(store *secrets.Store, grace time.Duration, signer interface {
+	RotateSecret(secret string, grace time.Duration)
+}) {
+	if store == nil || !store.Has("JWT_SECRET") {
+		return
+	}
+	store.OnRotate([]string{"JWT_SECRET"}, func() error {
+		signer.RotateSecret(store.Get("JWT_SECRET"), grace)
+		return nil
+	})
+}
+
+// checkRedisPassword authenticates a one-off connection with a rotated Redis
+// password so a bad rotation is reported before the pool dials with it.
+// Pooled connections stay authenticated with the password they opened with.
+func checkRedisPassword(options redis.Options, password string) error {
+	options.CredentialsProvider = nil
+	options.Password = password
+	options.PoolSize = 1
+	client := redis.NewClient(&options)
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("redis rejected the rotated password: %w", err)
+	}
+	return nil
+}
+
+// newTLSConfig builds the main server's TLS settings around a reloading certificate
+func newTLSConfig(cfg config.TLSConfig) (*tls.Config, *tlscert.Reloader, error) {
+	minVersion, err := tlscert.ParseMinVersion(cfg.MinVersion)
+	if err != nil {
+		return nil, nil, err
+	}
+	cipherSuites, err := tlscert.ParseCipherSuites(cfg.CipherSuites)
+	if err != nil {
+		return nil, nil, err
+	}
+	var certificates *tlscert.Reloader
+	if cfg.CertPEM != "" {
+		certificates, err = tlscert.NewPEMReloader([]byte(cfg.CertPEM), []byte(cfg.KeyPEM))
+	} else {
+		certificates, err = tlscert.NewReloader(cfg.CertFile, cfg.KeyFile)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return &tls.Config{
+		GetCertificate: certificates.GetCertificate,
+		MinVersion:     minVersion,
+		CipherSuites:   cipherSuites,
+	}, certificates, nil
+}
+
+// newInternalServer creates the internal HTTPS server that requires and verifies
+// client certificates against the configured CA bundle
+func newInternalServer(cfg *config.Config, handler http.Handler) (*http.Server, error) {
+	caPEM, err := os.ReadFile(cfg.InternalListener.ClientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA file: %w", err)
+	}
+
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in %s", cfg.InternalListener.ClientCAFile)
+	}
+
+	return &http.Server{
+		Addr:    fmt.Sprintf(":%s", cfg.InternalListener.Port),
+		Handler: handler,
+		TLSConfig: &tls.Config{
+			ClientAuth: tls.RequireAndVerifyClientCert,
+			ClientCAs:  clientCAs,
+			MinVersion: tls.VersionTLS12,
+		},
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.Timeout,
+		WriteTimeout:      cfg.Server.Timeout,
+		IdleTimeout:       120 * time.Second,
+		MaxHeaderBytes:    1 << 20, // 1MB
+		ErrorLog:          logging.StdLogger(slog.LevelWarn),
+	}, nil
+}
+
+// validateConfiguration checks the configuration and the route table, prints
+// the report as JSON and returns the exit code
+func validateConfiguration() int {
+	report, cfg := config.Check()
+	if os.Getenv("CONFIG_BUNDLE_URL") != "" {
+		report.Warnings = append(report.Warnings, "routes and settings of the config bundle were not checked")
+	} else if routes, err := router.LoadRoutes("config/routes.yaml"); err != nil {
+		report.AddError("routes: %v", err)
+	} else if cfg == nil {
+		// Without settings only the routes themselves can be checked
+		for _, err := range router.ValidateRoutes(routes) {
+			report.AddError("routes: %v", err)
+		}
+	} else {
+		checker, _ := router.NewServiceRouterFromRoutes(nil)
+		egressPolicy, err := egress.NewPolicy(cfg.Egress.Allowlist)
+		if err != nil {
+			report.AddError("%v", err)
+			egressPolicy, _ = egress.NewPolicy(nil)
+		}
+		descriptors, err := loadDescriptors(cfg.Proxy.DescriptorSets)
+		if err != nil {
+			report.AddError("descriptor set %v", err)
+			descriptors = proxy.NewDescriptorRegistry()
+		}
+		addRouteChecks(checker, cfg, egressPolicy, descriptors)
+		for _, err := range checker.CheckRoutes(routes) {
+			report.AddError("routes: %v", err)
+		}
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(report)
+	if !report.Valid {
+		return 1
+	}
+	return 0
 }
 
-// healthCheckHandler handles health check requests
-func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
+// loadDescriptors loads the protobuf descriptor sets routes are checked
+// against and requests are transcoded with
+func loadDescriptors(paths []string) (*proxy.DescriptorRegistry, error) {
+	descriptors := proxy.NewDescriptorRegistry()
+	for _, path := range paths {
+		if err := descriptors.LoadDescriptorSet(path); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		slog.Info("loaded protobuf descriptor set", "path", path)
+	}
+	return descriptors, nil
+}
 
-	response := `{
-		"status": "healthy",
-		"version": "` + version + `",
-		"timestamp": "` + time.Now().Format(time.RFC3339) + `"
-	}`
+// addRouteChecks installs the checks every route table must pass besides
+// route validation, at startup and on every change, and returns the CORS
+// handler (nil when CORS is disabled)
+func addRouteChecks(serviceRouter *router.ServiceRouter, cfg *config.Config, egressPolicy *egress.Policy, descriptors *proxy.DescriptorRegistry) *middleware.CORS {
+	if egressPolicy.Enabled() {
+		// Routes may only target configured (and therefore allowlisted) services
+		serviceRouter.AddRouteCheck(func(route *router.Route) error {
+			for _, target := range route.Targets() {
+				if _, ok := cfg.Services[target.Service]; !ok {
+					return fmt.Errorf("service %q is not a configured backend", target.Service)
+				}
+			}
+			if route.Mirror != nil {
+				if _, ok := cfg.Services[route.Mirror.Service]; !ok {
+					return fmt.Errorf("mirror service %q is not a configured backend", route.Mirror.Service)
+				}
+			}
+			return nil
+		})
+	}
+	// Backends only see the gateway-signed token, so header rules can't forward credentials
+	if cfg.InternalTokens.Enabled {
+		serviceRouter.AddRouteCheck(proxy.CheckInternalTokenHeaders)
+	}
+	// Every route must resolve to a known gRPC method whose request binds its path variables
+	serviceRouter.AddRouteCheck(descriptors.CheckRoute)
+	var cors *middleware.CORS
+	if cfg.CORS.Enabled {
+		cors = middleware.NewCORS(cfg.CORS)
+		serviceRouter.AddRouteCheck(cors.CheckRoute)
+	}
+	serviceRouter.SetStrictAmbiguity(cfg.Server.RouteAmbiguity == "fail")
+	return cors
+}
+
+// sendRoutingError answers requests no route accepts: 405 with an Allow
+// header when the path exists for other methods, 404 otherwise
+func sendRoutingError(w http.ResponseWriter, r *http.Request, err error) {
+	status, code := http.StatusNotFound, "ROUTE_NOT_FOUND"
+	message := fmt.Sprintf("No route found for %s %s", r.Method, r.URL.Path)
+
+	var methodErr *router.MethodNotAllowedError
+	if errors.As(err, &methodErr) {
+		status, code, message = http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", methodErr.Error()
+		w.Header().Set("Allow", strings.Join(methodErr.Allowed, ", "))
+	}
 
-	w.Write([]byte(response))
+	slog.WarnContext(r.Context(), "no route accepted request", "method", r.Method, "path", r.URL.Path, "code", code)
+	httperr.Send(w, r, status, code, message)
+}
+
+// sendRejection answers a request rejected by an extension's route match hook
+func sendRejection(w http.ResponseWriter, r *http.Request, err error) {
+	rejection := hooks.RejectionFor(err)
+	slog.WarnContext(r.Context(), "request rejected by extension", "method", r.Method, "path", r.URL.Path, "error", err)
+	httperr.Send(w, r, rejection.Status, rejection.Code, rejection.Message)
+}
+
+// newHealthChecker checks that Redis (when used) answers, that routes are
+// loaded and that the backend of every route has a usable connection. With
+// warm-up every configured service is checked, routed or not.
+func newHealthChecker(redisClient *redis.Client, serviceRouter *router.ServiceRouter, registry *proxy.ServiceRegistry, cfg *config.Config) *health.Checker {
+	checker := health.NewChecker(2 * time.Second)
+	if redisClient != nil {
+		checker.Register("redis", func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		})
+	}
+	checker.Register("routes", func(ctx context.Context) error {
+		if len(serviceRouter.GetRoutes()) == 0 {
+			return errors.New("no routes loaded")
+		}
+		return nil
+	})
+	checker.RegisterSet(func() map[string]health.Check {
+		checks := make(map[string]health.Check)
+		if cfg.Proxy.WarmUpEnabled {
+			for service := range cfg.Services {
+				checks["backend:"+service] = func(ctx context.Context) error {
+					return registry.CheckReady(service)
+				}
+			}
+		}
+		for _, route := range serviceRouter.GetRoutes() {
+			for _, target := range route.Targets() {
+				service := target.Service
+				checks["backend:"+service] = func(ctx context.Context) error {
+					return registry.CheckReady(service)
+				}
+			}
+		}
+		return checks
+	})
+	return checker
+}
+
+// newHealthCheckHandler reports the health of every dependency, and the
+// applied control plane config version when control plane sync is enabled
+func newHealthCheckHandler(checker *health.Checker, controlPlane *controlplane.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := checker.Run(r.Context())
+		response := map[string]interface{}{
+			"status":       report.Status,
+			"version":      version,
+			"timestamp":    report.Timestamp.Format(time.RFC3339),
+			"dependencies": report.Dependencies,
+		}
+		if controlPlane != nil {
+			response["config"] = controlPlane.Status()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(health.StatusCode(report))
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// newControlPlaneApplier validates a control plane update in full before
+// swapping the route table and egress policy, so updates apply atomically
+func newControlPlaneApplier(cfg *config.Config, serviceRouter *router.ServiceRouter, registry *proxy.ServiceRegistry) func(update *controlplane.Update) error {
+	return func(update *controlplane.Update) error {
+		if errs := serviceRouter.CheckRoutes(update.Routes); len(errs) > 0 {
+			return errors.Join(errs...)
+		}
+
+		var policy *egress.Policy
+		if update.EgressAllowlist != nil {
+			var err error
+			if policy, err = egress.NewPolicy(update.EgressAllowlist); err != nil {
+				return err
+			}
+			for name, service := range cfg.Services {
+				if err := policy.CheckTarget(context.Background(), service.Address); err != nil {
+					return fmt.Errorf("service %s: %w", name, err)
+				}
+			}
+		}
+
+		if err := serviceRouter.ReplaceRoutes(update.Routes); err != nil {
+			return err
+		}
+		if policy != nil {
+			registry.SetEgressPolicy(policy)
+		}
+		return nil
+	}
 }
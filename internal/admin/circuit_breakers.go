@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+
+	"hub-api-gateway/internal/audit"
+
+	"github.com/gorilla/mux"
+)
+
+// HandleListCircuitBreakers returns the statistics of every circuit breaker
+func (h *Handler) HandleListCircuitBreakers(w http.ResponseWriter, r *http.Request) {
+	breakers := h.registry.GetAllCircuitBreakers()
+	names := make([]string, 0, len(breakers))
+	for name := range breakers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	stats := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		stats = append(stats, breakers[name].GetStats())
+	}
+	h.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"enabled":          h.config.Proxy.CircuitBreakerEnabled,
+		"circuit_breakers": stats,
+	})
+}
+
+// HandleCircuitBreakerAction opens, closes or resets a circuit breaker by
+// name: a service, or <service>/<route> for routes with their own breaker.
+// A manually opened breaker stays open until it is closed or reset.
+func (h *Handler) HandleCircuitBreakerAction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name, action := vars["name"], vars["action"]
+
+	cb, ok := h.registry.CircuitBreakerByName(name)
+	if !ok {
+		h.sendError(w, r, http.StatusNotFound, "CIRCUIT_BREAKER_NOT_FOUND", fmt.Sprintf("Circuit breaker %s not found", name))
+		return
+	}
+
+	before := cb.GetState()
+	switch action {
+	case "open":
+		cb.ForceOpen()
+	case "close":
+		cb.ForceClose()
+	case "reset":
+		cb.Reset()
+	}
+
+	h.auditAction(r, "admin.circuit_breakers."+action, name, audit.ResultSuccess, map[string]string{
+		"previous_state": before.String(),
+	})
+	slog.WarnContext(r.Context(), "circuit breaker changed manually", "breaker", name, "action", action,
+		"previous_state", before.String(), "state", cb.GetState().String())
+	h.sendJSON(w, http.StatusOK, cb.GetStats())
+}
@@ -0,0 +1,142 @@
+package admin
+
+import (
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+
+	"hub-api-gateway/internal/discovery"
+	"hub-api-gateway/internal/errorlog"
+	"hub-api-gateway/internal/proxy"
+)
+
+// diagnosticsRecentErrors is the number of recent errors embedded in diagnostics
+const diagnosticsRecentErrors = 20
+
+// ErrorsResponse is returned by GET /admin/errors
+type ErrorsResponse struct {
+	Total    uint64           `json:"total"`
+	Capacity int              `json:"capacity"`
+	Errors   []errorlog.Entry `json:"errors"`
+}
+
+// ServiceDiagnostics describes the connection, breaker and health state of a backend
+type ServiceDiagnostics struct {
+	Name           string                 `json:"name"`
+	Address        string                 `json:"address"`
+	Endpoints      []string               `json:"endpoints,omitempty"` // Current endpoints of discovery:// addresses
+	Connection     string                 `json:"connection"`
+	Channels       []string               `json:"channels,omitempty"` // State of each pooled gRPC channel
+	Draining       bool                   `json:"draining"`
+	CircuitBreaker map[string]interface{} `json:"circuitBreaker,omitempty"`
+	Contract       *proxy.BackendVersion  `json:"contract,omitempty"` // Detected version of pinned backends
+	Health         proxy.ServiceHealth    `json:"health"`             // Health probes and outlier ejection
+}
+
+// ServicesResponse is returned by GET /admin/services
+type ServicesResponse struct {
+	Timestamp time.Time            `json:"timestamp"`
+	Services  []ServiceDiagnostics `json:"services"`
+}
+
+// DiagnosticsResponse is returned by GET /admin/diagnostics
+type DiagnosticsResponse struct {
+	Version       string               `json:"version"`
+	Timestamp     time.Time            `json:"timestamp"`
+	UptimeSeconds float64              `json:"uptimeSeconds"`
+	Goroutines    int                  `json:"goroutines"`
+	HeapAllocMB   float64              `json:"heapAllocMb"`
+	Routes        int                  `json:"routes"`
+	Services      []ServiceDiagnostics `json:"services"`
+	TotalErrors   uint64               `json:"totalErrors"`
+	RecentErrors  []errorlog.Entry     `json:"recentErrors"`
+}
+
+// HandleErrors returns the most recent gateway errors, newest first (?limit=N)
+func (h *Handler) HandleErrors(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 0 {
+			h.sendError(w, r, http.StatusBadRequest, "INVALID_LIMIT", "limit must be a non-negative integer")
+			return
+		}
+		limit = parsed
+	}
+
+	h.sendJSON(w, http.StatusOK, ErrorsResponse{
+		Total:    h.errors.Total(),
+		Capacity: h.errors.Capacity(),
+		Errors:   h.errors.Recent(limit),
+	})
+}
+
+// HandleDiagnostics returns a point-in-time view of gateway internals for on-call engineers
+func (h *Handler) HandleDiagnostics(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	h.sendJSON(w, http.StatusOK, DiagnosticsResponse{
+		Version:       h.version,
+		Timestamp:     time.Now(),
+		UptimeSeconds: time.Since(h.startTime).Seconds(),
+		Goroutines:    runtime.NumGoroutine(),
+		HeapAllocMB:   float64(mem.HeapAlloc) / (1024 * 1024),
+		Routes:        len(h.router.GetRoutes()),
+		Services:      h.serviceDiagnostics(),
+		TotalErrors:   h.errors.Total(),
+		RecentErrors:  h.errors.Recent(diagnosticsRecentErrors),
+	})
+}
+
+// HandleListServices returns the connection, breaker and health state of every backend
+func (h *Handler) HandleListServices(w http.ResponseWriter, r *http.Request) {
+	h.sendJSON(w, http.StatusOK, ServicesResponse{
+		Timestamp: time.Now(),
+		Services:  h.serviceDiagnostics(),
+	})
+}
+
+// serviceDiagnostics collects connection, breaker and health state for every configured service
+func (h *Handler) serviceDiagnostics() []ServiceDiagnostics {
+	breakers := h.registry.GetAllCircuitBreakers()
+	versions := h.registry.GetBackendVersions()
+	health := make(map[string]proxy.ServiceHealth)
+	for _, service := range h.registry.GetServiceHealth() {
+		health[service.Service] = service
+	}
+
+	names := make([]string, 0, len(h.config.Services))
+	for name := range h.config.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	services := make([]ServiceDiagnostics, 0, len(names))
+	for _, name := range names {
+		state, _ := h.registry.GetConnectionState(name)
+
+		diag := ServiceDiagnostics{
+			Name:       name,
+			Address:    h.config.Services[name].Address,
+			Connection: state,
+			Channels:   h.registry.GetChannelStates(name),
+			Health:     health[name],
+		}
+		if h.discovery != nil && discovery.IsTarget(diag.Address) {
+			diag.Endpoints = h.discovery.Endpoints(diag.Address)
+		}
+		_, diag.Draining = h.registry.GetDrainState(name)
+		if cb, ok := breakers[name]; ok {
+			diag.CircuitBreaker = cb.GetStats()
+		}
+		if version, ok := versions[name]; ok {
+			diag.Contract = &version
+		}
+		services = append(services, diag)
+	}
+
+	return services
+}
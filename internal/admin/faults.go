@@ -0,0 +1,108 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"hub-api-gateway/internal/audit"
+	"hub-api-gateway/internal/router"
+
+	"github.com/gorilla/mux"
+)
+
+// HandleListFaults returns the faults configured on routes and the ones set
+// at runtime, which take precedence
+func (h *Handler) HandleListFaults(w http.ResponseWriter, r *http.Request) {
+	if h.faults == nil {
+		h.sendError(w, r, http.StatusServiceUnavailable, "FAULT_INJECTION_DISABLED", "Fault injection is not enabled")
+		return
+	}
+
+	configured := make(map[string]*router.RouteFault)
+	for _, route := range h.router.GetRoutes() {
+		if route.Fault != nil {
+			configured[route.Name] = route.Fault
+		}
+	}
+	h.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"configured": configured,
+		"overrides":  h.faults.Overrides(),
+	})
+}
+
+// HandleSetFault injects a fault into a route's backend calls until cleared.
+// Aggregate branches are addressed as <route>.<branch>; a zero percent
+// suspends the route's configured fault.
+func (h *Handler) HandleSetFault(w http.ResponseWriter, r *http.Request) {
+	if h.faults == nil {
+		h.sendError(w, r, http.StatusServiceUnavailable, "FAULT_INJECTION_DISABLED", "Fault injection is not enabled")
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	if !h.faultTarget(name) {
+		h.sendError(w, r, http.StatusNotFound, "ROUTE_NOT_FOUND", fmt.Sprintf("Route %s not found", name))
+		return
+	}
+
+	var fault router.RouteFault
+	if err := json.NewDecoder(r.Body).Decode(&fault); err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON format")
+		return
+	}
+	if fault.Percent != 0 {
+		if err := fault.Validate(); err != nil {
+			h.sendError(w, r, http.StatusBadRequest, "INVALID_FAULT", err.Error())
+			return
+		}
+	}
+
+	h.faults.Set(name, fault)
+	h.auditAction(r, "admin.faults.set", name, audit.ResultSuccess, map[string]string{
+		"percent": fmt.Sprintf("%g", fault.Percent),
+		"delay":   fault.Delay,
+		"jitter":  fault.Jitter,
+		"code":    fault.Code,
+	})
+	slog.WarnContext(r.Context(), "fault injection set", "route", name, "percent", fault.Percent,
+		"delay", fault.Delay, "jitter", fault.Jitter, "code", fault.Code)
+	h.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"route": name,
+		"fault": fault,
+	})
+}
+
+// HandleClearFault removes a route's runtime fault, restoring its configured one
+func (h *Handler) HandleClearFault(w http.ResponseWriter, r *http.Request) {
+	if h.faults == nil {
+		h.sendError(w, r, http.StatusServiceUnavailable, "FAULT_INJECTION_DISABLED", "Fault injection is not enabled")
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	if !h.faults.Clear(name) {
+		h.sendError(w, r, http.StatusNotFound, "FAULT_NOT_FOUND", fmt.Sprintf("Route %s has no runtime fault", name))
+		return
+	}
+
+	h.auditAction(r, "admin.faults.clear", name, audit.ResultSuccess, nil)
+	slog.InfoContext(r.Context(), "fault injection cleared", "route", name)
+	h.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"route":   name,
+		"cleared": true,
+	})
+}
+
+// faultTarget reports whether faults can be set on a route or aggregate branch
+func (h *Handler) faultTarget(name string) bool {
+	for _, route := range h.router.GetRoutes() {
+		for _, target := range route.Targets() {
+			if target.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"hub-api-gateway/internal/audit"
+	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/discovery"
+	"hub-api-gateway/internal/errorlog"
+	"hub-api-gateway/internal/httperr"
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/proxy"
+	"hub-api-gateway/internal/router"
+	"hub-api-gateway/internal/trace"
+	"hub-api-gateway/internal/usage"
+
+	"github.com/gorilla/mux"
+)
+
+// Dependencies are the gateway components the admin API inspects and controls
+type Dependencies struct {
+	Version  string
+	Router   *router.ServiceRouter
+	Registry *proxy.ServiceRegistry
+	Metrics  *metrics.Metrics
+	Errors   *errorlog.Buffer
+	Audit    *audit.Logger
+	Traces   trace.Store
+	Usage    *usage.Tracker
+
+	Discovery *discovery.Builder   // Endpoints of discovery:// services; nil when discovery is off
+	Faults    *proxy.FaultInjector // Runtime faults of routes; nil when fault injection is off
+}
+
+// Handler serves the operational /admin API
+type Handler struct {
+	config    *config.Config
+	version   string
+	router    *router.ServiceRouter
+	registry  *proxy.ServiceRegistry
+	metrics   *metrics.Metrics
+	errors    *errorlog.Buffer
+	audit     *audit.Logger
+	traces    trace.Store
+	usage     *usage.Tracker
+	discovery *discovery.Builder
+	faults    *proxy.FaultInjector
+	startTime time.Time
+
+	// Serializes read-modify-write changes to the route table
+	routesMu sync.Mutex
+}
+
+// NewHandler creates a new admin API handler
+func NewHandler(cfg *config.Config, deps Dependencies) *Handler {
+	return &Handler{
+		config:    cfg,
+		version:   deps.Version,
+		router:    deps.Router,
+		registry:  deps.Registry,
+		metrics:   deps.Metrics,
+		errors:    deps.Errors,
+		audit:     deps.Audit,
+		traces:    deps.Traces,
+		usage:     deps.Usage,
+		discovery: deps.Discovery,
+		faults:    deps.Faults,
+		startTime: time.Now(),
+	}
+}
+
+// RegisterRoutes mounts the admin endpoints under /admin on the given router
+func (h *Handler) RegisterRoutes(r *mux.Router) {
+	adminRouter := r.PathPrefix("/admin").Subrouter()
+	adminRouter.Use(h.requireToken)
+
+	adminRouter.HandleFunc("/routes/export", h.HandleExportRoutes).Methods("GET")
+	adminRouter.HandleFunc("/routes/import", h.HandleImportRoutes).Methods("POST")
+	adminRouter.HandleFunc("/routes/usage", h.HandleRouteUsage).Methods("GET")
+	adminRouter.HandleFunc("/routes", h.HandleListRoutes).Methods("GET")
+	adminRouter.HandleFunc("/routes", h.HandleCreateRoute).Methods("POST")
+	adminRouter.HandleFunc("/routes/{name}", h.HandleGetRoute).Methods("GET")
+	adminRouter.HandleFunc("/routes/{name}", h.HandleUpdateRoute).Methods("PUT")
+	adminRouter.HandleFunc("/routes/{name}", h.HandleDeleteRoute).Methods("DELETE")
+	adminRouter.HandleFunc("/metrics/reset", h.HandleResetMetrics).Methods("POST")
+	adminRouter.HandleFunc("/errors", h.HandleErrors).Methods("GET")
+	adminRouter.HandleFunc("/diagnostics", h.HandleDiagnostics).Methods("GET")
+	adminRouter.HandleFunc("/traces/{requestId}", h.HandleGetTrace).Methods("GET")
+	adminRouter.HandleFunc("/services", h.HandleListServices).Methods("GET")
+	adminRouter.HandleFunc("/services/draining", h.HandleListDraining).Methods("GET")
+	adminRouter.HandleFunc("/services/{service}/drain", h.HandleStartDrain).Methods("POST")
+	adminRouter.HandleFunc("/services/{service}/drain", h.HandleStopDrain).Methods("DELETE")
+	adminRouter.HandleFunc("/circuit-breakers", h.HandleListCircuitBreakers).Methods("GET")
+	adminRouter.HandleFunc("/circuit-breakers/{name:.+}/{action:open|close|reset}", h.HandleCircuitBreakerAction).Methods("POST")
+	adminRouter.HandleFunc("/faults", h.HandleListFaults).Methods("GET")
+	adminRouter.HandleFunc("/faults/{name}", h.HandleSetFault).Methods("PUT")
+	adminRouter.HandleFunc("/faults/{name}", h.HandleClearFault).Methods("DELETE")
+}
+
+// requireToken rejects requests that don't carry the configured admin token
+func (h *Handler) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-Admin-Token")
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.config.Admin.Token)) != 1 {
+			slog.WarnContext(r.Context(), "rejected admin request: invalid admin token", "remote_addr", r.RemoteAddr)
+			h.sendError(w, r, http.StatusUnauthorized, "ADMIN_UNAUTHORIZED", "Valid X-Admin-Token header is required")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// auditAction records an admin change in the audit log
+func (h *Handler) auditAction(r *http.Request, action, resource, result string, details map[string]string) {
+	h.audit.Log(audit.Event{
+		Actor:      "admin",
+		Action:     action,
+		Resource:   resource,
+		Result:     result,
+		RemoteAddr: r.RemoteAddr,
+		RequestID:  r.Header.Get("X-Request-ID"),
+		Details:    details,
+	})
+}
+
+// sendJSON sends a JSON response
+func (h *Handler) sendJSON(w http.ResponseWriter, statusCode int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		slog.Error("failed to encode admin response", "error", err)
+	}
+}
+
+// sendError sends an error response
+func (h *Handler) sendError(w http.ResponseWriter, r *http.Request, statusCode int, errorCode, message string) {
+	httperr.Send(w, r, statusCode, errorCode, message)
+}
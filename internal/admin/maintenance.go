@@ -0,0 +1,87 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"hub-api-gateway/internal/audit"
+	"hub-api-gateway/internal/proxy"
+
+	"github.com/gorilla/mux"
+)
+
+// DrainRequest is the body of POST /admin/services/{service}/drain
+type DrainRequest struct {
+	Message     string `json:"message"`
+	ServeCached bool   `json:"serve_cached"`
+	Until       string `json:"until,omitempty"` // RFC3339 expected end of maintenance
+}
+
+// HandleListDraining returns all services currently draining
+func (h *Handler) HandleListDraining(w http.ResponseWriter, r *http.Request) {
+	h.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"draining": h.registry.GetDrainingServices(),
+	})
+}
+
+// HandleStartDrain puts a backend service into maintenance mode
+func (h *Handler) HandleStartDrain(w http.ResponseWriter, r *http.Request) {
+	serviceName := mux.Vars(r)["service"]
+	if _, ok := h.config.Services[serviceName]; !ok {
+		h.sendError(w, r, http.StatusNotFound, "SERVICE_NOT_FOUND", fmt.Sprintf("Unknown service %s", serviceName))
+		return
+	}
+
+	var req DrainRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.sendError(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON format")
+			return
+		}
+	}
+
+	state := proxy.DrainState{
+		Service:     serviceName,
+		Message:     req.Message,
+		ServeCached: req.ServeCached,
+	}
+
+	if req.Until != "" {
+		until, err := time.Parse(time.RFC3339, req.Until)
+		if err != nil {
+			h.sendError(w, r, http.StatusBadRequest, "INVALID_UNTIL", "until must be an RFC3339 timestamp")
+			return
+		}
+		state.Until = until
+	}
+
+	h.registry.StartDrain(state)
+	h.auditAction(r, "admin.services.drain", serviceName, audit.ResultSuccess, map[string]string{
+		"serve_cached": fmt.Sprintf("%v", req.ServeCached),
+		"until":        req.Until,
+	})
+	slog.InfoContext(r.Context(), "service draining", "service", serviceName, "serve_cached", req.ServeCached)
+
+	current, _ := h.registry.GetDrainState(serviceName)
+	h.sendJSON(w, http.StatusOK, current)
+}
+
+// HandleStopDrain returns a backend service to normal operation
+func (h *Handler) HandleStopDrain(w http.ResponseWriter, r *http.Request) {
+	serviceName := mux.Vars(r)["service"]
+
+	if !h.registry.StopDrain(serviceName) {
+		h.sendError(w, r, http.StatusNotFound, "NOT_DRAINING", fmt.Sprintf("Service %s is not draining", serviceName))
+		return
+	}
+
+	h.auditAction(r, "admin.services.undrain", serviceName, audit.ResultSuccess, nil)
+	slog.InfoContext(r.Context(), "service back in service", "service", serviceName)
+	h.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"service":  serviceName,
+		"draining": false,
+	})
+}
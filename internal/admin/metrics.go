@@ -0,0 +1,48 @@
+package admin
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"hub-api-gateway/internal/audit"
+)
+
+// HandleResetMetrics clears the metrics of one route (?route=) or one backend
+// service (?service=). There is deliberately no way to wipe everything.
+func (h *Handler) HandleResetMetrics(w http.ResponseWriter, r *http.Request) {
+	if h.metrics == nil {
+		h.sendError(w, r, http.StatusServiceUnavailable, "METRICS_DISABLED", "Metrics are not enabled")
+		return
+	}
+
+	routeName := r.URL.Query().Get("route")
+	serviceName := r.URL.Query().Get("service")
+
+	var scope, name string
+	var found bool
+	switch {
+	case routeName != "" && serviceName != "":
+		h.sendError(w, r, http.StatusBadRequest, "INVALID_SCOPE", "Specify either route or service, not both")
+		return
+	case routeName != "":
+		scope, name, found = "route", routeName, h.metrics.ResetRoute(routeName)
+	case serviceName != "":
+		scope, name, found = "service", serviceName, h.metrics.ResetService(serviceName)
+	default:
+		h.sendError(w, r, http.StatusBadRequest, "INVALID_SCOPE", "A route or service query parameter is required")
+		return
+	}
+
+	if !found {
+		h.sendError(w, r, http.StatusNotFound, "METRICS_NOT_FOUND", fmt.Sprintf("No metrics recorded for %s %s", scope, name))
+		return
+	}
+
+	h.auditAction(r, "admin.metrics.reset", scope+":"+name, audit.ResultSuccess, nil)
+	slog.InfoContext(r.Context(), "metrics reset", "scope", scope, "name", name)
+	h.sendJSON(w, http.StatusOK, map[string]interface{}{
+		"reset": scope,
+		"name":  name,
+	})
+}
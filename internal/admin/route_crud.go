@@ -0,0 +1,186 @@
+package admin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"hub-api-gateway/internal/audit"
+	"hub-api-gateway/internal/router"
+
+	"github.com/gorilla/mux"
+)
+
+// maxRouteSize bounds the size of a single route document
+const maxRouteSize = 64 << 10 // 64KB
+
+// RouteListResponse is returned by GET /admin/routes
+type RouteListResponse struct {
+	Count      int            `json:"count"`
+	ConfigPath string         `json:"configPath,omitempty"`
+	Routes     []router.Route `json:"routes"`
+}
+
+// RouteChangeResult is returned by the route create, update and delete endpoints
+type RouteChangeResult struct {
+	Route     *router.Route `json:"route,omitempty"`
+	Persisted bool          `json:"persisted"`
+	Warning   string        `json:"warning,omitempty"`
+	Errors    []string      `json:"errors,omitempty"`
+}
+
+// HandleListRoutes returns the active route table
+func (h *Handler) HandleListRoutes(w http.ResponseWriter, r *http.Request) {
+	routes := h.router.GetRoutes()
+	h.sendJSON(w, http.StatusOK, RouteListResponse{
+		Count:      len(routes),
+		ConfigPath: h.router.ConfigPath(),
+		Routes:     routes,
+	})
+}
+
+// HandleGetRoute returns a single route by name
+func (h *Handler) HandleGetRoute(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	route, ok := findRoute(h.router.GetRoutes(), name)
+	if !ok {
+		h.sendError(w, r, http.StatusNotFound, "ROUTE_NOT_FOUND", fmt.Sprintf("Route %s not found", name))
+		return
+	}
+	h.sendJSON(w, http.StatusOK, route)
+}
+
+// HandleCreateRoute adds a route and persists the route table
+func (h *Handler) HandleCreateRoute(w http.ResponseWriter, r *http.Request) {
+	route, ok := h.decodeRoute(w, r)
+	if !ok {
+		return
+	}
+
+	h.routesMu.Lock()
+	defer h.routesMu.Unlock()
+
+	current := h.router.GetRoutes()
+	if _, exists := findRoute(current, route.Name); exists {
+		h.sendError(w, r, http.StatusConflict, "ROUTE_EXISTS", fmt.Sprintf("Route %s already exists", route.Name))
+		return
+	}
+
+	h.applyRouteChange(w, r, "admin.routes.create", http.StatusCreated, &route, append(current, route))
+}
+
+// HandleUpdateRoute replaces an existing route and persists the route table
+func (h *Handler) HandleUpdateRoute(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	route, ok := h.decodeRoute(w, r)
+	if !ok {
+		return
+	}
+	if route.Name == "" {
+		route.Name = name
+	}
+	if route.Name != name {
+		h.sendError(w, r, http.StatusBadRequest, "ROUTE_NAME_MISMATCH", "Route name in body must match the URL")
+		return
+	}
+
+	h.routesMu.Lock()
+	defer h.routesMu.Unlock()
+
+	current := h.router.GetRoutes()
+	if _, exists := findRoute(current, name); !exists {
+		h.sendError(w, r, http.StatusNotFound, "ROUTE_NOT_FOUND", fmt.Sprintf("Route %s not found", name))
+		return
+	}
+
+	h.applyRouteChange(w, r, "admin.routes.update", http.StatusOK, &route, mergeRoutes(current, []router.Route{route}))
+}
+
+// HandleDeleteRoute removes a route and persists the route table
+func (h *Handler) HandleDeleteRoute(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+
+	h.routesMu.Lock()
+	defer h.routesMu.Unlock()
+
+	current := h.router.GetRoutes()
+	route, exists := findRoute(current, name)
+	if !exists {
+		h.sendError(w, r, http.StatusNotFound, "ROUTE_NOT_FOUND", fmt.Sprintf("Route %s not found", name))
+		return
+	}
+
+	proposed := make([]router.Route, 0, len(current)-1)
+	for _, existing := range current {
+		if existing.Name != name {
+			proposed = append(proposed, existing)
+		}
+	}
+
+	h.applyRouteChange(w, r, "admin.routes.delete", http.StatusOK, &route, proposed)
+}
+
+// applyRouteChange validates and applies the proposed route table, then writes
+// it back to the routes file. A table that applied but could not be persisted
+// is reported with persisted=false rather than rolled back.
+func (h *Handler) applyRouteChange(w http.ResponseWriter, r *http.Request, action string, successStatus int, route *router.Route, proposed []router.Route) {
+	result := RouteChangeResult{Route: route}
+
+	if errs := h.router.CheckRoutes(proposed); len(errs) > 0 {
+		for _, err := range errs {
+			result.Errors = append(result.Errors, err.Error())
+		}
+		h.sendJSON(w, http.StatusUnprocessableEntity, result)
+		return
+	}
+
+	if err := h.router.ReplaceRoutes(proposed); err != nil {
+		slog.ErrorContext(r.Context(), "failed to apply route change", "action", action, "route", route.Name, "error", err)
+		h.auditAction(r, action, route.Name, audit.ResultFailure, map[string]string{"error": err.Error()})
+		h.sendError(w, r, http.StatusInternalServerError, "ROUTE_CHANGE_FAILED", err.Error())
+		return
+	}
+
+	switch err := h.router.SaveConfig(); {
+	case err == nil:
+		result.Persisted = true
+	case errors.Is(err, router.ErrNoConfigPath):
+		result.Warning = "Routes are not loaded from a file; the change lasts until restart"
+	default:
+		slog.WarnContext(r.Context(), "route change applied but not persisted", "action", action, "route", route.Name, "error", err)
+		result.Warning = "Failed to persist routes file: " + err.Error()
+	}
+
+	h.auditAction(r, action, route.Name, audit.ResultSuccess, map[string]string{
+		"persisted": fmt.Sprintf("%v", result.Persisted),
+	})
+	slog.InfoContext(r.Context(), "route changed via admin API", "action", action, "route", route.Name, "persisted", result.Persisted)
+
+	h.sendJSON(w, successStatus, result)
+}
+
+// decodeRoute reads a single JSON route from the request body
+func (h *Handler) decodeRoute(w http.ResponseWriter, r *http.Request) (router.Route, bool) {
+	var route router.Route
+
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxRouteSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&route); err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "INVALID_ROUTE", fmt.Sprintf("Invalid route document: %v", err))
+		return route, false
+	}
+	return route, true
+}
+
+// findRoute looks up a route by name
+func findRoute(routes []router.Route, name string) (router.Route, bool) {
+	for _, route := range routes {
+		if route.Name == name {
+			return route, true
+		}
+	}
+	return router.Route{}, false
+}
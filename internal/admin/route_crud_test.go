@@ -0,0 +1,102 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hub-api-gateway/internal/router"
+)
+
+func TestRouteCRUD(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.yaml")
+	if err := os.WriteFile(path, []byte("routes:\n  - {name: orders, path: /api/v1/orders, method: GET, service: hub-monolith, grpc_service: OrderService, grpc_method: GetOrderDetails}\n"), 0o600); err != nil {
+		t.Fatalf("failed to write routes file: %v", err)
+	}
+	serviceRouter, err := router.NewServiceRouter(path)
+	if err != nil {
+		t.Fatalf("failed to load routes: %v", err)
+	}
+	handler := newTestAdmin(t, serviceRouter)
+
+	send := func(method, target string, route any) (int, RouteChangeResult) {
+		body, _ := json.Marshal(route)
+		rec := adminRequest(handler, method, target, "application/json", string(body))
+		var result RouteChangeResult
+		json.Unmarshal(rec.Body.Bytes(), &result)
+		return rec.Code, result
+	}
+	persisted := func() []string {
+		reloaded, err := router.NewServiceRouter(path)
+		if err != nil {
+			t.Fatalf("failed to reload the routes file: %v", err)
+		}
+		return routeNames(reloaded)
+	}
+
+	// Unknown routes can't be updated or deleted
+	if code, _ := send(http.MethodPut, "/admin/routes/quotes", testRoute("quotes")); code != http.StatusNotFound {
+		t.Errorf("expected 404 updating an unknown route but got %d", code)
+	}
+	if code, _ := send(http.MethodDelete, "/admin/routes/quotes", nil); code != http.StatusNotFound {
+		t.Errorf("expected 404 deleting an unknown route but got %d", code)
+	}
+
+	// Invalid routes are refused and nothing is written
+	invalid := testRoute("quotes")
+	invalid.Method = "FETCH"
+	if code, result := send(http.MethodPost, "/admin/routes", invalid); code != http.StatusUnprocessableEntity || len(result.Errors) == 0 {
+		t.Errorf("expected 422 creating an invalid route but got %d %+v", code, result)
+	}
+
+	// Changes apply and are written back to the routes file
+	if code, result := send(http.MethodPost, "/admin/routes", testRoute("quotes")); code != http.StatusCreated || !result.Persisted {
+		t.Fatalf("expected a persisted create but got %d %+v", code, result)
+	}
+	updated := testRoute("quotes")
+	updated.Timeout = "3s"
+	if code, result := send(http.MethodPut, "/admin/routes/quotes", updated); code != http.StatusOK || !result.Persisted {
+		t.Fatalf("expected a persisted update but got %d %+v", code, result)
+	}
+	if code, result := send(http.MethodDelete, "/admin/routes/orders", nil); code != http.StatusOK || !result.Persisted {
+		t.Fatalf("expected a persisted delete but got %d %+v", code, result)
+	}
+	if names := persisted(); strings.Join(names, ",") != "quotes" {
+		t.Errorf("expected only quotes in the routes file but got %v", names)
+	}
+	if routes := serviceRouter.GetRoutes(); len(routes) != 1 || routes[0].Timeout != "3s" {
+		t.Errorf("expected the updated route to be active but got %+v", routes)
+	}
+
+	// Saving replaces the file through a temporary file and keeps its mode
+	if info, err := os.Stat(path); err != nil {
+		t.Errorf("failed to stat the routes file: %v", err)
+	} else if info.Mode().Perm() != 0o600 {
+		t.Errorf("expected the routes file mode to be kept but got %v", info.Mode())
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".routes-*")); len(leftovers) != 0 {
+		t.Errorf("expected no temporary files left behind but got %v", leftovers)
+	}
+}
+
+func TestRouteCRUD_WithoutRoutesFile(t *testing.T) {
+	serviceRouter, err := router.NewServiceRouterFromRoutes([]router.Route{testRoute("orders")})
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+	handler := newTestAdmin(t, serviceRouter)
+
+	body, _ := json.Marshal(testRoute("quotes"))
+	rec := adminRequest(handler, http.MethodPost, "/admin/routes", "application/json", string(body))
+	var result RouteChangeResult
+	json.Unmarshal(rec.Body.Bytes(), &result)
+	if rec.Code != http.StatusCreated || result.Persisted || !strings.Contains(result.Warning, "until restart") {
+		t.Errorf("expected an applied but unpersisted change with a warning but got %d %+v", rec.Code, result)
+	}
+	if names := routeNames(serviceRouter); len(names) != 2 {
+		t.Errorf("expected the route to be active but got %v", names)
+	}
+}
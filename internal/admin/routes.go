@@ -0,0 +1,188 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"hub-api-gateway/internal/audit"
+	"hub-api-gateway/internal/router"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maxImportSize bounds the size of an imported route document
+const maxImportSize = 1 << 20 // 1MB
+
+// ImportResult is returned by the route import endpoint
+type ImportResult struct {
+	DryRun  bool             `json:"dry_run"`
+	Applied bool             `json:"applied"`
+	Mode    string           `json:"mode"`
+	Diff    router.RouteDiff `json:"diff"`
+	Errors  []string         `json:"errors,omitempty"`
+}
+
+// HandleExportRoutes returns the active route table as YAML (default) or JSON (?format=json)
+func (h *Handler) HandleExportRoutes(w http.ResponseWriter, r *http.Request) {
+	routeConfig := router.RouteConfig{Routes: h.router.GetRoutes()}
+
+	switch strings.ToLower(r.URL.Query().Get("format")) {
+	case "json":
+		h.sendJSON(w, http.StatusOK, routeConfig)
+
+	case "", "yaml", "yml":
+		data, err := yaml.Marshal(routeConfig)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to marshal routes to YAML", "error", err)
+			h.sendError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to export routes")
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Header().Set("Content-Disposition", `attachment; filename="routes.yaml"`)
+		w.WriteHeader(http.StatusOK)
+		w.Write(data)
+
+	default:
+		h.sendError(w, r, http.StatusBadRequest, "INVALID_FORMAT", "format must be yaml or json")
+	}
+}
+
+// HandleImportRoutes validates an uploaded route document and applies it.
+//
+// Query parameters:
+//   - dry_run=true: validate and return the diff without applying
+//   - mode=replace (default): the document becomes the full route table
+//   - mode=merge: routes in the document are added or updated by name
+func (h *Handler) HandleImportRoutes(w http.ResponseWriter, r *http.Request) {
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+	mode := r.URL.Query().Get("mode")
+	if mode == "" {
+		mode = "replace"
+	}
+	if mode != "replace" && mode != "merge" {
+		h.sendError(w, r, http.StatusBadRequest, "INVALID_MODE", "mode must be replace or merge")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxImportSize+1))
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "Failed to read request body")
+		return
+	}
+	defer r.Body.Close()
+
+	if len(body) > maxImportSize {
+		h.sendError(w, r, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE",
+			fmt.Sprintf("Route document exceeds %d bytes", maxImportSize))
+		return
+	}
+
+	imported, err := parseRouteDocument(body, r.Header.Get("Content-Type"))
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "INVALID_DOCUMENT", err.Error())
+		return
+	}
+
+	h.routesMu.Lock()
+	defer h.routesMu.Unlock()
+
+	current := h.router.GetRoutes()
+	proposed := imported.Routes
+	if mode == "merge" {
+		proposed = mergeRoutes(current, imported.Routes)
+	}
+
+	result := ImportResult{
+		DryRun: dryRun,
+		Mode:   mode,
+		Diff:   router.DiffRoutes(current, proposed),
+	}
+
+	if errs := h.router.CheckRoutes(proposed); len(errs) > 0 {
+		for _, err := range errs {
+			result.Errors = append(result.Errors, err.Error())
+		}
+		h.sendJSON(w, http.StatusUnprocessableEntity, result)
+		return
+	}
+
+	if dryRun || !result.Diff.HasChanges() {
+		h.sendJSON(w, http.StatusOK, result)
+		return
+	}
+
+	if err := h.router.ReplaceRoutes(proposed); err != nil {
+		slog.ErrorContext(r.Context(), "failed to apply imported routes", "error", err)
+		h.auditAction(r, "admin.routes.import", "routes", audit.ResultFailure, map[string]string{"error": err.Error()})
+		h.sendError(w, r, http.StatusInternalServerError, "IMPORT_FAILED", err.Error())
+		return
+	}
+
+	result.Applied = true
+	h.auditAction(r, "admin.routes.import", "routes", audit.ResultSuccess, map[string]string{
+		"mode":     mode,
+		"added":    strings.Join(result.Diff.Added, ","),
+		"removed":  strings.Join(result.Diff.Removed, ","),
+		"modified": strings.Join(result.Diff.Modified, ","),
+	})
+	slog.InfoContext(r.Context(), "imported routes via admin API", "mode", mode,
+		"added", len(result.Diff.Added), "removed", len(result.Diff.Removed), "modified", len(result.Diff.Modified))
+
+	h.sendJSON(w, http.StatusOK, result)
+}
+
+// parseRouteDocument decodes a JSON or YAML route document based on the content type
+func parseRouteDocument(body []byte, contentType string) (*router.RouteConfig, error) {
+	var routeConfig router.RouteConfig
+
+	if strings.Contains(contentType, "json") {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&routeConfig); err != nil {
+			return nil, fmt.Errorf("invalid JSON route document: %w", err)
+		}
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(body))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&routeConfig); err != nil {
+			return nil, fmt.Errorf("invalid YAML route document: %w", err)
+		}
+	}
+
+	if len(routeConfig.Routes) == 0 {
+		return nil, fmt.Errorf("route document contains no routes")
+	}
+
+	return &routeConfig, nil
+}
+
+// mergeRoutes upserts the imported routes into the current table by name
+func mergeRoutes(current, imported []router.Route) []router.Route {
+	merged := make([]router.Route, 0, len(current)+len(imported))
+	replacements := make(map[string]router.Route, len(imported))
+	for _, route := range imported {
+		replacements[route.Name] = route
+	}
+
+	for _, route := range current {
+		if replacement, ok := replacements[route.Name]; ok {
+			merged = append(merged, replacement)
+			delete(replacements, route.Name)
+		} else {
+			merged = append(merged, route)
+		}
+	}
+
+	for _, route := range imported {
+		if _, pending := replacements[route.Name]; pending {
+			merged = append(merged, route)
+		}
+	}
+
+	return merged
+}
@@ -0,0 +1,157 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/router"
+
+	"github.com/gorilla/mux"
+	"gopkg.in/yaml.v3"
+)
+
+const testAdminToken = "admin-token"
+
+func testRoute(name string) router.Route {
+	return router.Route{
+		Name:        name,
+		Path:        "/api/v1/" + name,
+		Method:      "GET",
+		Service:     "hub-monolith",
+		GRPCService: "OrderService",
+		GRPCMethod:  "GetOrderDetails",
+	}
+}
+
+// newTestAdmin serves the admin API over a router holding routes
+func newTestAdmin(t *testing.T, serviceRouter *router.ServiceRouter) http.Handler {
+	t.Helper()
+	handler := NewHandler(&config.Config{Admin: config.AdminConfig{Enabled: true, Token: testAdminToken}}, Dependencies{Router: serviceRouter})
+	routes := mux.NewRouter()
+	handler.RegisterRoutes(routes)
+	return routes
+}
+
+func adminRequest(handler http.Handler, method, target, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("X-Admin-Token", testAdminToken)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func routeNames(serviceRouter *router.ServiceRouter) []string {
+	var names []string
+	for _, route := range serviceRouter.GetRoutes() {
+		names = append(names, route.Name)
+	}
+	return names
+}
+
+func TestHandleExportRoutes(t *testing.T) {
+	serviceRouter, err := router.NewServiceRouterFromRoutes([]router.Route{testRoute("orders")})
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+	handler := newTestAdmin(t, serviceRouter)
+
+	for _, format := range []string{"", "json"} {
+		rec := adminRequest(handler, http.MethodGet, "/admin/routes/export?format="+format, "", "")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("format %q: expected 200 but got %d", format, rec.Code)
+		}
+		var exported router.RouteConfig
+		if format == "json" {
+			err = json.Unmarshal(rec.Body.Bytes(), &exported)
+		} else {
+			err = yaml.Unmarshal(rec.Body.Bytes(), &exported)
+		}
+		if err != nil || len(exported.Routes) != 1 || exported.Routes[0].Name != "orders" {
+			t.Errorf("format %q: expected the route table back but got %v (%v)", format, exported.Routes, err)
+		}
+	}
+	if contentType := adminRequest(handler, http.MethodGet, "/admin/routes/export", "", "").Header().Get("Content-Type"); contentType != "application/yaml" {
+		t.Errorf("expected YAML by default but got %s", contentType)
+	}
+	if rec := adminRequest(handler, http.MethodGet, "/admin/routes/export?format=xml", "", ""); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown format to be rejected but got %d", rec.Code)
+	}
+}
+
+func TestHandleImportRoutes(t *testing.T) {
+	serviceRouter, err := router.NewServiceRouterFromRoutes([]router.Route{testRoute("orders"), testRoute("positions")})
+	if err != nil {
+		t.Fatalf("failed to create router: %v", err)
+	}
+	handler := newTestAdmin(t, serviceRouter)
+
+	document := func(routes ...router.Route) string {
+		data, _ := json.Marshal(router.RouteConfig{Routes: routes})
+		return string(data)
+	}
+	decode := func(rec *httptest.ResponseRecorder) ImportResult {
+		var result ImportResult
+		json.Unmarshal(rec.Body.Bytes(), &result)
+		return result
+	}
+
+	// Unknown fields are typos, not silently ignored settings
+	yamlDoc := "routes:\n  - name: orders\n    path: /api/v1/orders\n    method: GET\n    service: hub-monolith\n    grpc_service: OrderService\n    grpc_method: GetOrderDetails\n    timeuot: 5s\n"
+	if rec := adminRequest(handler, http.MethodPost, "/admin/routes/import", "application/yaml", yamlDoc); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown YAML field to be rejected but got %d", rec.Code)
+	}
+	if rec := adminRequest(handler, http.MethodPost, "/admin/routes/import", "application/json", `{"routes":[{"name":"orders","colour":"red"}]}`); rec.Code != http.StatusBadRequest {
+		t.Errorf("expected an unknown JSON field to be rejected but got %d", rec.Code)
+	}
+
+	// A dry run reports the diff without applying it
+	changed := testRoute("orders")
+	changed.Timeout = "5s"
+	rec := adminRequest(handler, http.MethodPost, "/admin/routes/import?dry_run=true", "application/json", document(changed, testRoute("quotes")))
+	result := decode(rec)
+	if rec.Code != http.StatusOK || result.Applied || !result.DryRun {
+		t.Fatalf("expected an unapplied dry run but got %d %+v", rec.Code, result)
+	}
+	if strings.Join(result.Diff.Added, ",") != "quotes" || strings.Join(result.Diff.Removed, ",") != "positions" || strings.Join(result.Diff.Modified, ",") != "orders" {
+		t.Errorf("unexpected diff: %+v", result.Diff)
+	}
+	if names := routeNames(serviceRouter); len(names) != 2 {
+		t.Errorf("expected the dry run to leave the table alone but got %v", names)
+	}
+
+	// Merge upserts by name and keeps the other routes
+	rec = adminRequest(handler, http.MethodPost, "/admin/routes/import?mode=merge", "application/json", document(changed, testRoute("quotes")))
+	if result := decode(rec); rec.Code != http.StatusOK || !result.Applied || len(result.Diff.Removed) != 0 {
+		t.Fatalf("expected the merge to apply without removals but got %d %+v", rec.Code, result)
+	}
+	if names := routeNames(serviceRouter); len(names) != 3 {
+		t.Errorf("expected orders, positions and quotes after the merge but got %v", names)
+	}
+
+	// Replace makes the document the whole table
+	rec = adminRequest(handler, http.MethodPost, "/admin/routes/import", "application/json", document(testRoute("quotes")))
+	if result := decode(rec); rec.Code != http.StatusOK || !result.Applied || len(result.Diff.Removed) != 2 {
+		t.Fatalf("expected the replace to remove two routes but got %d %+v", rec.Code, result)
+	}
+	if names := routeNames(serviceRouter); len(names) != 1 || names[0] != "quotes" {
+		t.Errorf("expected only quotes after the replace but got %v", names)
+	}
+
+	// Invalid tables are refused with every problem listed
+	invalid := testRoute("orders")
+	invalid.Timeout = "banana"
+	rec = adminRequest(handler, http.MethodPost, "/admin/routes/import", "application/json", document(invalid, testRoute("quotes"), testRoute("quotes")))
+	if result := decode(rec); rec.Code != http.StatusUnprocessableEntity || result.Applied || len(result.Errors) != 2 {
+		t.Errorf("expected 422 with two errors but got %d %+v", rec.Code, result)
+	}
+	if names := routeNames(serviceRouter); len(names) != 1 {
+		t.Errorf("expected a refused import to leave the table alone but got %v", names)
+	}
+}
@@ -0,0 +1,33 @@
+package admin
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"hub-api-gateway/internal/trace"
+
+	"github.com/gorilla/mux"
+)
+
+// HandleGetTrace returns the recorded trace of a request flagged with X-Gateway-Trace
+func (h *Handler) HandleGetTrace(w http.ResponseWriter, r *http.Request) {
+	if h.traces == nil {
+		h.sendError(w, r, http.StatusServiceUnavailable, "TRACES_DISABLED", "Request tracing is not enabled (set TRACE_TOKEN)")
+		return
+	}
+
+	requestID := mux.Vars(r)["requestId"]
+	requestTrace, err := h.traces.Get(r.Context(), requestID)
+	if errors.Is(err, trace.ErrNotFound) {
+		h.sendError(w, r, http.StatusNotFound, "TRACE_NOT_FOUND", "No trace recorded for request "+requestID)
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to load trace", "trace_id", requestID, "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "TRACE_UNAVAILABLE", "Failed to load trace")
+		return
+	}
+
+	h.sendJSON(w, http.StatusOK, requestTrace)
+}
@@ -0,0 +1,28 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HandleRouteUsage reports when each route was last matched and which ones
+// are candidates for pruning (?unused_days=N overrides ROUTE_USAGE_UNUSED_DAYS)
+func (h *Handler) HandleRouteUsage(w http.ResponseWriter, r *http.Request) {
+	if h.usage == nil {
+		h.sendError(w, r, http.StatusServiceUnavailable, "USAGE_DISABLED", "Route usage tracking is not enabled")
+		return
+	}
+
+	unusedAfter := h.config.RouteUsage.UnusedAfter
+	if value := r.URL.Query().Get("unused_days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days <= 0 {
+			h.sendError(w, r, http.StatusBadRequest, "INVALID_UNUSED_DAYS", "unused_days must be a positive integer")
+			return
+		}
+		unusedAfter = time.Duration(days) * 24 * time.Hour
+	}
+
+	h.sendJSON(w, http.StatusOK, h.usage.Report(h.router.GetRoutes(), unusedAfter))
+}
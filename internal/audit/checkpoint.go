@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// CheckpointSink wraps a sink that can't be read back (syslog, Kafka) and
+// keeps the last written record in a local state file, so the chain continues
+// across restarts instead of starting over at seq 1
+type CheckpointSink struct {
+	Sink
+	path string
+}
+
+// NewCheckpointSink wraps sink and returns the last record of the state file
+// (nil when there is none yet)
+func NewCheckpointSink(sink Sink, path string) (*CheckpointSink, *Record, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &CheckpointSink{Sink: sink, path: path}, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read audit state %s: %w", path, err)
+	}
+
+	var last Record
+	if err := json.Unmarshal(data, &last); err != nil {
+		return nil, nil, fmt.Errorf("invalid audit state %s: %w", path, err)
+	}
+	return &CheckpointSink{Sink: sink, path: path}, &last, nil
+}
+
+// Write writes the record to the wrapped sink, then records it as the last one
+func (s *CheckpointSink) Write(record Record) error {
+	if err := s.Sink.Write(record); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit state: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".audit-state-*")
+	if err != nil {
+		return fmt.Errorf("failed to write audit state: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write audit state: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write audit state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write audit state: %w", err)
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
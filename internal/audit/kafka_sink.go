@@ -0,0 +1,201 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// kafkaContentType is the Kafka REST Proxy v2 media type for JSON records
+const kafkaContentType = "application/vnd.kafka.json.v2+json"
+
+// Backoff between attempts to produce a record
+const (
+	kafkaMinBackoff = 100 * time.Millisecond
+	kafkaMaxBackoff = 5 * time.Second
+)
+
+// KafkaSink produces records to a Kafka topic through a Kafka REST Proxy
+// (v2 API), so the gateway needs no Kafka client. Every record is keyed by
+// the same chain key, keeping the chain in one partition and in order.
+//
+// Writes only queue the record: a background producer sends the queue in
+// order and retries a record until the proxy acknowledges it, so the logger
+// never waits on the network and a record is never re-signed. A record whose
+// acknowledgement was lost may be produced twice; Verify skips the copy.
+type KafkaSink struct {
+	endpoint string
+	key      string
+	timeout  time.Duration
+	client   *http.Client
+
+	queue     chan Record
+	closing   chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// kafkaRecord is one record of a REST Proxy produce request
+type kafkaRecord struct {
+	Key   string `json:"key"`
+	Value Record `json:"value"`
+}
+
+// NewKafkaSink creates a sink producing to topic through the REST Proxy at
+// restURL; key identifies this gateway's chain (e.g. its hostname) and at
+// most queueSize records wait to be produced
+func NewKafkaSink(restURL, topic, key string, timeout time.Duration, queueSize int) (*KafkaSink, error) {
+	if restURL == "" || topic == "" {
+		return nil, fmt.Errorf("kafka audit sink needs a REST proxy URL and a topic")
+	}
+	if _, err := url.ParseRequestURI(restURL); err != nil {
+		return nil, fmt.Errorf("invalid kafka REST proxy URL %q: %w", restURL, err)
+	}
+	if queueSize <= 0 {
+		return nil, fmt.Errorf("kafka audit sink needs a positive queue size")
+	}
+	s := &KafkaSink{
+		endpoint: strings.TrimRight(restURL, "/") + "/topics/" + url.PathEscape(topic),
+		key:      key,
+		timeout:  timeout,
+		client:   &http.Client{},
+		queue:    make(chan Record, queueSize),
+		closing:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+// Write queues a record for the producer. It fails when the queue is full,
+// leaving the record out of the chain.
+func (s *KafkaSink) Write(record Record) error {
+	select {
+	case <-s.closing:
+		return errors.New("kafka audit sink is closed")
+	default:
+	}
+	select {
+	case s.queue <- record:
+		return nil
+	default:
+		return fmt.Errorf("kafka audit queue is full (%d records)", cap(s.queue))
+	}
+}
+
+// run produces queued records in order until the sink is closed
+func (s *KafkaSink) run() {
+	defer close(s.done)
+	for {
+		select {
+		case record := <-s.queue:
+			if !s.deliver(record) {
+				s.drop(1 + len(s.queue))
+				return
+			}
+		case <-s.closing:
+			s.flush()
+			return
+		}
+	}
+}
+
+// deliver produces a record, retrying until it is acknowledged. It gives up
+// only when the sink is closed, returning false.
+func (s *KafkaSink) deliver(record Record) bool {
+	backoff := kafkaMinBackoff
+	for {
+		err := s.produce(record)
+		if err == nil {
+			return true
+		}
+		slog.Error("failed to produce audit record, retrying", "seq", record.Sequence, "error", err)
+		select {
+		case <-time.After(backoff):
+		case <-s.closing:
+			return s.produce(record) == nil
+		}
+		backoff = min(backoff*2, kafkaMaxBackoff)
+	}
+}
+
+// flush makes one attempt at each record still queued at shutdown
+func (s *KafkaSink) flush() {
+	for {
+		select {
+		case record := <-s.queue:
+			if err := s.produce(record); err != nil {
+				slog.Error("failed to produce audit record", "seq", record.Sequence, "error", err)
+				s.drop(1 + len(s.queue))
+				return
+			}
+		default:
+			return
+		}
+	}
+}
+
+// drop reports records lost at shutdown; the chain shows them as a gap
+func (s *KafkaSink) drop(count int) {
+	slog.Error("audit records were not produced before shutdown", "count", count)
+}
+
+// produce sends one record and waits for the proxy to acknowledge it
+func (s *KafkaSink) produce(record Record) error {
+	body, err := json.Marshal(map[string][]kafkaRecord{"records": {{Key: s.key, Value: record}}})
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", kafkaContentType)
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to produce audit record: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("kafka REST proxy answered %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+
+	// The proxy answers 200 with per-record errors when a record is refused
+	var produced struct {
+		Offsets []struct {
+			Error string `json:"error"`
+		} `json:"offsets"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&produced); err == nil {
+		for _, offset := range produced.Offsets {
+			if offset.Error != "" {
+				return fmt.Errorf("kafka refused audit record: %s", offset.Error)
+			}
+		}
+	}
+	return nil
+}
+
+// Close stops accepting records, makes one more attempt at those still
+// queued and releases idle connections to the proxy
+func (s *KafkaSink) Close() error {
+	s.closeOnce.Do(func() { close(s.closing) })
+	<-s.done
+	s.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,123 @@
+package audit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestKafkaSink_ChainContinuesAcrossRestarts produces through a fake REST
+// proxy and checks the checkpoint resumes the chain after a restart
+func TestKafkaSink_ChainContinuesAcrossRestarts(t *testing.T) {
+	var produced []kafkaRecord
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/topics/gateway-audit" || r.Header.Get("Content-Type") != kafkaContentType {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		var body struct {
+			Records []kafkaRecord `json:"records"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		produced = append(produced, body.Records...)
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":1}]}`))
+	}))
+	defer proxy.Close()
+
+	signer, _ := NewSigner(map[string]string{"k1": "secret"}, "k1")
+	statePath := filepath.Join(t.TempDir(), "audit.state")
+	start := func() *Logger {
+		kafka, err := NewKafkaSink(proxy.URL, "gateway-audit", "gw-1", time.Second, 10)
+		if err != nil {
+			t.Fatalf("failed to create kafka sink: %v", err)
+		}
+		sink, last, err := NewCheckpointSink(kafka, statePath)
+		if err != nil {
+			t.Fatalf("failed to create checkpoint sink: %v", err)
+		}
+		return NewLogger(sink, signer, last)
+	}
+
+	logger := start()
+	logger.Log(Event{Actor: "user-1", Action: "order.submit", Result: ResultSuccess})
+	logger.Log(Event{Actor: "user-1", Action: "order.cancel", Result: ResultFailure})
+	logger.Close()
+
+	logger = start()
+	logger.Log(Event{Actor: "admin", Action: "admin.routes.import", Result: ResultSuccess})
+	logger.Close()
+
+	if len(produced) != 3 {
+		t.Fatalf("produced %d records, want 3", len(produced))
+	}
+	sink := &memorySink{}
+	for i, record := range produced {
+		if record.Key != "gw-1" || record.Value.Sequence != uint64(i+1) {
+			t.Errorf("record %d: key %q seq %d", i, record.Key, record.Value.Sequence)
+		}
+		sink.records = append(sink.records, record.Value)
+	}
+	if report := Verify(strings.NewReader(sink.jsonLines(t)), signer); !report.Valid() {
+		t.Errorf("chain broken across restart: %v", report.Err)
+	}
+}
+
+// TestKafkaSink_RetryKeepsTheChain times out a produce the proxy did accept
+// and checks the retry repeats the same record instead of forking the chain
+func TestKafkaSink_RetryKeepsTheChain(t *testing.T) {
+	var mu sync.Mutex
+	var produced []Record
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Records []kafkaRecord `json:"records"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		first := len(produced) == 0
+		for _, record := range body.Records {
+			produced = append(produced, record.Value)
+		}
+		mu.Unlock()
+		if first {
+			time.Sleep(200 * time.Millisecond)
+		}
+		w.Write([]byte(`{"offsets":[{"partition":0,"offset":1}]}`))
+	}))
+	defer proxy.Close()
+
+	sink, err := NewKafkaSink(proxy.URL, "gateway-audit", "gw-1", 50*time.Millisecond, 10)
+	if err != nil {
+		t.Fatalf("failed to create kafka sink: %v", err)
+	}
+	signer, _ := NewSigner(map[string]string{"k1": "secret"}, "k1")
+	logger := NewLogger(sink, signer, nil)
+	logger.Log(Event{Actor: "user-1", Action: "order.submit", Result: ResultSuccess})
+	logger.Log(Event{Actor: "user-1", Action: "order.cancel", Result: ResultFailure})
+	for {
+		mu.Lock()
+		n := len(produced)
+		mu.Unlock()
+		if n >= 3 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	logger.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if produced[0].Signature != produced[1].Signature || produced[2].Sequence != 2 {
+		t.Fatalf("expected seq 1 twice then seq 2 but got %d, %d, %d", produced[0].Sequence, produced[1].Sequence, produced[2].Sequence)
+	}
+	if report := Verify(strings.NewReader((&memorySink{records: produced}).jsonLines(t)), signer); !report.Valid() || report.Records != 2 {
+		t.Errorf("expected a valid chain of 2 records but got %d: %v", report.Records, report.Err)
+	}
+}
@@ -0,0 +1,104 @@
+package audit
+
+import (
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Result values for audit events
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// Event describes who did what, when, and with which result
+type Event struct {
+	Timestamp  time.Time         `json:"timestamp"`
+	Actor      string            `json:"actor"`  // Principal: user ID, "admin", service name
+	Action     string            `json:"action"` // e.g. "auth.login", "admin.routes.import"
+	Resource   string            `json:"resource,omitempty"`
+	Result     string            `json:"result"`
+	RemoteAddr string            `json:"remote_addr,omitempty"`
+	RequestID  string            `json:"request_id,omitempty"`
+	Details    map[string]string `json:"details,omitempty"`
+}
+
+// Record is a signed audit event linked to the previous record
+type Record struct {
+	Sequence uint64 `json:"seq"`
+	Event
+	KeyID     string `json:"kid"`
+	PrevHash  string `json:"prev"` // Signature of the previous record ("" for the first)
+	Signature string `json:"sig"`
+}
+
+// Sink persists audit records
+type Sink interface {
+	Write(record Record) error
+	Close() error
+}
+
+// Logger signs events into a hash chain and writes them to a sink.
+// A nil *Logger is valid and discards events, so callers don't need to
+// check whether auditing is enabled.
+type Logger struct {
+	mu       sync.Mutex
+	sink     Sink
+	signer   *Signer
+	sequence uint64
+	prevHash string
+}
+
+// NewLogger creates an audit logger continuing the chain after last (nil for a new chain)
+func NewLogger(sink Sink, signer *Signer, last *Record) *Logger {
+	logger := &Logger{sink: sink, signer: signer}
+	if last != nil {
+		logger.sequence = last.Sequence
+		logger.prevHash = last.Signature
+	}
+	return logger
+}
+
+// Log signs and persists an event. Failures are logged, never returned,
+// so auditing can't break the request being audited.
+func (l *Logger) Log(event Event) {
+	if l == nil {
+		return
+	}
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now().UTC()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	record := Record{
+		Sequence: l.sequence + 1,
+		Event:    event,
+		PrevHash: l.prevHash,
+	}
+
+	if err := l.signer.Sign(&record); err != nil {
+		slog.Error("failed to sign audit record", "action", event.Action, "error", err)
+		return
+	}
+
+	if err := l.sink.Write(record); err != nil {
+		slog.Error("failed to write audit record", "action", event.Action, "error", err)
+		return
+	}
+
+	l.sequence = record.Sequence
+	l.prevHash = record.Signature
+}
+
+// Close closes the underlying sink once no event is being logged
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.sink.Close()
+}
@@ -0,0 +1,23 @@
+//go:build windows || plan9
+
+package audit
+
+import "errors"
+
+// SyslogSink is unavailable on this platform
+type SyslogSink struct{}
+
+// NewSyslogSink fails where log/syslog isn't available
+func NewSyslogSink(network, address, tag string) (*SyslogSink, error) {
+	return nil, errors.New("AUDIT_SINK=syslog is not supported on this platform")
+}
+
+// Write implements Sink
+func (s *SyslogSink) Write(record Record) error {
+	return errors.New("syslog is not supported on this platform")
+}
+
+// Close implements Sink
+func (s *SyslogSink) Close() error {
+	return nil
+}
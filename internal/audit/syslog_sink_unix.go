@@ -0,0 +1,41 @@
+//go:build !(windows || plan9)
+
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/syslog"
+)
+
+// SyslogSink sends records as JSON messages with the auth facility
+type SyslogSink struct {
+	writer *syslog.Writer
+}
+
+// NewSyslogSink connects to the syslog server at address over network
+// ("udp", "tcp"), or to the local syslog daemon when address is empty
+func NewSyslogSink(network, address, tag string) (*SyslogSink, error) {
+	if address == "" {
+		network = ""
+	}
+	writer, err := syslog.Dial(network, address, syslog.LOG_AUTH|syslog.LOG_NOTICE, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to syslog: %w", err)
+	}
+	return &SyslogSink{writer: writer}, nil
+}
+
+// Write sends a record
+func (s *SyslogSink) Write(record Record) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit record: %w", err)
+	}
+	return s.writer.Notice(string(line))
+}
+
+// Close closes the syslog connection
+func (s *SyslogSink) Close() error {
+	return s.writer.Close()
+}
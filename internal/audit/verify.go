@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"fmt"
+	"io"
+)
+
+// VerificationReport summarizes the verification of an audit log
+type VerificationReport struct {
+	Records     uint64
+	KeysUsed    map[string]uint64
+	LastSeq     uint64
+	FirstBadSeq uint64 // 0 when the whole chain verified
+	Err         error
+}
+
+// Valid returns whether the whole chain verified
+func (r VerificationReport) Valid() bool {
+	return r.Err == nil
+}
+
+// Verify checks every signature, the sequence numbering and the hash links of
+// an audit log, stopping at the first broken record. A record repeated right
+// after itself, as a retried Kafka produce leaves it, is skipped.
+func Verify(r io.Reader, signer *Signer) VerificationReport {
+	report := VerificationReport{KeysUsed: make(map[string]uint64)}
+
+	var prev *Record
+	err := scanRecords(r, func(record Record) error {
+		if prev != nil && record.Sequence == prev.Sequence && record.Signature == prev.Signature {
+			return nil
+		}
+		if prev != nil {
+			if record.Sequence != prev.Sequence+1 {
+				return fmt.Errorf("sequence gap: expected %d but found %d", prev.Sequence+1, record.Sequence)
+			}
+			if record.PrevHash != prev.Signature {
+				return fmt.Errorf("broken hash link at seq %d", record.Sequence)
+			}
+		}
+
+		if err := signer.Verify(record); err != nil {
+			return err
+		}
+
+		report.Records++
+		report.KeysUsed[record.KeyID]++
+		report.LastSeq = record.Sequence
+		prev = &record
+		return nil
+	})
+
+	if err != nil {
+		report.Err = err
+		report.FirstBadSeq = report.LastSeq + 1
+	}
+	return report
+}
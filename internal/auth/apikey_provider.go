@@ -0,0 +1,44 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+)
+
+// APIKeyProvider validates static API keys configured for machine clients
+type APIKeyProvider struct {
+	// keys maps the SHA-256 digest of each key to the principal it identifies
+	keys map[[sha256.Size]byte]string
+}
+
+// NewAPIKeyProvider creates an API key provider from a key -> principal mapping
+func NewAPIKeyProvider(keys map[string]string) *APIKeyProvider {
+	hashed := make(map[[sha256.Size]byte]string, len(keys))
+	for key, principal := range keys {
+		hashed[sha256.Sum256([]byte(key))] = principal
+	}
+	return &APIKeyProvider{keys: hashed}
+}
+
+// Name returns the provider name
+func (p *APIKeyProvider) Name() string {
+	return ProviderAPIKey
+}
+
+// Validate looks up the API key and returns the associated principal
+func (p *APIKeyProvider) Validate(_ context.Context, credential Credential) (*Principal, error) {
+	digest := sha256.Sum256([]byte(credential.Value))
+
+	for known, principal := range p.keys {
+		if subtle.ConstantTimeCompare(digest[:], known[:]) == 1 {
+			return &Principal{
+				UserID:   principal,
+				Provider: p.Name(),
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: unknown API key", ErrInvalidCredential)
+}
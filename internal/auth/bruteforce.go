@@ -0,0 +1,414 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hub-api-gateway/internal/metrics"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrLoginThrottled is returned while a client must wait before its next
+	// login attempt, after failed attempts for the email or from its address
+	ErrLoginThrottled = errors.New("too many failed login attempts")
+	// ErrAccountLocked is returned while an email is locked out after too many failed logins
+	ErrAccountLocked = errors.New("account temporarily locked")
+)
+
+// LoginAttemptStore counts failed logins by key (an email or a client address)
+type LoginAttemptStore interface {
+	// Failures returns the failed logins counted for key and when the last one happened
+	Failures(ctx context.Context, key string) (int, time.Time, error)
+
+	// RecordFailure counts a failed login and returns the new count. Counts
+	// are forgotten window after the last failure.
+	RecordFailure(ctx context.Context, key string, window time.Duration) (int, error)
+
+	// AddFailedAccount remembers an account among those that failed to log in
+	// from key and returns how many distinct ones did within window
+	AddFailedAccount(ctx context.Context, key, account string, window time.Duration) (int, error)
+
+	// FailedAccounts returns how many distinct accounts failed to log in from key
+	FailedAccounts(ctx context.Context, key string) (int, error)
+
+	// Reset forgets the failures of key
+	Reset(ctx context.Context, key string) error
+}
+
+// RedisLoginAttemptStore shares failed login counts across gateway instances
+type RedisLoginAttemptStore struct {
+	client *redis.Client
+}
+
+// NewRedisLoginAttemptStore creates a Redis-backed login attempt store
+func NewRedisLoginAttemptStore(client *redis.Client) *RedisLoginAttemptStore {
+	return &RedisLoginAttemptStore{client: client}
+}
+
+// Failures reads the key's count and last failure time
+func (s *RedisLoginAttemptStore) Failures(ctx context.Context, key string) (int, time.Time, error) {
+	values, err := s.client.HMGet(ctx, "login_failures:"+key, "count", "last").Result()
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	count, _ := strconv.Atoi(stringValue(values[0]))
+	last, _ := strconv.ParseInt(stringValue(values[1]), 10, 64)
+	if count == 0 {
+		return 0, time.Time{}, nil
+	}
+	return count, time.UnixMilli(last), nil
+}
+
+// RecordFailure increments the key's count and pushes its expiry back
+func (s *RedisLoginAttemptStore) RecordFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	var count *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		count = pipe.HIncrBy(ctx, "login_failures:"+key, "count", 1)
+		pipe.HSet(ctx, "login_failures:"+key, "last", time.Now().UnixMilli())
+		pipe.PExpire(ctx, "login_failures:"+key, window)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(count.Val()), nil
+}
+
+// AddFailedAccount adds the account to the key's set of failed accounts
+func (s *RedisLoginAttemptStore) AddFailedAccount(ctx context.Context, key, account string, window time.Duration) (int, error) {
+	var count *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, "login_failed_accounts:"+key, account)
+		pipe.PExpire(ctx, "login_failed_accounts:"+key, window)
+		count = pipe.SCard(ctx, "login_failed_accounts:"+key)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int(count.Val()), nil
+}
+
+// FailedAccounts returns the size of the key's set of failed accounts
+func (s *RedisLoginAttemptStore) FailedAccounts(ctx context.Context, key string) (int, error) {
+	count, err := s.client.SCard(ctx, "login_failed_accounts:"+key).Result()
+	return int(count), err
+}
+
+// Reset deletes the key's count
+func (s *RedisLoginAttemptStore) Reset(ctx context.Context, key string) error {
+	return s.client.Del(ctx, "login_failures:"+key).Err()
+}
+
+// stringValue returns an HMGET value as a string ("" when the field is missing)
+func stringValue(value interface{}) string {
+	s, _ := value.(string)
+	return s
+}
+
+// loginFailures are the failed logins of one key held in process memory
+type loginFailures struct {
+	count    int
+	last     time.Time
+	expires  time.Time
+	accounts map[string]time.Time // Failed account -> when it is forgotten
+}
+
+// MemoryLoginAttemptStore keeps failed login counts in process memory (single instance only)
+type MemoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	failures map[string]*loginFailures
+}
+
+// NewMemoryLoginAttemptStore creates an in-memory login attempt store
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{failures: make(map[string]*loginFailures)}
+}
+
+// Failures returns the key's count unless it expired
+func (s *MemoryLoginAttemptStore) Failures(_ context.Context, key string) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.entry(key, time.Now())
+	return entry.count, entry.last, nil
+}
+
+// RecordFailure increments the key's count, dropping expired keys first
+func (s *MemoryLoginAttemptStore) RecordFailure(_ context.Context, key string, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range s.failures {
+		if now.After(entry.expires) {
+			delete(s.failures, k)
+		}
+	}
+
+	entry := s.entry(key, now)
+	entry.count++
+	entry.last = now
+	entry.expires = now.Add(window)
+	s.failures[key] = entry
+	return entry.count, nil
+}
+
+// AddFailedAccount adds the account to the key's failed accounts, dropping expired ones
+func (s *MemoryLoginAttemptStore) AddFailedAccount(_ context.Context, key, account string, window time.Duration) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	entry := s.entry(key, now)
+	if entry.accounts == nil {
+		entry.accounts = make(map[string]time.Time)
+	}
+	for a, expires := range entry.accounts {
+		if now.After(expires) {
+			delete(entry.accounts, a)
+		}
+	}
+	entry.accounts[account] = now.Add(window)
+	if entry.expires.Before(now.Add(window)) {
+		entry.expires = now.Add(window)
+	}
+	s.failures[key] = entry
+	return len(entry.accounts), nil
+}
+
+// FailedAccounts counts the key's failed accounts that haven't expired
+func (s *MemoryLoginAttemptStore) FailedAccounts(_ context.Context, key string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	count := 0
+	for _, expires := range s.entry(key, now).accounts {
+		if now.Before(expires) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Reset forgets the key's count, keeping its failed accounts
+func (s *MemoryLoginAttemptStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.failures[key]; ok {
+		entry.count, entry.last = 0, time.Time{}
+	}
+	return nil
+}
+
+// entry returns the key's live failures, a fresh entry when there are none
+func (s *MemoryLoginAttemptStore) entry(key string, now time.Time) *loginFailures {
+	entry, ok := s.failures[key]
+	if !ok || now.After(entry.expires) {
+		return &loginFailures{}
+	}
+	return entry
+}
+
+// LoginGuardPolicy configures how failed logins slow down and lock out clients
+type LoginGuardPolicy struct {
+	FreeAttempts       int           // Failures per email before backoff starts
+	BackoffBase        time.Duration // Wait after the first failure beyond the free ones, doubled per failure
+	BackoffMax         time.Duration
+	LockoutThreshold   int // Failures per email that lock the account; 0 disables lockout
+	IPLockoutThreshold int // Failures per client address that block it; 0 disables
+	LockoutDuration    time.Duration
+	Window             time.Duration // Failures are forgotten this long after the last one
+	StuffingThreshold  int           // Distinct emails failing from one address that flag credential stuffing; 0 disables
+}
+
+// LoginGuard protects the login endpoint against brute force and credential
+// stuffing. Failed logins are counted per email and per client address:
+// each email gets a few free attempts, then an exponentially growing wait
+// between attempts and, past the lockout threshold, a temporary lockout. An
+// address failing too often is blocked whatever emails it tries. The guard
+// fails open: an unavailable store must not lock every user out.
+type LoginGuard struct {
+	store             LoginAttemptStore
+	policy            LoginGuardPolicy
+	metrics           *metrics.Metrics
+	trustForwardedFor bool
+}
+
+// NewLoginGuard creates a login guard; m may be nil
+func NewLoginGuard(store LoginAttemptStore, policy LoginGuardPolicy, m *metrics.Metrics) *LoginGuard {
+	return &LoginGuard{store: store, policy: policy, metrics: m}
+}
+
+// TrustForwardedFor identifies clients by the first X-Forwarded-For address
+// (only safe behind a proxy that sets it)
+func (g *LoginGuard) TrustForwardedFor(trust bool) {
+	g.trustForwardedFor = trust
+}
+
+// Check returns ErrAccountLocked or ErrLoginThrottled, with how long the
+// client must wait, when a login for email may not be attempted yet
+func (g *LoginGuard) Check(r *http.Request, email string) (time.Duration, error) {
+	ctx := r.Context()
+	ipCount, ipLast, err := g.store.Failures(ctx, g.ipKey(r))
+	if err != nil {
+		slog.WarnContext(ctx, "login attempt store unavailable, allowing login", "error", err)
+		return 0, nil
+	}
+	if wait := g.lockedFor(ipCount, ipLast, g.policy.IPLockoutThreshold); wait > 0 {
+		g.record(metrics.LoginThrottled)
+		return wait, ErrLoginThrottled
+	}
+
+	count, last, err := g.store.Failures(ctx, emailKey(email))
+	if err != nil {
+		slog.WarnContext(ctx, "login attempt store unavailable, allowing login", "error", err)
+		return 0, nil
+	}
+	if wait := g.lockedFor(count, last, g.policy.LockoutThreshold); wait > 0 {
+		g.record(metrics.LoginLocked)
+		return wait, ErrAccountLocked
+	}
+	if wait := g.backoff(count, last); wait > 0 {
+		g.record(metrics.LoginThrottled)
+		return wait, ErrLoginThrottled
+	}
+	return 0, nil
+}
+
+// Failed records a login for email rejected for its credentials
+func (g *LoginGuard) Failed(r *http.Request, email string) {
+	g.record(metrics.LoginFailed)
+	ctx := r.Context()
+	ip := g.clientIP(r)
+
+	count, err := g.store.RecordFailure(ctx, emailKey(email), g.policy.Window)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to record failed login", "error", err)
+		return
+	}
+	if count == g.policy.LockoutThreshold {
+		slog.WarnContext(ctx, "account locked after failed logins", "email", email, "remote_ip", ip,
+			"failures", count, "duration", g.policy.LockoutDuration.String())
+	}
+
+	ipCount, err := g.store.RecordFailure(ctx, g.ipKey(r), g.policy.Window)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to record failed login", "error", err)
+		return
+	}
+	if ipCount == g.policy.IPLockoutThreshold {
+		slog.WarnContext(ctx, "client address blocked after failed logins", "remote_ip", ip,
+			"failures", ipCount, "duration", g.policy.LockoutDuration.String())
+	}
+
+	if g.policy.StuffingThreshold <= 0 {
+		return
+	}
+	accounts, err := g.store.AddFailedAccount(ctx, g.ipKey(r), emailKey(email), g.policy.Window)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to record failed login", "error", err)
+		return
+	}
+	if accounts == g.policy.StuffingThreshold {
+		slog.WarnContext(ctx, "credential stuffing suspected: failed logins for many accounts from one address",
+			"remote_ip", ip, "accounts", accounts, "window", g.policy.Window.String())
+		if g.metrics != nil {
+			g.metrics.RecordCredentialStuffing()
+		}
+	}
+}
+
+// Flagged reports whether the brute-force detector suspects a login: the
+// email has used up its free attempts, or the client's address failed for as
+// many accounts as flag credential stuffing. Store errors don't flag.
+func (g *LoginGuard) Flagged(r *http.Request, email string) bool {
+	ctx := r.Context()
+	count, _, err := g.store.Failures(ctx, emailKey(email))
+	if err == nil && count > 0 && count >= g.policy.FreeAttempts {
+		return true
+	}
+	if g.policy.StuffingThreshold <= 0 {
+		return false
+	}
+	accounts, err := g.store.FailedAccounts(ctx, g.ipKey(r))
+	return err == nil && accounts >= g.policy.StuffingThreshold
+}
+
+// Succeeded records a successful login, clearing the email's failures. The
+// address keeps its count so valid credentials can't launder a stuffing run.
+func (g *LoginGuard) Succeeded(r *http.Request, email string) {
+	g.record(metrics.LoginSucceeded)
+	if err := g.store.Reset(r.Context(), emailKey(email)); err != nil {
+		slog.WarnContext(r.Context(), "failed to reset failed logins", "error", err)
+	}
+}
+
+// lockedFor returns the rest of a lockout once count reached threshold
+func (g *LoginGuard) lockedFor(count int, last time.Time, threshold int) time.Duration {
+	if threshold <= 0 || count < threshold {
+		return 0
+	}
+	return g.policy.LockoutDuration - time.Since(last)
+}
+
+// backoff returns the rest of the wait after the last failure: BackoffBase
+// once the free attempts are spent, doubling per failure up to BackoffMax
+func (g *LoginGuard) backoff(count int, last time.Time) time.Duration {
+	if g.policy.BackoffBase <= 0 || count < g.policy.FreeAttempts || count == 0 {
+		return 0
+	}
+	delay := g.policy.BackoffBase
+	for i := g.policy.FreeAttempts; i < count && delay < g.policy.BackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, g.policy.BackoffMax) - time.Since(last)
+}
+
+// record counts a login attempt outcome
+func (g *LoginGuard) record(outcome string) {
+	if g.metrics != nil {
+		g.metrics.RecordLoginAttempt(outcome)
+	}
+}
+
+// ipKey returns the attempt store key of the client's address
+func (g *LoginGuard) ipKey(r *http.Request) string {
+	return "ip:" + g.clientIP(r)
+}
+
+// clientIP returns the address failed logins are counted by
+func (g *LoginGuard) clientIP(r *http.Request) string {
+	if g.trustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// emailKey returns the attempt store key of an email, hashed so addresses
+// aren't stored in the clear
+func emailKey(email string) string {
+	hash := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(email))))
+	return "email:" + hex.EncodeToString(hash[:])
+}
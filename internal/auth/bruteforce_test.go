@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/metrics"
+)
+
+func TestLoginGuard(t *testing.T) {
+	m := metrics.NewMetrics()
+	guard := NewLoginGuard(NewMemoryLoginAttemptStore(), LoginGuardPolicy{
+		FreeAttempts:       2,
+		BackoffBase:        time.Minute,
+		BackoffMax:         time.Hour,
+		LockoutThreshold:   4,
+		IPLockoutThreshold: 10,
+		LockoutDuration:    15 * time.Minute,
+		Window:             time.Hour,
+		StuffingThreshold:  5,
+	}, m)
+	request := func(ip string) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil)
+		r.RemoteAddr = ip + ":40000"
+		return r
+	}
+
+	// Free attempts, then a doubling wait, then a lockout
+	r := request("10.0.0.1")
+	for failures, want := range []error{nil, nil, ErrLoginThrottled, ErrLoginThrottled, ErrAccountLocked} {
+		wait, err := guard.Check(r, "victim@hub.com")
+		if !errors.Is(err, want) {
+			t.Fatalf("after %d failures: Check() = %v, want %v", failures, err, want)
+		}
+		if failures == 3 && (wait <= time.Minute || wait > 2*time.Minute) {
+			t.Errorf("after 3 failures: wait %v, want about 2m", wait)
+		}
+		guard.Failed(r, "victim@hub.com")
+	}
+
+	// Other emails from another address are unaffected, and success resets
+	other := request("10.0.0.2")
+	guard.Failed(other, "user@hub.com")
+	guard.Succeeded(other, "user@hub.com")
+	if _, err := guard.Check(other, "USER@hub.com"); err != nil {
+		t.Errorf("Check() after success = %v, want nil", err)
+	}
+
+	// One address failing for many accounts is flagged, then blocked
+	attacker := request("10.0.0.3")
+	for i := 0; i < 10; i++ {
+		guard.Failed(attacker, fmt.Sprintf("user%d@hub.com", i))
+	}
+	if _, err := guard.Check(attacker, "fresh@hub.com"); !errors.Is(err, ErrLoginThrottled) {
+		t.Errorf("Check() from blocked address = %v, want ErrLoginThrottled", err)
+	}
+	snapshot := m.GetSnapshot()
+	if snapshot.CredentialStuffing != 1 || snapshot.LoginLocked != 1 || snapshot.LoginFailed != 16 {
+		t.Errorf("unexpected login metrics: stuffing %d locked %d failed %d",
+			snapshot.CredentialStuffing, snapshot.LoginLocked, snapshot.LoginFailed)
+	}
+}
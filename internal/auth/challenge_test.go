@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoginHandler_ChallengesFlaggedLogins(t *testing.T) {
+	siteverify := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("secret") != "secret" || r.FormValue("remoteip") != "10.0.0.1" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if r.FormValue("response") == "solved" {
+			w.Write([]byte(`{"success": true}`))
+			return
+		}
+		w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+	}))
+	defer siteverify.Close()
+
+	verifier, err := NewSiteVerifier(ChallengeTurnstile, "secret", "site-key", siteverify.URL, 0, time.Second)
+	if err != nil {
+		t.Fatalf("NewSiteVerifier() error = %v", err)
+	}
+	guard := NewLoginGuard(NewMemoryLoginAttemptStore(), LoginGuardPolicy{FreeAttempts: 1, Window: time.Hour}, nil)
+	handler := NewLoginHandler(nil, nil)
+	handler.SetLoginGuard(guard)
+	handler.SetChallengeVerifier(verifier)
+
+	login := func(token string) *httptest.ResponseRecorder {
+		body := `{"email": "user@hub.com", "password": "secret", "challengeToken": "` + token + `"}`
+		r := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(body))
+		r.RemoteAddr = "10.0.0.1:40000"
+		w := httptest.NewRecorder()
+		handler.Handle(w, r)
+		return w
+	}
+
+	guard.Failed(httptest.NewRequest(http.MethodPost, "/", nil), "user@hub.com")
+	for token, wantCode := range map[string]string{"": "CHALLENGE_REQUIRED", "forged": "CHALLENGE_FAILED"} {
+		w := login(token)
+		var envelope struct {
+			Error struct {
+				Code    string                   `json:"code"`
+				Details []map[string]interface{} `json:"details"`
+			} `json:"error"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &envelope)
+		if w.Code != http.StatusForbidden || envelope.Error.Code != wantCode {
+			t.Errorf("token %q: got %d %s, want 403 %s", token, w.Code, envelope.Error.Code, wantCode)
+		}
+		if len(envelope.Error.Details) != 1 || envelope.Error.Details[0]["siteKey"] != "site-key" {
+			t.Errorf("token %q: details %v lack the challenge", token, envelope.Error.Details)
+		}
+	}
+
+	if err := verifier.Verify(t.Context(), "solved", "10.0.0.1"); err != nil {
+		t.Errorf("Verify(solved) = %v, want nil", err)
+	}
+}
@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// InternalTokenMetadata is the gRPC metadata key carrying the gateway-issued
+// token on backend calls
+const InternalTokenMetadata = "x-gateway-token"
+
+// internalTokenClaims are the claims of a gateway-issued internal token
+type internalTokenClaims struct {
+	Issuer       string   `json:"iss"`
+	Subject      string   `json:"sub,omitempty"`
+	Audience     string   `json:"aud"`
+	IssuedAt     int64    `json:"iat"`
+	ExpiresAt    int64    `json:"exp"`
+	ID           string   `json:"jti,omitempty"`
+	Email        string   `json:"email,omitempty"`
+	Scope        string   `json:"scope,omitempty"`
+	Roles        []string `json:"roles,omitempty"`
+	AuthProvider string   `json:"auth_provider,omitempty"`
+	TenantID     string   `json:"tenant_id,omitempty"`
+	Route        string   `json:"route"`
+	Method       string   `json:"method"`
+	Path         string   `json:"path"`
+}
+
+// BackendCall describes the backend call an internal token is issued for
+type BackendCall struct {
+	Service   string // Backend service, the token's audience
+	Route     string
+	Method    string
+	Path      string
+	RequestID string
+}
+
+// InternalTokenIssuer signs short-lived RS256 tokens for backend calls with
+// the gateway's own key. A token carries the validated identity of the caller
+// (if any) and the route it came through; backends verify it with the public
+// key published by HandleJWKS instead of trusting the user's token or plain
+// x-user-id metadata.
+type InternalTokenIssuer struct {
+	key    *rsa.PrivateKey
+	keyID  string
+	issuer string
+	ttl    time.Duration
+}
+
+// NewInternalTokenIssuer creates an issuer signing with the PEM private key in
+// keyFile. Without a key file a key is generated at startup, which only suits
+// a single instance: backends must refetch the JWKS after every restart.
+func NewInternalTokenIssuer(keyFile, issuer string, ttl time.Duration) (*InternalTokenIssuer, error) {
+	if ttl == 0 {
+		ttl = time.Minute
+	}
+
+	var key *rsa.PrivateKey
+	var err error
+	if keyFile == "" {
+		slog.Warn("no internal token key file configured, generated a signing key for this instance")
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	} else {
+		key, err = loadRSAPrivateKey(keyFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &InternalTokenIssuer{
+		key:    key,
+		keyID:  keyThumbprint(&key.PublicKey),
+		issuer: issuer,
+		ttl:    ttl,
+	}, nil
+}
+
+// Issue signs a token for a backend call; principal is nil for anonymous requests
+func (t *InternalTokenIssuer) Issue(principal *Principal, call BackendCall) (string, error) {
+	now := time.Now()
+	claims := internalTokenClaims{
+		Issuer:    t.issuer,
+		Audience:  call.Service,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(t.ttl).Unix(),
+		ID:        call.RequestID,
+		Route:     call.Route,
+		Method:    call.Method,
+		Path:      call.Path,
+	}
+	if principal != nil {
+		claims.Subject = principal.UserID
+		claims.Email = principal.Email
+		claims.Scope = strings.Join(principal.Scopes, " ")
+		claims.Roles = principal.Roles
+		claims.AuthProvider = principal.Provider
+		claims.TenantID = principal.TenantID
+	}
+
+	return signRS256(claims, t.key, t.keyID)
+}
+
+// HandleJWKS publishes the public signing key as a JWKS document
+func (t *InternalTokenIssuer) HandleJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	json.NewEncoder(w).Encode(jwkSet{Keys: []jwk{publicJWK(&t.key.PublicKey, t.keyID)}})
+}
+
+// loadRSAPrivateKey reads a PKCS#1 or PKCS#8 PEM-encoded RSA private key
+func loadRSAPrivateKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read internal token key: %w", err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("internal token key %s is not PEM encoded", path)
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse internal token key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("internal token key %s is not an RSA key", path)
+	}
+	return key, nil
+}
+
+// publicJWK encodes an RSA public key as a JWK
+func publicJWK(key *rsa.PublicKey, kid string) jwk {
+	return jwk{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		Alg: "RS256",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+// keyThumbprint returns the RFC 7638 thumbprint of an RSA public key, used as its key ID
+func keyThumbprint(key *rsa.PublicKey) string {
+	public := publicJWK(key, "")
+	sum := sha256.Sum256([]byte(`{"e":"` + public.E + `","kty":"RSA","n":"` + public.N + `"}`))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestInternalTokenIssuer(t *testing.T) {
+	issuer, err := NewInternalTokenIssuer("", "hub-api-gateway", time.Minute)
+	if err != nil {
+		t.Fatalf("failed to create issuer: %v", err)
+	}
+	jwks := httptest.NewServer(http.HandlerFunc(issuer.HandleJWKS))
+	defer jwks.Close()
+
+	token, err := issuer.Issue(&Principal{UserID: "user-1", Email: "user@example.com", Scopes: []string{"orders:write"}, Provider: ProviderJWT},
+		BackendCall{Service: "order-service", Route: "submit-order", Method: "POST", Path: "/api/v1/orders", RequestID: "req-1"})
+	if err != nil {
+		t.Fatalf("failed to issue token: %v", err)
+	}
+
+	// Backends verify the token with the published keys
+	parsed, err := parseJWT(token)
+	if err != nil {
+		t.Fatalf("failed to parse token: %v", err)
+	}
+	key, err := NewJWKSKeys(jwks.URL, time.Minute).Key(context.Background(), parsed.header.Kid)
+	if err != nil {
+		t.Fatalf("published keys don't include %q: %v", parsed.header.Kid, err)
+	}
+	if err := parsed.verifyRS256(key); err != nil {
+		t.Fatalf("signature doesn't verify: %v", err)
+	}
+
+	var claims internalTokenClaims
+	if err := json.Unmarshal(parsed.claims.Extra, &claims); err != nil {
+		t.Fatalf("failed to decode claims: %v", err)
+	}
+	if claims.Issuer != "hub-api-gateway" || claims.Audience != "order-service" || claims.Subject != "user-1" ||
+		claims.Scope != "orders:write" || claims.Route != "submit-order" || claims.ID != "req-1" {
+		t.Errorf("unexpected claims: %+v", claims)
+	}
+	if ttl := time.Unix(claims.ExpiresAt, 0).Sub(time.Unix(claims.IssuedAt, 0)); ttl != time.Minute {
+		t.Errorf("token lifetime = %v, want 1m", ttl)
+	}
+
+	// Anonymous requests still prove they came through the gateway
+	token, err = issuer.Issue(nil, BackendCall{Service: "market-data-service", Route: "get-market-data", Method: "GET", Path: "/api/v1/market-data/AAPL"})
+	if err != nil {
+		t.Fatalf("failed to issue anonymous token: %v", err)
+	}
+	if parsed, err := parseJWT(token); err != nil || parsed.claims.Subject != "" || parsed.verifyRS256(key) != nil {
+		t.Errorf("unexpected anonymous token %v (err: %v)", parsed, err)
+	}
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"hub-api-gateway/internal/httperr"
+)
+
+// maxIntrospectionRequestSize bounds the introspection request body
+const maxIntrospectionRequestSize = 16 << 10 // 16KB
+
+// IntrospectionRequest is the body of POST /internal/auth/introspect
+type IntrospectionRequest struct {
+	Token    string         `json:"token"`
+	Type     CredentialType `json:"type,omitempty"`     // bearer (default), api_key or ticket
+	Provider string         `json:"provider,omitempty"` // Provider name; defaults by credential type
+}
+
+// IntrospectionResponse describes the principal a token resolves to. Inactive
+// tokens only carry active=false, following RFC 7662.
+type IntrospectionResponse struct {
+	Active   bool     `json:"active"`
+	Subject  string   `json:"sub,omitempty"`
+	Email    string   `json:"email,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
+	Scope    string   `json:"scope,omitempty"`
+	Provider string   `json:"provider,omitempty"`
+}
+
+// IntrospectionHandler lets internal services validate tokens through the
+// gateway's providers and token cache instead of calling the User Service
+type IntrospectionHandler struct {
+	authenticate func(ctx context.Context, providerName string, credential Credential) (*Principal, error)
+	caller       func(r *http.Request) (*Principal, bool)
+}
+
+// NewIntrospectionHandler creates an introspection handler; authenticate
+// validates the submitted credential and caller resolves the internal service
+// authenticated by the auth middleware
+func NewIntrospectionHandler(
+	authenticate func(ctx context.Context, providerName string, credential Credential) (*Principal, error),
+	caller func(r *http.Request) (*Principal, bool),
+) *IntrospectionHandler {
+	return &IntrospectionHandler{
+		authenticate: authenticate,
+		caller:       caller,
+	}
+}
+
+// Handle validates the token in the request body and returns its principal
+func (h *IntrospectionHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+
+	callerName := "unknown"
+	if caller, ok := h.caller(r); ok {
+		callerName = caller.UserID
+	}
+
+	var req IntrospectionRequest
+	decoder := json.NewDecoder(io.LimitReader(r.Body, maxIntrospectionRequestSize))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil || strings.TrimSpace(req.Token) == "" {
+		httperr.Send(w, r, http.StatusBadRequest, "INVALID_REQUEST", "Request body must be JSON with a token")
+		return
+	}
+
+	if req.Type == "" {
+		req.Type = CredentialBearer
+	}
+	if req.Type != CredentialBearer && req.Type != CredentialAPIKey && req.Type != CredentialTicket {
+		httperr.Send(w, r, http.StatusBadRequest, "INVALID_REQUEST", "type must be bearer, api_key or ticket")
+		return
+	}
+
+	principal, err := h.authenticate(r.Context(), req.Provider, Credential{Type: req.Type, Value: strings.TrimSpace(req.Token)})
+	if errors.Is(err, ErrProviderNotFound) {
+		httperr.Send(w, r, http.StatusBadRequest, "UNKNOWN_PROVIDER", err.Error())
+		return
+	}
+	if err != nil {
+		slog.InfoContext(r.Context(), "introspection: inactive credential", "caller", callerName, "type", req.Type, "error", err)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(IntrospectionResponse{Active: false})
+		return
+	}
+
+	slog.InfoContext(r.Context(), "introspection: active credential", "caller", callerName, "type", req.Type, "user_id", principal.UserID)
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(IntrospectionResponse{
+		Active:   true,
+		Subject:  principal.UserID,
+		Email:    principal.Email,
+		Roles:    principal.Roles,
+		Scopes:   principal.Scopes,
+		Scope:    strings.Join(principal.Scopes, " "),
+		Provider: principal.Provider,
+	})
+}
@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestIntrospectionHandler(t *testing.T) {
+	authenticate := func(_ context.Context, providerName string, credential Credential) (*Principal, error) {
+		if providerName == "missing" {
+			return nil, ErrProviderNotFound
+		}
+		if credential.Type != CredentialBearer || credential.Value != "good-token" {
+			return nil, ErrInvalidCredential
+		}
+		return &Principal{UserID: "user-1", Scopes: []string{"orders:read", "orders:write"}, Provider: ProviderJWT}, nil
+	}
+	caller := func(*http.Request) (*Principal, bool) {
+		return &Principal{UserID: "order-service"}, true
+	}
+	handler := NewIntrospectionHandler(authenticate, caller)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantActive bool
+	}{
+		{name: "active token", body: `{"token":"good-token"}`, wantStatus: http.StatusOK, wantActive: true},
+		{name: "invalid token", body: `{"token":"bad-token"}`, wantStatus: http.StatusOK},
+		{name: "missing token", body: `{}`, wantStatus: http.StatusBadRequest},
+		{name: "unsupported type", body: `{"token":"good-token","type":"client_cert"}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown provider", body: `{"token":"good-token","provider":"missing"}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			handler.Handle(recorder, httptest.NewRequest("POST", "/internal/auth/introspect", strings.NewReader(tt.body)))
+
+			if recorder.Code != tt.wantStatus {
+				t.Fatalf("expected status %d but got %d: %s", tt.wantStatus, recorder.Code, recorder.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var response IntrospectionResponse
+			if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+				t.Fatalf("failed to decode response: %v", err)
+			}
+			if response.Active != tt.wantActive {
+				t.Errorf("expected active=%v but got %+v", tt.wantActive, response)
+			}
+			if tt.wantActive && (response.Subject != "user-1" || response.Scope != "orders:read orders:write") {
+				t.Errorf("unexpected principal: %+v", response)
+			}
+		})
+	}
+}
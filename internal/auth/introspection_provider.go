@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// IntrospectionProvider validates opaque bearer tokens issued by another
+// authorization server through its RFC 7662 introspection endpoint
+type IntrospectionProvider struct {
+	endpoint     string
+	clientID     string
+	clientSecret string
+	audience     string
+	client       *http.Client
+}
+
+// NewIntrospectionProvider creates a provider calling the introspection
+// endpoint with the gateway's client credentials. Tokens must be issued for
+// audience unless it is empty.
+func NewIntrospectionProvider(endpoint, clientID, clientSecret, audience string, timeout time.Duration) *IntrospectionProvider {
+	return &IntrospectionProvider{
+		endpoint:     endpoint,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		audience:     audience,
+		client:       &http.Client{Timeout: timeout},
+	}
+}
+
+// Name returns the provider name
+func (p *IntrospectionProvider) Name() string {
+	return ProviderIntrospection
+}
+
+// introspectionResult is an RFC 7662 introspection response; the claims it
+// shares with JWTs use the same names
+type introspectionResult struct {
+	Active   bool   `json:"active"`
+	Username string `json:"username,omitempty"`
+	ClientID string `json:"client_id,omitempty"`
+	JWTClaims
+}
+
+// Validate asks the authorization server whether the token is active
+func (p *IntrospectionProvider) Validate(ctx context.Context, credential Credential) (*Principal, error) {
+	if credential.Type != CredentialBearer {
+		return nil, fmt.Errorf("%w: introspection provider only accepts bearer tokens", ErrInvalidCredential)
+	}
+
+	form := url.Values{"token": {credential.Value}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.clientID != "" {
+		req.SetBasicAuth(url.QueryEscape(p.clientID), url.QueryEscape(p.clientSecret))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to introspect token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("introspection endpoint answered %d", resp.StatusCode)
+	}
+
+	var result introspectionResult
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 64<<10)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid introspection response: %w", err)
+	}
+	if !result.Active {
+		return nil, fmt.Errorf("%w: token is not active", ErrInvalidCredential)
+	}
+	if err := result.validateTimes(time.Now(), jwtClockSkew); err != nil {
+		return nil, err
+	}
+	if p.audience != "" && !result.Audience.Contains(p.audience) {
+		return nil, fmt.Errorf("%w: token not issued for audience %s", ErrInvalidCredential, p.audience)
+	}
+
+	// Client credentials tokens have no user; the client is the principal
+	if result.Subject == "" && result.UserID == "" {
+		result.Subject = result.Username
+		if result.Subject == "" {
+			result.Subject = result.ClientID
+		}
+	}
+	return result.toPrincipal(p.Name())
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIntrospectionProvider_Validate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID, secret, _ := r.BasicAuth()
+		if clientID != "gateway" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		switch r.PostFormValue("token") {
+		case "user-token":
+			w.Write([]byte(`{"active": true, "sub": "partner-user", "scope": "quotes:read", "aud": "hub-gateway"}`))
+		case "client-token":
+			w.Write([]byte(`{"active": true, "client_id": "partner-app", "aud": ["hub-gateway", "other"]}`))
+		case "other-audience":
+			w.Write([]byte(`{"active": true, "sub": "partner-user", "aud": "other"}`))
+		default:
+			w.Write([]byte(`{"active": false}`))
+		}
+	}))
+	defer server.Close()
+
+	provider := NewIntrospectionProvider(server.URL, "gateway", "s3cret", "hub-gateway", time.Second)
+	validate := func(token string) (*Principal, error) {
+		return provider.Validate(context.Background(), Credential{Type: CredentialBearer, Value: token})
+	}
+
+	principal, err := validate("user-token")
+	if err != nil || principal.UserID != "partner-user" || len(principal.Scopes) != 1 || principal.Provider != ProviderIntrospection {
+		t.Errorf("user token resolved to %+v, error %v", principal, err)
+	}
+	if principal, err := validate("client-token"); err != nil || principal.UserID != "partner-app" {
+		t.Errorf("client credentials token resolved to %+v, error %v", principal, err)
+	}
+	for _, token := range []string{"revoked", "other-audience"} {
+		if _, err := validate(token); !errors.Is(err, ErrInvalidCredential) {
+			t.Errorf("%s: expected ErrInvalidCredential, got %v", token, err)
+		}
+	}
+
+	// Endpoint failures are not credential rejections
+	provider = NewIntrospectionProvider(server.URL, "gateway", "wrong", "", time.Second)
+	if _, err := validate("user-token"); err == nil || errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("expected an endpoint error, got %v", err)
+	}
+}
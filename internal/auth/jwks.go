@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// jwk is a single JSON Web Key as served by a JWKS endpoint
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// jwkSet is the document returned by a JWKS endpoint
+type jwkSet struct {
+	Keys []jwk `json:"keys"`
+}
+
+// JWKSKeys caches the RSA signing keys published at a JWKS URL. Keys are
+// refreshed every cacheTTL by Run, and on demand when a token names an
+// unknown key (rotation) or the cache went stale without Run.
+type JWKSKeys struct {
+	url      string
+	cacheTTL time.Duration
+	client   *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// NewJWKSKeys creates a key cache for a JWKS URL (cacheTTL defaults to 10 minutes)
+func NewJWKSKeys(url string, cacheTTL time.Duration) *JWKSKeys {
+	if cacheTTL == 0 {
+		cacheTTL = 10 * time.Minute
+	}
+
+	return &JWKSKeys{
+		url:      url,
+		cacheTTL: cacheTTL,
+		client:   &http.Client{Timeout: 5 * time.Second},
+		keys:     make(map[string]*rsa.PublicKey),
+	}
+}
+
+// Key returns the signing key for kid, refreshing the JWKS when the cache
+// is stale or the key is unknown (key rotation)
+func (k *JWKSKeys) Key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	k.mu.RLock()
+	key, ok := k.keys[kid]
+	fresh := time.Since(k.fetchedAt) < k.cacheTTL
+	k.mu.RUnlock()
+
+	if ok && fresh {
+		return key, nil
+	}
+
+	if err := k.refresh(ctx); err != nil {
+		if ok {
+			slog.WarnContext(ctx, "JWKS refresh failed, using cached key", "kid", kid, "error", err)
+			return key, nil
+		}
+		return nil, err
+	}
+
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	if key, ok := k.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidCredential, kid)
+}
+
+// Run refreshes the keys every cacheTTL until ctx is done, so requests don't
+// wait on the JWKS endpoint. Failed refreshes keep the previous keys.
+func (k *JWKSKeys) Run(ctx context.Context) {
+	// Refresh a little before the keys go stale
+	ticker := time.NewTicker(k.cacheTTL * 9 / 10)
+	defer ticker.Stop()
+
+	if err := k.refresh(ctx); err != nil {
+		slog.WarnContext(ctx, "JWKS refresh failed", "jwks_url", k.url, "error", err)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := k.refresh(ctx); err != nil {
+				slog.WarnContext(ctx, "JWKS refresh failed", "jwks_url", k.url, "error", err)
+			}
+		}
+	}
+}
+
+// refresh downloads the JWKS document and replaces the cached key set
+func (k *JWKSKeys) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to build JWKS request: %w", err)
+	}
+
+	resp, err := k.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	var set jwkSet
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.Kty != "RSA" {
+			continue
+		}
+		pub, err := key.rsaPublicKey()
+		if err != nil {
+			slog.WarnContext(ctx, "skipping invalid JWKS key", "kid", key.Kid, "error", err)
+			continue
+		}
+		keys[key.Kid] = pub
+	}
+
+	k.mu.Lock()
+	k.keys = keys
+	k.fetchedAt = time.Now()
+	k.mu.Unlock()
+
+	slog.DebugContext(ctx, "loaded JWKS signing keys", "keys", len(keys), "jwks_url", k.url)
+	return nil
+}
+
+// rsaPublicKey decodes the modulus and exponent of an RSA JWK
+func (k jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	nBytes, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, fmt.Errorf("invalid modulus: %w", err)
+	}
+
+	eBytes, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exponent: %w", err)
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(nBytes),
+		E: int(new(big.Int).SetBytes(eBytes).Int64()),
+	}, nil
+}
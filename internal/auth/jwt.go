@@ -0,0 +1,323 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// jwtHeader is the decoded JOSE header of a JWT
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid,omitempty"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// JWTClaims holds the registered and gateway-relevant claims of a JWT
+type JWTClaims struct {
+	Subject   string          `json:"sub"`
+	UserID    string          `json:"userId,omitempty"`
+	Email     string          `json:"email,omitempty"`
+	Issuer    string          `json:"iss,omitempty"`
+	Audience  audienceClaim   `json:"aud,omitempty"`
+	ExpiresAt int64           `json:"exp,omitempty"`
+	NotBefore int64           `json:"nbf,omitempty"`
+	IssuedAt  int64           `json:"iat,omitempty"`
+	Scope     string          `json:"scope,omitempty"`
+	Roles     []string        `json:"roles,omitempty"`
+	Nonce     string          `json:"nonce,omitempty"` // OIDC ID tokens: echoes the nonce of the authorization request
+	TenantID  string          `json:"tenant_id,omitempty"`
+	Extra     json.RawMessage `json:"-"`
+}
+
+// audienceClaim accepts both the string and array forms of the "aud" claim
+type audienceClaim []string
+
+// UnmarshalJSON decodes a string or array audience
+func (a *audienceClaim) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audienceClaim{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+// Contains returns whether the audience includes the given value
+func (a audienceClaim) Contains(value string) bool {
+	for _, aud := range a {
+		if aud == value {
+			return true
+		}
+	}
+	return false
+}
+
+// parsedJWT is a JWT split into its decoded parts
+type parsedJWT struct {
+	header       jwtHeader
+	claims       JWTClaims
+	signingInput string
+	signature    []byte
+}
+
+// parseJWT decodes a compact JWT without verifying its signature
+func parseJWT(token string) (*parsedJWT, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed JWT", ErrInvalidCredential)
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid JWT header encoding", ErrInvalidCredential)
+	}
+
+	claimsBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid JWT claims encoding", ErrInvalidCredential)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid JWT signature encoding", ErrInvalidCredential)
+	}
+
+	parsed := &parsedJWT{
+		signingInput: parts[0] + "." + parts[1],
+		signature:    signature,
+	}
+
+	if err := json.Unmarshal(headerBytes, &parsed.header); err != nil {
+		return nil, fmt.Errorf("%w: invalid JWT header", ErrInvalidCredential)
+	}
+
+	if err := json.Unmarshal(claimsBytes, &parsed.claims); err != nil {
+		return nil, fmt.Errorf("%w: invalid JWT claims", ErrInvalidCredential)
+	}
+	parsed.claims.Extra = claimsBytes
+
+	return parsed, nil
+}
+
+// signHS256 encodes claims as a compact HS256 JWT
+func signHS256(claims interface{}, secret []byte) (string, error) {
+	signingInput, err := jwtSigningInput(jwtHeader{Alg: "HS256", Typ: "JWT"}, claims)
+	if err != nil {
+		return "", err
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(signingInput))
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// signRS256 encodes claims as a compact RS256 JWT signed with key
+func signRS256(claims interface{}, key *rsa.PrivateKey, kid string) (string, error) {
+	signingInput, err := jwtSigningInput(jwtHeader{Alg: "RS256", Kid: kid, Typ: "JWT"}, claims)
+	if err != nil {
+		return "", err
+	}
+
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(nil, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign JWT: %w", err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// jwtSigningInput encodes the header and claims of a JWT
+func jwtSigningInput(header jwtHeader, claims interface{}) (string, error) {
+	headerBytes, err := json.Marshal(header)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JWT header: %w", err)
+	}
+
+	claimsBytes, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode JWT claims: %w", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(headerBytes) + "." +
+		base64.RawURLEncoding.EncodeToString(claimsBytes), nil
+}
+
+// verifyHS256 verifies an HMAC-SHA256 signature
+func (p *parsedJWT) verifyHS256(secret []byte) error {
+	if p.header.Alg != "HS256" {
+		return fmt.Errorf("%w: unexpected signing algorithm %s", ErrInvalidCredential, p.header.Alg)
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(p.signingInput))
+	if !hmac.Equal(mac.Sum(nil), p.signature) {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidCredential)
+	}
+	return nil
+}
+
+// verifyRS256 verifies an RSA PKCS#1 v1.5 SHA-256 signature
+func (p *parsedJWT) verifyRS256(key *rsa.PublicKey) error {
+	if p.header.Alg != "RS256" {
+		return fmt.Errorf("%w: unexpected signing algorithm %s", ErrInvalidCredential, p.header.Alg)
+	}
+
+	digest := sha256.Sum256([]byte(p.signingInput))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], p.signature); err != nil {
+		return fmt.Errorf("%w: signature mismatch", ErrInvalidCredential)
+	}
+	return nil
+}
+
+// validateTimes checks the exp and nbf claims with a small clock skew allowance
+func (c *JWTClaims) validateTimes(now time.Time, skew time.Duration) error {
+	if c.ExpiresAt != 0 && now.After(time.Unix(c.ExpiresAt, 0).Add(skew)) {
+		return fmt.Errorf("%w: token expired", ErrInvalidCredential)
+	}
+	if c.NotBefore != 0 && now.Add(skew).Before(time.Unix(c.NotBefore, 0)) {
+		return fmt.Errorf("%w: token not yet valid", ErrInvalidCredential)
+	}
+	return nil
+}
+
+// validateAccessTimes is validateTimes for access tokens, which must expire
+func (c *JWTClaims) validateAccessTimes(now time.Time, skew time.Duration) error {
+	if c.ExpiresAt == 0 {
+		return fmt.Errorf("%w: token has no expiry", ErrInvalidCredential)
+	}
+	return c.validateTimes(now, skew)
+}
+
+// toPrincipal converts claims to a principal
+func (c *JWTClaims) toPrincipal(provider string) (*Principal, error) {
+	userID := c.UserID
+	if userID == "" {
+		userID = c.Subject
+	}
+	if userID == "" {
+		return nil, fmt.Errorf("%w: token has no subject", ErrInvalidCredential)
+	}
+
+	var scopes []string
+	if c.Scope != "" {
+		scopes = strings.Fields(c.Scope)
+	}
+
+	return &Principal{
+		UserID:   userID,
+		Email:    c.Email,
+		Roles:    c.Roles,
+		Scopes:   scopes,
+		Provider: provider,
+		TenantID: c.TenantID,
+	}, nil
+}
+
+// unverifiedGrants reads the roles, scopes and tenant of a token without
+// checking its signature. Only use it on tokens another service has just verified.
+func unverifiedGrants(token string) (roles, scopes []string, tenantID string) {
+	parsed, err := parseJWT(token)
+	if err != nil {
+		return nil, nil, ""
+	}
+	return parsed.claims.Roles, strings.Fields(parsed.claims.Scope), parsed.claims.TenantID
+}
+
+// jwtClockSkew is the tolerated clock difference when checking exp/nbf
+const jwtClockSkew = 30 * time.Second
+
+// JWTProvider validates tokens locally: HS256 tokens with the shared JWT
+// secret and, when a JWKS URL is configured, RS256 tokens with its keys
+type JWTProvider struct {
+	hmacSecret
+	issuer string
+	jwks   *JWKSKeys
+}
+
+// NewJWTProvider creates a local JWT provider; issuer is optional
+func NewJWTProvider(secret, issuer string) *JWTProvider {
+	return &JWTProvider{
+		hmacSecret: hmacSecret{current: []byte(secret)},
+		issuer:     issuer,
+	}
+}
+
+// EnableJWKS accepts RS256 tokens signed with the keys published at jwksURL
+func (p *JWTProvider) EnableJWKS(jwksURL string, cacheTTL time.Duration) {
+	p.jwks = NewJWKSKeys(jwksURL, cacheTTL)
+}
+
+// Name returns the provider name
+func (p *JWTProvider) Name() string {
+	return ProviderJWT
+}
+
+// ValidatesLocally reports that tokens are verified without a backend call
+func (p *JWTProvider) ValidatesLocally() bool {
+	return true
+}
+
+// Run refreshes the JWKS signing keys in the background until ctx is done
+func (p *JWTProvider) Run(ctx context.Context) {
+	if p.jwks != nil {
+		p.jwks.Run(ctx)
+	}
+}
+
+// Validate verifies the token signature and claims locally
+func (p *JWTProvider) Validate(ctx context.Context, credential Credential) (*Principal, error) {
+	if credential.Type != CredentialBearer {
+		return nil, fmt.Errorf("%w: jwt provider only accepts bearer tokens", ErrInvalidCredential)
+	}
+
+	parsed, err := parseJWT(credential.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	// The algorithm picks the key; RS256 is only accepted with a JWKS so the
+	// shared secret can never be used as an RSA key and vice versa
+	switch {
+	case parsed.header.Alg == "RS256" && p.jwks != nil:
+		key, err := p.jwks.Key(ctx, parsed.header.Kid)
+		if err != nil {
+			return nil, err
+		}
+		if err := parsed.verifyRS256(key); err != nil {
+			return nil, err
+		}
+	default:
+		if err := p.verifyHS256(parsed); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := parsed.claims.validateAccessTimes(time.Now(), jwtClockSkew); err != nil {
+		return nil, err
+	}
+
+	if p.issuer != "" && parsed.claims.Issuer != p.issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %s", ErrInvalidCredential, parsed.claims.Issuer)
+	}
+
+	// A reconnect ticket is never an access token, whatever secret signed it
+	if parsed.claims.Audience.Contains(ticketAudience) {
+		return nil, fmt.Errorf("%w: reconnect tickets are not access tokens", ErrInvalidCredential)
+	}
+
+	return parsed.claims.toPrincipal(p.Name())
+}
@@ -0,0 +1,298 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func signTestToken(t *testing.T, secret string, claims map[string]interface{}) string {
+	t.Helper()
+
+	header, _ := json.Marshal(map[string]string{"alg": "HS256", "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("failed to marshal claims: %v", err)
+	}
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signingInput))
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestJWTProvider_Validate(t *testing.T) {
+	const secret = "test-secret-with-at-least-32-bytes!!"
+	provider := NewJWTProvider(secret, "hub-user-service")
+
+	tests := []struct {
+		name        string
+		token       string
+		shouldError bool
+		userID      string
+	}{
+		{
+			name: "valid token",
+			token: signTestToken(t, secret, map[string]interface{}{
+				"sub": "user-1", "email": "user@example.com", "iss": "hub-user-service",
+				"exp": time.Now().Add(time.Minute).Unix(), "scope": "orders:read orders:write",
+			}),
+			userID: "user-1",
+		},
+		{
+			name: "expired token",
+			token: signTestToken(t, secret, map[string]interface{}{
+				"sub": "user-1", "iss": "hub-user-service", "exp": time.Now().Add(-time.Hour).Unix(),
+			}),
+			shouldError: true,
+		},
+		{
+			name: "token without expiry",
+			token: signTestToken(t, secret, map[string]interface{}{
+				"sub": "user-1", "iss": "hub-user-service",
+			}),
+			shouldError: true,
+		},
+		{
+			name: "wrong secret",
+			token: signTestToken(t, "another-secret", map[string]interface{}{
+				"sub": "user-1", "iss": "hub-user-service",
+			}),
+			shouldError: true,
+		},
+		{
+			name: "wrong issuer",
+			token: signTestToken(t, secret, map[string]interface{}{
+				"sub": "user-1", "iss": "someone-else",
+			}),
+			shouldError: true,
+		},
+		{
+			name: "reconnect ticket",
+			token: signTestToken(t, secret, map[string]interface{}{
+				"sub": "user-1", "iss": "hub-user-service", "aud": ticketAudience,
+				"exp": time.Now().Add(time.Minute).Unix(),
+			}),
+			shouldError: true,
+		},
+		{
+			name:        "malformed token",
+			token:       "not-a-jwt",
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, err := provider.Validate(context.Background(), Credential{Type: CredentialBearer, Value: tt.token})
+
+			if tt.shouldError {
+				if err == nil {
+					t.Fatalf("expected error but got none")
+				}
+				if !errors.Is(err, ErrInvalidCredential) {
+					t.Errorf("expected ErrInvalidCredential but got %v", err)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if principal.UserID != tt.userID {
+				t.Errorf("expected user %s but got %s", tt.userID, principal.UserID)
+			}
+			if principal.Provider != ProviderJWT {
+				t.Errorf("expected provider %s but got %s", ProviderJWT, principal.Provider)
+			}
+		})
+	}
+}
+
+func TestJWTProvider_RotateSecret(t *testing.T) {
+	const oldSecret, newSecret = "old-secret-with-at-least-32-bytes!!!", "new-secret-with-at-least-32-bytes!!!"
+	provider := NewJWTProvider(oldSecret, "")
+	claims := map[string]interface{}{"sub": "user-1", "exp": time.Now().Add(time.Minute).Unix()}
+	validate := func(secret string) error {
+		_, err := provider.Validate(context.Background(), Credential{Type: CredentialBearer, Value: signTestToken(t, secret, claims)})
+		return err
+	}
+
+	provider.RotateSecret(newSecret, time.Minute)
+	if err := validate(newSecret); err != nil {
+		t.Errorf("token signed with the new secret: %v", err)
+	}
+	if err := validate(oldSecret); err != nil {
+		t.Errorf("token signed with the old secret during the grace period: %v", err)
+	}
+
+	// Once the grace period is over only the new secret is accepted
+	provider.RotateSecret("newest-secret-with-at-least-32-bytes", 0)
+	if err := validate(newSecret); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("expected the rotated-out secret to be rejected but got %v", err)
+	}
+	if err := validate(oldSecret); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("expected the old secret to be rejected but got %v", err)
+	}
+}
+
+func TestJWTProvider_ValidateRS256WithJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	fetches := 0
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		json.NewEncoder(w).Encode(jwkSet{Keys: []jwk{{
+			Kty: "RSA", Kid: "key-1", Alg: "RS256",
+			N: base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer jwks.Close()
+
+	const secret = "test-secret-with-at-least-32-bytes!!"
+	provider := NewJWTProvider(secret, "")
+	provider.EnableJWKS(jwks.URL, time.Minute)
+	if !ValidatesLocally(provider) {
+		t.Errorf("jwt provider must validate locally")
+	}
+
+	signRS256 := func(kid string) string {
+		header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": kid})
+		payload, _ := json.Marshal(map[string]interface{}{"sub": "user-1", "exp": time.Now().Add(time.Minute).Unix()})
+		signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+		digest := sha256.Sum256([]byte(signingInput))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+		if err != nil {
+			t.Fatalf("failed to sign token: %v", err)
+		}
+		return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+	}
+
+	for i := 0; i < 2; i++ {
+		principal, err := provider.Validate(context.Background(), Credential{Type: CredentialBearer, Value: signRS256("key-1")})
+		if err != nil || principal.UserID != "user-1" {
+			t.Fatalf("expected RS256 token to validate, got %v (err: %v)", principal, err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("JWKS fetched %d times, want 1 while the keys are fresh", fetches)
+	}
+
+	if _, err := provider.Validate(context.Background(), Credential{Type: CredentialBearer, Value: signRS256("key-2")}); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("expected unknown key to be rejected, got %v", err)
+	}
+
+	// HS256 tokens are still verified with the shared secret
+	token := signTestToken(t, secret, map[string]interface{}{"sub": "user-2", "exp": time.Now().Add(time.Minute).Unix()})
+	if principal, err := provider.Validate(context.Background(), Credential{Type: CredentialBearer, Value: token}); err != nil || principal.UserID != "user-2" {
+		t.Errorf("expected HS256 token to validate, got %v (err: %v)", principal, err)
+	}
+
+	// Without a JWKS, RS256 tokens are refused
+	if _, err := NewJWTProvider(secret, "").Validate(context.Background(), Credential{Type: CredentialBearer, Value: signRS256("key-1")}); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("expected RS256 token to be rejected without a JWKS, got %v", err)
+	}
+}
+
+func TestProviderRegistry_Resolve(t *testing.T) {
+	registry := NewProviderRegistry()
+	registry.Register(NewJWTProvider("secret", ""))
+	registry.Register(NewAPIKeyProvider(map[string]string{"key-1": "batch-job"}))
+	registry.SetDefault(CredentialBearer, ProviderJWT)
+	registry.SetDefault(CredentialAPIKey, ProviderAPIKey)
+
+	provider, err := registry.Resolve("", CredentialAPIKey)
+	if err != nil || provider.Name() != ProviderAPIKey {
+		t.Fatalf("expected api-key provider, got %v (err: %v)", provider, err)
+	}
+
+	provider, err = registry.Resolve(ProviderAPIKey, CredentialBearer)
+	if err != nil || provider.Name() != ProviderAPIKey {
+		t.Fatalf("expected route provider to win, got %v (err: %v)", provider, err)
+	}
+
+	if _, err := registry.Resolve(ProviderOIDC, CredentialBearer); !errors.Is(err, ErrProviderNotFound) {
+		t.Errorf("expected ErrProviderNotFound but got %v", err)
+	}
+
+	principal, err := registry.providers[ProviderAPIKey].Validate(context.Background(), Credential{Type: CredentialAPIKey, Value: "key-1"})
+	if err != nil || principal.UserID != "batch-job" {
+		t.Errorf("expected batch-job principal, got %v (err: %v)", principal, err)
+	}
+}
+
+func TestTicketIssuer(t *testing.T) {
+	issuer := NewTicketIssuer("ticket-secret", time.Minute, nil)
+	principal := &Principal{UserID: "user-1", Email: "user@example.com", Roles: []string{"trader"}}
+
+	ticket, expiresAt, err := issuer.Issue(principal)
+	if err != nil {
+		t.Fatalf("failed to issue ticket: %v", err)
+	}
+	if time.Until(expiresAt) > time.Minute {
+		t.Errorf("ticket expiry exceeds TTL: %v", expiresAt)
+	}
+
+	got, err := issuer.Validate(context.Background(), Credential{Type: CredentialTicket, Value: ticket})
+	if err != nil {
+		t.Fatalf("expected ticket to validate: %v", err)
+	}
+	if got.UserID != "user-1" || got.Email != "user@example.com" || got.Provider != ProviderTicket {
+		t.Errorf("unexpected principal: %+v", got)
+	}
+
+	other := NewTicketIssuer("other-secret", time.Minute, nil)
+	if _, err := other.Validate(context.Background(), Credential{Type: CredentialTicket, Value: ticket}); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("expected signature mismatch, got %v", err)
+	}
+
+	// A regular access token signed with the same secret must not be accepted as a ticket
+	token := signTestToken(t, "ticket-secret", map[string]interface{}{
+		"sub": "user-1",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+	if _, err := issuer.Validate(context.Background(), Credential{Type: CredentialTicket, Value: token}); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("expected access token to be rejected as ticket, got %v", err)
+	}
+}
+
+func TestMTLSProvider(t *testing.T) {
+	provider := NewMTLSProvider(map[string]string{"spiffe://hub/order-service": "order-service"})
+
+	spiffeID, _ := url.Parse("spiffe://hub/order-service")
+	principal, err := provider.Validate(context.Background(), Credential{
+		Type:        CredentialClientCert,
+		Certificate: &x509.Certificate{URIs: []*url.URL{spiffeID}},
+	})
+	if err != nil {
+		t.Fatalf("expected mapped SAN to authenticate: %v", err)
+	}
+	if principal.UserID != "order-service" || principal.Provider != ProviderMTLS {
+		t.Errorf("unexpected principal: %+v", principal)
+	}
+
+	_, err = provider.Validate(context.Background(), Credential{
+		Type:        CredentialClientCert,
+		Certificate: &x509.Certificate{DNSNames: []string{"unknown.internal"}},
+	})
+	if !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("expected unmapped SAN to be rejected, got %v", err)
+	}
+}
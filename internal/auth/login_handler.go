@@ -2,66 +2,131 @@ package auth
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
+
+	"hub-api-gateway/internal/audit"
+	"hub-api-gateway/internal/httperr"
+	"hub-api-gateway/internal/metrics"
+
+	authpb "github.com/RodriguesYan/hub-proto-contracts/auth"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // LoginRequest represents the login request body
 type LoginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+
+	// Solved challenge, required once the brute-force detector flags the login
+	ChallengeToken string `json:"challengeToken,omitempty"`
 }
 
-// LoginResponse represents the successful login response
+// LoginResponse represents the successful login (and refresh) response
 type LoginResponse struct {
-	Token     string `json:"token"`
-	ExpiresIn int64  `json:"expiresIn"` // seconds
+	Token     string `json:"token,omitempty"` // Unless sent as a session cookie
+	ExpiresIn int64  `json:"expiresIn"`       // seconds
 	UserID    string `json:"userId"`
 	Email     string `json:"email"`
-}
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error ErrorDetail `json:"error"`
+	// CSRF token of the session cookie, echoed in X-CSRF-Token by mutating requests
+	CSRFToken string `json:"csrfToken,omitempty"`
+
+	// Refresh token, unless refresh tokens are disabled or sent as a cookie
+	RefreshToken     string `json:"refreshToken,omitempty"`
+	RefreshExpiresIn int64  `json:"refreshExpiresIn,omitempty"` // seconds
 }
 
-// ErrorDetail contains error information
-type ErrorDetail struct {
-	Code      string `json:"code"`
-	Message   string `json:"message"`
-	RequestID string `json:"requestId,omitempty"`
-	Timestamp string `json:"timestamp"`
+// RefreshRequest represents the refresh request body
+type RefreshRequest struct {
+	RefreshToken string `json:"refreshToken"`
 }
 
+// refreshCookie is the cookie holding the refresh token in cookie mode. It is
+// only sent to the refresh endpoint.
+const (
+	refreshCookie     = "refresh_token"
+	refreshCookiePath = "/api/v1/auth/refresh"
+)
+
+// tokenLifetime is how long User Service tokens are valid
+const tokenLifetime = 10 * time.Minute
+
 // LoginHandler handles the login endpoint
 type LoginHandler struct {
 	userClient *UserServiceClient
+	audit      *audit.Logger
+	sessions   *SessionLimiter
+	guard      *LoginGuard
+	challenge  ChallengeVerifier
+
+	refresh       *RefreshTokens
+	refreshCookie bool
+
+	webSessions *WebSessions
 }
 
-// NewLoginHandler creates a new login handler
-func NewLoginHandler(userClient *UserServiceClient) *LoginHandler {
+// NewLoginHandler creates a new login handler; auditLogger may be nil
+func NewLoginHandler(userClient *UserServiceClient, auditLogger *audit.Logger) *LoginHandler {
 	return &LoginHandler{
 		userClient: userClient,
+		audit:      auditLogger,
 	}
 }
 
+// SetSessionLimiter enforces a maximum number of concurrent sessions per user
+func (h *LoginHandler) SetSessionLimiter(limiter *SessionLimiter) {
+	h.sessions = limiter
+}
+
+// SetLoginGuard slows down and locks out repeated failed logins
+func (h *LoginHandler) SetLoginGuard(guard *LoginGuard) {
+	h.guard = guard
+}
+
+// SetChallengeVerifier makes logins the login guard flags solve a challenge
+// (CAPTCHA, proof of work, ...) before their credentials are checked
+func (h *LoginHandler) SetChallengeVerifier(verifier ChallengeVerifier) {
+	h.challenge = verifier
+}
+
+// EnableRefreshTokens returns a refresh token from login, as an httpOnly
+// secure cookie when cookie is set
+func (h *LoginHandler) EnableRefreshTokens(tokens *RefreshTokens, cookie bool) {
+	h.refresh = tokens
+	h.refreshCookie = cookie
+}
+
+// SetWebSessions keeps access tokens server-side and hands out session
+// cookies instead of returning them
+func (h *LoginHandler) SetWebSessions(sessions *WebSessions) {
+	h.webSessions = sessions
+}
+
 // Handle processes the login request
 func (h *LoginHandler) Handle(w http.ResponseWriter, r *http.Request) {
-	log.Printf("📥 Received login request from %s", r.RemoteAddr)
+	slog.InfoContext(r.Context(), "received login request", "remote_addr", r.RemoteAddr)
 
 	// Only accept POST
 	if r.Method != http.MethodPost {
-		h.sendError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed. Use POST.")
+		h.sendError(w, r, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "Method not allowed. Use POST.")
 		return
 	}
 
 	// Read request body
 	body, err := io.ReadAll(r.Body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		h.sendError(w, r, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", "Request body is too large")
+		return
+	}
 	if err != nil {
-		log.Printf("❌ Failed to read request body: %v", err)
-		h.sendError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to read request body")
+		slog.WarnContext(r.Context(), "failed to read login request body", "error", err)
+		h.sendError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "Failed to read request body")
 		return
 	}
 	defer r.Body.Close()
@@ -69,34 +134,42 @@ func (h *LoginHandler) Handle(w http.ResponseWriter, r *http.Request) {
 	// Parse request
 	var loginReq LoginRequest
 	if err := json.Unmarshal(body, &loginReq); err != nil {
-		log.Printf("❌ Failed to parse request body: %v", err)
-		h.sendError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON format")
+		slog.WarnContext(r.Context(), "failed to parse login request body", "error", err)
+		h.sendError(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON format")
 		return
 	}
 
 	// Validate request
 	if err := h.validateLoginRequest(&loginReq); err != nil {
-		log.Printf("❌ Request validation failed: %v", err)
-		h.sendError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		slog.WarnContext(r.Context(), "login request validation failed", "error", err)
+		h.sendError(w, r, http.StatusBadRequest, "VALIDATION_ERROR", err.Error())
+		return
+	}
+
+	if !h.checkGuard(w, r, loginReq.Email) || !h.checkChallenge(w, r, &loginReq) {
 		return
 	}
 
 	// Call User Service
-	log.Printf("🔄 Forwarding login request to User Service for email: %s", loginReq.Email)
+	slog.DebugContext(r.Context(), "forwarding login request to user service", "email", loginReq.Email)
 
 	ctx := r.Context()
 	resp, err := h.userClient.Login(ctx, loginReq.Email, loginReq.Password)
 	if err != nil {
-		log.Printf("❌ User Service returned error: %v", err)
+		slog.WarnContext(r.Context(), "user service rejected login", "email", loginReq.Email, "error", err)
+		h.auditLogin(r, loginReq.Email, audit.ResultFailure)
+		if h.guard != nil && credentialsRejected(resp, err) {
+			h.guard.Failed(r, loginReq.Email)
+		}
 		// Determine appropriate error code based on error
 		if resp != nil && resp.ApiResponse != nil {
 			statusCode := int(resp.ApiResponse.Code)
 			if statusCode == 0 {
 				statusCode = http.StatusUnauthorized
 			}
-			h.sendError(w, statusCode, "AUTH_FAILED", resp.ApiResponse.Message)
+			h.sendError(w, r, statusCode, "AUTH_FAILED", resp.ApiResponse.Message)
 		} else {
-			h.sendError(w, http.StatusUnauthorized, "AUTH_FAILED", "Invalid credentials")
+			h.sendError(w, r, http.StatusUnauthorized, "AUTH_FAILED", "Invalid credentials")
 		}
 		return
 	}
@@ -108,20 +181,273 @@ func (h *LoginHandler) Handle(w http.ResponseWriter, r *http.Request) {
 		email = resp.UserInfo.Email
 	}
 
-	// Build response
-	loginResp := LoginResponse{
-		Token:     resp.Token,
-		ExpiresIn: 600, // 10 minutes (from user service)
-		UserID:    userID,
-		Email:     email,
+	if h.guard != nil {
+		h.guard.Succeeded(r, loginReq.Email)
 	}
 
-	log.Printf("✅ Login successful for email: %s, userId: %s", email, userID)
+	principal := &Principal{UserID: userID, Email: email}
+	principal.Roles, principal.Scopes, principal.TenantID = unverifiedGrants(resp.Token)
+	loginResp, ok := h.completeLogin(w, r, principal, loginReq.Email, resp.Token)
+	if !ok {
+		return
+	}
 
 	// Send response
 	h.sendJSON(w, http.StatusOK, loginResp)
 }
 
+// completeLogin opens the session of a token issued to principal and
+// returns the login response, with a refresh token when enabled. It reports
+// false, having answered the request, when the session limit refuses it.
+// loginEmail is the email the client logged in with, for the audit log.
+func (h *LoginHandler) completeLogin(w http.ResponseWriter, r *http.Request, principal *Principal, loginEmail, token string) (*LoginResponse, bool) {
+	if !h.openSession(w, r, principal.UserID, loginEmail, token) {
+		return nil, false
+	}
+
+	loginResp := &LoginResponse{
+		Token:     token,
+		ExpiresIn: int64(tokenLifetime.Seconds()), // 10 minutes (from user service)
+		UserID:    principal.UserID,
+		Email:     principal.Email,
+	}
+	if !h.openWebSession(w, r, loginResp) {
+		return nil, false
+	}
+
+	if h.refresh != nil && principal.UserID != "" {
+		refreshToken, err := h.refresh.Issue(r.Context(), principal)
+		if err != nil {
+			slog.WarnContext(r.Context(), "failed to issue refresh token", "user_id", principal.UserID, "error", err)
+		} else {
+			h.setRefreshToken(w, loginResp, refreshToken)
+		}
+	}
+
+	slog.InfoContext(r.Context(), "login successful", "email", principal.Email, "user_id", principal.UserID)
+	h.auditLogin(r, loginEmail, audit.ResultSuccess)
+	return loginResp, true
+}
+
+// HandleRefresh exchanges a refresh token (from the body, or the cookie) for a
+// new access token and rotates the refresh token
+func (h *LoginHandler) HandleRefresh(w http.ResponseWriter, r *http.Request) {
+	if h.refresh == nil {
+		h.sendError(w, r, http.StatusNotFound, "NOT_FOUND", "Refresh tokens are disabled")
+		return
+	}
+
+	var refreshReq RefreshRequest
+	if body, err := io.ReadAll(r.Body); err == nil && len(body) > 0 {
+		if err := json.Unmarshal(body, &refreshReq); err != nil {
+			h.sendError(w, r, http.StatusBadRequest, "INVALID_JSON", "Invalid JSON format")
+			return
+		}
+	}
+	if refreshReq.RefreshToken == "" {
+		if cookie, err := r.Cookie(refreshCookie); err == nil {
+			refreshReq.RefreshToken = cookie.Value
+		}
+	}
+	if refreshReq.RefreshToken == "" {
+		h.sendError(w, r, http.StatusBadRequest, "VALIDATION_ERROR", "Refresh token is required")
+		return
+	}
+
+	session, refreshToken, err := h.refresh.Rotate(r.Context(), refreshReq.RefreshToken, func(session *RefreshSession) error {
+		return h.userClient.CheckUserActive(r.Context(), session.UserID)
+	})
+	if err != nil {
+		if errors.Is(err, ErrRefreshTokenInvalid) || errors.Is(err, ErrRefreshTokenReused) || errors.Is(err, ErrInvalidCredential) {
+			slog.WarnContext(r.Context(), "refresh token rejected", "error", err)
+			h.auditRefresh(r, "", audit.ResultFailure)
+			h.clearRefreshCookie(w)
+			h.sendError(w, r, http.StatusUnauthorized, "AUTH_REFRESH_TOKEN_INVALID", "Refresh token expired or invalid")
+			return
+		}
+		slog.ErrorContext(r.Context(), "token refresh failed", "error", err)
+		h.sendError(w, r, http.StatusServiceUnavailable, "AUTH_SERVICE_UNAVAILABLE", "Token refresh is temporarily unavailable")
+		return
+	}
+
+	token, err := h.refresh.AccessToken(session, tokenLifetime)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to sign access token", "error", err)
+		h.sendError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to issue access token")
+		return
+	}
+	if !h.openSession(w, r, session.UserID, session.Email, token) {
+		return
+	}
+
+	refreshResp := LoginResponse{
+		Token:     token,
+		ExpiresIn: int64(tokenLifetime.Seconds()),
+		UserID:    session.UserID,
+		Email:     session.Email,
+	}
+	if !h.openWebSession(w, r, &refreshResp) {
+		return
+	}
+	h.setRefreshToken(w, &refreshResp, refreshToken)
+
+	slog.InfoContext(r.Context(), "token refreshed", "user_id", session.UserID)
+	h.auditRefresh(r, session.Email, audit.ResultSuccess)
+	h.sendJSON(w, http.StatusOK, refreshResp)
+}
+
+// openWebSession moves the access token of resp into a web session when
+// session cookies are enabled. It reports false, having answered the request,
+// when the session can't be stored.
+func (h *LoginHandler) openWebSession(w http.ResponseWriter, r *http.Request, resp *LoginResponse) bool {
+	if h.webSessions == nil {
+		return true
+	}
+	csrfToken, err := h.webSessions.Open(r.Context(), w, resp.Token, time.Duration(resp.ExpiresIn)*time.Second)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to open web session", "user_id", resp.UserID, "error", err)
+		h.sendError(w, r, http.StatusServiceUnavailable, "SESSION_UNAVAILABLE", "Sessions are temporarily unavailable")
+		return false
+	}
+	resp.Token, resp.CSRFToken = "", csrfToken
+	return true
+}
+
+// HandleLogout ends the web session of the request's session cookie
+func (h *LoginHandler) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	if h.webSessions == nil {
+		h.sendError(w, r, http.StatusNotFound, "NOT_FOUND", "Session cookies are disabled")
+		return
+	}
+	if err := h.webSessions.Close(w, r); err != nil {
+		slog.WarnContext(r.Context(), "failed to delete web session", "error", err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// setRefreshToken hands the refresh token to the client, in a cookie or the response
+func (h *LoginHandler) setRefreshToken(w http.ResponseWriter, resp *LoginResponse, token string) {
+	if !h.refreshCookie {
+		resp.RefreshToken = token
+		resp.RefreshExpiresIn = int64(h.refresh.TTL().Seconds())
+		return
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookie,
+		Value:    token,
+		Path:     refreshCookiePath,
+		MaxAge:   int(h.refresh.TTL().Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// clearRefreshCookie removes a rejected refresh token cookie
+func (h *LoginHandler) clearRefreshCookie(w http.ResponseWriter) {
+	if h.refreshCookie {
+		http.SetCookie(w, &http.Cookie{Name: refreshCookie, Path: refreshCookiePath, MaxAge: -1, HttpOnly: true, Secure: true})
+	}
+}
+
+// checkGuard reports whether a login for email may be attempted, answering
+// 423 for locked accounts and 429 while the client must back off
+func (h *LoginHandler) checkGuard(w http.ResponseWriter, r *http.Request, email string) bool {
+	if h.guard == nil {
+		return true
+	}
+
+	wait, err := h.guard.Check(r, email)
+	if err == nil {
+		return true
+	}
+	slog.WarnContext(r.Context(), "login rejected after failed attempts", "email", email, "error", err,
+		"retry_after", wait.String())
+	h.auditLogin(r, email, audit.ResultFailure)
+
+	// Round up so clients never retry before the wait is over
+	retryAfter := (wait + time.Second - 1).Truncate(time.Second)
+	if errors.Is(err, ErrAccountLocked) {
+		httperr.Write(w, r, httperr.Error{Status: http.StatusLocked, Code: "ACCOUNT_LOCKED",
+			Message: "Account temporarily locked after too many failed login attempts", RetryAfter: retryAfter})
+		return false
+	}
+	httperr.Write(w, r, httperr.Error{Status: http.StatusTooManyRequests, Code: "LOGIN_THROTTLED",
+		Message: "Too many failed login attempts. Try again later.", RetryAfter: retryAfter})
+	return false
+}
+
+// checkChallenge reports whether a login may be forwarded to the User
+// Service: logins the guard flags need a challenge token the verifier accepts.
+// Flagged clients are refused while the verifier is unavailable.
+func (h *LoginHandler) checkChallenge(w http.ResponseWriter, r *http.Request, req *LoginRequest) bool {
+	if h.challenge == nil || h.guard == nil || !h.guard.Flagged(r, req.Email) {
+		return true
+	}
+
+	details := []map[string]interface{}{h.challenge.Details()}
+	if req.ChallengeToken == "" {
+		h.guard.record(metrics.LoginChallenged)
+		httperr.Write(w, r, httperr.Error{Status: http.StatusForbidden, Code: "CHALLENGE_REQUIRED",
+			Message: "Complete the challenge to continue signing in", Details: details})
+		return false
+	}
+
+	err := h.challenge.Verify(r.Context(), req.ChallengeToken, h.guard.clientIP(r))
+	switch {
+	case errors.Is(err, ErrChallengeFailed):
+		slog.WarnContext(r.Context(), "login challenge failed", "email", req.Email, "error", err)
+		h.guard.record(metrics.LoginChallenged)
+		h.auditLogin(r, req.Email, audit.ResultFailure)
+		httperr.Write(w, r, httperr.Error{Status: http.StatusForbidden, Code: "CHALLENGE_FAILED",
+			Message: "The challenge could not be verified. Try again.", Details: details})
+		return false
+	case err != nil:
+		slog.ErrorContext(r.Context(), "login challenge verification unavailable", "error", err)
+		h.sendError(w, r, http.StatusServiceUnavailable, "CHALLENGE_UNAVAILABLE",
+			"Sign-in verification is temporarily unavailable. Try again shortly.")
+		return false
+	}
+	return true
+}
+
+// credentialsRejected reports whether a failed login was refused for its
+// credentials, rather than because the User Service was unreachable
+func credentialsRejected(resp *authpb.LoginResponse, err error) bool {
+	if resp != nil {
+		return true
+	}
+	switch status.Code(err) {
+	case codes.Unauthenticated, codes.NotFound, codes.InvalidArgument, codes.PermissionDenied:
+		return true
+	}
+	return false
+}
+
+// openSession registers the new token with the session limiter and reports
+// whether the login may complete. The registry fails open: an unavailable
+// Redis must not lock every user out.
+func (h *LoginHandler) openSession(w http.ResponseWriter, r *http.Request, userID, email, token string) bool {
+	if h.sessions == nil || !h.sessions.Applies(userID) {
+		return true
+	}
+
+	evicted, err := h.sessions.Open(r.Context(), userID, token)
+	switch {
+	case errors.Is(err, ErrSessionLimitReached):
+		slog.WarnContext(r.Context(), "login rejected: concurrent session limit reached", "user_id", userID)
+		h.auditLogin(r, email, audit.ResultFailure)
+		h.sendError(w, r, http.StatusConflict, "SESSION_LIMIT_REACHED",
+			"Maximum number of concurrent sessions reached. Sign out of another device and try again.")
+		return false
+	case err != nil:
+		slog.WarnContext(r.Context(), "session registry unavailable, allowing login", "error", err)
+	case evicted > 0:
+		slog.InfoContext(r.Context(), "evicted oldest sessions", "user_id", userID, "evicted", evicted)
+	}
+	return true
+}
+
 // validateLoginRequest validates the login request
 func (h *LoginHandler) validateLoginRequest(req *LoginRequest) error {
 	if req.Email == "" {
@@ -140,27 +466,41 @@ func (h *LoginHandler) validateLoginRequest(req *LoginRequest) error {
 	return nil
 }
 
+// auditLogin records a login attempt in the audit log
+func (h *LoginHandler) auditLogin(r *http.Request, email, result string) {
+	h.audit.Log(audit.Event{
+		Actor:      email,
+		Action:     "auth.login",
+		Result:     result,
+		RemoteAddr: r.RemoteAddr,
+		RequestID:  r.Header.Get("X-Request-ID"),
+	})
+}
+
+// auditRefresh records a token refresh in the audit log
+func (h *LoginHandler) auditRefresh(r *http.Request, email, result string) {
+	h.audit.Log(audit.Event{
+		Actor:      email,
+		Action:     "auth.refresh",
+		Result:     result,
+		RemoteAddr: r.RemoteAddr,
+		RequestID:  r.Header.Get("X-Request-ID"),
+	})
+}
+
 // sendJSON sends a JSON response
 func (h *LoginHandler) sendJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(status)
 
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Printf("❌ Failed to encode response: %v", err)
+		slog.Error("failed to encode login response", "error", err)
 	}
 }
 
 // sendError sends an error response
-func (h *LoginHandler) sendError(w http.ResponseWriter, status int, code, message string) {
-	errResp := ErrorResponse{
-		Error: ErrorDetail{
-			Code:      code,
-			Message:   message,
-			Timestamp: time.Now().Format(time.RFC3339),
-		},
-	}
-
-	h.sendJSON(w, status, errResp)
+func (h *LoginHandler) sendError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	httperr.Send(w, r, status, code, message)
 }
 
 // ValidationError represents a validation error
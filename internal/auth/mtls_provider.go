@@ -0,0 +1,51 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+)
+
+// MTLSProvider authenticates internal services by the client certificate they
+// presented on the internal mTLS listener. The certificate chain is verified by
+// the TLS stack; the provider maps its SAN to a service principal.
+type MTLSProvider struct {
+	// principals maps a certificate SAN (URI such as spiffe://hub/order-service,
+	// or DNS name) to the service principal it identifies
+	principals map[string]string
+}
+
+// NewMTLSProvider creates an mTLS provider from a SAN -> service principal mapping
+func NewMTLSProvider(principals map[string]string) *MTLSProvider {
+	return &MTLSProvider{principals: principals}
+}
+
+// Name returns the provider name
+func (p *MTLSProvider) Name() string {
+	return ProviderMTLS
+}
+
+// Validate maps the verified client certificate to a service principal
+func (p *MTLSProvider) Validate(_ context.Context, credential Credential) (*Principal, error) {
+	if credential.Type != CredentialClientCert || credential.Certificate == nil {
+		return nil, fmt.Errorf("%w: mtls provider requires a verified client certificate", ErrInvalidCredential)
+	}
+
+	cert := credential.Certificate
+	sans := make([]string, 0, len(cert.URIs)+len(cert.DNSNames))
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+	sans = append(sans, cert.DNSNames...)
+
+	for _, san := range sans {
+		if service, ok := p.principals[san]; ok {
+			return &Principal{
+				UserID:   service,
+				Roles:    []string{"service"},
+				Provider: p.Name(),
+			}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: client certificate SAN %v is not mapped to a service principal", ErrInvalidCredential, sans)
+}
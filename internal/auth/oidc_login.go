@@ -0,0 +1,366 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hub-api-gateway/internal/audit"
+)
+
+// The state cookie carries the state, nonce and PKCE verifier of an OIDC
+// login from the login endpoint to the callback. It is signed, and its
+// audience keeps it from being accepted as any other gateway token.
+const (
+	oidcStateCookie     = "oidc_login"
+	oidcStateCookiePath = "/api/v1/auth/oidc"
+	oidcStateAudience   = "gateway-oidc-state"
+	oidcStateTTL        = 10 * time.Minute
+)
+
+// errOIDCState is returned for callbacks whose state doesn't match the login they claim to finish
+var errOIDCState = errors.New("missing or invalid oidc login state")
+
+// OIDCLoginConfig configures logins through an external OIDC identity provider
+type OIDCLoginConfig struct {
+	Issuer            string // Discovery document at {Issuer}/.well-known/openid-configuration
+	ClientID          string
+	ClientSecret      string
+	RedirectURL       string // Gateway callback URL registered with the identity provider
+	Scopes            []string
+	PostLoginRedirect string // Where browsers go after login, with the token in the fragment; empty answers JSON
+	TokenSecret       string // Signs gateway access tokens and the state cookie (the JWT secret)
+	TokenIssuer       string // "iss" of gateway access tokens
+	Timeout           time.Duration
+}
+
+// oidcStateClaims are the claims of the state cookie
+type oidcStateClaims struct {
+	Audience  string `json:"aud"`
+	State     string `json:"state"`
+	Nonce     string `json:"nonce"`
+	Verifier  string `json:"verifier"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// oidcDiscovery is the part of the provider's discovery document the flow uses
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcTokenResponse is the token endpoint's answer to a code exchange
+type oidcTokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// OIDCLoginHandler logs users in through an external OIDC identity provider
+// with the authorization code flow (PKCE, state and nonce checked). The
+// verified ID token is translated into a gateway session: an access token in
+// the User Service format signed with the JWT secret, plus a refresh token
+// when enabled, just like a password login.
+type OIDCLoginHandler struct {
+	config OIDCLoginConfig
+	hmacSecret
+	login  *LoginHandler
+	client *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery // Fetched on first use
+	keys      *JWKSKeys
+}
+
+// NewOIDCLoginHandler creates the OIDC login endpoints; sessions are opened
+// through login, so they obey its session limit and refresh token settings
+func NewOIDCLoginHandler(cfg OIDCLoginConfig, login *LoginHandler) *OIDCLoginHandler {
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	return &OIDCLoginHandler{
+		hmacSecret: hmacSecret{current: []byte(cfg.TokenSecret)},
+		config:     cfg,
+		login:      login,
+		client:     &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// HandleLogin redirects the browser to the identity provider's authorization endpoint
+func (h *OIDCLoginHandler) HandleLogin(w http.ResponseWriter, r *http.Request) {
+	discovery, err := h.discover(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "oidc discovery failed", "issuer", h.config.Issuer, "error", err)
+		h.login.sendError(w, r, http.StatusBadGateway, "OIDC_PROVIDER_UNAVAILABLE", "Identity provider is unavailable")
+		return
+	}
+
+	claims := oidcStateClaims{Audience: oidcStateAudience, ExpiresAt: time.Now().Add(oidcStateTTL).Unix()}
+	for _, value := range []*string{&claims.State, &claims.Nonce, &claims.Verifier} {
+		if *value, err = randomToken(); err != nil {
+			h.login.sendError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to start login")
+			return
+		}
+	}
+	cookie, err := signHS256(claims, h.signingSecret())
+	if err != nil {
+		h.login.sendError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to start login")
+		return
+	}
+	// Lax, since the callback is a cross-site navigation from the provider
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    cookie,
+		Path:     oidcStateCookiePath,
+		MaxAge:   int(oidcStateTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	challenge := sha256.Sum256([]byte(claims.Verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {h.config.ClientID},
+		"redirect_uri":          {h.config.RedirectURL},
+		"scope":                 {strings.Join(h.config.Scopes, " ")},
+		"state":                 {claims.State},
+		"nonce":                 {claims.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	http.Redirect(w, r, discovery.AuthorizationEndpoint+separator+query.Encode(), http.StatusFound)
+}
+
+// HandleCallback finishes a login: it checks the state, exchanges the code
+// for an ID token, verifies it and opens a gateway session
+func (h *OIDCLoginHandler) HandleCallback(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	state, err := h.readState(r, query.Get("state"))
+	http.SetCookie(w, &http.Cookie{Name: oidcStateCookie, Path: oidcStateCookiePath, MaxAge: -1, HttpOnly: true, Secure: true})
+	if err != nil {
+		slog.WarnContext(r.Context(), "oidc callback rejected", "error", err)
+		h.login.sendError(w, r, http.StatusBadRequest, "OIDC_STATE_INVALID", "Login session expired or invalid. Start the login again.")
+		return
+	}
+
+	if providerErr := query.Get("error"); providerErr != "" {
+		slog.WarnContext(r.Context(), "identity provider refused login", "error", providerErr,
+			"description", query.Get("error_description"))
+		h.login.auditLogin(r, "", audit.ResultFailure)
+		h.login.sendError(w, r, http.StatusUnauthorized, "OIDC_LOGIN_FAILED", "The identity provider refused the login")
+		return
+	}
+	code := query.Get("code")
+	if code == "" {
+		h.login.sendError(w, r, http.StatusBadRequest, "VALIDATION_ERROR", "Authorization code is required")
+		return
+	}
+
+	idToken, err := h.exchange(r.Context(), code, state.Verifier)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "oidc code exchange failed", "error", err)
+		h.login.sendError(w, r, http.StatusBadGateway, "OIDC_PROVIDER_ERROR", "Failed to complete login with the identity provider")
+		return
+	}
+	principal, err := h.verifyIDToken(r.Context(), idToken, state.Nonce)
+	if err != nil {
+		slog.WarnContext(r.Context(), "oidc id token rejected", "error", err)
+		h.login.auditLogin(r, "", audit.ResultFailure)
+		h.login.sendError(w, r, http.StatusUnauthorized, "OIDC_LOGIN_FAILED", "The identity provider's token could not be verified")
+		return
+	}
+
+	token, err := signAccessToken(principal, h.signingSecret(), h.config.TokenIssuer, tokenLifetime)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to sign access token", "error", err)
+		h.login.sendError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to issue access token")
+		return
+	}
+	loginResp, ok := h.login.completeLogin(w, r, principal, principal.Email, token)
+	if !ok {
+		return
+	}
+
+	if h.config.PostLoginRedirect == "" {
+		h.login.sendJSON(w, http.StatusOK, loginResp)
+		return
+	}
+	// The fragment never reaches servers or Referer headers
+	fragment := url.Values{"expiresIn": {strconv.FormatInt(loginResp.ExpiresIn, 10)}}
+	if loginResp.Token != "" {
+		fragment.Set("token", loginResp.Token)
+	} else {
+		fragment.Set("csrfToken", loginResp.CSRFToken)
+	}
+	if loginResp.RefreshToken != "" {
+		fragment.Set("refreshToken", loginResp.RefreshToken)
+		fragment.Set("refreshExpiresIn", strconv.FormatInt(loginResp.RefreshExpiresIn, 10))
+	}
+	http.Redirect(w, r, h.config.PostLoginRedirect+"#"+fragment.Encode(), http.StatusFound)
+}
+
+// readState verifies the state cookie and that the callback's state matches it
+func (h *OIDCLoginHandler) readState(r *http.Request, callbackState string) (*oidcStateClaims, error) {
+	cookie, err := r.Cookie(oidcStateCookie)
+	if err != nil {
+		return nil, errOIDCState
+	}
+	parsed, err := parseJWT(cookie.Value)
+	if err != nil {
+		return nil, errOIDCState
+	}
+	if err := h.verifyHS256(parsed); err != nil {
+		return nil, fmt.Errorf("%w: %w", errOIDCState, err)
+	}
+
+	var state oidcStateClaims
+	if err := json.Unmarshal(parsed.claims.Extra, &state); err != nil || state.Audience != oidcStateAudience {
+		return nil, errOIDCState
+	}
+	if time.Now().Unix() > state.ExpiresAt {
+		return nil, fmt.Errorf("%w: login started too long ago", errOIDCState)
+	}
+	if callbackState == "" || subtle.ConstantTimeCompare([]byte(callbackState), []byte(state.State)) != 1 {
+		return nil, fmt.Errorf("%w: state mismatch", errOIDCState)
+	}
+	return &state, nil
+}
+
+// exchange trades an authorization code for the provider's ID token
+func (h *OIDCLoginHandler) exchange(ctx context.Context, code, verifier string) (string, error) {
+	discovery, err := h.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {h.config.RedirectURL},
+		"client_id":     {h.config.ClientID},
+		"code_verifier": {verifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if h.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(h.config.ClientID), url.QueryEscape(h.config.ClientSecret))
+	}
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tokens oidcTokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&tokens); err != nil {
+		return "", fmt.Errorf("invalid token response (status %d): %w", resp.StatusCode, err)
+	}
+	if resp.StatusCode != http.StatusOK || tokens.Error != "" {
+		return "", fmt.Errorf("token endpoint answered %d: %s %s", resp.StatusCode, tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IDToken == "" {
+		return "", fmt.Errorf("token response has no id_token")
+	}
+	return tokens.IDToken, nil
+}
+
+// verifyIDToken checks the ID token's signature, issuer, audience, expiry and nonce
+func (h *OIDCLoginHandler) verifyIDToken(ctx context.Context, idToken, nonce string) (*Principal, error) {
+	discovery, err := h.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := parseJWT(idToken)
+	if err != nil {
+		return nil, err
+	}
+	key, err := h.keys.Key(ctx, parsed.header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := parsed.verifyRS256(key); err != nil {
+		return nil, err
+	}
+
+	claims := &parsed.claims
+	if claims.ExpiresAt == 0 {
+		return nil, fmt.Errorf("%w: id token has no expiry", ErrInvalidCredential)
+	}
+	if err := claims.validateTimes(time.Now(), jwtClockSkew); err != nil {
+		return nil, err
+	}
+	if claims.Issuer != discovery.Issuer {
+		return nil, fmt.Errorf("%w: unexpected issuer %s", ErrInvalidCredential, claims.Issuer)
+	}
+	if !claims.Audience.Contains(h.config.ClientID) {
+		return nil, fmt.Errorf("%w: id token not issued for client %s", ErrInvalidCredential, h.config.ClientID)
+	}
+	if subtle.ConstantTimeCompare([]byte(claims.Nonce), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidCredential)
+	}
+	return claims.toPrincipal(ProviderOIDC)
+}
+
+// discover returns the provider's endpoints, fetching its discovery document
+// on first use (and again after a failure)
+func (h *OIDCLoginHandler) discover(ctx context.Context) (*oidcDiscovery, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.discovery != nil {
+		return h.discovery, nil
+	}
+
+	discoveryURL := strings.TrimRight(h.config.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch discovery document: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("discovery document answered %d", resp.StatusCode)
+	}
+
+	var discovery oidcDiscovery
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&discovery); err != nil {
+		return nil, fmt.Errorf("invalid discovery document: %w", err)
+	}
+	if discovery.Issuer != h.config.Issuer {
+		return nil, fmt.Errorf("discovery document is for issuer %s, not %s", discovery.Issuer, h.config.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document lacks the authorization, token or jwks endpoint")
+	}
+
+	h.discovery = &discovery
+	h.keys = NewJWKSKeys(discovery.JWKSURI, 0)
+	return h.discovery, nil
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestOIDCLoginHandler_AuthorizationCodeFlow(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	var issuer, challenge, nonce string
+	idp := http.NewServeMux()
+	idp.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(oidcDiscovery{Issuer: issuer, AuthorizationEndpoint: issuer + "/authorize",
+			TokenEndpoint: issuer + "/token", JWKSURI: issuer + "/jwks"})
+	})
+	idp.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(jwkSet{Keys: []jwk{{
+			Kty: "RSA", Kid: "idp-key", Alg: "RS256",
+			N: base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E: base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	idp.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		verifier := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		clientID, secret, _ := r.BasicAuth()
+		if r.FormValue("code") != "code-1" || base64.RawURLEncoding.EncodeToString(verifier[:]) != challenge ||
+			clientID != "gateway" || secret != "client-secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "invalid_grant"}`))
+			return
+		}
+		idToken, _ := signRS256(map[string]interface{}{
+			"iss": issuer, "aud": "gateway", "sub": "idp-user", "email": "user@hub.com",
+			"nonce": nonce, "exp": time.Now().Add(time.Minute).Unix(),
+		}, key, "idp-key")
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idToken, "access_token": "idp-access"})
+	})
+	server := httptest.NewServer(idp)
+	defer server.Close()
+	issuer = server.URL
+
+	const secret = "test-secret-with-at-least-32-bytes!!"
+	handler := NewOIDCLoginHandler(OIDCLoginConfig{
+		Issuer:       issuer,
+		ClientID:     "gateway",
+		ClientSecret: "client-secret",
+		RedirectURL:  "https://gateway.hub.com/api/v1/auth/oidc/callback",
+		Scopes:       []string{"openid", "email"},
+		TokenSecret:  secret,
+	}, NewLoginHandler(nil, nil))
+
+	// Login redirects to the provider with state, nonce and a PKCE challenge
+	w := httptest.NewRecorder()
+	handler.HandleLogin(w, httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/login", nil))
+	location, err := url.Parse(w.Header().Get("Location"))
+	if w.Code != http.StatusFound || err != nil || location.Path != "/authorize" {
+		t.Fatalf("login answered %d redirecting to %q", w.Code, w.Header().Get("Location"))
+	}
+	query := location.Query()
+	challenge, nonce = query.Get("code_challenge"), query.Get("nonce")
+	cookies := w.Result().Cookies()
+
+	callback := func(state string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/api/v1/auth/oidc/callback?code=code-1&state="+url.QueryEscape(state), nil)
+		for _, cookie := range cookies {
+			r.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		handler.HandleCallback(w, r)
+		return w
+	}
+
+	if w := callback("forged-state"); w.Code != http.StatusBadRequest {
+		t.Errorf("callback with forged state answered %d, want 400", w.Code)
+	}
+
+	w = callback(query.Get("state"))
+	var resp LoginResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); w.Code != http.StatusOK || err != nil {
+		t.Fatalf("callback answered %d: %s", w.Code, w.Body)
+	}
+	principal, err := NewJWTProvider(secret, "").Validate(t.Context(), Credential{Type: CredentialBearer, Value: resp.Token})
+	if err != nil || principal.UserID != "idp-user" || principal.Email != "user@hub.com" {
+		t.Errorf("gateway token for %+v, error %v", principal, err)
+	}
+}
@@ -0,0 +1,213 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"hub-api-gateway/internal/config"
+)
+
+// CredentialType identifies how a credential was presented by the client
+type CredentialType string
+
+const (
+	CredentialBearer     CredentialType = "bearer"      // Authorization: Bearer <token>
+	CredentialAPIKey     CredentialType = "api_key"     // X-API-Key: <key>
+	CredentialTicket     CredentialType = "ticket"      // X-Reconnect-Ticket: <ticket> or ?ticket=<ticket>
+	CredentialClientCert CredentialType = "client_cert" // Verified client certificate on the internal mTLS listener
+)
+
+// Provider names for the built-in authentication providers
+const (
+	ProviderUserService = "user-service"
+	ProviderJWT         = "jwt"
+	ProviderOIDC        = "oidc"
+	ProviderAPIKey      = "api-key"
+	ProviderTicket      = "ticket"
+	ProviderMTLS        = "mtls"
+	// ProviderIntrospection validates opaque tokens of partner authorization servers (RFC 7662)
+	ProviderIntrospection = "introspection"
+)
+
+var (
+	// ErrProviderNotFound is returned when no provider is registered under a name
+	ErrProviderNotFound = errors.New("authentication provider not found")
+	// ErrInvalidCredential is returned when a provider rejects a credential
+	ErrInvalidCredential = errors.New("invalid credential")
+)
+
+// Credential is a raw credential extracted from a request
+type Credential struct {
+	Type  CredentialType
+	Value string
+
+	// Certificate is the verified client certificate for CredentialClientCert
+	Certificate *x509.Certificate
+}
+
+// VerifiedLocally returns whether the credential is validated without any
+// backend call, in which case caching the result has no benefit
+func (c Credential) VerifiedLocally() bool {
+	return c.Type == CredentialTicket || c.Type == CredentialClientCert
+}
+
+// Principal is the identity resolved by an authentication provider
+type Principal struct {
+	UserID   string
+	Email    string
+	Roles    []string
+	Scopes   []string
+	Provider string
+	TenantID string // From the token's tenant_id claim, when it has one
+}
+
+// Provider validates credentials and resolves them to a principal
+type Provider interface {
+	// Name returns the unique provider name used in routes.yaml (auth_provider)
+	Name() string
+	// Validate validates the credential and returns the resolved principal
+	Validate(ctx context.Context, credential Credential) (*Principal, error)
+}
+
+// LocalValidator is implemented by providers that verify credentials without
+// any backend call, so caching their results has no benefit
+type LocalValidator interface {
+	ValidatesLocally() bool
+}
+
+// ValidatesLocally returns whether the provider verifies credentials without a backend call
+func ValidatesLocally(provider Provider) bool {
+	local, ok := provider.(LocalValidator)
+	return ok && local.ValidatesLocally()
+}
+
+// ProviderRegistry holds the configured authentication providers and selects
+// one per route or per credential type
+type ProviderRegistry struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+	defaults  map[CredentialType]string
+}
+
+// NewProviderRegistry creates an empty provider registry
+func NewProviderRegistry() *ProviderRegistry {
+	return &ProviderRegistry{
+		providers: make(map[string]Provider),
+		defaults:  make(map[CredentialType]string),
+	}
+}
+
+// NewProviderRegistryFromConfig registers every provider enabled in configuration.
+// The User Service and local JWT providers are always available; OIDC, token
+// introspection and API keys are registered only when configured.
+func NewProviderRegistryFromConfig(cfg *config.Config, userClient *UserServiceClient) *ProviderRegistry {
+	registry := NewProviderRegistry()
+
+	registry.Register(NewUserServiceProvider(userClient))
+	jwtProvider := NewJWTProvider(cfg.Auth.JWTSecret, cfg.Auth.JWTIssuer)
+	if cfg.Auth.JWTJWKSURL != "" {
+		jwtProvider.EnableJWKS(cfg.Auth.JWTJWKSURL, cfg.Auth.JWKSCacheTTL)
+	}
+	registry.Register(jwtProvider)
+
+	if cfg.Auth.OIDCJWKSURL != "" {
+		registry.Register(NewOIDCProvider(cfg.Auth.OIDCJWKSURL, cfg.Auth.OIDCIssuer, cfg.Auth.OIDCAudience, cfg.Auth.JWKSCacheTTL))
+	}
+
+	if cfg.Auth.IntrospectionURL != "" {
+		registry.Register(NewIntrospectionProvider(cfg.Auth.IntrospectionURL, cfg.Auth.IntrospectionClientID,
+			cfg.Auth.IntrospectionClientSecret, cfg.Auth.IntrospectionAudience, cfg.Auth.IntrospectionTimeout))
+	}
+
+	if len(cfg.InternalListener.ServicePrincipals) > 0 {
+		registry.Register(NewMTLSProvider(cfg.InternalListener.ServicePrincipals))
+		registry.SetDefault(CredentialClientCert, ProviderMTLS)
+	}
+
+	if len(cfg.Auth.APIKeys) > 0 {
+		registry.Register(NewAPIKeyProvider(cfg.Auth.APIKeys))
+		registry.SetDefault(CredentialAPIKey, ProviderAPIKey)
+	}
+
+	registry.SetDefault(CredentialBearer, cfg.Auth.DefaultProvider)
+
+	slog.Info("authentication providers registered", "providers", registry.Names(), "bearer_default", cfg.Auth.DefaultProvider)
+	return registry
+}
+
+// Register adds a provider to the registry, replacing any provider with the same name
+func (r *ProviderRegistry) Register(provider Provider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[provider.Name()] = provider
+}
+
+// SetDefault sets the provider used for a credential type when the route doesn't specify one
+func (r *ProviderRegistry) SetDefault(credentialType CredentialType, providerName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.defaults[credentialType] = providerName
+}
+
+// Get returns a provider by name
+func (r *ProviderRegistry) Get(name string) (Provider, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	provider, ok := r.providers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrProviderNotFound, name)
+	}
+	return provider, nil
+}
+
+// Resolve selects the provider for a request: the route's provider if set,
+// otherwise the default provider for the credential type
+func (r *ProviderRegistry) Resolve(routeProvider string, credentialType CredentialType) (Provider, error) {
+	if routeProvider != "" {
+		return r.Get(routeProvider)
+	}
+
+	r.mu.RLock()
+	name, ok := r.defaults[credentialType]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w for credential type %s", ErrProviderNotFound, credentialType)
+	}
+
+	return r.Get(name)
+}
+
+// Run runs the background work of every provider that has some (e.g. JWKS
+// refreshes) until ctx is done
+func (r *ProviderRegistry) Run(ctx context.Context) {
+	r.mu.RLock()
+	var wg sync.WaitGroup
+	for _, provider := range r.providers {
+		if runner, ok := provider.(interface{ Run(context.Context) }); ok {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				runner.Run(ctx)
+			}()
+		}
+	}
+	r.mu.RUnlock()
+	wg.Wait()
+}
+
+// Names returns the names of all registered providers
+func (r *ProviderRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.providers))
+	for name := range r.providers {
+		names = append(names, name)
+	}
+	return names
+}
@@ -0,0 +1,372 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	// ErrRefreshTokenInvalid is returned for unknown, expired or revoked refresh tokens
+	ErrRefreshTokenInvalid = errors.New("invalid refresh token")
+	// ErrRefreshTokenReused is returned when an already rotated refresh token is
+	// presented again; the whole token family is revoked
+	ErrRefreshTokenReused = errors.New("refresh token reused")
+)
+
+// RefreshSession is what a refresh token stands for. Every token obtained by
+// rotating the one issued at login belongs to the same family.
+type RefreshSession struct {
+	UserID    string    `json:"userId"`
+	Email     string    `json:"email"`
+	Roles     []string  `json:"roles,omitempty"`
+	Scopes    []string  `json:"scopes,omitempty"`
+	TenantID  string    `json:"tenantId,omitempty"`
+	Family    string    `json:"family"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// RefreshStore keeps refresh tokens by ID until they expire or are used
+type RefreshStore interface {
+	// Save stores a refresh token, clearing any used mark it has
+	Save(ctx context.Context, id string, session *RefreshSession) error
+
+	// Consume removes a token and marks it used. For a token that was already
+	// used it returns ErrRefreshTokenReused with the token's session.
+	Consume(ctx context.Context, id string) (*RefreshSession, error)
+
+	// RevokeFamily stops every token of a family from being used for ttl
+	RevokeFamily(ctx context.Context, family string, ttl time.Duration) error
+
+	// FamilyRevoked reports whether a family was revoked
+	FamilyRevoked(ctx context.Context, family string) (bool, error)
+}
+
+// RedisRefreshStore shares refresh tokens across gateway instances
+type RedisRefreshStore struct {
+	client *redis.Client
+}
+
+// NewRedisRefreshStore creates a Redis-backed refresh token store
+func NewRedisRefreshStore(client *redis.Client) *RedisRefreshStore {
+	return &RedisRefreshStore{client: client}
+}
+
+// Save stores the token as JSON until it expires
+func (s *RedisRefreshStore) Save(ctx context.Context, id string, session *RefreshSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	_, err = s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, "refresh_token:"+id, data, time.Until(session.ExpiresAt))
+		pipe.Del(ctx, "refresh_token_used:"+id)
+		return nil
+	})
+	return err
+}
+
+// Consume deletes the token and leaves a used mark until it would have expired
+func (s *RedisRefreshStore) Consume(ctx context.Context, id string) (*RefreshSession, error) {
+	data, err := s.client.GetDel(ctx, "refresh_token:"+id).Bytes()
+	if err == redis.Nil {
+		used, err := s.client.Get(ctx, "refresh_token_used:"+id).Bytes()
+		if err == redis.Nil {
+			return nil, ErrRefreshTokenInvalid
+		}
+		if err != nil {
+			return nil, err
+		}
+		var session RefreshSession
+		if err := json.Unmarshal(used, &session); err != nil {
+			return nil, err
+		}
+		return &session, ErrRefreshTokenReused
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var session RefreshSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	if ttl := time.Until(session.ExpiresAt); ttl > 0 {
+		if err := s.client.Set(ctx, "refresh_token_used:"+id, data, ttl).Err(); err != nil {
+			slog.WarnContext(ctx, "failed to mark refresh token used", "error", err)
+		}
+	}
+	return &session, nil
+}
+
+// RevokeFamily sets the family's revocation mark
+func (s *RedisRefreshStore) RevokeFamily(ctx context.Context, family string, ttl time.Duration) error {
+	return s.client.Set(ctx, "refresh_family_revoked:"+family, 1, ttl).Err()
+}
+
+// FamilyRevoked checks for the family's revocation mark
+func (s *RedisRefreshStore) FamilyRevoked(ctx context.Context, family string) (bool, error) {
+	count, err := s.client.Exists(ctx, "refresh_family_revoked:"+family).Result()
+	return count > 0, err
+}
+
+// MemoryRefreshStore keeps refresh tokens in process memory (single instance only)
+type MemoryRefreshStore struct {
+	mu       sync.Mutex
+	tokens   map[string]*RefreshSession
+	used     map[string]*RefreshSession
+	families map[string]time.Time // Revoked family -> end of revocation
+}
+
+// NewMemoryRefreshStore creates an in-memory refresh token store
+func NewMemoryRefreshStore() *MemoryRefreshStore {
+	return &MemoryRefreshStore{
+		tokens:   make(map[string]*RefreshSession),
+		used:     make(map[string]*RefreshSession),
+		families: make(map[string]time.Time),
+	}
+}
+
+// Save stores the token, dropping expired entries first
+func (s *MemoryRefreshStore) Save(_ context.Context, id string, session *RefreshSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for _, entries := range []map[string]*RefreshSession{s.tokens, s.used} {
+		for key, entry := range entries {
+			if now.After(entry.ExpiresAt) {
+				delete(entries, key)
+			}
+		}
+	}
+	for family, until := range s.families {
+		if now.After(until) {
+			delete(s.families, family)
+		}
+	}
+
+	s.tokens[id] = session
+	delete(s.used, id)
+	return nil
+}
+
+// Consume removes the token and remembers it as used
+func (s *MemoryRefreshStore) Consume(_ context.Context, id string) (*RefreshSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if session, ok := s.used[id]; ok && now.Before(session.ExpiresAt) {
+		return session, ErrRefreshTokenReused
+	}
+	session, ok := s.tokens[id]
+	if !ok || now.After(session.ExpiresAt) {
+		return nil, ErrRefreshTokenInvalid
+	}
+	delete(s.tokens, id)
+	s.used[id] = session
+	return session, nil
+}
+
+// RevokeFamily marks the family revoked for ttl
+func (s *MemoryRefreshStore) RevokeFamily(_ context.Context, family string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.families[family] = time.Now().Add(ttl)
+	return nil
+}
+
+// FamilyRevoked reports whether the family's revocation is still in effect
+func (s *MemoryRefreshStore) FamilyRevoked(_ context.Context, family string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	until, ok := s.families[family]
+	return ok && time.Now().Before(until), nil
+}
+
+// accessTokenClaims are the claims of access tokens issued on refresh, in the
+// format of User Service tokens
+type accessTokenClaims struct {
+	Subject   string   `json:"sub"`
+	UserID    string   `json:"userId"`
+	Email     string   `json:"email,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	IssuedAt  int64    `json:"iat"`
+	ExpiresAt int64    `json:"exp"`
+	Scope     string   `json:"scope,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	TenantID  string   `json:"tenant_id,omitempty"`
+}
+
+// RefreshTokens issues opaque refresh tokens and exchanges them for new access
+// tokens. Tokens are single use: every refresh rotates the refresh token, and
+// presenting a rotated one again revokes its whole family, since either the
+// client or an attacker holds a stolen copy.
+//
+// The User Service contract has no refresh RPC, so access tokens are signed
+// here with the JWT secret shared with the User Service.
+type RefreshTokens struct {
+	hmacSecret
+	store  RefreshStore
+	issuer string
+	ttl    time.Duration
+}
+
+// NewRefreshTokens creates a refresh token issuer; issuer is the optional "iss"
+// of issued access tokens
+func NewRefreshTokens(store RefreshStore, secret, issuer string, ttl time.Duration) *RefreshTokens {
+	if ttl == 0 {
+		ttl = 7 * 24 * time.Hour
+	}
+
+	return &RefreshTokens{
+		hmacSecret: hmacSecret{current: []byte(secret)},
+		store:      store,
+		issuer:     issuer,
+		ttl:        ttl,
+	}
+}
+
+// TTL returns how long a refresh token is valid
+func (t *RefreshTokens) TTL() time.Duration {
+	return t.ttl
+}
+
+// Issue creates the first refresh token of a new family, at login
+func (t *RefreshTokens) Issue(ctx context.Context, principal *Principal) (string, error) {
+	family, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	return t.save(ctx, &RefreshSession{
+		UserID:   principal.UserID,
+		Email:    principal.Email,
+		Roles:    principal.Roles,
+		Scopes:   principal.Scopes,
+		TenantID: principal.TenantID,
+		Family:   family,
+	})
+}
+
+// Rotate consumes a refresh token and returns its session with the refresh
+// token replacing it. verify vets the user before the new token is issued;
+// when it fails with ErrInvalidCredential the family is revoked, on other
+// errors the old token stays usable.
+func (t *RefreshTokens) Rotate(ctx context.Context, token string, verify func(*RefreshSession) error) (*RefreshSession, string, error) {
+	id := SessionID(token)
+	session, err := t.store.Consume(ctx, id)
+	if errors.Is(err, ErrRefreshTokenReused) {
+		slog.WarnContext(ctx, "refresh token reused, revoking token family", "user_id", session.UserID)
+		t.revoke(ctx, session.Family)
+		return nil, "", err
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	revoked, err := t.store.FamilyRevoked(ctx, session.Family)
+	if err != nil {
+		t.restore(ctx, id, session)
+		return nil, "", err
+	}
+	if revoked {
+		return nil, "", ErrRefreshTokenInvalid
+	}
+
+	if err := verify(session); err != nil {
+		if errors.Is(err, ErrInvalidCredential) {
+			t.revoke(ctx, session.Family)
+		} else {
+			t.restore(ctx, id, session)
+		}
+		return nil, "", err
+	}
+
+	next, err := t.save(ctx, &RefreshSession{
+		UserID:   session.UserID,
+		Email:    session.Email,
+		Roles:    session.Roles,
+		Scopes:   session.Scopes,
+		TenantID: session.TenantID,
+		Family:   session.Family,
+	})
+	if err != nil {
+		t.restore(ctx, id, session)
+		return nil, "", err
+	}
+	return session, next, nil
+}
+
+// AccessToken signs a new access token for the session's user
+func (t *RefreshTokens) AccessToken(session *RefreshSession, lifetime time.Duration) (string, error) {
+	return signAccessToken(&Principal{
+		UserID:   session.UserID,
+		Email:    session.Email,
+		Roles:    session.Roles,
+		Scopes:   session.Scopes,
+		TenantID: session.TenantID,
+	}, t.signingSecret(), t.issuer, lifetime)
+}
+
+// signAccessToken signs an access token for a principal in the format of
+// User Service tokens
+func signAccessToken(principal *Principal, secret []byte, issuer string, lifetime time.Duration) (string, error) {
+	now := time.Now()
+	return signHS256(accessTokenClaims{
+		Subject:   principal.UserID,
+		UserID:    principal.UserID,
+		Email:     principal.Email,
+		Issuer:    issuer,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(lifetime).Unix(),
+		Scope:     strings.Join(principal.Scopes, " "),
+		Roles:     principal.Roles,
+		TenantID:  principal.TenantID,
+	}, secret)
+}
+
+// save stores a new token for the session and returns it
+func (t *RefreshTokens) save(ctx context.Context, session *RefreshSession) (string, error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	session.ExpiresAt = time.Now().Add(t.ttl)
+	if err := t.store.Save(ctx, SessionID(token), session); err != nil {
+		return "", fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	return token, nil
+}
+
+// restore puts back a consumed token after a failure that isn't the client's
+func (t *RefreshTokens) restore(ctx context.Context, id string, session *RefreshSession) {
+	if err := t.store.Save(ctx, id, session); err != nil {
+		slog.WarnContext(ctx, "failed to restore refresh token", "error", err)
+	}
+}
+
+// revoke revokes a family for as long as any of its tokens could be valid
+func (t *RefreshTokens) revoke(ctx context.Context, family string) {
+	if err := t.store.RevokeFamily(ctx, family, t.ttl); err != nil {
+		slog.WarnContext(ctx, "failed to revoke refresh token family", "error", err)
+	}
+}
+
+// randomToken returns 32 random bytes, base64url-encoded
+func randomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRefreshTokens_Rotate(t *testing.T) {
+	const secret = "test-secret-with-at-least-32-bytes!!"
+	ctx := context.Background()
+	tokens := NewRefreshTokens(NewMemoryRefreshStore(), secret, "", time.Hour)
+	allow := func(*RefreshSession) error { return nil }
+
+	first, err := tokens.Issue(ctx, &Principal{UserID: "user-1", Email: "user@example.com", Roles: []string{"trader"}})
+	if err != nil {
+		t.Fatalf("failed to issue refresh token: %v", err)
+	}
+
+	session, second, err := tokens.Rotate(ctx, first, allow)
+	if err != nil || session.UserID != "user-1" || second == first {
+		t.Fatalf("expected rotation, got %v %q (err: %v)", session, second, err)
+	}
+
+	// Access tokens issued on refresh validate like User Service tokens
+	accessToken, err := tokens.AccessToken(session, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to sign access token: %v", err)
+	}
+	principal, err := NewJWTProvider(secret, "").Validate(ctx, Credential{Type: CredentialBearer, Value: accessToken})
+	if err != nil || principal.UserID != "user-1" || principal.Email != "user@example.com" || len(principal.Roles) != 1 {
+		t.Errorf("expected access token for user-1, got %v (err: %v)", principal, err)
+	}
+
+	// A failure that isn't the client's keeps the token usable
+	unavailable := errors.New("user service unavailable")
+	if _, _, err := tokens.Rotate(ctx, second, func(*RefreshSession) error { return unavailable }); !errors.Is(err, unavailable) {
+		t.Fatalf("expected verify error, got %v", err)
+	}
+	_, third, err := tokens.Rotate(ctx, second, allow)
+	if err != nil {
+		t.Fatalf("expected token to survive a transient failure: %v", err)
+	}
+
+	// Reusing a rotated token revokes the whole family
+	if _, _, err := tokens.Rotate(ctx, first, allow); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Errorf("expected reuse to be detected, got %v", err)
+	}
+	if _, _, err := tokens.Rotate(ctx, third, allow); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Errorf("expected the family to be revoked, got %v", err)
+	}
+
+	// Deactivated users can't refresh
+	other, _ := tokens.Issue(ctx, &Principal{UserID: "user-2"})
+	inactive := func(*RefreshSession) error { return ErrInvalidCredential }
+	if _, _, err := tokens.Rotate(ctx, other, inactive); !errors.Is(err, ErrInvalidCredential) {
+		t.Errorf("expected inactive user to be rejected, got %v", err)
+	}
+	if _, _, err := tokens.Rotate(ctx, "unknown", allow); !errors.Is(err, ErrRefreshTokenInvalid) {
+		t.Errorf("expected unknown token to be rejected, got %v", err)
+	}
+}
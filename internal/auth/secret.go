@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// hmacSecret is the HS256 secret shared with the User Service. It can be
+// rotated at runtime; the replaced secret keeps verifying tokens for a grace
+// period, so tokens signed just before a rotation don't all fail at once.
+type hmacSecret struct {
+	mu            sync.RWMutex
+	current       []byte
+	previous      []byte
+	previousUntil time.Time
+}
+
+// RotateSecret signs with secret from now on. Tokens signed with the
+// replaced secret are accepted for grace.
+func (s *hmacSecret) RotateSecret(secret string, grace time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if string(s.current) == secret {
+		return
+	}
+	s.previous, s.previousUntil = s.current, time.Now().Add(grace)
+	s.current = []byte(secret)
+}
+
+// signingSecret returns the secret new tokens are signed with
+func (s *hmacSecret) signingSecret() []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// verifyHS256 verifies a token signed with the current secret or, during the
+// grace period of a rotation, the previous one
+func (s *hmacSecret) verifyHS256(parsed *parsedJWT) error {
+	s.mu.RLock()
+	current, previous := s.current, s.previous
+	if time.Now().After(s.previousUntil) {
+		previous = nil
+	}
+	s.mu.RUnlock()
+
+	err := parsed.verifyHS256(current)
+	if err != nil && previous != nil && parsed.verifyHS256(previous) == nil {
+		return nil
+	}
+	return err
+}
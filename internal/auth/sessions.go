@@ -0,0 +1,256 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Session limit modes
+const (
+	SessionLimitReject      = "reject"       // Refuse new logins once the limit is reached
+	SessionLimitEvictOldest = "evict_oldest" // Revoke the oldest session to make room
+)
+
+// ErrSessionLimitReached is returned when a login would exceed the session limit in reject mode
+var ErrSessionLimitReached = errors.New("concurrent session limit reached")
+
+// ErrSessionRevoked is returned for tokens whose session was evicted by a newer login
+var ErrSessionRevoked = errors.New("session was revoked by a newer login")
+
+// SessionStore is the registry of open sessions per user
+type SessionStore interface {
+	// Open registers a session that lasts until expires. When the user already
+	// has max live sessions it either returns ErrSessionLimitReached or, with
+	// evict, revokes the oldest sessions and returns their IDs.
+	Open(ctx context.Context, userID, sessionID string, expires time.Time, max int, evict bool) ([]string, error)
+
+	// Revoked reports whether the session was evicted
+	Revoked(ctx context.Context, sessionID string) (bool, error)
+}
+
+// openSessionScript drops expired sessions and registers a new one atomically so
+// concurrent logins on different replicas can't both slip under the limit.
+// Sessions are scored by expiry; with a fixed token lifetime that is login order.
+var openSessionScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local expires = tonumber(ARGV[2])
+local max = tonumber(ARGV[4])
+
+redis.call('ZREMRANGEBYSCORE', KEYS[1], '-inf', now)
+local count = redis.call('ZCARD', KEYS[1])
+local evicted = {}
+if count >= max then
+  if ARGV[5] ~= '1' then
+    return {0}
+  end
+  evicted = redis.call('ZRANGE', KEYS[1], 0, count - max, 'WITHSCORES')
+  for i = 1, #evicted, 2 do
+    redis.call('ZREM', KEYS[1], evicted[i])
+  end
+end
+
+redis.call('ZADD', KEYS[1], expires, ARGV[3])
+local newest = redis.call('ZRANGE', KEYS[1], -1, -1, 'WITHSCORES')
+redis.call('PEXPIREAT', KEYS[1], newest[2])
+
+local result = {1}
+for _, value in ipairs(evicted) do
+  table.insert(result, value)
+end
+return result
+`)
+
+// RedisSessionStore shares the session registry across gateway instances
+type RedisSessionStore struct {
+	client *redis.Client
+}
+
+// NewRedisSessionStore creates a Redis-backed session store
+func NewRedisSessionStore(client *redis.Client) *RedisSessionStore {
+	return &RedisSessionStore{client: client}
+}
+
+// Open registers the session in the user's sorted set and marks evicted sessions revoked
+func (s *RedisSessionStore) Open(ctx context.Context, userID, sessionID string, expires time.Time, max int, evict bool) ([]string, error) {
+	evictFlag := "0"
+	if evict {
+		evictFlag = "1"
+	}
+
+	now := time.Now()
+	values, err := openSessionScript.Run(ctx, s.client, []string{"sessions:" + userID},
+		now.UnixMilli(), expires.UnixMilli(), sessionID, max, evictFlag).Slice()
+	if err != nil {
+		return nil, err
+	}
+	if len(values) == 0 || len(values)%2 != 1 {
+		return nil, fmt.Errorf("unexpected session script result %v", values)
+	}
+	if opened, _ := values[0].(int64); opened != 1 {
+		return nil, ErrSessionLimitReached
+	}
+
+	var evicted []string
+	pipe := s.client.Pipeline()
+	for i := 1; i < len(values); i += 2 {
+		id, _ := values[i].(string)
+		score, _ := values[i+1].(string)
+		expiresAt, err := strconv.ParseFloat(score, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid session expiry %q: %w", score, err)
+		}
+
+		ttl := time.UnixMilli(int64(expiresAt)).Sub(now)
+		if ttl <= 0 {
+			continue
+		}
+		pipe.Set(ctx, "session_revoked:"+id, 1, ttl)
+		evicted = append(evicted, id)
+	}
+	if len(evicted) > 0 {
+		if _, err := pipe.Exec(ctx); err != nil {
+			return evicted, fmt.Errorf("failed to revoke evicted sessions: %w", err)
+		}
+	}
+
+	return evicted, nil
+}
+
+// Revoked checks for the session's revocation mark
+func (s *RedisSessionStore) Revoked(ctx context.Context, sessionID string) (bool, error) {
+	count, err := s.client.Exists(ctx, "session_revoked:"+sessionID).Result()
+	return count > 0, err
+}
+
+// memorySession is an open session held in process memory
+type memorySession struct {
+	id      string
+	expires time.Time
+}
+
+// MemorySessionStore keeps the session registry in process memory (single instance only)
+type MemorySessionStore struct {
+	mu       sync.Mutex
+	sessions map[string][]memorySession // userID -> open sessions, oldest first
+	revoked  map[string]time.Time       // sessionID -> token expiry
+}
+
+// NewMemorySessionStore creates an in-memory session store
+func NewMemorySessionStore() *MemorySessionStore {
+	return &MemorySessionStore{
+		sessions: make(map[string][]memorySession),
+		revoked:  make(map[string]time.Time),
+	}
+}
+
+// Open registers the session, dropping expired ones first
+func (s *MemorySessionStore) Open(_ context.Context, userID, sessionID string, expires time.Time, max int, evict bool) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, expiry := range s.revoked {
+		if now.After(expiry) {
+			delete(s.revoked, id)
+		}
+	}
+
+	var live []memorySession
+	for _, session := range s.sessions[userID] {
+		if session.expires.After(now) {
+			live = append(live, session)
+		}
+	}
+
+	var evicted []string
+	if len(live) >= max {
+		if !evict {
+			s.sessions[userID] = live
+			return nil, ErrSessionLimitReached
+		}
+		excess := len(live) - max + 1
+		for _, session := range live[:excess] {
+			s.revoked[session.id] = session.expires
+			evicted = append(evicted, session.id)
+		}
+		live = live[excess:]
+	}
+
+	live = append(live, memorySession{id: sessionID, expires: expires})
+	sort.SliceStable(live, func(i, j int) bool { return live[i].expires.Before(live[j].expires) })
+	s.sessions[userID] = live
+
+	return evicted, nil
+}
+
+// Revoked reports whether the session was evicted and its token is still unexpired
+func (s *MemorySessionStore) Revoked(_ context.Context, sessionID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiry, ok := s.revoked[sessionID]
+	return ok && time.Now().Before(expiry), nil
+}
+
+// SessionLimiter caps the number of concurrent sessions a user may hold
+type SessionLimiter struct {
+	store       SessionStore
+	max         int
+	evictOldest bool
+	users       map[string]bool // Limited users; empty limits everyone
+}
+
+// NewSessionLimiter creates a limiter allowing max sessions per user. mode is
+// SessionLimitReject or SessionLimitEvictOldest; users restricts the limit to
+// specific (e.g. high-risk) accounts.
+func NewSessionLimiter(store SessionStore, max int, mode string, users []string) *SessionLimiter {
+	limiter := &SessionLimiter{
+		store:       store,
+		max:         max,
+		evictOldest: mode == SessionLimitEvictOldest,
+		users:       make(map[string]bool, len(users)),
+	}
+	for _, user := range users {
+		limiter.users[user] = true
+	}
+	return limiter
+}
+
+// Applies reports whether the limit covers userID
+func (l *SessionLimiter) Applies(userID string) bool {
+	return len(l.users) == 0 || l.users[userID]
+}
+
+// Open registers a new session for the token issued to userID and returns the
+// number of older sessions evicted to make room
+func (l *SessionLimiter) Open(ctx context.Context, userID, token string) (int, error) {
+	evicted, err := l.store.Open(ctx, userID, SessionID(token), time.Now().Add(tokenLifetime), l.max, l.evictOldest)
+	return len(evicted), err
+}
+
+// Check returns ErrSessionRevoked when the token's session was evicted
+func (l *SessionLimiter) Check(ctx context.Context, token string) error {
+	revoked, err := l.store.Revoked(ctx, SessionID(token))
+	if err != nil {
+		return err
+	}
+	if revoked {
+		return ErrSessionRevoked
+	}
+	return nil
+}
+
+// SessionID derives the registry ID of a token so raw tokens are never stored
+func SessionID(token string) string {
+	hash := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(hash[:])
+}
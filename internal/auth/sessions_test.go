@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSessionLimiter_Reject(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewSessionLimiter(NewMemorySessionStore(), 2, SessionLimitReject, nil)
+
+	for _, token := range []string{"token-1", "token-2"} {
+		if _, err := limiter.Open(ctx, "user-1", token); err != nil {
+			t.Fatalf("Open(%s) error = %v", token, err)
+		}
+	}
+
+	if _, err := limiter.Open(ctx, "user-1", "token-3"); !errors.Is(err, ErrSessionLimitReached) {
+		t.Fatalf("third login error = %v, want ErrSessionLimitReached", err)
+	}
+	if _, err := limiter.Open(ctx, "user-2", "token-4"); err != nil {
+		t.Errorf("other user login error = %v, want nil", err)
+	}
+	if err := limiter.Check(ctx, "token-1"); err != nil {
+		t.Errorf("Check(token-1) = %v, existing sessions must stay valid", err)
+	}
+}
+
+func TestSessionLimiter_EvictOldest(t *testing.T) {
+	ctx := context.Background()
+	limiter := NewSessionLimiter(NewMemorySessionStore(), 1, SessionLimitEvictOldest, nil)
+
+	if _, err := limiter.Open(ctx, "user-1", "token-1"); err != nil {
+		t.Fatalf("first login error = %v", err)
+	}
+	evicted, err := limiter.Open(ctx, "user-1", "token-2")
+	if err != nil || evicted != 1 {
+		t.Fatalf("second login = (%d, %v), want (1, nil)", evicted, err)
+	}
+
+	if err := limiter.Check(ctx, "token-1"); !errors.Is(err, ErrSessionRevoked) {
+		t.Errorf("Check(token-1) = %v, want ErrSessionRevoked", err)
+	}
+	if err := limiter.Check(ctx, "token-2"); err != nil {
+		t.Errorf("Check(token-2) = %v, want nil", err)
+	}
+}
+
+func TestSessionLimiter_Applies(t *testing.T) {
+	limiter := NewSessionLimiter(NewMemorySessionStore(), 1, SessionLimitReject, []string{"high-risk"})
+
+	if !limiter.Applies("high-risk") {
+		t.Error("limit should apply to listed user")
+	}
+	if limiter.Applies("user-1") {
+		t.Error("limit should not apply to unlisted user")
+	}
+}
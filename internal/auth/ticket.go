@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"hub-api-gateway/internal/httperr"
+	"hub-api-gateway/internal/metrics"
+)
+
+// ticketAudience distinguishes reconnect tickets from regular access tokens,
+// so a ticket can't be replayed as a bearer token and vice versa
+const ticketAudience = "gateway-reconnect"
+
+// ticketClaims are the claims embedded in a reconnect ticket
+type ticketClaims struct {
+	Subject   string   `json:"sub"`
+	Email     string   `json:"email,omitempty"`
+	Audience  string   `json:"aud"`
+	IssuedAt  int64    `json:"iat"`
+	ExpiresAt int64    `json:"exp"`
+	Scope     string   `json:"scope,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	TenantID  string   `json:"tenant_id,omitempty"`
+}
+
+// TicketIssuer issues and validates short-lived signed reconnect tickets.
+//
+// A ticket is requested once a (WebSocket) connection is authenticated and
+// presented when the client reconnects. Tickets are validated locally from the
+// signature alone, so a wave of reconnects after a gateway restart doesn't
+// stampede the User Service or depend on a warm token cache.
+type TicketIssuer struct {
+	secret  []byte
+	ttl     time.Duration
+	metrics *metrics.Metrics
+}
+
+// NewTicketIssuer creates a reconnect ticket issuer
+func NewTicketIssuer(secret string, ttl time.Duration, m *metrics.Metrics) *TicketIssuer {
+	if ttl == 0 {
+		ttl = 2 * time.Minute
+	}
+
+	return &TicketIssuer{
+		secret:  []byte(secret),
+		ttl:     ttl,
+		metrics: m,
+	}
+}
+
+// Issue creates a ticket for an authenticated principal
+func (t *TicketIssuer) Issue(principal *Principal) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(t.ttl)
+
+	ticket, err := signHS256(ticketClaims{
+		Subject:   principal.UserID,
+		Email:     principal.Email,
+		Audience:  ticketAudience,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+		Scope:     strings.Join(principal.Scopes, " "),
+		Roles:     principal.Roles,
+		TenantID:  principal.TenantID,
+	}, t.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+
+	if t.metrics != nil {
+		t.metrics.RecordReconnectTicket(metrics.TicketIssued)
+	}
+	return ticket, expiresAt, nil
+}
+
+// Name returns the provider name
+func (t *TicketIssuer) Name() string {
+	return ProviderTicket
+}
+
+// Validate verifies a reconnect ticket without contacting any backend
+func (t *TicketIssuer) Validate(_ context.Context, credential Credential) (*Principal, error) {
+	principal, err := t.validate(credential)
+
+	if t.metrics != nil {
+		if err != nil {
+			t.metrics.RecordReconnectTicket(metrics.TicketRejected)
+		} else {
+			t.metrics.RecordReconnectTicket(metrics.TicketAccepted)
+		}
+	}
+	return principal, err
+}
+
+// validate checks the ticket signature, audience and expiry
+func (t *TicketIssuer) validate(credential Credential) (*Principal, error) {
+	if credential.Type != CredentialTicket {
+		return nil, fmt.Errorf("%w: ticket provider only accepts reconnect tickets", ErrInvalidCredential)
+	}
+
+	parsed, err := parseJWT(credential.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := parsed.verifyHS256(t.secret); err != nil {
+		return nil, err
+	}
+
+	if !parsed.claims.Audience.Contains(ticketAudience) {
+		return nil, fmt.Errorf("%w: not a reconnect ticket", ErrInvalidCredential)
+	}
+
+	if parsed.claims.ExpiresAt == 0 {
+		return nil, fmt.Errorf("%w: ticket has no expiry", ErrInvalidCredential)
+	}
+
+	if err := parsed.claims.validateTimes(time.Now(), jwtClockSkew); err != nil {
+		return nil, err
+	}
+
+	return parsed.claims.toPrincipal(t.Name())
+}
+
+// TicketResponse is returned by the reconnect ticket endpoint
+type TicketResponse struct {
+	Ticket    string    `json:"ticket"`
+	ExpiresAt time.Time `json:"expiresAt"`
+	ExpiresIn int       `json:"expiresIn"` // seconds
+}
+
+// TicketHandler issues reconnect tickets to authenticated clients
+type TicketHandler struct {
+	issuer    *TicketIssuer
+	principal func(r *http.Request) (*Principal, bool)
+}
+
+// NewTicketHandler creates a ticket handler; principal resolves the caller
+// authenticated by the auth middleware
+func NewTicketHandler(issuer *TicketIssuer, principal func(r *http.Request) (*Principal, bool)) *TicketHandler {
+	return &TicketHandler{
+		issuer:    issuer,
+		principal: principal,
+	}
+}
+
+// Handle issues a ticket for the authenticated caller
+func (h *TicketHandler) Handle(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	principal, ok := h.principal(r)
+	if !ok {
+		httperr.Send(w, r, http.StatusUnauthorized, "AUTH_TOKEN_MISSING", "Authentication required")
+		return
+	}
+	// A ticket can't renew itself, or a revoked session could reconnect forever
+	if principal.Provider == ProviderTicket {
+		httperr.Send(w, r, http.StatusUnauthorized, "AUTH_TOKEN_MISSING", "A bearer token or session is required")
+		return
+	}
+
+	ticket, expiresAt, err := h.issuer.Issue(principal)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to issue reconnect ticket", "user_id", principal.UserID, "error", err)
+		httperr.Send(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to issue reconnect ticket")
+		return
+	}
+
+	slog.InfoContext(r.Context(), "issued reconnect ticket", "user_id", principal.UserID)
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(TicketResponse{
+		Ticket:    ticket,
+		ExpiresAt: expiresAt,
+		ExpiresIn: int(time.Until(expiresAt).Seconds()),
+	})
+}
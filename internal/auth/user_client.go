@@ -3,33 +3,46 @@ package auth
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 
 	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/egress"
+	"hub-api-gateway/internal/logging"
 
 	authpb "github.com/RodriguesYan/hub-proto-contracts/auth"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
 // UserServiceClient wraps the gRPC client for User Service
 type UserServiceClient struct {
 	conn   *grpc.ClientConn
 	client authpb.AuthServiceClient
+	users  authpb.UserServiceClient
 	config config.ServiceConfig
 }
 
 // NewUserServiceClient creates a new User Service gRPC client
-func NewUserServiceClient(cfg *config.Config) (*UserServiceClient, error) {
+func NewUserServiceClient(cfg *config.Config, policy *egress.Policy) (*UserServiceClient, error) {
 	serviceConfig := cfg.Services["user-service"]
 
-	log.Printf("Connecting to User Service at %s...", serviceConfig.Address)
+	slog.Info("connecting to user service", "address", serviceConfig.Address)
 
-	// Create gRPC connection (non-blocking by default with NewClient)
-	conn, err := grpc.NewClient(
-		serviceConfig.Address,
+	opts := []grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+		grpc.WithChainUnaryInterceptor(logging.UnaryClientInterceptor()),
+	}
+	if policy.Enabled() {
+		if err := policy.CheckTarget(context.Background(), serviceConfig.Address); err != nil {
+			return nil, fmt.Errorf("user service: %w", err)
+		}
+		opts = append(opts, policy.DialOption(serviceConfig.Address))
+	}
+
+	// Create gRPC connection (non-blocking by default with NewClient)
+	conn, err := grpc.NewClient(serviceConfig.Address, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect to user service: %w", err)
 	}
@@ -39,11 +52,12 @@ func NewUserServiceClient(cfg *config.Config) (*UserServiceClient, error) {
 
 	client := authpb.NewAuthServiceClient(conn)
 
-	log.Printf("✅ Connected to User Service at %s", serviceConfig.Address)
+	slog.Info("connected to user service", "address", serviceConfig.Address)
 
 	return &UserServiceClient{
 		conn:   conn,
 		client: client,
+		users:  authpb.NewUserServiceClient(conn),
 		config: serviceConfig,
 	}, nil
 }
@@ -59,20 +73,20 @@ func (c *UserServiceClient) Login(ctx context.Context, email, password string) (
 		Password: password,
 	}
 
-	log.Printf("Calling User Service Login for email: %s", email)
+	slog.DebugContext(ctx, "calling user service login", "email", email)
 
 	resp, err := c.client.Login(ctx, req)
 	if err != nil {
-		log.Printf("❌ Login failed: %v", err)
+		slog.WarnContext(ctx, "login failed", "email", email, "error", err)
 		return nil, fmt.Errorf("login failed: %w", err)
 	}
 
 	if !resp.ApiResponse.Success {
-		log.Printf("❌ Login failed: %s", resp.ApiResponse.Message)
+		slog.WarnContext(ctx, "login failed", "email", email, "reason", resp.ApiResponse.Message)
 		return resp, fmt.Errorf("login failed: %s", resp.ApiResponse.Message)
 	}
 
-	log.Printf("✅ Login successful for email: %s", email)
+	slog.DebugContext(ctx, "user service login succeeded", "email", email)
 	return resp, nil
 }
 
@@ -98,10 +112,31 @@ func (c *UserServiceClient) ValidateToken(ctx context.Context, token string) (*a
 	return resp, nil
 }
 
+// CheckUserActive returns ErrInvalidCredential unless the user exists and is
+// active. User Services without the profile RPC can't tell, so users pass.
+func (c *UserServiceClient) CheckUserActive(ctx context.Context, userID string) error {
+	ctx, cancel := context.WithTimeout(ctx, c.config.Timeout)
+	defer cancel()
+
+	resp, err := c.users.GetUserProfile(ctx, &authpb.GetUserProfileRequest{UserId: userID})
+	if status.Code(err) == codes.Unimplemented {
+		slog.DebugContext(ctx, "user service has no profile RPC, skipping user status check")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("user profile lookup failed: %w", err)
+	}
+
+	if !resp.Success || !resp.IsActive {
+		return fmt.Errorf("%w: user %s is not active", ErrInvalidCredential, userID)
+	}
+	return nil
+}
+
 // Close closes the gRPC connection
 func (c *UserServiceClient) Close() error {
 	if c.conn != nil {
-		log.Println("Closing User Service gRPC connection...")
+		slog.Info("closing user service connection")
 		return c.conn.Close()
 	}
 	return nil
@@ -111,7 +146,7 @@ func (c *UserServiceClient) Close() error {
 func (c *UserServiceClient) Ping(_ context.Context) error {
 	// Check the connection state
 	state := c.conn.GetState()
-	log.Printf("User Service connection state: %v", state)
+	slog.Debug("user service connection state", "state", state.String())
 
 	return nil
 }
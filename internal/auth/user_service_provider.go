@@ -0,0 +1,57 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+)
+
+// UserServiceProvider validates bearer tokens by calling the User Service ValidateToken RPC
+type UserServiceProvider struct {
+	client *UserServiceClient
+}
+
+// NewUserServiceProvider creates a provider backed by the User Service gRPC client
+func NewUserServiceProvider(client *UserServiceClient) *UserServiceProvider {
+	return &UserServiceProvider{client: client}
+}
+
+// Name returns the provider name
+func (p *UserServiceProvider) Name() string {
+	return ProviderUserService
+}
+
+// Validate validates the token with the User Service
+func (p *UserServiceProvider) Validate(ctx context.Context, credential Credential) (*Principal, error) {
+	if credential.Type != CredentialBearer {
+		return nil, fmt.Errorf("%w: user service only accepts bearer tokens", ErrInvalidCredential)
+	}
+
+	resp, err := p.client.ValidateToken(ctx, credential.Value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate token with user service: %w", err)
+	}
+
+	if !resp.ApiResponse.Success {
+		return nil, fmt.Errorf("token validation failed: %s", resp.ApiResponse.Message)
+	}
+
+	if resp.UserInfo == nil {
+		return nil, fmt.Errorf("user info not found in response")
+	}
+
+	if resp.UserInfo.UserId == "" || resp.UserInfo.Email == "" {
+		return nil, fmt.Errorf("invalid user context from service")
+	}
+
+	// ValidateToken doesn't return grants; the token it just verified carries them
+	roles, scopes, tenantID := unverifiedGrants(credential.Value)
+
+	return &Principal{
+		UserID:   resp.UserInfo.UserId,
+		Email:    resp.UserInfo.Email,
+		Roles:    roles,
+		Scopes:   scopes,
+		Provider: p.Name(),
+		TenantID: tenantID,
+	}, nil
+}
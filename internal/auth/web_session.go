@@ -0,0 +1,219 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Cookies and header of cookie-authenticated web sessions. The session cookie
+// is httpOnly; the CSRF cookie is readable by the web app, which echoes it in
+// the CSRF header of mutating requests (double-submit).
+const (
+	SessionCookie = "session"
+	CSRFCookie    = "csrf_token"
+	CSRFHeader    = "X-CSRF-Token"
+)
+
+var (
+	// ErrWebSessionInvalid is returned for session cookies without a live session
+	ErrWebSessionInvalid = errors.New("session expired or invalid")
+	// ErrCSRFTokenInvalid is returned for cookie-authenticated mutating
+	// requests whose CSRF header doesn't match the session
+	ErrCSRFTokenInvalid = errors.New("missing or invalid CSRF token")
+)
+
+// WebSession is what a session cookie stands for: the access token, kept
+// server-side so the browser never sees it, and the session's CSRF token
+type WebSession struct {
+	Token     string    `json:"token"`
+	CSRFToken string    `json:"csrfToken"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// WebSessionStore keeps web sessions by ID until they expire or are closed
+type WebSessionStore interface {
+	Save(ctx context.Context, id string, session *WebSession) error
+
+	// Get returns ErrWebSessionInvalid for unknown or expired sessions
+	Get(ctx context.Context, id string) (*WebSession, error)
+
+	Delete(ctx context.Context, id string) error
+}
+
+// RedisWebSessionStore shares web sessions across gateway instances
+type RedisWebSessionStore struct {
+	client *redis.Client
+}
+
+// NewRedisWebSessionStore creates a Redis-backed web session store
+func NewRedisWebSessionStore(client *redis.Client) *RedisWebSessionStore {
+	return &RedisWebSessionStore{client: client}
+}
+
+// Save stores the session as JSON until it expires
+func (s *RedisWebSessionStore) Save(ctx context.Context, id string, session *WebSession) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, "web_session:"+id, data, time.Until(session.ExpiresAt)).Err()
+}
+
+// Get loads the session
+func (s *RedisWebSessionStore) Get(ctx context.Context, id string) (*WebSession, error) {
+	data, err := s.client.Get(ctx, "web_session:"+id).Bytes()
+	if err == redis.Nil {
+		return nil, ErrWebSessionInvalid
+	}
+	if err != nil {
+		return nil, err
+	}
+	var session WebSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Delete removes the session
+func (s *RedisWebSessionStore) Delete(ctx context.Context, id string) error {
+	return s.client.Del(ctx, "web_session:"+id).Err()
+}
+
+// MemoryWebSessionStore keeps web sessions in process memory (single instance only)
+type MemoryWebSessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*WebSession
+}
+
+// NewMemoryWebSessionStore creates an in-memory web session store
+func NewMemoryWebSessionStore() *MemoryWebSessionStore {
+	return &MemoryWebSessionStore{sessions: make(map[string]*WebSession)}
+}
+
+// Save stores the session, dropping expired sessions first
+func (s *MemoryWebSessionStore) Save(_ context.Context, id string, session *WebSession) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, entry := range s.sessions {
+		if now.After(entry.ExpiresAt) {
+			delete(s.sessions, key)
+		}
+	}
+	s.sessions[id] = session
+	return nil
+}
+
+// Get returns the session while it hasn't expired
+func (s *MemoryWebSessionStore) Get(_ context.Context, id string) (*WebSession, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[id]
+	if !ok || time.Now().After(session.ExpiresAt) {
+		return nil, ErrWebSessionInvalid
+	}
+	return session, nil
+}
+
+// Delete removes the session
+func (s *MemoryWebSessionStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, id)
+	return nil
+}
+
+// WebSessions issues session cookies at login and resolves them back to the
+// access token they stand for
+type WebSessions struct {
+	store    WebSessionStore
+	sameSite http.SameSite
+}
+
+// NewWebSessions creates web sessions whose cookies use the SameSite mode
+// sameSite (lax, strict or none)
+func NewWebSessions(store WebSessionStore, sameSite string) *WebSessions {
+	mode := http.SameSiteLaxMode
+	switch sameSite {
+	case "strict":
+		mode = http.SameSiteStrictMode
+	case "none":
+		mode = http.SameSiteNoneMode
+	}
+	return &WebSessions{store: store, sameSite: mode}
+}
+
+// Open stores a session for token, which is valid for lifetime, sets its
+// cookies and returns its CSRF token
+func (s *WebSessions) Open(ctx context.Context, w http.ResponseWriter, token string, lifetime time.Duration) (string, error) {
+	id, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+	csrfToken, err := randomToken()
+	if err != nil {
+		return "", err
+	}
+
+	session := &WebSession{Token: token, CSRFToken: csrfToken, ExpiresAt: time.Now().Add(lifetime)}
+	if err := s.store.Save(ctx, SessionID(id), session); err != nil {
+		return "", fmt.Errorf("failed to store web session: %w", err)
+	}
+
+	maxAge := int(lifetime.Seconds())
+	http.SetCookie(w, &http.Cookie{Name: SessionCookie, Value: id, Path: "/", MaxAge: maxAge,
+		HttpOnly: true, Secure: true, SameSite: s.sameSite})
+	http.SetCookie(w, &http.Cookie{Name: CSRFCookie, Value: csrfToken, Path: "/", MaxAge: maxAge,
+		Secure: true, SameSite: s.sameSite})
+	return csrfToken, nil
+}
+
+// Token returns the access token of the request's session cookie, or ""
+// when it has none. Requests with other methods than GET, HEAD and OPTIONS
+// must send the session's CSRF token in both the CSRF cookie and header.
+func (s *WebSessions) Token(r *http.Request) (string, error) {
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil || cookie.Value == "" {
+		return "", nil
+	}
+
+	session, err := s.store.Get(r.Context(), SessionID(cookie.Value))
+	if err != nil {
+		return "", err
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		header := r.Header.Get(CSRFHeader)
+		csrfCookie, err := r.Cookie(CSRFCookie)
+		if err != nil || header == "" ||
+			subtle.ConstantTimeCompare([]byte(header), []byte(csrfCookie.Value)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(header), []byte(session.CSRFToken)) != 1 {
+			return "", ErrCSRFTokenInvalid
+		}
+	}
+	return session.Token, nil
+}
+
+// Close ends the request's session and clears its cookies
+func (s *WebSessions) Close(w http.ResponseWriter, r *http.Request) error {
+	http.SetCookie(w, &http.Cookie{Name: SessionCookie, Path: "/", MaxAge: -1, HttpOnly: true, Secure: true, SameSite: s.sameSite})
+	http.SetCookie(w, &http.Cookie{Name: CSRFCookie, Path: "/", MaxAge: -1, Secure: true, SameSite: s.sameSite})
+
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil || cookie.Value == "" {
+		return nil
+	}
+	return s.store.Delete(r.Context(), SessionID(cookie.Value))
+}
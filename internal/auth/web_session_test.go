@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWebSessions_CSRFDoubleSubmit(t *testing.T) {
+	sessions := NewWebSessions(NewMemoryWebSessionStore(), "strict")
+
+	w := httptest.NewRecorder()
+	csrfToken, err := sessions.Open(context.Background(), w, "access-token", time.Minute)
+	if err != nil {
+		t.Fatalf("failed to open session: %v", err)
+	}
+	cookies := w.Result().Cookies()
+	for _, cookie := range cookies {
+		if !cookie.Secure || cookie.SameSite != http.SameSiteStrictMode || cookie.HttpOnly != (cookie.Name == SessionCookie) {
+			t.Errorf("unexpected cookie attributes: %+v", cookie)
+		}
+	}
+
+	request := func(method, csrfHeader string) (string, error) {
+		r := httptest.NewRequest(method, "/api/v1/orders", nil)
+		for _, cookie := range cookies {
+			r.AddCookie(cookie)
+		}
+		if csrfHeader != "" {
+			r.Header.Set(CSRFHeader, csrfHeader)
+		}
+		return sessions.Token(r)
+	}
+
+	if token, err := request(http.MethodGet, ""); err != nil || token != "access-token" {
+		t.Errorf("GET resolved to %q, error %v", token, err)
+	}
+	if token, err := request(http.MethodPost, csrfToken); err != nil || token != "access-token" {
+		t.Errorf("POST with CSRF header resolved to %q, error %v", token, err)
+	}
+	for _, header := range []string{"", "forged"} {
+		if _, err := request(http.MethodPost, header); !errors.Is(err, ErrCSRFTokenInvalid) {
+			t.Errorf("POST with CSRF header %q: expected ErrCSRFTokenInvalid, got %v", header, err)
+		}
+	}
+
+	// Logging out ends the session
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/auth/logout", nil)
+	for _, cookie := range cookies {
+		r.AddCookie(cookie)
+	}
+	if err := sessions.Close(httptest.NewRecorder(), r); err != nil {
+		t.Fatalf("failed to close session: %v", err)
+	}
+	if _, err := request(http.MethodGet, ""); !errors.Is(err, ErrWebSessionInvalid) {
+		t.Errorf("expected ErrWebSessionInvalid after logout, got %v", err)
+	}
+}
@@ -0,0 +1,202 @@
+// Package bulkhead caps the requests in flight per backend service and per
+// client, so a stampede (e.g. every user refreshing at market open) can't tie
+// up the monolith or let one client hog the gateway. Requests over a limit
+// wait briefly for a slot, then are shed with 503 and Retry-After.
+package bulkhead
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"hub-api-gateway/internal/httperr"
+	"hub-api-gateway/internal/middleware"
+	"hub-api-gateway/internal/router"
+)
+
+// retryAfter is suggested to shed clients; slots free up as requests finish
+const retryAfter = time.Second
+
+// Limits rejected requests are reported under
+const (
+	LimitService = "service"
+	LimitClient  = "client"
+)
+
+// Limits are the maximum concurrent requests; zero disables a limit
+type Limits struct {
+	Service  int            // Per backend service
+	Services map[string]int // Per-service overrides of Service
+	Client   int            // Per authenticated user, or per IP for anonymous requests
+}
+
+// compartment is a semaphore shared by the requests of one service or client
+type compartment struct {
+	slots chan struct{}
+	users int // Requests holding or waiting for a slot; empty compartments are dropped
+}
+
+// Bulkhead tracks in-flight requests per service and per client
+type Bulkhead struct {
+	limits            Limits
+	queueTimeout      time.Duration
+	trustForwardedFor bool
+	onReject          func(limit, name string)
+
+	mu           sync.Mutex
+	compartments map[string]*compartment
+}
+
+// New creates a bulkhead; requests over a limit wait up to queueTimeout
+func New(limits Limits, queueTimeout time.Duration) *Bulkhead {
+	return &Bulkhead{limits: limits, queueTimeout: queueTimeout, compartments: make(map[string]*compartment)}
+}
+
+// TrustForwardedFor identifies anonymous clients by X-Forwarded-For (behind a trusted proxy only)
+func (b *Bulkhead) TrustForwardedFor(trust bool) {
+	b.trustForwardedFor = trust
+}
+
+// OnReject registers a callback invoked for every shed request, with the
+// limit (LimitService or LimitClient) and the service or client it hit
+func (b *Bulkhead) OnReject(fn func(limit, name string)) {
+	b.onReject = fn
+}
+
+// InFlight returns the requests holding a slot of a service
+func (b *Bulkhead) InFlight(service string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.compartments["service:"+service]; ok {
+		return len(c.slots)
+	}
+	return 0
+}
+
+// Middleware holds a client slot and a slot of every service the route
+// calls while the request runs. It must run after authentication so
+// authenticated callers are limited per user.
+func (b *Bulkhead) Middleware(route *router.Route, next http.Handler) http.Handler {
+	var services []string
+	for _, target := range route.Targets() {
+		if service := target.GetTargetService(); service != "" && !slices.Contains(services, service) {
+			services = append(services, service)
+		}
+	}
+	// A fixed order keeps routes sharing services from waiting on each other
+	slices.Sort(services)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		client := "ip:" + b.clientIP(r)
+		if userContext, ok := middleware.GetUserContext(r.Context()); ok {
+			client = "user:" + userContext.UserID
+		}
+
+		release, ok := b.acquire(r.Context(), "client:"+client, b.limits.Client)
+		if !ok {
+			b.reject(w, r, route, LimitClient, client)
+			return
+		}
+		defer release()
+
+		for _, service := range services {
+			release, ok := b.acquire(r.Context(), "service:"+service, b.serviceLimit(service))
+			if !ok {
+				b.reject(w, r, route, LimitService, service)
+				return
+			}
+			defer release()
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serviceLimit returns the concurrency limit of a service
+func (b *Bulkhead) serviceLimit(service string) int {
+	if limit, ok := b.limits.Services[service]; ok && limit > 0 {
+		return limit
+	}
+	return b.limits.Service
+}
+
+// acquire takes a slot of the compartment, waiting up to the queue timeout,
+// and returns the function releasing it
+func (b *Bulkhead) acquire(ctx context.Context, key string, limit int) (func(), bool) {
+	if limit <= 0 {
+		return func() {}, true
+	}
+
+	b.mu.Lock()
+	c, ok := b.compartments[key]
+	if !ok {
+		c = &compartment{slots: make(chan struct{}, limit)}
+		b.compartments[key] = c
+	}
+	c.users++
+	b.mu.Unlock()
+
+	leave := func() {
+		b.mu.Lock()
+		if c.users--; c.users == 0 {
+			delete(b.compartments, key)
+		}
+		b.mu.Unlock()
+	}
+
+	select {
+	case c.slots <- struct{}{}:
+		return func() { <-c.slots; leave() }, true
+	default:
+	}
+
+	timer := time.NewTimer(b.queueTimeout)
+	defer timer.Stop()
+	select {
+	case c.slots <- struct{}{}:
+		return func() { <-c.slots; leave() }, true
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	leave()
+	return nil, false
+}
+
+// reject sheds a request over a limit
+func (b *Bulkhead) reject(w http.ResponseWriter, r *http.Request, route *router.Route, limit, name string) {
+	slog.WarnContext(r.Context(), "request shed by bulkhead", "route", route.Name, "limit", limit, "name", name)
+	if b.onReject != nil {
+		b.onReject(limit, name)
+	}
+	message := "Too many requests in progress, please retry shortly"
+	if limit == LimitService {
+		message = "Service is busy, please retry shortly"
+	}
+	sendError(w, r, http.StatusServiceUnavailable, "TOO_MANY_CONCURRENT_REQUESTS", message)
+}
+
+// clientIP returns the address anonymous requests are limited by
+func (b *Bulkhead) clientIP(r *http.Request) string {
+	if b.trustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// sendError sends a JSON error response with a Retry-After hint
+func sendError(w http.ResponseWriter, r *http.Request, statusCode int, errorCode, message string) {
+	httperr.Write(w, r, httperr.Error{Status: statusCode, Code: errorCode, Message: message, RetryAfter: retryAfter})
+}
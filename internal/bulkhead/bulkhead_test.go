@@ -0,0 +1,77 @@
+package bulkhead
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/middleware"
+	"hub-api-gateway/internal/router"
+)
+
+func TestBulkhead(t *testing.T) {
+	b := New(Limits{Service: 2, Services: map[string]int{"order-service": 1}, Client: 1}, 200*time.Millisecond)
+	var rejected []string
+	b.OnReject(func(limit, name string) { rejected = append(rejected, limit+":"+name) })
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 4)
+	route := &router.Route{Name: "get-portfolio", Service: "hub-monolith"}
+	handler := b.Middleware(route, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/portfolio", nil)
+		req = req.WithContext(context.WithValue(req.Context(), "user", &middleware.UserContext{UserID: userID}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// u1 and u2 fill the service; u1 is also at its own limit
+	done := make(chan int, 2)
+	for _, user := range []string{"u1", "u2"} {
+		go func(user string) { done <- serve(user).Code }(user)
+		<-started
+	}
+	if inFlight := b.InFlight("hub-monolith"); inFlight != 2 {
+		t.Fatalf("expected 2 requests in flight but got %d", inFlight)
+	}
+
+	if rec := serve("u1"); rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("expected u1 to be shed at its client limit, got %d", rec.Code)
+	}
+	if rec := serve("u3"); rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected u3 to be shed at the service limit, got %d", rec.Code)
+	}
+	if len(rejected) != 2 || rejected[0] != "client:user:u1" || rejected[1] != "service:hub-monolith" {
+		t.Errorf("unexpected rejections %v", rejected)
+	}
+
+	// Queued requests get the first slot that frees up
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		release <- struct{}{}
+	}()
+	go func() { done <- serve("u4").Code }()
+	<-started
+	close(release)
+	for i := 0; i < 3; i++ {
+		if code := <-done; code != http.StatusOK {
+			t.Errorf("expected admitted requests to succeed, got %d", code)
+		}
+	}
+	if inFlight := b.InFlight("hub-monolith"); inFlight != 0 {
+		t.Errorf("expected slots to be released but %d are held", inFlight)
+	}
+
+	// Per-service overrides apply
+	if b.serviceLimit("order-service") != 1 || b.serviceLimit("position-service") != 2 {
+		t.Errorf("unexpected service limits")
+	}
+}
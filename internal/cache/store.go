@@ -0,0 +1,163 @@
+// Package cache stores GET responses of routes with a cache policy, in Redis
+// shared by every gateway instance with a small in-process LRU in front.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrMiss is returned when no fresh entry exists for a key
+var ErrMiss = errors.New("cache miss")
+
+// Entry is a cached response body
+type Entry struct {
+	Body      []byte    `json:"body"`
+	ETag      string    `json:"etag"`
+	StoredAt  time.Time `json:"stored_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Store keeps entries until they expire
+type Store interface {
+	Get(ctx context.Context, key string) (*Entry, error)
+	Set(ctx context.Context, key string, entry *Entry) error
+}
+
+// RedisStore shares entries across gateway instances
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore creates a Redis-backed response store
+func NewRedisStore(client *redis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+// Get loads an entry
+func (s *RedisStore) Get(ctx context.Context, key string) (*Entry, error) {
+	data, err := s.client.Get(ctx, "response_cache:"+key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrMiss
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// Set stores the entry as JSON until it expires
+func (s *RedisStore) Set(ctx context.Context, key string, entry *Entry) error {
+	ttl := time.Until(entry.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, "response_cache:"+key, data, ttl).Err()
+}
+
+// MemoryStore is a bounded LRU of entries in process memory
+type MemoryStore struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // front = most recently used
+}
+
+// memoryEntry is a stored entry with its key
+type memoryEntry struct {
+	key   string
+	entry *Entry
+}
+
+// NewMemoryStore creates an in-memory store holding up to capacity entries
+func NewMemoryStore(capacity int) *MemoryStore {
+	return &MemoryStore{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Get returns an entry that hasn't expired
+func (s *MemoryStore) Get(_ context.Context, key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.entries[key]
+	if !ok {
+		return nil, ErrMiss
+	}
+
+	stored := element.Value.(*memoryEntry)
+	if time.Now().After(stored.entry.ExpiresAt) {
+		s.order.Remove(element)
+		delete(s.entries, key)
+		return nil, ErrMiss
+	}
+	s.order.MoveToFront(element)
+	return stored.entry, nil
+}
+
+// Set stores the entry, evicting the least recently used one when full
+func (s *MemoryStore) Set(_ context.Context, key string, entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.entries[key]; ok {
+		s.order.Remove(element)
+	}
+	s.entries[key] = s.order.PushFront(&memoryEntry{key: key, entry: entry})
+
+	for s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*memoryEntry).key)
+	}
+	return nil
+}
+
+// TieredStore answers hot keys from a local LRU and the rest from a shared
+// store, copying shared hits into the LRU until they expire
+type TieredStore struct {
+	local  *MemoryStore
+	shared Store
+}
+
+// NewTieredStore puts a local LRU of capacity entries in front of shared
+func NewTieredStore(capacity int, shared Store) *TieredStore {
+	return &TieredStore{local: NewMemoryStore(capacity), shared: shared}
+}
+
+// Get checks the local LRU, then the shared store
+func (s *TieredStore) Get(ctx context.Context, key string) (*Entry, error) {
+	if entry, err := s.local.Get(ctx, key); err == nil {
+		return entry, nil
+	}
+	entry, err := s.shared.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	s.local.Set(ctx, key, entry)
+	return entry, nil
+}
+
+// Set writes to both stores
+func (s *TieredStore) Set(ctx context.Context, key string, entry *Entry) error {
+	s.local.Set(ctx, key, entry)
+	return s.shared.Set(ctx, key, entry)
+}
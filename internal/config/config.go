@@ -2,9 +2,12 @@ package config
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"maps"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -12,21 +15,115 @@ import (
 
 // Config holds all gateway configuration
 type Config struct {
-	Server    ServerConfig
-	Redis     RedisConfig
-	Services  map[string]ServiceConfig
-	Auth      AuthConfig
-	CORS      CORSConfig
-	RateLimit RateLimitConfig
-	Logging   LoggingConfig
+	Server        ServerConfig
+	TLS           TLSConfig
+	Redis         RedisConfig
+	Services      map[string]ServiceConfig
+	Auth          AuthConfig
+	CORS          CORSConfig
+	RateLimit     RateLimitConfig
+	Logging       LoggingConfig
+	Admin         AdminConfig
+	RouteUsage    RouteUsageConfig
+	Features      FeatureFlagsConfig
+	Maintenance   MaintenanceConfig
+	Cache         ResponseCacheConfig
+	Compression   CompressionConfig
+	Audit         AuditConfig
+	Errors        ErrorsConfig
+	Status        StatusConfig
+	Docs          DocsConfig
+	LongPoll      LongPollConfig
+	Stream        StreamConfig
+	WebSocket     WebSocketConfig
+	Watchdog      WatchdogConfig
+	LoadShed      LoadShedConfig
+	Bulkhead      BulkheadConfig
+	AdaptiveLimit AdaptiveLimitConfig
+	Faults        FaultInjectionConfig
+	Replay        ReplayConfig
+	Idempotency   IdempotencyConfig
+	Egress        EgressConfig
+	Discovery     DiscoveryConfig
+	Tenancy       TenancyConfig
+	Bundle        BundleConfig
+	Secrets       SecretsConfig
+	ControlPlane  ControlPlaneConfig
+	Proxy         ProxyConfig
+	Tracing       TracingConfig
+	Recording     RecordingConfig
+
+	InternalListener InternalListenerConfig
+	GRPCPassthrough  GRPCPassthroughConfig
+	InternalTokens   InternalTokensConfig
 }
 
 // ServerConfig holds HTTP server configuration
 type ServerConfig struct {
 	Port            string
+	Environment     string // e.g. development, staging or production
 	Timeout         time.Duration
 	ShutdownTimeout time.Duration
 	MaxBodySize     int64
+	RouteCacheSize  int    // Cached (method, path) -> route matches; 0 disables
+	RouteAmbiguity  string // "warn" or "fail" when routes overlap with equal priority
+
+	// Slowloris and socket exhaustion protection
+	ReadHeaderTimeout  time.Duration // Time allowed to send request headers
+	MaxRequestDuration time.Duration // Deadline applied to every request context
+	MaxConnections     int           // Concurrent connections in total (0 = unlimited)
+	MaxConnsPerIP      int           // Concurrent connections per client IP (0 = unlimited)
+
+	// Graceful draining: readiness fails and connections close for this long
+	// before the listener stops, so load balancers stop sending traffic first
+	ShutdownDrainDelay time.Duration
+	ReusePort          bool // SO_REUSEPORT, so a new process can bind the port while the old one drains
+
+	MockMode bool // Serve routes' mock responses instead of calling their backends
+}
+
+// GRPCPassthroughConfig holds the h2c listener proxying native gRPC calls
+type GRPCPassthroughConfig struct {
+	Enabled      bool
+	Port         string
+	AuthRequired bool              // Reject calls without a bearer token
+	Services     map[string]string // Fully-qualified gRPC service -> backend, besides routed ones
+}
+
+// InternalTokensConfig holds the gateway-signed tokens sent to backends
+type InternalTokensConfig struct {
+	Enabled bool
+	KeyFile string        // PEM RSA private key shared by all instances; generated per instance when empty
+	Issuer  string        // "iss" of issued tokens
+	TTL     time.Duration // Lifetime of each token
+}
+
+// TLSConfig holds HTTPS configuration of the main server
+type TLSConfig struct {
+	Enabled        bool
+	CertFile       string
+	KeyFile        string
+	ReloadInterval time.Duration // How often the files are checked for a renewed certificate
+	MinVersion     string        // "1.2" or "1.3"
+	CipherSuites   []string      // IANA names of TLS 1.2 suites; empty uses Go's defaults
+
+	// PEM-encoded key pair used instead of the files, e.g. from a secrets manager
+	CertPEM string
+	KeyPEM  string
+
+	// Plain HTTP listener redirecting to HTTPS
+	RedirectEnabled bool
+	RedirectPort    string
+}
+
+// InternalListenerConfig holds the mTLS listener used for service-to-service calls
+type InternalListenerConfig struct {
+	Enabled           bool
+	Port              string
+	CertFile          string
+	KeyFile           string
+	ClientCAFile      string            // CA bundle used to verify client certificates
+	ServicePrincipals map[string]string // Certificate SAN -> service principal
 }
 
 // RedisConfig holds Redis configuration
@@ -43,6 +140,16 @@ type ServiceConfig struct {
 	Address    string
 	Timeout    time.Duration
 	MaxRetries int
+	MinVersion string // Minimum backend contract version; traffic is refused below it (empty disables)
+
+	MaxConcurrent int // Bulkhead limit of requests in flight; 0 uses BULKHEAD_SERVICE_LIMIT
+	PoolSize      int // gRPC channels to the service; 0 uses GRPC_CONNECTION_POOL_SIZE
+
+	// Circuit breaker overrides; 0 uses the CIRCUIT_BREAKER_* setting
+	BreakerThreshold   int
+	BreakerTimeout     time.Duration
+	BreakerHalfOpen    int
+	BreakerFailureRate float64
 }
 
 // AuthConfig holds authentication configuration
@@ -50,24 +157,124 @@ type AuthConfig struct {
 	JWTSecret    string
 	CacheEnabled bool
 	CacheTTL     time.Duration
+
+	// Pluggable providers
+	DefaultProvider       string            // Provider for bearer tokens when a route doesn't set auth_provider
+	JWTIssuer             string            // Expected "iss" for locally validated JWTs (optional)
+	JWTJWKSURL            string            // Lets the jwt provider accept RS256 tokens signed with these keys
+	OIDCJWKSURL           string            // Enables the OIDC provider when set
+	OIDCIssuer            string            // Expected "iss" for OIDC tokens
+	OIDCAudience          string            // Expected "aud" for OIDC tokens
+	OIDCClientID          string            // Enables browser logins through the OIDC issuer (authorization code flow)
+	OIDCClientSecret      string            // Client secret at the issuer; empty for public clients (PKCE only)
+	OIDCRedirectURL       string            // Callback URL registered with the issuer
+	OIDCScopes            []string          // Scopes requested at login
+	OIDCPostLoginRedirect string            // Where browsers go after login, token in the fragment; empty answers JSON
+	JWKSCacheTTL          time.Duration     // How long fetched signing keys are trusted
+	APIKeys               map[string]string // API key -> principal; enables the API key provider
+
+	// RFC 7662 introspection of opaque tokens issued by a partner authorization server
+	IntrospectionURL          string // Enables the introspection provider when set
+	IntrospectionClientID     string // Gateway's client credentials at the endpoint (HTTP Basic)
+	IntrospectionClientSecret string
+	IntrospectionAudience     string // Required "aud" of introspected tokens; empty accepts any
+	IntrospectionTimeout      time.Duration
+
+	// Reconnect tickets let WebSocket clients re-authenticate without a User Service call
+	ReconnectTicketsEnabled bool
+	ReconnectTicketSecret   string // Must differ from JWT_SECRET
+	ReconnectTicketTTL      time.Duration
+
+	// Refresh tokens returned at login and exchanged at POST /api/v1/auth/refresh
+	RefreshTokensEnabled bool
+	RefreshTokenTTL      time.Duration
+	RefreshTokenCookie   bool // Send refresh tokens as httpOnly secure cookies instead of in the body
+
+	// Session cookies for the web app: login keeps the access token in Redis and
+	// sets an httpOnly session cookie plus a CSRF cookie instead of returning it
+	SessionCookies        bool
+	SessionCookieSameSite string // lax, strict or none
+
+	// Concurrent session limits, enforced at login against a Redis session registry
+	MaxSessions       int      // 0 disables the limit
+	SessionLimitMode  string   // "reject" or "evict_oldest"
+	SessionLimitUsers []string // User IDs the limit applies to; empty applies it to everyone
+
+	// Brute-force protection of the login endpoint: failed logins are counted
+	// per email and per client address (in Redis when available)
+	LoginGuardEnabled       bool
+	LoginFreeAttempts       int           // Failures per email before backoff starts
+	LoginBackoffBase        time.Duration // First wait once the free attempts are spent, doubled per failure
+	LoginBackoffMax         time.Duration
+	LoginLockoutThreshold   int // Failures per email that lock the account (423); 0 disables
+	LoginIPLockoutThreshold int // Failures per client address that block it (429); 0 disables
+	LoginLockoutDuration    time.Duration
+	LoginFailureWindow      time.Duration // Failures are forgotten this long after the last one
+	LoginStuffingThreshold  int           // Distinct emails failing from one address that flag credential stuffing
+
+	// Challenge logins flagged by the brute-force protection must solve
+	LoginChallengeProvider  string  // recaptcha, hcaptcha or turnstile; empty disables challenges
+	LoginChallengeSecret    string  // Provider secret used to verify tokens
+	LoginChallengeSiteKey   string  // Site key returned to clients for their widget
+	LoginChallengeVerifyURL string  // Overrides the provider's siteverify endpoint
+	LoginChallengeMinScore  float64 // Minimum reCAPTCHA v3 score; 0 accepts any
+	LoginChallengeTimeout   time.Duration
 }
 
 // CORSConfig holds CORS configuration
 type CORSConfig struct {
 	Enabled          bool
-	AllowedOrigins   []string
+	AllowedOrigins   []string // Exact origins, "*", or wildcard subdomains such as https://*.hub.com
 	AllowedMethods   []string
 	AllowedHeaders   []string
+	ExposedHeaders   []string // Response headers readable by browser clients
 	AllowCredentials bool
+	MaxAge           time.Duration // How long browsers may cache preflight results
 }
 
 // RateLimitConfig holds rate limiting configuration
 type RateLimitConfig struct {
+	Enabled           bool
+	PerUserLimit      int
+	PerUserBurst      int
+	PerIPLimit        int
+	PerIPBurst        int
+	PreAuthPerIP      int // Per-IP flood guard checked before authentication, on every request
+	PreAuthBurst      int
+	Window            time.Duration // Period the per-user and per-IP limits refill over
+	TrustForwardedFor bool          // Identify anonymous clients by X-Forwarded-For (behind a trusted proxy only)
+
+	// Trusted automation that bypasses rate limiting (still logged and counted)
+	ExemptPrincipals []string          // User IDs or service principals
+	ExemptIPs        []string          // Client IPs or CIDRs
+	BypassTokens     map[string]string // Name -> token sent in X-RateLimit-Bypass
+}
+
+// ErrorsConfig holds gateway error response configuration
+type ErrorsConfig struct {
+	ProblemJSON bool   // Render 401/403/429/503 as RFC 7807 application/problem+json
+	DocsBaseURL string // Base of the public API docs used for problem type URIs
+	Templates   string // YAML file of templated error bodies per code and client type
+
+	// Translated error messages, negotiated from Accept-Language
+	Locales       string // Directory of <locale>.yaml message catalogs (empty disables)
+	DefaultLocale string // Language of the gateway's own messages
+}
+
+// StatusConfig holds the public status rollup configuration
+type StatusConfig struct {
+	Enabled              bool
+	CacheTTL             time.Duration
+	ProductAreas         map[string]string // service -> public product area name
+	MinRequests          int               // Requests needed before error rates are considered
+	DegradedErrorPercent int
+	OutageErrorPercent   int
+}
+
+// DocsConfig holds the OpenAPI document and Swagger UI configuration
+type DocsConfig struct {
 	Enabled      bool
-	PerUserLimit int
-	PerUserBurst int
-	PerIPLimit   int
-	PerIPBurst   int
+	SwaggerUIURL string // Base URL of the swagger-ui-dist assets
 }
 
 // LoggingConfig holds logging configuration
@@ -75,29 +282,376 @@ type LoggingConfig struct {
 	Level      string
 	Format     string
 	MaskTokens bool
+
+	// Incoming X-Request-ID handling; untrusted requests get a generated ID
+	TrustRequestID      bool     // Keep valid request IDs sent by callers
+	RequestIDTrustedIPs []string // Callers (IPs or CIDRs) whose IDs are kept; empty trusts all
+}
+
+// AdminConfig holds admin API configuration
+type AdminConfig struct {
+	Enabled          bool
+	Token            string // Shared secret sent as X-Admin-Token
+	RecentErrorsSize int    // Number of recent errors kept for /admin/errors
+}
+
+// RouteUsageConfig holds configuration for route usage tracking and dead-route reports
+type RouteUsageConfig struct {
+	Enabled        bool
+	UnusedAfter    time.Duration // Routes not matched for this long are reported as unused
+	ReportInterval time.Duration // How often unused routes are logged (0 disables)
+	FlushInterval  time.Duration // How often usage is written to Redis
+}
+
+// FeatureFlagsConfig holds per-user feature flag evaluation configuration
+type FeatureFlagsConfig struct {
+	Enabled  bool
+	Provider string   // "redis" or "launchdarkly"
+	Flags    []string // Flags evaluated per request and sent as x-feature-flags
+	CacheTTL time.Duration
+	Timeout  time.Duration
+
+	// LaunchDarkly-compatible evaluation endpoint (relay proxy)
+	LaunchDarklyURL    string
+	LaunchDarklySDKKey string
+}
+
+// MaintenanceConfig holds backend maintenance (draining) configuration
+type MaintenanceConfig struct {
+	SnapshotCacheSize int // Last successful GET responses kept for draining services and max_stale routes (0 disables)
+}
+
+// CompressionConfig holds response compression configuration
+type CompressionConfig struct {
+	Enabled bool
+	MinSize int // Smaller response bodies are sent uncompressed
+	Level   int // gzip level, 1 (fastest) to 9 (smallest)
+}
+
+// ResponseCacheConfig holds configuration for the cache of routes with a cache policy
+type ResponseCacheConfig struct {
+	Enabled   bool
+	LocalSize int // Responses kept in process memory in front of Redis
+}
+
+// ReplayConfig holds anti-replay configuration for routes with replay_protection
+type ReplayConfig struct {
+	MaxAge time.Duration // Maximum accepted X-Timestamp skew
+}
+
+// IdempotencyConfig holds Idempotency-Key configuration
+type IdempotencyConfig struct {
+	Enabled bool
+	Window  time.Duration // How long a response is replayed to retries with the same key
+	LockTTL time.Duration // How long a key stays locked while its first request runs
+}
+
+// RecordingConfig holds configuration for recording the traffic of routes
+// with record enabled, for replays against another environment
+type RecordingConfig struct {
+	Enabled      bool
+	Store        string   // "file" or "redis"
+	Path         string   // JSON Lines file of the file store
+	RedisKey     string   // List of the Redis store
+	MaxEntries   int      // Exchanges kept by the Redis store
+	Percent      float64  // Share of requests recorded, 0-100
+	MaxBodyBytes int      // Larger bodies aren't recorded
+	RedactFields []string // JSON fields redacted at any depth
+}
+
+// TracingConfig holds on-demand request trace configuration
+type TracingConfig struct {
+	Token     string        // X-Gateway-Trace value that enables tracing (empty disables)
+	TTL       time.Duration // How long traces stay retrievable
+	MaxTraces int           // Traces kept in memory when Redis is unavailable
+}
+
+// EgressConfig holds the outbound dial allowlist
+type EgressConfig struct {
+	Allowlist []string // CIDRs, IPs, hostnames or *.domain patterns (empty allows all)
+}
+
+// DiscoveryConfig holds configuration for resolving discovery://name service addresses
+type DiscoveryConfig struct {
+	Provider string // static, dns, kubernetes or consul (empty disables discovery)
+
+	Static map[string]string // service -> "host:port|host:port"
+
+	DNSDomain   string        // SRV records of _grpc._tcp.<service>.<domain> are looked up
+	DNSInterval time.Duration // How often SRV records are looked up again
+
+	KubernetesAPIURL    string // Empty uses the in-cluster API server and service account
+	KubernetesNamespace string // Empty uses the pod's namespace
+	KubernetesPortName  string // Endpoints port to dial; empty uses the first port
+
+	ConsulAddress string
+	ConsulToken   string
+}
+
+// TenancyConfig holds configuration for resolving the tenant of each request
+type TenancyConfig struct {
+	Enabled    bool
+	Sources    []string // claim, subdomain and header, in order of precedence
+	Header     string   // Header naming the tenant
+	BaseDomain string   // Domain whose subdomains name tenants, e.g. hub.com
+	Allowed    []string // Known tenants; empty accepts any well-formed tenant ID
+	Default    string   // Tenant of requests no source names one for
+	Required   bool     // Reject requests without a tenant
+
+	// Tenants' own deployments of services: "tenant/service" -> address.
+	// Each is registered as service@tenant, configured like the shared service.
+	ServiceAddresses map[string]string
+}
+
+// TenantServiceName names the service a tenant's own deployment of service
+// is registered as
+func TenantServiceName(service, tenant string) string {
+	return service + "@" + tenant
+}
+
+// BundleConfig holds the centrally managed encrypted config bundle location
+type BundleConfig struct {
+	Location        string        // https://, s3://, gs:// or file path (empty disables)
+	Key             string        // Base64 AES-256 key
+	KeyFile         string        // File holding the base64 key (takes precedence over Key)
+	RefreshInterval time.Duration // How often to check for a new bundle (0 disables refresh)
+	FetchTimeout    time.Duration
+}
+
+// SecretsConfig holds the secrets manager some settings are fetched from
+type SecretsConfig struct {
+	Provider        string            // "vault" or "aws" (empty disables)
+	Refs            map[string]string // Setting name -> secret reference, e.g. JWT_SECRET=secret/data/gateway#jwt_secret
+	RefreshInterval time.Duration     // How often secrets are fetched again to pick up rotations (0 disables)
+	RotationGrace   time.Duration     // How long tokens signed with a rotated-out JWT secret are still accepted
+	FetchTimeout    time.Duration
+
+	VaultAddress   string
+	VaultToken     string
+	VaultNamespace string
+
+	AWSRegion          string
+	AWSEndpoint        string // Overrides https://secretsmanager.<region>.amazonaws.com
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	AWSSessionToken    string
+}
+
+// ControlPlaneConfig holds the central config service client configuration
+type ControlPlaneConfig struct {
+	URL          string        // Base URL of the config service (empty disables)
+	Token        string        // Bearer token presented to the config service
+	InstanceID   string        // Reported as X-Gateway-Instance (defaults to hostname)
+	PollInterval time.Duration // Delay between polls and after failures
+	LongPollWait time.Duration // Server-side hold time per poll (0 = plain polling)
+	Timeout      time.Duration
+}
+
+// ProxyConfig holds HTTP-to-gRPC proxying configuration
+type ProxyConfig struct {
+	DescriptorSets  []string          // Compiled FileDescriptorSet files describing backend services
+	GRPCErrorStatus map[string]string // gRPC code name -> HTTP status overrides (e.g. FailedPrecondition=422)
+
+	// gRPC channels per service, each its own HTTP/2 connection; calls are
+	// spread round-robin so one connection's stream limit doesn't cap QPS
+	ConnectionPoolSize int
+
+	// Dial every service at startup and wait up to WarmUpTimeout for it to be
+	// ready before serving; unreachable ones are logged and fail readiness
+	WarmUpEnabled bool
+	WarmUpTimeout time.Duration
+
+	// Retries of idempotent routes on Unavailable/Aborted, up to each service's MaxRetries
+	RetryEnabled     bool
+	RetryBaseDelay   time.Duration // First backoff, doubled per attempt (with full jitter)
+	RetryMaxDelay    time.Duration
+	RetryBudgetRatio float64 // Retries earned per request (0.2 = at most 20% extra backend load)
+	RetryBudgetBurst int     // Retries that can be spent at once
+
+	// Circuit breakers per service (and per route with circuit_breaker in routes.yaml)
+	CircuitBreakerEnabled   bool
+	CircuitBreakerThreshold int           // Consecutive backend failures that open a breaker
+	CircuitBreakerTimeout   time.Duration // How long an open breaker rejects calls before probing
+	CircuitBreakerHalfOpen  int           // Successful probes needed to close it again
+
+	// Failure-rate mode: breakers open once this share of calls in a window
+	// failed instead of after CircuitBreakerThreshold consecutive failures
+	CircuitBreakerFailureRate float64       // 0 keeps consecutive counting (0.5 = 50%)
+	CircuitBreakerMinRequests int           // Calls in the window before the rate is judged
+	CircuitBreakerWindow      time.Duration // How long calls are counted before the window restarts
+
+	// Active grpc.health.v1 probes of every service; 0 interval disables them
+	HealthProbeInterval time.Duration
+	HealthProbeTimeout  time.Duration
+
+	// Passive ejection of services whose error rate reaches the threshold
+	OutlierDetectionEnabled bool
+	OutlierErrorRate        float64       // Failed share of calls that ejects a service (0.5 = 50%)
+	OutlierMinRequests      int           // Calls in the window before the error rate is judged
+	OutlierWindow           time.Duration // How long calls are counted before the window restarts
+	OutlierEjectionTime     time.Duration // First ejection; repeat ejections last longer (up to 5x)
+
+	// Mirrored calls (routes with mirror) in flight before further requests go unmirrored
+	MirrorMaxInFlight int
+}
+
+// WatchdogConfig holds configuration for cancelling stuck requests
+type WatchdogConfig struct {
+	Enabled           bool
+	TimeoutMultiplier float64       // Hard limit as a multiple of the route's upstream timeout
+	Interval          time.Duration // How often in-flight requests are checked
+	DumpInterval      time.Duration // Minimum time between goroutine dumps
+	DumpMaxBytes      int           // Goroutine dumps are truncated to this size
+}
+
+// BulkheadConfig holds the limits of concurrent requests per service and client
+type BulkheadConfig struct {
+	Enabled      bool
+	ServiceLimit int           // Requests in flight per backend service; 0 = unlimited
+	ClientLimit  int           // Requests in flight per user, or per IP for anonymous requests; 0 = unlimited
+	QueueTimeout time.Duration // How long a request over a limit waits for a slot
+}
+
+// AdaptiveLimitConfig holds the bounds of the concurrency limits learned per
+// service from upstream latency
+type AdaptiveLimitConfig struct {
+	Enabled      bool
+	InitialLimit int     // Limit of a service before any latency is observed
+	MinLimit     int     // The limit never shrinks below this
+	MaxLimit     int     // The limit never grows above this
+	Tolerance    float64 // Latency above Tolerance x the service's baseline shrinks the limit
+}
+
+// FaultInjectionConfig holds configuration for injecting faults into backend
+// calls to rehearse retries and circuit breaking
+type FaultInjectionConfig struct {
+	Enabled      bool
+	Environments []string // ENVIRONMENT values fault injection may be enabled in
+}
+
+// LoadShedConfig holds configuration for shedding traffic under runtime pressure
+type LoadShedConfig struct {
+	Enabled    bool
+	HeapMB     int           // Live heap threshold; 0 uses 80% of GOMEMLIMIT when set
+	Goroutines int           // Goroutine threshold; 0 disables the signal
+	GCPause    time.Duration // Longest GC pause threshold; 0 disables the signal
+	Interval   time.Duration // How often the runtime is sampled
+}
+
+// StreamConfig holds configuration for streaming (SSE/NDJSON) routes
+type StreamConfig struct {
+	BufferSize         int           // Messages buffered per client before the slow-consumer policy applies
+	SlowConsumerPolicy string        // "drop_oldest" or "disconnect"
+	HeartbeatInterval  time.Duration // Keep-alive comments on idle SSE streams (0 disables)
+}
+
+// WebSocketConfig holds configuration for WebSocket routes
+type WebSocketConfig struct {
+	PingInterval    time.Duration // How often clients are pinged
+	PongTimeout     time.Duration // Clients silent for this long are disconnected
+	WriteTimeout    time.Duration // Deadline for each frame sent to a client
+	MaxMessageBytes int           // Larger client messages close the connection
+	AllowedOrigins  []string      // Browser origins allowed besides the gateway's own
+}
+
+// LongPollConfig holds long-polling configuration
+type LongPollConfig struct {
+	MaxWait  time.Duration // Upper bound for ?wait= (0 disables long-polling)
+	Interval time.Duration // Backend polling interval while a request is held
+}
+
+// AuditConfig holds audit log configuration
+type AuditConfig struct {
+	Enabled     bool
+	Sink        string // file, syslog or kafka
+	FilePath    string
+	StatePath   string            // Last record of syslog and kafka chains, to continue them after a restart
+	SigningKeys map[string]string // Key ID -> HMAC secret; old keys stay for verification
+	ActiveKeyID string            // Key used to sign new records
+
+	SyslogNetwork string // udp or tcp
+	SyslogAddress string // host:port; empty uses the local syslog daemon
+	SyslogTag     string
+
+	KafkaRESTURL string // Kafka REST Proxy base URL
+	KafkaTopic   string
+	KafkaKey     string // Key of every record, keeping the chain in one partition (hostname by default)
+	KafkaTimeout time.Duration
+	KafkaQueue   int // Records waiting to be produced before new events are dropped
 }
 
 var globalConfig *Config
 
-// Load loads configuration from environment variables
+// Load loads configuration from environment variables, layered over the
+// config file named by CONFIG_FILE when set
 func Load() (*Config, error) {
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+	for _, problem := range loaded.invalid {
+		slog.Warn("ignoring invalid setting, using its default", "problem", problem)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("configuration validation failed: %w", err)
+	}
+
+	globalConfig = cfg
+	cfg.LogConfiguration()
+
+	return cfg, nil
+}
+
+// load reads the .env file, the config file and the environment into a Config
+func load() (*Config, error) {
+	loaded = newSettingsLog()
+
 	// Try to load from .env file (like HubInvestmentsServer does)
 	err := godotenv.Load(".env")
 	if err != nil {
-		log.Printf("⚠️  Could not load .env file: %v", err)
-		log.Println("Using environment variables or default values...")
+		slog.Info("no .env file loaded, using environment variables and defaults", "error", err)
 	} else {
-		log.Println("✅ Loaded configuration from .env file")
+		slog.Info("loaded configuration from .env file")
 	}
 
-	log.Println("Loading configuration from environment variables...")
+	if path := os.Getenv(FileEnv); path != "" {
+		if err := applyFile(path); err != nil {
+			return nil, err
+		}
+		slog.Info("loaded configuration file", "path", path, "settings", len(loaded.file))
+	}
 
 	cfg := &Config{
 		Server: ServerConfig{
 			Port:            getEnv("HTTP_PORT", "8080"),
+			Environment:     getEnv("ENVIRONMENT", "development"),
+			MockMode:        getBoolEnv("MOCK_MODE", false),
 			Timeout:         getDurationEnv("SERVER_TIMEOUT", 30*time.Second),
 			ShutdownTimeout: getDurationEnv("SHUTDOWN_TIMEOUT", 30*time.Second),
-			MaxBodySize:     getInt64Env("MAX_BODY_SIZE", 10485760), // 10MB
+			RouteCacheSize:  getIntEnv("ROUTE_MATCH_CACHE_SIZE", 10000),
+			RouteAmbiguity:  getEnv("ROUTE_AMBIGUITY_MODE", "warn"),
+
+			ReadHeaderTimeout:  getDurationEnv("SERVER_READ_HEADER_TIMEOUT", 5*time.Second),
+			MaxRequestDuration: getDurationEnv("SERVER_MAX_REQUEST_DURATION", 60*time.Second),
+			MaxConnections:     getIntEnv("SERVER_MAX_CONNECTIONS", 10000),
+			MaxConnsPerIP:      getIntEnv("SERVER_MAX_CONNS_PER_IP", 100),
+			MaxBodySize:        getInt64Env("MAX_BODY_SIZE", 10485760), // 10MB
+
+			ShutdownDrainDelay: getDurationEnv("SHUTDOWN_DRAIN_DELAY", 0),
+			ReusePort:          getBoolEnv("SERVER_REUSE_PORT", false),
+		},
+		TLS: TLSConfig{
+			Enabled:         getBoolEnv("TLS_ENABLED", false),
+			CertFile:        getEnv("TLS_CERT_FILE", ""),
+			KeyFile:         getEnv("TLS_KEY_FILE", ""),
+			ReloadInterval:  getDurationEnv("TLS_RELOAD_INTERVAL", 30*time.Second),
+			MinVersion:      getEnv("TLS_MIN_VERSION", "1.2"),
+			CipherSuites:    getSliceEnv("TLS_CIPHER_SUITES", nil),
+			CertPEM:         getEnv("TLS_CERT_PEM", ""),
+			KeyPEM:          getEnv("TLS_KEY_PEM", ""),
+			RedirectEnabled: getBoolEnv("TLS_REDIRECT_ENABLED", false),
+			RedirectPort:    getEnv("TLS_REDIRECT_PORT", "80"),
 		},
 		Redis: RedisConfig{
 			Host:          getEnv("REDIS_HOST", "localhost"),
@@ -111,40 +665,139 @@ func Load() (*Config, error) {
 				Address:    getEnv("USER_SERVICE_ADDRESS", "localhost:50051"),
 				Timeout:    getDurationEnv("USER_SERVICE_TIMEOUT", 5*time.Second),
 				MaxRetries: getIntEnv("USER_SERVICE_MAX_RETRIES", 3),
+				MinVersion: getEnv("USER_SERVICE_MIN_VERSION", ""),
+
+				MaxConcurrent: getIntEnv("USER_SERVICE_MAX_CONCURRENT", 0),
+				PoolSize:      getIntEnv("USER_SERVICE_CONNECTION_POOL_SIZE", 0),
+
+				BreakerThreshold:   getIntEnv("USER_SERVICE_CIRCUIT_BREAKER_THRESHOLD", 0),
+				BreakerTimeout:     getDurationEnv("USER_SERVICE_CIRCUIT_BREAKER_TIMEOUT", 0),
+				BreakerHalfOpen:    getIntEnv("USER_SERVICE_CIRCUIT_BREAKER_HALF_OPEN_REQUESTS", 0),
+				BreakerFailureRate: getFloatEnv("USER_SERVICE_CIRCUIT_BREAKER_FAILURE_RATE", 0),
 			},
 			// HubInvestments Monolith (Step 4.6.6)
 			"hub-monolith": {
 				Address:    getEnv("HUB_MONOLITH_ADDRESS", "localhost:50060"),
 				Timeout:    getDurationEnv("HUB_MONOLITH_TIMEOUT", 10*time.Second),
 				MaxRetries: getIntEnv("HUB_MONOLITH_MAX_RETRIES", 3),
+				MinVersion: getEnv("HUB_MONOLITH_MIN_VERSION", ""),
+
+				MaxConcurrent: getIntEnv("HUB_MONOLITH_MAX_CONCURRENT", 0),
+				PoolSize:      getIntEnv("HUB_MONOLITH_CONNECTION_POOL_SIZE", 0),
+
+				BreakerThreshold:   getIntEnv("HUB_MONOLITH_CIRCUIT_BREAKER_THRESHOLD", 0),
+				BreakerTimeout:     getDurationEnv("HUB_MONOLITH_CIRCUIT_BREAKER_TIMEOUT", 0),
+				BreakerHalfOpen:    getIntEnv("HUB_MONOLITH_CIRCUIT_BREAKER_HALF_OPEN_REQUESTS", 0),
+				BreakerFailureRate: getFloatEnv("HUB_MONOLITH_CIRCUIT_BREAKER_FAILURE_RATE", 0),
 			},
 			"order-service": {
 				Address:    getEnv("ORDER_SERVICE_ADDRESS", "localhost:50052"),
 				Timeout:    getDurationEnv("ORDER_SERVICE_TIMEOUT", 10*time.Second),
 				MaxRetries: getIntEnv("ORDER_SERVICE_MAX_RETRIES", 3),
+				MinVersion: getEnv("ORDER_SERVICE_MIN_VERSION", ""),
+
+				MaxConcurrent: getIntEnv("ORDER_SERVICE_MAX_CONCURRENT", 0),
+				PoolSize:      getIntEnv("ORDER_SERVICE_CONNECTION_POOL_SIZE", 0),
+
+				BreakerThreshold:   getIntEnv("ORDER_SERVICE_CIRCUIT_BREAKER_THRESHOLD", 0),
+				BreakerTimeout:     getDurationEnv("ORDER_SERVICE_CIRCUIT_BREAKER_TIMEOUT", 0),
+				BreakerHalfOpen:    getIntEnv("ORDER_SERVICE_CIRCUIT_BREAKER_HALF_OPEN_REQUESTS", 0),
+				BreakerFailureRate: getFloatEnv("ORDER_SERVICE_CIRCUIT_BREAKER_FAILURE_RATE", 0),
 			},
 			"position-service": {
 				Address:    getEnv("POSITION_SERVICE_ADDRESS", "localhost:50053"),
 				Timeout:    getDurationEnv("POSITION_SERVICE_TIMEOUT", 5*time.Second),
 				MaxRetries: getIntEnv("POSITION_SERVICE_MAX_RETRIES", 3),
+				MinVersion: getEnv("POSITION_SERVICE_MIN_VERSION", ""),
+
+				MaxConcurrent: getIntEnv("POSITION_SERVICE_MAX_CONCURRENT", 0),
+				PoolSize:      getIntEnv("POSITION_SERVICE_CONNECTION_POOL_SIZE", 0),
+
+				BreakerThreshold:   getIntEnv("POSITION_SERVICE_CIRCUIT_BREAKER_THRESHOLD", 0),
+				BreakerTimeout:     getDurationEnv("POSITION_SERVICE_CIRCUIT_BREAKER_TIMEOUT", 0),
+				BreakerHalfOpen:    getIntEnv("POSITION_SERVICE_CIRCUIT_BREAKER_HALF_OPEN_REQUESTS", 0),
+				BreakerFailureRate: getFloatEnv("POSITION_SERVICE_CIRCUIT_BREAKER_FAILURE_RATE", 0),
 			},
 			"market-data-service": {
 				Address:    getEnv("MARKET_DATA_SERVICE_ADDRESS", "localhost:50054"),
 				Timeout:    getDurationEnv("MARKET_DATA_SERVICE_TIMEOUT", 3*time.Second),
 				MaxRetries: getIntEnv("MARKET_DATA_SERVICE_MAX_RETRIES", 3),
+				MinVersion: getEnv("MARKET_DATA_SERVICE_MIN_VERSION", ""),
+
+				MaxConcurrent: getIntEnv("MARKET_DATA_SERVICE_MAX_CONCURRENT", 0),
+				PoolSize:      getIntEnv("MARKET_DATA_SERVICE_CONNECTION_POOL_SIZE", 0),
+
+				BreakerThreshold:   getIntEnv("MARKET_DATA_SERVICE_CIRCUIT_BREAKER_THRESHOLD", 0),
+				BreakerTimeout:     getDurationEnv("MARKET_DATA_SERVICE_CIRCUIT_BREAKER_TIMEOUT", 0),
+				BreakerHalfOpen:    getIntEnv("MARKET_DATA_SERVICE_CIRCUIT_BREAKER_HALF_OPEN_REQUESTS", 0),
+				BreakerFailureRate: getFloatEnv("MARKET_DATA_SERVICE_CIRCUIT_BREAKER_FAILURE_RATE", 0),
 			},
 		},
 		Auth: AuthConfig{
 			JWTSecret:    getEnv("JWT_SECRET", ""),
 			CacheEnabled: getBoolEnv("AUTH_CACHE_ENABLED", true),
 			CacheTTL:     getDurationEnv("AUTH_CACHE_TTL", 5*time.Minute),
+
+			DefaultProvider:       getEnv("AUTH_DEFAULT_PROVIDER", "user-service"),
+			JWTIssuer:             getEnv("AUTH_JWT_ISSUER", ""),
+			JWTJWKSURL:            getEnv("AUTH_JWT_JWKS_URL", ""),
+			OIDCJWKSURL:           getEnv("AUTH_OIDC_JWKS_URL", ""),
+			OIDCIssuer:            getEnv("AUTH_OIDC_ISSUER", ""),
+			OIDCAudience:          getEnv("AUTH_OIDC_AUDIENCE", ""),
+			OIDCClientID:          getEnv("AUTH_OIDC_CLIENT_ID", ""),
+			OIDCClientSecret:      getEnv("AUTH_OIDC_CLIENT_SECRET", ""),
+			OIDCRedirectURL:       getEnv("AUTH_OIDC_REDIRECT_URL", ""),
+			OIDCScopes:            getSliceEnv("AUTH_OIDC_SCOPES", []string{"openid", "email", "profile"}),
+			OIDCPostLoginRedirect: getEnv("AUTH_OIDC_POST_LOGIN_REDIRECT", ""),
+			JWKSCacheTTL:          getDurationEnv("AUTH_JWKS_CACHE_TTL", 10*time.Minute),
+			APIKeys:               getMapEnv("AUTH_API_KEYS", nil),
+
+			IntrospectionURL:          getEnv("AUTH_INTROSPECTION_URL", ""),
+			IntrospectionClientID:     getEnv("AUTH_INTROSPECTION_CLIENT_ID", ""),
+			IntrospectionClientSecret: getEnv("AUTH_INTROSPECTION_CLIENT_SECRET", ""),
+			IntrospectionAudience:     getEnv("AUTH_INTROSPECTION_AUDIENCE", ""),
+			IntrospectionTimeout:      getDurationEnv("AUTH_INTROSPECTION_TIMEOUT", 5*time.Second),
+
+			ReconnectTicketsEnabled: getBoolEnv("AUTH_RECONNECT_TICKETS_ENABLED", false),
+			ReconnectTicketSecret:   getEnv("AUTH_RECONNECT_TICKET_SECRET", ""),
+			ReconnectTicketTTL:      getDurationEnv("AUTH_RECONNECT_TICKET_TTL", 2*time.Minute),
+
+			RefreshTokensEnabled: getBoolEnv("AUTH_REFRESH_TOKENS_ENABLED", false),
+			RefreshTokenTTL:      getDurationEnv("AUTH_REFRESH_TOKEN_TTL", 7*24*time.Hour),
+			RefreshTokenCookie:   getBoolEnv("AUTH_REFRESH_TOKEN_COOKIE", false),
+
+			SessionCookies:        getBoolEnv("AUTH_SESSION_COOKIES", false),
+			SessionCookieSameSite: strings.ToLower(getEnv("AUTH_SESSION_COOKIE_SAMESITE", "lax")),
+
+			MaxSessions:       getIntEnv("AUTH_MAX_SESSIONS", 0),
+			SessionLimitMode:  getEnv("AUTH_SESSION_LIMIT_MODE", "reject"),
+			SessionLimitUsers: getSliceEnv("AUTH_SESSION_LIMIT_USERS", nil),
+
+			LoginGuardEnabled:       getBoolEnv("AUTH_LOGIN_GUARD_ENABLED", true),
+			LoginFreeAttempts:       getIntEnv("AUTH_LOGIN_FREE_ATTEMPTS", 3),
+			LoginBackoffBase:        getDurationEnv("AUTH_LOGIN_BACKOFF_BASE", time.Second),
+			LoginBackoffMax:         getDurationEnv("AUTH_LOGIN_BACKOFF_MAX", time.Minute),
+			LoginLockoutThreshold:   getIntEnv("AUTH_LOGIN_LOCKOUT_THRESHOLD", 10),
+			LoginIPLockoutThreshold: getIntEnv("AUTH_LOGIN_IP_LOCKOUT_THRESHOLD", 100),
+			LoginLockoutDuration:    getDurationEnv("AUTH_LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+			LoginFailureWindow:      getDurationEnv("AUTH_LOGIN_FAILURE_WINDOW", time.Hour),
+			LoginStuffingThreshold:  getIntEnv("AUTH_LOGIN_STUFFING_THRESHOLD", 20),
+
+			LoginChallengeProvider:  getEnv("AUTH_LOGIN_CHALLENGE_PROVIDER", ""),
+			LoginChallengeSecret:    getEnv("AUTH_LOGIN_CHALLENGE_SECRET", ""),
+			LoginChallengeSiteKey:   getEnv("AUTH_LOGIN_CHALLENGE_SITE_KEY", ""),
+			LoginChallengeVerifyURL: getEnv("AUTH_LOGIN_CHALLENGE_VERIFY_URL", ""),
+			LoginChallengeMinScore:  getFloatEnv("AUTH_LOGIN_CHALLENGE_MIN_SCORE", 0),
+			LoginChallengeTimeout:   getDurationEnv("AUTH_LOGIN_CHALLENGE_TIMEOUT", 5*time.Second),
 		},
 		CORS: CORSConfig{
 			Enabled:          getBoolEnv("CORS_ENABLED", true),
-			AllowedOrigins:   []string{getEnv("CORS_ALLOWED_ORIGINS", "http://localhost:3000")},
-			AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-			AllowedHeaders:   []string{"Authorization", "Content-Type", "X-Request-ID"},
+			AllowedOrigins:   getSliceEnv("CORS_ALLOWED_ORIGINS", []string{"http://localhost:3000"}),
+			AllowedMethods:   getSliceEnv("CORS_ALLOWED_METHODS", []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}),
+			AllowedHeaders:   getSliceEnv("CORS_ALLOWED_HEADERS", []string{"Authorization", "Content-Type", "X-Request-ID", "X-API-Key", "X-Timestamp", "X-Nonce", "Idempotency-Key", "X-CSRF-Token", "X-Tenant-ID"}),
+			ExposedHeaders:   getSliceEnv("CORS_EXPOSED_HEADERS", []string{"X-Request-ID", "X-RateLimit-Limit", "X-RateLimit-Remaining", "Retry-After", "Idempotent-Replayed"}),
 			AllowCredentials: getBoolEnv("CORS_ALLOW_CREDENTIALS", true),
+			MaxAge:           getDurationEnv("CORS_MAX_AGE", 10*time.Minute),
 		},
 		RateLimit: RateLimitConfig{
 			Enabled:      getBoolEnv("RATE_LIMIT_ENABLED", true),
@@ -152,69 +805,809 @@ func Load() (*Config, error) {
 			PerUserBurst: getIntEnv("RATE_LIMIT_PER_USER_BURST", 10),
 			PerIPLimit:   getIntEnv("RATE_LIMIT_PER_IP", 20),
 			PerIPBurst:   getIntEnv("RATE_LIMIT_PER_IP_BURST", 5),
+			PreAuthPerIP: getIntEnv("RATE_LIMIT_PREAUTH_PER_IP", 1000),
+			PreAuthBurst: getIntEnv("RATE_LIMIT_PREAUTH_PER_IP_BURST", 200),
+			Window:       getDurationEnv("RATE_LIMIT_WINDOW", time.Minute),
+
+			TrustForwardedFor: getBoolEnv("RATE_LIMIT_TRUST_FORWARDED_FOR", false),
+
+			ExemptPrincipals: getSliceEnv("RATE_LIMIT_EXEMPT_PRINCIPALS", nil),
+			ExemptIPs:        getSliceEnv("RATE_LIMIT_EXEMPT_IPS", nil),
+			BypassTokens:     getMapEnv("RATE_LIMIT_BYPASS_TOKENS", nil),
 		},
 		Logging: LoggingConfig{
 			Level:      getEnv("LOG_LEVEL", "info"),
 			Format:     getEnv("LOG_FORMAT", "json"),
 			MaskTokens: getBoolEnv("LOG_MASK_TOKENS", true),
+
+			TrustRequestID:      getBoolEnv("REQUEST_ID_TRUST_INCOMING", true),
+			RequestIDTrustedIPs: getSliceEnv("REQUEST_ID_TRUSTED_IPS", nil),
 		},
-	}
+		Admin: AdminConfig{
+			Enabled: getBoolEnv("ADMIN_API_ENABLED", false),
+			Token:   getEnv("ADMIN_API_TOKEN", ""),
 
-	if err := cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("configuration validation failed: %w", err)
-	}
+			RecentErrorsSize: getIntEnv("ADMIN_RECENT_ERRORS_SIZE", 100),
+		},
+		RouteUsage: RouteUsageConfig{
+			Enabled:        getBoolEnv("ROUTE_USAGE_ENABLED", true),
+			UnusedAfter:    time.Duration(getIntEnv("ROUTE_USAGE_UNUSED_DAYS", 30)) * 24 * time.Hour,
+			ReportInterval: getDurationEnv("ROUTE_USAGE_REPORT_INTERVAL", 24*time.Hour),
+			FlushInterval:  getDurationEnv("ROUTE_USAGE_FLUSH_INTERVAL", time.Minute),
+		},
+		Features: FeatureFlagsConfig{
+			Enabled:  getBoolEnv("FEATURE_FLAGS_ENABLED", false),
+			Provider: getEnv("FEATURE_FLAGS_PROVIDER", "redis"),
+			Flags:    getSliceEnv("FEATURE_FLAGS", nil),
+			CacheTTL: getDurationEnv("FEATURE_FLAGS_CACHE_TTL", 30*time.Second),
+			Timeout:  getDurationEnv("FEATURE_FLAGS_TIMEOUT", 200*time.Millisecond),
 
-	globalConfig = cfg
-	cfg.LogConfiguration()
+			LaunchDarklyURL:    getEnv("FEATURE_FLAGS_LAUNCHDARKLY_URL", ""),
+			LaunchDarklySDKKey: getEnv("FEATURE_FLAGS_LAUNCHDARKLY_SDK_KEY", ""),
+		},
+		Maintenance: MaintenanceConfig{
+			SnapshotCacheSize: getIntEnv("MAINTENANCE_SNAPSHOT_CACHE_SIZE", 1000),
+		},
+		Cache: ResponseCacheConfig{
+			Enabled:   getBoolEnv("RESPONSE_CACHE_ENABLED", true),
+			LocalSize: getIntEnv("RESPONSE_CACHE_LOCAL_SIZE", 1000),
+		},
+		Compression: CompressionConfig{
+			Enabled: getBoolEnv("COMPRESSION_ENABLED", true),
+			MinSize: getIntEnv("COMPRESSION_MIN_SIZE", 1024),
+			Level:   getIntEnv("COMPRESSION_LEVEL", 5),
+		},
+		InternalListener: InternalListenerConfig{
+			Enabled:           getBoolEnv("INTERNAL_LISTENER_ENABLED", false),
+			Port:              getEnv("INTERNAL_HTTP_PORT", "8443"),
+			CertFile:          getEnv("INTERNAL_TLS_CERT_FILE", ""),
+			KeyFile:           getEnv("INTERNAL_TLS_KEY_FILE", ""),
+			ClientCAFile:      getEnv("INTERNAL_TLS_CLIENT_CA_FILE", ""),
+			ServicePrincipals: getMapEnv("INTERNAL_MTLS_PRINCIPALS", nil),
+		},
+		GRPCPassthrough: GRPCPassthroughConfig{
+			Enabled:      getBoolEnv("GRPC_PASSTHROUGH_ENABLED", false),
+			Port:         getEnv("GRPC_PASSTHROUGH_PORT", "9090"),
+			AuthRequired: getBoolEnv("GRPC_PASSTHROUGH_AUTH_REQUIRED", true),
+			Services:     getMapEnv("GRPC_PASSTHROUGH_SERVICES", nil),
+		},
+		InternalTokens: InternalTokensConfig{
+			Enabled: getBoolEnv("INTERNAL_TOKENS_ENABLED", false),
+			KeyFile: getEnv("INTERNAL_TOKENS_KEY_FILE", ""),
+			Issuer:  getEnv("INTERNAL_TOKENS_ISSUER", "hub-api-gateway"),
+			TTL:     getDurationEnv("INTERNAL_TOKENS_TTL", time.Minute),
+		},
+		Replay: ReplayConfig{
+			MaxAge: getDurationEnv("REPLAY_MAX_AGE", 5*time.Minute),
+		},
+		Idempotency: IdempotencyConfig{
+			Enabled: getBoolEnv("IDEMPOTENCY_ENABLED", true),
+			Window:  getDurationEnv("IDEMPOTENCY_WINDOW", 24*time.Hour),
+			LockTTL: getDurationEnv("IDEMPOTENCY_LOCK_TTL", time.Minute),
+		},
+		Egress: EgressConfig{
+			Allowlist: getSliceEnv("EGRESS_ALLOWLIST", nil),
+		},
+		Discovery: DiscoveryConfig{
+			Provider:            getEnv("DISCOVERY_PROVIDER", ""),
+			Static:              getMapEnv("DISCOVERY_STATIC", nil),
+			DNSDomain:           getEnv("DISCOVERY_DNS_DOMAIN", ""),
+			DNSInterval:         getDurationEnv("DISCOVERY_DNS_INTERVAL", 30*time.Second),
+			KubernetesAPIURL:    getEnv("DISCOVERY_KUBERNETES_API_URL", ""),
+			KubernetesNamespace: getEnv("DISCOVERY_KUBERNETES_NAMESPACE", ""),
+			KubernetesPortName:  getEnv("DISCOVERY_KUBERNETES_PORT_NAME", ""),
+			ConsulAddress:       getEnv("DISCOVERY_CONSUL_ADDRESS", "http://localhost:8500"),
+			ConsulToken:         getEnv("DISCOVERY_CONSUL_TOKEN", ""),
+		},
+		Tenancy: TenancyConfig{
+			Enabled:          getBoolEnv("TENANCY_ENABLED", false),
+			Sources:          getSliceEnv("TENANT_SOURCES", []string{"claim", "subdomain", "header"}),
+			Header:           getEnv("TENANT_HEADER", "X-Tenant-ID"),
+			BaseDomain:       getEnv("TENANT_BASE_DOMAIN", ""),
+			Allowed:          getSliceEnv("TENANT_ALLOWED", nil),
+			Default:          getEnv("TENANT_DEFAULT", ""),
+			Required:         getBoolEnv("TENANT_REQUIRED", false),
+			ServiceAddresses: getMapEnv("TENANT_SERVICE_ADDRESSES", nil),
+		},
+		Recording: RecordingConfig{
+			Enabled:      getBoolEnv("RECORDING_ENABLED", false),
+			Store:        getEnv("RECORDING_STORE", "file"),
+			Path:         getEnv("RECORDING_PATH", "recordings.jsonl"),
+			RedisKey:     getEnv("RECORDING_REDIS_KEY", "recordings"),
+			MaxEntries:   getIntEnv("RECORDING_MAX_ENTRIES", 10000),
+			Percent:      getFloatEnv("RECORDING_PERCENT", 100),
+			MaxBodyBytes: getIntEnv("RECORDING_MAX_BODY_BYTES", 64<<10),
+			RedactFields: getSliceEnv("RECORDING_REDACT_FIELDS", []string{"password", "token", "access_token", "refresh_token", "secret", "cpf", "card_number", "cvv"}),
+		},
+		Tracing: TracingConfig{
+			Token:     getEnv("TRACE_TOKEN", ""),
+			TTL:       getDurationEnv("TRACE_TTL", 24*time.Hour),
+			MaxTraces: getIntEnv("TRACE_MAX_TRACES", 1000),
+		},
+		Bundle: BundleConfig{
+			Location:        getEnv("CONFIG_BUNDLE_URL", ""),
+			Key:             getEnv("CONFIG_BUNDLE_KEY", ""),
+			KeyFile:         getEnv("CONFIG_BUNDLE_KEY_FILE", ""),
+			RefreshInterval: getDurationEnv("CONFIG_BUNDLE_REFRESH_INTERVAL", 5*time.Minute),
+			FetchTimeout:    getDurationEnv("CONFIG_BUNDLE_FETCH_TIMEOUT", 10*time.Second),
+		},
+		Secrets: SecretsConfig{
+			Provider:           getEnv("SECRETS_PROVIDER", ""),
+			Refs:               getMapEnv("SECRETS_MAP", nil),
+			RefreshInterval:    getDurationEnv("SECRETS_REFRESH_INTERVAL", 5*time.Minute),
+			RotationGrace:      getDurationEnv("SECRETS_ROTATION_GRACE", time.Hour),
+			FetchTimeout:       getDurationEnv("SECRETS_FETCH_TIMEOUT", 10*time.Second),
+			VaultAddress:       getEnv("VAULT_ADDR", ""),
+			VaultToken:         getEnv("VAULT_TOKEN", ""),
+			VaultNamespace:     getEnv("VAULT_NAMESPACE", ""),
+			AWSRegion:          getEnv("AWS_REGION", ""),
+			AWSEndpoint:        getEnv("SECRETS_AWS_ENDPOINT", ""),
+			AWSAccessKeyID:     getEnv("AWS_ACCESS_KEY_ID", ""),
+			AWSSecretAccessKey: getEnv("AWS_SECRET_ACCESS_KEY", ""),
+			AWSSessionToken:    getEnv("AWS_SESSION_TOKEN", ""),
+		},
+		ControlPlane: ControlPlaneConfig{
+			URL:          strings.TrimSuffix(getEnv("CONTROL_PLANE_URL", ""), "/"),
+			Token:        getEnv("CONTROL_PLANE_TOKEN", ""),
+			InstanceID:   getEnv("CONTROL_PLANE_INSTANCE_ID", hostname()),
+			PollInterval: getDurationEnv("CONTROL_PLANE_POLL_INTERVAL", 30*time.Second),
+			LongPollWait: getDurationEnv("CONTROL_PLANE_LONG_POLL_WAIT", 0),
+			Timeout:      getDurationEnv("CONTROL_PLANE_TIMEOUT", 10*time.Second),
+		},
+		Proxy: ProxyConfig{
+			DescriptorSets:  getSliceEnv("PROTO_DESCRIPTOR_SETS", nil),
+			GRPCErrorStatus: getMapEnv("GRPC_ERROR_STATUS", nil),
+
+			ConnectionPoolSize: getIntEnv("GRPC_CONNECTION_POOL_SIZE", 1),
+			WarmUpEnabled:      getBoolEnv("GRPC_WARMUP_ENABLED", false),
+			WarmUpTimeout:      getDurationEnv("GRPC_WARMUP_TIMEOUT", 10*time.Second),
+
+			RetryEnabled:     getBoolEnv("RETRY_ENABLED", true),
+			RetryBaseDelay:   getDurationEnv("RETRY_BASE_DELAY", 50*time.Millisecond),
+			RetryMaxDelay:    getDurationEnv("RETRY_MAX_DELAY", time.Second),
+			RetryBudgetRatio: getFloatEnv("RETRY_BUDGET_RATIO", 0.2),
+			RetryBudgetBurst: getIntEnv("RETRY_BUDGET_BURST", 10),
+
+			CircuitBreakerEnabled:   getBoolEnv("CIRCUIT_BREAKER_ENABLED", true),
+			CircuitBreakerThreshold: getIntEnv("CIRCUIT_BREAKER_THRESHOLD", 5),
+			CircuitBreakerTimeout:   getDurationEnv("CIRCUIT_BREAKER_TIMEOUT", 30*time.Second),
+			CircuitBreakerHalfOpen:  getIntEnv("CIRCUIT_BREAKER_HALF_OPEN_REQUESTS", 3),
+
+			CircuitBreakerFailureRate: getFloatEnv("CIRCUIT_BREAKER_FAILURE_RATE", 0),
+			CircuitBreakerMinRequests: getIntEnv("CIRCUIT_BREAKER_MIN_REQUESTS", 20),
+			CircuitBreakerWindow:      getDurationEnv("CIRCUIT_BREAKER_WINDOW", 30*time.Second),
+
+			HealthProbeInterval: getDurationEnv("HEALTH_PROBE_INTERVAL", 10*time.Second),
+			HealthProbeTimeout:  getDurationEnv("HEALTH_PROBE_TIMEOUT", 2*time.Second),
+
+			OutlierDetectionEnabled: getBoolEnv("OUTLIER_DETECTION_ENABLED", true),
+			OutlierErrorRate:        getFloatEnv("OUTLIER_ERROR_RATE", 0.5),
+			OutlierMinRequests:      getIntEnv("OUTLIER_MIN_REQUESTS", 20),
+			OutlierWindow:           getDurationEnv("OUTLIER_WINDOW", 30*time.Second),
+			OutlierEjectionTime:     getDurationEnv("OUTLIER_EJECTION_TIME", 30*time.Second),
+
+			MirrorMaxInFlight: getIntEnv("MIRROR_MAX_IN_FLIGHT", 100),
+		},
+		Watchdog: WatchdogConfig{
+			Enabled:           getBoolEnv("WATCHDOG_ENABLED", true),
+			TimeoutMultiplier: getFloatEnv("WATCHDOG_TIMEOUT_MULTIPLIER", 2),
+			Interval:          getDurationEnv("WATCHDOG_INTERVAL", time.Second),
+			DumpInterval:      getDurationEnv("WATCHDOG_DUMP_INTERVAL", time.Minute),
+			DumpMaxBytes:      getIntEnv("WATCHDOG_DUMP_MAX_BYTES", 64<<10),
+		},
+		Bulkhead: BulkheadConfig{
+			Enabled:      getBoolEnv("BULKHEAD_ENABLED", true),
+			ServiceLimit: getIntEnv("BULKHEAD_SERVICE_LIMIT", 200),
+			ClientLimit:  getIntEnv("BULKHEAD_CLIENT_LIMIT", 20),
+			QueueTimeout: getDurationEnv("BULKHEAD_QUEUE_TIMEOUT", 100*time.Millisecond),
+		},
+		Faults: FaultInjectionConfig{
+			Enabled:      getBoolEnv("FAULT_INJECTION_ENABLED", false),
+			Environments: getSliceEnv("FAULT_INJECTION_ENVIRONMENTS", []string{"development", "staging"}),
+		},
+		AdaptiveLimit: AdaptiveLimitConfig{
+			Enabled:      getBoolEnv("ADAPTIVE_LIMIT_ENABLED", false),
+			InitialLimit: getIntEnv("ADAPTIVE_LIMIT_INITIAL", 50),
+			MinLimit:     getIntEnv("ADAPTIVE_LIMIT_MIN", 5),
+			MaxLimit:     getIntEnv("ADAPTIVE_LIMIT_MAX", 500),
+			Tolerance:    getFloatEnv("ADAPTIVE_LIMIT_TOLERANCE", 2.0),
+		},
+		LoadShed: LoadShedConfig{
+			Enabled:    getBoolEnv("LOAD_SHED_ENABLED", true),
+			HeapMB:     getIntEnv("LOAD_SHED_HEAP_MB", 0),
+			Goroutines: getIntEnv("LOAD_SHED_GOROUTINES", 10000),
+			GCPause:    getDurationEnv("LOAD_SHED_GC_PAUSE", 100*time.Millisecond),
+			Interval:   getDurationEnv("LOAD_SHED_INTERVAL", time.Second),
+		},
+		Stream: StreamConfig{
+			BufferSize:         getIntEnv("STREAM_BUFFER_SIZE", 64),
+			SlowConsumerPolicy: getEnv("STREAM_SLOW_CONSUMER_POLICY", "drop_oldest"),
+			HeartbeatInterval:  getDurationEnv("STREAM_HEARTBEAT_INTERVAL", 15*time.Second),
+		},
+		WebSocket: WebSocketConfig{
+			PingInterval:    getDurationEnv("WEBSOCKET_PING_INTERVAL", 30*time.Second),
+			PongTimeout:     getDurationEnv("WEBSOCKET_PONG_TIMEOUT", 60*time.Second),
+			WriteTimeout:    getDurationEnv("WEBSOCKET_WRITE_TIMEOUT", 10*time.Second),
+			MaxMessageBytes: getIntEnv("WEBSOCKET_MAX_MESSAGE_BYTES", 64<<10),
+			AllowedOrigins:  getSliceEnv("WEBSOCKET_ALLOWED_ORIGINS", nil),
+		},
+		LongPoll: LongPollConfig{
+			MaxWait:  getDurationEnv("LONG_POLL_MAX_WAIT", 25*time.Second),
+			Interval: getDurationEnv("LONG_POLL_INTERVAL", 1*time.Second),
+		},
+		Audit: AuditConfig{
+			Enabled:     getBoolEnv("AUDIT_ENABLED", false),
+			Sink:        getEnv("AUDIT_SINK", "file"),
+			FilePath:    getEnv("AUDIT_LOG_PATH", "audit.log"),
+			StatePath:   getEnv("AUDIT_STATE_PATH", "audit.state"),
+			SigningKeys: getMapEnv("AUDIT_SIGNING_KEYS", nil),
+			ActiveKeyID: getEnv("AUDIT_ACTIVE_KEY_ID", ""),
+
+			SyslogNetwork: getEnv("AUDIT_SYSLOG_NETWORK", "udp"),
+			SyslogAddress: getEnv("AUDIT_SYSLOG_ADDRESS", ""),
+			SyslogTag:     getEnv("AUDIT_SYSLOG_TAG", "hub-api-gateway-audit"),
+
+			KafkaRESTURL: getEnv("AUDIT_KAFKA_REST_URL", ""),
+			KafkaTopic:   getEnv("AUDIT_KAFKA_TOPIC", "gateway-audit"),
+			KafkaKey:     getEnv("AUDIT_KAFKA_KEY", hostname()),
+			KafkaTimeout: getDurationEnv("AUDIT_KAFKA_TIMEOUT", 5*time.Second),
+			KafkaQueue:   getIntEnv("AUDIT_KAFKA_QUEUE_SIZE", 1000),
+		},
+		Errors: ErrorsConfig{
+			ProblemJSON: getBoolEnv("ERRORS_PROBLEM_JSON", false),
+			DocsBaseURL: getEnv("ERRORS_DOCS_BASE_URL", "https://docs.hubinvestments.com/api"),
+			Templates:   getEnv("ERRORS_TEMPLATES_FILE", ""),
+
+			Locales:       getEnv("ERRORS_LOCALES_DIR", ""),
+			DefaultLocale: getEnv("ERRORS_DEFAULT_LOCALE", "en"),
+		},
+		Docs: DocsConfig{
+			Enabled:      getBoolEnv("DOCS_ENABLED", true),
+			SwaggerUIURL: getEnv("DOCS_SWAGGER_UI_URL", "https://unpkg.com/swagger-ui-dist@5"),
+		},
+		Status: StatusConfig{
+			Enabled:  getBoolEnv("STATUS_ENABLED", true),
+			CacheTTL: getDurationEnv("STATUS_CACHE_TTL", 15*time.Second),
+			ProductAreas: getMapEnv("STATUS_PRODUCT_AREAS", map[string]string{
+				"user-service":        "Accounts",
+				"order-service":       "Trading",
+				"position-service":    "Portfolio",
+				"market-data-service": "Market Data",
+			}),
+			MinRequests:          getIntEnv("STATUS_MIN_REQUESTS", 20),
+			DegradedErrorPercent: getIntEnv("STATUS_DEGRADED_ERROR_PERCENT", 5),
+			OutageErrorPercent:   getIntEnv("STATUS_OUTAGE_ERROR_PERCENT", 50),
+		},
+	}
+	cfg.addTenantServices()
 
 	return cfg, nil
 }
 
+// addTenantServices registers the tenants' own deployments of services.
+// Entries naming unknown services are skipped here and reported by Validate.
+func (c *Config) addTenantServices() {
+	for key, address := range c.Tenancy.ServiceAddresses {
+		tenant, service, _ := strings.Cut(key, "/")
+		shared, ok := c.Services[service]
+		if !ok || tenant == "" {
+			continue
+		}
+		shared.Address = address
+		c.Services[TenantServiceName(service, tenant)] = shared
+	}
+}
+
 // Get returns the global configuration
 func Get() *Config {
 	if globalConfig == nil {
-		log.Fatal("Configuration not loaded. Call Load() first.")
+		slog.Error("configuration not loaded, call Load() first")
+		os.Exit(1)
 	}
 	return globalConfig
 }
 
 // Validate validates the configuration
 func (c *Config) Validate() error {
-	if c.Auth.JWTSecret == "" {
+	if c.Auth.JWTSecret == "" && !c.Secrets.Has("JWT_SECRET") {
 		return fmt.Errorf("JWT_SECRET environment variable is required")
 	}
 
-	if len(c.Auth.JWTSecret) < 32 {
-		log.Println("⚠️  WARNING: JWT secret is shorter than 32 characters. Use a stronger secret in production!")
+	if c.Auth.JWTSecret != "" && len(c.Auth.JWTSecret) < 32 {
+		slog.Warn("JWT secret is shorter than 32 characters, use a stronger secret in production")
+	}
+
+	switch c.Auth.DefaultProvider {
+	case "user-service", "jwt":
+	case "oidc":
+		if c.Auth.OIDCJWKSURL == "" {
+			return fmt.Errorf("AUTH_OIDC_JWKS_URL is required when AUTH_DEFAULT_PROVIDER=oidc")
+		}
+	case "introspection":
+		if c.Auth.IntrospectionURL == "" {
+			return fmt.Errorf("AUTH_INTROSPECTION_URL is required when AUTH_DEFAULT_PROVIDER=introspection")
+		}
+	default:
+		return fmt.Errorf("unsupported AUTH_DEFAULT_PROVIDER: %s", c.Auth.DefaultProvider)
+	}
+
+	if c.Auth.OIDCClientID != "" {
+		if c.Auth.OIDCIssuer == "" || c.Auth.OIDCRedirectURL == "" {
+			return fmt.Errorf("AUTH_OIDC_ISSUER and AUTH_OIDC_REDIRECT_URL are required when AUTH_OIDC_CLIENT_ID is set")
+		}
+		if !slices.Contains(c.Auth.OIDCScopes, "openid") {
+			return fmt.Errorf("AUTH_OIDC_SCOPES must include openid")
+		}
+	}
+
+	// Tickets are signed with their own secret so a ticket can never pass as an access token
+	if c.Auth.ReconnectTicketsEnabled {
+		if c.Auth.ReconnectTicketSecret == "" {
+			return fmt.Errorf("AUTH_RECONNECT_TICKET_SECRET is required when AUTH_RECONNECT_TICKETS_ENABLED=true")
+		}
+		if c.Auth.ReconnectTicketSecret == c.Auth.JWTSecret {
+			return fmt.Errorf("AUTH_RECONNECT_TICKET_SECRET must differ from JWT_SECRET")
+		}
+	}
+
+	if c.Auth.RefreshTokensEnabled && c.Auth.RefreshTokenTTL <= 0 {
+		return fmt.Errorf("AUTH_REFRESH_TOKEN_TTL must be positive when refresh tokens are enabled")
+	}
+
+	switch c.Auth.SessionCookieSameSite {
+	case "lax", "strict", "none":
+	default:
+		return fmt.Errorf("AUTH_SESSION_COOKIE_SAMESITE must be lax, strict or none, got %s", c.Auth.SessionCookieSameSite)
+	}
+
+	if c.Auth.MaxSessions < 0 {
+		return fmt.Errorf("AUTH_MAX_SESSIONS must not be negative")
+	}
+	switch c.Auth.SessionLimitMode {
+	case "reject", "evict_oldest":
+	default:
+		return fmt.Errorf("AUTH_SESSION_LIMIT_MODE must be reject or evict_oldest, got %s", c.Auth.SessionLimitMode)
+	}
+
+	if c.Auth.LoginGuardEnabled {
+		if c.Auth.LoginFreeAttempts < 0 || c.Auth.LoginLockoutThreshold < 0 || c.Auth.LoginIPLockoutThreshold < 0 || c.Auth.LoginStuffingThreshold < 0 {
+			return fmt.Errorf("AUTH_LOGIN_FREE_ATTEMPTS and the AUTH_LOGIN_*_THRESHOLD settings must not be negative")
+		}
+		if c.Auth.LoginBackoffBase < 0 || c.Auth.LoginBackoffMax < c.Auth.LoginBackoffBase {
+			return fmt.Errorf("AUTH_LOGIN_BACKOFF_BASE must not be negative nor exceed AUTH_LOGIN_BACKOFF_MAX")
+		}
+		if c.Auth.LoginLockoutDuration <= 0 || c.Auth.LoginFailureWindow < c.Auth.LoginLockoutDuration {
+			return fmt.Errorf("AUTH_LOGIN_LOCKOUT_DURATION must be positive and not exceed AUTH_LOGIN_FAILURE_WINDOW")
+		}
+	}
+
+	switch c.Auth.LoginChallengeProvider {
+	case "":
+	case "recaptcha", "hcaptcha", "turnstile":
+		if !c.Auth.LoginGuardEnabled {
+			return fmt.Errorf("AUTH_LOGIN_CHALLENGE_PROVIDER needs AUTH_LOGIN_GUARD_ENABLED=true to flag logins")
+		}
+		if c.Auth.LoginChallengeSecret == "" {
+			return fmt.Errorf("AUTH_LOGIN_CHALLENGE_SECRET is required when AUTH_LOGIN_CHALLENGE_PROVIDER is set")
+		}
+		if c.Auth.LoginChallengeMinScore < 0 || c.Auth.LoginChallengeMinScore > 1 {
+			return fmt.Errorf("AUTH_LOGIN_CHALLENGE_MIN_SCORE must be between 0 and 1")
+		}
+	default:
+		return fmt.Errorf("AUTH_LOGIN_CHALLENGE_PROVIDER must be recaptcha, hcaptcha or turnstile, got %s", c.Auth.LoginChallengeProvider)
+	}
+
+	if c.Proxy.RetryEnabled {
+		if c.Proxy.RetryBaseDelay <= 0 || c.Proxy.RetryMaxDelay < c.Proxy.RetryBaseDelay {
+			return fmt.Errorf("RETRY_BASE_DELAY must be positive and not exceed RETRY_MAX_DELAY")
+		}
+		if c.Proxy.RetryBudgetRatio < 0 || c.Proxy.RetryBudgetBurst < 0 {
+			return fmt.Errorf("RETRY_BUDGET_RATIO and RETRY_BUDGET_BURST must not be negative")
+		}
+	}
+
+	if c.Proxy.ConnectionPoolSize <= 0 {
+		return fmt.Errorf("GRPC_CONNECTION_POOL_SIZE must be positive")
+	}
+	if c.Proxy.WarmUpEnabled && c.Proxy.WarmUpTimeout <= 0 {
+		return fmt.Errorf("GRPC_WARMUP_TIMEOUT must be positive when GRPC_WARMUP_ENABLED is true")
+	}
+	for name, svc := range c.Services {
+		if svc.PoolSize < 0 {
+			return fmt.Errorf("connection pool size for %s must not be negative", name)
+		}
+	}
+
+	if c.Proxy.CircuitBreakerEnabled {
+		if c.Proxy.CircuitBreakerThreshold <= 0 || c.Proxy.CircuitBreakerTimeout <= 0 || c.Proxy.CircuitBreakerHalfOpen <= 0 {
+			return fmt.Errorf("CIRCUIT_BREAKER_THRESHOLD, CIRCUIT_BREAKER_TIMEOUT and CIRCUIT_BREAKER_HALF_OPEN_REQUESTS must be positive")
+		}
+		if c.Proxy.CircuitBreakerFailureRate < 0 || c.Proxy.CircuitBreakerFailureRate > 1 {
+			return fmt.Errorf("CIRCUIT_BREAKER_FAILURE_RATE must be between 0 and 1")
+		}
+		if c.Proxy.CircuitBreakerMinRequests <= 0 || c.Proxy.CircuitBreakerWindow <= 0 {
+			return fmt.Errorf("CIRCUIT_BREAKER_MIN_REQUESTS and CIRCUIT_BREAKER_WINDOW must be positive")
+		}
+		for name, svc := range c.Services {
+			if svc.BreakerThreshold < 0 || svc.BreakerTimeout < 0 || svc.BreakerHalfOpen < 0 {
+				return fmt.Errorf("circuit breaker overrides for %s must not be negative", name)
+			}
+			if svc.BreakerFailureRate < 0 || svc.BreakerFailureRate > 1 {
+				return fmt.Errorf("circuit breaker failure rate for %s must be between 0 and 1", name)
+			}
+		}
+	}
+
+	if c.Watchdog.Enabled {
+		if c.Watchdog.TimeoutMultiplier < 1 {
+			return fmt.Errorf("WATCHDOG_TIMEOUT_MULTIPLIER must be at least 1, got %g", c.Watchdog.TimeoutMultiplier)
+		}
+		if c.Watchdog.Interval <= 0 || c.Watchdog.DumpInterval < 0 || c.Watchdog.DumpMaxBytes <= 0 {
+			return fmt.Errorf("WATCHDOG_INTERVAL and WATCHDOG_DUMP_MAX_BYTES must be positive and WATCHDOG_DUMP_INTERVAL not negative")
+		}
+	}
+
+	if c.RouteUsage.Enabled && (c.RouteUsage.UnusedAfter <= 0 || c.RouteUsage.ReportInterval < 0 || c.RouteUsage.FlushInterval <= 0) {
+		return fmt.Errorf("ROUTE_USAGE_UNUSED_DAYS and ROUTE_USAGE_FLUSH_INTERVAL must be positive, ROUTE_USAGE_REPORT_INTERVAL non-negative")
+	}
+
+	if c.Cache.Enabled && c.Cache.LocalSize <= 0 {
+		return fmt.Errorf("RESPONSE_CACHE_LOCAL_SIZE must be positive")
+	}
+
+	if c.Recording.Enabled {
+		switch c.Recording.Store {
+		case "file", "redis":
+		default:
+			return fmt.Errorf("RECORDING_STORE must be file or redis, got %s", c.Recording.Store)
+		}
+		if c.Recording.Percent <= 0 || c.Recording.Percent > 100 || c.Recording.MaxBodyBytes <= 0 || c.Recording.MaxEntries <= 0 {
+			return fmt.Errorf("RECORDING_PERCENT must be in (0, 100], RECORDING_MAX_BODY_BYTES and RECORDING_MAX_ENTRIES positive")
+		}
+	}
+
+	// Injected faults must never reach production traffic
+	if c.Faults.Enabled && !slices.Contains(c.Faults.Environments, c.Server.Environment) {
+		return fmt.Errorf("FAULT_INJECTION_ENABLED is only allowed when ENVIRONMENT is one of %s, got %s",
+			strings.Join(c.Faults.Environments, ", "), c.Server.Environment)
+	}
+
+	if c.LoadShed.Enabled {
+		if c.LoadShed.HeapMB < 0 || c.LoadShed.Goroutines < 0 || c.LoadShed.GCPause < 0 {
+			return fmt.Errorf("LOAD_SHED_HEAP_MB, LOAD_SHED_GOROUTINES and LOAD_SHED_GC_PAUSE must not be negative")
+		}
+		if c.LoadShed.Interval <= 0 {
+			return fmt.Errorf("LOAD_SHED_INTERVAL must be positive")
+		}
+	}
+
+	if c.Stream.BufferSize <= 0 {
+		return fmt.Errorf("STREAM_BUFFER_SIZE must be positive")
+	}
+	switch c.Stream.SlowConsumerPolicy {
+	case "drop_oldest", "disconnect":
+	default:
+		return fmt.Errorf("STREAM_SLOW_CONSUMER_POLICY must be drop_oldest or disconnect, got %s", c.Stream.SlowConsumerPolicy)
+	}
+
+	if c.WebSocket.PingInterval <= 0 || c.WebSocket.WriteTimeout <= 0 || c.WebSocket.MaxMessageBytes <= 0 {
+		return fmt.Errorf("WEBSOCKET_PING_INTERVAL, WEBSOCKET_WRITE_TIMEOUT and WEBSOCKET_MAX_MESSAGE_BYTES must be positive")
+	}
+	if c.WebSocket.PongTimeout <= c.WebSocket.PingInterval {
+		return fmt.Errorf("WEBSOCKET_PONG_TIMEOUT must be longer than WEBSOCKET_PING_INTERVAL")
+	}
+
+	switch strings.ToLower(c.Logging.Level) {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		return fmt.Errorf("LOG_LEVEL must be debug, info, warn or error, got %s", c.Logging.Level)
+	}
+
+	switch strings.ToLower(c.Logging.Format) {
+	case "json", "text":
+	default:
+		return fmt.Errorf("LOG_FORMAT must be json or text, got %s", c.Logging.Format)
 	}
 
 	if c.Server.Port == "" {
 		return fmt.Errorf("HTTP_PORT is required")
 	}
 
+	if c.Server.ReadHeaderTimeout <= 0 {
+		return fmt.Errorf("SERVER_READ_HEADER_TIMEOUT must be positive")
+	}
+
 	if c.Redis.Host == "" {
 		return fmt.Errorf("REDIS_HOST is required")
 	}
 
+	if c.Admin.Enabled && len(c.Admin.Token) < 16 {
+		return fmt.Errorf("ADMIN_API_TOKEN of at least 16 characters is required when ADMIN_API_ENABLED=true")
+	}
+
+	if c.Features.Enabled {
+		switch c.Features.Provider {
+		case "redis":
+		case "launchdarkly":
+			if c.Features.LaunchDarklyURL == "" {
+				return fmt.Errorf("FEATURE_FLAGS_LAUNCHDARKLY_URL is required when FEATURE_FLAGS_PROVIDER=launchdarkly")
+			}
+		default:
+			return fmt.Errorf("unsupported FEATURE_FLAGS_PROVIDER: %s", c.Features.Provider)
+		}
+	}
+
+	if c.Audit.Enabled {
+		if _, ok := c.Audit.SigningKeys[c.Audit.ActiveKeyID]; !ok {
+			return fmt.Errorf("AUDIT_ACTIVE_KEY_ID must name a key in AUDIT_SIGNING_KEYS when AUDIT_ENABLED=true")
+		}
+		switch c.Audit.Sink {
+		case "file", "syslog":
+		case "kafka":
+			if c.Audit.KafkaRESTURL == "" || c.Audit.KafkaTopic == "" {
+				return fmt.Errorf("AUDIT_KAFKA_REST_URL and AUDIT_KAFKA_TOPIC are required when AUDIT_SINK=kafka")
+			}
+			if c.Audit.KafkaQueue <= 0 {
+				return fmt.Errorf("AUDIT_KAFKA_QUEUE_SIZE must be positive")
+			}
+		default:
+			return fmt.Errorf("AUDIT_SINK must be file, syslog or kafka, got %s", c.Audit.Sink)
+		}
+	}
+
+	if c.TLS.Enabled {
+		fromPEM := (c.TLS.CertPEM != "" || c.Secrets.Has("TLS_CERT_PEM")) && (c.TLS.KeyPEM != "" || c.Secrets.Has("TLS_KEY_PEM"))
+		if !fromPEM && (c.TLS.CertFile == "" || c.TLS.KeyFile == "") {
+			return fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE (or TLS_CERT_PEM and TLS_KEY_PEM) are required when TLS_ENABLED=true")
+		}
+		if c.TLS.ReloadInterval <= 0 {
+			return fmt.Errorf("TLS_RELOAD_INTERVAL must be positive")
+		}
+		if c.TLS.MinVersion != "1.2" && c.TLS.MinVersion != "1.3" {
+			return fmt.Errorf("TLS_MIN_VERSION must be 1.2 or 1.3, got %s", c.TLS.MinVersion)
+		}
+		if c.TLS.RedirectEnabled && c.TLS.RedirectPort == c.Server.Port {
+			return fmt.Errorf("TLS_REDIRECT_PORT must differ from HTTP_PORT")
+		}
+	}
+
+	if c.InternalListener.Enabled {
+		if c.InternalListener.CertFile == "" || c.InternalListener.KeyFile == "" || c.InternalListener.ClientCAFile == "" {
+			return fmt.Errorf("INTERNAL_TLS_CERT_FILE, INTERNAL_TLS_KEY_FILE and INTERNAL_TLS_CLIENT_CA_FILE are required when INTERNAL_LISTENER_ENABLED=true")
+		}
+		if c.InternalListener.Port == c.Server.Port {
+			return fmt.Errorf("INTERNAL_HTTP_PORT must differ from HTTP_PORT")
+		}
+	}
+
+	if c.GRPCPassthrough.Enabled {
+		if c.GRPCPassthrough.Port == c.Server.Port || (c.InternalListener.Enabled && c.GRPCPassthrough.Port == c.InternalListener.Port) {
+			return fmt.Errorf("GRPC_PASSTHROUGH_PORT must differ from HTTP_PORT and INTERNAL_HTTP_PORT")
+		}
+	}
+
+	if c.InternalTokens.Enabled && c.InternalTokens.TTL <= 0 {
+		return fmt.Errorf("INTERNAL_TOKENS_TTL must be positive when internal tokens are enabled")
+	}
+
+	if c.Server.RouteAmbiguity != "warn" && c.Server.RouteAmbiguity != "fail" {
+		return fmt.Errorf("ROUTE_AMBIGUITY_MODE must be warn or fail")
+	}
+
+	if c.RateLimit.Enabled {
+		if c.RateLimit.PerUserLimit <= 0 || c.RateLimit.PerIPLimit <= 0 || c.RateLimit.Window <= 0 {
+			return fmt.Errorf("RATE_LIMIT_PER_USER, RATE_LIMIT_PER_IP and RATE_LIMIT_WINDOW must be positive when rate limiting is enabled")
+		}
+		if c.RateLimit.PreAuthPerIP < 0 {
+			return fmt.Errorf("RATE_LIMIT_PREAUTH_PER_IP must not be negative")
+		}
+		for name, token := range c.RateLimit.BypassTokens {
+			if len(token) < 32 {
+				return fmt.Errorf("RATE_LIMIT_BYPASS_TOKENS: token %s must be at least 32 characters", name)
+			}
+		}
+	}
+
+	if c.Replay.MaxAge <= 0 {
+		return fmt.Errorf("REPLAY_MAX_AGE must be positive")
+	}
+
+	if c.Tracing.Token != "" && (c.Tracing.TTL <= 0 || c.Tracing.MaxTraces <= 0) {
+		return fmt.Errorf("TRACE_TTL and TRACE_MAX_TRACES must be positive when tracing is enabled")
+	}
+
+	if c.Bundle.Location != "" {
+		if c.Bundle.Key == "" && c.Bundle.KeyFile == "" {
+			return fmt.Errorf("CONFIG_BUNDLE_KEY or CONFIG_BUNDLE_KEY_FILE is required when CONFIG_BUNDLE_URL is set")
+		}
+		if c.Bundle.RefreshInterval < 0 {
+			return fmt.Errorf("CONFIG_BUNDLE_REFRESH_INTERVAL must not be negative")
+		}
+	}
+
+	if c.Secrets.Provider != "" {
+		switch c.Secrets.Provider {
+		case "vault":
+			if c.Secrets.VaultAddress == "" || c.Secrets.VaultToken == "" {
+				return fmt.Errorf("VAULT_ADDR and VAULT_TOKEN are required when SECRETS_PROVIDER=vault")
+			}
+		case "aws":
+			if c.Secrets.AWSRegion == "" || c.Secrets.AWSAccessKeyID == "" || c.Secrets.AWSSecretAccessKey == "" {
+				return fmt.Errorf("AWS_REGION, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are required when SECRETS_PROVIDER=aws")
+			}
+		default:
+			return fmt.Errorf("SECRETS_PROVIDER must be vault or aws, got %s", c.Secrets.Provider)
+		}
+		if len(c.Secrets.Refs) == 0 {
+			return fmt.Errorf("SECRETS_MAP is required when SECRETS_PROVIDER is set")
+		}
+		if c.Secrets.RefreshInterval < 0 {
+			return fmt.Errorf("SECRETS_REFRESH_INTERVAL must not be negative")
+		}
+		if c.Secrets.RotationGrace < 0 || c.Secrets.FetchTimeout <= 0 {
+			return fmt.Errorf("SECRETS_ROTATION_GRACE must not be negative and SECRETS_FETCH_TIMEOUT must be positive")
+		}
+	} else if len(c.Secrets.Refs) > 0 {
+		return fmt.Errorf("SECRETS_MAP is set but SECRETS_PROVIDER is not")
+	}
+
+	if c.ControlPlane.URL != "" {
+		if c.ControlPlane.PollInterval <= 0 {
+			return fmt.Errorf("CONTROL_PLANE_POLL_INTERVAL must be positive")
+		}
+		if c.ControlPlane.LongPollWait < 0 {
+			return fmt.Errorf("CONTROL_PLANE_LONG_POLL_WAIT must not be negative")
+		}
+	}
+
+	if c.LongPoll.MaxWait > 0 {
+		if c.LongPoll.MaxWait >= c.Server.Timeout {
+			return fmt.Errorf("LONG_POLL_MAX_WAIT must be shorter than SERVER_TIMEOUT")
+		}
+		if c.LongPoll.Interval <= 0 {
+			return fmt.Errorf("LONG_POLL_INTERVAL must be positive")
+		}
+	}
+
+	if c.Status.Enabled && c.Status.DegradedErrorPercent > c.Status.OutageErrorPercent {
+		return fmt.Errorf("STATUS_DEGRADED_ERROR_PERCENT must not exceed STATUS_OUTAGE_ERROR_PERCENT")
+	}
+
+	if c.Tenancy.Enabled {
+		for _, source := range c.Tenancy.Sources {
+			if source != "claim" && source != "subdomain" && source != "header" {
+				return fmt.Errorf("TENANT_SOURCES must list claim, subdomain or header, got %s", source)
+			}
+			if source == "subdomain" && c.Tenancy.BaseDomain == "" {
+				return fmt.Errorf("TENANT_BASE_DOMAIN is required when TENANT_SOURCES includes subdomain")
+			}
+		}
+		if c.Tenancy.Default != "" && len(c.Tenancy.Allowed) > 0 && !slices.Contains(c.Tenancy.Allowed, c.Tenancy.Default) {
+			return fmt.Errorf("TENANT_DEFAULT %s is not in TENANT_ALLOWED", c.Tenancy.Default)
+		}
+	}
+	for key := range c.Tenancy.ServiceAddresses {
+		tenant, service, _ := strings.Cut(key, "/")
+		if _, ok := c.Services[service]; !ok || tenant == "" {
+			return fmt.Errorf("TENANT_SERVICE_ADDRESSES: %s must be tenant/service naming a known service", key)
+		}
+	}
+
 	userServiceAddr := c.Services["user-service"].Address
 	if userServiceAddr == "" {
 		return fmt.Errorf("USER_SERVICE_ADDRESS is required")
 	}
 
+	switch c.Discovery.Provider {
+	case "", "static", "dns", "kubernetes", "consul":
+	default:
+		return fmt.Errorf("DISCOVERY_PROVIDER must be static, dns, kubernetes or consul, got %s", c.Discovery.Provider)
+	}
+	for name, svc := range c.Services {
+		if strings.HasPrefix(svc.Address, "discovery://") && c.Discovery.Provider == "" {
+			return fmt.Errorf("service %s uses %s but DISCOVERY_PROVIDER is not set", name, svc.Address)
+		}
+	}
+
 	return nil
 }
 
 // LogConfiguration logs the loaded configuration (with sensitive data masked)
 func (c *Config) LogConfiguration() {
-	log.Println("✅ Configuration loaded successfully:")
-	log.Printf("   Server: localhost:%s (timeout: %v)", c.Server.Port, c.Server.Timeout)
-	log.Printf("   Redis: %s:%s (cache TTL: %v)", c.Redis.Host, c.Redis.Port, c.Redis.TokenCacheTTL)
-	log.Printf("   JWT Secret: %s (length: %d bytes)", maskSecret(c.Auth.JWTSecret), len(c.Auth.JWTSecret))
-	log.Printf("   User Service: %s", c.Services["user-service"].Address)
-	log.Printf("   CORS: enabled=%v, origins=%v", c.CORS.Enabled, c.CORS.AllowedOrigins)
-	log.Printf("   Rate Limit: enabled=%v, per_user=%d/min, per_ip=%d/min",
-		c.RateLimit.Enabled, c.RateLimit.PerUserLimit, c.RateLimit.PerIPLimit)
-	log.Printf("   Logging: level=%s, format=%s", c.Logging.Level, c.Logging.Format)
+	attrs := []any{
+		slog.Group("server", "port", c.Server.Port, "environment", c.Server.Environment, "timeout", c.Server.Timeout.String(),
+			"mock_mode", c.Server.MockMode),
+		slog.Group("redis", "address", c.GetRedisAddress(), "cache_ttl", c.Redis.TokenCacheTTL.String()),
+		slog.Group("jwt_secret", "value", maskSecret(c.Auth.JWTSecret), "length", len(c.Auth.JWTSecret)),
+		slog.Group("connections", "max", c.Server.MaxConnections, "per_ip", c.Server.MaxConnsPerIP,
+			"read_header_timeout", c.Server.ReadHeaderTimeout.String(), "max_request", c.Server.MaxRequestDuration.String()),
+		slog.String("user_service", c.Services["user-service"].Address),
+		slog.Group("auth", "default_provider", c.Auth.DefaultProvider, "jwt_jwks", c.Auth.JWTJWKSURL != "", "oidc", c.Auth.OIDCJWKSURL != "",
+			"introspection", c.Auth.IntrospectionURL != "",
+			"oidc_login", c.Auth.OIDCClientID != "", "api_keys", len(c.Auth.APIKeys), "reconnect_tickets", c.Auth.ReconnectTicketsEnabled,
+			"refresh_tokens", c.Auth.RefreshTokensEnabled, "session_cookies", c.Auth.SessionCookies),
+		slog.Group("cors", "enabled", c.CORS.Enabled, "origins", c.CORS.AllowedOrigins, "credentials", c.CORS.AllowCredentials),
+		slog.Group("rate_limit", "enabled", c.RateLimit.Enabled, "per_user", c.RateLimit.PerUserLimit,
+			"per_ip", c.RateLimit.PerIPLimit, "preauth_per_ip", c.RateLimit.PreAuthPerIP, "window", c.RateLimit.Window.String()),
+		slog.Group("logging", "level", c.Logging.Level, "format", c.Logging.Format, "trust_request_id", c.Logging.TrustRequestID),
+		slog.Group("admin", "enabled", c.Admin.Enabled),
+		slog.Group("route_usage", "enabled", c.RouteUsage.Enabled, "unused_after", c.RouteUsage.UnusedAfter.String(),
+			"report_interval", c.RouteUsage.ReportInterval.String()),
+		slog.Group("docs", "enabled", c.Docs.Enabled),
+		slog.Group("status_page", "enabled", c.Status.Enabled, "areas", len(c.Status.ProductAreas), "cache_ttl", c.Status.CacheTTL.String()),
+		slog.Group("internal_listener", "enabled", c.InternalListener.Enabled, "port", c.InternalListener.Port,
+			"principals", len(c.InternalListener.ServicePrincipals)),
+		slog.Group("grpc_passthrough", "enabled", c.GRPCPassthrough.Enabled, "port", c.GRPCPassthrough.Port,
+			"auth_required", c.GRPCPassthrough.AuthRequired, "services", len(c.GRPCPassthrough.Services)),
+		slog.Group("internal_tokens", "enabled", c.InternalTokens.Enabled, "issuer", c.InternalTokens.Issuer,
+			"ttl", c.InternalTokens.TTL.String(), "shared_key", c.InternalTokens.KeyFile != ""),
+		slog.Group("response_cache", "enabled", c.Cache.Enabled, "local_size", c.Cache.LocalSize),
+		slog.Group("compression", "enabled", c.Compression.Enabled, "min_size", c.Compression.MinSize, "level", c.Compression.Level),
+		slog.Group("replay", "max_age", c.Replay.MaxAge.String()),
+		slog.Group("idempotency", "enabled", c.Idempotency.Enabled, "window", c.Idempotency.Window.String()),
+		slog.Group("tracing", "enabled", c.Tracing.Token != "", "ttl", c.Tracing.TTL.String()),
+		slog.Group("recording", "enabled", c.Recording.Enabled, "store", c.Recording.Store, "percent", c.Recording.Percent),
+		slog.Group("egress", "allowlist", c.Egress.Allowlist),
+		slog.Group("connections", "pool_size", c.Proxy.ConnectionPoolSize, "warmup", c.Proxy.WarmUpEnabled,
+			"warmup_timeout", c.Proxy.WarmUpTimeout.String()),
+		slog.Group("retry", "enabled", c.Proxy.RetryEnabled, "base_delay", c.Proxy.RetryBaseDelay.String(),
+			"max_delay", c.Proxy.RetryMaxDelay.String(), "budget_ratio", c.Proxy.RetryBudgetRatio, "budget_burst", c.Proxy.RetryBudgetBurst),
+		slog.Group("circuit_breaker", "enabled", c.Proxy.CircuitBreakerEnabled, "threshold", c.Proxy.CircuitBreakerThreshold,
+			"timeout", c.Proxy.CircuitBreakerTimeout.String(), "half_open", c.Proxy.CircuitBreakerHalfOpen,
+			"failure_rate", c.Proxy.CircuitBreakerFailureRate),
+		slog.Group("watchdog", "enabled", c.Watchdog.Enabled, "multiplier", c.Watchdog.TimeoutMultiplier,
+			"interval", c.Watchdog.Interval.String(), "dump_interval", c.Watchdog.DumpInterval.String()),
+		slog.Group("bulkhead", "enabled", c.Bulkhead.Enabled, "service_limit", c.Bulkhead.ServiceLimit,
+			"client_limit", c.Bulkhead.ClientLimit, "queue_timeout", c.Bulkhead.QueueTimeout.String()),
+		slog.Group("fault_injection", "enabled", c.Faults.Enabled, "environments", c.Faults.Environments),
+		slog.Group("adaptive_limit", "enabled", c.AdaptiveLimit.Enabled, "initial", c.AdaptiveLimit.InitialLimit,
+			"min", c.AdaptiveLimit.MinLimit, "max", c.AdaptiveLimit.MaxLimit, "tolerance", c.AdaptiveLimit.Tolerance),
+		slog.Group("load_shed", "enabled", c.LoadShed.Enabled, "heap_mb", c.LoadShed.HeapMB, "goroutines", c.LoadShed.Goroutines,
+			"gc_pause", c.LoadShed.GCPause.String(), "interval", c.LoadShed.Interval.String()),
+		slog.Group("stream", "buffer_size", c.Stream.BufferSize, "slow_consumer_policy", c.Stream.SlowConsumerPolicy,
+			"heartbeat", c.Stream.HeartbeatInterval.String()),
+		slog.Group("websocket", "ping_interval", c.WebSocket.PingInterval.String(), "pong_timeout", c.WebSocket.PongTimeout.String(),
+			"max_message_bytes", c.WebSocket.MaxMessageBytes, "allowed_origins", c.WebSocket.AllowedOrigins),
+		slog.Group("long_poll", "max_wait", c.LongPoll.MaxWait.String(), "interval", c.LongPoll.Interval.String()),
+		slog.Group("errors", "problem_json", c.Errors.ProblemJSON, "docs", c.Errors.DocsBaseURL, "templates", c.Errors.Templates,
+			"locales", c.Errors.Locales),
+		slog.Group("audit", "enabled", c.Audit.Enabled, "sink", c.Audit.Sink, "path", c.Audit.FilePath, "active_key", c.Audit.ActiveKeyID),
+		slog.Group("features", "enabled", c.Features.Enabled, "provider", c.Features.Provider, "flags", c.Features.Flags),
+	}
+	if c.Auth.MaxSessions > 0 {
+		attrs = append(attrs, slog.Group("session_limit", "max", c.Auth.MaxSessions,
+			"mode", c.Auth.SessionLimitMode, "users", len(c.Auth.SessionLimitUsers)))
+	}
+	if c.Auth.LoginGuardEnabled {
+		attrs = append(attrs, slog.Group("login_guard", "free_attempts", c.Auth.LoginFreeAttempts,
+			"lockout_threshold", c.Auth.LoginLockoutThreshold, "ip_lockout_threshold", c.Auth.LoginIPLockoutThreshold,
+			"lockout", c.Auth.LoginLockoutDuration.String(), "challenge", c.Auth.LoginChallengeProvider))
+	}
+	if n := len(c.RateLimit.ExemptPrincipals) + len(c.RateLimit.ExemptIPs) + len(c.RateLimit.BypassTokens); n > 0 {
+		attrs = append(attrs, slog.Group("rate_limit_exemptions", "principals", c.RateLimit.ExemptPrincipals,
+			"ips", c.RateLimit.ExemptIPs, "bypass_tokens", len(c.RateLimit.BypassTokens)))
+	}
+	if c.Bundle.Location != "" {
+		attrs = append(attrs, slog.Group("config_bundle", "location", c.Bundle.Location, "refresh", c.Bundle.RefreshInterval.String()))
+	}
+	if c.Secrets.Provider != "" {
+		attrs = append(attrs, slog.Group("secrets", "provider", c.Secrets.Provider, "settings", slices.Sorted(maps.Keys(c.Secrets.Refs)),
+			"refresh", c.Secrets.RefreshInterval.String()))
+	}
+	if c.ControlPlane.URL != "" {
+		attrs = append(attrs, slog.Group("control_plane", "url", c.ControlPlane.URL, "instance", c.ControlPlane.InstanceID,
+			"poll", c.ControlPlane.PollInterval.String(), "long_poll_wait", c.ControlPlane.LongPollWait.String()))
+	}
+	if c.Tenancy.Enabled {
+		attrs = append(attrs, slog.Group("tenancy", "sources", c.Tenancy.Sources, "base_domain", c.Tenancy.BaseDomain,
+			"allowed", len(c.Tenancy.Allowed), "default", c.Tenancy.Default, "required", c.Tenancy.Required,
+			"dedicated_services", len(c.Tenancy.ServiceAddresses)))
+	}
+	if len(c.Proxy.DescriptorSets) > 0 {
+		attrs = append(attrs, slog.Any("descriptor_sets", c.Proxy.DescriptorSets))
+	}
+
+	slog.Info("configuration loaded", attrs...)
 }
 
 // GetRedisAddress returns the full Redis address
@@ -222,51 +1615,111 @@ func (c *Config) GetRedisAddress() string {
 	return fmt.Sprintf("%s:%s", c.Redis.Host, c.Redis.Port)
 }
 
+// Has reports whether setting name is fetched from the secrets manager
+func (s SecretsConfig) Has(name string) bool {
+	_, ok := s.Refs[name]
+	return s.Provider != "" && ok
+}
+
 // Helper functions
 
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
 func getIntEnv(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
 			return intValue
 		}
+		invalidSetting(key, value, "an integer")
 	}
 	return defaultValue
 }
 
 func getInt64Env(key string, defaultValue int64) int64 {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if intValue, err := strconv.ParseInt(value, 10, 64); err == nil {
 			return intValue
 		}
+		invalidSetting(key, value, "an integer")
+	}
+	return defaultValue
+}
+
+func getFloatEnv(key string, defaultValue float64) float64 {
+	if value := lookupEnv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+		invalidSetting(key, value, "a number")
 	}
 	return defaultValue
 }
 
 func getBoolEnv(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
 			return boolValue
 		}
+		invalidSetting(key, value, "true or false")
 	}
 	return defaultValue
 }
 
 func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if duration, err := time.ParseDuration(value); err == nil {
 			return duration
 		}
+		invalidSetting(key, value, "a duration")
 	}
 	return defaultValue
 }
 
+// getSliceEnv parses a comma-separated list (e.g. "a,b,c")
+// hostname returns the machine hostname, or "" when unavailable
+func hostname() string {
+	name, _ := os.Hostname()
+	return name
+}
+
+func getSliceEnv(key string, defaultValue []string) []string {
+	value := lookupEnv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	var result []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// getMapEnv parses a comma-separated list of key=value pairs (e.g. "k1=v1,k2=v2")
+func getMapEnv(key string, defaultValue map[string]string) map[string]string {
+	value := lookupEnv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	result := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || k == "" {
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
+
 func maskSecret(secret string) string {
 	if len(secret) <= 8 {
 		return "***"
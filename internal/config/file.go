@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the environment variable holding the path of the gateway
+// config file (set by --config)
+const FileEnv = "CONFIG_FILE"
+
+// exported holds the settings applyFile set in the environment, so a reload
+// (e.g. with a config bundle applied) still tells them from real variables
+var exported = make(map[string]string)
+
+// applyFile exports the settings of a YAML (or JSON) config file as
+// environment variables, skipping those the environment already sets:
+// environment variables, .env included, override the file.
+//
+// Nested keys are joined with underscores and upper-cased, so
+//
+//	rate_limit:
+//	  per_user: 100
+//
+// sets RATE_LIMIT_PER_USER. Keys under services are service names, e.g.
+// services.user-service.address sets USER_SERVICE_ADDRESS. Lists are joined
+// with commas; map settings are lists of key=value items.
+func applyFile(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml", ".json":
+	default:
+		return fmt.Errorf("config file %s: unsupported format, use YAML (.yaml, .yml) or JSON", path)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	var document map[string]any
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	settings := make(map[string]string)
+	for key, value := range document {
+		if key != "services" {
+			if err := flattenSetting("", key, value, settings); err != nil {
+				return fmt.Errorf("config file %s: %w", path, err)
+			}
+			continue
+		}
+		services, ok := value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("config file %s: services must map service names to their settings", path)
+		}
+		for name, service := range services {
+			if err := flattenSetting("", name, service, settings); err != nil {
+				return fmt.Errorf("config file %s: %w", path, err)
+			}
+		}
+	}
+
+	for name, value := range settings {
+		current, set := os.LookupEnv(name)
+		if previous, ours := exported[name]; set && (!ours || current != previous) {
+			continue
+		}
+		if err := os.Setenv(name, value); err != nil {
+			return fmt.Errorf("failed to apply setting %s: %w", name, err)
+		}
+		exported[name] = value
+		loaded.file[name] = true
+	}
+	return nil
+}
+
+// flattenSetting adds the setting key of the section named prefix, and of
+// its subsections, to settings
+func flattenSetting(prefix, key string, value any, settings map[string]string) error {
+	name := settingName(key)
+	if prefix != "" {
+		name = prefix + "_" + name
+	}
+	if _, ok := settings[name]; ok {
+		return fmt.Errorf("%s is set twice", name)
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		for key, setting := range value {
+			if err := flattenSetting(name, key, setting, settings); err != nil {
+				return err
+			}
+		}
+	case []any:
+		items := make([]string, len(value))
+		for i, item := range value {
+			switch item.(type) {
+			case map[string]any, []any, nil:
+				return fmt.Errorf("%s: list items must be single values", name)
+			}
+			items[i] = fmt.Sprint(item)
+		}
+		settings[name] = strings.Join(items, ",")
+	case map[any]any:
+		return fmt.Errorf("%s: keys must be names", name)
+	case nil:
+		settings[name] = ""
+	default:
+		settings[name] = fmt.Sprint(value)
+	}
+	return nil
+}
+
+// settingName returns the environment variable name of a config file key
+func settingName(key string) string {
+	return strings.ToUpper(strings.ReplaceAll(key, "-", "_"))
+}
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCheck_ConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gateway.yaml")
+	err := os.WriteFile(path, []byte(`
+jwt_secret: file-secret-that-is-long-enough-for-hs256
+services:
+  user-service:
+    address: users.internal:50051
+rate_limit:
+  per_user: 50
+  window: soon
+cors:
+  allowed_origins: [https://app.hub.com, https://admin.hub.com]
+  allowed_orignis: [https://typo.hub.com]
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(FileEnv, path)
+	t.Setenv("RATE_LIMIT_PER_USER", "75")
+	t.Cleanup(func() {
+		for name := range exported {
+			os.Unsetenv(name)
+			delete(exported, name)
+		}
+	})
+
+	report, _ := Check()
+	if report.Valid || !slices.Equal(report.Errors, []string{`RATE_LIMIT_WINDOW="soon" is not a duration`}) {
+		t.Errorf("expected the invalid window to be reported but got %+v", report.Errors)
+	}
+	if !slices.Equal(report.Warnings, []string{"CORS_ALLOWED_ORIGNIS in the config file is not a gateway setting"}) {
+		t.Errorf("expected the misspelled key to be reported but got %+v", report.Warnings)
+	}
+
+	// The environment overrides the file
+	if report.Sources["RATE_LIMIT_PER_USER"] != "env" || os.Getenv("RATE_LIMIT_PER_USER") != "75" {
+		t.Errorf("expected RATE_LIMIT_PER_USER from the environment but got %s=%s", report.Sources["RATE_LIMIT_PER_USER"], os.Getenv("RATE_LIMIT_PER_USER"))
+	}
+	if report.Sources["USER_SERVICE_ADDRESS"] != "file" || os.Getenv("USER_SERVICE_ADDRESS") != "users.internal:50051" {
+		t.Errorf("expected the service address from the file but got %s", os.Getenv("USER_SERVICE_ADDRESS"))
+	}
+	if got := os.Getenv("CORS_ALLOWED_ORIGINS"); got != "https://app.hub.com,https://admin.hub.com" {
+		t.Errorf("expected lists to be joined with commas but got %s", got)
+	}
+}
@@ -0,0 +1,85 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+)
+
+// settingsLog records what loading the configuration read, for the
+// validation report
+type settingsLog struct {
+	read    map[string]bool // Settings looked up
+	file    map[string]bool // Settings whose value comes from the config file
+	invalid []string        // Values that didn't parse and were replaced by defaults
+}
+
+// loaded is the log of the latest load
+var loaded = newSettingsLog()
+
+func newSettingsLog() *settingsLog {
+	return &settingsLog{read: make(map[string]bool), file: make(map[string]bool)}
+}
+
+// lookupEnv returns a setting's value, recording that it was read
+func lookupEnv(key string) string {
+	loaded.read[key] = true
+	return os.Getenv(key)
+}
+
+// invalidSetting records a value that doesn't parse as the setting's type
+func invalidSetting(key, value, want string) {
+	loaded.invalid = append(loaded.invalid, fmt.Sprintf("%s=%q is not %s", key, value, want))
+}
+
+// Report is the outcome of checking the configuration (--validate-config)
+type Report struct {
+	Valid    bool              `json:"valid"`
+	File     string            `json:"file,omitempty"`
+	Sources  map[string]string `json:"sources"` // Setting -> "env" or "file", for settings not left at their default
+	Errors   []string          `json:"errors,omitempty"`
+	Warnings []string          `json:"warnings,omitempty"`
+}
+
+// AddError records a problem found outside the configuration, e.g. in routes
+func (r *Report) AddError(format string, args ...any) {
+	r.Errors = append(r.Errors, fmt.Sprintf(format, args...))
+	r.Valid = false
+}
+
+// Check loads and validates the configuration like Load, without applying
+// it, and reports every problem found: unparseable values, which Load
+// replaces by defaults, are errors here; config file keys the gateway
+// doesn't know are warnings. The loaded configuration is returned for
+// further checks, nil when it couldn't be loaded.
+func Check() (*Report, *Config) {
+	report := &Report{File: os.Getenv(FileEnv), Sources: make(map[string]string)}
+
+	cfg, err := load()
+	if err != nil {
+		report.AddError("%v", err)
+		return report, nil
+	}
+	for _, problem := range loaded.invalid {
+		report.AddError("%s", problem)
+	}
+	if err := cfg.Validate(); err != nil {
+		report.AddError("%v", err)
+	}
+
+	for key := range loaded.read {
+		if loaded.file[key] {
+			report.Sources[key] = "file"
+		} else if os.Getenv(key) != "" {
+			report.Sources[key] = "env"
+		}
+	}
+	for key := range loaded.file {
+		if !loaded.read[key] {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("%s in the config file is not a gateway setting", key))
+		}
+	}
+	sort.Strings(report.Warnings)
+	report.Valid = len(report.Errors) == 0
+	return report, cfg
+}
@@ -0,0 +1,199 @@
+// Package configbundle loads centrally managed gateway configuration from an
+// encrypted bundle: a gzip tarball holding gateway.yaml (settings) and
+// routes.yaml (route table), sealed with AES-256-GCM.
+//
+// Bundles are built with cmd/configbundle and published to object storage;
+// every gateway instance fetches the same bundle at startup and refreshes it
+// periodically.
+package configbundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"hub-api-gateway/internal/router"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File names inside a bundle
+const (
+	SettingsFile = "gateway.yaml"
+	RoutesFile   = "routes.yaml"
+)
+
+// magic prefixes sealed bundles so a wrong or plaintext object fails fast
+var magic = []byte("HGWBUNDLE1")
+
+// maxBundleSize bounds the decompressed bundle to guard against archive bombs
+const maxBundleSize = 16 << 20
+
+// ErrDecrypt is returned when a bundle can't be authenticated with the key
+var ErrDecrypt = errors.New("config bundle decryption failed (wrong key or tampered bundle)")
+
+// Bundle is a decrypted configuration bundle
+type Bundle struct {
+	Version  string            // SHA-256 of the sealed bundle
+	Settings map[string]string // gateway.yaml: environment variable name -> value
+	Routes   []router.Route    // routes.yaml (nil when the bundle has no route table)
+}
+
+// Seal packs files into a gzip tarball and encrypts it with key (32 bytes)
+func Seal(files map[string][]byte, key []byte) ([]byte, error) {
+	var archive bytes.Buffer
+	gz := gzip.NewWriter(&archive)
+	tw := tar.NewWriter(gz)
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		header := &tar.Header{Name: name, Mode: 0o600, Size: int64(len(files[name]))}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	sealed := append(append([]byte{}, magic...), nonce...)
+	return aead.Seal(sealed, nonce, archive.Bytes(), magic), nil
+}
+
+// Open decrypts and parses a sealed bundle
+func Open(sealed []byte, key []byte) (*Bundle, error) {
+	files, err := decrypt(sealed, key)
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(sealed)
+	bundle := &Bundle{
+		Version:  hex.EncodeToString(sum[:])[:16],
+		Settings: make(map[string]string),
+	}
+
+	if data, ok := files[SettingsFile]; ok {
+		if err := yaml.Unmarshal(data, &bundle.Settings); err != nil {
+			return nil, fmt.Errorf("invalid %s in config bundle: %w", SettingsFile, err)
+		}
+	}
+
+	if data, ok := files[RoutesFile]; ok {
+		var routeConfig router.RouteConfig
+		if err := yaml.Unmarshal(data, &routeConfig); err != nil {
+			return nil, fmt.Errorf("invalid %s in config bundle: %w", RoutesFile, err)
+		}
+		if errs := router.ValidateRoutes(routeConfig.Routes); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid %s in config bundle: %w", RoutesFile, errors.Join(errs...))
+		}
+		bundle.Routes = routeConfig.Routes
+	}
+
+	if len(files[SettingsFile]) == 0 && bundle.Routes == nil {
+		return nil, fmt.Errorf("config bundle contains neither %s nor %s", SettingsFile, RoutesFile)
+	}
+
+	return bundle, nil
+}
+
+// ApplySettings exports the bundle settings as environment variables so the
+// regular configuration loader picks them up
+func (b *Bundle) ApplySettings() error {
+	for name, value := range b.Settings {
+		if err := os.Setenv(name, value); err != nil {
+			return fmt.Errorf("failed to apply setting %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// decrypt authenticates the bundle and extracts its files
+func decrypt(sealed []byte, key []byte) (map[string][]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < len(magic)+aead.NonceSize() || !bytes.Equal(sealed[:len(magic)], magic) {
+		return nil, fmt.Errorf("not a gateway config bundle")
+	}
+	nonce := sealed[len(magic) : len(magic)+aead.NonceSize()]
+
+	archive, err := aead.Open(nil, nonce, sealed[len(magic)+aead.NonceSize():], magic)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(archive))
+	if err != nil {
+		return nil, fmt.Errorf("invalid config bundle archive: %w", err)
+	}
+	defer gz.Close()
+
+	files := make(map[string][]byte)
+	tr := tar.NewReader(io.LimitReader(gz, maxBundleSize))
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid config bundle archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid config bundle archive: %w", err)
+		}
+		files[header.Name] = data
+	}
+
+	return files, nil
+}
+
+// newAEAD creates the AES-256-GCM cipher for key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("config bundle key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,82 @@
+package configbundle
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var testKey = bytes.Repeat([]byte{7}, 32)
+
+const testRoutes = `routes:
+  - name: get-orders
+    path: /api/v1/orders
+    method: GET
+    service: order-service
+    grpc_service: OrderService
+    grpc_method: GetOrders
+    auth_required: true
+`
+
+func TestSealOpen(t *testing.T) {
+	sealed, err := Seal(map[string][]byte{
+		SettingsFile: []byte("HTTP_PORT: 9090\nAUTH_CACHE_ENABLED: true\n"),
+		RoutesFile:   []byte(testRoutes),
+	}, testKey)
+	if err != nil {
+		t.Fatalf("seal failed: %v", err)
+	}
+
+	bundle, err := Open(sealed, testKey)
+	if err != nil {
+		t.Fatalf("open failed: %v", err)
+	}
+
+	if bundle.Settings["HTTP_PORT"] != "9090" || bundle.Settings["AUTH_CACHE_ENABLED"] != "true" {
+		t.Errorf("unexpected settings: %v", bundle.Settings)
+	}
+	if len(bundle.Routes) != 1 || bundle.Routes[0].Name != "get-orders" {
+		t.Errorf("unexpected routes: %+v", bundle.Routes)
+	}
+	if bundle.Version == "" {
+		t.Errorf("expected bundle version")
+	}
+
+	// Wrong key and tampered ciphertext must both be rejected
+	if _, err := Open(sealed, bytes.Repeat([]byte{8}, 32)); err != ErrDecrypt {
+		t.Errorf("expected ErrDecrypt for wrong key, got %v", err)
+	}
+	sealed[len(sealed)-1] ^= 0xff
+	if _, err := Open(sealed, testKey); err != ErrDecrypt {
+		t.Errorf("expected ErrDecrypt for tampered bundle, got %v", err)
+	}
+}
+
+func TestSourceAndLoad(t *testing.T) {
+	if got, _ := resolveLocation("s3://configs/gateway/bundle.enc"); got != "https://configs.s3.amazonaws.com/gateway/bundle.enc" {
+		t.Errorf("unexpected s3 location: %s", got)
+	}
+	if got, _ := resolveLocation("gs://configs/bundle.enc"); got != "https://storage.googleapis.com/configs/bundle.enc" {
+		t.Errorf("unexpected gs location: %s", got)
+	}
+
+	sealed, err := Seal(map[string][]byte{RoutesFile: []byte(testRoutes)}, testKey)
+	if err != nil {
+		t.Fatalf("seal failed: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "bundle.enc")
+	if err := os.WriteFile(path, sealed, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	bundle, source, err := Load(context.Background(), path, testKey, time.Second)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if source.Location() != path || len(bundle.Routes) != 1 {
+		t.Errorf("unexpected load result: %s %+v", source.Location(), bundle.Routes)
+	}
+}
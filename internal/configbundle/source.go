@@ -0,0 +1,146 @@
+package configbundle
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Source fetches a sealed bundle from object storage or the local filesystem.
+//
+// Supported locations:
+//   - https://... (including pre-signed S3/GCS URLs)
+//   - s3://bucket/key  (fetched from https://bucket.s3.amazonaws.com/key)
+//   - gs://bucket/key  (fetched from https://storage.googleapis.com/bucket/key)
+//   - file:///path or a plain path
+type Source struct {
+	location string
+	client   *http.Client
+	etag     string // ETag of the last fetched object, for conditional refreshes
+}
+
+// NewSource creates a bundle source for location
+func NewSource(location string, timeout time.Duration) (*Source, error) {
+	resolved, err := resolveLocation(location)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Source{
+		location: resolved,
+		client:   &http.Client{Timeout: timeout},
+	}, nil
+}
+
+// Location returns the resolved bundle location
+func (s *Source) Location() string {
+	return s.location
+}
+
+// Fetch downloads the sealed bundle. It returns (nil, nil) when the object
+// hasn't changed since the previous fetch.
+func (s *Source) Fetch(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(s.location, "http://") && !strings.HasPrefix(s.location, "https://") {
+		return os.ReadFile(strings.TrimPrefix(s.location, "file://"))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.location, nil)
+	if err != nil {
+		return nil, err
+	}
+	if s.etag != "" {
+		req.Header.Set("If-None-Match", s.etag)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch config bundle: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("failed to fetch config bundle: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBundleSize))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config bundle: %w", err)
+	}
+
+	s.etag = resp.Header.Get("ETag")
+	return data, nil
+}
+
+// resolveLocation maps s3:// and gs:// locations to their HTTPS endpoints
+func resolveLocation(location string) (string, error) {
+	if location == "" {
+		return "", fmt.Errorf("config bundle location is empty")
+	}
+
+	parsed, err := url.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("invalid config bundle location: %w", err)
+	}
+
+	object := strings.TrimPrefix(parsed.Path, "/")
+	switch parsed.Scheme {
+	case "s3":
+		return fmt.Sprintf("https://%s.s3.amazonaws.com/%s", parsed.Host, object), nil
+	case "gs":
+		return fmt.Sprintf("https://storage.googleapis.com/%s/%s", parsed.Host, object), nil
+	case "http", "https", "file", "":
+		return location, nil
+	default:
+		return "", fmt.Errorf("unsupported config bundle scheme %q", parsed.Scheme)
+	}
+}
+
+// Load fetches and opens the bundle at location. The returned source keeps the
+// object's ETag so later refreshes can be conditional.
+func Load(ctx context.Context, location string, key []byte, timeout time.Duration) (*Bundle, *Source, error) {
+	source, err := NewSource(location, timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sealed, err := source.Fetch(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bundle, err := Open(sealed, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bundle, source, nil
+}
+
+// LoadKey decodes the base64 bundle key, reading it from path when given
+func LoadKey(encoded, path string) ([]byte, error) {
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config bundle key: %w", err)
+		}
+		encoded = string(data)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("config bundle key must be base64: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("config bundle key must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
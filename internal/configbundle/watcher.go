@@ -0,0 +1,76 @@
+package configbundle
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Watcher periodically refreshes the bundle and hands new versions to onChange.
+// A bundle that fails to fetch, decrypt or validate is logged and skipped, so
+// the gateway keeps running on the last good configuration.
+type Watcher struct {
+	source   *Source
+	key      []byte
+	interval time.Duration
+	version  string
+	onChange func(bundle *Bundle) error
+}
+
+// NewWatcher creates a watcher; current is the bundle applied at startup
+func NewWatcher(source *Source, key []byte, interval time.Duration, current *Bundle, onChange func(bundle *Bundle) error) *Watcher {
+	w := &Watcher{
+		source:   source,
+		key:      key,
+		interval: interval,
+		onChange: onChange,
+	}
+	if current != nil {
+		w.version = current.Version
+	}
+	return w
+}
+
+// Run refreshes the bundle until ctx is cancelled
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.refresh(ctx)
+		}
+	}
+}
+
+// refresh fetches the bundle and applies it when its version changed
+func (w *Watcher) refresh(ctx context.Context) {
+	sealed, err := w.source.Fetch(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "config bundle refresh failed", "error", err)
+		return
+	}
+	if sealed == nil {
+		return
+	}
+
+	bundle, err := Open(sealed, w.key)
+	if err != nil {
+		slog.WarnContext(ctx, "ignoring config bundle", "source", w.source.Location(), "error", err)
+		return
+	}
+	if bundle.Version == w.version {
+		return
+	}
+
+	if err := w.onChange(bundle); err != nil {
+		slog.ErrorContext(ctx, "failed to apply config bundle", "version", bundle.Version, "error", err)
+		return
+	}
+
+	slog.InfoContext(ctx, "applied config bundle", "version", bundle.Version, "previous", w.version)
+	w.version = bundle.Version
+}
@@ -0,0 +1,141 @@
+// Package connlimit bounds the number of concurrent client connections, in
+// total and per remote IP, protecting the gateway from socket exhaustion and
+// slowloris-style attacks that hold many idle connections open.
+package connlimit
+
+import (
+	"log/slog"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Listener wraps a net.Listener and closes connections exceeding the limits
+// immediately after accepting them
+type Listener struct {
+	net.Listener
+
+	maxTotal int // 0 = unlimited
+	maxPerIP int // 0 = unlimited
+
+	mu     sync.Mutex
+	total  int
+	perIP  map[string]int
+	onDrop func(reason string)
+
+	dropped       atomic.Uint64
+	lastDropLogNs atomic.Int64
+}
+
+// Drop reasons passed to the OnDrop callback
+const (
+	DropTotalLimit = "total_limit"
+	DropPerIPLimit = "per_ip_limit"
+)
+
+// NewListener wraps ln with total and per-IP connection limits
+func NewListener(ln net.Listener, maxTotal, maxPerIP int) *Listener {
+	return &Listener{
+		Listener: ln,
+		maxTotal: maxTotal,
+		maxPerIP: maxPerIP,
+		perIP:    make(map[string]int),
+	}
+}
+
+// OnDrop registers a callback invoked for every rejected connection
+func (l *Listener) OnDrop(fn func(reason string)) {
+	l.onDrop = fn
+}
+
+// Accept returns the next connection within the limits
+func (l *Listener) Accept() (net.Conn, error) {
+	for {
+		conn, err := l.Listener.Accept()
+		if err != nil {
+			return nil, err
+		}
+
+		ip := remoteIP(conn)
+		if reason, ok := l.acquire(ip); !ok {
+			conn.Close()
+			l.reject(ip, reason)
+			continue
+		}
+
+		return &trackedConn{Conn: conn, release: func() { l.release(ip) }}, nil
+	}
+}
+
+// acquire reserves a connection slot for ip
+func (l *Listener) acquire(ip string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.maxTotal > 0 && l.total >= l.maxTotal {
+		return DropTotalLimit, false
+	}
+	if l.maxPerIP > 0 && l.perIP[ip] >= l.maxPerIP {
+		return DropPerIPLimit, false
+	}
+
+	l.total++
+	l.perIP[ip]++
+	return "", true
+}
+
+// release frees the connection slot of ip
+func (l *Listener) release(ip string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.total--
+	if l.perIP[ip]--; l.perIP[ip] <= 0 {
+		delete(l.perIP, ip)
+	}
+}
+
+// reject records a dropped connection, logging at most once per second
+func (l *Listener) reject(ip, reason string) {
+	l.dropped.Add(1)
+	if l.onDrop != nil {
+		l.onDrop(reason)
+	}
+
+	now := time.Now().UnixNano()
+	last := l.lastDropLogNs.Load()
+	if now-last >= int64(time.Second) && l.lastDropLogNs.CompareAndSwap(last, now) {
+		slog.Warn("rejecting connection", "ip", ip, "reason", reason, "dropped", l.dropped.Load())
+	}
+}
+
+// Active returns the number of open connections
+func (l *Listener) Active() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.total
+}
+
+// trackedConn releases its slot exactly once when closed
+type trackedConn struct {
+	net.Conn
+	once    sync.Once
+	release func()
+}
+
+// Close closes the connection and releases its slot
+func (c *trackedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
+
+// remoteIP returns the IP of the connection's remote address
+func remoteIP(conn net.Conn) string {
+	host, _, err := net.SplitHostPort(conn.RemoteAddr().String())
+	if err != nil {
+		return conn.RemoteAddr().String()
+	}
+	return host
+}
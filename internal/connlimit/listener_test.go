@@ -0,0 +1,62 @@
+package connlimit
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestListenerPerIPLimit(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	limited := NewListener(ln, 0, 2)
+	defer limited.Close()
+
+	drops := make(chan string, 1)
+	limited.OnDrop(func(reason string) { drops <- reason })
+
+	accepted := make(chan net.Conn, 3)
+	go func() {
+		for {
+			conn, err := limited.Accept()
+			if err != nil {
+				return
+			}
+			accepted <- conn
+		}
+	}()
+
+	var clients []net.Conn
+	for i := 0; i < 3; i++ {
+		conn, err := net.Dial("tcp", ln.Addr().String())
+		if err != nil {
+			t.Fatalf("failed to dial: %v", err)
+		}
+		defer conn.Close()
+		clients = append(clients, conn)
+	}
+
+	select {
+	case reason := <-drops:
+		if reason != DropPerIPLimit {
+			t.Errorf("expected per-IP drop but got %s", reason)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected the third connection to be dropped")
+	}
+
+	first := <-accepted
+	<-accepted
+	if active := limited.Active(); active != 2 {
+		t.Errorf("expected 2 active connections but got %d", active)
+	}
+
+	// Closing a connection frees its slot
+	first.Close()
+	first.Close()
+	if active := limited.Active(); active != 1 {
+		t.Errorf("expected 1 active connection after close but got %d", active)
+	}
+}
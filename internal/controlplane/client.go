@@ -0,0 +1,215 @@
+// Package controlplane keeps the gateway's route table and policies in sync
+// with a central configuration service, so a fleet of gateways can be managed
+// from one place.
+//
+// The gateway polls GET {url}/v1/gateway/config?version=<applied>. The service
+// answers 304 Not Modified when the gateway is current, or 200 with an Update.
+// With long-polling enabled the request also carries wait=<seconds> and the
+// service holds it until a newer version exists or the wait elapses.
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"hub-api-gateway/internal/router"
+)
+
+// maxUpdateSize bounds the update document read from the control plane
+const maxUpdateSize = 16 << 20
+
+// Update is a versioned configuration published by the control plane
+type Update struct {
+	Version         string         `json:"version"`
+	Routes          []router.Route `json:"routes"`
+	EgressAllowlist []string       `json:"egress_allowlist,omitempty"` // nil keeps the current policy
+}
+
+// Status reports the control plane sync state
+type Status struct {
+	Version    string    `json:"version"`
+	AppliedAt  time.Time `json:"appliedAt,omitempty"`
+	LastSyncAt time.Time `json:"lastSyncAt,omitempty"`
+	LastError  string    `json:"lastError,omitempty"`
+}
+
+// Options configures a control plane client
+type Options struct {
+	URL          string
+	Token        string
+	InstanceID   string
+	PollInterval time.Duration // Delay between polls, and backoff after failures
+	LongPollWait time.Duration // How long the server may hold a poll (0 disables long-polling)
+	Timeout      time.Duration // Request timeout on top of LongPollWait
+}
+
+// Client polls the control plane and applies new versions through apply.
+// apply must validate the whole update before changing anything, so an update
+// is either fully applied or rejected.
+type Client struct {
+	options Options
+	http    *http.Client
+	apply   func(update *Update) error
+	onSync  func(version string, applied bool)
+
+	mu     sync.RWMutex
+	status Status
+}
+
+// NewClient creates a control plane client
+func NewClient(options Options, apply func(update *Update) error) *Client {
+	return &Client{
+		options: options,
+		http:    &http.Client{Timeout: options.LongPollWait + options.Timeout},
+		apply:   apply,
+	}
+}
+
+// OnSync registers a callback invoked after every update is applied or rejected
+func (c *Client) OnSync(fn func(version string, applied bool)) {
+	c.onSync = fn
+}
+
+// Status returns the current sync state
+func (c *Client) Status() Status {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.status
+}
+
+// Version returns the applied configuration version ("" before the first sync)
+func (c *Client) Version() string {
+	return c.Status().Version
+}
+
+// Sync polls once without long-polling and applies any new version
+func (c *Client) Sync(ctx context.Context) error {
+	return c.poll(ctx, 0)
+}
+
+// Run keeps polling until ctx is cancelled
+func (c *Client) Run(ctx context.Context) {
+	for {
+		err := c.poll(ctx, c.options.LongPollWait)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "control plane sync failed", "error", err)
+		}
+
+		// Long-polls return as soon as something changes, so only pause after failures
+		delay := c.options.PollInterval
+		if err == nil && c.options.LongPollWait > 0 {
+			delay = 0
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// poll fetches the config and applies it when the version changed
+func (c *Client) poll(ctx context.Context, wait time.Duration) error {
+	update, err := c.fetch(ctx, wait)
+	if err != nil {
+		c.recordError(err)
+		return err
+	}
+
+	c.mu.Lock()
+	c.status.LastSyncAt = time.Now()
+	current := c.status.Version
+	c.mu.Unlock()
+
+	if update == nil || update.Version == current {
+		return nil
+	}
+
+	if err := c.apply(update); err != nil {
+		err = fmt.Errorf("rejected config version %s: %w", update.Version, err)
+		c.recordError(err)
+		if c.onSync != nil {
+			c.onSync(update.Version, false)
+		}
+		return err
+	}
+
+	c.mu.Lock()
+	c.status.Version = update.Version
+	c.status.AppliedAt = time.Now()
+	c.status.LastError = ""
+	c.mu.Unlock()
+
+	if c.onSync != nil {
+		c.onSync(update.Version, true)
+	}
+	slog.InfoContext(ctx, "applied control plane config", "version", update.Version, "previous", current, "routes", len(update.Routes))
+	return nil
+}
+
+// fetch requests the current config; it returns nil when nothing changed
+func (c *Client) fetch(ctx context.Context, wait time.Duration) (*Update, error) {
+	query := url.Values{}
+	query.Set("version", c.Version())
+	if wait > 0 {
+		query.Set("wait", strconv.Itoa(int(wait.Seconds())))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.options.URL+"/v1/gateway/config?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.options.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.options.Token)
+	}
+	if c.options.InstanceID != "" {
+		req.Header.Set("X-Gateway-Instance", c.options.InstanceID)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified, http.StatusNoContent:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("control plane returned %s", resp.Status)
+	}
+
+	var update Update
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxUpdateSize)).Decode(&update); err != nil {
+		return nil, fmt.Errorf("invalid control plane response: %w", err)
+	}
+	if update.Version == "" {
+		return nil, fmt.Errorf("control plane response has no version")
+	}
+	if len(update.Routes) == 0 {
+		// Never let an empty document wipe the route table
+		return nil, fmt.Errorf("control plane version %s has no routes", update.Version)
+	}
+	return &update, nil
+}
+
+// recordError stores the last sync error for /health
+func (c *Client) recordError(err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status.LastError = err.Error()
+}
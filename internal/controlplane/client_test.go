@@ -0,0 +1,75 @@
+package controlplane
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/router"
+)
+
+func TestClientSync(t *testing.T) {
+	published := Update{
+		Version: "v2",
+		Routes:  []router.Route{{Name: "get-orders", Path: "/api/v1/orders", Method: "GET", Service: "order-service"}},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" || r.Header.Get("X-Gateway-Instance") != "gw-1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("version") == published.Version {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		json.NewEncoder(w).Encode(published)
+	}))
+	defer server.Close()
+
+	var applied []string
+	rejectNext := false
+	client := NewClient(Options{URL: server.URL, Token: "secret", InstanceID: "gw-1", Timeout: time.Second}, func(update *Update) error {
+		if rejectNext {
+			return errors.New("invalid routes")
+		}
+		applied = append(applied, update.Version)
+		return nil
+	})
+
+	var synced []bool
+	client.OnSync(func(version string, ok bool) { synced = append(synced, ok) })
+
+	if err := client.Sync(context.Background()); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if client.Version() != "v2" || len(applied) != 1 {
+		t.Fatalf("expected v2 to be applied once, got version %q applied %v", client.Version(), applied)
+	}
+
+	// Unchanged version is a no-op
+	if err := client.Sync(context.Background()); err != nil {
+		t.Fatalf("sync failed: %v", err)
+	}
+	if len(applied) != 1 {
+		t.Errorf("expected no re-apply for unchanged version, got %v", applied)
+	}
+
+	// A rejected update keeps the previous version and reports the error
+	published.Version = "v3"
+	rejectNext = true
+	if err := client.Sync(context.Background()); err == nil {
+		t.Fatalf("expected rejected update to fail")
+	}
+	status := client.Status()
+	if status.Version != "v2" || status.LastError == "" {
+		t.Errorf("expected v2 with an error after rejection, got %+v", status)
+	}
+	if len(synced) != 2 || !synced[0] || synced[1] {
+		t.Errorf("unexpected sync callbacks: %v", synced)
+	}
+}
@@ -0,0 +1,116 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// consulWait is how long a blocking query waits for a change
+const consulWait = 5 * time.Minute
+
+// ConsulResolver follows the passing instances of each service with Consul
+// blocking queries
+type ConsulResolver struct {
+	address string
+	token   string
+	client  *http.Client
+}
+
+// NewConsulResolver creates a resolver for the Consul agent at address
+func NewConsulResolver(address, token string) *ConsulResolver {
+	if address == "" {
+		address = "http://localhost:8500"
+	}
+	return &ConsulResolver{address: strings.TrimSuffix(address, "/"), token: token, client: &http.Client{}}
+}
+
+// consulEntry is the part of a /v1/health/service entry the resolver reads
+type consulEntry struct {
+	Node struct {
+		Address string `json:"Address"`
+	} `json:"Node"`
+	Service struct {
+		Address string `json:"Address"`
+		Port    int    `json:"Port"`
+	} `json:"Service"`
+}
+
+// Watch reports the service's passing instances each time Consul's index moves
+func (c *ConsulResolver) Watch(ctx context.Context, service string, update func([]string, error)) {
+	index := uint64(0)
+	for ctx.Err() == nil {
+		endpoints, next, err := c.query(ctx, service, index)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			update(nil, fmt.Errorf("consul instances of %s: %w", service, err))
+			index = 0
+			if !sleep(ctx, retryDelay) {
+				return
+			}
+		default:
+			if index == 0 || next != index {
+				update(endpoints, nil)
+			}
+			switch {
+			case next == 0:
+				// Without an index the query can't block; poll instead
+				if !sleep(ctx, retryDelay) {
+					return
+				}
+			case next < index:
+				// Consul's index may go backwards (e.g. after a snapshot restore); start over then
+				next = 0
+			}
+			index = next
+		}
+	}
+}
+
+// query runs a blocking query returning once the index moves past index
+func (c *ConsulResolver) query(ctx context.Context, service string, index uint64) ([]string, uint64, error) {
+	query := url.Values{"passing": {"true"}, "index": {strconv.FormatUint(index, 10)}, "wait": {consulWait.String()}}
+	ctx, cancel := context.WithTimeout(ctx, consulWait+30*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.address+"/v1/health/service/"+url.PathEscape(service)+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+
+	response, err := c.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("consul returned %s", response.Status)
+	}
+
+	var entries []consulEntry
+	if err := json.NewDecoder(response.Body).Decode(&entries); err != nil {
+		return nil, 0, err
+	}
+	next, _ := strconv.ParseUint(response.Header.Get("X-Consul-Index"), 10, 64)
+
+	endpoints := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		host := entry.Service.Address
+		if host == "" {
+			host = entry.Node.Address
+		}
+		endpoints = append(endpoints, net.JoinHostPort(host, strconv.Itoa(entry.Service.Port)))
+	}
+	return endpoints, next, nil
+}
@@ -0,0 +1,183 @@
+// Package discovery resolves service addresses of the form discovery://name
+// to the service's current endpoints, keeping gRPC connections pointed at
+// the live endpoint set without restarting the gateway.
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"hub-api-gateway/internal/config"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// Scheme is the address scheme of services found through discovery
+const Scheme = "discovery"
+
+// retryDelay is how long watchers wait after a failed lookup or watch
+const retryDelay = 5 * time.Second
+
+// Resolver finds the endpoints (host:port) of services
+type Resolver interface {
+	// Watch calls update with the service's endpoints, or a lookup error,
+	// whenever they may have changed, until ctx is done
+	Watch(ctx context.Context, service string, update func(endpoints []string, err error))
+}
+
+// IsTarget reports whether a service address uses discovery
+func IsTarget(address string) bool {
+	return strings.HasPrefix(address, Scheme+"://")
+}
+
+// New creates the resolver selected by DISCOVERY_PROVIDER
+func New(cfg config.DiscoveryConfig) (Resolver, error) {
+	switch cfg.Provider {
+	case "static":
+		return NewStaticResolver(cfg.Static), nil
+	case "dns":
+		return NewDNSResolver(cfg.DNSDomain, cfg.DNSInterval), nil
+	case "kubernetes":
+		return NewKubernetesResolver(cfg.KubernetesAPIURL, cfg.KubernetesNamespace, cfg.KubernetesPortName)
+	case "consul":
+		return NewConsulResolver(cfg.ConsulAddress, cfg.ConsulToken), nil
+	default:
+		return nil, fmt.Errorf("unsupported DISCOVERY_PROVIDER: %s", cfg.Provider)
+	}
+}
+
+// roundRobin spreads calls over every endpoint of a discovered service
+const roundRobin = `{"loadBalancingConfig": [{"round_robin": {}}]}`
+
+// Builder plugs a Resolver into gRPC for discovery:// targets and remembers
+// the endpoints last resolved for each service
+type Builder struct {
+	resolver Resolver
+
+	mu        sync.RWMutex
+	endpoints map[string][]string
+}
+
+// NewBuilder creates a gRPC resolver builder backed by r
+func NewBuilder(r Resolver) *Builder {
+	return &Builder{resolver: r, endpoints: make(map[string][]string)}
+}
+
+// Register makes every gRPC connection resolve discovery:// targets with r.
+// Call it during startup, before any connection is created.
+func Register(r Resolver) *Builder {
+	builder := NewBuilder(r)
+	resolver.Register(builder)
+	return builder
+}
+
+// Scheme returns the scheme handled by the builder
+func (b *Builder) Scheme() string {
+	return Scheme
+}
+
+// Build starts watching the target's service for a gRPC connection
+func (b *Builder) Build(target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions) (resolver.Resolver, error) {
+	service := target.URL.Host
+	if service == "" {
+		service = target.Endpoint()
+	}
+	if service == "" {
+		return nil, fmt.Errorf("discovery target %q names no service", target.URL.String())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	watch := &watch{cancel: cancel}
+	var current []string
+	go b.resolver.Watch(ctx, service, func(endpoints []string, err error) {
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			slog.Warn("service discovery failed", "service", service, "error", err)
+			cc.ReportError(err)
+			return
+		}
+
+		endpoints = slices.Clone(endpoints)
+		slices.Sort(endpoints)
+		endpoints = slices.Compact(endpoints)
+		if current != nil && slices.Equal(current, endpoints) {
+			return
+		}
+		current = endpoints
+		b.setEndpoints(service, endpoints)
+		slog.Info("service endpoints updated", "service", service, "endpoints", endpoints)
+
+		addresses := make([]resolver.Address, len(endpoints))
+		for i, endpoint := range endpoints {
+			addresses[i] = resolver.Address{Addr: endpoint}
+		}
+		if len(addresses) == 0 {
+			cc.ReportError(fmt.Errorf("service %s has no endpoints", service))
+			return
+		}
+		cc.UpdateState(resolver.State{Addresses: addresses, ServiceConfig: cc.ParseServiceConfig(roundRobin)})
+	})
+	return watch, nil
+}
+
+// setEndpoints stores the latest endpoints of a service
+func (b *Builder) setEndpoints(service string, endpoints []string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.endpoints[service] = endpoints
+}
+
+// Endpoints returns the endpoints last resolved for a discovery:// address
+func (b *Builder) Endpoints(address string) []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.endpoints[strings.Trim(strings.TrimPrefix(address, Scheme+"://"), "/")]
+}
+
+// watch stops a service watch when its connection closes
+type watch struct {
+	cancel context.CancelFunc
+}
+
+// ResolveNow is a no-op: watchers push changes as they happen
+func (w *watch) ResolveNow(resolver.ResolveNowOptions) {}
+
+// Close stops the watch
+func (w *watch) Close() {
+	w.cancel()
+}
+
+// poll calls lookup every interval until ctx is done
+func poll(ctx context.Context, interval time.Duration, lookup func(ctx context.Context) ([]string, error), update func([]string, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		update(lookup(ctx))
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sleep waits for d, returning false if ctx is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,132 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestBuilderFollowsEndpoints(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := grpc.NewServer()
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(listener)
+	defer server.Stop()
+
+	builder := NewBuilder(NewStaticResolver(map[string]string{"order-service": listener.Addr().String()}))
+	conn, err := grpc.NewClient("discovery://order-service",
+		grpc.WithTransportCredentials(insecure.NewCredentials()), grpc.WithResolvers(builder))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}); err != nil {
+		t.Fatalf("call through discovered endpoint: %v", err)
+	}
+	if endpoints := builder.Endpoints("discovery://order-service"); !reflect.DeepEqual(endpoints, []string{listener.Addr().String()}) {
+		t.Errorf("endpoints = %v", endpoints)
+	}
+}
+
+func TestConsulResolver(t *testing.T) {
+	index := 7
+	consul := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/health/service/order-service" || r.URL.Query().Get("passing") != "true" || r.Header.Get("X-Consul-Token") != "secret" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		if r.URL.Query().Get("index") == fmt.Sprint(index) {
+			index++ // The blocking query returns on the next change
+		}
+		w.Header().Set("X-Consul-Index", fmt.Sprint(index))
+		fmt.Fprintf(w, `[{"Node":{"Address":"10.0.0.1"},"Service":{"Address":"","Port":50052}},
+			{"Node":{"Address":"10.0.0.2"},"Service":{"Address":"10.1.0.%d","Port":50052}}]`, index)
+	}))
+	defer consul.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := make(chan []string, 4)
+	go NewConsulResolver(consul.URL, "secret").Watch(ctx, "order-service", func(endpoints []string, err error) {
+		if err != nil {
+			t.Errorf("watch error: %v", err)
+			cancel()
+			return
+		}
+		updates <- endpoints
+		if len(updates) == 2 {
+			cancel()
+		}
+	})
+
+	for _, want := range [][]string{{"10.0.0.1:50052", "10.1.0.7:50052"}, {"10.0.0.1:50052", "10.1.0.8:50052"}} {
+		select {
+		case got := <-updates:
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("endpoints = %v, want %v", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no update, want %v", want)
+		}
+	}
+}
+
+func TestKubernetesResolver(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/namespaces/hub/endpoints/order-service":
+			fmt.Fprint(w, `{"metadata":{"resourceVersion":"41"},"subsets":[{"addresses":[{"ip":"10.0.0.1"}],
+				"ports":[{"name":"metrics","port":9090},{"name":"grpc","port":50052}]}]}`)
+		case r.URL.Path == "/api/v1/namespaces/hub/endpoints" && r.URL.Query().Get("resourceVersion") == "41":
+			fmt.Fprint(w, `{"type":"MODIFIED","object":{"metadata":{"resourceVersion":"42"},"subsets":[{"addresses":[{"ip":"10.0.0.1"},{"ip":"10.0.0.2"}],
+				"ports":[{"name":"grpc","port":50052}]}]}}`+"\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		default:
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+		}
+	}))
+	defer api.Close()
+
+	resolver, err := NewKubernetesResolver(api.URL, "hub", "grpc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	updates := make(chan []string, 4)
+	go resolver.Watch(ctx, "order-service", func(endpoints []string, err error) {
+		if err != nil {
+			t.Errorf("watch error: %v", err)
+			return
+		}
+		updates <- endpoints
+	})
+
+	for _, want := range [][]string{{"10.0.0.1:50052"}, {"10.0.0.1:50052", "10.0.0.2:50052"}} {
+		select {
+		case got := <-updates:
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("endpoints = %v, want %v", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no update, want %v", want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// DNSResolver looks up SRV records of _grpc._tcp.<service>.<domain>
+type DNSResolver struct {
+	domain   string
+	interval time.Duration
+	resolver *net.Resolver
+}
+
+// NewDNSResolver creates a resolver re-querying DNS every interval (30s by default)
+func NewDNSResolver(domain string, interval time.Duration) *DNSResolver {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &DNSResolver{domain: strings.Trim(domain, "."), interval: interval, resolver: net.DefaultResolver}
+}
+
+// Watch polls the service's SRV records
+func (d *DNSResolver) Watch(ctx context.Context, service string, update func([]string, error)) {
+	name := service
+	if d.domain != "" {
+		name += "." + d.domain
+	}
+
+	poll(ctx, d.interval, func(ctx context.Context) ([]string, error) {
+		_, records, err := d.resolver.LookupSRV(ctx, "grpc", "tcp", name)
+		if err != nil {
+			return nil, fmt.Errorf("SRV lookup of %s: %w", name, err)
+		}
+		endpoints := make([]string, 0, len(records))
+		for _, record := range records {
+			endpoints = append(endpoints, net.JoinHostPort(strings.TrimSuffix(record.Target, "."), fmt.Sprint(record.Port)))
+		}
+		return endpoints, nil
+	}, update)
+}
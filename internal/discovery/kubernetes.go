@@ -0,0 +1,210 @@
+package discovery
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// In-cluster service account files
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	tokenFile         = serviceAccountDir + "/token"
+	caFile            = serviceAccountDir + "/ca.crt"
+	namespaceFile     = serviceAccountDir + "/namespace"
+)
+
+// errWatchExpired means the watched resource version is gone and the
+// endpoints must be listed again
+var errWatchExpired = errors.New("watch expired")
+
+// KubernetesResolver watches the Endpoints object of each service through
+// the Kubernetes API
+type KubernetesResolver struct {
+	apiURL    string
+	namespace string
+	portName  string
+	tokenFile string
+	client    *http.Client
+}
+
+// NewKubernetesResolver creates a resolver for the API at apiURL, defaulting
+// to the in-cluster API server, service account and namespace. Endpoints use
+// the port named portName, or the first port when empty.
+func NewKubernetesResolver(apiURL, namespace, portName string) (*KubernetesResolver, error) {
+	client := &http.Client{}
+	if apiURL == "" {
+		host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+		if host == "" || port == "" {
+			return nil, errors.New("DISCOVERY_KUBERNETES_API_URL is required outside a cluster")
+		}
+		apiURL = "https://" + net.JoinHostPort(host, port)
+
+		ca, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read service account CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		pool.AppendCertsFromPEM(ca)
+		client.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}
+	}
+	if namespace == "" {
+		data, err := os.ReadFile(namespaceFile)
+		if err != nil {
+			namespace = "default"
+		} else {
+			namespace = strings.TrimSpace(string(data))
+		}
+	}
+
+	return &KubernetesResolver{
+		apiURL:    strings.TrimSuffix(apiURL, "/"),
+		namespace: namespace,
+		portName:  portName,
+		tokenFile: tokenFile,
+		client:    client,
+	}, nil
+}
+
+// endpointsObject is the part of a v1 Endpoints object the resolver reads
+type endpointsObject struct {
+	Metadata struct {
+		ResourceVersion string `json:"resourceVersion"`
+	} `json:"metadata"`
+	Subsets []struct {
+		Addresses []struct {
+			IP string `json:"ip"`
+		} `json:"addresses"`
+		Ports []struct {
+			Name string `json:"name"`
+			Port int    `json:"port"`
+		} `json:"ports"`
+	} `json:"subsets"`
+}
+
+// watchEvent is a line of a Kubernetes watch stream
+type watchEvent struct {
+	Type   string          `json:"type"`
+	Object json.RawMessage `json:"object"`
+}
+
+// Watch lists the service's endpoints, then follows changes with a watch,
+// listing again whenever the watch ends
+func (k *KubernetesResolver) Watch(ctx context.Context, service string, update func([]string, error)) {
+	for ctx.Err() == nil {
+		var object endpointsObject
+		err := k.get(ctx, "/api/v1/namespaces/"+url.PathEscape(k.namespace)+"/endpoints/"+url.PathEscape(service), &object)
+		if err == nil {
+			update(k.endpoints(object), nil)
+			err = k.follow(ctx, service, object.Metadata.ResourceVersion, update)
+		}
+		if err != nil && !errors.Is(err, errWatchExpired) && ctx.Err() == nil {
+			update(nil, fmt.Errorf("kubernetes endpoints of %s: %w", service, err))
+			if !sleep(ctx, retryDelay) {
+				return
+			}
+		}
+	}
+}
+
+// follow streams changes to the service's endpoints from resourceVersion on
+func (k *KubernetesResolver) follow(ctx context.Context, service, resourceVersion string, update func([]string, error)) error {
+	query := url.Values{
+		"watch":           {"true"},
+		"fieldSelector":   {"metadata.name=" + service},
+		"resourceVersion": {resourceVersion},
+		"timeoutSeconds":  {"300"},
+	}
+	response, err := k.do(ctx, "/api/v1/namespaces/"+url.PathEscape(k.namespace)+"/endpoints?"+query.Encode())
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	decoder := json.NewDecoder(response.Body)
+	for {
+		var event watchEvent
+		if err := decoder.Decode(&event); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			// The server closes watches after timeoutSeconds; list and watch again
+			return errWatchExpired
+		}
+
+		switch event.Type {
+		case "ADDED", "MODIFIED":
+			var object endpointsObject
+			if err := json.Unmarshal(event.Object, &object); err != nil {
+				return err
+			}
+			update(k.endpoints(object), nil)
+		case "DELETED":
+			update(nil, nil)
+		case "ERROR":
+			return errWatchExpired
+		}
+	}
+}
+
+// endpoints returns the ready addresses of an Endpoints object with the resolver's port
+func (k *KubernetesResolver) endpoints(object endpointsObject) []string {
+	var endpoints []string
+	for _, subset := range object.Subsets {
+		port := 0
+		for _, candidate := range subset.Ports {
+			if k.portName == "" || candidate.Name == k.portName {
+				port = candidate.Port
+				break
+			}
+		}
+		if port == 0 {
+			continue
+		}
+		for _, address := range subset.Addresses {
+			endpoints = append(endpoints, net.JoinHostPort(address.IP, strconv.Itoa(port)))
+		}
+	}
+	return endpoints
+}
+
+// get decodes a JSON object from the API
+func (k *KubernetesResolver) get(ctx context.Context, path string, target interface{}) error {
+	response, err := k.do(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	return json.NewDecoder(response.Body).Decode(target)
+}
+
+// do sends an authenticated GET to the API, failing on non-2xx responses
+func (k *KubernetesResolver) do(ctx context.Context, path string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, k.apiURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	// Projected service account tokens rotate; read the current one
+	if token, err := os.ReadFile(k.tokenFile); err == nil {
+		req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	}
+
+	response, err := k.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		response.Body.Close()
+		return nil, fmt.Errorf("kubernetes API returned %s", response.Status)
+	}
+	return response, nil
+}
@@ -0,0 +1,35 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// StaticResolver serves fixed endpoint lists from configuration
+type StaticResolver struct {
+	endpoints map[string][]string
+}
+
+// NewStaticResolver creates a resolver from service -> "host:port|host:port" lists
+func NewStaticResolver(services map[string]string) *StaticResolver {
+	endpoints := make(map[string][]string, len(services))
+	for service, list := range services {
+		for _, endpoint := range strings.Split(list, "|") {
+			if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+				endpoints[service] = append(endpoints[service], endpoint)
+			}
+		}
+	}
+	return &StaticResolver{endpoints: endpoints}
+}
+
+// Watch reports the service's configured endpoints once
+func (s *StaticResolver) Watch(_ context.Context, service string, update func([]string, error)) {
+	endpoints, ok := s.endpoints[service]
+	if !ok {
+		update(nil, fmt.Errorf("service %s is not listed in DISCOVERY_STATIC", service))
+		return
+	}
+	update(endpoints, nil)
+}
@@ -0,0 +1,96 @@
+// Package drain lets the gateway shut down without cutting off requests: it
+// tracks in-flight requests (WebSockets and streams included), asks clients
+// to close their connections once draining starts, and reports the requests
+// still running when the shutdown deadline passes.
+package drain
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// Tracker counts in-flight requests and signals when they have all finished
+type Tracker struct {
+	draining atomic.Bool
+
+	mu       sync.Mutex
+	inFlight int
+	idle     chan struct{} // Closed while no request is in flight
+}
+
+// NewTracker creates a tracker with no request in flight
+func NewTracker() *Tracker {
+	idle := make(chan struct{})
+	close(idle)
+	return &Tracker{idle: idle}
+}
+
+// Middleware counts requests for the whole time their handler runs, which
+// covers hijacked WebSocket connections and streams. While draining, HTTP/1.1
+// clients are told to close the connection after the response.
+func (t *Tracker) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.begin()
+		defer t.end()
+
+		if t.draining.Load() {
+			w.Header().Set("Connection", "close")
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// begin records a request starting
+func (t *Tracker) begin() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.inFlight == 0 {
+		t.idle = make(chan struct{})
+	}
+	t.inFlight++
+}
+
+// end records a request finishing
+func (t *Tracker) end() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.inFlight--
+	if t.inFlight == 0 {
+		close(t.idle)
+	}
+}
+
+// Start begins draining: readiness fails and connections are closed after
+// their current response. Requests are still served.
+func (t *Tracker) Start() {
+	t.draining.Store(true)
+}
+
+// Draining reports whether the gateway is shutting down
+func (t *Tracker) Draining() bool {
+	return t.draining.Load()
+}
+
+// InFlight returns the number of requests being served
+func (t *Tracker) InFlight() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.inFlight
+}
+
+// Wait blocks until no request is in flight or ctx is done, and returns the
+// number of requests still running
+func (t *Tracker) Wait(ctx context.Context) int {
+	t.mu.Lock()
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return 0
+	case <-ctx.Done():
+		return t.InFlight()
+	}
+}
@@ -0,0 +1,63 @@
+package drain
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestTrackerDrainsInFlightRequests(t *testing.T) {
+	tracker := NewTracker()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := tracker.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stream" {
+			close(started)
+			<-release
+		}
+	}))
+
+	go handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/stream", nil))
+	<-started
+	tracker.Start()
+
+	// Requests arriving while draining are served but close their connection
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/orders", nil))
+	if rec.Header().Get("Connection") != "close" {
+		t.Errorf("Connection = %q, want close while draining", rec.Header().Get("Connection"))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if dropped := tracker.Wait(ctx); dropped != 1 {
+		t.Errorf("dropped = %d, want the stream still in flight", dropped)
+	}
+
+	close(release)
+	if dropped := tracker.Wait(context.Background()); dropped != 0 || tracker.InFlight() != 0 {
+		t.Errorf("dropped = %d, in flight = %d after the stream ended", dropped, tracker.InFlight())
+	}
+}
+
+func TestListenReusePort(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("SO_REUSEPORT handoff is tested on Linux")
+	}
+
+	first, err := Listen("127.0.0.1:0", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer first.Close()
+
+	// A new process binds the same port while the old one still listens
+	second, err := Listen(first.Addr().String(), true)
+	if err != nil {
+		t.Fatalf("second listener: %v", err)
+	}
+	second.Close()
+}
@@ -0,0 +1,17 @@
+package drain
+
+import (
+	"context"
+	"net"
+)
+
+// Listen opens the server's TCP listener. With reusePort, SO_REUSEPORT lets
+// a new gateway process bind the same port while the old one drains, so
+// restarts don't refuse connections.
+func Listen(address string, reusePort bool) (net.Listener, error) {
+	if !reusePort {
+		return net.Listen("tcp", address)
+	}
+	config := net.ListenConfig{Control: reusePortControl}
+	return config.Listen(context.Background(), "tcp", address)
+}
@@ -0,0 +1,13 @@
+//go:build !(linux || darwin || freebsd)
+
+package drain
+
+import (
+	"errors"
+	"syscall"
+)
+
+// reusePortControl fails where SO_REUSEPORT isn't available
+func reusePortControl(network, address string, conn syscall.RawConn) error {
+	return errors.New("SERVER_REUSE_PORT is not supported on this platform")
+}
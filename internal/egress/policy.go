@@ -0,0 +1,157 @@
+// Package egress restricts the addresses the gateway may dial to an allowlist
+// of CIDR ranges and hostnames, so a misconfigured service address or route
+// can't turn the gateway into an open proxy.
+package egress
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"hub-api-gateway/internal/discovery"
+
+	"google.golang.org/grpc"
+)
+
+// Policy is an egress allowlist. A policy without entries allows everything.
+type Policy struct {
+	networks []*net.IPNet
+	hosts    []string // exact hostnames or "*.suffix" patterns, lowercase
+}
+
+// NewPolicy parses allowlist entries: CIDRs (10.0.0.0/8), IPs (10.1.2.3),
+// hostnames (order-service) and wildcard domains (*.svc.cluster.local)
+func NewPolicy(entries []string) (*Policy, error) {
+	policy := &Policy{}
+
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+
+		if strings.Contains(entry, "/") {
+			_, network, err := net.ParseCIDR(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid egress CIDR %q: %w", entry, err)
+			}
+			policy.networks = append(policy.networks, network)
+			continue
+		}
+
+		if ip := net.ParseIP(entry); ip != nil {
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			policy.networks = append(policy.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		policy.hosts = append(policy.hosts, entry)
+	}
+
+	return policy, nil
+}
+
+// Enabled returns whether the policy restricts egress
+func (p *Policy) Enabled() bool {
+	return p != nil && (len(p.networks) > 0 || len(p.hosts) > 0)
+}
+
+// allowsHost returns whether a hostname is allowlisted by name
+func (p *Policy) allowsHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, allowed := range p.hosts {
+		if strings.HasPrefix(allowed, "*.") {
+			if strings.HasSuffix(host, allowed[1:]) {
+				return true
+			}
+		} else if host == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsIP returns whether an IP falls in an allowlisted range
+func (p *Policy) allowsIP(ip net.IP) bool {
+	for _, network := range p.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckTarget validates a configured host:port address. IP literals must be in
+// an allowed range; hostnames must be allowlisted by name or resolve only to
+// allowed ranges.
+func (p *Policy) CheckTarget(ctx context.Context, address string) error {
+	// Discovered endpoints aren't known up front; DialOption checks each one
+	if !p.Enabled() || discovery.IsTarget(address) {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: %w", address, err)
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if !p.allowsIP(ip) {
+			return fmt.Errorf("egress to %s is not allowed by EGRESS_ALLOWLIST", address)
+		}
+		return nil
+	}
+
+	if p.allowsHost(host) {
+		return nil
+	}
+
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("egress to %s is not allowed: host is not allowlisted and could not be resolved: %w", address, err)
+	}
+	for _, ip := range ips {
+		if !p.allowsIP(ip) {
+			return fmt.Errorf("egress to %s is not allowed: %s resolves to %s", address, host, ip)
+		}
+	}
+	return nil
+}
+
+// DialOption returns a gRPC dial option enforcing the policy for target. When
+// the target's hostname is allowlisted by name its resolved addresses are
+// trusted; otherwise every dialed IP must be in an allowed range, which also
+// catches DNS answers that change after startup.
+func (p *Policy) DialOption(target string) grpc.DialOption {
+	var dialer net.Dialer
+
+	return grpc.WithContextDialer(func(ctx context.Context, address string) (net.Conn, error) {
+		if p.Enabled() {
+			host, _, _ := net.SplitHostPort(target)
+			if !p.allowsHost(host) {
+				if err := p.checkDialAddress(address); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return dialer.DialContext(ctx, "tcp", address)
+	})
+}
+
+// checkDialAddress validates the resolved host:port gRPC is about to dial
+func (p *Policy) checkDialAddress(address string) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		host = address
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !p.allowsIP(ip) {
+		return fmt.Errorf("egress to %s blocked by policy", address)
+	}
+	return nil
+}
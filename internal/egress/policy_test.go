@@ -0,0 +1,57 @@
+package egress
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPolicyCheckTarget(t *testing.T) {
+	policy, err := NewPolicy([]string{"10.0.0.0/8", "127.0.0.1", "user-service", "*.svc.cluster.local"})
+	if err != nil {
+		t.Fatalf("failed to parse policy: %v", err)
+	}
+
+	allowed := []string{
+		"10.1.2.3:50051",
+		"127.0.0.1:50060",
+		"user-service:50051",
+		"order-service.trading.svc.cluster.local:50052",
+	}
+	for _, address := range allowed {
+		if err := policy.CheckTarget(context.Background(), address); err != nil {
+			t.Errorf("expected %s to be allowed: %v", address, err)
+		}
+	}
+
+	denied := []string{
+		"8.8.8.8:443",
+		"192.168.1.10:50051",
+		"127.0.0.2:50051",
+	}
+	for _, address := range denied {
+		if err := policy.CheckTarget(context.Background(), address); err == nil {
+			t.Errorf("expected %s to be denied", address)
+		}
+	}
+
+	if err := policy.checkDialAddress("169.254.169.254:80"); err == nil {
+		t.Errorf("expected metadata endpoint to be blocked at dial time")
+	}
+}
+
+func TestPolicyDisabled(t *testing.T) {
+	policy, err := NewPolicy(nil)
+	if err != nil {
+		t.Fatalf("failed to parse policy: %v", err)
+	}
+	if policy.Enabled() {
+		t.Errorf("expected empty policy to be disabled")
+	}
+	if err := policy.CheckTarget(context.Background(), "8.8.8.8:443"); err != nil {
+		t.Errorf("expected disabled policy to allow everything: %v", err)
+	}
+
+	if _, err := NewPolicy([]string{"10.0.0.0/33"}); err == nil {
+		t.Errorf("expected invalid CIDR to be rejected")
+	}
+}
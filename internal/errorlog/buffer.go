@@ -0,0 +1,98 @@
+package errorlog
+
+import (
+	"sync"
+	"time"
+)
+
+// MaxMessageLength is the maximum stored length of an error message
+const MaxMessageLength = 256
+
+// Entry describes a single gateway error
+type Entry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Route     string    `json:"route,omitempty"`
+	Status    int       `json:"status"`
+	Code      string    `json:"code"`
+	Backend   string    `json:"backend,omitempty"`
+	RequestID string    `json:"requestId,omitempty"`
+	Message   string    `json:"message"`
+}
+
+// Buffer is a fixed-size ring buffer holding the most recent gateway errors
+type Buffer struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int
+	count   int
+	total   uint64
+}
+
+// NewBuffer creates a ring buffer that keeps the last size errors
+func NewBuffer(size int) *Buffer {
+	if size <= 0 {
+		size = 100
+	}
+	return &Buffer{entries: make([]Entry, size)}
+}
+
+// Record stores an error, overwriting the oldest entry when the buffer is full
+func (b *Buffer) Record(entry Entry) {
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	entry.Message = truncate(entry.Message, MaxMessageLength)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.entries[b.next] = entry
+	b.next = (b.next + 1) % len(b.entries)
+	if b.count < len(b.entries) {
+		b.count++
+	}
+	b.total++
+}
+
+// Recent returns up to limit entries, newest first (limit <= 0 returns all)
+func (b *Buffer) Recent(limit int) []Entry {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if limit <= 0 || limit > b.count {
+		limit = b.count
+	}
+
+	result := make([]Entry, 0, limit)
+	for i := 1; i <= limit; i++ {
+		idx := (b.next - i + len(b.entries)) % len(b.entries)
+		result = append(result, b.entries[idx])
+	}
+	return result
+}
+
+// Total returns the number of errors recorded since startup
+func (b *Buffer) Total() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.total
+}
+
+// Capacity returns the maximum number of entries kept
+func (b *Buffer) Capacity() int {
+	return len(b.entries)
+}
+
+// truncate shortens s to at most max bytes without splitting a UTF-8 sequence
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && s[cut]&0xC0 == 0x80 {
+		cut--
+	}
+	return s[:cut] + "..."
+}
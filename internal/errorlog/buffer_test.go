@@ -0,0 +1,46 @@
+package errorlog
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuffer_RecentWrapsAround(t *testing.T) {
+	buffer := NewBuffer(3)
+	for _, code := range []string{"A", "B", "C", "D", "E"} {
+		buffer.Record(Entry{Code: code})
+	}
+
+	recent := buffer.Recent(0)
+	if len(recent) != 3 {
+		t.Fatalf("expected 3 entries but got %d", len(recent))
+	}
+
+	expected := []string{"E", "D", "C"}
+	for i, entry := range recent {
+		if entry.Code != expected[i] {
+			t.Errorf("entry %d: expected %s but got %s", i, expected[i], entry.Code)
+		}
+	}
+
+	if buffer.Total() != 5 {
+		t.Errorf("expected total 5 but got %d", buffer.Total())
+	}
+
+	if limited := buffer.Recent(1); len(limited) != 1 || limited[0].Code != "E" {
+		t.Errorf("expected newest entry only but got %v", limited)
+	}
+}
+
+func TestBuffer_TruncatesMessage(t *testing.T) {
+	buffer := NewBuffer(1)
+	buffer.Record(Entry{Message: strings.Repeat("x", MaxMessageLength*2)})
+
+	entry := buffer.Recent(1)[0]
+	if len(entry.Message) != MaxMessageLength+len("...") {
+		t.Errorf("expected truncated message but got length %d", len(entry.Message))
+	}
+	if entry.Timestamp.IsZero() {
+		t.Errorf("expected timestamp to be set")
+	}
+}
@@ -0,0 +1,246 @@
+// Package errtemplate renders configured JSON bodies for gateway-generated
+// errors (maintenance, circuit open, rate limited, ...), so their wording and
+// shape can change per client type without touching the handlers.
+//
+// Templates are plain JSON documents written in YAML. String values may
+// reference variables as {{name}}; a value that is a single variable keeps the
+// variable's type (e.g. retry_after stays a number). Variables are inserted as
+// data, never evaluated, so a template always renders valid JSON.
+package errtemplate
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"hub-api-gateway/internal/logging"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ClientTypeHeader lets clients say which variant of the error bodies they want
+const ClientTypeHeader = "X-Client-Type"
+
+// Client types detected when ClientTypeHeader is missing
+const (
+	ClientWeb    = "web"
+	ClientMobile = "mobile"
+)
+
+const (
+	anyCode        = "*"       // Template used for codes without their own
+	defaultVariant = "default" // Variant used for client types without their own
+)
+
+// mobileAgents are User-Agent markers of mobile apps and browsers
+var mobileAgents = []string{"Mobile", "Android", "iPhone", "iPad", "okhttp", "CFNetwork", "Dart/"}
+
+// variablePattern matches {{name}} references
+var variablePattern = regexp.MustCompile(`\{\{\s*([a-z_]+)\s*\}\}`)
+
+// Vars are the error's values templates can reference
+type Vars struct {
+	Status     int
+	Code       string
+	Message    string
+	Service    string // Backend service, when the error concerns one
+	RetryAfter int    // Seconds; defaults to the response's Retry-After header
+}
+
+// variables returns every variable by name for a request
+func (v Vars) variables(r *http.Request) map[string]interface{} {
+	requestID := logging.RequestID(r.Context())
+	if requestID == "" {
+		requestID = r.Header.Get(logging.RequestIDHeader)
+	}
+	return map[string]interface{}{
+		"status":      v.Status,
+		"code":        v.Code,
+		"message":     v.Message,
+		"service":     v.Service,
+		"retry_after": v.RetryAfter,
+		"request_id":  requestID,
+		"method":      r.Method,
+		"path":        r.URL.Path,
+	}
+}
+
+// knownVariables are the names templates may reference
+var knownVariables = Vars{}.variables(&http.Request{Header: http.Header{}, URL: &url.URL{}})
+
+// Set is a loaded set of templates: error code -> client type -> body
+type Set struct {
+	templates map[string]map[string]interface{}
+}
+
+// file is the templates file layout
+type file struct {
+	Templates map[string]map[string]interface{} `yaml:"templates"`
+}
+
+// Load reads a templates file
+func Load(path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read error templates: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse parses templates and checks every variable they reference exists
+func Parse(data []byte) (*Set, error) {
+	var f file
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("invalid error templates: %w", err)
+	}
+
+	for code, variants := range f.Templates {
+		for client, body := range variants {
+			if _, ok := body.(map[string]interface{}); !ok {
+				return nil, fmt.Errorf("error template %s/%s must be an object", code, client)
+			}
+			if err := checkVariables(body); err != nil {
+				return nil, fmt.Errorf("error template %s/%s: %w", code, client, err)
+			}
+		}
+	}
+	return &Set{templates: f.Templates}, nil
+}
+
+// checkVariables rejects references to unknown variables
+func checkVariables(value interface{}) error {
+	switch v := value.(type) {
+	case string:
+		for _, match := range variablePattern.FindAllStringSubmatch(v, -1) {
+			if _, ok := knownVariables[match[1]]; !ok {
+				return fmt.Errorf("unknown variable {{%s}}", match[1])
+			}
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			if err := checkVariables(item); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range v {
+			if err := checkVariables(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// template returns the body for an error code and client type, if any
+func (s *Set) template(code, client string) (interface{}, bool) {
+	for _, c := range []string{code, anyCode} {
+		variants, ok := s.templates[c]
+		if !ok {
+			continue
+		}
+		if body, ok := variants[client]; ok {
+			return body, true
+		}
+		if body, ok := variants[defaultVariant]; ok {
+			return body, true
+		}
+	}
+	return nil, false
+}
+
+var (
+	mu      sync.RWMutex
+	current *Set
+)
+
+// Configure installs the templates used by Write (nil disables them)
+func Configure(set *Set) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = set
+}
+
+// Write sends the templated body for the error, if one is configured for its
+// code, and reports whether it did
+func Write(w http.ResponseWriter, r *http.Request, vars Vars) bool {
+	mu.RLock()
+	set := current
+	mu.RUnlock()
+	if set == nil {
+		return false
+	}
+
+	body, ok := set.template(vars.Code, ClientType(r))
+	if !ok {
+		return false
+	}
+	if vars.RetryAfter == 0 {
+		vars.RetryAfter, _ = strconv.Atoi(w.Header().Get("Retry-After"))
+	}
+
+	encoded, err := json.Marshal(render(body, vars.variables(r)))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to render error template", "code", vars.Code, "error", err)
+		return false
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(vars.Status)
+	w.Write(append(encoded, '\n'))
+	return true
+}
+
+// ClientType returns the client type of a request: ClientTypeHeader when set,
+// else mobile or web from the User-Agent
+func ClientType(r *http.Request) string {
+	if client := strings.ToLower(strings.TrimSpace(r.Header.Get(ClientTypeHeader))); client != "" && len(client) <= 32 {
+		return client
+	}
+	userAgent := r.UserAgent()
+	for _, marker := range mobileAgents {
+		if strings.Contains(userAgent, marker) {
+			return ClientMobile
+		}
+	}
+	return ClientWeb
+}
+
+// render substitutes variables in a template value
+func render(value interface{}, variables map[string]interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		if match := variablePattern.FindStringSubmatch(v); match != nil && match[0] == v {
+			return variables[match[1]]
+		}
+		return variablePattern.ReplaceAllStringFunc(v, func(ref string) string {
+			switch value := variables[variablePattern.FindStringSubmatch(ref)[1]].(type) {
+			case int:
+				return strconv.Itoa(value)
+			default:
+				return fmt.Sprint(value)
+			}
+		})
+	case map[string]interface{}:
+		rendered := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			rendered[key] = render(item, variables)
+		}
+		return rendered
+	case []interface{}:
+		rendered := make([]interface{}, len(v))
+		for i, item := range v {
+			rendered[i] = render(item, variables)
+		}
+		return rendered
+	default:
+		return v
+	}
+}
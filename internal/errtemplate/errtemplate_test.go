@@ -0,0 +1,117 @@
+package errtemplate
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	set, err := Parse([]byte(`
+templates:
+  SERVICE_MAINTENANCE:
+    default:
+      error: "{{service}} is down for maintenance"
+      retry_after_seconds: "{{retry_after}}"
+      request_id: "{{request_id}}"
+    mobile:
+      title: "Back in {{retry_after}}s"
+  "*":
+    mobile:
+      title: "Something went wrong"
+      code: "{{code}}"
+`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	Configure(set)
+	t.Cleanup(func() { Configure(nil) })
+
+	tests := []struct {
+		name      string
+		code      string
+		userAgent string
+		client    string
+		want      map[string]interface{}
+	}{
+		{
+			name: "default variant",
+			code: "SERVICE_MAINTENANCE",
+			want: map[string]interface{}{
+				"error":               `order-service "v2" is down for maintenance`,
+				"retry_after_seconds": float64(120),
+				"request_id":          "req-1",
+			},
+		},
+		{
+			name:      "mobile user agent",
+			code:      "SERVICE_MAINTENANCE",
+			userAgent: "HubInvest/3.2 (iPhone; iOS 17.0)",
+			want:      map[string]interface{}{"title": "Back in 120s"},
+		},
+		{
+			name:   "client type header",
+			code:   "RATE_LIMIT_EXCEEDED",
+			client: "Mobile",
+			want:   map[string]interface{}{"title": "Something went wrong", "code": "RATE_LIMIT_EXCEEDED"},
+		},
+		{
+			name: "no template",
+			code: "RATE_LIMIT_EXCEEDED",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/v1/orders", nil)
+			r.Header.Set("X-Request-ID", "req-1")
+			r.Header.Set("User-Agent", tt.userAgent)
+			if tt.client != "" {
+				r.Header.Set(ClientTypeHeader, tt.client)
+			}
+			w := httptest.NewRecorder()
+			w.Header().Set("Retry-After", "120")
+
+			written := Write(w, r, Vars{Status: http.StatusServiceUnavailable, Code: tt.code, Service: `order-service "v2"`})
+			if written != (tt.want != nil) {
+				t.Fatalf("Write() = %v, want %v", written, tt.want != nil)
+			}
+			if !written {
+				return
+			}
+
+			var got map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("body %q is not JSON: %v", w.Body.String(), err)
+			}
+			if w.Code != http.StatusServiceUnavailable || len(got) != len(tt.want) {
+				t.Fatalf("response = %d %v, want 503 %v", w.Code, got, tt.want)
+			}
+			for key, value := range tt.want {
+				if got[key] != value {
+					t.Errorf("%s = %v, want %v", key, got[key], value)
+				}
+			}
+		})
+	}
+}
+
+func TestParse_RejectsInvalidTemplates(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown variable": "templates:\n  LOAD_SHED:\n    default:\n      error: \"{{password}}\"\n",
+		"not an object":    "templates:\n  LOAD_SHED:\n    default: overloaded\n",
+	} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("Parse(%s) succeeded, want an error", name)
+		}
+	}
+}
+
+func TestLoad_ExampleFile(t *testing.T) {
+	path := filepath.Join("..", "..", "config", "error-templates.yaml")
+	if _, err := Load(path); err != nil {
+		t.Fatalf("Load(%s) error = %v", path, err)
+	}
+}
@@ -0,0 +1,175 @@
+package features
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"hub-api-gateway/internal/httperr"
+	"hub-api-gateway/internal/middleware"
+	"hub-api-gateway/internal/router"
+)
+
+// flagsContextKey is the request context key holding evaluated flags
+type flagsContextKey struct{}
+
+// cachedFlags is a per-user evaluation result with its expiry
+type cachedFlags struct {
+	flags     map[string]string
+	expiresAt time.Time
+}
+
+// Evaluator evaluates the configured flags per user with a short-lived cache,
+// so the flag provider is called at most once per user per TTL
+type Evaluator struct {
+	provider Provider
+	flags    []string
+	cacheTTL time.Duration
+	timeout  time.Duration
+
+	mu    sync.RWMutex
+	cache map[string]cachedFlags
+}
+
+// NewEvaluator creates a flag evaluator for the given flags
+func NewEvaluator(provider Provider, flags []string, cacheTTL, timeout time.Duration) *Evaluator {
+	return &Evaluator{
+		provider: provider,
+		flags:    flags,
+		cacheTTL: cacheTTL,
+		timeout:  timeout,
+		cache:    make(map[string]cachedFlags),
+	}
+}
+
+// Evaluate returns the configured flags for a user. Provider failures are
+// logged and yield no flags, so backends fall back to their defaults.
+func (e *Evaluator) Evaluate(ctx context.Context, user User) map[string]string {
+	return e.evaluate(ctx, user.ID, user, e.flags)
+}
+
+// Flag returns the value of one flag for a user, "" when it can't be
+// evaluated. Results are cached per user and flag.
+func (e *Evaluator) Flag(ctx context.Context, user User, flag string) string {
+	return e.evaluate(ctx, flag+"\x00"+user.ID, user, []string{flag})[flag]
+}
+
+// evaluate returns the flags for a user, cached under cacheKey
+func (e *Evaluator) evaluate(ctx context.Context, cacheKey string, user User, flags []string) map[string]string {
+	e.mu.RLock()
+	cached, ok := e.cache[cacheKey]
+	e.mu.RUnlock()
+
+	if ok && time.Now().Before(cached.expiresAt) {
+		return cached.flags
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	values, err := e.provider.Evaluate(ctx, user, flags)
+	if err != nil {
+		slog.WarnContext(ctx, "feature flag evaluation failed", "provider", e.provider.Name(), "error", err)
+		return nil
+	}
+
+	e.mu.Lock()
+	e.cache[cacheKey] = cachedFlags{flags: values, expiresAt: time.Now().Add(e.cacheTTL)}
+	e.evictExpiredLocked()
+	e.mu.Unlock()
+
+	return values
+}
+
+// evictExpiredLocked drops expired cache entries once the cache grows large
+func (e *Evaluator) evictExpiredLocked() {
+	if len(e.cache) < 10000 {
+		return
+	}
+	now := time.Now()
+	for id, entry := range e.cache {
+		if now.After(entry.expiresAt) {
+			delete(e.cache, id)
+		}
+	}
+}
+
+// Middleware evaluates flags for the authenticated user and stores them in the request context
+func (e *Evaluator) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userContext, ok := middleware.GetUserContext(r.Context())
+		if !ok || userContext == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		flags := e.Evaluate(r.Context(), User{ID: userContext.UserID, Email: userContext.Email})
+		if len(flags) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), flagsContextKey{}, flags)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// Gate serves a route dark-launched behind a feature flag only to users the
+// flag is on for; everyone else gets 404 as if the route didn't exist. The
+// flag's value joins the request's flags, so backends see it and the proxy
+// serves the matching variant. It must run inside Middleware.
+func (e *Evaluator) Gate(route *router.Route, next http.Handler) http.Handler {
+	flag := route.FeatureFlag.Name
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flags, _ := FromContext(r.Context())
+		value, ok := flags[flag]
+		if !ok {
+			if userContext, authenticated := middleware.GetUserContext(r.Context()); authenticated && userContext != nil {
+				value = e.Flag(r.Context(), User{ID: userContext.UserID, Email: userContext.Email}, flag)
+			}
+		}
+
+		if value == "" || value == router.FlagOff {
+			slog.InfoContext(r.Context(), "route hidden by feature flag", "route", route.Name, "flag", flag)
+			httperr.Send(w, r, http.StatusNotFound, "ROUTE_NOT_FOUND", fmt.Sprintf("No route found for %s %s", r.Method, r.URL.Path))
+			return
+		}
+		if ok {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		merged := make(map[string]string, len(flags)+1)
+		maps.Copy(merged, flags)
+		merged[flag] = value
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), flagsContextKey{}, merged)))
+	})
+}
+
+// FromContext returns the flags evaluated for the current request
+func FromContext(ctx context.Context) (map[string]string, bool) {
+	flags, ok := ctx.Value(flagsContextKey{}).(map[string]string)
+	return flags, ok
+}
+
+// Encode formats flags as the x-feature-flags metadata value ("a=true,b=variant"),
+// sorted by flag name so the header is stable
+func Encode(flags map[string]string) string {
+	names := make([]string, 0, len(flags))
+	for name := range flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + "=" + flags[name]
+	}
+	return strings.Join(pairs, ",")
+}
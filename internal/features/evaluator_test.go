@@ -0,0 +1,117 @@
+package features
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/middleware"
+	"hub-api-gateway/internal/router"
+)
+
+type stubProvider struct {
+	calls  int
+	err    error
+	values map[string]string // User ID -> value of every flag, when set
+}
+
+func (p *stubProvider) Name() string { return "stub" }
+
+func (p *stubProvider) Evaluate(_ context.Context, user User, flags []string) (map[string]string, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+	result := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		result[flag] = "on-for-" + user.ID
+		if p.values != nil {
+			result[flag] = p.values[user.ID]
+		}
+	}
+	return result, nil
+}
+
+func TestEvaluator_CachesPerUser(t *testing.T) {
+	provider := &stubProvider{}
+	evaluator := NewEvaluator(provider, []string{"new-checkout"}, time.Minute, time.Second)
+
+	first := evaluator.Evaluate(context.Background(), User{ID: "u1"})
+	second := evaluator.Evaluate(context.Background(), User{ID: "u1"})
+	evaluator.Evaluate(context.Background(), User{ID: "u2"})
+
+	if first["new-checkout"] != "on-for-u1" || second["new-checkout"] != "on-for-u1" {
+		t.Errorf("unexpected flag values: %v %v", first, second)
+	}
+	if provider.calls != 2 {
+		t.Errorf("expected 2 provider calls but got %d", provider.calls)
+	}
+}
+
+func TestEvaluator_ProviderFailureYieldsNoFlags(t *testing.T) {
+	evaluator := NewEvaluator(&stubProvider{err: errors.New("down")}, []string{"f"}, time.Minute, time.Second)
+
+	if flags := evaluator.Evaluate(context.Background(), User{ID: "u1"}); flags != nil {
+		t.Errorf("expected no flags but got %v", flags)
+	}
+}
+
+func TestEvaluator_Gate(t *testing.T) {
+	provider := &stubProvider{values: map[string]string{"u1": "v2", "u2": "false"}}
+	evaluator := NewEvaluator(provider, nil, time.Minute, time.Second)
+	route := &router.Route{Name: "portfolio-v2", FeatureFlag: &router.RouteFeatureFlag{Name: "portfolio-v2"}}
+	handler := evaluator.Gate(route, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flags, _ := FromContext(r.Context())
+		w.Write([]byte(flags["portfolio-v2"]))
+	}))
+
+	serve := func(userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v2/portfolio", nil)
+		if userID != "" {
+			req = req.WithContext(context.WithValue(req.Context(), "user", &middleware.UserContext{UserID: userID}))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve("u1"); rec.Code != http.StatusOK || rec.Body.String() != "v2" {
+		t.Errorf("enabled user got %d %q, want the v2 variant", rec.Code, rec.Body.String())
+	}
+	if rec := serve("u2"); rec.Code != http.StatusNotFound {
+		t.Errorf("disabled user got %d, want 404", rec.Code)
+	}
+	if rec := serve(""); rec.Code != http.StatusNotFound {
+		t.Errorf("anonymous request got %d, want 404", rec.Code)
+	}
+}
+
+func TestFlagDefinition_Evaluate(t *testing.T) {
+	def := FlagDefinition{Enabled: true, Users: []string{"vip"}, RolloutPercentage: 0}
+	if def.evaluate("f", "vip") != "true" {
+		t.Errorf("expected listed user to get the flag")
+	}
+	if def.evaluate("f", "someone") != "false" {
+		t.Errorf("expected unlisted user to be excluded at 0%% rollout")
+	}
+
+	def = FlagDefinition{Enabled: true, RolloutPercentage: 100, Variant: "v2"}
+	if def.evaluate("f", "someone") != "v2" {
+		t.Errorf("expected variant at 100%% rollout")
+	}
+
+	def.Enabled = false
+	if def.evaluate("f", "someone") != "false" {
+		t.Errorf("expected disabled flag to be off")
+	}
+}
+
+func TestEncode(t *testing.T) {
+	encoded := Encode(map[string]string{"b": "false", "a": "true"})
+	if encoded != "a=true,b=false" {
+		t.Errorf("unexpected encoding: %s", encoded)
+	}
+}
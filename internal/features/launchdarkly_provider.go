@@ -0,0 +1,82 @@
+package features
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// LaunchDarklyProvider evaluates flags through a LaunchDarkly-compatible
+// evaluation endpoint (LaunchDarkly Relay Proxy or a compatible service).
+// It calls REPORT {baseURL}/sdk/evalx/user with the user as the body and
+// reads {"flag-key": {"value": ...}} from the response.
+type LaunchDarklyProvider struct {
+	baseURL string
+	sdkKey  string
+	client  *http.Client
+}
+
+// NewLaunchDarklyProvider creates a LaunchDarkly-compatible flag provider
+func NewLaunchDarklyProvider(baseURL, sdkKey string, timeout time.Duration) *LaunchDarklyProvider {
+	return &LaunchDarklyProvider{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		sdkKey:  sdkKey,
+		client:  &http.Client{Timeout: timeout},
+	}
+}
+
+// Name returns the provider name
+func (p *LaunchDarklyProvider) Name() string {
+	return "launchdarkly"
+}
+
+// evaluationResult is a single flag evaluation in the evalx response
+type evaluationResult struct {
+	Value interface{} `json:"value"`
+}
+
+// Evaluate evaluates all flags for the user and returns the requested ones
+func (p *LaunchDarklyProvider) Evaluate(ctx context.Context, user User, flags []string) (map[string]string, error) {
+	body, err := json.Marshal(user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode flag user: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "REPORT", p.baseURL+"/sdk/evalx/user", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build flag evaluation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", p.sdkKey)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("flag evaluation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("flag evaluation returned status %d", resp.StatusCode)
+	}
+
+	var evaluations map[string]evaluationResult
+	if err := json.NewDecoder(resp.Body).Decode(&evaluations); err != nil {
+		return nil, fmt.Errorf("failed to decode flag evaluations: %w", err)
+	}
+
+	result := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		evaluation, ok := evaluations[flag]
+		if !ok || evaluation.Value == nil {
+			result[flag] = "false"
+			continue
+		}
+		result[flag] = fmt.Sprint(evaluation.Value)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,30 @@
+package features
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// User is the evaluation context a flag provider decides on
+type User struct {
+	ID    string `json:"key"`
+	Email string `json:"email,omitempty"`
+}
+
+// Provider evaluates feature flags for a user.
+// Values are strings so boolean flags ("true"/"false") and multivariate
+// flags ("control", "variant-b") share one representation.
+type Provider interface {
+	// Name returns the provider name for logging
+	Name() string
+	// Evaluate returns the value of each requested flag for the user
+	Evaluate(ctx context.Context, user User, flags []string) (map[string]string, error)
+}
+
+// bucket deterministically maps a user to [0, 100) for a flag, so percentage
+// rollouts are stable across requests and gateway instances
+func bucket(flag, userID string) int {
+	sum := sha256.Sum256([]byte(flag + ":" + userID))
+	return int(binary.BigEndian.Uint32(sum[:4]) % 100)
+}
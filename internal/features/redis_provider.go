@@ -0,0 +1,97 @@
+package features
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisFlagKeyPrefix is the key prefix of flag definitions stored in Redis
+const redisFlagKeyPrefix = "feature_flag:"
+
+// FlagDefinition is the JSON document stored at feature_flag:<name>
+type FlagDefinition struct {
+	Enabled           bool     `json:"enabled"`
+	RolloutPercentage int      `json:"rollout_percentage"` // 0-100, applied when the user isn't listed
+	Users             []string `json:"users,omitempty"`    // Always enabled for these user IDs
+	Variant           string   `json:"variant,omitempty"`  // Value served when enabled (default "true")
+}
+
+// RedisProvider evaluates flags whose definitions are stored as JSON in Redis
+type RedisProvider struct {
+	client *redis.Client
+}
+
+// NewRedisProvider creates a Redis-backed flag provider
+func NewRedisProvider(client *redis.Client) *RedisProvider {
+	return &RedisProvider{client: client}
+}
+
+// Name returns the provider name
+func (p *RedisProvider) Name() string {
+	return "redis"
+}
+
+// Evaluate loads all flag definitions in one round trip and evaluates them for the user
+func (p *RedisProvider) Evaluate(ctx context.Context, user User, flags []string) (map[string]string, error) {
+	if len(flags) == 0 {
+		return map[string]string{}, nil
+	}
+
+	keys := make([]string, len(flags))
+	for i, flag := range flags {
+		keys[i] = redisFlagKeyPrefix + flag
+	}
+
+	values, err := p.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load feature flags from redis: %w", err)
+	}
+
+	result := make(map[string]string, len(flags))
+	for i, flag := range flags {
+		raw, ok := values[i].(string)
+		if !ok {
+			result[flag] = "false" // Undefined flags are off
+			continue
+		}
+
+		var def FlagDefinition
+		if err := json.Unmarshal([]byte(raw), &def); err != nil {
+			slog.WarnContext(ctx, "invalid feature flag definition", "flag", flag, "error", err)
+			result[flag] = "false"
+			continue
+		}
+
+		result[flag] = def.evaluate(flag, user.ID)
+	}
+
+	return result, nil
+}
+
+// evaluate returns the flag value for a user
+func (d FlagDefinition) evaluate(flag, userID string) string {
+	if !d.Enabled {
+		return "false"
+	}
+
+	on := d.Variant
+	if on == "" {
+		on = strconv.FormatBool(true)
+	}
+
+	for _, id := range d.Users {
+		if id == userID {
+			return on
+		}
+	}
+
+	if bucket(flag, userID) < d.RolloutPercentage {
+		return on
+	}
+	return "false"
+}
@@ -0,0 +1,160 @@
+// Package health reports whether the gateway is alive and ready to serve,
+// with the status and check latency of every dependency, for Kubernetes
+// liveness and readiness probes.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Gateway statuses
+const (
+	StatusHealthy   = "healthy"
+	StatusUnhealthy = "unhealthy"
+)
+
+// Dependency statuses
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check returns an error when a dependency isn't usable
+type Check func(ctx context.Context) error
+
+// Dependency is the result of a dependency check
+type Dependency struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the readiness of the gateway and its dependencies
+type Report struct {
+	Status       string       `json:"status"`
+	Timestamp    time.Time    `json:"timestamp"`
+	Dependencies []Dependency `json:"dependencies"`
+}
+
+// Ready reports whether every dependency is up
+func (r Report) Ready() bool {
+	return r.Status == StatusHealthy
+}
+
+// Checker runs the registered dependency checks
+type Checker struct {
+	timeout time.Duration
+
+	mu     sync.RWMutex
+	checks map[string]Check
+	sets   []func() map[string]Check
+}
+
+// NewChecker creates a checker giving each check up to timeout
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = 2 * time.Second
+	}
+	return &Checker{timeout: timeout, checks: make(map[string]Check)}
+}
+
+// Register adds a dependency check
+func (c *Checker) Register(name string, check Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checks[name] = check
+}
+
+// RegisterSet adds checks listed anew on every run, for dependencies that
+// change at runtime (e.g. the backends of the current route table)
+func (c *Checker) RegisterSet(checks func() map[string]Check) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sets = append(c.sets, checks)
+}
+
+// Run checks every dependency concurrently
+func (c *Checker) Run(ctx context.Context) Report {
+	c.mu.RLock()
+	checks := make(map[string]Check, len(c.checks))
+	for name, check := range c.checks {
+		checks[name] = check
+	}
+	sets := c.sets
+	c.mu.RUnlock()
+	for _, set := range sets {
+		for name, check := range set() {
+			checks[name] = check
+		}
+	}
+
+	report := Report{Status: StatusHealthy, Timestamp: time.Now(), Dependencies: make([]Dependency, 0, len(checks))}
+	results := make(chan Dependency, len(checks))
+	for name, check := range checks {
+		go func() {
+			checkCtx, cancel := context.WithTimeout(ctx, c.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(checkCtx)
+			dependency := Dependency{
+				Name:      name,
+				Status:    StatusUp,
+				LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			}
+			if err != nil {
+				dependency.Status = StatusDown
+				dependency.Error = err.Error()
+			}
+			results <- dependency
+		}()
+	}
+	for range checks {
+		dependency := <-results
+		if dependency.Status == StatusDown {
+			report.Status = StatusUnhealthy
+		}
+		report.Dependencies = append(report.Dependencies, dependency)
+	}
+
+	sort.Slice(report.Dependencies, func(i, j int) bool {
+		return report.Dependencies[i].Name < report.Dependencies[j].Name
+	})
+	return report
+}
+
+// HandleLive answers liveness probes: the process is up and serving HTTP
+func (c *Checker) HandleLive(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"status":    StatusHealthy,
+		"timestamp": time.Now(),
+	})
+}
+
+// HandleReady answers readiness probes: 200 when every dependency is up, 503 otherwise
+func (c *Checker) HandleReady(w http.ResponseWriter, r *http.Request) {
+	report := c.Run(r.Context())
+	writeJSON(w, StatusCode(report), report)
+}
+
+// StatusCode returns the HTTP status of a report
+func StatusCode(report Report) int {
+	if report.Ready() {
+		return http.StatusOK
+	}
+	return http.StatusServiceUnavailable
+}
+
+// writeJSON sends an uncacheable JSON response
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
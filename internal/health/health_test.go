@@ -0,0 +1,63 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckerReadiness(t *testing.T) {
+	checker := NewChecker(50 * time.Millisecond)
+	checker.Register("redis", func(ctx context.Context) error { return nil })
+	backends := map[string]error{"backend:hub-order-service": nil}
+	checker.RegisterSet(func() map[string]Check {
+		checks := make(map[string]Check)
+		for name, err := range backends {
+			checks[name] = func(ctx context.Context) error { return err }
+		}
+		return checks
+	})
+
+	rec := httptest.NewRecorder()
+	checker.HandleReady(rec, httptest.NewRequest("GET", "/health/ready", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ready = %d %s, want 200", rec.Code, rec.Body)
+	}
+
+	// A failing backend or a hung check makes the gateway unready, not the process dead
+	backends["backend:hub-user-service"] = errors.New("connection to hub-user-service is not ready: CONNECTING")
+	checker.Register("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	rec = httptest.NewRecorder()
+	checker.HandleReady(rec, httptest.NewRequest("GET", "/health/ready", nil))
+	var report Report
+	json.NewDecoder(rec.Body).Decode(&report)
+	if rec.Code != http.StatusServiceUnavailable || report.Status != StatusUnhealthy {
+		t.Fatalf("ready = %d %s, want 503 unhealthy", rec.Code, report.Status)
+	}
+	statuses := make(map[string]string)
+	for _, dependency := range report.Dependencies {
+		statuses[dependency.Name] = dependency.Status
+	}
+	want := map[string]string{"backend:hub-order-service": StatusUp, "backend:hub-user-service": StatusDown, "redis": StatusUp, "slow": StatusDown}
+	if len(statuses) != len(want) {
+		t.Fatalf("dependencies = %v, want %v", statuses, want)
+	}
+	for name, status := range want {
+		if statuses[name] != status {
+			t.Errorf("%s = %q, want %q", name, statuses[name], status)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	checker.HandleLive(rec, httptest.NewRequest("GET", "/health/live", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("live = %d, want 200", rec.Code)
+	}
+}
@@ -0,0 +1,199 @@
+// Package hooks lets internal extensions plug company-specific behavior (e.g.
+// compliance checks) into the gateway lifecycle without changing the core.
+//
+// An extension implements Extension plus any of the hook interfaces it needs,
+// and registers itself from an init function:
+//
+//	func init() {
+//		hooks.Register(&complianceExtension{})
+//	}
+//
+// The extension package is then linked into the gateway with a blank import
+// in cmd/server.
+package hooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+
+	"hub-api-gateway/internal/router"
+)
+
+// Extension is a named bundle of lifecycle hooks
+type Extension interface {
+	Name() string
+}
+
+// StartupHook runs once before the gateway accepts traffic; an error aborts startup
+type StartupHook interface {
+	OnStartup(ctx context.Context) error
+}
+
+// ShutdownHook runs once after the listeners have stopped
+type ShutdownHook interface {
+	OnShutdown(ctx context.Context) error
+}
+
+// ConfigReloadHook runs after a new route table has been applied (admin API,
+// config bundle or control plane)
+type ConfigReloadHook interface {
+	OnConfigReload(routes []router.Route) error
+}
+
+// RouteMatchHook runs for every routed request before authentication. A
+// non-nil error rejects the request (see Rejection).
+type RouteMatchHook interface {
+	OnRouteMatch(r *http.Request, route *router.Route) error
+}
+
+// BackendErrorHook observes failed backend calls
+type BackendErrorHook interface {
+	OnBackendError(ctx context.Context, route *router.Route, err error)
+}
+
+// DegradationHook observes load shedding level changes: level is the number
+// of priority classes shed, 0 once the gateway has recovered
+type DegradationHook interface {
+	OnDegradation(level int)
+}
+
+// Rejection is returned by a RouteMatchHook to answer with a specific status
+// and error code; any other error rejects the request with 403 REQUEST_REJECTED
+type Rejection struct {
+	Status  int
+	Code    string
+	Message string
+}
+
+// Error implements error
+func (r *Rejection) Error() string {
+	return fmt.Sprintf("%s: %s", r.Code, r.Message)
+}
+
+// RejectionFor returns the rejection to send for a RouteMatchHook error
+func RejectionFor(err error) *Rejection {
+	var rejection *Rejection
+	if errors.As(err, &rejection) {
+		return rejection
+	}
+	return &Rejection{Status: http.StatusForbidden, Code: "REQUEST_REJECTED", Message: err.Error()}
+}
+
+// Registry holds the registered extensions and dispatches hooks to them in
+// registration order (shutdown runs in reverse order)
+type Registry struct {
+	mu         sync.RWMutex
+	extensions []Extension
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// defaultRegistry collects extensions registered from init functions
+var defaultRegistry = NewRegistry()
+
+// Register adds an extension to the default registry
+func Register(ext Extension) {
+	defaultRegistry.Register(ext)
+}
+
+// Default returns the registry extensions register themselves with
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register adds an extension
+func (r *Registry) Register(ext Extension) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.extensions = append(r.extensions, ext)
+}
+
+// Names returns the names of the registered extensions
+func (r *Registry) Names() []string {
+	extensions := r.snapshot()
+	names := make([]string, len(extensions))
+	for i, ext := range extensions {
+		names[i] = ext.Name()
+	}
+	return names
+}
+
+// snapshot copies the extension list so hooks run without holding the lock
+func (r *Registry) snapshot() []Extension {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]Extension(nil), r.extensions...)
+}
+
+// Startup runs every StartupHook, stopping at the first failure
+func (r *Registry) Startup(ctx context.Context) error {
+	for _, ext := range r.snapshot() {
+		if hook, ok := ext.(StartupHook); ok {
+			if err := hook.OnStartup(ctx); err != nil {
+				return fmt.Errorf("extension %s failed to start: %w", ext.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// Shutdown runs every ShutdownHook in reverse registration order; failures are logged
+func (r *Registry) Shutdown(ctx context.Context) {
+	extensions := r.snapshot()
+	for i := len(extensions) - 1; i >= 0; i-- {
+		if hook, ok := extensions[i].(ShutdownHook); ok {
+			if err := hook.OnShutdown(ctx); err != nil {
+				slog.WarnContext(ctx, "extension failed to shut down", "extension", extensions[i].Name(), "error", err)
+			}
+		}
+	}
+}
+
+// ConfigReloaded runs every ConfigReloadHook; failures are logged since the
+// route table has already been applied
+func (r *Registry) ConfigReloaded(routes []router.Route) {
+	for _, ext := range r.snapshot() {
+		if hook, ok := ext.(ConfigReloadHook); ok {
+			if err := hook.OnConfigReload(routes); err != nil {
+				slog.Warn("extension failed to handle config reload", "extension", ext.Name(), "error", err)
+			}
+		}
+	}
+}
+
+// RouteMatched runs every RouteMatchHook and returns the first rejection
+func (r *Registry) RouteMatched(req *http.Request, route *router.Route) error {
+	for _, ext := range r.snapshot() {
+		if hook, ok := ext.(RouteMatchHook); ok {
+			if err := hook.OnRouteMatch(req, route); err != nil {
+				return fmt.Errorf("extension %s: %w", ext.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// BackendError runs every BackendErrorHook
+func (r *Registry) BackendError(ctx context.Context, route *router.Route, err error) {
+	for _, ext := range r.snapshot() {
+		if hook, ok := ext.(BackendErrorHook); ok {
+			hook.OnBackendError(ctx, route, err)
+		}
+	}
+}
+
+// Degraded runs every DegradationHook
+func (r *Registry) Degraded(level int) {
+	for _, ext := range r.snapshot() {
+		if hook, ok := ext.(DegradationHook); ok {
+			hook.OnDegradation(level)
+		}
+	}
+}
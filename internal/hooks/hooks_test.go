@@ -0,0 +1,86 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hub-api-gateway/internal/router"
+)
+
+// recordingExtension implements every hook and records the calls it receives
+type recordingExtension struct {
+	name   string
+	calls  *[]string
+	reject error
+}
+
+func (e *recordingExtension) Name() string { return e.name }
+
+func (e *recordingExtension) OnStartup(context.Context) error {
+	*e.calls = append(*e.calls, e.name+":startup")
+	return nil
+}
+
+func (e *recordingExtension) OnShutdown(context.Context) error {
+	*e.calls = append(*e.calls, e.name+":shutdown")
+	return nil
+}
+
+func (e *recordingExtension) OnRouteMatch(*http.Request, *router.Route) error {
+	*e.calls = append(*e.calls, e.name+":match")
+	return e.reject
+}
+
+func (e *recordingExtension) OnBackendError(context.Context, *router.Route, error) {
+	*e.calls = append(*e.calls, e.name+":backend")
+}
+
+// nameOnly implements no hooks
+type nameOnly struct{}
+
+func (nameOnly) Name() string { return "name-only" }
+
+func TestRegistry(t *testing.T) {
+	var calls []string
+	registry := NewRegistry()
+	registry.Register(&recordingExtension{name: "audit", calls: &calls})
+	registry.Register(nameOnly{})
+	registry.Register(&recordingExtension{name: "compliance", calls: &calls, reject: &Rejection{
+		Status: http.StatusUnavailableForLegalReasons, Code: "REGION_BLOCKED", Message: "Not available in your region",
+	}})
+
+	if err := registry.Startup(context.Background()); err != nil {
+		t.Fatalf("unexpected startup error: %v", err)
+	}
+
+	route := &router.Route{Name: "get-balance"}
+	err := registry.RouteMatched(httptest.NewRequest("GET", "/api/v1/balance", nil), route)
+	if rejection := RejectionFor(err); rejection.Status != http.StatusUnavailableForLegalReasons || rejection.Code != "REGION_BLOCKED" {
+		t.Errorf("expected compliance rejection but got %v", err)
+	}
+
+	registry.BackendError(context.Background(), route, errors.New("unavailable"))
+	registry.Shutdown(context.Background())
+
+	expected := []string{
+		"audit:startup", "compliance:startup",
+		"audit:match", "compliance:match",
+		"audit:backend", "compliance:backend",
+		"compliance:shutdown", "audit:shutdown",
+	}
+	if len(calls) != len(expected) {
+		t.Fatalf("expected calls %v but got %v", expected, calls)
+	}
+	for i := range expected {
+		if calls[i] != expected[i] {
+			t.Errorf("call %d: expected %s but got %s", i, expected[i], calls[i])
+		}
+	}
+
+	if rejection := RejectionFor(errors.New("blocked")); rejection.Status != http.StatusForbidden || rejection.Code != "REQUEST_REJECTED" {
+		t.Errorf("expected plain errors to map to 403 but got %+v", rejection)
+	}
+}
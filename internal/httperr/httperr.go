@@ -0,0 +1,104 @@
+// Package httperr writes gateway-generated errors. Every handler and
+// middleware sends its errors through Write, so clients get one envelope
+// whatever rejected the request:
+//
+//	{"error": {"code": "...", "message": "...", "requestId": "...", "timestamp": "...", "details": [...]}}
+//
+// Messages are translated by Accept-Language (see package i18n), and codes
+// with a configured error template or rendered as problem+json take those
+// shapes instead.
+package httperr
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hub-api-gateway/internal/errtemplate"
+	"hub-api-gateway/internal/i18n"
+	"hub-api-gateway/internal/logging"
+	"hub-api-gateway/internal/problem"
+)
+
+// Error is a gateway-generated error
+type Error struct {
+	Status     int
+	Code       string
+	Message    string
+	Service    string        // Backend service, when the error concerns one
+	RetryAfter time.Duration // Sent as Retry-After and a RetryInfo detail when positive
+	Details    []map[string]interface{}
+}
+
+// Envelope is the body of every gateway error response
+type Envelope struct {
+	Error Body `json:"error"`
+}
+
+// Body describes the error inside the envelope
+type Body struct {
+	Code      string                   `json:"code"`
+	Message   string                   `json:"message"`
+	RequestID string                   `json:"requestId,omitempty"`
+	Timestamp string                   `json:"timestamp"`
+	Details   []map[string]interface{} `json:"details,omitempty"`
+}
+
+// Send writes an error without details
+func Send(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	Write(w, r, Error{Status: status, Code: code, Message: message})
+}
+
+// Write sends an error: its template when one is configured for its code,
+// else a problem document when enabled for its status, else the envelope
+func Write(w http.ResponseWriter, r *http.Request, e Error) {
+	message := i18n.Localize(w, r, e.Code, e.Message)
+	retryAfter := int(e.RetryAfter / time.Second)
+	if retryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	}
+
+	if errtemplate.Write(w, r, errtemplate.Vars{Status: e.Status, Code: e.Code, Message: message,
+		Service: e.Service, RetryAfter: retryAfter}) {
+		return
+	}
+
+	if problem.Applies(e.Status) {
+		details := problem.New(e.Status, e.Code, message)
+		details.RetryAfter = retryAfter
+		details.Details = e.Details
+		problem.Write(w, details)
+		return
+	}
+
+	details := e.Details
+	if retryAfter > 0 {
+		details = append(details[:len(details):len(details)], map[string]interface{}{
+			"type":                "RetryInfo",
+			"retry_after_seconds": retryAfter,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.Status)
+	err := json.NewEncoder(w).Encode(Envelope{Error: Body{
+		Code:      e.Code,
+		Message:   message,
+		RequestID: requestID(r),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Details:   details,
+	}})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to encode error response", "code", e.Code, "error", err)
+	}
+}
+
+// requestID returns the ID of a request, from its context or its header
+func requestID(r *http.Request) string {
+	if id := logging.RequestID(r.Context()); id != "" {
+		return id
+	}
+	return r.Header.Get(logging.RequestIDHeader)
+}
@@ -0,0 +1,112 @@
+package httperr_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/auth"
+	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/httperr"
+	"hub-api-gateway/internal/middleware"
+)
+
+// TestEnvelopeSchema checks that every error sender answers with the same envelope
+func TestEnvelopeSchema(t *testing.T) {
+	authMiddleware := middleware.NewAuthMiddleware(nil, nil, &config.Config{}, nil)
+	senders := []struct {
+		name     string
+		handler  http.Handler
+		method   string
+		wantCode string
+		wantKeys []string
+	}{
+		{
+			name: "write",
+			handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				httperr.Write(w, r, httperr.Error{
+					Status:     http.StatusServiceUnavailable,
+					Code:       "SERVICE_MAINTENANCE",
+					Message:    "Service is down for maintenance",
+					RetryAfter: 30 * time.Second,
+					Details:    []map[string]interface{}{{"type": "ErrorInfo", "reason": "MAINTENANCE"}},
+				})
+			}),
+			method:   http.MethodGet,
+			wantCode: "SERVICE_MAINTENANCE",
+			wantKeys: []string{"code", "details", "message", "requestId", "timestamp"},
+		},
+		{
+			name:     "login handler",
+			handler:  http.HandlerFunc(auth.NewLoginHandler(nil, nil).Handle),
+			method:   http.MethodGet,
+			wantCode: "METHOD_NOT_ALLOWED",
+			wantKeys: []string{"code", "message", "requestId", "timestamp"},
+		},
+		{
+			name:     "auth middleware",
+			handler:  authMiddleware.Middleware(http.NotFoundHandler()),
+			method:   http.MethodGet,
+			wantCode: "AUTH_TOKEN_MISSING",
+			wantKeys: []string{"code", "message", "requestId", "timestamp"},
+		},
+	}
+
+	for _, tt := range senders {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/api/v1/orders", nil)
+			r.Header.Set("X-Request-ID", "req-1")
+			w := httptest.NewRecorder()
+			tt.handler.ServeHTTP(w, r)
+
+			if got := w.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q, want application/json", got)
+			}
+			var envelope map[string]map[string]interface{}
+			if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil || len(envelope) != 1 {
+				t.Fatalf("body is not an error envelope: %s", w.Body)
+			}
+			body := envelope["error"]
+
+			var keys []string
+			for key := range body {
+				keys = append(keys, key)
+			}
+			slices.Sort(keys)
+			if !slices.Equal(keys, tt.wantKeys) {
+				t.Errorf("envelope fields = %v, want %v", keys, tt.wantKeys)
+			}
+			if body["code"] != tt.wantCode || body["message"] == "" || body["requestId"] != "req-1" {
+				t.Errorf("unexpected envelope: %v", body)
+			}
+			if _, err := time.Parse(time.RFC3339, body["timestamp"].(string)); err != nil {
+				t.Errorf("timestamp %v is not RFC 3339", body["timestamp"])
+			}
+		})
+	}
+}
+
+func TestWrite_RetryAfter(t *testing.T) {
+	w := httptest.NewRecorder()
+	httperr.Write(w, httptest.NewRequest(http.MethodGet, "/", nil), httperr.Error{
+		Status:     http.StatusTooManyRequests,
+		Code:       "RATE_LIMIT_EXCEEDED",
+		Message:    "Too many requests",
+		RetryAfter: 2 * time.Second,
+	})
+
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "2" {
+		t.Fatalf("got %d with Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	var envelope httperr.Envelope
+	if err := json.Unmarshal(w.Body.Bytes(), &envelope); err != nil {
+		t.Fatalf("invalid JSON body: %v", err)
+	}
+	details := envelope.Error.Details
+	if len(details) != 1 || details[0]["type"] != "RetryInfo" || details[0]["retry_after_seconds"] != float64(2) {
+		t.Errorf("unexpected details: %v", details)
+	}
+}
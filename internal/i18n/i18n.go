@@ -0,0 +1,215 @@
+// Package i18n translates the messages of gateway-generated errors into the
+// language a client asks for with Accept-Language. Only the human-readable
+// message changes: error codes stay the same in every language, so clients
+// keep branching on them.
+//
+// Catalogs are YAML files named after their locale (pt-BR.yaml, es.yaml, ...)
+// mapping error codes to messages. Codes a catalog doesn't list keep the
+// gateway's own (English) message.
+package i18n
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// wildcard is the Accept-Language range matching any language
+const wildcard = "*"
+
+// Catalogs are the loaded message catalogs, by lower-cased locale
+type Catalogs struct {
+	defaultLocale string
+	messages      map[string]map[string]string
+	names         map[string]string // Locale as its catalog file is named
+	locales       []string          // Sorted, for deterministic base language matches
+}
+
+// file is the catalog file layout
+type file struct {
+	Messages map[string]string `yaml:"messages"`
+}
+
+// Load reads every <locale>.yaml catalog of a directory. defaultLocale is the
+// language of the gateway's own messages; clients preferring it get them
+// untranslated.
+func Load(dir, defaultLocale string) (*Catalogs, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message catalogs: %w", err)
+	}
+
+	catalogs := map[string][]byte{}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read message catalog: %w", err)
+		}
+		catalogs[strings.TrimSuffix(entry.Name(), ext)] = data
+	}
+	return Parse(catalogs, defaultLocale)
+}
+
+// Parse parses catalogs given as locale -> YAML document
+func Parse(catalogs map[string][]byte, defaultLocale string) (*Catalogs, error) {
+	c := &Catalogs{
+		defaultLocale: strings.ToLower(defaultLocale),
+		messages:      make(map[string]map[string]string, len(catalogs)),
+		names:         make(map[string]string, len(catalogs)),
+	}
+	for locale, data := range catalogs {
+		var f file
+		if err := yaml.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("invalid message catalog %s: %w", locale, err)
+		}
+		for code, message := range f.Messages {
+			if strings.TrimSpace(message) == "" {
+				return nil, fmt.Errorf("message catalog %s: empty message for %s", locale, code)
+			}
+		}
+		key := strings.ToLower(locale)
+		c.messages[key] = f.Messages
+		c.names[key] = locale
+		c.locales = append(c.locales, key)
+	}
+	slices.Sort(c.locales)
+	return c, nil
+}
+
+// Locales returns the locales that have a catalog
+func (c *Catalogs) Locales() []string {
+	return c.locales
+}
+
+// Negotiate returns the catalog locale that best matches an Accept-Language
+// header, false when the client prefers the default locale or none of its
+// languages has a catalog. A language matches its exact locale first, then
+// its base language (pt-BR falls back to pt, and pt to pt-BR).
+func (c *Catalogs) Negotiate(acceptLanguage string) (string, bool) {
+	for _, tag := range preferences(acceptLanguage) {
+		if tag == wildcard {
+			return "", false
+		}
+		if locale, ok := c.match(tag); ok {
+			return locale, locale != c.defaultLocale
+		}
+	}
+	return "", false
+}
+
+// match finds the locale serving a language tag, the default locale included
+func (c *Catalogs) match(tag string) (string, bool) {
+	base, _, _ := strings.Cut(tag, "-")
+	if _, ok := c.messages[tag]; ok || tag == c.defaultLocale {
+		return tag, true
+	}
+	if _, ok := c.messages[base]; ok || base == c.defaultLocale {
+		return base, true
+	}
+	defaultBase, _, _ := strings.Cut(c.defaultLocale, "-")
+	if base == defaultBase {
+		return c.defaultLocale, true
+	}
+	for _, locale := range c.locales {
+		if localeBase, _, _ := strings.Cut(locale, "-"); localeBase == base {
+			return locale, true
+		}
+	}
+	return "", false
+}
+
+// Message returns the translation of an error code in a locale, if any
+func (c *Catalogs) Message(locale, code string) (string, bool) {
+	message, ok := c.messages[locale][code]
+	return message, ok
+}
+
+// preferences returns the lower-cased language tags of an Accept-Language
+// header by decreasing quality, dropping those with q=0
+func preferences(header string) []string {
+	type preference struct {
+		tag     string
+		quality float64
+	}
+	var prefs []preference
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" {
+			continue
+		}
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			quality = q
+		}
+		if quality > 0 {
+			prefs = append(prefs, preference{tag: tag, quality: quality})
+		}
+	}
+	slices.SortStableFunc(prefs, func(a, b preference) int {
+		switch {
+		case a.quality > b.quality:
+			return -1
+		case a.quality < b.quality:
+			return 1
+		}
+		return 0
+	})
+
+	tags := make([]string, len(prefs))
+	for i, pref := range prefs {
+		tags[i] = pref.tag
+	}
+	return tags
+}
+
+var (
+	mu      sync.RWMutex
+	current *Catalogs
+)
+
+// Configure installs the catalogs used by Localize (nil disables translation)
+func Configure(catalogs *Catalogs) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = catalogs
+}
+
+// Localize returns the message of an error in the request's preferred
+// language, or message itself when there's no translation. Translated
+// responses carry Content-Language; all of them vary on Accept-Language.
+func Localize(w http.ResponseWriter, r *http.Request, code, message string) string {
+	mu.RLock()
+	catalogs := current
+	mu.RUnlock()
+	if catalogs == nil {
+		return message
+	}
+
+	w.Header().Add("Vary", "Accept-Language")
+	locale, ok := catalogs.Negotiate(r.Header.Get("Accept-Language"))
+	if !ok {
+		return message
+	}
+	translated, ok := catalogs.Message(locale, code)
+	if !ok {
+		return message
+	}
+	w.Header().Set("Content-Language", catalogs.names[locale])
+	return translated
+}
@@ -0,0 +1,64 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLocalize(t *testing.T) {
+	catalogs, err := Parse(map[string][]byte{
+		"pt-BR": []byte("messages:\n  AUTH_FAILED: \"E-mail ou senha inválidos\"\n"),
+		"es":    []byte("messages:\n  AUTH_FAILED: \"Correo o contraseña no válidos\"\n"),
+	}, "en")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	Configure(catalogs)
+	t.Cleanup(func() { Configure(nil) })
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		code           string
+		want           string
+		wantLanguage   string
+	}{
+		{name: "exact locale", acceptLanguage: "pt-BR", code: "AUTH_FAILED", want: "E-mail ou senha inválidos", wantLanguage: "pt-BR"},
+		{name: "base language", acceptLanguage: "pt", code: "AUTH_FAILED", want: "E-mail ou senha inválidos", wantLanguage: "pt-BR"},
+		{name: "region falls back to base", acceptLanguage: "es-MX", code: "AUTH_FAILED", want: "Correo o contraseña no válidos", wantLanguage: "es"},
+		{name: "quality order", acceptLanguage: "fr, es;q=0.5, pt-BR;q=0.8", code: "AUTH_FAILED", want: "E-mail ou senha inválidos", wantLanguage: "pt-BR"},
+		{name: "default locale preferred", acceptLanguage: "en-US, pt-BR;q=0.9", code: "AUTH_FAILED", want: "Invalid credentials"},
+		{name: "untranslated code", acceptLanguage: "pt-BR", code: "RATE_LIMIT_EXCEEDED", want: "Invalid credentials"},
+		{name: "no header", code: "AUTH_FAILED", want: "Invalid credentials"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/login", nil)
+			if tt.acceptLanguage != "" {
+				r.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			w := httptest.NewRecorder()
+
+			if got := Localize(w, r, tt.code, "Invalid credentials"); got != tt.want {
+				t.Errorf("Localize() = %q, want %q", got, tt.want)
+			}
+			if got := w.Header().Get("Content-Language"); got != tt.wantLanguage {
+				t.Errorf("Content-Language = %q, want %q", got, tt.wantLanguage)
+			}
+			if got := w.Header().Get("Vary"); got != "Accept-Language" {
+				t.Errorf("Vary = %q, want Accept-Language", got)
+			}
+		})
+	}
+}
+
+func TestLoad_SampleCatalogs(t *testing.T) {
+	catalogs, err := Load("../../config/locales", "en")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, ok := catalogs.Message("pt-br", "AUTH_FAILED"); !ok {
+		t.Error("pt-BR catalog has no AUTH_FAILED message")
+	}
+}
@@ -0,0 +1,183 @@
+package loadshed
+
+import (
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"hub-api-gateway/internal/proxy"
+	"hub-api-gateway/internal/respwriter"
+	"hub-api-gateway/internal/router"
+)
+
+// AdaptiveOptions tune the adaptive concurrency limits
+type AdaptiveOptions struct {
+	InitialLimit int
+	MinLimit     int
+	MaxLimit     int
+	Tolerance    float64 // Latency above Tolerance x the service's baseline counts as degraded
+	Backoff      float64 // Factor applied to the limit when the service degrades
+}
+
+// adaptiveShare is the share of a service's limit each priority class may
+// fill, so low priority traffic is shed first as the limit shrinks.
+// Critical requests are always admitted.
+var adaptiveShare = map[proxy.Priority]float64{
+	proxy.PriorityLow:    0.5,
+	proxy.PriorityNormal: 0.75,
+	proxy.PriorityHigh:   0.9,
+}
+
+// serviceLimit is the learned concurrency limit of one backend service
+type serviceLimit struct {
+	limit        float64
+	inFlight     int
+	baseline     time.Duration // Smoothed latency the service answers in when healthy
+	lastDecrease time.Time
+}
+
+// AdaptiveLimiter learns how many concurrent requests each backend service
+// can take from its latency (AIMD): the limit grows by one per limit's worth
+// of healthy responses while it is in use, and shrinks by the backoff factor
+// when latency rises past the tolerance or the service answers 503/504.
+// Requests over their priority class's share of the limit are shed.
+type AdaptiveLimiter struct {
+	opts     AdaptiveOptions
+	onChange func(service string, limit int)
+	onShed   func(service string, priority proxy.Priority)
+
+	mu       sync.Mutex
+	services map[string]*serviceLimit
+}
+
+// NewAdaptiveLimiter creates an adaptive limiter
+func NewAdaptiveLimiter(opts AdaptiveOptions) *AdaptiveLimiter {
+	if opts.MinLimit < 1 {
+		opts.MinLimit = 1
+	}
+	if opts.MaxLimit < opts.MinLimit {
+		opts.MaxLimit = opts.MinLimit
+	}
+	opts.InitialLimit = min(max(opts.InitialLimit, opts.MinLimit), opts.MaxLimit)
+	if opts.Tolerance <= 1 {
+		opts.Tolerance = 2
+	}
+	if opts.Backoff <= 0 || opts.Backoff >= 1 {
+		opts.Backoff = 0.9
+	}
+	return &AdaptiveLimiter{opts: opts, services: make(map[string]*serviceLimit)}
+}
+
+// OnChange registers a callback invoked when a service's limit changes
+func (a *AdaptiveLimiter) OnChange(fn func(service string, limit int)) {
+	a.onChange = fn
+}
+
+// OnShed registers a callback invoked for every rejected request
+func (a *AdaptiveLimiter) OnShed(fn func(service string, priority proxy.Priority)) {
+	a.onShed = fn
+}
+
+// Limit returns the current concurrency limit of a service
+func (a *AdaptiveLimiter) Limit(service string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return int(a.service(service).limit)
+}
+
+// service returns the state of a service, creating it at the initial limit
+func (a *AdaptiveLimiter) service(name string) *serviceLimit {
+	s, ok := a.services[name]
+	if !ok {
+		s = &serviceLimit{limit: float64(a.opts.InitialLimit)}
+		a.services[name] = s
+	}
+	return s
+}
+
+// acquire admits a request when its priority class has room under the limit
+func (a *AdaptiveLimiter) acquire(service string, priority proxy.Priority) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	s := a.service(service)
+	if share, ok := adaptiveShare[priority]; ok && float64(s.inFlight) >= max(1, s.limit*share) {
+		return false
+	}
+	s.inFlight++
+	return true
+}
+
+// release records the outcome of an admitted request and adjusts the limit
+func (a *AdaptiveLimiter) release(service string, latency time.Duration, overloaded bool) {
+	a.mu.Lock()
+	s := a.service(service)
+	s.inFlight--
+	before := int(s.limit)
+
+	degraded := overloaded || (s.baseline > 0 && float64(latency) > a.opts.Tolerance*float64(s.baseline))
+	switch {
+	case degraded:
+		// Back off at most once per baseline latency, as the requests already
+		// in flight report the same degradation
+		if time.Since(s.lastDecrease) > s.baseline {
+			s.limit = max(float64(a.opts.MinLimit), s.limit*a.opts.Backoff)
+			s.lastDecrease = time.Now()
+		}
+	case float64(s.inFlight+1) >= s.limit/2:
+		// Only grow a limit that is in use
+		s.limit = min(float64(a.opts.MaxLimit), s.limit+1/s.limit)
+	}
+
+	// The baseline follows healthy latency, and slowly follows a service
+	// that has become slower for good
+	switch {
+	case overloaded:
+	case s.baseline == 0:
+		s.baseline = latency
+	case degraded:
+		s.baseline += (latency - s.baseline) / 100
+	default:
+		s.baseline += (latency - s.baseline) / 20
+	}
+
+	after := int(s.limit)
+	a.mu.Unlock()
+
+	if after != before && a.onChange != nil {
+		a.onChange(service, after)
+	}
+}
+
+// Middleware sheds requests to the route's service beyond its priority
+// class's share of the service's limit. Routes calling several services
+// (aggregates, weighted upstreams) aren't limited, nor are streams, whose
+// connection lifetime says nothing about the service's latency.
+func (a *AdaptiveLimiter) Middleware(route *router.Route, next http.Handler) http.Handler {
+	service := route.GetTargetService()
+	if service == "" || len(route.Targets()) > 1 || route.Stream != "" {
+		return next
+	}
+	priority := proxy.RoutePriority(route)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !a.acquire(service, priority) {
+			slog.WarnContext(r.Context(), "request shed by adaptive limit", "route", route.Name, "service", service,
+				"priority", priority.String(), "limit", a.Limit(service))
+			if a.onShed != nil {
+				a.onShed(service, priority)
+			}
+			sendError(w, r, http.StatusServiceUnavailable, "SERVICE_OVERLOADED", "The service is overloaded, please retry shortly")
+			return
+		}
+
+		start := time.Now()
+		recorder := respwriter.New(w)
+		defer func() {
+			overloaded := recorder.Status() == http.StatusServiceUnavailable || recorder.Status() == http.StatusGatewayTimeout
+			a.release(service, time.Since(start), overloaded)
+		}()
+		next.ServeHTTP(recorder, r)
+	})
+}
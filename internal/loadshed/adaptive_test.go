@@ -0,0 +1,87 @@
+package loadshed
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/proxy"
+	"hub-api-gateway/internal/router"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestAdaptiveLimiter(t *testing.T) {
+	limiter := NewAdaptiveLimiter(AdaptiveOptions{InitialLimit: 10, MinLimit: 2, MaxLimit: 20, Tolerance: 2})
+
+	// A slow service loses concurrency
+	limiter.acquire("order-service", proxy.PriorityNormal)
+	limiter.release("order-service", 10*time.Millisecond, false)
+	for range 20 {
+		limiter.acquire("order-service", proxy.PriorityCritical)
+		limiter.services["order-service"].lastDecrease = time.Time{}
+		limiter.release("order-service", time.Second, false)
+	}
+	if limit := limiter.Limit("order-service"); limit >= 10 || limit < 2 {
+		t.Fatalf("expected the limit to shrink but got %d", limit)
+	}
+
+	// Low priority is shed before critical
+	limiter.services["order-service"].limit = 4
+	block := make(chan struct{})
+	handler := func(tier string) http.Handler {
+		route := &router.Route{Name: tier, Service: "order-service", Tags: map[string]string{"tier": tier}}
+		return limiter.Middleware(route, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { <-block }))
+	}
+	go handler("critical").ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	go handler("critical").ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	for inFlight(limiter, "order-service") < 2 {
+		time.Sleep(time.Millisecond)
+	}
+
+	w := httptest.NewRecorder()
+	handler("low").ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected low priority to be shed but got %d", w.Code)
+	}
+	if !limiter.acquire("order-service", proxy.PriorityCritical) {
+		t.Error("expected critical priority to be admitted")
+	}
+	close(block)
+}
+
+func TestAdaptiveLimiter_WebSocket(t *testing.T) {
+	limiter := NewAdaptiveLimiter(AdaptiveOptions{InitialLimit: 1, MinLimit: 1, MaxLimit: 1, Tolerance: 2})
+	route := &router.Route{Name: "events", Service: "event-service", Stream: router.StreamWebSocket}
+	var upgrader websocket.Upgrader
+	server := httptest.NewServer(limiter.Middleware(route, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.ReadMessage()
+	})))
+	defer server.Close()
+
+	// Open streams neither fail to upgrade nor hold a slot
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	for range 2 {
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatalf("expected the upgrade to succeed: %v", err)
+		}
+		defer conn.Close()
+	}
+	if n := inFlight(limiter, "event-service"); n != 0 {
+		t.Errorf("expected no slots held by streams but got %d", n)
+	}
+}
+
+func inFlight(a *AdaptiveLimiter, service string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.service(service).inFlight
+}
@@ -0,0 +1,204 @@
+// Package loadshed protects the gateway itself under memory and scheduling
+// pressure. It samples Go runtime signals (live heap, goroutines, GC pauses)
+// and, as they cross their thresholds, sheds progressively higher priority
+// classes of traffic so the gateway degrades instead of being OOM-killed.
+package loadshed
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"hub-api-gateway/internal/httperr"
+	"hub-api-gateway/internal/proxy"
+	"hub-api-gateway/internal/router"
+)
+
+// retryAfter is suggested to shed clients; pressure is re-evaluated every interval
+const retryAfter = 5 * time.Second
+
+// Thresholds at which the gateway is considered under pressure; zero disables a signal
+type Thresholds struct {
+	HeapBytes  uint64        // Live heap
+	Goroutines int           // Running goroutines
+	GCPause    time.Duration // Longest GC pause since the previous sample
+}
+
+// Signals is one sample of the runtime
+type Signals struct {
+	HeapBytes  uint64
+	Goroutines int
+	GCPause    time.Duration
+}
+
+// Shedder tracks runtime pressure and rejects traffic below the shed level:
+// level 1 sheds low priority requests, 2 also normal ones and 3 everything
+// but critical routes
+type Shedder struct {
+	thresholds Thresholds
+	interval   time.Duration
+	sample     func() Signals
+	onChange   func(level int, pressure float64, signals Signals)
+	onShed     func(priority proxy.Priority)
+
+	mu    sync.Mutex // Serializes updates
+	level atomic.Int32
+	numGC uint32
+}
+
+// New creates a shedder; call Run to start sampling
+func New(thresholds Thresholds, interval time.Duration) *Shedder {
+	if interval <= 0 {
+		interval = time.Second
+	}
+	s := &Shedder{thresholds: thresholds, interval: interval}
+	s.sample = s.readRuntime
+	return s
+}
+
+// OnChange registers a callback invoked when the shed level changes, with the
+// pressure (highest signal relative to its threshold) that caused it
+func (s *Shedder) OnChange(fn func(level int, pressure float64, signals Signals)) {
+	s.onChange = fn
+}
+
+// OnShed registers a callback invoked for every rejected request
+func (s *Shedder) OnShed(fn func(priority proxy.Priority)) {
+	s.onShed = fn
+}
+
+// Level returns the current shed level (0 when nothing is shed)
+func (s *Shedder) Level() int {
+	return int(s.level.Load())
+}
+
+// Allows reports whether requests of the given priority are served
+func (s *Shedder) Allows(priority proxy.Priority) bool {
+	return int(priority) >= s.Level()
+}
+
+// Run samples the runtime every interval until ctx is done
+func (s *Shedder) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.update(s.sample())
+		}
+	}
+}
+
+// update applies a sample. Pressure raises the level at once; recovery lowers
+// it one step per interval so traffic returns gradually.
+func (s *Shedder) update(signals Signals) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pressure := s.pressure(signals)
+	current := s.Level()
+	target := levelFor(pressure)
+	if target < current {
+		target = current - 1
+	}
+	if target == current {
+		return
+	}
+
+	s.level.Store(int32(target))
+	if s.onChange != nil {
+		s.onChange(target, pressure, signals)
+	}
+}
+
+// pressure returns the highest signal relative to its threshold
+func (s *Shedder) pressure(signals Signals) float64 {
+	var pressure float64
+	if s.thresholds.HeapBytes > 0 {
+		pressure = max(pressure, float64(signals.HeapBytes)/float64(s.thresholds.HeapBytes))
+	}
+	if s.thresholds.Goroutines > 0 {
+		pressure = max(pressure, float64(signals.Goroutines)/float64(s.thresholds.Goroutines))
+	}
+	if s.thresholds.GCPause > 0 {
+		pressure = max(pressure, float64(signals.GCPause)/float64(s.thresholds.GCPause))
+	}
+	return pressure
+}
+
+// levelFor maps pressure to a shed level: each 25% past the thresholds sheds
+// one more priority class, critical traffic is never shed
+func levelFor(pressure float64) int {
+	switch {
+	case pressure < 1:
+		return 0
+	case pressure < 1.25:
+		return int(proxy.PriorityNormal)
+	case pressure < 1.5:
+		return int(proxy.PriorityHigh)
+	default:
+		return int(proxy.PriorityCritical)
+	}
+}
+
+// readRuntime samples the live heap, goroutines and the longest GC pause
+// since the previous sample
+func (s *Shedder) readRuntime() Signals {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+
+	// PauseNs is a circular buffer of the last 256 pauses
+	var longest uint64
+	for gc := max(s.numGC, stats.NumGC-min(stats.NumGC, 256)); gc < stats.NumGC; gc++ {
+		longest = max(longest, stats.PauseNs[gc%256])
+	}
+	s.numGC = stats.NumGC
+
+	return Signals{
+		HeapBytes:  stats.HeapAlloc,
+		Goroutines: runtime.NumGoroutine(),
+		GCPause:    time.Duration(longest),
+	}
+}
+
+// MemoryLimitThreshold returns a heap threshold at 80% of the runtime's soft
+// memory limit (GOMEMLIMIT), or 0 when no limit is set
+func MemoryLimitThreshold() uint64 {
+	limit := debug.SetMemoryLimit(-1)
+	if limit <= 0 || limit == math.MaxInt64 {
+		return 0
+	}
+	return uint64(limit) / 10 * 8
+}
+
+// Middleware rejects requests to the route while its priority class is shed
+func (s *Shedder) Middleware(route *router.Route, next http.Handler) http.Handler {
+	priority := proxy.RoutePriority(route)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.Allows(priority) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		slog.WarnContext(r.Context(), "request shed under load", "route", route.Name, "priority", priority.String(), "level", s.Level())
+		if s.onShed != nil {
+			s.onShed(priority)
+		}
+		sendError(w, r, http.StatusServiceUnavailable, "LOAD_SHED", "The gateway is overloaded, please retry shortly")
+	})
+}
+
+// sendError sends a JSON error response with a Retry-After hint
+func sendError(w http.ResponseWriter, r *http.Request, statusCode int, errorCode, message string) {
+	httperr.Write(w, r, httperr.Error{Status: statusCode, Code: errorCode, Message: message, RetryAfter: retryAfter})
+}
@@ -0,0 +1,72 @@
+package loadshed
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/proxy"
+	"hub-api-gateway/internal/router"
+)
+
+func TestShedderLevels(t *testing.T) {
+	s := New(Thresholds{HeapBytes: 1000, Goroutines: 100, GCPause: 10 * time.Millisecond}, time.Hour)
+	var levels []int
+	s.OnChange(func(level int, pressure float64, signals Signals) { levels = append(levels, level) })
+
+	steps := []struct {
+		signals Signals
+		want    int
+	}{
+		{Signals{HeapBytes: 500, Goroutines: 50}, 0},
+		{Signals{HeapBytes: 1100}, 1},                // Past the heap threshold: shed low
+		{Signals{GCPause: 16 * time.Millisecond}, 3}, // Far past the pause threshold: shed all but critical
+		{Signals{}, 2},                // Recovery steps down one level per sample
+		{Signals{Goroutines: 130}, 2}, // Still 25% past a threshold
+		{Signals{}, 1},
+		{Signals{}, 0},
+		{Signals{HeapBytes: 999, Goroutines: 99}, 0},
+	}
+	for i, step := range steps {
+		s.update(step.signals)
+		if got := s.Level(); got != step.want {
+			t.Errorf("step %d: level = %d, want %d", i, got, step.want)
+		}
+	}
+
+	if want := []int{1, 3, 2, 1, 0}; len(levels) != len(want) {
+		t.Errorf("level changes = %v, want %v", levels, want)
+	}
+}
+
+func TestShedderMiddleware(t *testing.T) {
+	s := New(Thresholds{Goroutines: 100}, time.Hour)
+	s.update(Signals{Goroutines: 130}) // Sheds low and normal
+
+	var shed []proxy.Priority
+	s.OnShed(func(p proxy.Priority) { shed = append(shed, p) })
+
+	for tier, wantStatus := range map[string]int{
+		"low":      http.StatusServiceUnavailable,
+		"":         http.StatusServiceUnavailable,
+		"high":     http.StatusOK,
+		"critical": http.StatusOK,
+	} {
+		route := &router.Route{Name: "route-" + tier, Tags: map[string]string{"tier": tier}}
+		handler := s.Middleware(route, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest("GET", "/api/v1/orders", nil))
+		if rec.Code != wantStatus {
+			t.Errorf("tier %q: status = %d, want %d", tier, rec.Code, wantStatus)
+		}
+		if wantStatus == http.StatusServiceUnavailable && rec.Header().Get("Retry-After") == "" {
+			t.Errorf("tier %q: missing Retry-After", tier)
+		}
+	}
+
+	if len(shed) != 2 {
+		t.Errorf("shed callbacks = %v, want 2", shed)
+	}
+}
@@ -0,0 +1,241 @@
+// Package logging configures structured logging with log/slog and correlates
+// log lines, responses and backend calls through the X-Request-ID header.
+//
+// Request-scoped code logs with the *Context variants so the request ID is
+// attached automatically:
+//
+//	slog.WarnContext(r.Context(), "rate limit exceeded", "identity", identity)
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDHeader carries the request ID on HTTP requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDMetadata carries the request ID on outgoing gRPC calls
+const RequestIDMetadata = "x-request-id"
+
+// maxRequestIDLength bounds client-supplied request IDs
+const maxRequestIDLength = 128
+
+// Setup installs the default slog logger. format is "json" or "text"; level is
+// "debug", "info", "warn" or "error". The standard log package is routed
+// through the same handler.
+func Setup(level, format string) {
+	slog.SetDefault(slog.New(NewHandler(os.Stdout, level, format)))
+}
+
+// NewHandler creates a handler writing to w that adds the request ID of the
+// context to every record
+func NewHandler(w io.Writer, level, format string) slog.Handler {
+	options := &slog.HandlerOptions{Level: ParseLevel(level)}
+
+	var handler slog.Handler
+	if strings.EqualFold(format, "text") {
+		handler = slog.NewTextHandler(w, options)
+	} else {
+		handler = slog.NewJSONHandler(w, options)
+	}
+	return contextHandler{handler}
+}
+
+// ParseLevel converts a LOG_LEVEL value to a slog level (info when unknown)
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// Fatal logs an error and exits, replacing log.Fatalf during startup
+func Fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// contextHandler adds request_id from the context to each record
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle implements slog.Handler
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if requestID := RequestID(ctx); requestID != "" {
+		record.AddAttrs(slog.String("request_id", requestID))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup implements slog.Handler
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// requestIDKey is the context key of the request ID
+type requestIDKey struct{}
+
+// WithRequestID returns a context carrying the request ID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID of the context, if any
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// NewRequestID generates a random (version 4) UUID request ID
+func NewRequestID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// validRequestID accepts propagated IDs that are safe to log and forward
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.ContainsRune("-_.:", c)) {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestIDs assigns every request an ID, honoring the caller's X-Request-ID
+// only when it comes from a trusted peer
+type RequestIDs struct {
+	trustIncoming bool
+	trustedPeers  []*net.IPNet // Empty trusts every peer
+}
+
+// NewRequestIDs creates the request ID policy. With trustIncoming unset every
+// request gets a new ID; otherwise valid incoming IDs are kept when the peer
+// is in trustedPeers (IPs or CIDRs), or from anyone when it's empty.
+func NewRequestIDs(trustIncoming bool, trustedPeers []string) (*RequestIDs, error) {
+	ids := &RequestIDs{trustIncoming: trustIncoming}
+	for _, entry := range trustedPeers {
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request ID trusted peer %q: %w", entry, err)
+		}
+		ids.trustedPeers = append(ids.trustedPeers, network)
+	}
+	return ids, nil
+}
+
+// Middleware propagates the caller's X-Request-ID (or generates one), echoes it
+// on the response and stores it in the request context for logging
+func Middleware(next http.Handler) http.Handler {
+	return (&RequestIDs{trustIncoming: true}).Middleware(next)
+}
+
+// Middleware keeps a trusted incoming X-Request-ID or generates one, echoes it
+// on the response and stores it in the request context for logging
+func (ids *RequestIDs) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) || !ids.trusted(r) {
+			requestID = NewRequestID()
+			r.Header.Set(RequestIDHeader, requestID)
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		next.ServeHTTP(w, r.WithContext(WithRequestID(r.Context(), requestID)))
+	})
+}
+
+// trusted reports whether the request ID sent by the request's peer is kept
+func (ids *RequestIDs) trusted(r *http.Request) bool {
+	if !ids.trustIncoming {
+		return false
+	}
+	if len(ids.trustedPeers) == 0 {
+		return true
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range ids.trustedPeers {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// UnaryClientInterceptor forwards the request ID of the call context to the
+// backend as x-request-id metadata
+func UnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(outgoingRequestID(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientInterceptor is UnaryClientInterceptor for streaming calls
+func StreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(outgoingRequestID(ctx), desc, cc, method, opts...)
+	}
+}
+
+// outgoingRequestID adds the request ID of the context to its outgoing
+// metadata, unless the caller already set one (e.g. passthrough calls)
+func outgoingRequestID(ctx context.Context) context.Context {
+	requestID := RequestID(ctx)
+	if requestID == "" {
+		return ctx
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(RequestIDMetadata)) > 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, RequestIDMetadata, requestID)
+}
+
+// StdLogger returns a standard library logger writing through slog at level,
+// for components that require a *log.Logger (e.g. http.Server.ErrorLog)
+func StdLogger(level slog.Level) *log.Logger {
+	return slog.NewLogLogger(slog.Default().Handler(), level)
+}
@@ -0,0 +1,127 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestHandler_AddsRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(NewHandler(&buf, "info", "json"))
+
+	logger.InfoContext(WithRequestID(context.Background(), "req-123"), "proxying request", "path", "/api/v1/orders")
+	logger.DebugContext(context.Background(), "route matched")
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("expected one JSON record (debug filtered out), got %q: %v", buf.String(), err)
+	}
+	if record["request_id"] != "req-123" || record["msg"] != "proxying request" || record["path"] != "/api/v1/orders" {
+		t.Errorf("unexpected record: %v", record)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	var seen string
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+	}))
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"propagated", "client-req-42", true},
+		{"generated", "", false},
+		{"invalid replaced", "bad id\nwith newline", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/orders", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if seen == "" || rec.Header().Get(RequestIDHeader) != seen {
+				t.Fatalf("context ID %q does not match response header %q", seen, rec.Header().Get(RequestIDHeader))
+			}
+			if (seen == tt.incoming) != tt.keep {
+				t.Errorf("incoming %q, got %q", tt.incoming, seen)
+			}
+		})
+	}
+}
+
+func TestRequestIDs_TrustedPeers(t *testing.T) {
+	ids, err := NewRequestIDs(true, []string{"10.0.0.0/8", "192.168.1.7"})
+	if err != nil {
+		t.Fatalf("NewRequestIDs() error = %v", err)
+	}
+	var seen string
+	handler := ids.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestID(r.Context())
+	}))
+	uuid := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		keep       bool
+	}{
+		{"trusted network", "10.1.2.3:4000", true},
+		{"trusted address", "192.168.1.7:4000", true},
+		{"untrusted peer", "203.0.113.9:4000", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/api/v1/orders", nil)
+			req.RemoteAddr = tt.remoteAddr
+			req.Header.Set(RequestIDHeader, "edge-req-42")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if (seen == "edge-req-42") != tt.keep {
+				t.Errorf("peer %s: got request ID %q", tt.remoteAddr, seen)
+			}
+			if !tt.keep && !uuid.MatchString(seen) {
+				t.Errorf("generated request ID %q is not a UUID", seen)
+			}
+			if rec.Header().Get(RequestIDHeader) != seen || req.Header.Get(RequestIDHeader) != seen {
+				t.Errorf("request ID %q not echoed on the request and response headers", seen)
+			}
+		})
+	}
+
+	if _, err := NewRequestIDs(true, []string{"not-an-ip"}); err == nil {
+		t.Error("expected an invalid trusted peer to be rejected")
+	}
+}
+
+func TestUnaryClientInterceptor(t *testing.T) {
+	var got []string
+	invoker := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		got = md.Get(RequestIDMetadata)
+		return nil
+	}
+
+	ctx := WithRequestID(context.Background(), "req-123")
+	if err := UnaryClientInterceptor()(ctx, "/orders.OrderService/GetOrder", nil, nil, nil, invoker); err != nil {
+		t.Fatalf("interceptor error = %v", err)
+	}
+	if len(got) != 1 || got[0] != "req-123" {
+		t.Errorf("expected x-request-id metadata req-123, got %v", got)
+	}
+}
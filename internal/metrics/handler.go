@@ -6,23 +6,38 @@ import (
 	"net/http"
 	"sort"
 	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"hub-api-gateway/internal/httperr"
 )
 
 // Handler provides HTTP endpoints for metrics
 type Handler struct {
-	metrics *Metrics
+	metrics    *Metrics
+	prometheus http.Handler
 }
 
 // NewHandler creates a new metrics handler
 func NewHandler(metrics *Metrics) *Handler {
 	return &Handler{
-		metrics: metrics,
+		metrics:    metrics,
+		prometheus: promhttp.HandlerFor(metrics.registry, promhttp.HandlerOpts{}),
 	}
 }
 
-// HandleJSON returns metrics in JSON format
+// HandleJSON returns metrics in JSON format; ?window=1m|5m|1h limits request
+// metrics to a rolling window
 func (h *Handler) HandleJSON(w http.ResponseWriter, r *http.Request) {
-	snapshot := h.metrics.GetSnapshot()
+	var snapshot interface{} = h.metrics.GetSnapshot()
+	if window := r.URL.Query().Get("window"); window != "" {
+		windowSnapshot, err := h.metrics.GetWindowSnapshot(window)
+		if err != nil {
+			h.sendError(w, r, http.StatusBadRequest, "INVALID_WINDOW", err.Error())
+			return
+		}
+		snapshot = windowSnapshot
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -32,106 +47,101 @@ func (h *Handler) HandleJSON(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// HandlePrometheus returns metrics in Prometheus format
+// sendError sends a JSON error response
+func (h *Handler) sendError(w http.ResponseWriter, r *http.Request, statusCode int, errorCode, message string) {
+	httperr.Send(w, r, statusCode, errorCode, message)
+}
+
+// HandlePrometheus returns metrics in the Prometheus exposition format
 func (h *Handler) HandlePrometheus(w http.ResponseWriter, r *http.Request) {
+	h.prometheus.ServeHTTP(w, r)
+}
+
+// HandleSummary returns a human-readable summary; ?window=1m|5m|1h summarizes
+// request metrics over a rolling window instead
+func (h *Handler) HandleSummary(w http.ResponseWriter, r *http.Request) {
+	if window := r.URL.Query().Get("window"); window != "" {
+		h.handleWindowSummary(w, r, window)
+		return
+	}
+
 	snapshot := h.metrics.GetSnapshot()
 
-	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(http.StatusOK)
 
 	var sb strings.Builder
 
-	// Gateway info
-	sb.WriteString("# HELP gateway_info Gateway information\n")
-	sb.WriteString("# TYPE gateway_info gauge\n")
-	sb.WriteString("gateway_info{version=\"1.0.0\"} 1\n\n")
-
-	// Uptime
-	sb.WriteString("# HELP gateway_uptime_seconds Gateway uptime in seconds\n")
-	sb.WriteString("# TYPE gateway_uptime_seconds gauge\n")
-	sb.WriteString(fmt.Sprintf("gateway_uptime_seconds %.2f\n\n", snapshot.UptimeSeconds))
-
-	// Total requests
-	sb.WriteString("# HELP gateway_requests_total Total number of requests\n")
-	sb.WriteString("# TYPE gateway_requests_total counter\n")
-	sb.WriteString(fmt.Sprintf("gateway_requests_total %d\n\n", snapshot.TotalRequests))
-
-	// Successful requests
-	sb.WriteString("# HELP gateway_requests_successful_total Total number of successful requests\n")
-	sb.WriteString("# TYPE gateway_requests_successful_total counter\n")
-	sb.WriteString(fmt.Sprintf("gateway_requests_successful_total %d\n\n", snapshot.SuccessfulRequests))
-
-	// Failed requests
-	sb.WriteString("# HELP gateway_requests_failed_total Total number of failed requests\n")
-	sb.WriteString("# TYPE gateway_requests_failed_total counter\n")
-	sb.WriteString(fmt.Sprintf("gateway_requests_failed_total %d\n\n", snapshot.FailedRequests))
-
-	// Success rate
-	sb.WriteString("# HELP gateway_success_rate Success rate percentage\n")
-	sb.WriteString("# TYPE gateway_success_rate gauge\n")
-	sb.WriteString(fmt.Sprintf("gateway_success_rate %.2f\n\n", snapshot.SuccessRate))
-
-	// Average latency
-	sb.WriteString("# HELP gateway_latency_avg_ms Average latency in milliseconds\n")
-	sb.WriteString("# TYPE gateway_latency_avg_ms gauge\n")
-	sb.WriteString(fmt.Sprintf("gateway_latency_avg_ms %.2f\n\n", snapshot.AvgLatencyMs))
-
-	// Requests per second
-	sb.WriteString("# HELP gateway_requests_per_second Requests per second\n")
-	sb.WriteString("# TYPE gateway_requests_per_second gauge\n")
-	sb.WriteString(fmt.Sprintf("gateway_requests_per_second %.2f\n\n", snapshot.RequestsPerSecond))
-
-	// Cache metrics
-	sb.WriteString("# HELP gateway_cache_hits_total Total cache hits\n")
-	sb.WriteString("# TYPE gateway_cache_hits_total counter\n")
-	sb.WriteString(fmt.Sprintf("gateway_cache_hits_total %d\n\n", snapshot.CacheHits))
-
-	sb.WriteString("# HELP gateway_cache_misses_total Total cache misses\n")
-	sb.WriteString("# TYPE gateway_cache_misses_total counter\n")
-	sb.WriteString(fmt.Sprintf("gateway_cache_misses_total %d\n\n", snapshot.CacheMisses))
-
-	sb.WriteString("# HELP gateway_cache_hit_rate Cache hit rate percentage\n")
-	sb.WriteString("# TYPE gateway_cache_hit_rate gauge\n")
-	sb.WriteString(fmt.Sprintf("gateway_cache_hit_rate %.2f\n\n", snapshot.CacheHitRate))
-
-	// Circuit breaker trips
-	sb.WriteString("# HELP gateway_circuit_breaker_trips_total Total circuit breaker trips\n")
-	sb.WriteString("# TYPE gateway_circuit_breaker_trips_total counter\n")
-	sb.WriteString(fmt.Sprintf("gateway_circuit_breaker_trips_total %d\n\n", snapshot.CircuitBreakerTrips))
-
-	// Route metrics
+	sb.WriteString("=== Hub API Gateway Metrics ===\n\n")
+
+	sb.WriteString("Overall Statistics:\n")
+	sb.WriteString(fmt.Sprintf("  Uptime: %.0f seconds (%.1f minutes)\n", snapshot.UptimeSeconds, snapshot.UptimeSeconds/60))
+	sb.WriteString(fmt.Sprintf("  Total Requests: %d\n", snapshot.TotalRequests))
+	sb.WriteString(fmt.Sprintf("  Successful: %d (%.1f%%)\n", snapshot.SuccessfulRequests, snapshot.SuccessRate))
+	sb.WriteString(fmt.Sprintf("  Failed: %d\n", snapshot.FailedRequests))
+	sb.WriteString(fmt.Sprintf("  Avg Latency: %.2f ms\n", snapshot.AvgLatencyMs))
+	sb.WriteString(fmt.Sprintf("  Requests/sec: %.2f\n", snapshot.RequestsPerSecond))
+	sb.WriteString("\n")
+
+	sb.WriteString("Cache Performance:\n")
+	sb.WriteString(fmt.Sprintf("  Cache Hits: %d\n", snapshot.CacheHits))
+	sb.WriteString(fmt.Sprintf("  Cache Misses: %d\n", snapshot.CacheMisses))
+	sb.WriteString(fmt.Sprintf("  Hit Rate: %.1f%%\n", snapshot.CacheHitRate))
+	sb.WriteString(fmt.Sprintf("  Route Match Cache: %d hits, %d misses (%.1f%%)\n",
+		snapshot.RouteCacheHits, snapshot.RouteCacheMisses, snapshot.RouteCacheHitRate))
+	sb.WriteString(fmt.Sprintf("  Response Cache: %d hits, %d misses\n",
+		snapshot.ResponseCacheHits, snapshot.ResponseCacheMisses))
+	sb.WriteString("\n")
+
+	sb.WriteString("Reliability:\n")
+	sb.WriteString(fmt.Sprintf("  Circuit Breaker Trips: %d\n", snapshot.CircuitBreakerTrips))
+	sb.WriteString(fmt.Sprintf("  Stuck Requests Cancelled: %d\n", snapshot.StuckRequests))
+	sb.WriteString(fmt.Sprintf("  Client Aborted Requests: %d\n", snapshot.ClientAborted))
+	sb.WriteString(fmt.Sprintf("  Load Shedding: level %d, %d requests shed\n", snapshot.LoadShedLevel, snapshot.LoadShedRequests))
+	sb.WriteString(fmt.Sprintf("  Bulkhead: %d requests shed\n", snapshot.BulkheadRejected))
+	sb.WriteString(fmt.Sprintf("  Adaptive Limit: %d requests shed\n", snapshot.AdaptiveShed))
+	sb.WriteString(fmt.Sprintf("  Fault Injection: %d faults injected\n", snapshot.FaultsInjected))
+	sb.WriteString(fmt.Sprintf("  Hedged Requests: %d sent, %d answered first\n", snapshot.HedgedRequests, snapshot.HedgeWins))
+	sb.WriteString(fmt.Sprintf("  Mirrored Requests: %d matched, %d diverged, %d failed, %d skipped\n",
+		snapshot.MirrorMatched, snapshot.MirrorDiverged, snapshot.MirrorFailed, snapshot.MirrorSkipped))
+	sb.WriteString(fmt.Sprintf("  Reconnect Tickets: %d issued, %d accepted, %d rejected\n",
+		snapshot.TicketsIssued, snapshot.TicketsAccepted, snapshot.TicketsRejected))
+	if snapshot.ConfigVersion != "" {
+		sb.WriteString(fmt.Sprintf("  Config Version: %s (%d applied, %d rejected)\n",
+			snapshot.ConfigVersion, snapshot.ConfigUpdatesApplied, snapshot.ConfigUpdatesRejected))
+	}
+	sb.WriteString("\n")
+
 	if len(snapshot.Routes) > 0 {
-		sb.WriteString("# HELP gateway_route_requests_total Total requests per route\n")
-		sb.WriteString("# TYPE gateway_route_requests_total counter\n")
+		sb.WriteString("Top Routes by Traffic:\n")
 
-		// Sort routes for consistent output
-		routes := make([]string, 0, len(snapshot.Routes))
-		for route := range snapshot.Routes {
-			routes = append(routes, route)
+		// Sort routes by request count
+		type routeStat struct {
+			name    string
+			metrics RouteSnapshot
 		}
-		sort.Strings(routes)
-
-		for _, route := range routes {
-			rm := snapshot.Routes[route]
-			sb.WriteString(fmt.Sprintf("gateway_route_requests_total{route=\"%s\"} %d\n", route, rm.Requests))
+		routes := make([]routeStat, 0, len(snapshot.Routes))
+		for name, metrics := range snapshot.Routes {
+			routes = append(routes, routeStat{name, metrics})
 		}
-		sb.WriteString("\n")
+		sort.Slice(routes, func(i, j int) bool {
+			return routes[i].metrics.Requests > routes[j].metrics.Requests
+		})
 
-		sb.WriteString("# HELP gateway_route_latency_avg_ms Average latency per route in milliseconds\n")
-		sb.WriteString("# TYPE gateway_route_latency_avg_ms gauge\n")
-		for _, route := range routes {
-			rm := snapshot.Routes[route]
-			sb.WriteString(fmt.Sprintf("gateway_route_latency_avg_ms{route=\"%s\"} %.2f\n", route, rm.AvgLatencyMs))
+		for i, rs := range routes {
+			if i >= 10 {
+				break // Show top 10
+			}
+			sb.WriteString(fmt.Sprintf("  %d. %s - %d requests (%.2f ms avg)\n",
+				i+1, rs.name, rs.metrics.Requests, rs.metrics.AvgLatencyMs))
 		}
 		sb.WriteString("\n")
 	}
 
-	// Service metrics
 	if len(snapshot.Services) > 0 {
-		sb.WriteString("# HELP gateway_service_requests_total Total requests per service\n")
-		sb.WriteString("# TYPE gateway_service_requests_total counter\n")
+		sb.WriteString("Backend Services:\n")
 
-		// Sort services for consistent output
+		// Sort services by name
 		services := make([]string, 0, len(snapshot.Services))
 		for service := range snapshot.Services {
 			services = append(services, service)
@@ -140,42 +150,61 @@ func (h *Handler) HandlePrometheus(w http.ResponseWriter, r *http.Request) {
 
 		for _, service := range services {
 			sm := snapshot.Services[service]
-			sb.WriteString(fmt.Sprintf("gateway_service_requests_total{service=\"%s\"} %d\n", service, sm.Requests))
+			sb.WriteString(fmt.Sprintf("  %s: %d requests, %d failures (%.2f ms avg)\n",
+				service, sm.Requests, sm.Failures, sm.AvgLatencyMs))
 		}
 		sb.WriteString("\n")
+	}
 
-		sb.WriteString("# HELP gateway_service_latency_avg_ms Average latency per service in milliseconds\n")
-		sb.WriteString("# TYPE gateway_service_latency_avg_ms gauge\n")
-		for _, service := range services {
-			sm := snapshot.Services[service]
-			sb.WriteString(fmt.Sprintf("gateway_service_latency_avg_ms{service=\"%s\"} %.2f\n", service, sm.AvgLatencyMs))
+	if len(snapshot.Stages) > 0 {
+		sb.WriteString("Latency by Stage:\n")
+
+		stages := make([]string, 0, len(snapshot.Stages))
+		for stage := range snapshot.Stages {
+			stages = append(stages, stage)
+		}
+		sort.Strings(stages)
+
+		for _, stage := range stages {
+			hs := snapshot.Stages[stage]
+			sb.WriteString(fmt.Sprintf("  %-12s %8d samples, avg %.2f ms\n", stage+":", hs.Count, hs.AvgMs))
 		}
 		sb.WriteString("\n")
+	}
 
-		sb.WriteString("# HELP gateway_service_failures_total Total failures per service\n")
-		sb.WriteString("# TYPE gateway_service_failures_total counter\n")
-		for _, service := range services {
-			sm := snapshot.Services[service]
-			sb.WriteString(fmt.Sprintf("gateway_service_failures_total{service=\"%s\"} %d\n", service, sm.Failures))
+	if len(snapshot.Tags) > 0 {
+		sb.WriteString("Route Tags:\n")
+
+		tags := make([]string, 0, len(snapshot.Tags))
+		for tag := range snapshot.Tags {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+
+		for _, tag := range tags {
+			ts := snapshot.Tags[tag]
+			sb.WriteString(fmt.Sprintf("  %s: %d requests, %d failures (%.1f%% error rate, %.2f ms avg)\n",
+				tag, ts.Requests, ts.Failures, ts.ErrorRate, ts.AvgLatencyMs))
 		}
 	}
 
 	w.Write([]byte(sb.String()))
 }
 
-// HandleSummary returns a human-readable summary
-func (h *Handler) HandleSummary(w http.ResponseWriter, r *http.Request) {
-	snapshot := h.metrics.GetSnapshot()
+// handleWindowSummary returns a human-readable summary of a rolling window
+func (h *Handler) handleWindowSummary(w http.ResponseWriter, r *http.Request, window string) {
+	snapshot, err := h.metrics.GetWindowSnapshot(window)
+	if err != nil {
+		h.sendError(w, r, http.StatusBadRequest, "INVALID_WINDOW", err.Error())
+		return
+	}
 
 	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(http.StatusOK)
 
 	var sb strings.Builder
 
-	sb.WriteString("=== Hub API Gateway Metrics ===\n\n")
-
-	sb.WriteString("Overall Statistics:\n")
-	sb.WriteString(fmt.Sprintf("  Uptime: %.0f seconds (%.1f minutes)\n", snapshot.UptimeSeconds, snapshot.UptimeSeconds/60))
+	sb.WriteString(fmt.Sprintf("=== Hub API Gateway Metrics (last %s) ===\n\n", snapshot.Window))
 	sb.WriteString(fmt.Sprintf("  Total Requests: %d\n", snapshot.TotalRequests))
 	sb.WriteString(fmt.Sprintf("  Successful: %d (%.1f%%)\n", snapshot.SuccessfulRequests, snapshot.SuccessRate))
 	sb.WriteString(fmt.Sprintf("  Failed: %d\n", snapshot.FailedRequests))
@@ -183,38 +212,21 @@ func (h *Handler) HandleSummary(w http.ResponseWriter, r *http.Request) {
 	sb.WriteString(fmt.Sprintf("  Requests/sec: %.2f\n", snapshot.RequestsPerSecond))
 	sb.WriteString("\n")
 
-	sb.WriteString("Cache Performance:\n")
-	sb.WriteString(fmt.Sprintf("  Cache Hits: %d\n", snapshot.CacheHits))
-	sb.WriteString(fmt.Sprintf("  Cache Misses: %d\n", snapshot.CacheMisses))
-	sb.WriteString(fmt.Sprintf("  Hit Rate: %.1f%%\n", snapshot.CacheHitRate))
-	sb.WriteString("\n")
-
-	sb.WriteString("Reliability:\n")
-	sb.WriteString(fmt.Sprintf("  Circuit Breaker Trips: %d\n", snapshot.CircuitBreakerTrips))
-	sb.WriteString("\n")
-
 	if len(snapshot.Routes) > 0 {
-		sb.WriteString("Top Routes by Traffic:\n")
+		sb.WriteString("Routes:\n")
 
-		// Sort routes by request count
-		type routeStat struct {
-			name    string
-			metrics RouteSnapshot
-		}
-		routes := make([]routeStat, 0, len(snapshot.Routes))
-		for name, metrics := range snapshot.Routes {
-			routes = append(routes, routeStat{name, metrics})
+		routes := make([]string, 0, len(snapshot.Routes))
+		for route := range snapshot.Routes {
+			routes = append(routes, route)
 		}
 		sort.Slice(routes, func(i, j int) bool {
-			return routes[i].metrics.Requests > routes[j].metrics.Requests
+			return snapshot.Routes[routes[i]].Requests > snapshot.Routes[routes[j]].Requests
 		})
 
-		for i, rs := range routes {
-			if i >= 10 {
-				break // Show top 10
-			}
-			sb.WriteString(fmt.Sprintf("  %d. %s - %d requests (%.2f ms avg)\n",
-				i+1, rs.name, rs.metrics.Requests, rs.metrics.AvgLatencyMs))
+		for _, route := range routes {
+			rs := snapshot.Routes[route]
+			sb.WriteString(fmt.Sprintf("  %s: %d requests, %d failures (%.2f ms avg)\n",
+				route, rs.Requests, rs.Failures, rs.AvgLatencyMs))
 		}
 		sb.WriteString("\n")
 	}
@@ -222,7 +234,6 @@ func (h *Handler) HandleSummary(w http.ResponseWriter, r *http.Request) {
 	if len(snapshot.Services) > 0 {
 		sb.WriteString("Backend Services:\n")
 
-		// Sort services by name
 		services := make([]string, 0, len(snapshot.Services))
 		for service := range snapshot.Services {
 			services = append(services, service)
@@ -4,6 +4,9 @@ import (
 	"sync"
 	"sync/atomic"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 )
 
 // Metrics collects gateway performance metrics
@@ -19,9 +22,18 @@ type Metrics struct {
 	// Response time tracking
 	totalLatency atomic.Uint64 // in milliseconds
 
+	// Request counts for windowed queries
+	window *rollingWindow
+
 	// Service-specific metrics
 	serviceMetrics sync.Map // map[string]*ServiceMetrics
 
+	// Per-stage latency histograms
+	stageLatency sync.Map // map[string]*Histogram
+
+	// Route tags used for tag-level aggregation
+	routeTags sync.Map // map[string]map[string]string
+
 	// Circuit breaker metrics
 	circuitBreakerTrips atomic.Uint64
 
@@ -29,6 +41,90 @@ type Metrics struct {
 	cacheHits   atomic.Uint64
 	cacheMisses atomic.Uint64
 
+	// Connections rejected by the connection limiter
+	connectionsRejected atomic.Uint64
+
+	// Requests still in flight when the shutdown deadline passed
+	shutdownDropped atomic.Uint64
+
+	// Requests rejected by the rate limiter
+	rateLimited atomic.Uint64
+
+	// Requests from exempt callers that bypassed the rate limiter
+	rateLimitExempt atomic.Uint64
+
+	// Upstream retries and retries skipped because the budget was spent
+	upstreamRetries      atomic.Uint64
+	retryBudgetExhausted atomic.Uint64
+
+	// Second attempts of slow hedged reads, and those that answered first
+	hedgedRequests atomic.Uint64
+	hedgeWins      atomic.Uint64
+
+	// Requests cancelled by the watchdog for exceeding their hard limit
+	stuckRequests atomic.Uint64
+
+	// Requests whose client went away before the backend answered
+	clientAborted atomic.Uint64
+
+	// Load shedding level and requests shed under runtime pressure
+	loadShedLevel    atomic.Int32
+	loadShedRequests atomic.Uint64
+	bulkheadRejected atomic.Uint64
+	adaptiveShed     atomic.Uint64
+
+	// Faults injected into backend calls (chaos testing)
+	faultsInjected atomic.Uint64
+
+	// Streaming messages dropped and clients disconnected for falling behind
+	streamMessagesDropped atomic.Uint64
+	streamSlowDisconnects atomic.Uint64
+
+	// Mirrored requests by how the mirror's response compared with the primary's
+	mirrorMatched  atomic.Uint64
+	mirrorDiverged atomic.Uint64
+	mirrorFailed   atomic.Uint64
+	mirrorSkipped  atomic.Uint64
+
+	// Route match cache metrics
+	routeCacheHits   atomic.Uint64
+	routeCacheMisses atomic.Uint64
+
+	// Response cache metrics (routes with cache)
+	responseCacheHits   atomic.Uint64
+	responseCacheMisses atomic.Uint64
+
+	// Reconnect ticket metrics
+	ticketsIssued   atomic.Uint64
+	ticketsAccepted atomic.Uint64
+	ticketsRejected atomic.Uint64
+
+	// Login attempts by outcome, and credential stuffing runs detected
+	loginSucceeded     atomic.Uint64
+	loginFailed        atomic.Uint64
+	loginThrottled     atomic.Uint64
+	loginLocked        atomic.Uint64
+	loginChallenged    atomic.Uint64
+	credentialStuffing atomic.Uint64
+
+	// Control plane config sync
+	configVersion         atomic.Value // string
+	configUpdatesApplied  atomic.Uint64
+	configUpdatesRejected atomic.Uint64
+
+	// Prometheus latency histograms and in-flight gauges, exported by /metrics
+	// together with the counters above
+	registry         *prometheus.Registry
+	requestDuration  *prometheus.HistogramVec // route, service, code
+	serviceDuration  *prometheus.HistogramVec // service
+	requestsInFlight *prometheus.GaugeVec     // route
+	stuckByRoute     *prometheus.CounterVec   // route
+	abortedByRoute   *prometheus.CounterVec   // route
+	mirrorByRoute    *prometheus.CounterVec   // route, result
+	adaptiveLimit    *prometheus.GaugeVec     // service
+	breakerState     *prometheus.GaugeVec     // breaker, state
+	breakerChanges   *prometheus.CounterVec   // breaker, state
+
 	startTime time.Time
 }
 
@@ -39,6 +135,7 @@ type RouteMetrics struct {
 	failures      atomic.Uint64
 	totalLatency  atomic.Uint64 // in milliseconds
 	lastRequestAt atomic.Value  // time.Time
+	window        rollingWindow
 }
 
 // ServiceMetrics tracks metrics for a specific backend service
@@ -47,17 +144,76 @@ type ServiceMetrics struct {
 	successes    atomic.Uint64
 	failures     atomic.Uint64
 	totalLatency atomic.Uint64 // in milliseconds
+	window       rollingWindow
 }
 
 // NewMetrics creates a new metrics collector
 func NewMetrics() *Metrics {
-	return &Metrics{
+	m := &Metrics{
+		window:    &rollingWindow{},
 		startTime: time.Now(),
+		registry:  prometheus.NewRegistry(),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gateway_request_duration_seconds",
+			Help:    "Request latency by route, backend service and HTTP status code",
+			Buckets: latencyBuckets,
+		}, []string{"route", "service", "code"}),
+		serviceDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gateway_service_duration_seconds",
+			Help:    "Latency of proxied requests per backend service",
+			Buckets: latencyBuckets,
+		}, []string{"service"}),
+		requestsInFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "gateway_requests_in_flight",
+			Help: "Requests currently being served per route",
+		}, []string{"route"}),
+		stuckByRoute: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gateway_stuck_requests_total",
+			Help: "Requests cancelled by the watchdog for exceeding their hard limit",
+		}, []string{"route"}),
+		abortedByRoute: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gateway_client_aborted_requests_total",
+			Help: "Requests whose client disconnected or gave up before the backend answered",
+		}, []string{"route"}),
+		mirrorByRoute: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gateway_mirror_requests_total",
+			Help: "Mirrored requests per route by result (match, diverged, error, skipped)",
+		}, []string{"route", "result"}),
+		adaptiveLimit: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "gateway_adaptive_concurrency_limit",
+			Help: "Concurrency limit learned per backend service from its latency",
+		}, []string{"service"}),
+		breakerState: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "gateway_circuit_breaker_state",
+			Help: "1 for the current state of each circuit breaker (CLOSED, OPEN, HALF_OPEN), 0 for the others",
+		}, []string{"breaker", "state"}),
+		breakerChanges: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gateway_circuit_breaker_transitions_total",
+			Help: "Circuit breaker state changes by the state entered",
+		}, []string{"breaker", "state"}),
 	}
+
+	m.registry.MustRegister(
+		m.requestDuration,
+		m.serviceDuration,
+		m.requestsInFlight,
+		m.stuckByRoute,
+		m.abortedByRoute,
+		m.mirrorByRoute,
+		m.adaptiveLimit,
+		m.breakerState,
+		m.breakerChanges,
+		&collector{metrics: m},
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
 }
 
 // RecordRequest records a request and its outcome
 func (m *Metrics) RecordRequest(routeName, serviceName string, latency time.Duration, success bool) {
+	now := time.Now()
+
 	// Update total counters
 	m.totalRequests.Add(1)
 	if success {
@@ -66,6 +222,7 @@ func (m *Metrics) RecordRequest(routeName, serviceName string, latency time.Dura
 		m.failedRequests.Add(1)
 	}
 	m.totalLatency.Add(uint64(latency.Milliseconds()))
+	m.window.record(now, latency, success)
 
 	// Update route metrics
 	rm := m.getOrCreateRouteMetrics(routeName)
@@ -76,7 +233,8 @@ func (m *Metrics) RecordRequest(routeName, serviceName string, latency time.Dura
 		rm.failures.Add(1)
 	}
 	rm.totalLatency.Add(uint64(latency.Milliseconds()))
-	rm.lastRequestAt.Store(time.Now())
+	rm.lastRequestAt.Store(now)
+	rm.window.record(now, latency, success)
 
 	// Update service metrics
 	if serviceName != "" {
@@ -88,9 +246,18 @@ func (m *Metrics) RecordRequest(routeName, serviceName string, latency time.Dura
 			sm.failures.Add(1)
 		}
 		sm.totalLatency.Add(uint64(latency.Milliseconds()))
+		sm.window.record(now, latency, success)
+		m.serviceDuration.WithLabelValues(serviceName).Observe(latency.Seconds())
 	}
 }
 
+// RecordCircuitBreakerState records a circuit breaker moving between states
+func (m *Metrics) RecordCircuitBreakerState(breaker, from, to string) {
+	m.breakerState.WithLabelValues(breaker, from).Set(0)
+	m.breakerState.WithLabelValues(breaker, to).Set(1)
+	m.breakerChanges.WithLabelValues(breaker, to).Inc()
+}
+
 // RecordCacheHit records a cache hit
 func (m *Metrics) RecordCacheHit() {
 	m.cacheHits.Add(1)
@@ -101,20 +268,240 @@ func (m *Metrics) RecordCacheMiss() {
 	m.cacheMisses.Add(1)
 }
 
+// RecordConnectionRejected records a connection closed by the connection limiter
+func (m *Metrics) RecordConnectionRejected(reason string) {
+	m.connectionsRejected.Add(1)
+}
+
+// RecordShutdownDropped records requests cut off by the shutdown deadline
+func (m *Metrics) RecordShutdownDropped(count int) {
+	m.shutdownDropped.Add(uint64(count))
+}
+
+// RecordStuckRequest records a request cancelled by the watchdog
+func (m *Metrics) RecordStuckRequest(routeName string) {
+	m.stuckRequests.Add(1)
+	m.stuckByRoute.WithLabelValues(routeName).Inc()
+}
+
+// RecordClientAborted records a request abandoned by its client while the
+// backend call was in flight
+func (m *Metrics) RecordClientAborted(routeName string) {
+	m.clientAborted.Add(1)
+	m.abortedByRoute.WithLabelValues(routeName).Inc()
+}
+
+// Mirrored request results
+const (
+	MirrorMatch    = "match"    // Same status and response as the primary
+	MirrorDiverged = "diverged" // Different status or response
+	MirrorError    = "error"    // The mirror couldn't be reached
+	MirrorSkipped  = "skipped"  // Too many mirrored requests in flight
+)
+
+// RecordMirror records the result of a mirrored request
+func (m *Metrics) RecordMirror(routeName, result string) {
+	switch result {
+	case MirrorMatch:
+		m.mirrorMatched.Add(1)
+	case MirrorDiverged:
+		m.mirrorDiverged.Add(1)
+	case MirrorError:
+		m.mirrorFailed.Add(1)
+	case MirrorSkipped:
+		m.mirrorSkipped.Add(1)
+	}
+	m.mirrorByRoute.WithLabelValues(routeName, result).Inc()
+}
+
+// RecordRateLimited records a request rejected by the rate limiter
+func (m *Metrics) RecordRateLimited() {
+	m.rateLimited.Add(1)
+}
+
+// RecordRateLimitExempt records a request from an exempt caller that bypassed the rate limiter
+func (m *Metrics) RecordRateLimitExempt() {
+	m.rateLimitExempt.Add(1)
+}
+
+// RecordUpstreamRetry records a backend call retried after a transient failure
+func (m *Metrics) RecordUpstreamRetry() {
+	m.upstreamRetries.Add(1)
+}
+
+// RecordHedge records a second attempt sent for a slow read
+func (m *Metrics) RecordHedge() {
+	m.hedgedRequests.Add(1)
+}
+
+// RecordHedgeWin records a second attempt that answered before the first
+func (m *Metrics) RecordHedgeWin() {
+	m.hedgeWins.Add(1)
+}
+
+// RecordRetryBudgetExhausted records a retry skipped because the service's retry budget was spent
+func (m *Metrics) RecordRetryBudgetExhausted() {
+	m.retryBudgetExhausted.Add(1)
+}
+
+// SetLoadShedLevel records the current load shedding level (0 when nothing is shed)
+func (m *Metrics) SetLoadShedLevel(level int) {
+	m.loadShedLevel.Store(int32(level))
+}
+
+// RecordLoadShed records a request shed under runtime pressure
+func (m *Metrics) RecordLoadShed() {
+	m.loadShedRequests.Add(1)
+}
+
+// RecordBulkheadRejected records a request shed over a concurrency limit
+func (m *Metrics) RecordBulkheadRejected() {
+	m.bulkheadRejected.Add(1)
+}
+
+// RecordAdaptiveShed records a request shed over a service's adaptive limit
+func (m *Metrics) RecordAdaptiveShed() {
+	m.adaptiveShed.Add(1)
+}
+
+// RecordFaultInjected records a fault injected into a backend call
+func (m *Metrics) RecordFaultInjected() {
+	m.faultsInjected.Add(1)
+}
+
+// SetAdaptiveLimit records the concurrency limit learned for a service
+func (m *Metrics) SetAdaptiveLimit(serviceName string, limit int) {
+	m.adaptiveLimit.WithLabelValues(serviceName).Set(float64(limit))
+}
+
+// RecordStreamDrop records a streaming message dropped for a slow client ("drop_oldest")
+// or a slow client disconnected ("disconnect")
+func (m *Metrics) RecordStreamDrop(reason string) {
+	if reason == "disconnect" {
+		m.streamSlowDisconnects.Add(1)
+	} else {
+		m.streamMessagesDropped.Add(1)
+	}
+}
+
+// RecordRouteCacheLookup records a route match cache hit or miss
+func (m *Metrics) RecordRouteCacheLookup(hit bool) {
+	if hit {
+		m.routeCacheHits.Add(1)
+	} else {
+		m.routeCacheMisses.Add(1)
+	}
+}
+
+// RecordResponseCacheLookup records a response cache hit or miss
+func (m *Metrics) RecordResponseCacheLookup(hit bool) {
+	if hit {
+		m.responseCacheHits.Add(1)
+	} else {
+		m.responseCacheMisses.Add(1)
+	}
+}
+
+// RecordConfigUpdate records a control plane config version being applied or rejected
+func (m *Metrics) RecordConfigUpdate(version string, applied bool) {
+	if applied {
+		m.configVersion.Store(version)
+		m.configUpdatesApplied.Add(1)
+	} else {
+		m.configUpdatesRejected.Add(1)
+	}
+}
+
+// Reconnect ticket outcomes
+const (
+	TicketIssued   = "issued"
+	TicketAccepted = "accepted"
+	TicketRejected = "rejected"
+)
+
+// RecordReconnectTicket records a reconnect ticket being issued, accepted or rejected
+func (m *Metrics) RecordReconnectTicket(outcome string) {
+	switch outcome {
+	case TicketIssued:
+		m.ticketsIssued.Add(1)
+	case TicketAccepted:
+		m.ticketsAccepted.Add(1)
+	case TicketRejected:
+		m.ticketsRejected.Add(1)
+	}
+}
+
+// Login attempt outcomes
+const (
+	LoginSucceeded  = "success"
+	LoginFailed     = "failure"
+	LoginThrottled  = "throttled"  // Rejected during backoff or from a blocked address
+	LoginLocked     = "locked"     // Rejected for a locked account
+	LoginChallenged = "challenged" // Rejected for a missing or failed challenge
+)
+
+// RecordLoginAttempt records a login attempt by outcome
+func (m *Metrics) RecordLoginAttempt(outcome string) {
+	switch outcome {
+	case LoginSucceeded:
+		m.loginSucceeded.Add(1)
+	case LoginFailed:
+		m.loginFailed.Add(1)
+	case LoginThrottled:
+		m.loginThrottled.Add(1)
+	case LoginLocked:
+		m.loginLocked.Add(1)
+	case LoginChallenged:
+		m.loginChallenged.Add(1)
+	}
+}
+
+// RecordCredentialStuffing records one address failing logins for many accounts
+func (m *Metrics) RecordCredentialStuffing() {
+	m.credentialStuffing.Add(1)
+}
+
 // RecordCircuitBreakerTrip records a circuit breaker trip
 func (m *Metrics) RecordCircuitBreakerTrip() {
 	m.circuitBreakerTrips.Add(1)
 }
 
+// SetRouteTags registers the tags of a route so its metrics are aggregated per tag
+func (m *Metrics) SetRouteTags(routeName string, tags map[string]string) {
+	if len(tags) == 0 {
+		m.routeTags.Delete(routeName)
+		return
+	}
+
+	copied := make(map[string]string, len(tags))
+	for k, v := range tags {
+		copied[k] = v
+	}
+	m.routeTags.Store(routeName, copied)
+}
+
+// SetAllRouteTags replaces the tags of every route, so routes removed or
+// renamed by a reload stop counting towards their old tags
+func (m *Metrics) SetAllRouteTags(tags map[string]map[string]string) {
+	m.routeTags.Range(func(key, _ interface{}) bool {
+		if len(tags[key.(string)]) == 0 {
+			m.routeTags.Delete(key)
+		}
+		return true
+	})
+	for routeName, routeTags := range tags {
+		m.SetRouteTags(routeName, routeTags)
+	}
+}
+
 // getOrCreateRouteMetrics gets or creates route metrics
 func (m *Metrics) getOrCreateRouteMetrics(routeName string) *RouteMetrics {
 	if val, ok := m.routeMetrics.Load(routeName); ok {
 		return val.(*RouteMetrics)
 	}
 
-	rm := &RouteMetrics{}
-	m.routeMetrics.Store(routeName, rm)
-	return rm
+	val, _ := m.routeMetrics.LoadOrStore(routeName, &RouteMetrics{})
+	return val.(*RouteMetrics)
 }
 
 // getOrCreateServiceMetrics gets or creates service metrics
@@ -123,9 +510,8 @@ func (m *Metrics) getOrCreateServiceMetrics(serviceName string) *ServiceMetrics
 		return val.(*ServiceMetrics)
 	}
 
-	sm := &ServiceMetrics{}
-	m.serviceMetrics.Store(serviceName, sm)
-	return sm
+	val, _ := m.serviceMetrics.LoadOrStore(serviceName, &ServiceMetrics{})
+	return val.(*ServiceMetrics)
 }
 
 // GetSnapshot returns a snapshot of current metrics
@@ -193,6 +579,9 @@ func (m *Metrics) GetSnapshot() MetricsSnapshot {
 		return true
 	})
 
+	// Aggregate route metrics by tag
+	tags := aggregateByTag(routes, &m.routeTags)
+
 	// Calculate requests per second
 	uptime := time.Since(m.startTime).Seconds()
 	var reqsPerSec float64
@@ -207,38 +596,164 @@ func (m *Metrics) GetSnapshot() MetricsSnapshot {
 		cacheHitRate = float64(m.cacheHits.Load()) / float64(totalCacheReqs) * 100
 	}
 
+	// Calculate route match cache hit rate
+	routeCacheHits := m.routeCacheHits.Load()
+	routeCacheMisses := m.routeCacheMisses.Load()
+	var routeCacheHitRate float64
+	if routeCacheHits+routeCacheMisses > 0 {
+		routeCacheHitRate = float64(routeCacheHits) / float64(routeCacheHits+routeCacheMisses) * 100
+	}
+
+	configVersion, _ := m.configVersion.Load().(string)
+
 	return MetricsSnapshot{
-		TotalRequests:       totalReqs,
-		SuccessfulRequests:  successReqs,
-		FailedRequests:      failedReqs,
-		SuccessRate:         successRate,
-		AvgLatencyMs:        avgLatency,
-		RequestsPerSecond:   reqsPerSec,
-		CacheHits:           m.cacheHits.Load(),
-		CacheMisses:         m.cacheMisses.Load(),
-		CacheHitRate:        cacheHitRate,
-		CircuitBreakerTrips: m.circuitBreakerTrips.Load(),
-		UptimeSeconds:       uptime,
-		Routes:              routes,
-		Services:            services,
+		TotalRequests:         totalReqs,
+		SuccessfulRequests:    successReqs,
+		FailedRequests:        failedReqs,
+		SuccessRate:           successRate,
+		AvgLatencyMs:          avgLatency,
+		RequestsPerSecond:     reqsPerSec,
+		CacheHits:             m.cacheHits.Load(),
+		CacheMisses:           m.cacheMisses.Load(),
+		CacheHitRate:          cacheHitRate,
+		CircuitBreakerTrips:   m.circuitBreakerTrips.Load(),
+		ConnectionsRejected:   m.connectionsRejected.Load(),
+		ShutdownDropped:       m.shutdownDropped.Load(),
+		RateLimited:           m.rateLimited.Load(),
+		RateLimitExempt:       m.rateLimitExempt.Load(),
+		UpstreamRetries:       m.upstreamRetries.Load(),
+		HedgedRequests:        m.hedgedRequests.Load(),
+		HedgeWins:             m.hedgeWins.Load(),
+		RetryBudgetExhausted:  m.retryBudgetExhausted.Load(),
+		StuckRequests:         m.stuckRequests.Load(),
+		ClientAborted:         m.clientAborted.Load(),
+		LoadShedLevel:         int(m.loadShedLevel.Load()),
+		LoadShedRequests:      m.loadShedRequests.Load(),
+		BulkheadRejected:      m.bulkheadRejected.Load(),
+		AdaptiveShed:          m.adaptiveShed.Load(),
+		FaultsInjected:        m.faultsInjected.Load(),
+		StreamMessagesDropped: m.streamMessagesDropped.Load(),
+		StreamSlowDisconnects: m.streamSlowDisconnects.Load(),
+		MirrorMatched:         m.mirrorMatched.Load(),
+		MirrorDiverged:        m.mirrorDiverged.Load(),
+		MirrorFailed:          m.mirrorFailed.Load(),
+		MirrorSkipped:         m.mirrorSkipped.Load(),
+		RouteCacheHits:        routeCacheHits,
+		RouteCacheMisses:      routeCacheMisses,
+		RouteCacheHitRate:     routeCacheHitRate,
+		ResponseCacheHits:     m.responseCacheHits.Load(),
+		ResponseCacheMisses:   m.responseCacheMisses.Load(),
+		TicketsIssued:         m.ticketsIssued.Load(),
+		TicketsAccepted:       m.ticketsAccepted.Load(),
+		TicketsRejected:       m.ticketsRejected.Load(),
+		LoginSucceeded:        m.loginSucceeded.Load(),
+		LoginFailed:           m.loginFailed.Load(),
+		LoginThrottled:        m.loginThrottled.Load(),
+		LoginLocked:           m.loginLocked.Load(),
+		LoginChallenged:       m.loginChallenged.Load(),
+		CredentialStuffing:    m.credentialStuffing.Load(),
+		ConfigVersion:         configVersion,
+		ConfigUpdatesApplied:  m.configUpdatesApplied.Load(),
+		ConfigUpdatesRejected: m.configUpdatesRejected.Load(),
+		UptimeSeconds:         uptime,
+		Routes:                routes,
+		Services:              services,
+		Tags:                  tags,
+		Stages:                m.collectStages(),
 	}
 }
 
+// aggregateByTag sums route snapshots into per-tag snapshots keyed by "key=value"
+func aggregateByTag(routes map[string]RouteSnapshot, routeTags *sync.Map) map[string]TagSnapshot {
+	tags := make(map[string]TagSnapshot)
+	totalLatency := make(map[string]float64)
+
+	routeTags.Range(func(key, value interface{}) bool {
+		rs, ok := routes[key.(string)]
+		if !ok {
+			return true
+		}
+
+		for tagKey, tagValue := range value.(map[string]string) {
+			id := tagKey + "=" + tagValue
+			ts := tags[id]
+			ts.Key = tagKey
+			ts.Value = tagValue
+			ts.Requests += rs.Requests
+			ts.Successes += rs.Successes
+			ts.Failures += rs.Failures
+			tags[id] = ts
+			totalLatency[id] += rs.AvgLatencyMs * float64(rs.Requests)
+		}
+		return true
+	})
+
+	for id, ts := range tags {
+		if ts.Requests > 0 {
+			ts.AvgLatencyMs = totalLatency[id] / float64(ts.Requests)
+			ts.ErrorRate = float64(ts.Failures) / float64(ts.Requests) * 100
+		}
+		tags[id] = ts
+	}
+
+	return tags
+}
+
 // MetricsSnapshot represents a point-in-time snapshot of metrics
 type MetricsSnapshot struct {
-	TotalRequests       uint64
-	SuccessfulRequests  uint64
-	FailedRequests      uint64
-	SuccessRate         float64
-	AvgLatencyMs        float64
-	RequestsPerSecond   float64
-	CacheHits           uint64
-	CacheMisses         uint64
-	CacheHitRate        float64
-	CircuitBreakerTrips uint64
-	UptimeSeconds       float64
-	Routes              map[string]RouteSnapshot
-	Services            map[string]ServiceSnapshot
+	TotalRequests         uint64
+	SuccessfulRequests    uint64
+	FailedRequests        uint64
+	SuccessRate           float64
+	AvgLatencyMs          float64
+	RequestsPerSecond     float64
+	CacheHits             uint64
+	CacheMisses           uint64
+	CacheHitRate          float64
+	CircuitBreakerTrips   uint64
+	ConnectionsRejected   uint64
+	ShutdownDropped       uint64
+	RateLimited           uint64
+	RateLimitExempt       uint64
+	UpstreamRetries       uint64
+	HedgedRequests        uint64
+	HedgeWins             uint64
+	RetryBudgetExhausted  uint64
+	StuckRequests         uint64
+	ClientAborted         uint64
+	LoadShedLevel         int
+	LoadShedRequests      uint64
+	BulkheadRejected      uint64
+	AdaptiveShed          uint64
+	FaultsInjected        uint64
+	StreamMessagesDropped uint64
+	StreamSlowDisconnects uint64
+	MirrorMatched         uint64
+	MirrorDiverged        uint64
+	MirrorFailed          uint64
+	MirrorSkipped         uint64
+	RouteCacheHits        uint64
+	RouteCacheMisses      uint64
+	RouteCacheHitRate     float64
+	ResponseCacheHits     uint64
+	ResponseCacheMisses   uint64
+	TicketsIssued         uint64
+	TicketsAccepted       uint64
+	TicketsRejected       uint64
+	LoginSucceeded        uint64
+	LoginFailed           uint64
+	LoginThrottled        uint64
+	LoginLocked           uint64
+	LoginChallenged       uint64
+	CredentialStuffing    uint64
+	ConfigVersion         string
+	ConfigUpdatesApplied  uint64
+	ConfigUpdatesRejected uint64
+	UptimeSeconds         float64
+	Routes                map[string]RouteSnapshot
+	Services              map[string]ServiceSnapshot
+	Tags                  map[string]TagSnapshot
+	Stages                map[string]HistogramSnapshot
 }
 
 // RouteSnapshot represents metrics for a specific route
@@ -258,6 +773,17 @@ type ServiceSnapshot struct {
 	AvgLatencyMs float64
 }
 
+// TagSnapshot represents metrics aggregated across all routes sharing a tag
+type TagSnapshot struct {
+	Key          string
+	Value        string
+	Requests     uint64
+	Successes    uint64
+	Failures     uint64
+	AvgLatencyMs float64
+	ErrorRate    float64
+}
+
 // Reset resets all metrics
 func (m *Metrics) Reset() {
 	m.totalRequests.Store(0)
@@ -267,7 +793,68 @@ func (m *Metrics) Reset() {
 	m.cacheHits.Store(0)
 	m.cacheMisses.Store(0)
 	m.circuitBreakerTrips.Store(0)
+	m.connectionsRejected.Store(0)
+	m.shutdownDropped.Store(0)
+	m.rateLimited.Store(0)
+	m.rateLimitExempt.Store(0)
+	m.upstreamRetries.Store(0)
+	m.hedgedRequests.Store(0)
+	m.hedgeWins.Store(0)
+	m.retryBudgetExhausted.Store(0)
+	m.stuckRequests.Store(0)
+	m.clientAborted.Store(0)
+	m.loadShedRequests.Store(0)
+	m.bulkheadRejected.Store(0)
+	m.adaptiveShed.Store(0)
+	m.faultsInjected.Store(0)
+	m.streamMessagesDropped.Store(0)
+	m.streamSlowDisconnects.Store(0)
+	m.mirrorMatched.Store(0)
+	m.mirrorDiverged.Store(0)
+	m.mirrorFailed.Store(0)
+	m.mirrorSkipped.Store(0)
+	m.routeCacheHits.Store(0)
+	m.routeCacheMisses.Store(0)
+	m.responseCacheHits.Store(0)
+	m.responseCacheMisses.Store(0)
+	m.ticketsIssued.Store(0)
+	m.ticketsAccepted.Store(0)
+	m.ticketsRejected.Store(0)
+	m.loginSucceeded.Store(0)
+	m.loginFailed.Store(0)
+	m.loginThrottled.Store(0)
+	m.loginLocked.Store(0)
+	m.loginChallenged.Store(0)
+	m.credentialStuffing.Store(0)
 	m.routeMetrics = sync.Map{}
 	m.serviceMetrics = sync.Map{}
+	m.stageLatency = sync.Map{}
+	m.requestDuration.Reset()
+	m.serviceDuration.Reset()
+	m.stuckByRoute.Reset()
+	m.abortedByRoute.Reset()
+	m.mirrorByRoute.Reset()
+	m.breakerChanges.Reset()
+	m.window = &rollingWindow{}
 	m.startTime = time.Now()
 }
+
+// ResetRoute clears the metrics of a single route and reports whether it had
+// any. Gateway-wide totals are left untouched.
+func (m *Metrics) ResetRoute(routeName string) bool {
+	_, ok := m.routeMetrics.LoadAndDelete(routeName)
+	m.requestDuration.DeletePartialMatch(prometheus.Labels{"route": routeName})
+	m.stuckByRoute.DeleteLabelValues(routeName)
+	m.abortedByRoute.DeleteLabelValues(routeName)
+	m.mirrorByRoute.DeletePartialMatch(prometheus.Labels{"route": routeName})
+	return ok
+}
+
+// ResetService clears the metrics of a single backend service and reports
+// whether it had any. Gateway-wide totals are left untouched.
+func (m *Metrics) ResetService(serviceName string) bool {
+	_, ok := m.serviceMetrics.LoadAndDelete(serviceName)
+	m.requestDuration.DeletePartialMatch(prometheus.Labels{"service": serviceName})
+	m.serviceDuration.DeleteLabelValues(serviceName)
+	return ok
+}
@@ -0,0 +1,165 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMetrics_TagAggregation(t *testing.T) {
+	m := NewMetrics()
+	m.SetRouteTags("submit-order", map[string]string{"domain": "orders", "tier": "critical"})
+	m.SetRouteTags("get-balance", map[string]string{"domain": "balance", "tier": "critical"})
+	m.SetRouteTags("get-quote", map[string]string{"domain": "market-data"})
+
+	m.RecordRequest("submit-order", "hub-monolith", 10*time.Millisecond, true)
+	m.RecordRequest("submit-order", "hub-monolith", 30*time.Millisecond, false)
+	m.RecordRequest("get-balance", "hub-monolith", 20*time.Millisecond, true)
+	m.RecordRequest("get-quote", "hub-monolith", 5*time.Millisecond, true)
+	m.RecordRequest("untagged", "hub-monolith", 5*time.Millisecond, false)
+
+	snapshot := m.GetSnapshot()
+
+	critical, ok := snapshot.Tags["tier=critical"]
+	if !ok {
+		t.Fatalf("expected tier=critical tag in snapshot")
+	}
+	if critical.Requests != 3 {
+		t.Errorf("expected 3 requests but got %d", critical.Requests)
+	}
+	if critical.Failures != 1 {
+		t.Errorf("expected 1 failure but got %d", critical.Failures)
+	}
+	if critical.AvgLatencyMs != 20 {
+		t.Errorf("expected 20ms avg latency but got %.2f", critical.AvgLatencyMs)
+	}
+
+	if orders := snapshot.Tags["domain=orders"]; orders.Requests != 2 {
+		t.Errorf("expected 2 order requests but got %d", orders.Requests)
+	}
+
+	if len(snapshot.Tags) != 4 {
+		t.Errorf("expected 4 tags but got %d", len(snapshot.Tags))
+	}
+}
+
+func TestMetrics_SetRouteTagsEmptyRemovesRoute(t *testing.T) {
+	m := NewMetrics()
+	m.SetRouteTags("route", map[string]string{"tier": "critical"})
+	m.SetRouteTags("route", nil)
+	m.RecordRequest("route", "svc", time.Millisecond, true)
+
+	if len(m.GetSnapshot().Tags) != 0 {
+		t.Errorf("expected no tags after clearing route tags")
+	}
+}
+
+func TestMetrics_StageHistograms(t *testing.T) {
+	m := NewMetrics()
+	m.RecordStage(StageBackend, 3*time.Millisecond)
+	m.RecordStage(StageBackend, 40*time.Millisecond)
+	m.RecordStage(StageBackend, 20*time.Second)
+	m.RecordStage(StageAuth, 200*time.Microsecond)
+
+	snapshot := m.GetSnapshot()
+
+	backend, ok := snapshot.Stages[StageBackend]
+	if !ok {
+		t.Fatalf("expected backend stage in snapshot")
+	}
+	if backend.Count != 3 {
+		t.Errorf("expected 3 samples but got %d", backend.Count)
+	}
+
+	cumulative := make(map[float64]uint64)
+	for _, bucket := range backend.Buckets {
+		cumulative[bucket.UpperBound] = bucket.Count
+	}
+	if cumulative[0.001] != 0 || cumulative[0.005] != 1 || cumulative[0.05] != 2 || cumulative[10] != 2 {
+		t.Errorf("unexpected cumulative buckets: %+v", backend.Buckets)
+	}
+
+	if snapshot.Stages[StageAuth].Buckets[0].Count != 1 {
+		t.Errorf("expected auth sample in the smallest bucket")
+	}
+}
+
+func TestMetrics_WindowSnapshot(t *testing.T) {
+	m := NewMetrics()
+	m.RecordRequest("get-quote", "market-data", 10*time.Millisecond, true)
+	m.RecordRequest("get-quote", "market-data", 30*time.Millisecond, false)
+
+	// Traffic from ten minutes ago only shows up in the 1h window
+	m.window.record(time.Now().Add(-10*time.Minute), 5*time.Millisecond, true)
+
+	recent, err := m.GetWindowSnapshot("5m")
+	if err != nil {
+		t.Fatalf("GetWindowSnapshot(5m) error = %v", err)
+	}
+	if recent.TotalRequests != 2 || recent.FailedRequests != 1 {
+		t.Errorf("expected 2 requests and 1 failure in 5m window but got %d and %d", recent.TotalRequests, recent.FailedRequests)
+	}
+	if recent.AvgLatencyMs != 20 {
+		t.Errorf("expected 20ms avg latency but got %.2f", recent.AvgLatencyMs)
+	}
+	if recent.Routes["get-quote"].Requests != 2 || recent.Services["market-data"].Failures != 1 {
+		t.Errorf("unexpected route/service window metrics: %+v %+v", recent.Routes, recent.Services)
+	}
+
+	hour, _ := m.GetWindowSnapshot("1h")
+	if hour.TotalRequests != 3 {
+		t.Errorf("expected 3 requests in 1h window but got %d", hour.TotalRequests)
+	}
+
+	if _, err := m.GetWindowSnapshot("2d"); err == nil {
+		t.Error("expected error for unknown window")
+	}
+}
+
+func TestMetrics_ScopedReset(t *testing.T) {
+	m := NewMetrics()
+	m.RecordRequest("get-quote", "market-data", time.Millisecond, true)
+	m.RecordRequest("submit-order", "hub-monolith", time.Millisecond, true)
+
+	if !m.ResetRoute("get-quote") {
+		t.Fatal("expected ResetRoute to find get-quote")
+	}
+	if m.ResetRoute("get-quote") {
+		t.Error("expected second ResetRoute to report no metrics")
+	}
+	if !m.ResetService("hub-monolith") {
+		t.Fatal("expected ResetService to find hub-monolith")
+	}
+
+	snapshot := m.GetSnapshot()
+	if _, ok := snapshot.Routes["get-quote"]; ok {
+		t.Error("expected get-quote metrics to be cleared")
+	}
+	if _, ok := snapshot.Routes["submit-order"]; !ok {
+		t.Error("expected submit-order metrics to be kept")
+	}
+	if _, ok := snapshot.Services["hub-monolith"]; ok {
+		t.Error("expected hub-monolith metrics to be cleared")
+	}
+	if snapshot.TotalRequests != 2 {
+		t.Errorf("expected gateway totals to be kept but got %d", snapshot.TotalRequests)
+	}
+}
+
+func TestMetrics_SetAllRouteTagsDropsRemovedRoutes(t *testing.T) {
+	m := NewMetrics()
+	m.SetAllRouteTags(map[string]map[string]string{
+		"old-route": {"tier": "critical"},
+		"kept":      {"tier": "critical"},
+	})
+	m.SetAllRouteTags(map[string]map[string]string{
+		"kept":      {"tier": "critical"},
+		"new-route": {"domain": "orders"},
+	})
+	m.RecordRequest("old-route", "svc", time.Millisecond, true)
+	m.RecordRequest("kept", "svc", time.Millisecond, true)
+
+	snapshot := m.GetSnapshot()
+	if critical := snapshot.Tags["tier=critical"]; critical.Requests != 1 {
+		t.Errorf("expected only the kept route under tier=critical but got %d requests", critical.Requests)
+	}
+}
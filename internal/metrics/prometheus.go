@@ -0,0 +1,159 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"hub-api-gateway/internal/respwriter"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Instrument wraps a route's handler to track in-flight requests and observe
+// their latency by status code in gateway_request_duration_seconds
+func (m *Metrics) Instrument(routeName, serviceName string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		inFlight := m.requestsInFlight.WithLabelValues(routeName)
+		inFlight.Inc()
+		defer inFlight.Dec()
+
+		start := time.Now()
+		recorder := respwriter.New(w)
+		next.ServeHTTP(recorder, r)
+
+		m.requestDuration.WithLabelValues(routeName, serviceName, strconv.Itoa(recorder.Status())).
+			Observe(time.Since(start).Seconds())
+	})
+}
+
+// collector exports the gateway's counters and stage histograms to Prometheus
+// from a snapshot taken at scrape time
+type collector struct {
+	metrics *Metrics
+}
+
+var (
+	infoDesc                  = prometheus.NewDesc("gateway_info", "Gateway information", []string{"version"}, nil)
+	uptimeDesc                = prometheus.NewDesc("gateway_uptime_seconds", "Gateway uptime in seconds", nil, nil)
+	requestsDesc              = prometheus.NewDesc("gateway_requests_total", "Total number of requests", nil, nil)
+	requestsSuccessfulDesc    = prometheus.NewDesc("gateway_requests_successful_total", "Total number of successful requests", nil, nil)
+	requestsFailedDesc        = prometheus.NewDesc("gateway_requests_failed_total", "Total number of failed requests", nil, nil)
+	cacheHitsDesc             = prometheus.NewDesc("gateway_cache_hits_total", "Total cache hits", nil, nil)
+	cacheMissesDesc           = prometheus.NewDesc("gateway_cache_misses_total", "Total cache misses", nil, nil)
+	connectionsRejectedDesc   = prometheus.NewDesc("gateway_connections_rejected_total", "Connections closed by the connection limiter", nil, nil)
+	shutdownDroppedDesc       = prometheus.NewDesc("gateway_shutdown_dropped_requests_total", "Requests still in flight when the shutdown deadline passed", nil, nil)
+	rateLimitedDesc           = prometheus.NewDesc("gateway_rate_limited_total", "Requests rejected by the rate limiter", nil, nil)
+	rateLimitExemptDesc       = prometheus.NewDesc("gateway_rate_limit_exempt_total", "Requests from exempt callers that bypassed the rate limiter", nil, nil)
+	upstreamRetriesDesc       = prometheus.NewDesc("gateway_upstream_retries_total", "Backend calls retried after a transient failure", nil, nil)
+	retryBudgetExhaustedDesc  = prometheus.NewDesc("gateway_retry_budget_exhausted_total", "Retries skipped because the retry budget was spent", nil, nil)
+	hedgedRequestsDesc        = prometheus.NewDesc("gateway_hedged_requests_total", "Second attempts sent for slow reads of hedged routes", nil, nil)
+	hedgeWinsDesc             = prometheus.NewDesc("gateway_hedge_wins_total", "Hedged attempts that answered before the first attempt", nil, nil)
+	loadShedLevelDesc         = prometheus.NewDesc("gateway_load_shed_level", "Priority classes currently shed under runtime pressure (0 = none)", nil, nil)
+	loadShedRequestsDesc      = prometheus.NewDesc("gateway_load_shed_requests_total", "Requests shed under runtime pressure", nil, nil)
+	bulkheadRejectedDesc      = prometheus.NewDesc("gateway_bulkhead_rejected_total", "Requests shed over a per-service or per-client concurrency limit", nil, nil)
+	adaptiveShedDesc          = prometheus.NewDesc("gateway_adaptive_shed_total", "Requests shed over a service's adaptive concurrency limit", nil, nil)
+	faultsInjectedDesc        = prometheus.NewDesc("gateway_faults_injected_total", "Faults injected into backend calls for chaos testing", nil, nil)
+	streamDroppedDesc         = prometheus.NewDesc("gateway_stream_messages_dropped_total", "Streaming messages dropped for clients that fell behind", nil, nil)
+	streamSlowDisconnectsDesc = prometheus.NewDesc("gateway_stream_slow_consumer_disconnects_total", "Streaming clients disconnected for falling behind", nil, nil)
+	routeCacheHitsDesc        = prometheus.NewDesc("gateway_route_cache_hits_total", "Route match cache hits", nil, nil)
+	routeCacheMissesDesc      = prometheus.NewDesc("gateway_route_cache_misses_total", "Route match cache misses", nil, nil)
+	responseCacheHitsDesc     = prometheus.NewDesc("gateway_response_cache_hits_total", "GET responses served from the response cache", nil, nil)
+	responseCacheMissesDesc   = prometheus.NewDesc("gateway_response_cache_misses_total", "GET requests on cached routes that reached the backend", nil, nil)
+	circuitBreakerTripsDesc   = prometheus.NewDesc("gateway_circuit_breaker_trips_total", "Total circuit breaker trips", nil, nil)
+	reconnectTicketsDesc      = prometheus.NewDesc("gateway_reconnect_tickets_total", "Reconnect tickets by outcome", []string{"outcome"}, nil)
+	loginAttemptsDesc         = prometheus.NewDesc("gateway_login_attempts_total", "Login attempts by outcome", []string{"outcome"}, nil)
+	credentialStuffingDesc    = prometheus.NewDesc("gateway_credential_stuffing_suspected_total", "Client addresses seen failing logins for many accounts", nil, nil)
+	configInfoDesc            = prometheus.NewDesc("gateway_config_info", "Applied control plane config version", []string{"version"}, nil)
+	configUpdatesDesc         = prometheus.NewDesc("gateway_config_updates_total", "Control plane config updates by result", []string{"result"}, nil)
+	stageDurationDesc         = prometheus.NewDesc("gateway_stage_duration_seconds", "Time spent per request pipeline stage", []string{"stage"}, nil)
+	routeRequestsDesc         = prometheus.NewDesc("gateway_route_requests_total", "Total requests per route", []string{"route"}, nil)
+	routeFailuresDesc         = prometheus.NewDesc("gateway_route_failures_total", "Total failures per route", []string{"route"}, nil)
+	serviceRequestsDesc       = prometheus.NewDesc("gateway_service_requests_total", "Total requests per service", []string{"service"}, nil)
+	serviceFailuresDesc       = prometheus.NewDesc("gateway_service_failures_total", "Total failures per service", []string{"service"}, nil)
+	tagRequestsDesc           = prometheus.NewDesc("gateway_tag_requests_total", "Total requests per route tag", []string{"tag", "value"}, nil)
+	tagFailuresDesc           = prometheus.NewDesc("gateway_tag_failures_total", "Total failures per route tag", []string{"tag", "value"}, nil)
+)
+
+// Describe is derived from Collect since the labelled series vary at runtime
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(c, ch)
+}
+
+// Collect sends the current counter values and stage histograms
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	snapshot := c.metrics.GetSnapshot()
+
+	counter := func(desc *prometheus.Desc, value uint64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(value), labels...)
+	}
+
+	ch <- prometheus.MustNewConstMetric(infoDesc, prometheus.GaugeValue, 1, "1.0.0")
+	ch <- prometheus.MustNewConstMetric(uptimeDesc, prometheus.GaugeValue, snapshot.UptimeSeconds)
+
+	counter(requestsDesc, snapshot.TotalRequests)
+	counter(requestsSuccessfulDesc, snapshot.SuccessfulRequests)
+	counter(requestsFailedDesc, snapshot.FailedRequests)
+	counter(cacheHitsDesc, snapshot.CacheHits)
+	counter(cacheMissesDesc, snapshot.CacheMisses)
+	counter(connectionsRejectedDesc, snapshot.ConnectionsRejected)
+	counter(shutdownDroppedDesc, snapshot.ShutdownDropped)
+	counter(rateLimitedDesc, snapshot.RateLimited)
+	counter(rateLimitExemptDesc, snapshot.RateLimitExempt)
+	counter(upstreamRetriesDesc, snapshot.UpstreamRetries)
+	counter(retryBudgetExhaustedDesc, snapshot.RetryBudgetExhausted)
+	counter(hedgedRequestsDesc, snapshot.HedgedRequests)
+	counter(hedgeWinsDesc, snapshot.HedgeWins)
+	ch <- prometheus.MustNewConstMetric(loadShedLevelDesc, prometheus.GaugeValue, float64(snapshot.LoadShedLevel))
+	counter(loadShedRequestsDesc, snapshot.LoadShedRequests)
+	counter(bulkheadRejectedDesc, snapshot.BulkheadRejected)
+	counter(adaptiveShedDesc, snapshot.AdaptiveShed)
+	counter(faultsInjectedDesc, snapshot.FaultsInjected)
+	counter(streamDroppedDesc, snapshot.StreamMessagesDropped)
+	counter(streamSlowDisconnectsDesc, snapshot.StreamSlowDisconnects)
+	counter(routeCacheHitsDesc, snapshot.RouteCacheHits)
+	counter(routeCacheMissesDesc, snapshot.RouteCacheMisses)
+	counter(responseCacheHitsDesc, snapshot.ResponseCacheHits)
+	counter(responseCacheMissesDesc, snapshot.ResponseCacheMisses)
+	counter(circuitBreakerTripsDesc, snapshot.CircuitBreakerTrips)
+
+	counter(reconnectTicketsDesc, snapshot.TicketsIssued, TicketIssued)
+	counter(reconnectTicketsDesc, snapshot.TicketsAccepted, TicketAccepted)
+	counter(reconnectTicketsDesc, snapshot.TicketsRejected, TicketRejected)
+
+	counter(loginAttemptsDesc, snapshot.LoginSucceeded, LoginSucceeded)
+	counter(loginAttemptsDesc, snapshot.LoginFailed, LoginFailed)
+	counter(loginAttemptsDesc, snapshot.LoginThrottled, LoginThrottled)
+	counter(loginAttemptsDesc, snapshot.LoginLocked, LoginLocked)
+	counter(loginAttemptsDesc, snapshot.LoginChallenged, LoginChallenged)
+	counter(credentialStuffingDesc, snapshot.CredentialStuffing)
+
+	if snapshot.ConfigVersion != "" {
+		ch <- prometheus.MustNewConstMetric(configInfoDesc, prometheus.GaugeValue, 1, snapshot.ConfigVersion)
+	}
+	counter(configUpdatesDesc, snapshot.ConfigUpdatesApplied, "applied")
+	counter(configUpdatesDesc, snapshot.ConfigUpdatesRejected, "rejected")
+
+	for stage, hs := range snapshot.Stages {
+		buckets := make(map[float64]uint64, len(hs.Buckets))
+		for _, bucket := range hs.Buckets {
+			buckets[bucket.UpperBound] = bucket.Count
+		}
+		ch <- prometheus.MustNewConstHistogram(stageDurationDesc, hs.Count, hs.SumSeconds, buckets, stage)
+	}
+
+	for route, rs := range snapshot.Routes {
+		counter(routeRequestsDesc, rs.Requests, route)
+		counter(routeFailuresDesc, rs.Failures, route)
+	}
+
+	for service, ss := range snapshot.Services {
+		counter(serviceRequestsDesc, ss.Requests, service)
+		counter(serviceFailuresDesc, ss.Failures, service)
+	}
+
+	for _, ts := range snapshot.Tags {
+		counter(tagRequestsDesc, ts.Requests, ts.Key, ts.Value)
+		counter(tagFailuresDesc, ts.Failures, ts.Key, ts.Value)
+	}
+}
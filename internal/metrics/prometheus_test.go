@@ -0,0 +1,51 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandlePrometheus_Histograms(t *testing.T) {
+	m := NewMetrics()
+	m.RecordRequest("get-quote", "market-data", 20*time.Millisecond, true)
+	m.RecordStage(StageBackend, 15*time.Millisecond)
+
+	instrumented := m.Instrument("get-quote", "market-data", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	instrumented.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/market-data/AAPL", nil))
+
+	rec := httptest.NewRecorder()
+	NewHandler(m).HandlePrometheus(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+
+	for _, want := range []string{
+		`gateway_request_duration_seconds_count{code="429",route="get-quote",service="market-data"} 1`,
+		`gateway_service_duration_seconds_bucket{service="market-data",le="0.025"} 1`,
+		`gateway_stage_duration_seconds_bucket{stage="backend",le="0.025"} 1`,
+		`gateway_requests_in_flight{route="get-quote"} 0`,
+		`gateway_route_requests_total{route="get-quote"} 1`,
+		`gateway_requests_total 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in /metrics output", want)
+		}
+	}
+}
+
+func TestResetRoute_DropsHistogramSeries(t *testing.T) {
+	m := NewMetrics()
+	handler := m.Instrument("get-quote", "market-data", http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	m.ResetRoute("get-quote")
+
+	rec := httptest.NewRecorder()
+	NewHandler(m).HandlePrometheus(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if strings.Contains(rec.Body.String(), "gateway_request_duration_seconds_count") {
+		t.Error("expected route histogram series to be removed by ResetRoute")
+	}
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Request pipeline stages timed individually so latency regressions can be
+// attributed to the subsystem that caused them
+const (
+	StageRouting   = "routing"    // Route lookup
+	StageAuth      = "auth"       // Credential validation
+	StageRateLimit = "rate_limit" // Rate limit checks
+	StageBinding   = "binding"    // Reading the body and building the gRPC request
+	StageBackend   = "backend"    // gRPC call to the backend service
+	StageMarshal   = "marshal"    // Encoding the response as JSON
+)
+
+// latencyBuckets are the histogram upper bounds in seconds, shared by the
+// stage histograms and the Prometheus request histograms
+var latencyBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histogram is a fixed-bucket latency histogram safe for concurrent use
+type Histogram struct {
+	buckets []atomic.Uint64 // per-bucket (non-cumulative) counts; the last one is +Inf
+	count   atomic.Uint64
+	sumNs   atomic.Uint64
+}
+
+// newHistogram creates a histogram with the stage buckets
+func newHistogram() *Histogram {
+	return &Histogram{buckets: make([]atomic.Uint64, len(latencyBuckets)+1)}
+}
+
+// Observe records a duration
+func (h *Histogram) Observe(d time.Duration) {
+	seconds := d.Seconds()
+	i := 0
+	for i < len(latencyBuckets) && seconds > latencyBuckets[i] {
+		i++
+	}
+	h.buckets[i].Add(1)
+	h.count.Add(1)
+	h.sumNs.Add(uint64(d.Nanoseconds()))
+}
+
+// HistogramBucket is a cumulative bucket count
+type HistogramBucket struct {
+	UpperBound float64 // seconds
+	Count      uint64
+}
+
+// HistogramSnapshot is a point-in-time view of a histogram
+type HistogramSnapshot struct {
+	Count      uint64
+	SumSeconds float64
+	AvgMs      float64
+	Buckets    []HistogramBucket // cumulative, excluding +Inf (equal to Count)
+}
+
+// snapshot returns the histogram with cumulative bucket counts
+func (h *Histogram) snapshot() HistogramSnapshot {
+	s := HistogramSnapshot{
+		Count:      h.count.Load(),
+		SumSeconds: float64(h.sumNs.Load()) / float64(time.Second),
+		Buckets:    make([]HistogramBucket, len(latencyBuckets)),
+	}
+
+	var cumulative uint64
+	for i, bound := range latencyBuckets {
+		cumulative += h.buckets[i].Load()
+		s.Buckets[i] = HistogramBucket{UpperBound: bound, Count: cumulative}
+	}
+
+	if s.Count > 0 {
+		s.AvgMs = s.SumSeconds * 1000 / float64(s.Count)
+	}
+	return s
+}
+
+// RecordStage records the time spent in a pipeline stage
+func (m *Metrics) RecordStage(stage string, d time.Duration) {
+	value, ok := m.stageLatency.Load(stage)
+	if !ok {
+		value, _ = m.stageLatency.LoadOrStore(stage, newHistogram())
+	}
+	value.(*Histogram).Observe(d)
+}
+
+// collectStages snapshots every stage histogram
+func (m *Metrics) collectStages() map[string]HistogramSnapshot {
+	stages := make(map[string]HistogramSnapshot)
+	m.stageLatency.Range(func(key, value interface{}) bool {
+		stages[key.(string)] = value.(*Histogram).snapshot()
+		return true
+	})
+	return stages
+}
@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Windows are the rolling windows metrics can be queried over (?window=)
+var Windows = map[string]time.Duration{
+	"1m": time.Minute,
+	"5m": 5 * time.Minute,
+	"1h": time.Hour,
+}
+
+const (
+	windowBucketWidth = 10 * time.Second
+	windowBucketCount = int(time.Hour / windowBucketWidth) // Enough buckets for the longest window
+)
+
+// windowBucket counts the requests of one bucket-width slot
+type windowBucket struct {
+	slot      int64 // Unix time / bucket width; identifies which slot the counts belong to
+	requests  uint64
+	successes uint64
+	failures  uint64
+	latencyMs uint64
+}
+
+// windowTotals are request counts summed over a window
+type windowTotals struct {
+	requests  uint64
+	successes uint64
+	failures  uint64
+	latencyMs uint64
+	last      time.Time // Start of the most recent slot with traffic
+}
+
+// rollingWindow keeps the last hour of request counts in 10 second buckets
+type rollingWindow struct {
+	mu      sync.Mutex
+	buckets [windowBucketCount]windowBucket
+}
+
+// record adds a request to the bucket for now, recycling the bucket if it
+// still holds counts from an hour ago
+func (w *rollingWindow) record(now time.Time, latency time.Duration, success bool) {
+	slot := now.Unix() / int64(windowBucketWidth.Seconds())
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	bucket := &w.buckets[slot%int64(windowBucketCount)]
+	if bucket.slot != slot {
+		*bucket = windowBucket{slot: slot}
+	}
+	bucket.requests++
+	if success {
+		bucket.successes++
+	} else {
+		bucket.failures++
+	}
+	bucket.latencyMs += uint64(latency.Milliseconds())
+}
+
+// sum adds up the buckets covering the last window (including the current, partial one)
+func (w *rollingWindow) sum(now time.Time, window time.Duration) windowTotals {
+	width := int64(windowBucketWidth.Seconds())
+	current := now.Unix() / width
+	oldest := current - int64(window/windowBucketWidth) + 1
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var totals windowTotals
+	for slot := oldest; slot <= current; slot++ {
+		bucket := w.buckets[slot%int64(windowBucketCount)]
+		if bucket.slot != slot || bucket.requests == 0 {
+			continue
+		}
+		totals.requests += bucket.requests
+		totals.successes += bucket.successes
+		totals.failures += bucket.failures
+		totals.latencyMs += bucket.latencyMs
+		totals.last = time.Unix(slot*width, 0)
+	}
+	return totals
+}
+
+// avgLatency returns the average latency in milliseconds
+func (t windowTotals) avgLatency() float64 {
+	if t.requests == 0 {
+		return 0
+	}
+	return float64(t.latencyMs) / float64(t.requests)
+}
+
+// WindowSnapshot represents request metrics over a rolling window
+type WindowSnapshot struct {
+	Window             string
+	TotalRequests      uint64
+	SuccessfulRequests uint64
+	FailedRequests     uint64
+	SuccessRate        float64
+	AvgLatencyMs       float64
+	RequestsPerSecond  float64
+	Routes             map[string]RouteSnapshot
+	Services           map[string]ServiceSnapshot
+}
+
+// GetWindowSnapshot returns request metrics over one of the Windows ("1m", "5m", "1h").
+// Routes and services without traffic in the window are omitted.
+func (m *Metrics) GetWindowSnapshot(window string) (WindowSnapshot, error) {
+	duration, ok := Windows[window]
+	if !ok {
+		return WindowSnapshot{}, fmt.Errorf("unknown window %q (use 1m, 5m or 1h)", window)
+	}
+
+	now := time.Now()
+	totals := m.window.sum(now, duration)
+
+	snapshot := WindowSnapshot{
+		Window:             window,
+		TotalRequests:      totals.requests,
+		SuccessfulRequests: totals.successes,
+		FailedRequests:     totals.failures,
+		AvgLatencyMs:       totals.avgLatency(),
+		Routes:             make(map[string]RouteSnapshot),
+		Services:           make(map[string]ServiceSnapshot),
+	}
+	if totals.requests > 0 {
+		snapshot.SuccessRate = float64(totals.successes) / float64(totals.requests) * 100
+	}
+
+	// A window longer than the uptime would understate the request rate
+	elapsed := duration
+	if uptime := now.Sub(m.startTime); uptime < elapsed {
+		elapsed = uptime
+	}
+	if elapsed > 0 {
+		snapshot.RequestsPerSecond = float64(totals.requests) / elapsed.Seconds()
+	}
+
+	m.routeMetrics.Range(func(key, value interface{}) bool {
+		rt := value.(*RouteMetrics).window.sum(now, duration)
+		if rt.requests > 0 {
+			snapshot.Routes[key.(string)] = RouteSnapshot{
+				Requests:      rt.requests,
+				Successes:     rt.successes,
+				Failures:      rt.failures,
+				AvgLatencyMs:  rt.avgLatency(),
+				LastRequestAt: rt.last,
+			}
+		}
+		return true
+	})
+
+	m.serviceMetrics.Range(func(key, value interface{}) bool {
+		st := value.(*ServiceMetrics).window.sum(now, duration)
+		if st.requests > 0 {
+			snapshot.Services[key.(string)] = ServiceSnapshot{
+				Requests:     st.requests,
+				Successes:    st.successes,
+				Failures:     st.failures,
+				AvgLatencyMs: st.avgLatency(),
+			}
+		}
+		return true
+	})
+
+	return snapshot, nil
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+
+	"hub-api-gateway/internal/audit"
+	"hub-api-gateway/internal/logging"
+	"hub-api-gateway/internal/respwriter"
+	"hub-api-gateway/internal/router"
+)
+
+// anonymousActor is the audit actor of requests without an authenticated user
+const anonymousActor = "anonymous"
+
+// auditActorKey is the context key of the request's *auditActor
+type auditActorKey struct{}
+
+// auditActor carries the authenticated user out to Audit, which wraps
+// authentication so that rejected credentials are recorded too
+type auditActor struct {
+	userID string
+}
+
+// setAuditActor records the authenticated user for Audit, if it wraps the request
+func setAuditActor(ctx context.Context, userID string) {
+	if actor, ok := ctx.Value(auditActorKey{}).(*auditActor); ok {
+		actor.userID = userID
+	}
+}
+
+// Audit records every request to a route with an audit action in the audit
+// log: who sent it (the authenticated user, "anonymous" when authentication
+// failed), what it targeted (method, path and path variables) and its result,
+// a failure when the response status is 4xx or 5xx. Routes without an audit
+// action get next unchanged.
+func Audit(logger *audit.Logger, route *router.Route, next http.Handler) http.Handler {
+	if logger == nil || route.Audit == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authenticated := &auditActor{}
+		if userContext, ok := GetUserContext(r.Context()); ok {
+			authenticated.userID = userContext.UserID
+		}
+		recorder := respwriter.New(w)
+		next.ServeHTTP(recorder, r.WithContext(context.WithValue(r.Context(), auditActorKey{}, authenticated)))
+
+		actor := anonymousActor
+		if authenticated.userID != "" {
+			actor = authenticated.userID
+		}
+		result := audit.ResultSuccess
+		if recorder.Status() >= http.StatusBadRequest {
+			result = audit.ResultFailure
+		}
+
+		details := map[string]string{
+			"route":  route.Name,
+			"method": r.Method,
+			"status": strconv.Itoa(recorder.Status()),
+		}
+		if vars, ok := router.PathVarsFromContext(r.Context()); ok {
+			for name, value := range vars {
+				details["path."+name] = value
+			}
+		}
+
+		logger.Log(audit.Event{
+			Actor:      actor,
+			Action:     route.Audit,
+			Resource:   r.URL.Path,
+			Result:     result,
+			RemoteAddr: r.RemoteAddr,
+			RequestID:  logging.RequestID(r.Context()),
+			Details:    details,
+		})
+	})
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hub-api-gateway/internal/audit"
+	"hub-api-gateway/internal/auth"
+	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/router"
+)
+
+func TestAudit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, _, err := audit.NewFileSink(path)
+	if err != nil {
+		t.Fatalf("failed to create sink: %v", err)
+	}
+	signer, _ := audit.NewSigner(map[string]string{"k1": "secret"}, "k1")
+	logger := audit.NewLogger(sink, signer, nil)
+
+	providers := auth.NewProviderRegistry()
+	providers.Register(auth.NewAPIKeyProvider(map[string]string{"key-1": "u1"}))
+	providers.SetDefault(auth.CredentialAPIKey, auth.ProviderAPIKey)
+	authMiddleware := NewAuthMiddleware(providers, nil, &config.Config{}, nil)
+
+	// Audit wraps authentication, so rejected credentials are recorded too
+	route := &router.Route{Name: "cancel-order", Audit: "order.cancel"}
+	handler := Audit(logger, route, authMiddleware.MiddlewareFor("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Fail") != "" {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		w.Write([]byte(`{}`))
+	})))
+
+	for _, header := range []string{"X-Anonymous", "X-Succeed", "X-Fail"} {
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/orders/42/cancel", nil)
+		req.Header.Set(header, "1")
+		if header != "X-Anonymous" {
+			req.Header.Set("X-API-Key", "key-1")
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req.WithContext(router.WithPathVars(req.Context(), map[string]string{"id": "42"})))
+	}
+	logger.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read audit log: %v", err)
+	}
+	report := audit.Verify(strings.NewReader(string(data)), signer)
+	if !report.Valid() || report.Records != 3 {
+		t.Fatalf("unexpected audit log: %+v", report)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	for i, want := range [][2]string{
+		{`"actor":"anonymous"`, `"status":"401"`},
+		{`"actor":"u1"`, `"result":"success"`},
+		{`"actor":"u1"`, `"result":"failure"`},
+	} {
+		for _, field := range []string{want[0], `"action":"order.cancel"`, `"path.id":"42"`, want[1]} {
+			if !strings.Contains(lines[i], field) {
+				t.Errorf("record %d lacks %s: %s", i+1, field, lines[i])
+			}
+		}
+	}
+}
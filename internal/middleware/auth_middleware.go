@@ -5,73 +5,205 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
 	"time"
 
 	"hub-api-gateway/internal/auth"
 	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/httperr"
 	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/trace"
 
 	"github.com/redis/go-redis/v9"
 )
 
 // UserContext contains validated user information
 type UserContext struct {
-	UserID string `json:"userId"`
-	Email  string `json:"email"`
+	UserID   string   `json:"userId"`
+	Email    string   `json:"email"`
+	Roles    []string `json:"roles,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
+	Provider string   `json:"provider,omitempty"`
+	TenantID string   `json:"tenantId,omitempty"` // Tenant claimed by the token
 }
 
-// AuthMiddleware handles JWT token validation
+// AuthMiddleware handles credential validation through pluggable providers
 type AuthMiddleware struct {
-	userClient  *auth.UserServiceClient
+	providers   *auth.ProviderRegistry
 	redisClient *redis.Client
 	config      *config.Config
 	metrics     *metrics.Metrics
+	sessions    *auth.SessionLimiter
+	webSessions *auth.WebSessions
 }
 
+// errWebSessionUnavailable is returned when the web session store can't be read
+var errWebSessionUnavailable = errors.New("web session store unavailable")
+
 // NewAuthMiddleware creates a new authentication middleware
-func NewAuthMiddleware(userClient *auth.UserServiceClient, redisClient *redis.Client, cfg *config.Config, m *metrics.Metrics) *AuthMiddleware {
+func NewAuthMiddleware(providers *auth.ProviderRegistry, redisClient *redis.Client, cfg *config.Config, m *metrics.Metrics) *AuthMiddleware {
 	return &AuthMiddleware{
-		userClient:  userClient,
+		providers:   providers,
 		redisClient: redisClient,
 		config:      cfg,
 		metrics:     m,
 	}
 }
 
-// Middleware returns an HTTP middleware function for token validation
+// SetSessionLimiter rejects bearer tokens whose session was evicted by a newer login
+func (m *AuthMiddleware) SetSessionLimiter(limiter *auth.SessionLimiter) {
+	m.sessions = limiter
+}
+
+// SetWebSessions accepts session cookies issued at login, with CSRF
+// double-submit protection of mutating requests, besides bearer tokens
+func (m *AuthMiddleware) SetWebSessions(sessions *auth.WebSessions) {
+	m.webSessions = sessions
+}
+
+// Middleware returns an HTTP middleware function that selects the provider by credential type
 func (m *AuthMiddleware) Middleware(next http.Handler) http.Handler {
+	return m.MiddlewareFor("", next)
+}
+
+// MiddlewareFor returns an HTTP middleware function that validates credentials with
+// the named provider (a route's auth_provider), falling back to the credential type default
+func (m *AuthMiddleware) MiddlewareFor(providerName string, next http.Handler) http.Handler {
+	return m.middleware(providerName, false, false, next)
+}
+
+// WebSocketMiddlewareFor is MiddlewareFor for WebSocket routes, which also
+// accept a reconnect ticket when the upgrade request has no Authorization
+// header: browsers can't set headers on WebSockets
+func (m *AuthMiddleware) WebSocketMiddlewareFor(providerName string, next http.Handler) http.Handler {
+	return m.middleware(providerName, false, true, next)
+}
+
+// RevocationCheckMiddleware is MiddlewareFor for revocation-sensitive routes:
+// bearer tokens are validated by the User Service on every request, skipping
+// local validation and the token cache, so revoked tokens are refused at once
+func (m *AuthMiddleware) RevocationCheckMiddleware(providerName string, next http.Handler) http.Handler {
+	return m.middleware(providerName, true, false, next)
+}
+
+// middleware validates credentials, remotely and uncached when revocationCheck
+// is set, and accepts reconnect tickets when tickets is set
+func (m *AuthMiddleware) middleware(providerName string, revocationCheck, tickets bool, next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		token, err := m.extractToken(r)
+		requestTrace := trace.FromContext(r.Context())
+
+		credential, err := m.extractCredential(r, providerName, tickets)
 		if err != nil {
-			log.Printf("❌ Token extraction failed: %v", err)
-			m.sendErrorResponse(w, http.StatusUnauthorized, "AUTH_TOKEN_MISSING", "Authorization token is required")
+			slog.WarnContext(r.Context(), "token extraction failed", "error", err)
+			requestTrace.Record(metrics.StageAuth, "credential missing", 0, map[string]string{"error": err.Error()})
+			switch {
+			case errors.Is(err, auth.ErrCSRFTokenInvalid):
+				m.sendErrorResponse(w, r, http.StatusForbidden, "CSRF_TOKEN_INVALID", "Missing or invalid X-CSRF-Token header")
+			case errors.Is(err, auth.ErrWebSessionInvalid):
+				m.sendErrorResponse(w, r, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Session expired or invalid")
+			case errors.Is(err, errWebSessionUnavailable):
+				m.sendErrorResponse(w, r, http.StatusServiceUnavailable, "AUTH_SERVICE_UNAVAILABLE", "Sessions are temporarily unavailable")
+			default:
+				m.sendErrorResponse(w, r, http.StatusUnauthorized, "AUTH_TOKEN_MISSING", "Authorization token is required")
+			}
 			return
 		}
 
-		userContext, err := m.ValidateToken(r.Context(), token)
+		authStart := time.Now()
+		var userContext *UserContext
+		if revocationCheck && credential.Type == auth.CredentialBearer {
+			userContext, err = m.authenticateRemote(r.Context(), providerName, credential)
+		} else {
+			userContext, err = m.Authenticate(r.Context(), providerName, credential)
+		}
+		if m.metrics != nil {
+			m.metrics.RecordStage(metrics.StageAuth, time.Since(authStart))
+		}
 		if err != nil {
-			log.Printf("❌ Token validation failed: %v", err)
-			m.sendErrorResponse(w, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Token expired or invalid")
+			slog.WarnContext(r.Context(), "token validation failed", "credential", string(credential.Type), "error", err)
+			requestTrace.Record(metrics.StageAuth, "credential rejected", time.Since(authStart), map[string]string{
+				"credential": string(credential.Type),
+				"error":      err.Error(),
+			})
+			if errors.Is(err, auth.ErrSessionRevoked) {
+				m.sendErrorResponse(w, r, http.StatusUnauthorized, "AUTH_SESSION_REVOKED", "Session ended by a newer login")
+				return
+			}
+			m.sendErrorResponse(w, r, http.StatusUnauthorized, "AUTH_TOKEN_INVALID", "Token expired or invalid")
 			return
 		}
+		requestTrace.Record(metrics.StageAuth, "authenticated", time.Since(authStart), map[string]string{
+			"credential": string(credential.Type),
+			"provider":   userContext.Provider,
+			"userId":     userContext.UserID,
+		})
 
 		// Add user context to request
 		ctx := context.WithValue(r.Context(), "user", userContext)
+		setAuditActor(ctx, userContext.UserID)
 
 		// Add user context to request headers for downstream services
 		r.Header.Set("X-User-ID", userContext.UserID)
 		r.Header.Set("X-User-Email", userContext.Email)
 
-		log.Printf("✅ Token validated for user: %s (%s)", userContext.Email, userContext.UserID)
+		slog.InfoContext(r.Context(), "token validated", "user_id", userContext.UserID, "email", userContext.Email, "provider", userContext.Provider)
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
+// extractCredential extracts an API key from X-API-Key, a JWT from the
+// Authorization header (or the session cookie standing for one), or the client
+// certificate for mtls routes. When tickets is set and there is no
+// Authorization header, a reconnect ticket from X-Reconnect-Ticket (or ?ticket=
+// for WebSocket clients that can't set headers) is accepted too.
+func (m *AuthMiddleware) extractCredential(r *http.Request, providerName string, tickets bool) (auth.Credential, error) {
+	// mtls routes only accept the client certificate verified by the internal listener
+	if providerName == auth.ProviderMTLS {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			return auth.Credential{}, fmt.Errorf("verified client certificate required")
+		}
+		return auth.Credential{Type: auth.CredentialClientCert, Certificate: r.TLS.VerifiedChains[0][0]}, nil
+	}
+
+	if apiKey := strings.TrimSpace(r.Header.Get("X-API-Key")); apiKey != "" {
+		return auth.Credential{Type: auth.CredentialAPIKey, Value: apiKey}, nil
+	}
+
+	if tickets && r.Header.Get("Authorization") == "" {
+		ticket := strings.TrimSpace(r.Header.Get("X-Reconnect-Ticket"))
+		if ticket == "" {
+			ticket = strings.TrimSpace(r.URL.Query().Get("ticket"))
+		}
+		if ticket != "" {
+			return auth.Credential{Type: auth.CredentialTicket, Value: ticket}, nil
+		}
+	}
+
+	if m.webSessions != nil && r.Header.Get("Authorization") == "" {
+		token, err := m.webSessions.Token(r)
+		if err != nil {
+			if errors.Is(err, auth.ErrWebSessionInvalid) || errors.Is(err, auth.ErrCSRFTokenInvalid) {
+				return auth.Credential{}, err
+			}
+			return auth.Credential{}, fmt.Errorf("%w: %w", errWebSessionUnavailable, err)
+		}
+		if token != "" {
+			return auth.Credential{Type: auth.CredentialBearer, Value: token}, nil
+		}
+	}
+
+	token, err := m.extractToken(r)
+	if err != nil {
+		return auth.Credential{}, err
+	}
+	return auth.Credential{Type: auth.CredentialBearer, Value: token}, nil
+}
+
 // extractToken extracts JWT token from Authorization header
 func (m *AuthMiddleware) extractToken(r *http.Request) (string, error) {
 	authHeader := r.Header.Get("Authorization")
@@ -96,69 +228,163 @@ func (m *AuthMiddleware) extractToken(r *http.Request) (string, error) {
 	return token, nil
 }
 
-// ValidateToken validates a JWT token using cache-first strategy
+// ValidateToken validates a bearer token with the default provider
 func (m *AuthMiddleware) ValidateToken(ctx context.Context, token string) (*UserContext, error) {
-	tokenHash := hashToken(token)
-	cacheKey := fmt.Sprintf("token_valid:%s", tokenHash)
+	return m.Authenticate(ctx, "", auth.Credential{Type: auth.CredentialBearer, Value: token})
+}
+
+// Authenticate validates a credential using cache-first strategy and the selected provider
+func (m *AuthMiddleware) Authenticate(ctx context.Context, providerName string, credential auth.Credential) (*UserContext, error) {
+	// Reconnect tickets are issued by the gateway itself, whatever provider
+	// authenticated the original connection
+	if credential.Type == auth.CredentialTicket {
+		providerName = ""
+	}
+	provider, err := m.providers.Resolve(providerName, credential.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	// Tickets and client certificates are verified locally; caching them would only add Redis round-trips
+	if credential.VerifiedLocally() {
+		principal, err := provider.Validate(ctx, credential)
+		if err != nil {
+			return nil, err
+		}
+		return userContextFromPrincipal(principal), nil
+	}
+
+	// Evicted sessions must be refused even while their validation is cached
+	if err := m.checkSession(ctx, credential); err != nil {
+		return nil, err
+	}
+
+	// Locally validated tokens (jwt, oidc) are cheaper to verify than to look up
+	if auth.ValidatesLocally(provider) {
+		principal, err := provider.Validate(ctx, credential)
+		if err != nil {
+			return nil, err
+		}
+		return userContextFromPrincipal(principal), nil
+	}
+
+	tokenHash := hashToken(credential.Value)
+	cacheKey := fmt.Sprintf("token_valid:%s:%s", provider.Name(), tokenHash)
 
 	if m.redisClient != nil {
 		cachedUser, err := m.getFromCache(ctx, cacheKey)
 		if err == nil && cachedUser != nil {
-			log.Printf("🚀 Token validation cache HIT for user: %s", cachedUser.Email)
+			slog.DebugContext(ctx, "token validation cache hit", "user_id", cachedUser.UserID)
 			if m.metrics != nil {
 				m.metrics.RecordCacheHit()
 			}
 			return cachedUser, nil
 		}
 		if err != nil && err != redis.Nil {
-			log.Printf("⚠️  Redis error (continuing without cache): %v", err)
+			slog.WarnContext(ctx, "token cache unavailable, continuing without cache", "error", err)
 		}
 	}
 
-	log.Printf("📞 Token validation cache MISS, calling User Service...")
+	slog.DebugContext(ctx, "token validation cache miss", "provider", provider.Name())
 	if m.metrics != nil {
 		m.metrics.RecordCacheMiss()
 	}
 
-	userContext, err := m.validateTokenWithUserService(ctx, token)
+	principal, err := provider.Validate(ctx, credential)
 	if err != nil {
 		return nil, err
 	}
 
+	userContext := userContextFromPrincipal(principal)
+
 	if m.redisClient != nil {
 		if err := m.saveToCache(ctx, cacheKey, userContext, 5*time.Minute); err != nil {
-			log.Printf("⚠️  Failed to cache token validation: %v", err)
+			slog.WarnContext(ctx, "failed to cache token validation", "error", err)
 		} else {
-			log.Printf("💾 Cached token validation for user: %s", userContext.Email)
+			slog.DebugContext(ctx, "cached token validation", "user_id", userContext.UserID)
 		}
 	}
 
 	return userContext, nil
 }
 
-// validateTokenWithUserService calls user service gRPC to validate token
-func (m *AuthMiddleware) validateTokenWithUserService(ctx context.Context, token string) (*UserContext, error) {
-	resp, err := m.userClient.ValidateToken(ctx, token)
+// authenticateRemote validates a bearer token with the User Service (or the
+// route's remote provider) without the token cache
+func (m *AuthMiddleware) authenticateRemote(ctx context.Context, providerName string, credential auth.Credential) (*UserContext, error) {
+	if providerName == "" {
+		providerName = auth.ProviderUserService
+	}
+	provider, err := m.providers.Get(providerName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to validate token with user service: %w", err)
+		return nil, err
+	}
+
+	if err := m.checkSession(ctx, credential); err != nil {
+		return nil, err
 	}
 
-	if !resp.ApiResponse.Success {
-		return nil, fmt.Errorf("token validation failed: %s", resp.ApiResponse.Message)
+	principal, err := provider.Validate(ctx, credential)
+	if err != nil {
+		return nil, err
 	}
+	return userContextFromPrincipal(principal), nil
+}
 
-	if resp.UserInfo == nil {
-		return nil, fmt.Errorf("user info not found in response")
+// checkSession refuses bearer tokens whose session was evicted by a newer login
+func (m *AuthMiddleware) checkSession(ctx context.Context, credential auth.Credential) error {
+	if m.sessions == nil || credential.Type != auth.CredentialBearer {
+		return nil
+	}
+	if err := m.sessions.Check(ctx, credential.Value); err != nil {
+		if errors.Is(err, auth.ErrSessionRevoked) {
+			return err
+		}
+		slog.WarnContext(ctx, "session registry unavailable, skipping revocation check", "error", err)
 	}
+	return nil
+}
 
-	if resp.UserInfo.UserId == "" || resp.UserInfo.Email == "" {
-		return nil, fmt.Errorf("invalid user context from service")
+// AuthenticatePrincipal is Authenticate for callers that work with auth principals
+// (e.g. the token introspection endpoint)
+func (m *AuthMiddleware) AuthenticatePrincipal(ctx context.Context, providerName string, credential auth.Credential) (*auth.Principal, error) {
+	userContext, err := m.Authenticate(ctx, providerName, credential)
+	if err != nil {
+		return nil, err
 	}
+	return principalFromUserContext(userContext), nil
+}
 
+// userContextFromPrincipal converts a provider principal to a user context
+func userContextFromPrincipal(principal *auth.Principal) *UserContext {
 	return &UserContext{
-		UserID: resp.UserInfo.UserId,
-		Email:  resp.UserInfo.Email,
-	}, nil
+		UserID:   principal.UserID,
+		Email:    principal.Email,
+		Roles:    principal.Roles,
+		Scopes:   principal.Scopes,
+		Provider: principal.Provider,
+		TenantID: principal.TenantID,
+	}
+}
+
+// PrincipalFromRequest returns the principal authenticated by the middleware
+func PrincipalFromRequest(r *http.Request) (*auth.Principal, bool) {
+	userContext, ok := GetUserContext(r.Context())
+	if !ok {
+		return nil, false
+	}
+	return principalFromUserContext(userContext), true
+}
+
+// principalFromUserContext converts a user context back to a principal
+func principalFromUserContext(userContext *UserContext) *auth.Principal {
+	return &auth.Principal{
+		UserID:   userContext.UserID,
+		Email:    userContext.Email,
+		Roles:    userContext.Roles,
+		Scopes:   userContext.Scopes,
+		Provider: userContext.Provider,
+		TenantID: userContext.TenantID,
+	}
 }
 
 // getFromCache retrieves cached user context
@@ -193,16 +419,8 @@ func hashToken(token string) string {
 }
 
 // sendErrorResponse sends a JSON error response
-func (m *AuthMiddleware) sendErrorResponse(w http.ResponseWriter, statusCode int, errorCode, message string) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	response := map[string]interface{}{
-		"error": message,
-		"code":  errorCode,
-	}
-
-	json.NewEncoder(w).Encode(response)
+func (m *AuthMiddleware) sendErrorResponse(w http.ResponseWriter, r *http.Request, statusCode int, errorCode, message string) {
+	httperr.Send(w, r, statusCode, errorCode, message)
 }
 
 // GetUserContext extracts user context from request context
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/auth"
+	"hub-api-gateway/internal/config"
+)
+
+func TestWebSocketMiddlewareFor_TicketOnProviderRoute(t *testing.T) {
+	issuer := auth.NewTicketIssuer("ticket-secret", time.Minute, nil)
+	providers := auth.NewProviderRegistry()
+	providers.Register(auth.NewJWTProvider("jwt-secret", ""))
+	providers.Register(issuer)
+	providers.SetDefault(auth.CredentialTicket, auth.ProviderTicket)
+
+	authMiddleware := NewAuthMiddleware(providers, nil, &config.Config{}, nil)
+	handler := authMiddleware.WebSocketMiddlewareFor(auth.ProviderJWT, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userContext, _ := GetUserContext(r.Context())
+		w.Header().Set("X-Provider", userContext.Provider)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	ticket, _, err := issuer.Issue(&auth.Principal{UserID: "u1", Email: "u1@example.com"})
+	if err != nil {
+		t.Fatalf("failed to issue ticket: %v", err)
+	}
+
+	// A route pinned to jwt still accepts the gateway's reconnect tickets
+	req := httptest.NewRequest(http.MethodGet, "/ws/quotes?ticket="+ticket, nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent || rec.Header().Get("X-Provider") != auth.ProviderTicket {
+		t.Errorf("expected the ticket to authenticate but got %d (provider %q)", rec.Code, rec.Header().Get("X-Provider"))
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/ws/quotes?ticket=forged", nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("expected a forged ticket to be refused but got %d", rec.Code)
+	}
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"hub-api-gateway/internal/router"
+)
+
+func TestAuthorize(t *testing.T) {
+	route := &router.Route{Name: "cancel-order", RequiredRoles: []string{"trader", "admin"}, RequiredScopes: []string{"orders:read", "orders:write"}}
+	handler := Authorize(route, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	serve := func(userContext *UserContext) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("DELETE", "/api/v1/orders/1", nil)
+		if userContext != nil {
+			req = req.WithContext(context.WithValue(req.Context(), "user", userContext))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// One of the roles and all of the scopes are needed
+	if rec := serve(&UserContext{UserID: "u1", Roles: []string{"admin"}, Scopes: []string{"orders:write", "orders:read"}}); rec.Code != http.StatusNoContent {
+		t.Errorf("authorized user got %d", rec.Code)
+	}
+
+	rec := serve(&UserContext{UserID: "u2", Roles: []string{"viewer"}, Scopes: []string{"orders:read"}})
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("unauthorized user got %d, want 403", rec.Code)
+	}
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Details []struct {
+				RequiredRoles []string `json:"requiredRoles"`
+				MissingScopes []string `json:"missingScopes"`
+			} `json:"details"`
+		} `json:"error"`
+	}
+	json.NewDecoder(rec.Body).Decode(&body)
+	if body.Error.Code != "AUTH_FORBIDDEN" || len(body.Error.Details) != 1 ||
+		!reflect.DeepEqual(body.Error.Details[0].RequiredRoles, route.RequiredRoles) ||
+		!reflect.DeepEqual(body.Error.Details[0].MissingScopes, []string{"orders:write"}) {
+		t.Errorf("unexpected error body: %+v", body)
+	}
+
+	if rec := serve(nil); rec.Code != http.StatusForbidden {
+		t.Errorf("anonymous request got %d, want 403", rec.Code)
+	}
+}
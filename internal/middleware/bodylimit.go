@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"hub-api-gateway/internal/httperr"
+)
+
+// limitedBody is a request body capped by MaxBytesReader that remembers the
+// uncapped body, so a route can replace the gateway-wide limit with its own
+type limitedBody struct {
+	io.ReadCloser
+	original io.ReadCloser
+}
+
+// BodyLimit caps request bodies at max bytes: reading past it fails with
+// *http.MaxBytesError. Routes replace the cap with LimitBody, so a larger
+// declared Content-Length isn't rejected here. A non-positive max disables it.
+func BodyLimit(max int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return limitBody(max, false, next)
+	}
+}
+
+// LimitBody caps the request body at limit bytes, replacing any limit set
+// before (e.g. a route's max_body_size overriding MAX_BODY_SIZE). Requests
+// declaring a larger Content-Length get 413 PAYLOAD_TOO_LARGE at once.
+func LimitBody(limit int64, next http.Handler) http.Handler {
+	return limitBody(limit, true, next)
+}
+
+// limitBody wraps the body in a MaxBytesReader over the uncapped body
+func limitBody(limit int64, rejectDeclared bool, next http.Handler) http.Handler {
+	if limit <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rejectDeclared && r.ContentLength > limit {
+			SendPayloadTooLarge(w, r, limit)
+			return
+		}
+
+		if r.Body != nil && r.Body != http.NoBody {
+			original := r.Body
+			if limited, ok := original.(*limitedBody); ok {
+				original = limited.original
+			}
+			r.Body = &limitedBody{ReadCloser: http.MaxBytesReader(w, original, limit), original: original}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// SendPayloadTooLarge sends 413 PAYLOAD_TOO_LARGE for a body over limit bytes
+func SendPayloadTooLarge(w http.ResponseWriter, r *http.Request, limit int64) {
+	w.Header().Set("Connection", "close")
+	httperr.Send(w, r, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", fmt.Sprintf("Request body exceeds %d bytes", limit))
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLimitBody(t *testing.T) {
+	var readErr error
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, readErr = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	// Declared lengths over the limit are rejected before the handler runs
+	handler := LimitBody(10, echo)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/api/v1/orders", strings.NewReader(strings.Repeat("x", 11))))
+	if rec.Code != http.StatusRequestEntityTooLarge || !strings.Contains(rec.Body.String(), "PAYLOAD_TOO_LARGE") {
+		t.Errorf("oversized request got %d %s, want 413", rec.Code, rec.Body)
+	}
+
+	// Bodies fail when read past the limit, whatever their declared length
+	handler = BodyLimit(10)(echo)
+	req := httptest.NewRequest("POST", "/api/v1/orders", strings.NewReader(strings.Repeat("x", 11)))
+	req.ContentLength = -1
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	var tooLarge *http.MaxBytesError
+	if !errors.As(readErr, &tooLarge) {
+		t.Errorf("expected MaxBytesError reading a chunked body, got %v", readErr)
+	}
+
+	// A route limit replaces the gateway-wide one, even when larger
+	handler = BodyLimit(10)(LimitBody(20, echo))
+	req = httptest.NewRequest("POST", "/api/v1/statements", strings.NewReader(strings.Repeat("x", 15)))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent || readErr != nil {
+		t.Errorf("route limit not applied: %d, %v", rec.Code, readErr)
+	}
+}
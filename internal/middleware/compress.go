@@ -0,0 +1,239 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"hub-api-gateway/internal/router"
+)
+
+// NewEncoder creates a compressing writer over w
+type NewEncoder func(w io.Writer) io.WriteCloser
+
+// contentEncoding is a content coding the gateway can produce
+type contentEncoding struct {
+	name       string
+	newEncoder NewEncoder
+}
+
+// Compressor compresses JSON responses for clients that accept it. Bodies
+// under the minimum size are sent as they are, since compressing them saves
+// less than it costs.
+type Compressor struct {
+	minSize   int
+	encodings []contentEncoding // In order of preference
+}
+
+// NewCompressor creates a compressor offering gzip at level for responses of
+// at least minSize bytes
+func NewCompressor(minSize, level int) *Compressor {
+	if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+		level = gzip.DefaultCompression
+	}
+	pool := &sync.Pool{New: func() any {
+		writer, _ := gzip.NewWriterLevel(io.Discard, level)
+		return writer
+	}}
+
+	return &Compressor{
+		minSize: minSize,
+		encodings: []contentEncoding{{name: "gzip", newEncoder: func(w io.Writer) io.WriteCloser {
+			writer := pool.Get().(*gzip.Writer)
+			writer.Reset(w)
+			return &pooledGzip{Writer: writer, pool: pool}
+		}}},
+	}
+}
+
+// AddEncoding offers another content coding, e.g. br with a brotli encoder.
+// Encodings added later are preferred over earlier ones when the client
+// accepts both equally.
+func (c *Compressor) AddEncoding(name string, newEncoder NewEncoder) {
+	c.encodings = append([]contentEncoding{{name: strings.ToLower(name), newEncoder: newEncoder}}, c.encodings...)
+}
+
+// Middleware compresses the route's responses unless it opts out with
+// compress: false. Streaming routes are never compressed.
+func (c *Compressor) Middleware(route *router.Route, next http.Handler) http.Handler {
+	if route.Stream != "" || (route.Compress != nil && !*route.Compress) {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		encoding := c.negotiate(r.Header.Get("Accept-Encoding"))
+		if encoding == nil || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: c.minSize}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// negotiate picks the encoding the client weighs highest, preferring ours on ties
+func (c *Compressor) negotiate(acceptEncoding string) *contentEncoding {
+	if acceptEncoding == "" {
+		return nil
+	}
+
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(q, 64); err == nil {
+				weight = parsed
+			}
+		}
+		weights[strings.ToLower(strings.TrimSpace(name))] = weight
+	}
+
+	var best *contentEncoding
+	bestWeight := 0.0
+	for i := range c.encodings {
+		weight, ok := weights[c.encodings[i].name]
+		if !ok {
+			weight = weights["*"]
+		}
+		if weight > bestWeight {
+			best, bestWeight = &c.encodings[i], weight
+		}
+	}
+	return best
+}
+
+// compressible reports whether a content type is worth compressing
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || mediaType == "application/x-ndjson" ||
+		(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
+
+// compressWriter holds back the start of a response until it knows whether
+// the body is large enough to compress, then streams it through the encoder
+type compressWriter struct {
+	http.ResponseWriter
+	encoding *contentEncoding
+	minSize  int
+
+	status  int
+	buffer  bytes.Buffer
+	decided bool
+	encoder io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(statusCode int) {
+	if w.status == 0 {
+		w.status = statusCode
+	}
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	if !w.decided {
+		w.buffer.Write(b)
+		if w.buffer.Len() >= w.minSize {
+			if err := w.decide(true); err != nil {
+				return 0, err
+			}
+		}
+		return len(b), nil
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// decide starts the response, compressed when large and compressible
+func (w *compressWriter) decide(large bool) error {
+	w.decided = true
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	header := w.Header()
+	if large && header.Get("Content-Encoding") == "" && compressible(header.Get("Content-Type")) &&
+		w.status != http.StatusNoContent && w.status != http.StatusNotModified {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", w.encoding.name)
+		// The compressed bytes differ, so a strong validator can't be kept
+		if etag := header.Get("ETag"); strings.HasPrefix(etag, `"`) {
+			header.Set("ETag", "W/"+etag)
+		}
+		w.ResponseWriter.WriteHeader(w.status)
+		w.encoder = w.encoding.newEncoder(w.ResponseWriter)
+		_, err := w.encoder.Write(w.buffer.Bytes())
+		w.buffer.Reset()
+		return err
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.ResponseWriter.Write(w.buffer.Bytes())
+	w.buffer.Reset()
+	return err
+}
+
+// Flush sends what is buffered; a response flushed before reaching the
+// minimum size is sent uncompressed
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if flusher, ok := w.encoder.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Close finishes the response
+func (w *compressWriter) Close() error {
+	if !w.decided {
+		if w.status == 0 {
+			return nil // Nothing was written; leave the response to the server
+		}
+		return w.decide(false)
+	}
+	if w.encoder != nil {
+		return w.encoder.Close()
+	}
+	return nil
+}
+
+// Hijack hands the connection over, e.g. for WebSocket upgrades
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// pooledGzip returns its writer to the pool once closed
+type pooledGzip struct {
+	*gzip.Writer
+	pool *sync.Pool
+}
+
+func (p *pooledGzip) Close() error {
+	err := p.Writer.Close()
+	p.pool.Put(p.Writer)
+	return err
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"hub-api-gateway/internal/router"
+)
+
+func TestCompressor(t *testing.T) {
+	compressor := NewCompressor(64, gzip.BestSpeed)
+	large := `{"positions":[` + strings.Repeat(`{"symbol":"PETR4","quantity":100},`, 20) + `{}]}`
+
+	serve := func(route *router.Route, acceptEncoding, body string) *httptest.ResponseRecorder {
+		handler := compressor.Middleware(route, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("ETag", `"v1"`)
+			w.WriteHeader(http.StatusOK)
+			io.WriteString(w, body)
+		}))
+		req := httptest.NewRequest("GET", "/api/v1/positions", nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := serve(&router.Route{Name: "positions"}, "br;q=1.0, gzip;q=0.8", large)
+	if rec.Header().Get("Content-Encoding") != "gzip" || rec.Header().Get("ETag") != `W/"v1"` {
+		t.Fatalf("expected a gzip response with a weak ETag, got %v", rec.Header())
+	}
+	reader, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded, _ := io.ReadAll(reader); string(decoded) != large {
+		t.Errorf("decompressed body doesn't match: %s", decoded)
+	}
+
+	if rec := serve(&router.Route{Name: "positions"}, "gzip", `{"ok":true}`); rec.Header().Get("Content-Encoding") != "" || rec.Body.String() != `{"ok":true}` {
+		t.Errorf("expected a small body to be sent as is, got %v", rec.Header())
+	}
+	if rec := serve(&router.Route{Name: "positions"}, "gzip;q=0, identity", large); rec.Header().Get("Content-Encoding") != "" {
+		t.Errorf("expected gzip;q=0 to be refused, got %v", rec.Header())
+	}
+	disabled := false
+	if rec := serve(&router.Route{Name: "positions", Compress: &disabled}, "gzip", large); rec.Header().Get("Content-Encoding") != "" {
+		t.Errorf("expected compress: false to opt out, got %v", rec.Header())
+	}
+}
@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/httperr"
+	"hub-api-gateway/internal/router"
+)
+
+// CORS applies the configured cross-origin policy to every response and
+// answers browser preflight requests before they reach the router
+type CORS struct {
+	policy        corsPolicy
+	allowMethods  string
+	allowHeaders  string
+	exposeHeaders string
+	maxAge        string
+
+	// Finds the route of a request so its cors override applies (optional)
+	resolveRoute func(path, method string) *router.Route
+}
+
+// corsPolicy is the set of origins allowed to read responses
+type corsPolicy struct {
+	origins          map[string]bool
+	originPatterns   []originPattern
+	anyOrigin        bool
+	allowCredentials bool
+}
+
+// originPattern matches wildcard subdomain origins such as https://*.example.com
+type originPattern struct {
+	scheme string // "https://"
+	domain string // ".example.com"
+}
+
+// matches reports whether origin is a subdomain origin covered by the pattern
+func (p originPattern) matches(origin string) bool {
+	return strings.HasPrefix(origin, p.scheme) && strings.HasSuffix(origin, p.domain) &&
+		len(origin) > len(p.scheme)+len(p.domain)
+}
+
+// NewCORS creates the CORS middleware from configuration
+func NewCORS(cfg config.CORSConfig) *CORS {
+	c := &CORS{
+		policy:        newCORSPolicy(cfg.AllowedOrigins, cfg.AllowCredentials),
+		allowMethods:  strings.Join(cfg.AllowedMethods, ", "),
+		allowHeaders:  strings.Join(cfg.AllowedHeaders, ", "),
+		exposeHeaders: strings.Join(cfg.ExposedHeaders, ", "),
+	}
+	if cfg.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+
+	// Browsers reject credentialed responses with a wildcard origin; never
+	// reflect arbitrary origins with credentials instead
+	if c.policy.anyOrigin && c.policy.allowCredentials {
+		slog.Warn("CORS credentials are not allowed with a * origin, ignoring CORS_ALLOW_CREDENTIALS")
+		c.policy.allowCredentials = false
+	}
+
+	return c
+}
+
+// newCORSPolicy parses exact origins, * and wildcard subdomain origins
+func newCORSPolicy(origins []string, allowCredentials bool) corsPolicy {
+	policy := corsPolicy{
+		origins:          make(map[string]bool),
+		allowCredentials: allowCredentials,
+	}
+
+	for _, origin := range origins {
+		switch {
+		case origin == "*":
+			policy.anyOrigin = true
+		case strings.Contains(origin, "://*."):
+			scheme, domain, _ := strings.Cut(strings.ToLower(origin), "://*")
+			policy.originPatterns = append(policy.originPatterns, originPattern{scheme: scheme + "://", domain: domain})
+		default:
+			policy.origins[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+		}
+	}
+
+	return policy
+}
+
+// allowed reports whether requests from origin may read responses
+func (p corsPolicy) allowed(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if p.origins[origin] {
+		return true
+	}
+	for _, pattern := range p.originPatterns {
+		if pattern.matches(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRouteResolver lets routes with a cors block override the global origins
+func (c *CORS) SetRouteResolver(resolve func(path, method string) *router.Route) {
+	c.resolveRoute = resolve
+}
+
+// CheckRoute rejects route overrides that would send credentials with a *
+// origin by inheriting CORS_ALLOW_CREDENTIALS (see ServiceRouter.AddRouteCheck)
+func (c *CORS) CheckRoute(route *router.Route) error {
+	if route.CORS == nil || route.CORS.AllowCredentials != nil || !c.policy.allowCredentials {
+		return nil
+	}
+	for _, origin := range route.CORS.AllowedOrigins {
+		if origin == "*" {
+			return fmt.Errorf("cors origin * inherits CORS_ALLOW_CREDENTIALS=true; set allow_credentials: false")
+		}
+	}
+	return nil
+}
+
+// policyFor returns the policy of the route serving the request; preflights
+// are matched by the method the browser is about to use
+func (c *CORS) policyFor(r *http.Request, preflight bool) corsPolicy {
+	if c.resolveRoute == nil {
+		return c.policy
+	}
+
+	method := r.Method
+	if preflight {
+		method = r.Header.Get("Access-Control-Request-Method")
+	}
+	route := c.resolveRoute(r.URL.Path, method)
+	if route == nil || route.CORS == nil {
+		return c.policy
+	}
+
+	allowCredentials := c.policy.allowCredentials
+	if route.CORS.AllowCredentials != nil {
+		allowCredentials = *route.CORS.AllowCredentials
+	}
+	return newCORSPolicy(route.CORS.AllowedOrigins, allowCredentials)
+}
+
+// Handler wraps the whole router so preflights are answered for every path,
+// including routes registered without an OPTIONS method
+func (c *CORS) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+		policy := c.policyFor(r, preflight)
+
+		if !policy.allowed(origin) {
+			if preflight {
+				slog.WarnContext(r.Context(), "CORS preflight from disallowed origin", "origin", origin, "path", r.URL.Path)
+				httperr.Send(w, r, http.StatusForbidden, "CORS_ORIGIN_NOT_ALLOWED", "Origin "+origin+" is not allowed")
+				return
+			}
+			// Without CORS headers the browser blocks the response
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if policy.anyOrigin {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		if policy.allowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if preflight {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", c.allowMethods)
+			w.Header().Set("Access-Control-Allow-Headers", c.allowHeaders)
+			if c.maxAge != "" {
+				w.Header().Set("Access-Control-Max-Age", c.maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if c.exposeHeaders != "" {
+			w.Header().Set("Access-Control-Expose-Headers", c.exposeHeaders)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/router"
+)
+
+func TestCORS(t *testing.T) {
+	cors := NewCORS(config.CORSConfig{
+		AllowedOrigins:   []string{"https://app.hub.com", "https://*.partners.hub.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+	handler := cors.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(method, origin string, preflight bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/api/v1/orders", nil)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		if preflight {
+			req.Header.Set("Access-Control-Request-Method", "POST")
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := send(http.MethodOptions, "https://app.hub.com", true)
+	if rec.Code != http.StatusNoContent ||
+		rec.Header().Get("Access-Control-Allow-Origin") != "https://app.hub.com" ||
+		rec.Header().Get("Access-Control-Allow-Methods") != "GET, POST" ||
+		rec.Header().Get("Access-Control-Allow-Credentials") != "true" ||
+		rec.Header().Get("Access-Control-Max-Age") != "600" {
+		t.Errorf("unexpected preflight response: %d %v", rec.Code, rec.Header())
+	}
+
+	rec = send(http.MethodGet, "https://acme.partners.hub.com", false)
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "https://acme.partners.hub.com" ||
+		rec.Header().Get("Access-Control-Expose-Headers") != "X-Request-ID" {
+		t.Errorf("expected wildcard subdomain origin to be allowed: %d %v", rec.Code, rec.Header())
+	}
+
+	if rec := send(http.MethodOptions, "https://evil.com", true); rec.Code != http.StatusForbidden {
+		t.Errorf("expected disallowed preflight to be rejected, got %d", rec.Code)
+	}
+	rec = send(http.MethodGet, "https://partners.hub.com.evil.com", false)
+	if rec.Code != http.StatusOK || rec.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("expected no CORS headers for disallowed origin, got %v", rec.Header())
+	}
+
+	if rec := send(http.MethodGet, "", false); rec.Header().Get("Vary") != "" {
+		t.Errorf("expected same-origin requests to pass untouched, got %v", rec.Header())
+	}
+
+	// Credentials are never combined with a wildcard origin
+	wildcard := NewCORS(config.CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Origin", "https://any.example")
+	wildcard.Handler(http.NotFoundHandler()).ServeHTTP(rec, req)
+	if rec.Header().Get("Access-Control-Allow-Origin") != "*" || rec.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("unexpected wildcard headers: %v", rec.Header())
+	}
+}
+
+func TestCORS_RouteOverride(t *testing.T) {
+	cors := NewCORS(config.CORSConfig{
+		AllowedOrigins:   []string{"https://app.hub.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowCredentials: true,
+	})
+	noCredentials := false
+	partnerRoute := &router.Route{Name: "partner-quotes", CORS: &router.RouteCORS{
+		AllowedOrigins:   []string{"https://portal.partners.hub.com"},
+		AllowCredentials: &noCredentials,
+	}}
+	cors.SetRouteResolver(func(path, method string) *router.Route {
+		if path == "/api/v1/partner/quotes" && method == http.MethodGet {
+			return partnerRoute
+		}
+		return nil
+	})
+	handler := cors.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	preflight := func(path, origin string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodOptions, path, nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := preflight("/api/v1/partner/quotes", "https://portal.partners.hub.com")
+	if rec.Code != http.StatusNoContent || rec.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("expected partner origin allowed without credentials, got %d %v", rec.Code, rec.Header())
+	}
+	if rec := preflight("/api/v1/partner/quotes", "https://app.hub.com"); rec.Code != http.StatusForbidden {
+		t.Errorf("expected global origin to be replaced on the partner route, got %d", rec.Code)
+	}
+	if rec := preflight("/api/v1/orders", "https://app.hub.com"); rec.Code != http.StatusNoContent {
+		t.Errorf("expected global origins on other routes, got %d", rec.Code)
+	}
+
+	// A * override would inherit CORS_ALLOW_CREDENTIALS=true
+	if err := cors.CheckRoute(&router.Route{Name: "public", CORS: &router.RouteCORS{AllowedOrigins: []string{"*"}}}); err == nil {
+		t.Error("expected inherited credentials with * origin to be rejected")
+	}
+}
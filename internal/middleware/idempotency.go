@@ -0,0 +1,313 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"hub-api-gateway/internal/httperr"
+	"hub-api-gateway/internal/respwriter"
+	"hub-api-gateway/internal/router"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// IdempotencyKeyHeader carries the client's key for a mutating request
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// IdempotentReplayedHeader marks responses replayed from an earlier request
+const IdempotentReplayedHeader = "Idempotent-Replayed"
+
+// maxIdempotencyKey bounds the length of client keys
+const maxIdempotencyKey = 255
+
+// maxIdempotentBody bounds the size of responses stored for replay; larger
+// ones release the key instead
+const maxIdempotentBody = 1 << 20
+
+// IdempotencyRecord is the outcome of the first request with a key. Status is
+// 0 while that request is still in progress.
+type IdempotencyRecord struct {
+	Fingerprint string              `json:"fingerprint"`
+	Status      int                 `json:"status"`
+	Header      map[string][]string `json:"header,omitempty"`
+	Body        []byte              `json:"body,omitempty"`
+}
+
+// IdempotencyStore keeps idempotency records until they expire
+type IdempotencyStore interface {
+	// Claim stores an in-progress record for the key unless one exists, and
+	// returns the existing record otherwise
+	Claim(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error)
+	// Save replaces the key's record with the finished response
+	Save(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error
+	// Release forgets the key so the request can be retried
+	Release(ctx context.Context, key string) error
+}
+
+// RedisIdempotencyStore shares idempotency records across gateway instances
+type RedisIdempotencyStore struct {
+	client *redis.Client
+}
+
+// NewRedisIdempotencyStore creates a Redis-backed idempotency store
+func NewRedisIdempotencyStore(client *redis.Client) *RedisIdempotencyStore {
+	return &RedisIdempotencyStore{client: client}
+}
+
+// Claim stores the record with SET NX
+func (s *RedisIdempotencyStore) Claim(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	claimed, err := s.client.SetNX(ctx, "idempotency:"+key, data, ttl).Result()
+	if err != nil || claimed {
+		return nil, err
+	}
+
+	data, err = s.client.Get(ctx, "idempotency:"+key).Bytes()
+	if err == redis.Nil {
+		// Expired in between; report it in progress and let the client retry
+		return &IdempotencyRecord{Fingerprint: record.Fingerprint}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var existing IdempotencyRecord
+	if err := json.Unmarshal(data, &existing); err != nil {
+		return nil, err
+	}
+	return &existing, nil
+}
+
+// Save stores the record as JSON
+func (s *RedisIdempotencyStore) Save(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, "idempotency:"+key, data, ttl).Err()
+}
+
+// Release deletes the record
+func (s *RedisIdempotencyStore) Release(ctx context.Context, key string) error {
+	return s.client.Del(ctx, "idempotency:"+key).Err()
+}
+
+// MemoryIdempotencyStore keeps idempotency records in process memory (single instance only)
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	records   map[string]memoryIdempotencyRecord
+	lastSweep time.Time
+}
+
+// memoryIdempotencyRecord is a stored record with its expiry
+type memoryIdempotencyRecord struct {
+	record *IdempotencyRecord
+	expiry time.Time
+}
+
+// NewMemoryIdempotencyStore creates an in-memory idempotency store
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		records:   make(map[string]memoryIdempotencyRecord),
+		lastSweep: time.Now(),
+	}
+}
+
+// Claim stores the record unless an unexpired one exists, evicting expired entries
+// at most once a minute
+func (s *MemoryIdempotencyStore) Claim(_ context.Context, key string, record *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) > time.Minute {
+		for k, stored := range s.records {
+			if now.After(stored.expiry) {
+				delete(s.records, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if stored, ok := s.records[key]; ok && !now.After(stored.expiry) {
+		return stored.record, nil
+	}
+	s.records[key] = memoryIdempotencyRecord{record: record, expiry: now.Add(ttl)}
+	return nil, nil
+}
+
+// Save replaces the record
+func (s *MemoryIdempotencyStore) Save(_ context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = memoryIdempotencyRecord{record: record, expiry: time.Now().Add(ttl)}
+	return nil
+}
+
+// Release deletes the record
+func (s *MemoryIdempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// IdempotencyGuard honors Idempotency-Key headers on mutating requests of
+// authenticated users: the first response for a key is stored and replayed
+// to retries within the window, so a retried order submission isn't placed
+// twice. Server errors release the key so the request can be retried.
+type IdempotencyGuard struct {
+	store   IdempotencyStore
+	window  time.Duration
+	lockTTL time.Duration
+}
+
+// NewIdempotencyGuard creates a guard replaying responses for window. A key
+// stays locked for at most lockTTL while its first request is in progress.
+func NewIdempotencyGuard(store IdempotencyStore, window, lockTTL time.Duration) *IdempotencyGuard {
+	return &IdempotencyGuard{store: store, window: window, lockTTL: lockTTL}
+}
+
+// Middleware applies idempotency keys to the route's requests. Streaming and
+// WebSocket routes are left alone, as their responses can't be replayed.
+func (g *IdempotencyGuard) Middleware(route *router.Route, next http.Handler) http.Handler {
+	if route.Stream != "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idempotencyKey := r.Header.Get(IdempotencyKeyHeader)
+		userContext, authenticated := GetUserContext(r.Context())
+		if idempotencyKey == "" || !authenticated || !isMutating(r.Method) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(idempotencyKey) > maxIdempotencyKey {
+			g.sendError(w, r, http.StatusBadRequest, "IDEMPOTENCY_KEY_INVALID", "Idempotency-Key must be at most 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				g.sendError(w, r, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE", "Request body exceeds "+strconv.FormatInt(tooLarge.Limit, 10)+" bytes")
+				return
+			}
+			g.sendError(w, r, http.StatusBadRequest, "INVALID_REQUEST", "Failed to read request body")
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Keys are scoped per user and route so clients can't collide with each other
+		key := userContext.UserID + ":" + route.Name + ":" + idempotencyKey
+		if tenant := TenantFromContext(r.Context()); tenant != "" {
+			key = tenant + ":" + key
+		}
+		fingerprint := requestFingerprint(r, body)
+
+		existing, err := g.store.Claim(r.Context(), key, &IdempotencyRecord{Fingerprint: fingerprint}, g.lockTTL)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "idempotency store unavailable", "error", err)
+			g.sendError(w, r, http.StatusServiceUnavailable, "IDEMPOTENCY_UNAVAILABLE", "Unable to check the Idempotency-Key")
+			return
+		}
+		if existing != nil {
+			g.replay(w, r, existing, fingerprint)
+			return
+		}
+
+		recorder := respwriter.New(w)
+		recorder.CaptureBody(maxIdempotentBody)
+		defer func() {
+			// The client's request may be over; the outcome still has to be recorded
+			ctx := context.WithoutCancel(r.Context())
+			body, complete := recorder.Body()
+			if !recorder.Written() || recorder.Status() >= 500 || recorder.Status() == http.StatusTooManyRequests || !complete {
+				if err := g.store.Release(ctx, key); err != nil {
+					slog.WarnContext(r.Context(), "failed to release idempotency key", "error", err)
+				}
+				return
+			}
+			record := &IdempotencyRecord{
+				Fingerprint: fingerprint,
+				Status:      recorder.Status(),
+				Header:      replayableHeader(w.Header()),
+				Body:        body,
+			}
+			if err := g.store.Save(ctx, key, record, g.window); err != nil {
+				slog.WarnContext(r.Context(), "failed to store idempotent response", "error", err)
+			}
+		}()
+		next.ServeHTTP(recorder, r)
+	})
+}
+
+// replay answers a retry from the record of the first request
+func (g *IdempotencyGuard) replay(w http.ResponseWriter, r *http.Request, record *IdempotencyRecord, fingerprint string) {
+	if record.Fingerprint != fingerprint {
+		g.sendError(w, r, http.StatusUnprocessableEntity, "IDEMPOTENCY_KEY_REUSED", "Idempotency-Key was already used for a different request")
+		return
+	}
+	if record.Status == 0 {
+		w.Header().Set("Retry-After", "1")
+		g.sendError(w, r, http.StatusConflict, "IDEMPOTENCY_IN_PROGRESS", "A request with this Idempotency-Key is still in progress")
+		return
+	}
+
+	slog.InfoContext(r.Context(), "replayed idempotent response", "method", r.Method, "path", r.URL.Path, "status", record.Status)
+	for name, values := range record.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(record.Status)
+	w.Write(record.Body)
+}
+
+// isMutating reports whether a method changes state
+func isMutating(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// requestFingerprint identifies a request by method, path, query and body,
+// so a key reused for a different request is refused
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method+" "+r.URL.RequestURI()+"\n")
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// replayableHeader copies the response headers worth replaying, leaving out
+// those tied to the original exchange
+func replayableHeader(header http.Header) map[string][]string {
+	replayable := make(map[string][]string, len(header))
+	for name, values := range header {
+		switch name {
+		case "Set-Cookie", "Date", "X-Request-Id", "Content-Length":
+			continue
+		}
+		replayable[name] = values
+	}
+	return replayable
+}
+
+// sendError sends a JSON error response
+func (g *IdempotencyGuard) sendError(w http.ResponseWriter, r *http.Request, statusCode int, errorCode, message string) {
+	httperr.Send(w, r, statusCode, errorCode, message)
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/router"
+)
+
+func TestIdempotencyGuard(t *testing.T) {
+	guard := NewIdempotencyGuard(NewMemoryIdempotencyStore(), time.Hour, time.Minute)
+
+	var calls int
+	status := http.StatusCreated
+	handler := guard.Middleware(&router.Route{Name: "submit-order"}, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write([]byte(`{"order_id":"o-1"}`))
+	}))
+
+	send := func(userID, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/orders", strings.NewReader(body))
+		req.Header.Set(IdempotencyKeyHeader, key)
+		req = req.WithContext(context.WithValue(req.Context(), "user", &UserContext{UserID: userID}))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := send("u1", "key-1", `{"symbol":"PETR4"}`)
+	retry := send("u1", "key-1", `{"symbol":"PETR4"}`)
+	if calls != 1 || retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() || retry.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("expected the retry to be replayed, got %d calls and %d %s", calls, retry.Code, retry.Body)
+	}
+	if rec := send("u1", "key-1", `{"symbol":"VALE3"}`); rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected a reused key with another body to be refused with 422, got %d", rec.Code)
+	}
+	if send("u2", "key-1", `{"symbol":"PETR4"}`); calls != 2 {
+		t.Errorf("expected keys to be scoped per user, got %d calls", calls)
+	}
+
+	// Server errors release the key for a real retry
+	status = http.StatusServiceUnavailable
+	send("u1", "key-2", `{}`)
+	status = http.StatusCreated
+	if rec := send("u1", "key-2", `{}`); rec.Code != http.StatusCreated || calls != 4 {
+		t.Errorf("expected the retry after a 503 to reach the backend, got %d after %d calls", rec.Code, calls)
+	}
+
+	// A retry while the first request runs is told to wait
+	store := NewMemoryIdempotencyStore()
+	store.Claim(context.Background(), "u1:submit-order:key-3", &IdempotencyRecord{Fingerprint: requestFingerprint(httptest.NewRequest(http.MethodPost, "/api/v1/orders", nil), nil)}, time.Minute)
+	guard.store = store
+	if rec := send("u1", "key-3", ""); rec.Code != http.StatusConflict {
+		t.Errorf("expected an in-progress key to be refused with 409, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,435 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hub-api-gateway/internal/httperr"
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/router"
+	"hub-api-gateway/internal/trace"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RateLimit is a token bucket: Requests tokens are refilled every Per and the
+// bucket holds at most Burst tokens (Requests when Burst is 0)
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+// capacity returns the bucket size
+func (l RateLimit) capacity() int {
+	if l.Burst > 0 {
+		return l.Burst
+	}
+	return l.Requests
+}
+
+// refillRate returns the tokens added per second
+func (l RateLimit) refillRate() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// RateLimitResult is the outcome of taking a token from a bucket
+type RateLimitResult struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration // When the next token is available (rejected requests only)
+}
+
+// RateLimitStore holds token buckets
+type RateLimitStore interface {
+	// Take removes a token from the bucket under key
+	Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error)
+}
+
+// takeTokenScript refills and takes from a bucket atomically. Redis server time
+// is used so gateway replicas with skewed clocks share consistent buckets.
+var takeTokenScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local capacity = tonumber(ARGV[2])
+local now = redis.call('TIME')
+now = tonumber(now[1]) + tonumber(now[2]) / 1000000
+
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(bucket[1])
+local ts = tonumber(bucket[2])
+if tokens == nil or ts == nil then
+  tokens = capacity
+  ts = now
+end
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry = math.ceil((1 - tokens) / rate * 1000)
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('PEXPIRE', KEYS[1], math.ceil(capacity / rate * 1000) + 1000)
+return {allowed, math.floor(tokens), retry}
+`)
+
+// RedisRateLimitStore shares token buckets across gateway instances
+type RedisRateLimitStore struct {
+	client *redis.Client
+}
+
+// NewRedisRateLimitStore creates a Redis-backed rate limit store
+func NewRedisRateLimitStore(client *redis.Client) *RedisRateLimitStore {
+	return &RedisRateLimitStore{client: client}
+}
+
+// Take takes a token with a Lua script so concurrent replicas can't overspend a bucket
+func (s *RedisRateLimitStore) Take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	values, err := takeTokenScript.Run(ctx, s.client, []string{"rate_limit:" + key},
+		strconv.FormatFloat(limit.refillRate(), 'f', -1, 64), limit.capacity()).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	if len(values) != 3 {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit script result %v", values)
+	}
+
+	return RateLimitResult{
+		Allowed:    values[0] == 1,
+		Remaining:  int(values[1]),
+		RetryAfter: time.Duration(values[2]) * time.Millisecond,
+	}, nil
+}
+
+// tokenBucket is an in-memory token bucket
+type tokenBucket struct {
+	tokens    float64
+	updated   time.Time
+	fullAfter time.Duration // Idle time after which the bucket is full again
+}
+
+// MemoryRateLimitStore keeps token buckets in process memory (single instance only)
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// NewMemoryRateLimitStore creates an in-memory rate limit store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// Take refills the bucket for the elapsed time and takes a token
+func (s *MemoryRateLimitStore) Take(_ context.Context, key string, limit RateLimit) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	capacity := float64(limit.capacity())
+	rate := limit.refillRate()
+
+	// Buckets idle long enough to be full again carry no state worth keeping
+	if now.Sub(s.lastSweep) > time.Minute {
+		for bucketKey, bucket := range s.buckets {
+			if now.Sub(bucket.updated) > bucket.fullAfter {
+				delete(s.buckets, bucketKey)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{
+			tokens:    capacity,
+			updated:   now,
+			fullAfter: time.Duration(capacity / rate * float64(time.Second)),
+		}
+		s.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(capacity, bucket.tokens+now.Sub(bucket.updated).Seconds()*rate)
+	bucket.updated = now
+
+	if bucket.tokens < 1 {
+		retryAfter := time.Duration((1 - bucket.tokens) / rate * float64(time.Second))
+		return RateLimitResult{Allowed: false, RetryAfter: retryAfter}, nil
+	}
+	bucket.tokens--
+	return RateLimitResult{Allowed: true, Remaining: int(bucket.tokens)}, nil
+}
+
+// RateLimiter enforces per-user limits on authenticated requests, per-IP limits
+// on anonymous ones, and a route's rate_limit on top of either
+type RateLimiter struct {
+	store             RateLimitStore
+	perUser           RateLimit
+	perIP             RateLimit
+	preAuth           RateLimit // Per client IP before authentication; zero disables IPMiddleware
+	trustForwardedFor bool
+	metrics           *metrics.Metrics
+
+	// Trusted automation that bypasses the limits
+	exemptPrincipals map[string]bool
+	exemptNetworks   []*net.IPNet
+	bypassTokens     map[string]string // name -> token
+}
+
+// RateLimitExemptions are trusted callers (monitoring probes, internal batch
+// jobs) that bypass rate limiting
+type RateLimitExemptions struct {
+	Principals   []string          // Authenticated user IDs or service principals
+	Networks     []string          // Client IPs or CIDRs
+	BypassTokens map[string]string // Name -> token sent in X-RateLimit-Bypass
+}
+
+// NewRateLimiter creates a rate limiter backed by store
+func NewRateLimiter(store RateLimitStore, perUser, perIP RateLimit, m *metrics.Metrics) *RateLimiter {
+	return &RateLimiter{
+		store:   store,
+		perUser: perUser,
+		perIP:   perIP,
+		metrics: m,
+	}
+}
+
+// SetPreAuthLimit sets the per-IP flood guard IPMiddleware applies before
+// authentication. It covers authenticated traffic too, so it should sit well
+// above the per-user limit.
+func (l *RateLimiter) SetPreAuthLimit(limit RateLimit) {
+	l.preAuth = limit
+}
+
+// TrustForwardedFor identifies anonymous clients by the first X-Forwarded-For
+// address; only enable it behind a proxy that overwrites the header
+func (l *RateLimiter) TrustForwardedFor(trust bool) {
+	l.trustForwardedFor = trust
+}
+
+// SetExemptions configures the callers that bypass rate limiting
+func (l *RateLimiter) SetExemptions(exemptions RateLimitExemptions) error {
+	l.exemptPrincipals = make(map[string]bool, len(exemptions.Principals))
+	for _, principal := range exemptions.Principals {
+		l.exemptPrincipals[principal] = true
+	}
+
+	l.exemptNetworks = nil
+	for _, entry := range exemptions.Networks {
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return fmt.Errorf("invalid rate limit exempt network %q: %w", entry, err)
+		}
+		l.exemptNetworks = append(l.exemptNetworks, network)
+	}
+
+	l.bypassTokens = exemptions.BypassTokens
+	return nil
+}
+
+// exemption returns who a request is exempt as ("token:batch-jobs",
+// "principal:uptime-probe", "ip:10.0.0.5"), or false when it is limited
+func (l *RateLimiter) exemption(r *http.Request) (string, bool) {
+	if presented := r.Header.Get("X-RateLimit-Bypass"); presented != "" {
+		for name, token := range l.bypassTokens {
+			if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1 {
+				return "token:" + name, true
+			}
+		}
+	}
+
+	if userContext, ok := GetUserContext(r.Context()); ok && l.exemptPrincipals[userContext.UserID] {
+		return "principal:" + userContext.UserID, true
+	}
+
+	if len(l.exemptNetworks) > 0 {
+		if ip := net.ParseIP(l.clientIP(r)); ip != nil {
+			for _, network := range l.exemptNetworks {
+				if network.Contains(ip) {
+					return "ip:" + ip.String(), true
+				}
+			}
+		}
+	}
+
+	return "", false
+}
+
+// IPMiddleware guards against floods per client IP before authentication, so
+// floods of missing or invalid credentials are rejected before they reach the
+// auth providers. Its bucket is separate from the per-IP limit Middleware
+// applies to anonymous callers. Without a pre-auth limit it returns next.
+func (l *RateLimiter) IPMiddleware(next http.Handler) http.Handler {
+	if l.preAuth.Requests <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Principal exemptions are only known after authentication
+		if _, exempt := l.exemption(r); exempt {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		identity := "preauth:" + l.clientIP(r)
+		result, ok := l.take(r.Context(), identity, l.preAuth)
+		if l.metrics != nil {
+			l.metrics.RecordStage(metrics.StageRateLimit, time.Since(start))
+		}
+		if !ok {
+			l.reject(w, r, identity, l.preAuth, result, start)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Middleware limits requests to route (nil for endpoints without a route).
+// It must run after authentication so authenticated callers are limited per user.
+func (l *RateLimiter) Middleware(route *router.Route, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		// Exempt callers are still logged and counted so bypass usage stays visible.
+		// Bypass tokens are gateway credentials and never reach backends.
+		exemptAs, exempt := l.exemption(r)
+		r.Header.Del("X-RateLimit-Bypass")
+		if exempt {
+			slog.InfoContext(r.Context(), "rate limit bypassed", "exempt_as", exemptAs, "method", r.Method, "path", r.URL.Path)
+			trace.FromContext(r.Context()).Record(metrics.StageRateLimit, "rate limit exempt", time.Since(start), map[string]string{
+				"exemptAs": exemptAs,
+			})
+			if l.metrics != nil {
+				l.metrics.RecordRateLimitExempt()
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		identity, limit := "ip:"+l.clientIP(r), l.perIP
+		userContext, authenticated := GetUserContext(r.Context())
+		if authenticated {
+			identity, limit = "user:"+userContext.UserID, l.perUser
+		}
+
+		// Tenants get their own buckets and may have their own route limit
+		limited := route
+		if tenant := TenantFromContext(r.Context()); tenant != "" {
+			identity = "tenant:" + tenant + ":" + identity
+			if route != nil {
+				limited = route.ForTenant(tenant)
+			}
+		}
+
+		result, ok := l.take(r.Context(), identity, limit)
+		if ok && limited != nil && limited.RateLimit != nil {
+			routeLimit := RateLimit{Requests: limited.RateLimit.Requests, Per: ratePeriod(limited.RateLimit.Per)}
+			var routeResult RateLimitResult
+			if routeResult, ok = l.take(r.Context(), "route:"+route.Name+":"+identity, routeLimit); !ok || routeResult.Remaining < result.Remaining {
+				result, limit = routeResult, routeLimit
+			}
+		}
+
+		if l.metrics != nil {
+			l.metrics.RecordStage(metrics.StageRateLimit, time.Since(start))
+		}
+
+		if !ok {
+			l.reject(w, r, identity, limit, result, start)
+			return
+		}
+
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit.Requests))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// reject answers a request over limit with 429 and a Retry-After
+func (l *RateLimiter) reject(w http.ResponseWriter, r *http.Request, identity string, limit RateLimit, result RateLimitResult, start time.Time) {
+	retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+
+	slog.WarnContext(r.Context(), "rate limit exceeded", "identity", identity, "method", r.Method, "path", r.URL.Path)
+	trace.FromContext(r.Context()).Record(metrics.StageRateLimit, "rate limit exceeded", time.Since(start), map[string]string{
+		"identity":   identity,
+		"retryAfter": strconv.Itoa(retryAfter),
+	})
+	if l.metrics != nil {
+		l.metrics.RecordRateLimited()
+	}
+
+	w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit.Requests))
+	w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	l.sendError(w, r, http.StatusTooManyRequests, "RATE_LIMIT_EXCEEDED",
+		fmt.Sprintf("Too many requests, retry in %d seconds", retryAfter))
+}
+
+// take takes a token and reports whether the request may proceed. The limiter
+// fails open: an unavailable store must not take the whole API down.
+func (l *RateLimiter) take(ctx context.Context, key string, limit RateLimit) (RateLimitResult, bool) {
+	result, err := l.store.Take(ctx, key, limit)
+	if err != nil {
+		slog.WarnContext(ctx, "rate limit store unavailable, allowing request", "error", err)
+		return RateLimitResult{Allowed: true, Remaining: limit.capacity()}, true
+	}
+	return result, result.Allowed
+}
+
+// clientIP returns the address anonymous requests are limited by
+func (l *RateLimiter) clientIP(r *http.Request) string {
+	if l.trustForwardedFor {
+		if forwarded := r.Header.Get("X-Forwarded-For"); forwarded != "" {
+			first, _, _ := strings.Cut(forwarded, ",")
+			return strings.TrimSpace(first)
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// ratePeriod converts a route rate_limit.per value to a duration
+func ratePeriod(per string) time.Duration {
+	switch per {
+	case "second":
+		return time.Second
+	case "hour":
+		return time.Hour
+	default:
+		return time.Minute
+	}
+}
+
+// sendError sends a JSON error response
+func (l *RateLimiter) sendError(w http.ResponseWriter, r *http.Request, statusCode int, errorCode, message string) {
+	httperr.Send(w, r, statusCode, errorCode, message)
+}
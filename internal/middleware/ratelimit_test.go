@@ -0,0 +1,213 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/router"
+)
+
+// failingRateLimitStore simulates an unavailable Redis
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(context.Context, string, RateLimit) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("connection refused")
+}
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(NewMemoryRateLimitStore(),
+		RateLimit{Requests: 3, Per: time.Hour},
+		RateLimit{Requests: 1, Per: time.Hour},
+		nil)
+	route := &router.Route{Name: "submit-order", RateLimit: &router.RateLimitConfig{Requests: 2, Per: "hour"}}
+	handler := limiter.Middleware(route, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(userID, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/orders", nil)
+		req.RemoteAddr = remoteAddr
+		if userID != "" {
+			req = req.WithContext(context.WithValue(req.Context(), "user", &UserContext{UserID: userID}))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Anonymous clients are limited per IP
+	if rec := send("", "10.0.0.1:5000"); rec.Code != http.StatusOK {
+		t.Fatalf("expected first anonymous request to pass, got %d", rec.Code)
+	}
+	rec := send("", "10.0.0.1:5001")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("expected 429 with Retry-After for the same IP, got %d %v", rec.Code, rec.Header())
+	}
+	if rec := send("", "10.0.0.2:5000"); rec.Code != http.StatusOK {
+		t.Errorf("expected another IP to have its own bucket, got %d", rec.Code)
+	}
+
+	// The route limit (2/hour) is stricter than the user limit (3/hour)
+	for i := 0; i < 2; i++ {
+		if rec := send("user-1", "10.0.0.1:5000"); rec.Code != http.StatusOK {
+			t.Fatalf("expected request %d for user-1 to pass, got %d", i+1, rec.Code)
+		}
+	}
+	if rec := send("user-1", "10.0.0.1:5000"); rec.Code != http.StatusTooManyRequests || rec.Header().Get("X-RateLimit-Limit") != "2" {
+		t.Errorf("expected route limit to apply, got %d %v", rec.Code, rec.Header())
+	}
+	if rec := send("user-2", "10.0.0.1:5000"); rec.Code != http.StatusOK {
+		t.Errorf("expected user-2 to have its own bucket, got %d", rec.Code)
+	}
+
+	// An unavailable store must not reject traffic
+	failOpen := NewRateLimiter(failingRateLimitStore{}, RateLimit{Requests: 1, Per: time.Hour}, RateLimit{Requests: 1, Per: time.Hour}, nil)
+	rec = httptest.NewRecorder()
+	failOpen.Middleware(nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected rate limiter to fail open, got %d", rec.Code)
+	}
+}
+
+func TestRateLimiter_IPMiddleware(t *testing.T) {
+	limiter := NewRateLimiter(NewMemoryRateLimitStore(),
+		RateLimit{Requests: 5, Per: time.Hour},
+		RateLimit{Requests: 2, Per: time.Hour},
+		nil)
+	limiter.SetPreAuthLimit(RateLimit{Requests: 2, Per: time.Hour})
+
+	// A protected route whose auth rejects every credential
+	authCalls := 0
+	protected := limiter.IPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authCalls++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	for i := 0; i < 3; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/orders", nil)
+		req.RemoteAddr = "10.0.0.1:5000"
+		protected.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if authCalls != 2 {
+		t.Errorf("expected the per-IP limit to stop the flood before auth, auth ran %d times", authCalls)
+	}
+
+}
+
+func TestRateLimiter_AuthenticatedBehindOneIP(t *testing.T) {
+	// The gateway's defaults
+	limiter := NewRateLimiter(NewMemoryRateLimitStore(),
+		RateLimit{Requests: 100, Per: time.Minute, Burst: 10},
+		RateLimit{Requests: 20, Per: time.Minute, Burst: 5},
+		nil)
+	limiter.SetPreAuthLimit(RateLimit{Requests: 1000, Per: time.Minute, Burst: 200})
+
+	authenticate := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if userID := r.Header.Get("X-User"); userID != "" {
+				r = r.WithContext(context.WithValue(r.Context(), "user", &UserContext{UserID: userID}))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	handler := limiter.IPMiddleware(authenticate(limiter.Middleware(nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))))
+	send := func(userID string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/quotes", nil)
+		req.RemoteAddr = "10.0.0.2:5000"
+		req.Header.Set("X-User", userID)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Signed-in users behind one address get their own burst, not the per-IP one
+	for _, userID := range []string{"u1", "u2"} {
+		for i := 0; i < 10; i++ {
+			if code := send(userID); code != http.StatusOK {
+				t.Fatalf("expected request %d of %s within the per-user burst to pass, got %d", i+1, userID, code)
+			}
+		}
+	}
+
+	// Anonymous callers keep the per-IP limit
+	passed := 0
+	for i := 0; i < 10; i++ {
+		if send("") == http.StatusOK {
+			passed++
+		}
+	}
+	if passed != 5 {
+		t.Errorf("expected the per-IP burst of 5 for anonymous callers, %d passed", passed)
+	}
+}
+
+func TestRateLimiter_Exemptions(t *testing.T) {
+	m := metrics.NewMetrics()
+	limiter := NewRateLimiter(NewMemoryRateLimitStore(),
+		RateLimit{Requests: 1, Per: time.Hour},
+		RateLimit{Requests: 1, Per: time.Hour},
+		m)
+	if err := limiter.SetExemptions(RateLimitExemptions{
+		Principals:   []string{"uptime-probe"},
+		Networks:     []string{"10.20.0.0/16", "192.168.1.7"},
+		BypassTokens: map[string]string{"batch-jobs": "0123456789abcdef0123456789abcdef"},
+	}); err != nil {
+		t.Fatalf("SetExemptions() error = %v", err)
+	}
+	handler := limiter.Middleware(nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-RateLimit-Bypass") != "" {
+			t.Errorf("bypass token must not be forwarded")
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(userID, remoteAddr, bypass string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/quotes", nil)
+		req.RemoteAddr = remoteAddr
+		if userID != "" {
+			req = req.WithContext(context.WithValue(req.Context(), "user", &UserContext{UserID: userID}))
+		}
+		if bypass != "" {
+			req.Header.Set("X-RateLimit-Bypass", bypass)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	for i := 0; i < 3; i++ {
+		if code := send("uptime-probe", "10.0.0.1:5000", ""); code != http.StatusOK {
+			t.Errorf("expected exempt principal to pass, got %d", code)
+		}
+		if code := send("", "10.20.3.4:5000", ""); code != http.StatusOK {
+			t.Errorf("expected exempt network to pass, got %d", code)
+		}
+		if code := send("", "192.168.1.7:5000", ""); code != http.StatusOK {
+			t.Errorf("expected exempt IP to pass, got %d", code)
+		}
+		if code := send("", "10.0.0.9:5000", "0123456789abcdef0123456789abcdef"); code != http.StatusOK {
+			t.Errorf("expected bypass token to pass, got %d", code)
+		}
+	}
+
+	send("", "10.0.0.9:5000", "wrong-token")
+	if code := send("", "10.0.0.9:5000", "wrong-token"); code != http.StatusTooManyRequests {
+		t.Errorf("expected invalid bypass token to be limited, got %d", code)
+	}
+
+	if exempt := m.GetSnapshot().RateLimitExempt; exempt != 12 {
+		t.Errorf("expected 12 exempt requests counted, got %d", exempt)
+	}
+
+	if err := limiter.SetExemptions(RateLimitExemptions{Networks: []string{"not-an-ip"}}); err == nil {
+		t.Error("expected error for invalid network")
+	}
+}
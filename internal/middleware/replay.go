@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"hub-api-gateway/internal/httperr"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NonceStore remembers nonces until they expire
+type NonceStore interface {
+	// Remember stores the nonce and returns false if it was already seen
+	Remember(ctx context.Context, nonce string, ttl time.Duration) (bool, error)
+}
+
+// RedisNonceStore shares seen nonces across gateway instances
+type RedisNonceStore struct {
+	client *redis.Client
+}
+
+// NewRedisNonceStore creates a Redis-backed nonce store
+func NewRedisNonceStore(client *redis.Client) *RedisNonceStore {
+	return &RedisNonceStore{client: client}
+}
+
+// Remember stores the nonce with SET NX
+func (s *RedisNonceStore) Remember(ctx context.Context, nonce string, ttl time.Duration) (bool, error) {
+	return s.client.SetNX(ctx, "replay_nonce:"+nonce, 1, ttl).Result()
+}
+
+// MemoryNonceStore keeps seen nonces in process memory (single instance only)
+type MemoryNonceStore struct {
+	mu     sync.Mutex
+	nonces map[string]time.Time // nonce -> expiry
+}
+
+// NewMemoryNonceStore creates an in-memory nonce store
+func NewMemoryNonceStore() *MemoryNonceStore {
+	return &MemoryNonceStore{nonces: make(map[string]time.Time)}
+}
+
+// Remember stores the nonce, evicting expired entries
+func (s *MemoryNonceStore) Remember(_ context.Context, nonce string, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, expiry := range s.nonces {
+		if now.After(expiry) {
+			delete(s.nonces, key)
+		}
+	}
+
+	if _, seen := s.nonces[nonce]; seen {
+		return false, nil
+	}
+	s.nonces[nonce] = now.Add(ttl)
+	return true, nil
+}
+
+// ReplayGuard rejects stale or replayed requests on sensitive routes. Clients
+// send X-Timestamp (Unix seconds or RFC 3339) and a unique X-Nonce; partners
+// that sign requests must include both headers in the signed payload.
+type ReplayGuard struct {
+	maxAge time.Duration
+	store  NonceStore
+}
+
+// NewReplayGuard creates a replay guard accepting timestamps within maxAge
+func NewReplayGuard(maxAge time.Duration, store NonceStore) *ReplayGuard {
+	return &ReplayGuard{
+		maxAge: maxAge,
+		store:  store,
+	}
+}
+
+// Middleware enforces timestamp freshness and nonce uniqueness
+func (g *ReplayGuard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timestamp, err := parseTimestamp(r.Header.Get("X-Timestamp"))
+		if err != nil {
+			g.sendError(w, r, http.StatusBadRequest, "TIMESTAMP_INVALID", "A valid X-Timestamp header is required")
+			return
+		}
+
+		if skew := time.Since(timestamp); skew > g.maxAge || skew < -g.maxAge {
+			slog.WarnContext(r.Context(), "rejected stale request", "method", r.Method, "path", r.URL.Path, "skew", skew.Round(time.Second).String())
+			g.sendError(w, r, http.StatusUnauthorized, "REQUEST_EXPIRED",
+				fmt.Sprintf("X-Timestamp must be within %d seconds of server time", int(g.maxAge.Seconds())))
+			return
+		}
+
+		nonce := r.Header.Get("X-Nonce")
+		if nonce == "" || len(nonce) > 128 {
+			g.sendError(w, r, http.StatusBadRequest, "NONCE_INVALID", "An X-Nonce header of at most 128 characters is required")
+			return
+		}
+
+		// Nonces are scoped per caller so clients can't collide with each other
+		if userContext, ok := GetUserContext(r.Context()); ok {
+			nonce = userContext.UserID + ":" + nonce
+		}
+
+		// A nonce only needs to be remembered while its timestamp is acceptable
+		fresh, err := g.store.Remember(r.Context(), nonce, 2*g.maxAge)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "nonce store unavailable", "error", err)
+			g.sendError(w, r, http.StatusServiceUnavailable, "REPLAY_CHECK_UNAVAILABLE", "Unable to verify request uniqueness")
+			return
+		}
+		if !fresh {
+			slog.WarnContext(r.Context(), "replay detected", "method", r.Method, "path", r.URL.Path)
+			g.sendError(w, r, http.StatusConflict, "REPLAY_DETECTED", "Request nonce has already been used")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// parseTimestamp accepts Unix seconds or RFC 3339
+func parseTimestamp(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("timestamp missing")
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// sendError sends a JSON error response
+func (g *ReplayGuard) sendError(w http.ResponseWriter, r *http.Request, statusCode int, errorCode, message string) {
+	httperr.Send(w, r, statusCode, errorCode, message)
+}
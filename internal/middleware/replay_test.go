@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestReplayGuard(t *testing.T) {
+	guard := NewReplayGuard(time.Minute, NewMemoryNonceStore())
+	handler := guard.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	send := func(timestamp, nonce string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/orders", nil)
+		if timestamp != "" {
+			req.Header.Set("X-Timestamp", timestamp)
+		}
+		if nonce != "" {
+			req.Header.Set("X-Nonce", nonce)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+
+	if code := send(now, "nonce-1"); code != http.StatusOK {
+		t.Errorf("expected fresh request to pass, got %d", code)
+	}
+	if code := send(now, "nonce-1"); code != http.StatusConflict {
+		t.Errorf("expected replayed nonce to be rejected with 409, got %d", code)
+	}
+	if code := send(time.Now().Add(-2*time.Minute).Format(time.RFC3339), "nonce-2"); code != http.StatusUnauthorized {
+		t.Errorf("expected stale timestamp to be rejected with 401, got %d", code)
+	}
+	if code := send("", "nonce-3"); code != http.StatusBadRequest {
+		t.Errorf("expected missing timestamp to be rejected with 400, got %d", code)
+	}
+	if code := send(now, ""); code != http.StatusBadRequest {
+		t.Errorf("expected missing nonce to be rejected with 400, got %d", code)
+	}
+}
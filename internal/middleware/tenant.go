@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"hub-api-gateway/internal/httperr"
+	"hub-api-gateway/internal/router"
+)
+
+// Tenant sources, tried in the configured order
+const (
+	TenantSourceClaim     = "claim"     // tenant_id claim of the authenticated token
+	TenantSourceSubdomain = "subdomain" // acme.<base domain>
+	TenantSourceHeader    = "header"    // X-Tenant-ID, or the configured header
+)
+
+var (
+	// ErrTenantRequired is returned when no source names a tenant and there is no default
+	ErrTenantRequired = errors.New("tenant required")
+	// ErrTenantUnknown is returned for malformed tenant IDs and tenants not in the allowed list
+	ErrTenantUnknown = errors.New("unknown tenant")
+	// ErrTenantMismatch is returned when the subdomain or header names another
+	// tenant than the authenticated token
+	ErrTenantMismatch = errors.New("tenant does not match the token")
+)
+
+// tenantContextKey is the request context key of the resolved tenant
+type tenantContextKey struct{}
+
+// TenantOptions configures tenant resolution
+type TenantOptions struct {
+	Sources    []string // claim, subdomain and header, in order of precedence
+	Header     string   // Header naming the tenant, e.g. X-Tenant-ID
+	BaseDomain string   // Domain whose subdomains name tenants, e.g. hub.com
+	Allowed    []string // Known tenants; empty accepts any well-formed tenant ID
+	Default    string   // Tenant of requests no source names one for
+	Required   bool     // Reject requests without a tenant
+}
+
+// TenantResolver works out which tenant a request belongs to
+type TenantResolver struct {
+	options TenantOptions
+	allowed map[string]bool
+}
+
+// NewTenantResolver creates a tenant resolver
+func NewTenantResolver(options TenantOptions) *TenantResolver {
+	options.BaseDomain = strings.ToLower(strings.Trim(options.BaseDomain, "."))
+	allowed := make(map[string]bool, len(options.Allowed))
+	for _, tenant := range options.Allowed {
+		allowed[strings.ToLower(tenant)] = true
+	}
+	return &TenantResolver{options: options, allowed: allowed}
+}
+
+// Resolve returns the request's tenant, "" when it has none. The first
+// source naming a tenant wins, but the authenticated token's tenant_id claim
+// can't be contradicted by the subdomain or header.
+func (t *TenantResolver) Resolve(r *http.Request) (string, error) {
+	claimed := ""
+	if userContext, ok := GetUserContext(r.Context()); ok {
+		claimed = strings.ToLower(userContext.TenantID)
+	}
+
+	tenant := ""
+	for _, source := range t.options.Sources {
+		var value string
+		switch source {
+		case TenantSourceClaim:
+			value = claimed
+		case TenantSourceSubdomain:
+			value = t.subdomain(r.Host)
+		case TenantSourceHeader:
+			value = strings.ToLower(strings.TrimSpace(r.Header.Get(t.options.Header)))
+		}
+		if value == "" {
+			continue
+		}
+		if claimed != "" && value != claimed {
+			return "", ErrTenantMismatch
+		}
+		if tenant == "" {
+			tenant = value
+		}
+	}
+
+	if tenant == "" {
+		tenant = t.options.Default
+	}
+	if tenant == "" {
+		if t.options.Required {
+			return "", ErrTenantRequired
+		}
+		return "", nil
+	}
+	if !router.TenantIDPattern.MatchString(tenant) || (len(t.allowed) > 0 && !t.allowed[tenant]) {
+		return "", ErrTenantUnknown
+	}
+	return tenant, nil
+}
+
+// subdomain returns the label of host directly under the base domain
+func (t *TenantResolver) subdomain(host string) string {
+	if t.options.BaseDomain == "" {
+		return ""
+	}
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		host = hostname
+	}
+	prefix, ok := strings.CutSuffix(strings.ToLower(host), "."+t.options.BaseDomain)
+	if !ok {
+		return ""
+	}
+	if i := strings.LastIndex(prefix, "."); i >= 0 {
+		prefix = prefix[i+1:]
+	}
+	return prefix
+}
+
+// Middleware resolves the tenant of each request into its context. It must
+// run after authentication so the token's claim is known, and before rate
+// limiting, which is scoped by tenant.
+func (t *TenantResolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, err := t.Resolve(r)
+		if err != nil {
+			slog.WarnContext(r.Context(), "tenant rejected", "host", r.Host, "header", r.Header.Get(t.options.Header), "error", err)
+			switch {
+			case errors.Is(err, ErrTenantMismatch):
+				httperr.Send(w, r, http.StatusForbidden, "TENANT_MISMATCH", "The requested tenant doesn't match your account")
+			case errors.Is(err, ErrTenantRequired):
+				httperr.Send(w, r, http.StatusBadRequest, "TENANT_REQUIRED", "Tenant is required")
+			default:
+				httperr.Send(w, r, http.StatusNotFound, "TENANT_NOT_FOUND", "Unknown tenant")
+			}
+			return
+		}
+		if tenant == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), tenant)))
+	})
+}
+
+// WithTenant returns a context carrying the request's tenant
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant resolved for the request, "" when it has none
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenant
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTenantResolver(t *testing.T) {
+	resolver := NewTenantResolver(TenantOptions{
+		Sources:    []string{TenantSourceClaim, TenantSourceSubdomain, TenantSourceHeader},
+		Header:     "X-Tenant-ID",
+		BaseDomain: "hub.com",
+		Allowed:    []string{"acme", "globex"},
+		Required:   true,
+	})
+	handler := resolver.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(TenantFromContext(r.Context())))
+	}))
+
+	serve := func(host, header string, userContext *UserContext) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "/api/v1/orders", nil)
+		req.Host = host
+		if header != "" {
+			req.Header.Set("X-Tenant-ID", header)
+		}
+		if userContext != nil {
+			req = req.WithContext(context.WithValue(req.Context(), "user", userContext))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := serve("acme.hub.com:443", "", nil); rec.Code != http.StatusOK || rec.Body.String() != "acme" {
+		t.Errorf("subdomain: got %d %q, want acme", rec.Code, rec.Body.String())
+	}
+	if rec := serve("api.example.com", "Globex", nil); rec.Code != http.StatusOK || rec.Body.String() != "globex" {
+		t.Errorf("header: got %d %q, want globex", rec.Code, rec.Body.String())
+	}
+	if rec := serve("api.example.com", "", &UserContext{UserID: "u1", TenantID: "acme"}); rec.Body.String() != "acme" {
+		t.Errorf("claim: got %d %q, want acme", rec.Code, rec.Body.String())
+	}
+
+	// The token's tenant can't be overridden by the caller
+	if rec := serve("globex.hub.com", "", &UserContext{UserID: "u1", TenantID: "acme"}); rec.Code != http.StatusForbidden {
+		t.Errorf("mismatched subdomain got %d, want 403", rec.Code)
+	}
+	if rec := serve("api.example.com", "initech", nil); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown tenant got %d, want 404", rec.Code)
+	}
+	if rec := serve("api.example.com", "", nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("missing tenant got %d, want 400", rec.Code)
+	}
+}
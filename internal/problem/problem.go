@@ -0,0 +1,94 @@
+// Package problem renders gateway-generated errors as RFC 7807
+// application/problem+json documents when enabled by configuration.
+package problem
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of problem documents
+const ContentType = "application/problem+json"
+
+// Details is an RFC 7807 problem document. Code and RetryAfter are extension
+// members carrying the gateway's stable error code and retry hint.
+type Details struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	Code     string `json:"code"`
+
+	// RetryAfter mirrors the Retry-After header for errors that can be retried
+	RetryAfter int `json:"retry_after_seconds,omitempty"`
+
+	// Details carries structured backend error details (e.g. gRPC ErrorInfo)
+	Details []map[string]interface{} `json:"details,omitempty"`
+}
+
+// statuses are the gateway-generated error statuses rendered as problems
+var statuses = map[int]bool{
+	http.StatusUnauthorized:       true,
+	http.StatusForbidden:          true,
+	http.StatusTooManyRequests:    true,
+	http.StatusServiceUnavailable: true,
+}
+
+var (
+	mu          sync.RWMutex
+	enabled     bool
+	docsBaseURL string
+)
+
+// Configure enables problem+json responses; type URIs are built from docsBaseURL
+func Configure(enable bool, baseURL string) {
+	mu.Lock()
+	defer mu.Unlock()
+	enabled = enable
+	docsBaseURL = strings.TrimRight(baseURL, "/")
+}
+
+// Applies returns whether errors with this status are rendered as problems
+func Applies(status int) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return enabled && statuses[status]
+}
+
+// New builds a problem document for a gateway error code
+func New(status int, code, detail string) Details {
+	return Details{
+		Type:   TypeURI(code),
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   code,
+	}
+}
+
+// TypeURI returns the public documentation URI of an error code,
+// e.g. CIRCUIT_BREAKER_OPEN -> {docs}/errors/circuit-breaker-open
+func TypeURI(code string) string {
+	mu.RLock()
+	base := docsBaseURL
+	mu.RUnlock()
+
+	if base == "" {
+		return "about:blank"
+	}
+	return base + "/errors/" + strings.ReplaceAll(strings.ToLower(code), "_", "-")
+}
+
+// Write sends a problem document
+func Write(w http.ResponseWriter, details Details) {
+	w.Header().Set("Content-Type", ContentType)
+	w.WriteHeader(details.Status)
+
+	if err := json.NewEncoder(w).Encode(details); err != nil {
+		slog.Error("failed to encode problem response", "error", err)
+	}
+}
@@ -0,0 +1,48 @@
+package problem
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestApplies(t *testing.T) {
+	Configure(false, "")
+	if Applies(http.StatusTooManyRequests) {
+		t.Errorf("expected problems to be disabled by default")
+	}
+
+	Configure(true, "https://docs.example.com/api/")
+	defer Configure(false, "")
+
+	if !Applies(http.StatusTooManyRequests) || !Applies(http.StatusServiceUnavailable) {
+		t.Errorf("expected 429 and 503 to be rendered as problems")
+	}
+	if Applies(http.StatusNotFound) {
+		t.Errorf("expected 404 to keep the legacy format")
+	}
+}
+
+func TestWrite(t *testing.T) {
+	Configure(true, "https://docs.example.com/api")
+	defer Configure(false, "")
+
+	rec := httptest.NewRecorder()
+	Write(rec, New(http.StatusServiceUnavailable, "CIRCUIT_BREAKER_OPEN", "Service unavailable"))
+
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Errorf("expected %s but got %s", ContentType, ct)
+	}
+
+	var body Details
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON body: %v", err)
+	}
+	if body.Type != "https://docs.example.com/api/errors/circuit-breaker-open" {
+		t.Errorf("unexpected type URI: %s", body.Type)
+	}
+	if body.Status != http.StatusServiceUnavailable || body.Title != "Service Unavailable" || body.Code != "CIRCUIT_BREAKER_OPEN" {
+		t.Errorf("unexpected problem: %+v", body)
+	}
+}
@@ -0,0 +1,207 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"hub-api-gateway/internal/logging"
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/middleware"
+	"hub-api-gateway/internal/router"
+	"hub-api-gateway/internal/trace"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// PartialResponseHeader lists the branches missing from an aggregate response
+const PartialResponseHeader = "X-Partial-Response"
+
+// aggregateService is the service label of aggregate route metrics
+const aggregateService = "aggregate"
+
+// errServiceDraining marks branches skipped because their service is drained
+var errServiceDraining = errors.New("service is draining")
+
+// branchResult is the outcome of one branch of an aggregate route
+type branchResult struct {
+	body []byte
+	err  error
+}
+
+// branchFailure describes a failed branch in the merged response
+type branchFailure struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// handleAggregate calls every branch of an aggregate route concurrently and
+// merges their responses under the branch names. Failed optional branches
+// are null and described under "errors"; a failed required branch fails the
+// whole request with its error.
+func (h *ProxyHandler) handleAggregate(w http.ResponseWriter, r *http.Request, route *router.Route, upstreamPath string, pathVars map[string]string, userContext *middleware.UserContext, vars router.HeaderVars) {
+	startTime := time.Now()
+	requestTrace := trace.FromContext(r.Context())
+	priority := RoutePriority(route)
+
+	targets := route.Targets()
+	results := make([]branchResult, len(targets))
+	var wg sync.WaitGroup
+	for i := range targets {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			branchStart := time.Now()
+			target := &targets[i]
+			results[i].body, results[i].err = h.callBranch(r, target, upstreamPath, priority, userContext, pathVars, vars)
+			requestTrace.Record(metrics.StageBackend, "aggregate branch", time.Since(branchStart), map[string]string{
+				"branch":     route.Branches[i].Name,
+				"service":    target.Service,
+				"grpcMethod": target.GRPCService + "/" + target.GRPCMethod,
+				"code":       status.Code(results[i].err).String(),
+			})
+		}(i)
+	}
+	wg.Wait()
+	if h.clientAborted(r, route) {
+		h.fail(w, r, route, statusClientClosedRequest, "REQUEST_CANCELLED", "Client closed the request")
+		return
+	}
+
+	merged := make(map[string]json.RawMessage, len(targets)+1)
+	failures := make(map[string]branchFailure)
+	var partial []string
+	for i, branch := range route.Branches {
+		err := results[i].err
+		if err == nil {
+			merged[branch.Name] = results[i].body
+			continue
+		}
+
+		h.backendError(r, &targets[i], err)
+		slog.WarnContext(r.Context(), "aggregate branch failed", "route", route.Name, "branch", branch.Name, "error", err)
+		if branch.Required {
+			h.metrics.RecordRequest(route.Name, aggregateService, time.Since(startTime), false)
+			h.failBranch(w, r, &targets[i], err)
+			return
+		}
+
+		_, code, message := h.branchErrorMapping(err)
+		failures[branch.Name] = branchFailure{Error: message, Code: code}
+		merged[branch.Name] = json.RawMessage("null")
+		partial = append(partial, branch.Name)
+	}
+
+	if len(failures) > 0 {
+		encoded, _ := json.Marshal(failures)
+		merged[router.AggregateErrorsField] = encoded
+		w.Header().Set(PartialResponseHeader, strings.Join(partial, ","))
+	}
+
+	body, err := json.Marshal(merged)
+	if err != nil {
+		h.fail(w, r, route, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to encode response")
+		return
+	}
+	h.metrics.RecordRequest(route.Name, aggregateService, time.Since(startTime), len(failures) == 0)
+	slog.InfoContext(r.Context(), "request completed", "method", r.Method, "path", r.URL.Path,
+		"duration_ms", time.Since(startTime).Milliseconds(), "failed_branches", len(failures))
+	writeJSONBody(w, http.StatusOK, body)
+}
+
+// callBranch calls one branch of an aggregate route and returns its JSON
+func (h *ProxyHandler) callBranch(r *http.Request, target *router.Route, upstreamPath string, priority Priority, userContext *middleware.UserContext, pathVars map[string]string, vars router.HeaderVars) ([]byte, error) {
+	serviceName := target.GetTargetService()
+	if _, drained := h.registry.GetDrainState(serviceName); drained && !target.HasTag("tier", "critical") {
+		return nil, fmt.Errorf("%s: %w", serviceName, errServiceDraining)
+	}
+
+	method, err := h.descriptors.FindMethod(target)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	// Branches only receive the path variables their request has fields for
+	fields := method.Input().Fields()
+	branchVars := make(map[string]string, len(pathVars))
+	for variable, value := range pathVars {
+		if fields.ByName(protoreflect.Name(target.PathFieldFor(variable))) != nil {
+			branchVars[variable] = value
+		}
+	}
+
+	var userID string
+	if userContext != nil {
+		userID = userContext.UserID
+	}
+	request, response, err := bindRequest(method, target, nil, branchVars, userID)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	md, err := h.outgoingMetadata(r, target, upstreamPath, priority, userContext, branchVars, vars)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "failed to authorize backend call")
+	}
+	ctx, cancel := upstreamContext(r, h.upstreamTimeout(target, serviceName))
+	defer cancel()
+	ctx = logging.WithRequestID(ctx, logging.RequestID(r.Context()))
+	ctx = metadata.NewOutgoingContext(h.withFault(ctx, target), md)
+
+	err = callWithBreaker(h.registry.RouteCircuitBreaker(target), func() error {
+		conn, err := h.registry.GetConnection(serviceName)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errNoConnection, err)
+		}
+		if err := h.registry.CheckVersion(r.Context(), serviceName, conn); err != nil {
+			return err
+		}
+		if err := h.registry.CheckAvailable(serviceName); err != nil {
+			return err
+		}
+		return h.invoke(ctx, r, serviceName, conn, fullMethodName(method), request, response)
+	})
+	h.registry.RecordOutcome(serviceName, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.encodeJSON(response, target)
+}
+
+// branchErrorMapping returns the HTTP status, error code and message of a failed branch
+func (h *ProxyHandler) branchErrorMapping(err error) (int, string, string) {
+	switch {
+	case errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrTooManyRequests):
+		return http.StatusServiceUnavailable, "CIRCUIT_BREAKER_OPEN", "Service is temporarily unavailable (circuit breaker open)"
+	case errors.Is(err, errNoConnection), errors.Is(err, ErrBackendEjected), errors.Is(err, errServiceDraining):
+		return http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE", "Service is unavailable"
+	case errors.Is(err, ErrIncompatibleBackend):
+		return http.StatusServiceUnavailable, "BACKEND_INCOMPATIBLE", "Service is running an incompatible version"
+	case errors.Is(err, context.DeadlineExceeded):
+		err = status.Error(codes.DeadlineExceeded, err.Error())
+	}
+
+	st := status.Convert(err)
+	mapping := h.grpcErrorMapping(st.Code())
+	return mapping.Status, mapping.Code, st.Message()
+}
+
+// failBranch fails an aggregate request with the error of a required branch
+func (h *ProxyHandler) failBranch(w http.ResponseWriter, r *http.Request, target *router.Route, err error) {
+	if _, ok := status.FromError(err); ok {
+		h.handleGRPCError(w, r, target, err)
+		return
+	}
+	statusCode, code, message := h.branchErrorMapping(err)
+	h.fail(w, r, target, statusCode, code, message)
+}
@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestHandleAggregate(t *testing.T) {
+	descriptors := NewDescriptorRegistry()
+	if err := descriptors.LoadDescriptorSet(writeDescriptorSet(t)); err != nil {
+		t.Fatalf("failed to load descriptor set: %v", err)
+	}
+
+	registry := NewServiceRegistry(&config.Config{Services: map[string]config.ServiceConfig{
+		"loyalty-service": {},
+		"rewards-service": {},
+	}})
+	healthy, _ := flakyBackend(t, 0, codes.OK)
+	failing, _ := flakyBackend(t, 1000, codes.Unavailable)
+	registry.connections["loyalty-service"] = newConnPool(healthy)
+	registry.connections["rewards-service"] = newConnPool(failing)
+
+	h := NewProxyHandler(registry, metrics.NewMetrics(), nil)
+	h.SetDescriptors(descriptors)
+
+	route := &router.Route{
+		Name:       "dashboard",
+		Path:       "/api/v1/dashboard/{program}",
+		Method:     "GET",
+		Type:       router.RouteTypeAggregate,
+		PathFields: map[string]string{"program": "program_id"},
+		Branches: []router.AggregateBranch{
+			{Name: "points", Service: "loyalty-service", GRPCService: "loyalty.LoyaltyService", GRPCMethod: "GetPoints", Required: true},
+			{Name: "rewards", Service: "rewards-service", GRPCService: "loyalty.LoyaltyService", GRPCMethod: "GetPoints"},
+		},
+	}
+	if err := descriptors.CheckRoute(route); err != nil {
+		t.Fatalf("expected aggregate route to be valid: %v", err)
+	}
+
+	// A failed optional branch leaves a partial response
+	w := httptest.NewRecorder()
+	h.HandleRequest(w, httptest.NewRequest("GET", "/api/v1/dashboard/42", nil), route)
+	if w.Code != http.StatusOK || w.Header().Get(PartialResponseHeader) != "rewards" {
+		t.Fatalf("expected partial 200 but got %d %v: %s", w.Code, w.Header(), w.Body)
+	}
+	var body map[string]json.RawMessage
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if string(body["rewards"]) != "null" || len(body["points"]) == 0 {
+		t.Errorf("unexpected merged response: %s", w.Body)
+	}
+	var failures map[string]branchFailure
+	json.Unmarshal(body[router.AggregateErrorsField], &failures)
+	if failures["rewards"].Code != "SERVICE_UNAVAILABLE" {
+		t.Errorf("expected rewards failure to be reported but got %v", failures)
+	}
+
+	// A failed required branch fails the request
+	route.Branches[0], route.Branches[1] = route.Branches[1], route.Branches[0]
+	route.Branches[0].Required = true
+	w = httptest.NewRecorder()
+	h.HandleRequest(w, httptest.NewRequest("GET", "/api/v1/dashboard/42", nil), route)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected 503 for a failed required branch but got %d: %s", w.Code, w.Body)
+	}
+}
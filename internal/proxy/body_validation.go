@@ -0,0 +1,275 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// fieldViolation is a problem with one field of a request, reported like a
+// backend's BadRequest detail
+type fieldViolation struct {
+	Field       string `json:"field"`
+	Description string `json:"description"`
+}
+
+// failValidation rejects a request with field-level violations
+func (h *ProxyHandler) failValidation(w http.ResponseWriter, r *http.Request, route *router.Route, violations []fieldViolation) {
+	details := []map[string]interface{}{{"type": "BadRequest", "field_violations": violations}}
+	h.failWithDetails(w, r, route, http.StatusBadRequest, "INVALID_REQUEST", "Request has invalid fields", 0, details)
+}
+
+// validateBody explains, field by field, why a JSON body doesn't decode into
+// the message: unknown fields, wrong JSON types, unknown enum values and
+// integers out of range
+func validateBody(message protoreflect.MessageDescriptor, body []byte) []fieldViolation {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return []fieldViolation{{Field: "", Description: "body is not valid JSON"}}
+	}
+
+	var violations []fieldViolation
+	checkMessage(message, value, "", &violations)
+	return violations
+}
+
+// checkMessage checks a JSON value against a message
+func checkMessage(message protoreflect.MessageDescriptor, value any, path string, violations *[]fieldViolation) {
+	// Well-known types have their own JSON forms; protojson reports those
+	if message.ParentFile().Package() == "google.protobuf" {
+		return
+	}
+
+	object, ok := value.(map[string]any)
+	if !ok {
+		*violations = append(*violations, fieldViolation{Field: path, Description: "must be an object"})
+		return
+	}
+
+	for _, key := range sortedKeys(object) {
+		fieldPath := key
+		if path != "" {
+			fieldPath = path + "." + key
+		}
+
+		fields := message.Fields()
+		field := fields.ByJSONName(key)
+		if field == nil {
+			field = fields.ByName(protoreflect.Name(key))
+		}
+		if field == nil {
+			*violations = append(*violations, fieldViolation{Field: fieldPath, Description: "unknown field"})
+			continue
+		}
+		checkField(field, object[key], fieldPath, violations)
+	}
+}
+
+// checkField checks a JSON value against a field, including lists and maps
+func checkField(field protoreflect.FieldDescriptor, value any, path string, violations *[]fieldViolation) {
+	if value == nil {
+		return
+	}
+
+	switch {
+	case field.IsMap():
+		object, ok := value.(map[string]any)
+		if !ok {
+			*violations = append(*violations, fieldViolation{Field: path, Description: "must be an object"})
+			return
+		}
+		for _, key := range sortedKeys(object) {
+			checkValue(field.MapValue(), object[key], path+"."+key, violations)
+		}
+	case field.IsList():
+		list, ok := value.([]any)
+		if !ok {
+			*violations = append(*violations, fieldViolation{Field: path, Description: "must be an array"})
+			return
+		}
+		for i, element := range list {
+			checkValue(field, element, fmt.Sprintf("%s[%d]", path, i), violations)
+		}
+	default:
+		checkValue(field, value, path, violations)
+	}
+}
+
+// checkValue checks a single JSON value against a field's type
+func checkValue(field protoreflect.FieldDescriptor, value any, path string, violations *[]fieldViolation) {
+	if value == nil {
+		return
+	}
+
+	var problem string
+	switch field.Kind() {
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		checkMessage(field.Message(), value, path, violations)
+		return
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		if _, ok := value.(string); !ok {
+			problem = "must be a string"
+		}
+	case protoreflect.BoolKind:
+		if _, ok := value.(bool); !ok {
+			problem = "must be a boolean"
+		}
+	case protoreflect.EnumKind:
+		problem = checkEnum(field.Enum(), value)
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		problem = checkFloat(value)
+	default:
+		problem = checkInteger(field.Kind(), value)
+	}
+
+	if problem != "" {
+		*violations = append(*violations, fieldViolation{Field: path, Description: problem})
+	}
+}
+
+// checkEnum accepts an enum value name or number
+func checkEnum(enum protoreflect.EnumDescriptor, value any) string {
+	switch v := value.(type) {
+	case json.Number:
+		if _, err := v.Int64(); err != nil {
+			return "must be an enum value name or number"
+		}
+		return ""
+	case string:
+		if enum.Values().ByName(protoreflect.Name(v)) != nil {
+			return ""
+		}
+		names := make([]string, enum.Values().Len())
+		for i := range names {
+			names[i] = string(enum.Values().Get(i).Name())
+		}
+		return "must be one of " + strings.Join(names, ", ")
+	default:
+		return "must be an enum value name or number"
+	}
+}
+
+// checkFloat accepts numbers and numeric strings, including NaN and Infinity
+func checkFloat(value any) string {
+	var text string
+	switch v := value.(type) {
+	case json.Number:
+		text = v.String()
+	case string:
+		if v == "NaN" || v == "Infinity" || v == "-Infinity" {
+			return ""
+		}
+		text = v
+	default:
+		return "must be a number"
+	}
+	if _, err := strconv.ParseFloat(text, 64); err != nil {
+		return "must be a number"
+	}
+	return ""
+}
+
+// checkInteger accepts integral numbers and numeric strings in the range of the kind
+func checkInteger(kind protoreflect.Kind, value any) string {
+	var text string
+	switch v := value.(type) {
+	case json.Number:
+		text = v.String()
+	case string:
+		text = v
+	default:
+		return "must be an integer"
+	}
+
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil || n != math.Trunc(n) {
+		return "must be an integer"
+	}
+
+	var low, high float64
+	switch kind {
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		low, high = math.MinInt32, math.MaxInt32
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		low, high = 0, math.MaxUint32
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		low, high = 0, math.MaxUint64
+	default:
+		low, high = math.MinInt64, math.MaxInt64
+	}
+	if n < low || n > high {
+		return fmt.Sprintf("must be between %.0f and %.0f", low, high)
+	}
+	return ""
+}
+
+// missingFields returns the route's required fields left unset once the
+// request is bound, from the body, path variables or the caller's identity
+func missingFields(request proto.Message, required []string) []fieldViolation {
+	var violations []fieldViolation
+	for _, path := range required {
+		if !fieldSet(request.ProtoReflect(), strings.Split(path, ".")) {
+			violations = append(violations, fieldViolation{Field: path, Description: "is required"})
+		}
+	}
+	return violations
+}
+
+// fieldSet reports whether the field at path is set to a non-default value
+func fieldSet(message protoreflect.Message, path []string) bool {
+	field := message.Descriptor().Fields().ByName(protoreflect.Name(path[0]))
+	if field == nil || !message.Has(field) {
+		return false
+	}
+	if len(path) == 1 {
+		return true
+	}
+	if field.Message() == nil || field.IsList() || field.IsMap() {
+		return false
+	}
+	return fieldSet(message.Get(field).Message(), path[1:])
+}
+
+// checkRequiredFields verifies every required field path names a field,
+// through singular message fields
+func checkRequiredFields(message protoreflect.MessageDescriptor, required []string) error {
+	for _, path := range required {
+		descriptor := message
+		segments := strings.Split(path, ".")
+		for i, segment := range segments {
+			field := descriptor.Fields().ByName(protoreflect.Name(segment))
+			if field == nil {
+				return fmt.Errorf("required field %q: no field %s in %s", path, segment, descriptor.FullName())
+			}
+			if i < len(segments)-1 {
+				if field.Message() == nil || field.IsList() || field.IsMap() {
+					return fmt.Errorf("required field %q: %s is not a message field", path, segment)
+				}
+				descriptor = field.Message()
+			}
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a JSON object in order, for stable reports
+func sortedKeys(object map[string]any) []string {
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
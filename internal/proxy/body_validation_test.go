@@ -0,0 +1,89 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestHandleRequest_BodyValidation(t *testing.T) {
+	descriptors := NewDescriptorRegistry()
+	if err := descriptors.LoadDescriptorSet(writeDescriptorSet(t)); err != nil {
+		t.Fatalf("failed to load descriptor set: %v", err)
+	}
+	registry := NewServiceRegistry(&config.Config{Services: map[string]config.ServiceConfig{"loyalty-service": {}}})
+	conn, _ := flakyBackend(t, 0, codes.OK)
+	registry.connections["loyalty-service"] = newConnPool(conn)
+
+	h := NewProxyHandler(registry, metrics.NewMetrics(), nil)
+	h.SetDescriptors(descriptors)
+
+	route := &router.Route{
+		Name:           "get-points",
+		Path:           "/api/v1/points",
+		Method:         "POST",
+		Service:        "loyalty-service",
+		GRPCService:    "loyalty.LoyaltyService",
+		GRPCMethod:     "GetPoints",
+		RequiredFields: []string{"note"},
+	}
+	if err := descriptors.CheckRoute(route); err != nil {
+		t.Fatalf("expected route to be valid: %v", err)
+	}
+
+	violations := func(body string) []fieldViolation {
+		t.Helper()
+		w := httptest.NewRecorder()
+		h.HandleRequest(w, httptest.NewRequest("POST", "/api/v1/points", strings.NewReader(body)), route)
+		if w.Code == http.StatusOK {
+			return nil
+		}
+		var response struct {
+			Error struct {
+				Code    string `json:"code"`
+				Details []struct {
+					Violations []fieldViolation `json:"field_violations"`
+				} `json:"details"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || w.Code != http.StatusBadRequest || len(response.Error.Details) != 1 {
+			t.Fatalf("expected 400 with field violations but got %d: %s", w.Code, w.Body)
+		}
+		return response.Error.Details[0].Violations
+	}
+
+	got := violations(`{"program_id": "abc", "colour": "red", "note": 3}`)
+	want := []fieldViolation{
+		{Field: "colour", Description: "unknown field"},
+		{Field: "note", Description: "must be a string"},
+		{Field: "program_id", Description: "must be an integer"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %v but got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected %v but got %v", want[i], got[i])
+		}
+	}
+
+	if got := violations(`{"program_id": "7"}`); len(got) != 1 || got[0].Field != "note" || got[0].Description != "is required" {
+		t.Errorf("expected note to be required but got %v", got)
+	}
+	if got := violations(`{"program_id": 7, "note": "birthday"}`); got != nil {
+		t.Errorf("expected a valid body to pass but got %v", got)
+	}
+
+	route.RequiredFields = []string{"missing"}
+	if err := descriptors.CheckRoute(route); err == nil {
+		t.Errorf("expected an unknown required field to fail the route check")
+	}
+}
@@ -0,0 +1,47 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errNoConnection marks failures to obtain a backend connection
+var errNoConnection = errors.New("no connection to backend")
+
+// callWithBreaker runs an upstream call through the breaker, or directly when
+// circuit breaking is disabled (nil breaker)
+func callWithBreaker(cb *CircuitBreaker, fn func() error) error {
+	if cb == nil {
+		return fn()
+	}
+	return cb.CallCounting(fn, isBreakerFailure)
+}
+
+// isCancellation reports whether a call ended because its caller gave up,
+// e.g. a client that disconnected
+func isCancellation(err error) bool {
+	return errors.Is(err, context.Canceled) || status.Code(err) == codes.Canceled
+}
+
+// isBreakerFailure reports whether an upstream error means the backend is
+// unhealthy. Errors about the request itself (NotFound, InvalidArgument, ...),
+// client cancellations, incompatible versions and ejected backends leave the
+// breaker alone.
+func isBreakerFailure(err error) bool {
+	switch {
+	case errors.Is(err, errNoConnection):
+		return true
+	case errors.Is(err, ErrIncompatibleBackend), errors.Is(err, ErrBackendEjected), errors.Is(err, context.Canceled):
+		return false
+	}
+
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown:
+		return true
+	default:
+		return false
+	}
+}
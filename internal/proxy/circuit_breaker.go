@@ -35,12 +35,22 @@ type CircuitBreaker struct {
 	maxFailures      uint32        // Max failures before opening
 	resetTimeout     time.Duration // Time to wait before attempting recovery
 	halfOpenRequests uint32        // Number of test requests in half-open state
+	failureRate      float64       // Failed share of calls that opens the breaker; 0 counts consecutive failures
+	minRequests      uint32        // Calls in the window before the failure rate is judged
+	window           time.Duration // How long calls are counted before the window restarts
 
 	mu              sync.RWMutex
 	state           CircuitState
 	failures        uint32
 	lastFailureTime time.Time
 	successCount    uint32
+	probes          chan struct{} // Tokens of the current half-open period, one per probe in flight
+	requests        uint32        // Calls in the current window (failure-rate mode)
+	windowStart     time.Time     // Start of the current window (failure-rate mode)
+	forcedOpen      bool          // Opened by an operator; stays open until closed or reset
+
+	// Observer of state changes, called outside the lock
+	onStateChange func(name string, from, to CircuitState)
 }
 
 // CircuitBreakerConfig holds configuration for circuit breaker
@@ -48,6 +58,12 @@ type CircuitBreakerConfig struct {
 	MaxFailures      uint32        // Default: 5
 	ResetTimeout     time.Duration // Default: 30s
 	HalfOpenRequests uint32        // Default: 3
+
+	// With a failure rate the breaker opens once that share of the calls in a
+	// window failed, instead of after MaxFailures consecutive failures
+	FailureRate float64       // 0..1, default: 0 (consecutive counting)
+	MinRequests uint32        // Default: 20
+	Window      time.Duration // Default: 30s
 }
 
 var (
@@ -68,78 +84,167 @@ func NewCircuitBreaker(name string, config CircuitBreakerConfig) *CircuitBreaker
 	if config.HalfOpenRequests == 0 {
 		config.HalfOpenRequests = 3
 	}
+	if config.FailureRate > 0 {
+		if config.MinRequests == 0 {
+			config.MinRequests = 20
+		}
+		if config.Window == 0 {
+			config.Window = 30 * time.Second
+		}
+	}
 
 	return &CircuitBreaker{
 		name:             name,
 		maxFailures:      config.MaxFailures,
 		resetTimeout:     config.ResetTimeout,
 		halfOpenRequests: config.HalfOpenRequests,
+		failureRate:      config.FailureRate,
+		minRequests:      config.MinRequests,
+		window:           config.Window,
 		state:            StateClosed,
+		windowStart:      time.Now(),
 	}
 }
 
 // Call executes a function with circuit breaker protection
 func (cb *CircuitBreaker) Call(fn func() error) error {
-	if err := cb.beforeRequest(); err != nil {
+	return cb.CallCounting(fn, func(error) bool { return true })
+}
+
+// CallCounting is Call where only errors for which isFailure returns true
+// count against the breaker; others (e.g. a client's invalid argument) count
+// as successes since the backend answered
+func (cb *CircuitBreaker) CallCounting(fn func() error, isFailure func(error) bool) error {
+	probe, err := cb.beforeRequest()
+	if err != nil {
 		return err
 	}
 
-	err := fn()
-	cb.afterRequest(err)
+	err = fn()
+	switch {
+	case isCancellation(err):
+		// The caller gave up; the call says nothing about the backend
+		if probe != nil {
+			<-probe
+		}
+	case err != nil && !isFailure(err):
+		cb.afterRequest(nil, probe)
+	default:
+		cb.afterRequest(err, probe)
+	}
 	return err
 }
 
-// beforeRequest checks if request should be allowed
-func (cb *CircuitBreaker) beforeRequest() error {
+// Config returns the breaker's settings
+func (cb *CircuitBreaker) Config() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		MaxFailures:      cb.maxFailures,
+		ResetTimeout:     cb.resetTimeout,
+		HalfOpenRequests: cb.halfOpenRequests,
+		FailureRate:      cb.failureRate,
+		MinRequests:      cb.minRequests,
+		Window:           cb.window,
+	}
+}
+
+// OnStateChange registers an observer of the breaker's state changes. It must
+// be set before the breaker is used.
+func (cb *CircuitBreaker) OnStateChange(fn func(name string, from, to CircuitState)) {
+	cb.onStateChange = fn
+}
+
+// notify reports a state change to the observer
+func (cb *CircuitBreaker) notify(from, to CircuitState) {
+	if from != to && cb.onStateChange != nil {
+		cb.onStateChange(cb.name, from, to)
+	}
+}
+
+// beforeRequest checks if request should be allowed. Probes of a half-open
+// breaker get the token channel they must give back to afterRequest.
+func (cb *CircuitBreaker) beforeRequest() (chan struct{}, error) {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	from := cb.state
+	probe, err := cb.admit()
+	to := cb.state
+	cb.mu.Unlock()
 
-	switch cb.state {
-	case StateClosed:
-		// Normal operation - allow request
-		return nil
+	cb.notify(from, to)
+	return probe, err
+}
 
+// admit decides whether a request may pass, probing an open breaker once its
+// reset timeout has passed
+func (cb *CircuitBreaker) admit() (chan struct{}, error) {
+	if cb.forcedOpen {
+		return nil, ErrCircuitOpen
+	}
+
+	switch cb.state {
 	case StateOpen:
 		// Check if enough time has passed to try recovery
-		if time.Since(cb.lastFailureTime) > cb.resetTimeout {
-			cb.state = StateHalfOpen
-			cb.successCount = 0
-			return nil
+		if time.Since(cb.lastFailureTime) <= cb.resetTimeout {
+			return nil, ErrCircuitOpen
 		}
-		return ErrCircuitOpen
+		cb.state = StateHalfOpen
+		cb.successCount = 0
+		cb.probes = make(chan struct{}, cb.halfOpenRequests)
+		fallthrough
 
 	case StateHalfOpen:
-		// Allow limited requests to test service
-		if cb.successCount >= cb.halfOpenRequests {
-			return ErrTooManyRequests
+		// Only as many probes as there are tokens may be in flight at once,
+		// however many requests arrive together
+		select {
+		case cb.probes <- struct{}{}:
+			return cb.probes, nil
+		default:
+			return nil, ErrTooManyRequests
 		}
-		return nil
 
 	default:
-		return nil
+		// Normal operation - allow request
+		return nil, nil
 	}
 }
 
-// afterRequest records the result of a request
-func (cb *CircuitBreaker) afterRequest(err error) {
+// afterRequest records the result of a request and returns its probe token
+func (cb *CircuitBreaker) afterRequest(err error, probe chan struct{}) {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
-
-	if err != nil {
+	from := cb.state
+	if probe != nil {
+		<-probe
+	}
+	switch {
+	case cb.state == StateHalfOpen && probe != cb.probes:
+		// Admitted before the current half-open period; only its probes
+		// decide whether the backend recovered
+	case err != nil:
 		cb.onFailure()
-	} else {
+	default:
 		cb.onSuccess()
 	}
+	to := cb.state
+	cb.mu.Unlock()
+
+	cb.notify(from, to)
 }
 
 // onFailure handles a failed request
 func (cb *CircuitBreaker) onFailure() {
-	cb.failures++
+	if cb.state == StateClosed && cb.failureRate > 0 {
+		cb.countRequest(true)
+	} else {
+		cb.failures++
+	}
 	cb.lastFailureTime = time.Now()
 
 	switch cb.state {
 	case StateClosed:
-		if cb.failures >= cb.maxFailures {
+		if cb.failureRate > 0 {
+			if cb.requests >= cb.minRequests && float64(cb.failures)/float64(cb.requests) >= cb.failureRate {
+				cb.state = StateOpen
+			}
+		} else if cb.failures >= cb.maxFailures {
 			cb.state = StateOpen
 		}
 
@@ -154,6 +259,10 @@ func (cb *CircuitBreaker) onFailure() {
 func (cb *CircuitBreaker) onSuccess() {
 	switch cb.state {
 	case StateClosed:
+		if cb.failureRate > 0 {
+			cb.countRequest(false)
+			return
+		}
 		// Reset failure count on success
 		cb.failures = 0
 
@@ -162,11 +271,28 @@ func (cb *CircuitBreaker) onSuccess() {
 		cb.successCount++
 		if cb.successCount >= cb.halfOpenRequests {
 			cb.state = StateClosed
-			cb.failures = 0
+			cb.restartWindow()
 		}
 	}
 }
 
+// countRequest counts a call in the failure-rate window, starting a new
+// window once the current one is over
+func (cb *CircuitBreaker) countRequest(failed bool) {
+	if time.Since(cb.windowStart) >= cb.window {
+		cb.restartWindow()
+	}
+	cb.requests++
+	if failed {
+		cb.failures++
+	}
+}
+
+// restartWindow forgets the failures counted so far
+func (cb *CircuitBreaker) restartWindow() {
+	cb.windowStart, cb.requests, cb.failures = time.Now(), 0, 0
+}
+
 // GetState returns the current circuit breaker state
 func (cb *CircuitBreaker) GetState() CircuitState {
 	cb.mu.RLock()
@@ -174,6 +300,30 @@ func (cb *CircuitBreaker) GetState() CircuitState {
 	return cb.state
 }
 
+// RetryAfter returns how long clients should wait before retrying a rejected
+// request: the remaining reset timeout while open, rounded up to whole seconds
+// with a minimum of one second, and zero when the breaker is closed
+func (cb *CircuitBreaker) RetryAfter() time.Duration {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+
+	switch cb.state {
+	case StateOpen:
+		if cb.forcedOpen {
+			return max(cb.resetTimeout.Truncate(time.Second), time.Second)
+		}
+		remaining := cb.resetTimeout - time.Since(cb.lastFailureTime)
+		if remaining < time.Second {
+			return time.Second
+		}
+		return (remaining + time.Second - 1).Truncate(time.Second)
+	case StateHalfOpen:
+		return time.Second
+	default:
+		return 0
+	}
+}
+
 // GetFailures returns the current failure count
 func (cb *CircuitBreaker) GetFailures() uint32 {
 	cb.mu.RLock()
@@ -181,14 +331,52 @@ func (cb *CircuitBreaker) GetFailures() uint32 {
 	return cb.failures
 }
 
-// Reset manually resets the circuit breaker
+// Reset manually resets the circuit breaker, clearing its failure history
 func (cb *CircuitBreaker) Reset() {
 	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	from := cb.state
+	cb.state = StateClosed
+	cb.restartWindow()
+	cb.successCount = 0
+	cb.forcedOpen = false
+	cb.lastFailureTime = time.Time{}
+	cb.mu.Unlock()
 
+	cb.notify(from, StateClosed)
+}
+
+// ForceClose closes the breaker, e.g. once on-call knows the backend recovered
+func (cb *CircuitBreaker) ForceClose() {
+	cb.mu.Lock()
+	from := cb.state
 	cb.state = StateClosed
-	cb.failures = 0
+	cb.restartWindow()
 	cb.successCount = 0
+	cb.forcedOpen = false
+	cb.mu.Unlock()
+
+	cb.notify(from, StateClosed)
+}
+
+// ForceOpen opens the breaker until it is closed or reset, regardless of its
+// reset timeout, e.g. to shield a backend under maintenance
+func (cb *CircuitBreaker) ForceOpen() {
+	cb.mu.Lock()
+	from := cb.state
+	cb.state = StateOpen
+	cb.successCount = 0
+	cb.forcedOpen = true
+	cb.mu.Unlock()
+
+	cb.notify(from, StateOpen)
+}
+
+// probesInFlight returns the number of half-open probes still running
+func (cb *CircuitBreaker) probesInFlight() int {
+	if cb.state != StateHalfOpen {
+		return 0
+	}
+	return len(cb.probes)
 }
 
 // GetStats returns circuit breaker statistics
@@ -199,10 +387,14 @@ func (cb *CircuitBreaker) GetStats() map[string]interface{} {
 	return map[string]interface{}{
 		"name":             cb.name,
 		"state":            cb.state.String(),
+		"forced_open":      cb.forcedOpen,
 		"failures":         cb.failures,
 		"max_failures":     cb.maxFailures,
 		"last_failure":     cb.lastFailureTime,
 		"success_count":    cb.successCount,
 		"reset_timeout_ms": cb.resetTimeout.Milliseconds(),
+		"probes_in_flight": cb.probesInFlight(),
+		"failure_rate":     cb.failureRate,
+		"window_requests":  cb.requests,
 	}
 }
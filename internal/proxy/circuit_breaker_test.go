@@ -0,0 +1,219 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestCircuitBreakerRetryAfter(t *testing.T) {
+	cb := NewCircuitBreaker("test-service", CircuitBreakerConfig{
+		MaxFailures:  1,
+		ResetTimeout: 10 * time.Second,
+	})
+
+	if got := cb.RetryAfter(); got != 0 {
+		t.Errorf("expected no retry hint while closed but got %v", got)
+	}
+
+	cb.Call(func() error { return errors.New("backend down") })
+	if cb.GetState() != StateOpen {
+		t.Fatalf("expected breaker to be open")
+	}
+
+	if got := cb.RetryAfter(); got != 10*time.Second {
+		t.Errorf("expected 10s retry hint but got %v", got)
+	}
+
+	cb.mu.Lock()
+	cb.lastFailureTime = time.Now().Add(-9800 * time.Millisecond)
+	cb.mu.Unlock()
+
+	if got := cb.RetryAfter(); got != time.Second {
+		t.Errorf("expected retry hint rounded up to 1s but got %v", got)
+	}
+}
+
+func TestCircuitBreakerCountsBackendFailures(t *testing.T) {
+	req := httptest.NewRequest("GET", "/api/v1/market-data/AAPL", nil)
+	h := newRetryHandler(0, RetryPolicy{})
+
+	call := func(cb *CircuitBreaker, conn *grpc.ClientConn) error {
+		return callWithBreaker(cb, func() error {
+			return h.invoke(context.Background(), req, "market-data-service", conn, "/market.MarketData/GetQuote", &emptypb.Empty{}, &emptypb.Empty{})
+		})
+	}
+
+	cb := NewCircuitBreaker("market-data-service", CircuitBreakerConfig{MaxFailures: 2})
+	conn, _ := flakyBackend(t, 10, codes.NotFound)
+	for i := 0; i < 5; i++ {
+		call(cb, conn)
+	}
+	if cb.GetState() != StateClosed {
+		t.Errorf("client errors must not open the breaker, state = %s", cb.GetState())
+	}
+
+	conn, calls := flakyBackend(t, 10, codes.Unavailable)
+	for i := 0; i < 3; i++ {
+		call(cb, conn)
+	}
+	if cb.GetState() != StateOpen || *calls != 2 {
+		t.Errorf("state = %s after %d backend calls, want open after 2", cb.GetState(), *calls)
+	}
+
+	if err := callWithBreaker(cb, func() error { return fmt.Errorf("%w: refused", errNoConnection) }); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("open breaker returned %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestRouteCircuitBreaker(t *testing.T) {
+	registry := NewServiceRegistry(&config.Config{
+		Services: map[string]config.ServiceConfig{
+			"order-service": {BreakerThreshold: 10},
+		},
+		Proxy: config.ProxyConfig{
+			CircuitBreakerEnabled:   true,
+			CircuitBreakerThreshold: 5,
+			CircuitBreakerTimeout:   30 * time.Second,
+			CircuitBreakerHalfOpen:  3,
+		},
+	})
+
+	shared := &router.Route{Name: "list-orders", Service: "order-service"}
+	own := &router.Route{Name: "submit-order", Service: "order-service",
+		CircuitBreaker: &router.RouteBreaker{ResetTimeout: "5s"}}
+
+	service := registry.RouteCircuitBreaker(shared)
+	if service != registry.GetCircuitBreaker("order-service") {
+		t.Error("routes without circuit_breaker must share the service's breaker")
+	}
+	if got := service.Config(); got.MaxFailures != 10 || got.ResetTimeout != 30*time.Second {
+		t.Errorf("service breaker config = %+v, want the service threshold over the global timeout", got)
+	}
+
+	route := registry.RouteCircuitBreaker(own)
+	if route == service {
+		t.Fatal("a route with circuit_breaker must get its own breaker")
+	}
+	want := CircuitBreakerConfig{MaxFailures: 10, ResetTimeout: 5 * time.Second, HalfOpenRequests: 3}
+	if got := route.Config(); got != want {
+		t.Errorf("route breaker config = %+v, want %+v", got, want)
+	}
+	if registry.RouteCircuitBreaker(own) != route {
+		t.Error("the route breaker must be reused while its settings are unchanged")
+	}
+
+	own.CircuitBreaker.MaxFailures = 2
+	if registry.RouteCircuitBreaker(own) == route {
+		t.Error("changed settings must replace the route breaker")
+	}
+}
+
+func TestCircuitBreakerManualControl(t *testing.T) {
+	cb := NewCircuitBreaker("order-service", CircuitBreakerConfig{MaxFailures: 1, ResetTimeout: time.Millisecond})
+	var transitions []string
+	cb.OnStateChange(func(name string, from, to CircuitState) {
+		transitions = append(transitions, from.String()+"->"+to.String())
+	})
+
+	// A forced breaker stays open past its reset timeout
+	cb.ForceOpen()
+	time.Sleep(5 * time.Millisecond)
+	if err := cb.Call(func() error { return nil }); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected a forced open breaker to reject calls but got %v", err)
+	}
+
+	cb.ForceClose()
+	if err := cb.Call(func() error { return nil }); err != nil {
+		t.Fatalf("expected a closed breaker to admit calls but got %v", err)
+	}
+
+	// Trips and recoveries are reported too
+	cb.Call(func() error { return errors.New("backend down") })
+	time.Sleep(5 * time.Millisecond)
+	cb.Call(func() error { return nil })
+
+	want := []string{"CLOSED->OPEN", "OPEN->CLOSED", "CLOSED->OPEN", "OPEN->HALF_OPEN"}
+	if fmt.Sprint(transitions) != fmt.Sprint(want) {
+		t.Errorf("expected transitions %v but got %v", want, transitions)
+	}
+}
+
+func TestCircuitBreakerFailureRate(t *testing.T) {
+	cb := NewCircuitBreaker("market-data-service", CircuitBreakerConfig{FailureRate: 0.5, MinRequests: 4, Window: time.Minute})
+	fail := func() error { return errors.New("backend down") }
+	ok := func() error { return nil }
+
+	// Interleaved failures never add up to consecutive ones, but do to a rate
+	for _, fn := range []func() error{fail, ok, fail} {
+		cb.Call(fn)
+	}
+	if cb.GetState() != StateClosed {
+		t.Fatalf("expected the breaker to wait for 4 requests but it is %s", cb.GetState())
+	}
+	cb.Call(ok)
+	cb.Call(fail)
+	if cb.GetState() != StateOpen {
+		t.Fatalf("expected 3 failures in 5 calls to open the breaker but it is %s", cb.GetState())
+	}
+
+	// A new window forgets earlier failures
+	cb = NewCircuitBreaker("market-data-service", CircuitBreakerConfig{FailureRate: 0.5, MinRequests: 2, Window: time.Millisecond})
+	cb.Call(fail)
+	time.Sleep(5 * time.Millisecond)
+	cb.Call(ok)
+	cb.Call(ok)
+	cb.Call(fail)
+	if cb.GetState() != StateClosed {
+		t.Errorf("expected 1 failure in 3 calls to keep the breaker closed but it is %s", cb.GetState())
+	}
+}
+
+func TestCircuitBreakerHalfOpenProbes(t *testing.T) {
+	cb := NewCircuitBreaker("order-service", CircuitBreakerConfig{MaxFailures: 1, ResetTimeout: time.Millisecond, HalfOpenRequests: 2})
+	cb.Call(func() error { return errors.New("backend down") })
+	time.Sleep(5 * time.Millisecond)
+
+	// Requests arriving together while half-open can't all get through
+	release := make(chan struct{})
+	var admitted, rejected atomic.Int32
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := cb.Call(func() error {
+				admitted.Add(1)
+				<-release
+				return nil
+			})
+			if errors.Is(err, ErrTooManyRequests) {
+				rejected.Add(1)
+			}
+		}()
+	}
+	for admitted.Load()+rejected.Load() < 10 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+
+	if admitted.Load() != 2 || rejected.Load() != 8 {
+		t.Errorf("expected 2 probes and 8 rejections but got %d and %d", admitted.Load(), rejected.Load())
+	}
+	if cb.GetState() != StateClosed {
+		t.Errorf("expected the breaker to close after 2 successful probes but it is %s", cb.GetState())
+	}
+}
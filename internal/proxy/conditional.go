@@ -0,0 +1,54 @@
+package proxy
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// LastModifiedMetadata is the gRPC response header a backend sets to report
+// when the returned resource last changed (HTTP date, RFC 3339 or unix seconds)
+const LastModifiedMetadata = "last-modified"
+
+// isRead reports whether the request only reads: GET, or HEAD served by a GET route
+func isRead(r *http.Request) bool {
+	return r.Method == http.MethodGet || r.Method == http.MethodHead
+}
+
+// lastModified extracts the backend's last-modified timestamp from response
+// metadata. It returns the zero time when the backend did not supply one.
+func lastModified(header metadata.MD) time.Time {
+	values := header.Get(LastModifiedMetadata)
+	if len(values) == 0 {
+		return time.Time{}
+	}
+
+	value := values[0]
+	if t, err := http.ParseTime(value); err == nil {
+		return t.UTC()
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC()
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil && seconds > 0 {
+		return time.Unix(seconds, 0).UTC()
+	}
+	return time.Time{}
+}
+
+// notModified reports whether a conditional read can be answered with 304.
+// If-None-Match takes precedence over If-Modified-Since (RFC 9110 13.1.3), so
+// such requests are left to the ETags of cached routes.
+func notModified(r *http.Request, modified time.Time) bool {
+	if modified.IsZero() || !isRead(r) || r.Header.Get("If-None-Match") != "" {
+		return false
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	// HTTP dates have one-second resolution
+	return !modified.Truncate(time.Second).After(since)
+}
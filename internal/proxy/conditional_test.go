@@ -0,0 +1,72 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestLastModified(t *testing.T) {
+	want := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		value string
+		want  time.Time
+	}{
+		{"http date", "Fri, 01 Mar 2024 12:30:00 GMT", want},
+		{"rfc3339", "2024-03-01T09:30:00-03:00", want},
+		{"unix seconds", "1709296200", want},
+		{"garbage", "yesterday", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lastModified(metadata.Pairs(LastModifiedMetadata, tt.value))
+			if !got.Equal(tt.want) {
+				t.Errorf("lastModified(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+
+	if got := lastModified(nil); !got.IsZero() {
+		t.Errorf("lastModified(nil) = %v, want zero", got)
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	modified := time.Date(2024, 3, 1, 12, 30, 0, 500, time.UTC)
+
+	tests := []struct {
+		name    string
+		method  string
+		headers map[string]string
+		want    bool
+	}{
+		{"unchanged since", "GET", map[string]string{"If-Modified-Since": "Fri, 01 Mar 2024 12:30:00 GMT"}, true},
+		{"head unchanged", "HEAD", map[string]string{"If-Modified-Since": "Fri, 01 Mar 2024 13:00:00 GMT"}, true},
+		{"changed since", "GET", map[string]string{"If-Modified-Since": "Fri, 01 Mar 2024 12:29:59 GMT"}, false},
+		{"no condition", "GET", nil, false},
+		{"if-none-match wins", "GET", map[string]string{"If-Modified-Since": "Fri, 01 Mar 2024 13:00:00 GMT", "If-None-Match": `"abc"`}, false},
+		{"not a read", "POST", map[string]string{"If-Modified-Since": "Fri, 01 Mar 2024 13:00:00 GMT"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/api/v1/orders/history", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if got := notModified(r, modified); got != tt.want {
+				t.Errorf("notModified() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/orders/history", nil)
+	r.Header.Set("If-Modified-Since", "Fri, 01 Mar 2024 13:00:00 GMT")
+	if notModified(r, time.Time{}) {
+		t.Error("a backend without timestamps must never produce 304")
+	}
+}
@@ -0,0 +1,80 @@
+package proxy
+
+import (
+	"sync/atomic"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// connPool spreads a service's calls over several gRPC channels, since one
+// HTTP/2 connection caps the streams in flight (100 by default on most servers)
+type connPool struct {
+	conns []*grpc.ClientConn
+	next  atomic.Uint32
+}
+
+// newConnPool returns a pool of already created channels
+func newConnPool(conns ...*grpc.ClientConn) *connPool {
+	return &connPool{conns: conns}
+}
+
+// pick returns the next channel in round-robin order, skipping channels that
+// are failing or shut down. Failing channels are asked to reconnect. When no
+// channel is usable the next one is returned anyway, so the call fails with
+// the channel's own error.
+func (p *connPool) pick() *grpc.ClientConn {
+	start := p.next.Add(1) - 1
+	for i := range uint32(len(p.conns)) {
+		conn := p.conns[(start+i)%uint32(len(p.conns))]
+		switch conn.GetState() {
+		case connectivity.TransientFailure:
+			conn.Connect()
+		case connectivity.Shutdown:
+		default:
+			return conn
+		}
+	}
+	return p.conns[start%uint32(len(p.conns))]
+}
+
+// shutdown reports whether every channel of the pool was closed
+func (p *connPool) shutdown() bool {
+	for _, conn := range p.conns {
+		if conn.GetState() != connectivity.Shutdown {
+			return false
+		}
+	}
+	return true
+}
+
+// state summarizes the pool: READY when any channel is ready, otherwise the
+// state of the first channel
+func (p *connPool) state() connectivity.State {
+	for _, conn := range p.conns {
+		if conn.GetState() == connectivity.Ready {
+			return connectivity.Ready
+		}
+	}
+	return p.conns[0].GetState()
+}
+
+// states returns the state of every channel
+func (p *connPool) states() []string {
+	states := make([]string, len(p.conns))
+	for i, conn := range p.conns {
+		states[i] = conn.GetState().String()
+	}
+	return states
+}
+
+// close closes every channel and returns the first error
+func (p *connPool) close() error {
+	var first error
+	for _, conn := range p.conns {
+		if err := conn.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
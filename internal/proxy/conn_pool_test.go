@@ -0,0 +1,32 @@
+package proxy
+
+import (
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+func TestConnPoolPick(t *testing.T) {
+	pool := newConnPool()
+	for range 3 {
+		conn, err := grpc.NewClient("passthrough:///localhost:1", grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pool.conns = append(pool.conns, conn)
+	}
+	defer pool.close()
+
+	// Closed channels are skipped
+	pool.conns[1].Close()
+	want := []*grpc.ClientConn{pool.conns[0], pool.conns[2], pool.conns[2], pool.conns[0]}
+	for i, conn := range want {
+		if got := pool.pick(); got != conn {
+			t.Errorf("pick %d: expected channel %p but got %p", i, conn, got)
+		}
+	}
+	if pool.shutdown() {
+		t.Error("expected a pool with open channels not to be shut down")
+	}
+}
@@ -0,0 +1,276 @@
+package proxy
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"hub-api-gateway/internal/router"
+
+	// Registers the monolith service descriptors linked into the gateway
+	_ "github.com/RodriguesYan/hub-proto-contracts/monolith"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// defaultProtoPackage qualifies route grpc_service names without a package
+const defaultProtoPackage = "hub_investments"
+
+// userIDField is populated with the authenticated caller's ID when present in
+// a request message, overriding any value sent by the client
+const userIDField = "user_id"
+
+// DescriptorRegistry resolves routes to gRPC method descriptors so request and
+// response messages can be built without per-method code. Descriptors come
+// from compiled descriptor sets (protoc --include_imports --descriptor_set_out)
+// and, as a fallback, from the contracts linked into the gateway.
+type DescriptorRegistry struct {
+	files *protoregistry.Files
+}
+
+// NewDescriptorRegistry creates a registry holding only the linked-in descriptors
+func NewDescriptorRegistry() *DescriptorRegistry {
+	return &DescriptorRegistry{files: &protoregistry.Files{}}
+}
+
+// LoadDescriptorSet registers every file in a serialized FileDescriptorSet
+func (d *DescriptorRegistry) LoadDescriptorSet(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read descriptor set: %w", err)
+	}
+
+	var set descriptorpb.FileDescriptorSet
+	if err := proto.Unmarshal(data, &set); err != nil {
+		return fmt.Errorf("invalid descriptor set %s: %w", path, err)
+	}
+
+	files, err := protodesc.NewFiles(&set)
+	if err != nil {
+		return fmt.Errorf("invalid descriptor set %s: %w", path, err)
+	}
+
+	files.RangeFiles(func(file protoreflect.FileDescriptor) bool {
+		if _, findErr := d.files.FindFileByPath(file.Path()); findErr == nil {
+			return true // already loaded from another set
+		}
+		err = d.files.RegisterFile(file)
+		return err == nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to register descriptor set %s: %w", path, err)
+	}
+	return nil
+}
+
+// FindMethod resolves a route's gRPC service and method
+func (d *DescriptorRegistry) FindMethod(route *router.Route) (protoreflect.MethodDescriptor, error) {
+	service := route.GRPCService
+	if !strings.Contains(service, ".") {
+		service = defaultProtoPackage + "." + service
+	}
+	name := protoreflect.FullName(service + "." + route.GRPCMethod)
+
+	descriptor, err := d.files.FindDescriptorByName(name)
+	if err != nil {
+		descriptor, err = protoregistry.GlobalFiles.FindDescriptorByName(name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unknown gRPC method %s (load its descriptor set)", name)
+	}
+
+	method, ok := descriptor.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, fmt.Errorf("%s is not a gRPC method", name)
+	}
+
+	// Streaming routes need the kind of stream they relay
+	switch route.Stream {
+	case "":
+		if method.IsStreamingClient() || method.IsStreamingServer() {
+			return nil, fmt.Errorf("streaming method %s can't be proxied as a unary call (set stream)", name)
+		}
+	case router.StreamWebSocket:
+		if !method.IsStreamingClient() || !method.IsStreamingServer() {
+			return nil, fmt.Errorf("stream %s needs a bidirectional streaming method, %s isn't one", route.Stream, name)
+		}
+	default:
+		if method.IsStreamingClient() || !method.IsStreamingServer() {
+			return nil, fmt.Errorf("stream %s needs a server-streaming method, %s isn't one", route.Stream, name)
+		}
+	}
+	return method, nil
+}
+
+// CheckRoute verifies the route's method is known and every path variable
+// binds to a request field. It is installed as a route check so bad routes
+// fail at load time rather than on the first request.
+func (d *DescriptorRegistry) CheckRoute(route *router.Route) error {
+	if route.IsAggregate() {
+		return d.checkAggregate(route)
+	}
+
+	for _, target := range route.Targets() {
+		method, err := d.FindMethod(&target)
+		if err != nil {
+			// Mocked routes may front RPCs that don't exist yet
+			if route.Mock != nil {
+				continue
+			}
+			return err
+		}
+
+		fields := method.Input().Fields()
+		for _, variable := range append(route.PathVariables(), route.RewriteVariables()...) {
+			field := route.PathFieldFor(variable)
+			if fields.ByName(protoreflect.Name(field)) == nil {
+				return fmt.Errorf("path variable {%s} has no field %q in %s (set path_fields)", variable, field, method.Input().FullName())
+			}
+		}
+		if err := checkRequiredFields(method.Input(), route.RequiredFields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkAggregate verifies every branch method is known and every path
+// variable binds to a request field of at least one branch
+func (d *DescriptorRegistry) checkAggregate(route *router.Route) error {
+	bound := make(map[string]bool)
+	for _, target := range route.Targets() {
+		method, err := d.FindMethod(&target)
+		if err != nil {
+			if route.Mock != nil {
+				return nil
+			}
+			return fmt.Errorf("branch %s: %w", target.Name, err)
+		}
+		for _, variable := range route.PathVariables() {
+			if method.Input().Fields().ByName(protoreflect.Name(route.PathFieldFor(variable))) != nil {
+				bound[variable] = true
+			}
+		}
+	}
+	for _, variable := range route.PathVariables() {
+		if !bound[variable] {
+			return fmt.Errorf("path variable {%s} has no field %q in any branch (set path_fields)", variable, route.PathFieldFor(variable))
+		}
+	}
+	return nil
+}
+
+// fullMethodName returns the method path used by grpc.Invoke
+func fullMethodName(method protoreflect.MethodDescriptor) string {
+	return fmt.Sprintf("/%s/%s", method.Parent().FullName(), method.Name())
+}
+
+// newMessage creates a message, preferring the generated type when linked in
+func newMessage(descriptor protoreflect.MessageDescriptor) proto.Message {
+	if messageType, err := protoregistry.GlobalTypes.FindMessageByName(descriptor.FullName()); err == nil {
+		return messageType.New().Interface()
+	}
+	return dynamicpb.NewMessage(descriptor)
+}
+
+// bindRequest builds the request and response messages for a route: the JSON
+// body is decoded into the request, then path variables and the caller's user
+// ID are bound to their fields
+func bindRequest(method protoreflect.MethodDescriptor, route *router.Route, body []byte, pathVars map[string]string, userID string) (proto.Message, proto.Message, error) {
+	request := newMessage(method.Input())
+
+	if len(body) > 0 {
+		if err := protojson.Unmarshal(body, request); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal %s request: %w", method.Name(), err)
+		}
+	}
+
+	message := request.ProtoReflect()
+	fields := method.Input().Fields()
+
+	for variable, value := range pathVars {
+		field := fields.ByName(protoreflect.Name(route.PathFieldFor(variable)))
+		if field == nil {
+			return nil, nil, fmt.Errorf("path variable {%s} has no field in %s", variable, method.Input().FullName())
+		}
+		if err := setField(message, field, value); err != nil {
+			return nil, nil, fmt.Errorf("invalid path variable {%s}: %w", variable, err)
+		}
+	}
+
+	if field := fields.ByName(userIDField); userID != "" && field != nil && field.Kind() == protoreflect.StringKind && !field.IsList() {
+		message.Set(field, protoreflect.ValueOfString(userID))
+	}
+
+	return request, newMessage(method.Output()), nil
+}
+
+// setField parses a string into a singular scalar field
+func setField(message protoreflect.Message, field protoreflect.FieldDescriptor, value string) error {
+	if field.IsList() || field.IsMap() {
+		return fmt.Errorf("field %s is not a scalar", field.Name())
+	}
+
+	var v protoreflect.Value
+	switch field.Kind() {
+	case protoreflect.StringKind:
+		v = protoreflect.ValueOfString(value)
+	case protoreflect.BoolKind:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		v = protoreflect.ValueOfBool(b)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		n, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return err
+		}
+		v = protoreflect.ValueOfInt32(int32(n))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		v = protoreflect.ValueOfInt64(n)
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		n, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return err
+		}
+		v = protoreflect.ValueOfUint32(uint32(n))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		n, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		v = protoreflect.ValueOfUint64(n)
+	case protoreflect.DoubleKind, protoreflect.FloatKind:
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		if field.Kind() == protoreflect.FloatKind {
+			v = protoreflect.ValueOfFloat32(float32(n))
+		} else {
+			v = protoreflect.ValueOfFloat64(n)
+		}
+	case protoreflect.EnumKind:
+		enumValue := field.Enum().Values().ByName(protoreflect.Name(strings.ToUpper(value)))
+		if enumValue == nil {
+			return fmt.Errorf("unknown %s value %q", field.Enum().Name(), value)
+		}
+		v = protoreflect.ValueOfEnum(enumValue.Number())
+	default:
+		return fmt.Errorf("field %s has unsupported type %s", field.Name(), field.Kind())
+	}
+
+	message.Set(field, v)
+	return nil
+}
@@ -0,0 +1,157 @@
+package proxy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// writeDescriptorSet writes a descriptor set for a small loyalty service
+func writeDescriptorSet(t *testing.T) string {
+	t.Helper()
+
+	field := func(name string, number int32, kind descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:     proto.String(name),
+			Number:   proto.Int32(number),
+			Type:     kind.Enum(),
+			Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+			JsonName: proto.String(name),
+		}
+	}
+
+	set := &descriptorpb.FileDescriptorSet{File: []*descriptorpb.FileDescriptorProto{{
+		Name:    proto.String("loyalty.proto"),
+		Package: proto.String("loyalty"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("GetPointsRequest"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("user_id", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+				field("program_id", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64),
+				field("note", 3, descriptorpb.FieldDescriptorProto_TYPE_STRING),
+			}},
+			{Name: proto.String("GetPointsResponse"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("points", 1, descriptorpb.FieldDescriptorProto_TYPE_INT64),
+			}},
+		},
+		Service: []*descriptorpb.ServiceDescriptorProto{{
+			Name: proto.String("LoyaltyService"),
+			Method: []*descriptorpb.MethodDescriptorProto{{
+				Name:       proto.String("GetPoints"),
+				InputType:  proto.String(".loyalty.GetPointsRequest"),
+				OutputType: proto.String(".loyalty.GetPointsResponse"),
+			}, {
+				Name:            proto.String("WatchPoints"),
+				InputType:       proto.String(".loyalty.GetPointsRequest"),
+				OutputType:      proto.String(".loyalty.GetPointsResponse"),
+				ServerStreaming: proto.Bool(true),
+			}},
+		}},
+	}}}
+
+	data, err := proto.Marshal(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "loyalty.pb")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestDescriptorRegistry_DynamicBinding(t *testing.T) {
+	descriptors := NewDescriptorRegistry()
+	if err := descriptors.LoadDescriptorSet(writeDescriptorSet(t)); err != nil {
+		t.Fatalf("failed to load descriptor set: %v", err)
+	}
+
+	route := &router.Route{
+		Name:        "loyalty-points",
+		Path:        "/api/v1/loyalty/{program}",
+		GRPCService: "loyalty.LoyaltyService",
+		GRPCMethod:  "GetPoints",
+	}
+	if err := descriptors.CheckRoute(route); err == nil {
+		t.Errorf("expected unmapped path variable to be rejected")
+	}
+
+	route.PathFields = map[string]string{"program": "program_id"}
+	if err := descriptors.CheckRoute(route); err != nil {
+		t.Fatalf("expected route to be valid: %v", err)
+	}
+
+	method, err := descriptors.FindMethod(route)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fullMethodName(method); got != "/loyalty.LoyaltyService/GetPoints" {
+		t.Errorf("unexpected full method: %s", got)
+	}
+
+	request, response, err := bindRequest(method, route, []byte(`{"note":"hi","user_id":"spoofed"}`), map[string]string{"program": "42"}, "user-1")
+	if err != nil {
+		t.Fatalf("failed to bind request: %v", err)
+	}
+	message := request.ProtoReflect()
+	get := func(name string) protoreflect.Value {
+		return message.Get(method.Input().Fields().ByName(protoreflect.Name(name)))
+	}
+	if get("program_id").Int() != 42 || get("note").String() != "hi" || get("user_id").String() != "user-1" {
+		t.Errorf("unexpected request: %v", request)
+	}
+	if response.ProtoReflect().Descriptor().FullName() != "loyalty.GetPointsResponse" {
+		t.Errorf("unexpected response type: %s", response.ProtoReflect().Descriptor().FullName())
+	}
+
+	if _, _, err := bindRequest(method, route, nil, map[string]string{"program": "abc"}, ""); err == nil {
+		t.Errorf("expected non-numeric path variable to be rejected")
+	}
+}
+
+func TestDescriptorRegistry_LinkedContracts(t *testing.T) {
+	descriptors := NewDescriptorRegistry()
+
+	route := &router.Route{Name: "balance", Path: "/api/v1/balance", GRPCService: "BalanceService", GRPCMethod: "GetBalance"}
+	method, err := descriptors.FindMethod(route)
+	if err != nil {
+		t.Fatalf("expected linked-in method to resolve: %v", err)
+	}
+	if got := fullMethodName(method); got != "/hub_investments.BalanceService/GetBalance" {
+		t.Errorf("unexpected full method: %s", got)
+	}
+
+	route.GRPCMethod = "DoesNotExist"
+	if _, err := descriptors.FindMethod(route); err == nil {
+		t.Errorf("expected unknown method to fail")
+	}
+}
+
+func TestDescriptorRegistry_StreamingMethods(t *testing.T) {
+	descriptors := NewDescriptorRegistry()
+	if err := descriptors.LoadDescriptorSet(writeDescriptorSet(t)); err != nil {
+		t.Fatalf("failed to load descriptor set: %v", err)
+	}
+
+	for _, tc := range []struct {
+		method, stream string
+		ok             bool
+	}{
+		{"GetPoints", "", true},
+		{"WatchPoints", "", false},
+		{"WatchPoints", router.StreamSSE, true},
+		{"GetPoints", router.StreamNDJSON, false},
+		{"WatchPoints", router.StreamWebSocket, false},
+	} {
+		route := &router.Route{GRPCService: "loyalty.LoyaltyService", GRPCMethod: tc.method, Stream: tc.stream}
+		if _, err := descriptors.FindMethod(route); (err == nil) != tc.ok {
+			t.Errorf("FindMethod(%s, stream %q) error = %v, want ok %v", tc.method, tc.stream, err, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,192 @@
+package proxy
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// fakeMaxDepth bounds recursion into nested (possibly self-referencing) messages
+const fakeMaxDepth = 4
+
+// fakeEpoch anchors generated timestamps so output doesn't drift between runs
+var fakeEpoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// FakeGenerator produces schema-valid fake responses from message descriptors.
+// Output is random but deterministic for a given seed and request, so a
+// frontend developer sees the same data on every reload.
+type FakeGenerator struct {
+	seed int64
+}
+
+// NewFakeGenerator creates a fake data generator
+func NewFakeGenerator(seed int64) *FakeGenerator {
+	return &FakeGenerator{seed: seed}
+}
+
+// Generate fills a new message of the given type; key selects the variation
+func (g *FakeGenerator) Generate(descriptor protoreflect.MessageDescriptor, key string) proto.Message {
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%d|%s", g.seed, key)
+	rng := rand.New(rand.NewSource(int64(hash.Sum64())))
+
+	message := newMessage(descriptor)
+	g.fill(rng, message.ProtoReflect(), 0)
+	return message
+}
+
+// fill populates every field of message, choosing one field per oneof
+func (g *FakeGenerator) fill(rng *rand.Rand, message protoreflect.Message, depth int) {
+	descriptor := message.Descriptor()
+
+	switch descriptor.FullName() {
+	case "google.protobuf.Timestamp":
+		offset := time.Duration(rng.Int63n(int64(365 * 24 * time.Hour)))
+		message.Set(descriptor.Fields().ByName("seconds"), protoreflect.ValueOfInt64(fakeEpoch.Add(offset).Unix()))
+		return
+	case "google.protobuf.Any", "google.protobuf.Struct", "google.protobuf.Value",
+		"google.protobuf.ListValue", "google.protobuf.FieldMask", "google.protobuf.Empty":
+		// No meaningful generic value; leave unset
+		return
+	}
+
+	// Only one member of each oneof can be set
+	chosen := make(map[protoreflect.FullName]protoreflect.FieldDescriptor)
+	oneofs := descriptor.Oneofs()
+	for i := 0; i < oneofs.Len(); i++ {
+		if oneof := oneofs.Get(i); !oneof.IsSynthetic() {
+			chosen[oneof.FullName()] = oneof.Fields().Get(rng.Intn(oneof.Fields().Len()))
+		}
+	}
+
+	fields := descriptor.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+
+		if oneof := field.ContainingOneof(); oneof != nil && !oneof.IsSynthetic() && chosen[oneof.FullName()] != field {
+			continue
+		}
+
+		switch {
+		case field.IsMap():
+			if depth >= fakeMaxDepth {
+				continue
+			}
+			entries := message.Mutable(field).Map()
+			for n := 0; n < 1+rng.Intn(2); n++ {
+				key := g.scalar(rng, field.MapKey(), n).MapKey()
+				if field.MapValue().Kind() == protoreflect.MessageKind {
+					value := entries.NewValue()
+					g.fill(rng, value.Message(), depth+1)
+					entries.Set(key, value)
+				} else {
+					entries.Set(key, g.scalar(rng, field.MapValue(), n))
+				}
+			}
+
+		case field.IsList():
+			if field.Kind() == protoreflect.MessageKind && depth >= fakeMaxDepth {
+				continue
+			}
+			list := message.Mutable(field).List()
+			for n := 0; n < 1+rng.Intn(3); n++ {
+				if field.Kind() == protoreflect.MessageKind || field.Kind() == protoreflect.GroupKind {
+					element := list.NewElement()
+					g.fill(rng, element.Message(), depth+1)
+					list.Append(element)
+				} else {
+					list.Append(g.scalar(rng, field, n))
+				}
+			}
+
+		case field.Kind() == protoreflect.MessageKind || field.Kind() == protoreflect.GroupKind:
+			if depth >= fakeMaxDepth {
+				continue
+			}
+			g.fill(rng, message.Mutable(field).Message(), depth+1)
+
+		default:
+			message.Set(field, g.scalar(rng, field, 0))
+		}
+	}
+}
+
+// scalar generates a value for a scalar field, using the field name to make
+// common fields (ids, emails, symbols, success flags) look plausible
+func (g *FakeGenerator) scalar(rng *rand.Rand, field protoreflect.FieldDescriptor, index int) protoreflect.Value {
+	name := strings.ToLower(string(field.Name()))
+
+	switch field.Kind() {
+	case protoreflect.StringKind:
+		switch {
+		case strings.Contains(name, "email"):
+			return protoreflect.ValueOfString(fmt.Sprintf("user%d@example.com", rng.Intn(1000)))
+		case strings.Contains(name, "symbol"):
+			symbols := []string{"PETR4", "VALE3", "ITUB4", "BBDC4", "ABEV3"}
+			return protoreflect.ValueOfString(symbols[rng.Intn(len(symbols))])
+		case strings.Contains(name, "currency"):
+			return protoreflect.ValueOfString("BRL")
+		case name == "id" || strings.HasSuffix(name, "_id"):
+			return protoreflect.ValueOfString(fmt.Sprintf("%08x-%04x", rng.Uint32(), rng.Intn(0x10000)))
+		case strings.HasSuffix(name, "_at") || strings.Contains(name, "date") || strings.Contains(name, "time"):
+			offset := time.Duration(rng.Int63n(int64(365 * 24 * time.Hour)))
+			return protoreflect.ValueOfString(fakeEpoch.Add(offset).Format(time.RFC3339))
+		default:
+			return protoreflect.ValueOfString(fmt.Sprintf("%s-%d", field.Name(), index+1+rng.Intn(100)))
+		}
+	case protoreflect.BoolKind:
+		if name == "success" {
+			return protoreflect.ValueOfBool(true)
+		}
+		return protoreflect.ValueOfBool(rng.Intn(2) == 0)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(int32(rng.Intn(1000)))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(int64(rng.Intn(100000)))
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(uint32(rng.Intn(1000)))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(uint64(rng.Intn(100000)))
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(float32(rng.Intn(100000)) / 100)
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(float64(rng.Intn(10000000)) / 100)
+	case protoreflect.BytesKind:
+		data := make([]byte, 8)
+		rng.Read(data)
+		return protoreflect.ValueOfBytes(data)
+	case protoreflect.EnumKind:
+		values := field.Enum().Values()
+		// Skip the zero (UNSPECIFIED) value when there is an alternative
+		n := rng.Intn(values.Len())
+		if n == 0 && values.Len() > 1 {
+			n = 1
+		}
+		return protoreflect.ValueOfEnum(values.Get(n).Number())
+	default:
+		return field.Default()
+	}
+}
+
+// EnableDevMode serves fake responses generated from the route's descriptors
+// whenever its backend is unreachable, so frontends can be developed without
+// the service stack running
+func (h *ProxyHandler) EnableDevMode(seed int64) {
+	h.fakes = NewFakeGenerator(seed)
+}
+
+// serveFake writes a fake response for the route's method
+func (h *ProxyHandler) serveFake(w http.ResponseWriter, r *http.Request, route *router.Route, method protoreflect.MethodDescriptor) {
+	slog.InfoContext(r.Context(), "dev mode: serving fake response", "message", string(method.Output().FullName()), "method", r.Method, "path", r.URL.Path)
+	w.Header().Set("X-Gateway-Fake", "true")
+	h.sendProtoJSON(w, r, http.StatusOK, h.fakes.Generate(method.Output(), r.Method+" "+r.URL.Path), route)
+}
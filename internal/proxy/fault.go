@@ -0,0 +1,126 @@
+package proxy
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"time"
+
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/grpc/status"
+)
+
+// FaultInjector holds the faults injected into backend calls: the routes'
+// configured faults, overridden at runtime through the admin API
+type FaultInjector struct {
+	mu        sync.RWMutex
+	overrides map[string]router.RouteFault // route -> fault
+	onInject  func(route string)
+}
+
+// NewFaultInjector creates a fault injector without overrides
+func NewFaultInjector() *FaultInjector {
+	return &FaultInjector{overrides: make(map[string]router.RouteFault)}
+}
+
+// OnInject registers a callback invoked for every injected fault
+func (f *FaultInjector) OnInject(fn func(route string)) {
+	f.onInject = fn
+}
+
+// Set overrides the fault of a route; a zero percent suspends a configured one
+func (f *FaultInjector) Set(routeName string, fault router.RouteFault) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.overrides[routeName] = fault
+}
+
+// Clear removes a route's override and reports whether it had one
+func (f *FaultInjector) Clear(routeName string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	_, ok := f.overrides[routeName]
+	delete(f.overrides, routeName)
+	return ok
+}
+
+// Overrides returns the faults set at runtime by route
+func (f *FaultInjector) Overrides() map[string]router.RouteFault {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	overrides := make(map[string]router.RouteFault, len(f.overrides))
+	for name, fault := range f.overrides {
+		overrides[name] = fault
+	}
+	return overrides
+}
+
+// faultFor returns the fault applying to a route, if any
+func (f *FaultInjector) faultFor(route *router.Route) *router.RouteFault {
+	f.mu.RLock()
+	override, ok := f.overrides[route.Name]
+	f.mu.RUnlock()
+	if ok {
+		return &override
+	}
+	return route.Fault
+}
+
+// EnableFaultInjection injects the routes' faults into their backend calls
+func (h *ProxyHandler) EnableFaultInjection(injector *FaultInjector) {
+	h.faults = injector
+}
+
+// faultKey carries the fault of a backend call's route
+type faultKey struct{}
+
+// routeFault is the fault applying to the backend calls of a route
+type routeFault struct {
+	route string
+	fault *router.RouteFault
+	fn    func(route string)
+}
+
+// withFault attaches the route's fault to the context of its backend calls
+func (h *ProxyHandler) withFault(ctx context.Context, route *router.Route) context.Context {
+	if h.faults == nil {
+		return ctx
+	}
+	fault := h.faults.faultFor(route)
+	if fault == nil || fault.Percent <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, faultKey{}, routeFault{route: route.Name, fault: fault, fn: h.faults.onInject})
+}
+
+// injectFault delays or aborts a sampled backend call of a route with a
+// fault. A delay past the call's deadline fails it like a slow backend would.
+func injectFault(ctx context.Context) error {
+	injected, ok := ctx.Value(faultKey{}).(routeFault)
+	if !ok || rand.Float64()*100 >= injected.fault.Percent {
+		return nil
+	}
+	if injected.fn != nil {
+		injected.fn(injected.route)
+	}
+
+	delay := injected.fault.DelayDuration()
+	if jitter := injected.fault.JitterDuration(); jitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(jitter)))
+	}
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-timer.C:
+		}
+	}
+
+	if code, ok := injected.fault.GRPCCode(); ok {
+		return status.Error(code, "injected fault")
+	}
+	return nil
+}
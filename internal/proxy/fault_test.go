@@ -0,0 +1,51 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestFaultInjection(t *testing.T) {
+	h := &ProxyHandler{}
+	route := &router.Route{Name: "get-order", Fault: &router.RouteFault{Percent: 100, Code: "UNAVAILABLE"}}
+
+	// Faults only apply once injection is enabled
+	if err := injectFault(h.withFault(context.Background(), route)); err != nil {
+		t.Fatalf("expected no fault while disabled but got %v", err)
+	}
+
+	injector := NewFaultInjector()
+	injected := 0
+	injector.OnInject(func(string) { injected++ })
+	h.EnableFaultInjection(injector)
+	if err := injectFault(h.withFault(context.Background(), route)); status.Code(err) != codes.Unavailable {
+		t.Errorf("expected the configured UNAVAILABLE fault but got %v", err)
+	}
+
+	// Runtime overrides take precedence; a delay past the deadline times out
+	injector.Set("get-order", router.RouteFault{Percent: 100, Delay: "1s"})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := injectFault(h.withFault(ctx, route)); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("expected a delayed call to exceed its deadline but got %v", err)
+	}
+
+	// A zero percent override suspends the configured fault
+	injector.Set("get-order", router.RouteFault{})
+	if err := injectFault(h.withFault(context.Background(), route)); err != nil {
+		t.Errorf("expected the fault to be suspended but got %v", err)
+	}
+	if !injector.Clear("get-order") || injected != 2 {
+		t.Errorf("expected 2 injected faults and an override to clear, got %d", injected)
+	}
+
+	if err := (&router.RouteFault{Percent: 50, Code: "OK"}).Validate(); err == nil {
+		t.Error("expected an OK fault code to be rejected")
+	}
+}
@@ -0,0 +1,140 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcErrorMapping is the HTTP status and gateway error code a gRPC code maps to
+type grpcErrorMapping struct {
+	Status int
+	Code   string
+}
+
+// defaultGRPCErrorMappings follows the HTTP mapping in google/rpc/code.proto
+var defaultGRPCErrorMappings = map[codes.Code]grpcErrorMapping{
+	codes.Canceled:           {statusClientClosedRequest, "REQUEST_CANCELLED"},
+	codes.Unknown:            {http.StatusInternalServerError, "INTERNAL_ERROR"},
+	codes.InvalidArgument:    {http.StatusBadRequest, "INVALID_ARGUMENT"},
+	codes.DeadlineExceeded:   {http.StatusGatewayTimeout, "TIMEOUT"},
+	codes.NotFound:           {http.StatusNotFound, "NOT_FOUND"},
+	codes.AlreadyExists:      {http.StatusConflict, "ALREADY_EXISTS"},
+	codes.PermissionDenied:   {http.StatusForbidden, "PERMISSION_DENIED"},
+	codes.ResourceExhausted:  {http.StatusTooManyRequests, "RESOURCE_EXHAUSTED"},
+	codes.FailedPrecondition: {http.StatusBadRequest, "FAILED_PRECONDITION"},
+	codes.Aborted:            {http.StatusConflict, "ABORTED"},
+	codes.OutOfRange:         {http.StatusBadRequest, "OUT_OF_RANGE"},
+	codes.Unimplemented:      {http.StatusNotImplemented, "NOT_IMPLEMENTED"},
+	codes.Internal:           {http.StatusInternalServerError, "INTERNAL_ERROR"},
+	codes.Unavailable:        {http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE"},
+	codes.DataLoss:           {http.StatusInternalServerError, "INTERNAL_ERROR"},
+	codes.Unauthenticated:    {http.StatusUnauthorized, "UNAUTHENTICATED"},
+}
+
+// SetGRPCErrorStatus overrides the HTTP status returned for gRPC codes, keyed by
+// code name in either form ("FailedPrecondition" or "FAILED_PRECONDITION")
+func (h *ProxyHandler) SetGRPCErrorStatus(overrides map[string]string) error {
+	mappings := make(map[codes.Code]grpcErrorMapping, len(defaultGRPCErrorMappings))
+	for code, mapping := range defaultGRPCErrorMappings {
+		mappings[code] = mapping
+	}
+
+	for name, value := range overrides {
+		code, ok := parseGRPCCode(name)
+		if !ok || code == codes.OK {
+			return fmt.Errorf("unknown gRPC error code %q", name)
+		}
+		statusCode, err := strconv.Atoi(value)
+		if err != nil || statusCode < 400 || statusCode > 599 {
+			return fmt.Errorf("invalid HTTP status %q for gRPC code %s", value, name)
+		}
+
+		mapping := mappings[code]
+		mapping.Status = statusCode
+		mappings[code] = mapping
+	}
+
+	h.grpcErrors = mappings
+	return nil
+}
+
+// parseGRPCCode resolves a gRPC code by its Go or canonical name
+func parseGRPCCode(name string) (codes.Code, bool) {
+	normalized := strings.ToLower(strings.ReplaceAll(name, "_", ""))
+	for code := codes.OK; code <= codes.Unauthenticated; code++ {
+		if strings.ToLower(code.String()) == normalized {
+			return code, true
+		}
+	}
+	// code.proto spells it CANCELLED
+	if normalized == "cancelled" {
+		return codes.Canceled, true
+	}
+	return 0, false
+}
+
+// handleGRPCError converts a gRPC status to an HTTP error, forwarding the
+// status message and any ErrorInfo, BadRequest and RetryInfo details
+func (h *ProxyHandler) handleGRPCError(w http.ResponseWriter, r *http.Request, route *router.Route, err error) {
+	st := status.Convert(err)
+	mapping := h.grpcErrorMapping(st.Code())
+
+	details, retryAfter := grpcErrorDetails(st)
+	h.failWithDetails(w, r, route, mapping.Status, mapping.Code, st.Message(), retryAfter, details)
+}
+
+// grpcErrorMapping returns the HTTP status and error code for a gRPC code
+func (h *ProxyHandler) grpcErrorMapping(code codes.Code) grpcErrorMapping {
+	mappings := h.grpcErrors
+	if mappings == nil {
+		mappings = defaultGRPCErrorMappings
+	}
+	if mapping, ok := mappings[code]; ok {
+		return mapping
+	}
+	return grpcErrorMapping{http.StatusInternalServerError, "INTERNAL_ERROR"}
+}
+
+// grpcErrorDetails converts the status details clients can act on to JSON
+// objects and returns the retry delay suggested by a RetryInfo detail
+func grpcErrorDetails(st *status.Status) ([]map[string]interface{}, time.Duration) {
+	var details []map[string]interface{}
+	var retryAfter time.Duration
+
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			details = append(details, map[string]interface{}{
+				"type":     "ErrorInfo",
+				"reason":   d.GetReason(),
+				"domain":   d.GetDomain(),
+				"metadata": d.GetMetadata(),
+			})
+		case *errdetails.BadRequest:
+			violations := make([]map[string]string, 0, len(d.GetFieldViolations()))
+			for _, violation := range d.GetFieldViolations() {
+				violations = append(violations, map[string]string{
+					"field":       violation.GetField(),
+					"description": violation.GetDescription(),
+				})
+			}
+			details = append(details, map[string]interface{}{
+				"type":             "BadRequest",
+				"field_violations": violations,
+			})
+		case *errdetails.RetryInfo:
+			retryAfter = d.GetRetryDelay().AsDuration()
+		}
+	}
+
+	return details, retryAfter
+}
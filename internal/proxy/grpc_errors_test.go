@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/httperr"
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+func TestHandleGRPCError(t *testing.T) {
+	route := &router.Route{Name: "submit-order", Service: "hub-monolith"}
+	h := &ProxyHandler{}
+
+	st, err := status.New(codes.InvalidArgument, "quantity must be positive").WithDetails(
+		&errdetails.ErrorInfo{Reason: "INVALID_QUANTITY", Domain: "orders.hub", Metadata: map[string]string{"min": "1"}},
+		&errdetails.BadRequest{FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{Field: "quantity", Description: "must be greater than 0"},
+		}},
+		&errdetails.RetryInfo{RetryDelay: durationpb.New(3 * time.Second)},
+	)
+	if err != nil {
+		t.Fatalf("WithDetails() error = %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	h.handleGRPCError(recorder, httptest.NewRequest("POST", "/api/v1/orders", nil), route, st.Err())
+
+	if recorder.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 but got %d", recorder.Code)
+	}
+	if recorder.Header().Get("Retry-After") != "3" {
+		t.Errorf("expected Retry-After 3 from RetryInfo but got %q", recorder.Header().Get("Retry-After"))
+	}
+
+	var body httperr.Envelope
+	if err := json.Unmarshal(recorder.Body.Bytes(), &body); err != nil {
+		t.Fatalf("invalid JSON body: %v", err)
+	}
+	if body.Error.Code != "INVALID_ARGUMENT" || body.Error.Message != "quantity must be positive" {
+		t.Errorf("unexpected error body: %+v", body)
+	}
+	details := body.Error.Details
+	if len(details) != 3 || details[0]["reason"] != "INVALID_QUANTITY" || details[1]["type"] != "BadRequest" ||
+		details[2]["type"] != "RetryInfo" || details[2]["retry_after_seconds"] != float64(3) {
+		t.Errorf("unexpected details: %+v", details)
+	}
+}
+
+func TestSetGRPCErrorStatus(t *testing.T) {
+	route := &router.Route{Name: "submit-order", Service: "hub-monolith"}
+	h := &ProxyHandler{}
+
+	if err := h.SetGRPCErrorStatus(map[string]string{"FAILED_PRECONDITION": "422"}); err != nil {
+		t.Fatalf("SetGRPCErrorStatus() error = %v", err)
+	}
+
+	recorder := httptest.NewRecorder()
+	h.handleGRPCError(recorder, httptest.NewRequest("POST", "/api/v1/orders", nil), route,
+		status.Error(codes.FailedPrecondition, "market closed"))
+	if recorder.Code != http.StatusUnprocessableEntity {
+		t.Errorf("expected overridden status 422 but got %d", recorder.Code)
+	}
+
+	recorder = httptest.NewRecorder()
+	h.handleGRPCError(recorder, httptest.NewRequest("GET", "/api/v1/orders/1", nil), route,
+		status.Error(codes.NotFound, "order not found"))
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("expected default status 404 but got %d", recorder.Code)
+	}
+
+	for _, overrides := range []map[string]string{{"NoSuchCode": "500"}, {"NotFound": "200"}, {"NotFound": "abc"}} {
+		if err := h.SetGRPCErrorStatus(overrides); err == nil {
+			t.Errorf("expected error for %v", overrides)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"hub-api-gateway/internal/logging"
+	"hub-api-gateway/internal/middleware"
+	"hub-api-gateway/internal/respwriter"
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// headerVars collects the values a route's header templates can reference
+func headerVars(r *http.Request, route *router.Route, pathVars map[string]string, userContext *middleware.UserContext) router.HeaderVars {
+	vars := router.HeaderVars{
+		Route:     route.Name,
+		Service:   route.Service,
+		Method:    r.Method,
+		Path:      r.URL.Path,
+		ClientIP:  r.RemoteAddr,
+		RequestID: logging.RequestID(r.Context()),
+		PathVars:  pathVars,
+		Header:    r.Header,
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		vars.ClientIP = host
+	}
+	if userContext != nil {
+		vars.UserID = userContext.UserID
+		vars.Email = userContext.Email
+		vars.Roles = strings.Join(userContext.Roles, ",")
+	}
+	return vars
+}
+
+// applyHeaderRules runs rules against a header set. Values whose template
+// fails to render are skipped rather than failing the request.
+func applyHeaderRules(rules *router.HeaderRules, vars router.HeaderVars, set, add func(name, value string), remove func(name string)) {
+	for _, name := range rules.Remove {
+		remove(name)
+	}
+	for _, step := range []struct {
+		values map[string]string
+		apply  func(name, value string)
+	}{{rules.Set, set}, {rules.Add, add}} {
+		for name, value := range step.values {
+			rendered, err := router.RenderHeader(value, vars)
+			if err != nil {
+				slog.Warn("failed to render header template", "route", vars.Route, "header", name, "error", err)
+				continue
+			}
+			step.apply(name, rendered)
+		}
+	}
+}
+
+// applyRequestHeaders transforms the metadata sent to the backend
+func applyRequestHeaders(md metadata.MD, rules *router.HeaderRules, vars router.HeaderVars) {
+	if rules == nil {
+		return
+	}
+	applyHeaderRules(rules, vars,
+		func(name, value string) { md.Set(name, value) },
+		func(name, value string) { md.Append(name, value) },
+		func(name string) { md.Delete(name) })
+}
+
+// withResponseHeaders wraps w when the route transforms response headers. The
+// rules run just before the response headers are written, so they also cover
+// headers the handler sets
+func withResponseHeaders(w http.ResponseWriter, rules *router.HeaderRules, vars router.HeaderVars) http.ResponseWriter {
+	if rules == nil {
+		return w
+	}
+	recorder := respwriter.New(w)
+	recorder.OnHeader(func(int) {
+		header := w.Header()
+		applyHeaderRules(rules, vars, header.Set, header.Add, header.Del)
+	})
+	return recorder
+}
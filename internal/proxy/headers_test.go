@@ -0,0 +1,58 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hub-api-gateway/internal/middleware"
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRouteHeaderRules(t *testing.T) {
+	route := &router.Route{
+		Name:    "get-order",
+		Service: "order-service",
+		RequestHeaders: &router.HeaderRules{
+			Set:    map[string]string{"X-Forwarded-User": "{{.UserID}}", "X-Order": "{{.PathVars.id}}"},
+			Add:    map[string]string{"X-Tenant": `{{.Header.Get "X-Tenant"}}`},
+			Remove: []string{"X-User-Email"},
+		},
+		ResponseHeaders: &router.HeaderRules{
+			Set:    map[string]string{"Cache-Control": "no-store", "X-Route": "{{.Route}}"},
+			Remove: []string{"X-Internal"},
+		},
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/orders/42", nil)
+	req.Header.Set("X-Tenant", "acme")
+	vars := headerVars(req, route, map[string]string{"id": "42"}, &middleware.UserContext{UserID: "user-1", Email: "a@hub.com"})
+
+	md := metadata.New(map[string]string{"x-user-email": "a@hub.com", "x-tenant": "default"})
+	applyRequestHeaders(md, route.RequestHeaders, vars)
+	if got := md.Get("x-forwarded-user"); len(got) != 1 || got[0] != "user-1" {
+		t.Errorf("expected x-forwarded-user user-1 but got %v", got)
+	}
+	if got := md.Get("x-order"); len(got) != 1 || got[0] != "42" {
+		t.Errorf("expected x-order 42 but got %v", got)
+	}
+	if got := md.Get("x-tenant"); len(got) != 2 || got[1] != "acme" {
+		t.Errorf("expected x-tenant to be appended but got %v", got)
+	}
+	if got := md.Get("x-user-email"); len(got) != 0 {
+		t.Errorf("expected x-user-email to be removed but got %v", got)
+	}
+
+	// Response rules also cover headers the handler sets itself
+	recorder := httptest.NewRecorder()
+	w := withResponseHeaders(recorder, route.ResponseHeaders, vars)
+	w.Header().Set("X-Internal", "debug")
+	w.Header().Set("Cache-Control", "private")
+	w.WriteHeader(http.StatusOK)
+
+	if recorder.Header().Get("X-Internal") != "" || recorder.Header().Get("Cache-Control") != "no-store" || recorder.Header().Get("X-Route") != "get-order" {
+		t.Errorf("unexpected response headers %v", recorder.Header())
+	}
+}
@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// hedgeSamples is how many recent latencies of a route its p95 is taken from
+	hedgeSamples = 128
+	// hedgeMinSamples is how many latencies a route needs before it's hedged on its p95
+	hedgeMinSamples = 20
+)
+
+// latencyTracker keeps the latest successful call latencies of a route
+type latencyTracker struct {
+	mu      sync.Mutex
+	samples [hedgeSamples]time.Duration
+	count   int
+	next    int
+}
+
+// add records a latency, replacing the oldest once full
+func (t *latencyTracker) add(latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.samples[t.next] = latency
+	t.next = (t.next + 1) % hedgeSamples
+	t.count = min(t.count+1, hedgeSamples)
+}
+
+// p95 returns the 95th percentile of the recorded latencies, false until
+// there are enough of them
+func (t *latencyTracker) p95() (time.Duration, bool) {
+	t.mu.Lock()
+	if t.count < hedgeMinSamples {
+		t.mu.Unlock()
+		return 0, false
+	}
+	sorted := slices.Clone(t.samples[:t.count])
+	t.mu.Unlock()
+
+	slices.Sort(sorted)
+	return sorted[len(sorted)*95/100], true
+}
+
+// hedgeAttempt is the outcome of one attempt of a hedged call
+type hedgeAttempt struct {
+	response        proto.Message
+	header, trailer metadata.MD
+	latency         time.Duration
+	hedged          bool
+	err             error
+}
+
+// hedged reports whether a request is hedged: a GET on a route with hedge
+func hedged(r *http.Request, route *router.Route) bool {
+	return route.Hedge != nil && r.Method == http.MethodGet
+}
+
+// hedgeDelay returns how long the first attempt of a route may take before a
+// second one is sent: the route's fixed delay, else its recent p95 latency
+func (h *ProxyHandler) hedgeDelay(route *router.Route) (time.Duration, bool) {
+	if delay := route.Hedge.DelayDuration(); delay > 0 {
+		return delay, true
+	}
+	return h.latencyFor(route.Name).p95()
+}
+
+// latencyFor returns the latency tracker of a route
+func (h *ProxyHandler) latencyFor(routeName string) *latencyTracker {
+	if tracker, ok := h.hedgeLatency.Load(routeName); ok {
+		return tracker.(*latencyTracker)
+	}
+	tracker, _ := h.hedgeLatency.LoadOrStore(routeName, &latencyTracker{})
+	return tracker.(*latencyTracker)
+}
+
+// invokeHedged is invoke for hedged routes. When the first attempt hasn't
+// answered within the hedge delay, a second one goes out on the service's
+// next channel (and so usually another replica); the first success wins and
+// the other attempt is cancelled. A first attempt that fails before the
+// delay isn't hedged. With retries enabled, hedges spend the retry budget.
+func (h *ProxyHandler) invokeHedged(ctx context.Context, r *http.Request, route *router.Route, serviceName string, conn *grpc.ClientConn, fullMethod string, request, response proto.Message, header, trailer *metadata.MD) error {
+	tracker := h.latencyFor(route.Name)
+	delay, ok := h.hedgeDelay(route)
+	if !ok {
+		// Not enough latencies yet to know what slow is
+		start := time.Now()
+		err := h.invoke(ctx, r, serviceName, conn, fullMethod, request, response, grpc.Header(header), grpc.Trailer(trailer))
+		if err == nil {
+			tracker.add(time.Since(start))
+		}
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	attempts := make(chan hedgeAttempt, 2)
+	send := func(conn *grpc.ClientConn, hedged bool) {
+		attempt := hedgeAttempt{response: response.ProtoReflect().New().Interface(), hedged: hedged}
+		start := time.Now()
+		attempt.err = h.invoke(ctx, r, serviceName, conn, fullMethod, request, attempt.response,
+			grpc.Header(&attempt.header), grpc.Trailer(&attempt.trailer))
+		attempt.latency = time.Since(start)
+		attempts <- attempt
+	}
+	go send(conn, false)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	pending, hedgeSent := 1, false
+	var result hedgeAttempt
+	for pending > 0 {
+		select {
+		case result = <-attempts:
+			pending--
+			if result.err == nil || !hedgeSent {
+				pending = 0
+			}
+		case <-timer.C:
+			if h.retryPolicy != nil && !h.budgetFor(serviceName).withdraw() {
+				continue
+			}
+			hedgeConn, err := h.registry.GetConnection(serviceName)
+			if err != nil {
+				continue
+			}
+			hedgeSent = true
+			pending++
+			h.metrics.RecordHedge()
+			slog.DebugContext(r.Context(), "hedging slow backend call", "route", route.Name, "grpc_method", fullMethod,
+				"delay_ms", delay.Milliseconds())
+			go send(hedgeConn, true)
+		}
+	}
+
+	*header, *trailer = result.header, result.trailer
+	if result.err != nil {
+		return result.err
+	}
+	tracker.add(result.latency)
+	if result.hedged {
+		h.metrics.RecordHedgeWin()
+	}
+	proto.Merge(response, result.response)
+	return nil
+}
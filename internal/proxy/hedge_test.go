@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+func TestInvokeHedged(t *testing.T) {
+	// The first call hangs like a slow replica, later ones answer at once
+	listener := bufconn.Listen(1 << 20)
+	var calls atomic.Int32
+	server := grpc.NewServer(grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+		if err := stream.RecvMsg(&emptypb.Empty{}); err != nil {
+			return err
+		}
+		if calls.Add(1) == 1 {
+			<-stream.Context().Done()
+			return stream.Context().Err()
+		}
+		return stream.SendMsg(&emptypb.Empty{})
+	}))
+	go server.Serve(listener)
+	defer server.Stop()
+
+	pool := newConnPool()
+	for range 2 {
+		conn, err := grpc.NewClient("passthrough:///bufnet",
+			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		pool.conns = append(pool.conns, conn)
+	}
+	defer pool.close()
+
+	registry := NewServiceRegistry(&config.Config{Services: map[string]config.ServiceConfig{"market-data-service": {}}})
+	registry.connections["market-data-service"] = pool
+	h := NewProxyHandler(registry, metrics.NewMetrics(), nil)
+	route := &router.Route{Name: "get-quote", Method: "GET", Hedge: &router.RouteHedge{Delay: "20ms"}}
+
+	start := time.Now()
+	var header, trailer metadata.MD
+	err := h.invokeHedged(context.Background(), httptest.NewRequest("GET", "/api/v1/market-data/AAPL", nil), route,
+		"market-data-service", pool.pick(), "/market.MarketDataService/GetQuote", &emptypb.Empty{}, &emptypb.Empty{}, &header, &trailer)
+	if err != nil {
+		t.Fatalf("expected the hedged attempt to answer but got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the hedge to cut the wait short but it took %v", elapsed)
+	}
+	if snapshot := h.metrics.GetSnapshot(); snapshot.HedgedRequests != 1 || snapshot.HedgeWins != 1 {
+		t.Errorf("expected 1 hedge that won but got %d sent and %d won", snapshot.HedgedRequests, snapshot.HedgeWins)
+	}
+
+	// Without a fixed delay, routes aren't hedged until their p95 is known
+	route.Hedge.Delay = ""
+	if _, ok := h.hedgeDelay(route); ok {
+		t.Error("expected no hedge delay before enough latencies were recorded")
+	}
+}
@@ -0,0 +1,42 @@
+package proxy
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/auth"
+	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/router"
+)
+
+func TestInternalTokenReplacesClientCredentials(t *testing.T) {
+	issuer, err := auth.NewInternalTokenIssuer("", "hub-api-gateway", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewProxyHandler(NewServiceRegistry(&config.Config{}), metrics.NewMetrics(), nil)
+	h.EnableInternalTokens(issuer)
+
+	route := &router.Route{Name: "get-order", Service: "order-service", RequestHeaders: &router.HeaderRules{
+		Set: map[string]string{"Authorization": "Bearer forwarded", "x-source": "gateway"},
+	}}
+	r := httptest.NewRequest("GET", "/api/v1/orders/1", nil)
+	r.Header.Set("Authorization", "Bearer client-token")
+
+	md, err := h.outgoingMetadata(r, route, "/api/v1/orders/1", PriorityNormal, nil, nil, router.HeaderVars{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := md.Get("authorization"); len(got) != 0 {
+		t.Errorf("expected no authorization metadata next to the internal token but got %v", got)
+	}
+	if len(md.Get(auth.InternalTokenMetadata)) != 1 || md.Get("x-source")[0] != "gateway" {
+		t.Errorf("expected the internal token and other header rules to apply, got %v", md)
+	}
+
+	if err := CheckInternalTokenHeaders(route); err == nil {
+		t.Error("expected routes setting authorization to be rejected with internal tokens")
+	}
+}
@@ -0,0 +1,79 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// decodeJSON decodes a JSON document keeping numbers as json.Number so their
+// exact textual representation survives re-encoding (no float64 round-trip)
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// stringifyFields rewrites the numeric fields at the given paths as JSON strings.
+// Paths are dot-separated field names relative to the response body; a "*"
+// segment matches every element of an array or every value of an object,
+// e.g. "positions.*.market_value".
+func stringifyFields(body []byte, paths []string) []byte {
+	if len(paths) == 0 {
+		return body
+	}
+
+	document, err := decodeJSON(body)
+	if err != nil {
+		return body
+	}
+
+	for _, path := range paths {
+		document = stringifyPath(document, strings.Split(path, "."))
+	}
+
+	encoded, err := json.Marshal(document)
+	if err != nil {
+		return body
+	}
+	return encoded
+}
+
+// stringifyPath applies a single path to a decoded JSON value
+func stringifyPath(value interface{}, segments []string) interface{} {
+	if len(segments) == 0 {
+		if number, ok := value.(json.Number); ok {
+			return number.String()
+		}
+		return value
+	}
+
+	segment, rest := segments[0], segments[1:]
+
+	switch node := value.(type) {
+	case map[string]interface{}:
+		if segment == "*" {
+			for key, child := range node {
+				node[key] = stringifyPath(child, rest)
+			}
+		} else if child, ok := node[segment]; ok {
+			node[segment] = stringifyPath(child, rest)
+		}
+	case []interface{}:
+		// Arrays are traversed implicitly, so "positions.market_value" and
+		// "positions.*.market_value" are equivalent
+		if segment != "*" {
+			rest = segments
+		}
+		for i, child := range node {
+			node[i] = stringifyPath(child, rest)
+		}
+	}
+
+	return value
+}
@@ -0,0 +1,61 @@
+package proxy
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestStringifyFieldsLargeBalances(t *testing.T) {
+	body := []byte(`{"balance":{"available_balance":12345678901234567.89,"currency":"BRL"},` +
+		`"positions":[{"symbol":"PETR4","market_value":98765432109876.54},{"symbol":"VALE3","market_value":0.1}],` +
+		`"total":9007199254740993}`)
+
+	result := stringifyFields(body, []string{
+		"balance.available_balance",
+		"positions.*.market_value",
+		"total",
+		"missing.field",
+	})
+
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(result, &decoded); err != nil {
+		t.Fatalf("invalid JSON output: %v", err)
+	}
+
+	balance := decoded["balance"].(map[string]interface{})
+	if balance["available_balance"] != "12345678901234567.89" {
+		t.Errorf("expected exact balance string but got %v", balance["available_balance"])
+	}
+	if balance["currency"] != "BRL" {
+		t.Errorf("non-numeric fields must be untouched, got %v", balance["currency"])
+	}
+
+	positions := decoded["positions"].([]interface{})
+	if positions[0].(map[string]interface{})["market_value"] != "98765432109876.54" ||
+		positions[1].(map[string]interface{})["market_value"] != "0.1" {
+		t.Errorf("expected market values as strings but got %v", positions)
+	}
+
+	if decoded["total"] != "9007199254740993" {
+		t.Errorf("expected integer beyond 2^53 preserved but got %v", decoded["total"])
+	}
+}
+
+func TestStringifyFieldsImplicitArrays(t *testing.T) {
+	body := []byte(`[{"amount":1.5},{"amount":2}]`)
+
+	result := stringifyFields(body, []string{"amount"})
+	if string(result) != `[{"amount":"1.5"},{"amount":"2"}]` {
+		t.Errorf("unexpected output: %s", result)
+	}
+}
+
+func TestUnwrapAPIResponsePreservesPrecision(t *testing.T) {
+	h := &ProxyHandler{}
+	body := []byte(`{"api_response":{"success":true},"balance":{"total":12345678901234567.89}}`)
+
+	result := h.unwrapAPIResponse(body)
+	if string(result) != `{"total":12345678901234567.89}` {
+		t.Errorf("unexpected output: %s", result)
+	}
+}
@@ -0,0 +1,111 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Long-poll outcomes reported in the X-Long-Poll response header
+const (
+	longPollChanged = "changed"
+	longPollTimeout = "timeout"
+)
+
+// longPollRequest holds the long-poll parameters of a request
+type longPollRequest struct {
+	wait     time.Duration // How long the client is willing to wait
+	since    string        // Last value seen by the client; empty uses the first polled value
+	interval time.Duration
+}
+
+// EnableLongPolling allows routes with long_poll_field to hold GET requests
+// (?wait=<seconds>) until the watched field changes, up to maxWait
+func (h *ProxyHandler) EnableLongPolling(maxWait, interval time.Duration) {
+	h.longPollMaxWait = maxWait
+	h.longPollInterval = interval
+}
+
+// parseLongPoll returns the long-poll parameters when the route supports
+// long-polling and the client asked for it with ?wait=<seconds>
+func (h *ProxyHandler) parseLongPoll(r *http.Request, route *router.Route) (*longPollRequest, bool) {
+	if route.LongPollField == "" || h.longPollMaxWait <= 0 || r.Method != http.MethodGet {
+		return nil, false
+	}
+
+	seconds, err := strconv.Atoi(r.URL.Query().Get("wait"))
+	if err != nil || seconds <= 0 {
+		return nil, false
+	}
+
+	wait := time.Duration(seconds) * time.Second
+	if wait > h.longPollMaxWait {
+		wait = h.longPollMaxWait
+	}
+
+	return &longPollRequest{
+		wait:     wait,
+		since:    r.URL.Query().Get("since"),
+		interval: h.longPollInterval,
+	}, true
+}
+
+// longPoll invokes the backend repeatedly until the route's watched field
+// differs from the client's last known value or the wait elapses. It returns
+// the latest response and whether a change was observed.
+func (h *ProxyHandler) longPoll(ctx context.Context, conn *grpc.ClientConn, fullMethod string, route *router.Route,
+	poll *longPollRequest, newMessages func() (proto.Message, proto.Message, error)) (proto.Message, bool, error) {
+
+	deadline := time.NewTimer(poll.wait)
+	defer deadline.Stop()
+
+	baseline := poll.since
+	for {
+		request, response, err := newMessages()
+		if err != nil {
+			return nil, false, err
+		}
+
+		if err := conn.Invoke(ctx, fullMethod, request, response); err != nil {
+			return nil, false, err
+		}
+
+		current, err := fieldValue(response, route.LongPollField)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if baseline == "" {
+			baseline = current
+		} else if current != baseline {
+			return response, true, nil
+		}
+
+		select {
+		case <-time.After(poll.interval):
+		case <-deadline.C:
+			return response, false, nil
+		case <-ctx.Done():
+			return response, false, nil
+		}
+	}
+}
+
+// fieldValue returns the string form of a top-level field of a proto message
+func fieldValue(msg proto.Message, field string) (string, error) {
+	message := msg.ProtoReflect()
+	descriptor := message.Descriptor().Fields().ByName(protoreflect.Name(field))
+	if descriptor == nil {
+		return "", fmt.Errorf("long_poll_field %s not found in %s", field, message.Descriptor().FullName())
+	}
+
+	return fmt.Sprint(message.Get(descriptor).Interface()), nil
+}
@@ -0,0 +1,133 @@
+package proxy
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DrainState describes a backend service placed in maintenance
+type DrainState struct {
+	Service     string    `json:"service"`
+	Message     string    `json:"message"`
+	ServeCached bool      `json:"serve_cached"`        // Serve last known GET responses instead of 503
+	Since       time.Time `json:"since"`               // When draining started
+	Until       time.Time `json:"until,omitempty"`     // Expected end of the window (optional)
+	RetryAfter  int       `json:"retry_after_seconds"` // Derived from Until; 0 when unknown
+}
+
+// StartDrain marks a service as draining: new non-critical traffic is no longer proxied to it
+func (r *ServiceRegistry) StartDrain(state DrainState) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if state.Since.IsZero() {
+		state.Since = time.Now()
+	}
+	r.draining[state.Service] = state
+}
+
+// StopDrain returns a draining service to normal operation
+func (r *ServiceRegistry) StopDrain(serviceName string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, existed := r.draining[serviceName]
+	delete(r.draining, serviceName)
+	return existed
+}
+
+// GetDrainState returns the drain state of a service, if it is draining
+func (r *ServiceRegistry) GetDrainState(serviceName string) (DrainState, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	state, ok := r.draining[serviceName]
+	if ok && !state.Until.IsZero() {
+		if remaining := time.Until(state.Until); remaining > 0 {
+			state.RetryAfter = int(remaining.Seconds()) + 1
+		}
+	}
+	return state, ok
+}
+
+// GetDrainingServices returns all draining services sorted by name
+func (r *ServiceRegistry) GetDrainingServices() []DrainState {
+	r.mu.RLock()
+	names := make([]string, 0, len(r.draining))
+	for name := range r.draining {
+		names = append(names, name)
+	}
+	r.mu.RUnlock()
+
+	sort.Strings(names)
+	states := make([]DrainState, 0, len(names))
+	for _, name := range names {
+		if state, ok := r.GetDrainState(name); ok {
+			states = append(states, state)
+		}
+	}
+	return states
+}
+
+// responseSnapshot is a previously served successful GET response
+type responseSnapshot struct {
+	key      string
+	body     []byte
+	storedAt time.Time
+}
+
+// SnapshotStore is a bounded LRU of the last successful GET responses,
+// used to keep read paths answering while a backend is drained
+type SnapshotStore struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List
+	items    map[string]*list.Element
+}
+
+// NewSnapshotStore creates a snapshot store holding up to capacity responses
+func NewSnapshotStore(capacity int) *SnapshotStore {
+	return &SnapshotStore{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Store saves a response body under key, evicting the least recently used entry
+func (s *SnapshotStore) Store(key string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.items[key]; ok {
+		snapshot := elem.Value.(*responseSnapshot)
+		snapshot.body = body
+		snapshot.storedAt = time.Now()
+		s.order.MoveToFront(elem)
+		return
+	}
+
+	s.items[key] = s.order.PushFront(&responseSnapshot{key: key, body: body, storedAt: time.Now()})
+
+	if s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.items, oldest.Value.(*responseSnapshot).key)
+	}
+}
+
+// Load returns the stored body for key and when it was captured
+func (s *SnapshotStore) Load(key string) ([]byte, time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	elem, ok := s.items[key]
+	if !ok {
+		return nil, time.Time{}, false
+	}
+	s.order.MoveToFront(elem)
+	snapshot := elem.Value.(*responseSnapshot)
+	return snapshot.body, snapshot.storedAt, true
+}
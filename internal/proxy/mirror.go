@@ -0,0 +1,126 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"net/http"
+
+	"hub-api-gateway/internal/auth"
+	"hub-api-gateway/internal/logging"
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/middleware"
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// MirrorMetadata marks mirrored calls, so mirror backends can skip side
+// effects (e.g. placing a real order)
+const MirrorMetadata = "x-gateway-mirror"
+
+// defaultMirrorLimit caps mirrored calls in flight unless SetMirrorLimit is called
+const defaultMirrorLimit = 100
+
+// SetMirrorLimit caps the mirrored calls in flight; further requests aren't
+// mirrored until some finish
+func (h *ProxyHandler) SetMirrorLimit(limit int) {
+	if limit > 0 {
+		h.mirrorSlots = make(chan struct{}, limit)
+	}
+}
+
+// mirror copies a sampled request to the route's mirror service in the
+// background and records how its response compared with the primary's.
+// Requests that never reached the primary backend aren't mirrored.
+func (h *ProxyHandler) mirror(r *http.Request, route *router.Route, userContext *middleware.UserContext, fullMethod string, md metadata.MD, request, primary proto.Message, primaryErr error, newResponse func() proto.Message) {
+	if route.Mirror == nil || rand.Float64()*100 >= route.Mirror.Percent || !reachedBackend(primaryErr) {
+		return
+	}
+
+	select {
+	case h.mirrorSlots <- struct{}{}:
+	default:
+		h.metrics.RecordMirror(route.Name, metrics.MirrorSkipped)
+		return
+	}
+
+	service := route.Mirror.Service
+	mirrorMD := md.Copy()
+	mirrorMD.Set(MirrorMetadata, "true")
+	if h.internalTokens != nil {
+		err := setInternalToken(mirrorMD, h.internalTokens, userContext, auth.BackendCall{
+			Service:   service,
+			Route:     route.Name,
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			RequestID: logging.RequestID(r.Context()),
+		})
+		if err != nil {
+			<-h.mirrorSlots
+			h.metrics.RecordMirror(route.Name, metrics.MirrorError)
+			return
+		}
+	}
+
+	timeout := route.Mirror.TimeoutDuration()
+	if timeout <= 0 {
+		timeout = h.upstreamTimeout(&router.Route{}, service)
+	}
+	requestID := logging.RequestID(r.Context())
+
+	go func() {
+		defer func() { <-h.mirrorSlots }()
+
+		// The client's request is over by now; the mirror has its own deadline
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		ctx = metadata.NewOutgoingContext(logging.WithRequestID(ctx, requestID), mirrorMD)
+
+		mirrored := newResponse()
+		err := callWithBreaker(h.registry.ServiceCircuitBreaker(service), func() error {
+			conn, err := h.registry.GetConnection(service)
+			if err != nil {
+				return fmt.Errorf("%w: %w", errNoConnection, err)
+			}
+			return conn.Invoke(ctx, fullMethod, request, mirrored)
+		})
+
+		result := compareMirror(primary, primaryErr, mirrored, err)
+		h.metrics.RecordMirror(route.Name, result)
+		switch result {
+		case metrics.MirrorDiverged:
+			slog.Warn("mirrored response diverged", "route", route.Name, "mirror", service, "request_id", requestID,
+				"primary_code", status.Code(primaryErr).String(), "mirror_code", status.Code(err).String())
+		case metrics.MirrorError:
+			slog.Debug("mirrored request failed", "route", route.Name, "mirror", service, "error", err)
+		}
+	}()
+}
+
+// reachedBackend reports whether a primary call got an answer from its
+// backend, rather than being stopped by the gateway
+func reachedBackend(err error) bool {
+	return !errors.Is(err, errNoConnection) && !errors.Is(err, ErrCircuitOpen) && !errors.Is(err, ErrTooManyRequests) &&
+		!errors.Is(err, ErrBackendEjected) && !errors.Is(err, ErrIncompatibleBackend) && !errors.Is(err, context.Canceled)
+}
+
+// compareMirror classifies a mirrored call against the primary call
+func compareMirror(primary proto.Message, primaryErr error, mirrored proto.Message, mirrorErr error) string {
+	primaryCode, mirrorCode := status.Code(primaryErr), status.Code(mirrorErr)
+	switch {
+	case !reachedBackend(mirrorErr), mirrorCode == codes.Unavailable && primaryCode != codes.Unavailable:
+		return metrics.MirrorError
+	case primaryCode != mirrorCode:
+		return metrics.MirrorDiverged
+	case primaryErr == nil && !proto.Equal(primary, mirrored):
+		return metrics.MirrorDiverged
+	default:
+		return metrics.MirrorMatch
+	}
+}
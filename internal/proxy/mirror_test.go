@@ -0,0 +1,70 @@
+package proxy
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestCompareMirror(t *testing.T) {
+	tests := []struct {
+		name       string
+		primary    proto.Message
+		primaryErr error
+		mirrored   proto.Message
+		mirrorErr  error
+		expected   string
+	}{
+		{name: "same response", primary: wrapperspb.String("a"), mirrored: wrapperspb.String("a"), expected: metrics.MirrorMatch},
+		{name: "different response", primary: wrapperspb.String("a"), mirrored: wrapperspb.String("b"), expected: metrics.MirrorDiverged},
+		{name: "same error", primaryErr: status.Error(codes.NotFound, "x"), mirrorErr: status.Error(codes.NotFound, "y"), expected: metrics.MirrorMatch},
+		{name: "different status", primary: wrapperspb.String("a"), mirrorErr: status.Error(codes.Internal, "boom"), expected: metrics.MirrorDiverged},
+		{name: "mirror unreachable", primary: wrapperspb.String("a"), mirrorErr: status.Error(codes.Unavailable, "down"), expected: metrics.MirrorError},
+		{name: "mirror breaker open", primary: wrapperspb.String("a"), mirrorErr: ErrCircuitOpen, expected: metrics.MirrorError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := compareMirror(tt.primary, tt.primaryErr, tt.mirrored, tt.mirrorErr); got != tt.expected {
+				t.Errorf("expected %s but got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestMirror(t *testing.T) {
+	registry := NewServiceRegistry(&config.Config{Services: map[string]config.ServiceConfig{"order-service-canary": {}}})
+	canary, calls := flakyBackend(t, 1000, codes.NotFound)
+	registry.connections["order-service-canary"] = newConnPool(canary)
+
+	m := metrics.NewMetrics()
+	h := NewProxyHandler(registry, m, nil)
+	route := &router.Route{Name: "get-order", Service: "order-service", Mirror: &router.RouteMirror{Service: "order-service-canary", Percent: 100}}
+	r := httptest.NewRequest("GET", "/api/v1/orders/1", nil)
+	newResponse := func() proto.Message { return &emptypb.Empty{} }
+
+	// The primary answered, the canary doesn't know the order
+	h.mirror(r, route, nil, "/orders.OrderService/GetOrder", metadata.MD{}, &emptypb.Empty{}, &emptypb.Empty{}, nil, newResponse)
+
+	// Calls the gateway refused aren't mirrored
+	h.mirror(r, route, nil, "/orders.OrderService/GetOrder", metadata.MD{}, &emptypb.Empty{}, &emptypb.Empty{}, ErrCircuitOpen, newResponse)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for m.GetSnapshot().MirrorDiverged == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if snapshot := m.GetSnapshot(); snapshot.MirrorDiverged != 1 || snapshot.MirrorMatched != 0 || *calls != 1 {
+		t.Errorf("expected one diverged mirror call but got %d diverged, %d matched after %d calls", snapshot.MirrorDiverged, snapshot.MirrorMatched, *calls)
+	}
+}
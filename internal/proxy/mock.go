@@ -0,0 +1,47 @@
+package proxy
+
+import (
+	"log/slog"
+	"net/http"
+	"time"
+
+	"hub-api-gateway/internal/router"
+)
+
+// MockHeader marks responses served from a route's mock
+const MockHeader = "X-Gateway-Mock"
+
+// EnableMockMode serves the mock responses of routes that define one instead
+// of calling their backends
+func (h *ProxyHandler) EnableMockMode() {
+	h.mockMode = true
+}
+
+// serveMock writes the route's mock response after its simulated latency
+func (h *ProxyHandler) serveMock(w http.ResponseWriter, r *http.Request, route *router.Route) {
+	startTime := time.Now()
+	if latency := route.Mock.LatencyDuration(); latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-r.Context().Done():
+			return
+		case <-timer.C:
+		}
+	}
+
+	body, err := route.Mock.JSON()
+	if err != nil {
+		h.fail(w, r, route, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to encode mock response")
+		return
+	}
+	for name, value := range route.Mock.Headers {
+		w.Header().Set(name, value)
+	}
+	w.Header().Set(MockHeader, "true")
+
+	statusCode := route.Mock.StatusCode()
+	slog.InfoContext(r.Context(), "mock mode: serving mock response", "route", route.Name, "method", r.Method, "path", r.URL.Path, "status", statusCode)
+	h.metrics.RecordRequest(route.Name, "", time.Since(startTime), statusCode < http.StatusInternalServerError)
+	writeJSONBody(w, statusCode, body)
+}
@@ -0,0 +1,49 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/router"
+)
+
+func TestMockMode(t *testing.T) {
+	h := NewProxyHandler(NewServiceRegistry(&config.Config{}), metrics.NewMetrics(), nil)
+	route := &router.Route{
+		Name:        "get-watchlist",
+		Path:        "/api/v1/watchlist",
+		Method:      "GET",
+		Service:     "watchlist-service",
+		GRPCService: "watchlist.WatchlistService",
+		GRPCMethod:  "GetWatchlist",
+		Mock: &router.RouteMock{
+			Status:  http.StatusCreated,
+			Body:    map[string]interface{}{"symbols": []string{"PETR4", "VALE3"}},
+			Latency: "5ms",
+			Headers: map[string]string{"Cache-Control": "no-store"},
+		},
+	}
+	if err := NewDescriptorRegistry().CheckRoute(route); err != nil {
+		t.Fatalf("expected a mocked route to load without its RPC: %v", err)
+	}
+
+	// Outside mock mode the missing RPC isn't implemented yet
+	w := httptest.NewRecorder()
+	h.HandleRequest(w, httptest.NewRequest("GET", "/api/v1/watchlist", nil), route)
+	if w.Code != http.StatusNotImplemented {
+		t.Errorf("expected 501 outside mock mode but got %d: %s", w.Code, w.Body)
+	}
+
+	h.EnableMockMode()
+	w = httptest.NewRecorder()
+	h.HandleRequest(w, httptest.NewRequest("GET", "/api/v1/watchlist", nil), route)
+	if w.Code != http.StatusCreated || w.Header().Get(MockHeader) != "true" || w.Header().Get("Cache-Control") != "no-store" {
+		t.Fatalf("unexpected mock response %d %v", w.Code, w.Header())
+	}
+	if body := w.Body.String(); body != `{"symbols":["PETR4","VALE3"]}` {
+		t.Errorf("unexpected mock body %s", body)
+	}
+}
@@ -0,0 +1,299 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"hub-api-gateway/internal/auth"
+	"hub-api-gateway/internal/logging"
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/middleware"
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// passthroughStreamDesc lets every kind of call through: unary calls are
+// streams of one message each way
+var passthroughStreamDesc = &grpc.StreamDesc{ClientStreams: true, ServerStreams: true}
+
+// Metadata clients can't set on passthrough calls: the gateway's own identity
+// headers and the transport's
+var passthroughStrippedMetadata = []string{
+	"x-user-id", "x-user-email", TenantMetadata, auth.InternalTokenMetadata, ":authority", "content-type", "user-agent", "te",
+}
+
+// rawFrame is an undecoded gRPC message
+type rawFrame struct {
+	data []byte
+}
+
+// rawCodec relays messages as opaque bytes. It is named "proto" so calls keep
+// the application/grpc content type clients and backends expect.
+type rawCodec struct{}
+
+func (rawCodec) Marshal(v any) ([]byte, error) {
+	frame, ok := v.(*rawFrame)
+	if !ok {
+		return nil, fmt.Errorf("raw codec can't marshal %T", v)
+	}
+	return frame.data, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v any) error {
+	frame, ok := v.(*rawFrame)
+	if !ok {
+		return fmt.Errorf("raw codec can't unmarshal into %T", v)
+	}
+	frame.data = append(frame.data[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
+
+// Passthrough proxies native gRPC calls to backends without transcoding. The
+// backend comes from the service name in the call's :path, so any method of a
+// routed service (unary or streaming) goes through with the gateway's
+// authentication, circuit breakers, metrics and request IDs.
+type Passthrough struct {
+	registry     *ServiceRegistry
+	metrics      *metrics.Metrics
+	authenticate func(ctx context.Context, token string) (*middleware.UserContext, error)
+	authRequired bool
+
+	// Signs the caller's identity for backends (nil forwards authorization)
+	internalTokens *auth.InternalTokenIssuer
+
+	mu        sync.RWMutex
+	services  map[string]string // Fully-qualified gRPC service -> backend service
+	overrides map[string]string
+}
+
+// NewPassthrough creates a passthrough proxy. overrides maps fully-qualified
+// gRPC services to backends, on top of those known from the route table.
+func NewPassthrough(registry *ServiceRegistry, m *metrics.Metrics, overrides map[string]string) *Passthrough {
+	p := &Passthrough{
+		registry:  registry,
+		metrics:   m,
+		services:  make(map[string]string),
+		overrides: overrides,
+	}
+	p.SetRoutes(nil)
+	return p
+}
+
+// SetAuthenticator validates bearer tokens from the authorization metadata.
+// When required, calls without a token are rejected as Unauthenticated.
+func (p *Passthrough) SetAuthenticator(fn func(ctx context.Context, token string) (*middleware.UserContext, error), required bool) {
+	p.authenticate = fn
+	p.authRequired = required
+}
+
+// SetRoutes rebuilds the service map from the route table (call it whenever
+// routes change)
+func (p *Passthrough) SetRoutes(routes []router.Route) {
+	services := make(map[string]string, len(routes)+len(p.overrides))
+	for _, route := range routes {
+		if route.GRPCService == "" {
+			continue
+		}
+		service := route.GRPCService
+		if !strings.Contains(service, ".") {
+			service = defaultProtoPackage + "." + service
+		}
+		services[service] = route.GetTargetService()
+	}
+	for service, backend := range p.overrides {
+		services[service] = backend
+	}
+
+	p.mu.Lock()
+	p.services = services
+	p.mu.Unlock()
+}
+
+// backendFor returns the backend serving a fully-qualified gRPC service
+func (p *Passthrough) backendFor(service string) (string, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	backend, ok := p.services[service]
+	return backend, ok
+}
+
+// NewServer returns a gRPC server that proxies every call it receives
+func (p *Passthrough) NewServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.ForceServerCodec(rawCodec{}), grpc.UnknownServiceHandler(p.handle))
+	return grpc.NewServer(opts...)
+}
+
+// handle proxies one call
+func (p *Passthrough) handle(_ any, stream grpc.ServerStream) error {
+	startTime := time.Now()
+	fullMethod, _ := grpc.MethodFromServerStream(stream)
+	service, _, _ := strings.Cut(strings.TrimPrefix(fullMethod, "/"), "/")
+
+	backend, ok := p.backendFor(service)
+	if !ok {
+		return status.Errorf(codes.Unimplemented, "unknown service %s", service)
+	}
+
+	incoming, _ := metadata.FromIncomingContext(stream.Context())
+	requestID := firstMetadata(incoming, logging.RequestIDMetadata)
+	if requestID == "" {
+		requestID = logging.NewRequestID()
+	}
+	ctx := logging.WithRequestID(stream.Context(), requestID)
+
+	outgoing := incoming.Copy()
+	for _, key := range passthroughStrippedMetadata {
+		delete(outgoing, key)
+	}
+	outgoing.Set(logging.RequestIDMetadata, requestID)
+
+	userContext, err := p.authenticateCall(ctx, incoming)
+	if err != nil {
+		slog.WarnContext(ctx, "rejected passthrough call", "grpc_method", fullMethod, "error", err)
+		p.metrics.RecordRequest(fullMethod, backend, time.Since(startTime), false)
+		return err
+	}
+	if userContext != nil {
+		outgoing.Set("x-user-id", userContext.UserID)
+		outgoing.Set("x-user-email", userContext.Email)
+		if userContext.TenantID != "" {
+			outgoing.Set(TenantMetadata, userContext.TenantID)
+		}
+	}
+	if p.internalTokens != nil {
+		err := setInternalToken(outgoing, p.internalTokens, userContext, auth.BackendCall{
+			Service:   backend,
+			Method:    "POST",
+			Path:      fullMethod,
+			RequestID: requestID,
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to issue internal token", "grpc_method", fullMethod, "error", err)
+			p.metrics.RecordRequest(fullMethod, backend, time.Since(startTime), false)
+			return status.Error(codes.Internal, "failed to authorize backend call")
+		}
+	}
+
+	ctx, cancel := context.WithCancel(metadata.NewOutgoingContext(ctx, outgoing))
+	defer cancel()
+
+	var upstream grpc.ClientStream
+	err = callWithBreaker(p.registry.ServiceCircuitBreaker(backend), func() error {
+		conn, err := p.registry.GetConnection(backend)
+		if err != nil {
+			return fmt.Errorf("%w: %w", errNoConnection, err)
+		}
+		if err := p.registry.CheckAvailable(backend); err != nil {
+			return err
+		}
+		upstream, err = conn.NewStream(ctx, passthroughStreamDesc, fullMethod, grpc.ForceCodec(rawCodec{}))
+		if err != nil {
+			return err
+		}
+		return relay(stream, upstream, cancel)
+	})
+	p.registry.RecordOutcome(backend, err)
+
+	switch {
+	case errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrTooManyRequests):
+		p.metrics.RecordCircuitBreakerTrip()
+		err = status.Errorf(codes.Unavailable, "service %s is temporarily unavailable (circuit breaker open)", backend)
+	case errors.Is(err, errNoConnection), errors.Is(err, ErrBackendEjected):
+		err = status.Errorf(codes.Unavailable, "service %s is unavailable", backend)
+	}
+
+	elapsed := time.Since(startTime)
+	p.metrics.RecordRequest(fullMethod, backend, elapsed, status.Code(err) == codes.OK)
+	if err != nil {
+		slog.WarnContext(ctx, "passthrough call failed", "grpc_method", fullMethod, "service", backend,
+			"code", status.Code(err).String(), "duration_ms", elapsed.Milliseconds())
+	} else {
+		slog.InfoContext(ctx, "passthrough call completed", "grpc_method", fullMethod, "service", backend,
+			"duration_ms", elapsed.Milliseconds())
+	}
+	return err
+}
+
+// authenticateCall validates the call's bearer token, if any
+func (p *Passthrough) authenticateCall(ctx context.Context, md metadata.MD) (*middleware.UserContext, error) {
+	token := strings.TrimSpace(strings.TrimPrefix(firstMetadata(md, "authorization"), "Bearer "))
+	if token == "" || p.authenticate == nil {
+		if p.authRequired {
+			return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+		}
+		return nil, nil
+	}
+
+	userContext, err := p.authenticate(ctx, token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "invalid or expired token")
+	}
+	return userContext, nil
+}
+
+// relay copies messages both ways until the backend ends the call, and
+// returns the backend's status. The client's end of input half-closes the
+// upstream; a client that goes away cancels it.
+func relay(client grpc.ServerStream, upstream grpc.ClientStream, cancel context.CancelFunc) error {
+	go func() {
+		for {
+			frame := &rawFrame{}
+			if err := client.RecvMsg(frame); err != nil {
+				if err == io.EOF {
+					upstream.CloseSend()
+				} else {
+					cancel()
+				}
+				return
+			}
+			if err := upstream.SendMsg(frame); err != nil {
+				// The backend ended the call; RecvMsg below returns its status
+				return
+			}
+		}
+	}()
+
+	for first := true; ; first = false {
+		frame := &rawFrame{}
+		err := upstream.RecvMsg(frame)
+		if first {
+			// Headers arrive with the first message, or with the status of calls without any
+			if header, headerErr := upstream.Header(); headerErr == nil {
+				client.SendHeader(header)
+			}
+		}
+		if err != nil {
+			client.SetTrailer(upstream.Trailer())
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		if err := client.SendMsg(frame); err != nil {
+			cancel()
+			return err
+		}
+	}
+}
+
+// firstMetadata returns the first value of a metadata key
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,105 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/middleware"
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// serveBufconn serves server on an in-memory listener and returns a client for it
+func serveBufconn(t *testing.T, server *grpc.Server) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// newTestPassthrough proxies hub_investments.OrderService to a backend that
+// answers with the method called and the user it was called for
+func newTestPassthrough(t *testing.T, authRequired bool) *grpc.ClientConn {
+	backend := serveBufconn(t, grpc.NewServer(grpc.UnknownServiceHandler(func(_ any, stream grpc.ServerStream) error {
+		if err := stream.RecvMsg(&structpb.Struct{}); err != nil {
+			return err
+		}
+		method, _ := grpc.MethodFromServerStream(stream)
+		md, _ := metadata.FromIncomingContext(stream.Context())
+		reply, _ := structpb.NewStruct(map[string]any{"method": method, "user": firstMetadata(md, "x-user-id")})
+		return stream.SendMsg(reply)
+	})))
+
+	registry := NewServiceRegistry(&config.Config{})
+	registry.connections["order-service"] = newConnPool(backend)
+
+	p := NewPassthrough(registry, metrics.NewMetrics(), nil)
+	p.SetRoutes([]router.Route{{Name: "submit-order", Service: "order-service", GRPCService: "OrderService", GRPCMethod: "SubmitOrder"}})
+	p.SetAuthenticator(func(_ context.Context, token string) (*middleware.UserContext, error) {
+		if token != "valid" {
+			return nil, errors.New("invalid token")
+		}
+		return &middleware.UserContext{UserID: "user-1"}, nil
+	}, authRequired)
+	return serveBufconn(t, p.NewServer())
+}
+
+func callPassthrough(conn *grpc.ClientConn, method string, md ...string) (map[string]any, error) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), md...)
+	reply := &structpb.Struct{}
+	err := conn.Invoke(ctx, method, &structpb.Struct{}, reply)
+	return reply.AsMap(), err
+}
+
+func TestPassthrough_ProxiesAuthenticatedCalls(t *testing.T) {
+	gateway := newTestPassthrough(t, true)
+
+	reply, err := callPassthrough(gateway, "/hub_investments.OrderService/GetOrder", "authorization", "Bearer valid")
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if reply["method"] != "/hub_investments.OrderService/GetOrder" || reply["user"] != "user-1" {
+		t.Errorf("backend saw %v, want the call for user-1", reply)
+	}
+
+	if _, err := callPassthrough(gateway, "/hub_investments.OrderService/GetOrder"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("call without token error = %v, want Unauthenticated", err)
+	}
+	if _, err := callPassthrough(gateway, "/hub_investments.OrderService/GetOrder", "authorization", "Bearer forged"); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("call with invalid token error = %v, want Unauthenticated", err)
+	}
+	if _, err := callPassthrough(gateway, "/hub_investments.AdminService/Wipe", "authorization", "Bearer valid"); status.Code(err) != codes.Unimplemented {
+		t.Errorf("call to unrouted service error = %v, want Unimplemented", err)
+	}
+}
+
+func TestPassthrough_StripsIdentityMetadata(t *testing.T) {
+	gateway := newTestPassthrough(t, false)
+
+	reply, err := callPassthrough(gateway, "/hub_investments.OrderService/GetOrder", "x-user-id", "admin")
+	if err != nil {
+		t.Fatalf("Invoke() error = %v", err)
+	}
+	if reply["user"] != "" {
+		t.Errorf("backend saw user %v, want the client's x-user-id stripped", reply["user"])
+	}
+}
@@ -0,0 +1,171 @@
+package proxy
+
+import (
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Metadata keys of the priority scheme shared with backends
+const (
+	// PriorityMetadata carries the request's priority class to the backend
+	PriorityMetadata = "x-request-priority"
+
+	// DeprioritizeBelowMetadata is set by an overloaded backend (in response
+	// headers or trailers) to stop receiving requests below a priority class
+	DeprioritizeBelowMetadata = "x-deprioritize-below"
+
+	// DeprioritizeForMetadata says how long DeprioritizeBelowMetadata applies
+	// (Go duration or seconds)
+	DeprioritizeForMetadata = "x-deprioritize-for"
+)
+
+// Bounds for backend deprioritization requests
+const (
+	defaultDeprioritization = 30 * time.Second
+	maxDeprioritization     = 5 * time.Minute
+)
+
+// Priority is a request's QoS class; higher values are served first
+type Priority int
+
+// Priority classes, from the first to be shed to the last
+const (
+	PriorityLow Priority = iota
+	PriorityNormal
+	PriorityHigh
+	PriorityCritical
+)
+
+var priorityNames = map[Priority]string{
+	PriorityLow:      "low",
+	PriorityNormal:   "normal",
+	PriorityHigh:     "high",
+	PriorityCritical: "critical",
+}
+
+// String returns the class name sent in x-request-priority
+func (p Priority) String() string {
+	if name, ok := priorityNames[p]; ok {
+		return name
+	}
+	return priorityNames[PriorityNormal]
+}
+
+// ParsePriority parses a class name
+func ParsePriority(name string) (Priority, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for p, n := range priorityNames {
+		if n == name {
+			return p, true
+		}
+	}
+	return PriorityNormal, false
+}
+
+// RoutePriority returns the priority class of a route from its tier tag
+// (critical, high, normal or low); other routes are normal
+func RoutePriority(route *router.Route) Priority {
+	if p, ok := ParsePriority(route.Tags["tier"]); ok {
+		return p
+	}
+	return PriorityNormal
+}
+
+// Deprioritization is a backend's request to stop receiving traffic below a
+// priority class for a while
+type Deprioritization struct {
+	Service string
+	Below   Priority // Classes below this one are rejected
+	Until   time.Time
+}
+
+// Rejects reports whether requests of class p are held back
+func (d Deprioritization) Rejects(p Priority) bool {
+	return p < d.Below && time.Now().Before(d.Until)
+}
+
+// RetryAfter returns how long until the deprioritization ends, rounded up to
+// whole seconds
+func (d Deprioritization) RetryAfter() time.Duration {
+	remaining := time.Until(d.Until)
+	if remaining < time.Second {
+		return time.Second
+	}
+	return (remaining + time.Second - 1).Truncate(time.Second)
+}
+
+// GetDeprioritization returns the active deprioritization of a service, if any
+func (r *ServiceRegistry) GetDeprioritization(serviceName string) (Deprioritization, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d, ok := r.deprioritized[serviceName]
+	if !ok || !time.Now().Before(d.Until) {
+		return Deprioritization{}, false
+	}
+	return d, true
+}
+
+// applyDeprioritization records a deprioritization requested in a backend's
+// response metadata. Asking to deprioritize below "low" lifts it early.
+func (r *ServiceRegistry) applyDeprioritization(serviceName string, mds ...metadata.MD) {
+	var below, duration string
+	for _, md := range mds {
+		if values := md.Get(DeprioritizeBelowMetadata); len(values) > 0 {
+			below = values[0]
+			if values := md.Get(DeprioritizeForMetadata); len(values) > 0 {
+				duration = values[0]
+			}
+		}
+	}
+	if below == "" {
+		return
+	}
+
+	priority, ok := ParsePriority(below)
+	if !ok {
+		slog.Warn("ignoring invalid deprioritization from backend", "service", serviceName, "below", below)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if priority == PriorityLow {
+		if _, active := r.deprioritized[serviceName]; active {
+			delete(r.deprioritized, serviceName)
+			slog.Info("backend lifted deprioritization", "service", serviceName)
+		}
+		return
+	}
+
+	d := Deprioritization{Service: serviceName, Below: priority, Until: time.Now().Add(deprioritizationFor(duration))}
+	if previous, active := r.deprioritized[serviceName]; !active || previous.Below != d.Below || !previous.Until.After(time.Now()) {
+		slog.Warn("backend requested deprioritization", "service", serviceName, "below", priority.String(),
+			"until", d.Until.Format(time.RFC3339))
+	}
+	r.deprioritized[serviceName] = d
+}
+
+// deprioritizationFor parses a deprioritization duration, bounded to
+// maxDeprioritization so a misbehaving backend can't shed traffic forever
+func deprioritizationFor(value string) time.Duration {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, err := strconv.Atoi(value)
+		if err != nil {
+			return defaultDeprioritization
+		}
+		duration = time.Duration(seconds) * time.Second
+	}
+	if duration <= 0 {
+		return defaultDeprioritization
+	}
+	return min(duration, maxDeprioritization)
+}
@@ -0,0 +1,62 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/grpc/metadata"
+)
+
+func TestRoutePriority(t *testing.T) {
+	tests := []struct {
+		tier string
+		want Priority
+	}{
+		{"critical", PriorityCritical},
+		{"High", PriorityHigh},
+		{"low", PriorityLow},
+		{"", PriorityNormal},
+		{"gold", PriorityNormal},
+	}
+	for _, tt := range tests {
+		route := &router.Route{Name: "get-quote", Tags: map[string]string{"tier": tt.tier}}
+		if got := RoutePriority(route); got != tt.want {
+			t.Errorf("RoutePriority(tier=%q) = %s, want %s", tt.tier, got, tt.want)
+		}
+	}
+}
+
+func TestApplyDeprioritization(t *testing.T) {
+	registry := NewServiceRegistry(&config.Config{})
+
+	registry.applyDeprioritization("order-service", metadata.MD{})
+	if _, ok := registry.GetDeprioritization("order-service"); ok {
+		t.Fatal("responses without deprioritization metadata must not shed traffic")
+	}
+
+	trailer := metadata.Pairs(DeprioritizeBelowMetadata, "high", DeprioritizeForMetadata, "1h")
+	registry.applyDeprioritization("order-service", nil, trailer)
+	shed, ok := registry.GetDeprioritization("order-service")
+	if !ok {
+		t.Fatal("expected order-service to be deprioritized")
+	}
+	if !shed.Rejects(PriorityNormal) || shed.Rejects(PriorityHigh) || shed.Rejects(PriorityCritical) {
+		t.Errorf("deprioritization below high must reject only low and normal requests")
+	}
+	if remaining := time.Until(shed.Until); remaining > maxDeprioritization {
+		t.Errorf("deprioritization lasts %v, want at most %v", remaining, maxDeprioritization)
+	}
+
+	registry.applyDeprioritization("order-service", metadata.Pairs(DeprioritizeBelowMetadata, "low"))
+	if _, ok := registry.GetDeprioritization("order-service"); ok {
+		t.Error("deprioritizing below low must lift the deprioritization")
+	}
+
+	registry.applyDeprioritization("order-service", metadata.Pairs(DeprioritizeBelowMetadata, "urgent"))
+	if _, ok := registry.GetDeprioritization("order-service"); ok {
+		t.Error("unknown priority classes must be ignored")
+	}
+}
@@ -3,34 +3,121 @@ package proxy
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"maps"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"hub-api-gateway/internal/auth"
+	"hub-api-gateway/internal/cache"
+	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/errorlog"
+	"hub-api-gateway/internal/features"
+	"hub-api-gateway/internal/httperr"
+	"hub-api-gateway/internal/logging"
 	"hub-api-gateway/internal/metrics"
 	"hub-api-gateway/internal/middleware"
 	"hub-api-gateway/internal/router"
+	"hub-api-gateway/internal/stream"
+	"hub-api-gateway/internal/trace"
 
-	monolithpb "github.com/RodriguesYan/hub-proto-contracts/monolith"
+	"github.com/gorilla/websocket"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 )
 
+// TenantMetadata carries the request's tenant to the backend
+const TenantMetadata = "x-tenant-id"
+
 // ProxyHandler handles HTTP requests and proxies them to gRPC services
 type ProxyHandler struct {
-	registry *ServiceRegistry
-	metrics  *metrics.Metrics
+	registry  *ServiceRegistry
+	metrics   *metrics.Metrics
+	errors    *errorlog.Buffer
+	snapshots *SnapshotStore
+
+	// GET responses of routes with a cache policy (nil disables caching)
+	responseCache cache.Store
+
+	// Signs the caller's identity for backends (nil forwards Authorization)
+	internalTokens *auth.InternalTokenIssuer
+
+	// Method descriptors used to build request/response messages per route
+	descriptors *DescriptorRegistry
+
+	// Fake response generator for unreachable backends (dev mode only)
+	fakes *FakeGenerator
+
+	// Serve routes' mock responses instead of calling backends
+	mockMode bool
+
+	// Observer notified of failed backend calls (e.g. extension hooks)
+	onBackendError func(ctx context.Context, route *router.Route, err error)
+
+	longPollMaxWait  time.Duration
+	longPollInterval time.Duration
+
+	// Per-client buffering of streaming routes
+	streamBufferSize int
+	streamPolicy     stream.Policy
+	streamHeartbeat  time.Duration
+
+	// Keep-alive, limits and allowed origins of WebSocket routes
+	webSocket WebSocketOptions
+
+	// HTTP status and error code per gRPC code (defaults unless overridden)
+	grpcErrors map[codes.Code]grpcErrorMapping
+
+	// Retries of idempotent routes on transient errors (nil disables)
+	retryPolicy  *RetryPolicy
+	retryBudgets sync.Map // service -> *retryBudget
+
+	// Recent latencies of hedged routes, whose p95 triggers the hedge
+	hedgeLatency sync.Map // route -> *latencyTracker
+
+	// Mirrored calls in flight, bounded by the channel's capacity
+	mirrorSlots chan struct{}
+
+	// Delays and errors injected into backend calls (nil disables)
+	faults *FaultInjector
 }
 
 // NewProxyHandler creates a new proxy handler
-func NewProxyHandler(registry *ServiceRegistry, m *metrics.Metrics) *ProxyHandler {
+func NewProxyHandler(registry *ServiceRegistry, m *metrics.Metrics, errors *errorlog.Buffer) *ProxyHandler {
 	return &ProxyHandler{
-		registry: registry,
-		metrics:  m,
+		registry:    registry,
+		metrics:     m,
+		errors:      errors,
+		descriptors: NewDescriptorRegistry(),
+		mirrorSlots: make(chan struct{}, defaultMirrorLimit),
+	}
+}
+
+// SetDescriptors replaces the descriptor registry (e.g. one with descriptor sets loaded)
+func (h *ProxyHandler) SetDescriptors(descriptors *DescriptorRegistry) {
+	h.descriptors = descriptors
+}
+
+// OnBackendError registers an observer for failed backend calls
+func (h *ProxyHandler) OnBackendError(observer func(ctx context.Context, route *router.Route, err error)) {
+	h.onBackendError = observer
+}
+
+// EnableMaintenanceSnapshots keeps the last capacity successful GET responses so
+// draining services can keep serving reads
+func (h *ProxyHandler) EnableMaintenanceSnapshots(capacity int) {
+	if capacity > 0 {
+		h.snapshots = NewSnapshotStore(capacity)
 	}
 }
 
@@ -38,226 +125,571 @@ func NewProxyHandler(registry *ServiceRegistry, m *metrics.Metrics) *ProxyHandle
 func (h *ProxyHandler) HandleRequest(w http.ResponseWriter, r *http.Request, route *router.Route) {
 	startTime := time.Now()
 
-	log.Printf("📨 Proxying request: %s %s -> %s.%s",
-		r.Method, r.URL.Path, route.GRPCService, route.GRPCMethod)
+	slog.InfoContext(r.Context(), "proxying request", "method", r.Method, "path", r.URL.Path,
+		"grpc_method", route.GRPCService+"."+route.GRPCMethod)
 
 	// Extract path variables
-	pathVars := route.ExtractPathVariables(r.URL.Path)
+	pathVars, ok := router.PathVarsFromContext(r.Context())
+	if !ok {
+		pathVars = route.ExtractPathVariables(r.URL.Path)
+	}
+
+	// strip_prefix and rewrite decide the path the backend sees; named
+	// rewrite groups bind like path variables
+	upstreamPath, rewriteVars := route.RewritePath(r.URL.Path)
+	if len(rewriteVars) > 0 {
+		merged := make(map[string]string, len(pathVars)+len(rewriteVars))
+		maps.Copy(merged, pathVars)
+		maps.Copy(merged, rewriteVars)
+		pathVars = merged
+	}
 
 	// Get user context from middleware (if authenticated)
 	userContext, _ := middleware.GetUserContext(r.Context())
 
-	// Get circuit breaker for the service
-	serviceName := route.GetTargetService()
-	circuitBreaker := h.registry.GetCircuitBreaker(serviceName)
+	// Tenants see their own overrides of the route
+	tenant := middleware.TenantFromContext(r.Context())
+	if tenant != "" {
+		route = route.ForTenant(tenant)
+	}
 
-	// Get gRPC connection with circuit breaker protection
-	var conn *grpc.ClientConn
-	if err := circuitBreaker.Call(func() error {
-		var err error
-		conn, err = h.registry.GetConnection(serviceName)
-		if err != nil {
-			log.Printf("❌ Failed to get connection to %s: %v", serviceName, err)
-			return err
+	// Routes behind a feature flag serve the variant of the user's flag value
+	if route.FeatureFlag != nil {
+		route = route.ForVariant(flagValue(r, route))
+	}
+
+	// Routes split between upstreams continue as the chosen upstream's route;
+	// users stick to one upstream
+	if len(route.Upstreams) > 0 {
+		stickyKey := ""
+		if userContext != nil {
+			stickyKey = userContext.UserID
 		}
-		return nil
-	}); err != nil {
-		if err == ErrCircuitOpen {
-			log.Printf("⚠️  Circuit breaker OPEN for %s", serviceName)
-			h.metrics.RecordCircuitBreakerTrip()
-			h.metrics.RecordRequest(route.Name, serviceName, time.Since(startTime), false)
-			h.sendError(w, http.StatusServiceUnavailable, "CIRCUIT_BREAKER_OPEN",
-				fmt.Sprintf("Service %s is temporarily unavailable (circuit breaker open)", serviceName))
+		route = route.SelectUpstream(r, stickyKey)
+		trace.FromContext(r.Context()).Record(metrics.StageRouting, "selected upstream", 0, map[string]string{"service": route.Service})
+	}
+
+	// Tenants with their own deployment of the service are sent there
+	if tenant != "" && h.registry.HasService(config.TenantServiceName(route.Service, tenant)) {
+		dedicated := *route
+		dedicated.Service = config.TenantServiceName(route.Service, tenant)
+		route = &dedicated
+	}
+
+	// Route header rules see the caller's identity and the path variables
+	vars := headerVars(r, route, pathVars, userContext)
+	w = withResponseHeaders(w, route.ResponseHeaders, vars)
+
+	if h.mockMode && route.Mock != nil {
+		h.serveMock(w, r, route)
+		return
+	}
+
+	// Aggregate routes fan out to their branches instead of one backend
+	if route.IsAggregate() {
+		h.handleAggregate(w, r, route, upstreamPath, pathVars, userContext, vars)
+		return
+	}
+
+	serviceName := route.GetTargetService()
+	requestTrace := trace.FromContext(r.Context())
+
+	// Cached reads don't reach the backend, even while it is drained
+	if h.serveCached(w, r, route, userContext) {
+		slog.InfoContext(r.Context(), "served cached response", "method", r.Method, "path", r.URL.Path)
+		h.metrics.RecordRequest(route.Name, serviceName, time.Since(startTime), true)
+		return
+	}
+
+	// Draining services only receive critical-tier traffic
+	if drain, ok := h.registry.GetDrainState(serviceName); ok && !route.HasTag("tier", "critical") {
+		requestTrace.Record(metrics.StageBackend, "service draining", 0, map[string]string{"service": serviceName})
+		h.handleDraining(w, r, route, userContext, drain)
+		h.metrics.RecordRequest(route.Name, serviceName, time.Since(startTime), false)
+		return
+	}
+
+	// Hold back lower classes while the backend asks to be deprioritized
+	priority := RoutePriority(route)
+	if shed, ok := h.registry.GetDeprioritization(serviceName); ok && shed.Rejects(priority) {
+		requestTrace.Record(metrics.StageBackend, "deprioritized", 0, map[string]string{
+			"service":  serviceName,
+			"priority": priority.String(),
+			"below":    shed.Below.String(),
+		})
+		h.metrics.RecordRequest(route.Name, serviceName, time.Since(startTime), false)
+		if h.serveStale(w, r, route, userContext, staleDeprioritized) {
 			return
 		}
-		h.metrics.RecordRequest(route.Name, serviceName, time.Since(startTime), false)
-		h.sendError(w, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE",
-			fmt.Sprintf("Service %s is unavailable", serviceName))
+		h.failWithRetryAfter(w, r, route, http.StatusServiceUnavailable, "DEPRIORITIZED",
+			fmt.Sprintf("Service %s is only accepting %s priority requests", serviceName, shed.Below.String()),
+			shed.RetryAfter())
+		return
+	}
+
+	// Streaming routes relay a server-streaming RPC; HEAD only gets the headers
+	webSocket := route.Stream == router.StreamWebSocket
+	streaming := route.Stream != "" && !webSocket
+	if streaming && r.Method == http.MethodHead {
+		w.Header().Set("Content-Type", streamContentTypes[streamFormat(r, route)])
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// WebSocket routes bridge a bidirectional stream and only accept upgrades
+	if webSocket && !websocket.IsWebSocketUpgrade(r) {
+		w.Header().Set("Upgrade", "websocket")
+		h.fail(w, r, route, http.StatusUpgradeRequired, "UPGRADE_REQUIRED", "This route only accepts WebSocket connections")
 		return
 	}
 
 	// Read request body
+	bindingStart := time.Now()
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		log.Printf("❌ Failed to read request body: %v", err)
-		h.sendError(w, http.StatusBadRequest, "INVALID_REQUEST", "Failed to read request body")
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			slog.WarnContext(r.Context(), "request body too large", "route", route.Name, "limit", tooLarge.Limit)
+			h.fail(w, r, route, http.StatusRequestEntityTooLarge, "PAYLOAD_TOO_LARGE",
+				fmt.Sprintf("Request body exceeds %d bytes", tooLarge.Limit))
+			return
+		}
+		slog.WarnContext(r.Context(), "failed to read request body", "error", err)
+		h.fail(w, r, route, http.StatusBadRequest, "INVALID_REQUEST", "Failed to read request body")
 		return
 	}
 	defer r.Body.Close()
 
-	// Create gRPC context with metadata
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Create gRPC context with metadata. Long-polls get the client's wait on
+	// top of the upstream timeout for the final backend call, streams run
+	// until either side ends them.
+	poll, longPolling := h.parseLongPoll(r, route)
+	timeout := h.upstreamTimeout(route, serviceName)
+	if longPolling {
+		timeout += poll.wait
+	}
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if streaming || webSocket {
+		ctx, cancel = context.WithCancel(r.Context())
+	} else {
+		ctx, cancel = upstreamContext(r, timeout)
+	}
 	defer cancel()
+	ctx = logging.WithRequestID(ctx, logging.RequestID(r.Context()))
 
 	// Add metadata to gRPC context
-	md := metadata.New(map[string]string{
-		"x-forwarded-method": r.Method,
-		"x-forwarded-path":   r.URL.Path,
-		"x-original-uri":     r.RequestURI,
-	})
+	md, err := h.outgoingMetadata(r, route, upstreamPath, priority, userContext, pathVars, vars)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to issue internal token", "route", route.Name, "error", err)
+		h.fail(w, r, route, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to authorize backend call")
+		return
+	}
+	ctx = metadata.NewOutgoingContext(h.withFault(ctx, route), md)
 
-	// Forward Authorization header to gRPC metadata
-	if authHeader := r.Header.Get("Authorization"); authHeader != "" {
-		md.Set("authorization", authHeader)
+	// Resolve the gRPC method and build its messages from the descriptors
+	method, err := h.descriptors.FindMethod(route)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to resolve gRPC method", "route", route.Name, "error", err)
+		if route.Mock != nil {
+			// Mocked routes whose RPC doesn't exist yet outside mock mode
+			h.fail(w, r, route, http.StatusNotImplemented, "NOT_IMPLEMENTED", "This endpoint is not implemented yet")
+			return
+		}
+		h.fail(w, r, route, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		return
 	}
+	fullMethod := fullMethodName(method)
+	newResponse := func() proto.Message { return newMessage(method.Output()) }
 
-	// Add user context if authenticated
+	var userID string
 	if userContext != nil {
-		md.Set("x-user-id", userContext.UserID)
-		md.Set("x-user-email", userContext.Email)
+		userID = userContext.UserID
+	}
+	createMessages := func() (proto.Message, proto.Message, error) {
+		return bindRequest(method, route, body, pathVars, userID)
 	}
 
-	// Add path variables to metadata
-	for key, value := range pathVars {
-		md.Set(fmt.Sprintf("x-path-%s", key), value)
+	request, response, err := createMessages()
+	if err != nil {
+		slog.WarnContext(r.Context(), "failed to bind request", "grpc_method", fullMethod, "error", err)
+		requestTrace.Record(metrics.StageBinding, "request binding failed", time.Since(bindingStart), map[string]string{"error": err.Error()})
+		if violations := validateBody(method.Input(), body); len(body) > 0 && len(violations) > 0 {
+			h.failValidation(w, r, route, violations)
+			return
+		}
+		h.fail(w, r, route, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if violations := missingFields(request, route.RequiredFields); len(violations) > 0 {
+		requestTrace.Record(metrics.StageBinding, "required fields missing", time.Since(bindingStart), nil)
+		h.failValidation(w, r, route, violations)
+		return
 	}
+	h.metrics.RecordStage(metrics.StageBinding, time.Since(bindingStart))
+	requestTrace.Record(metrics.StageBinding, "bound request", time.Since(bindingStart), map[string]string{
+		"grpcMethod": fullMethod,
+		"bodyBytes":  strconv.Itoa(len(body)),
+	})
+
+	// The breaker guards the whole upstream call, so failing invocations
+	// count against it and not just failing dials
+	circuitBreaker := h.registry.RouteCircuitBreaker(route)
+	longPollResult := ""
+	var responseHeader, responseTrailer metadata.MD
+	var upstream grpc.ClientStream
+	var first proto.Message
+	err = callWithBreaker(circuitBreaker, func() error {
+		conn, err := h.registry.GetConnection(serviceName)
+		if err != nil {
+			slog.ErrorContext(r.Context(), "failed to get service connection", "service", serviceName, "error", err)
+			return fmt.Errorf("%w: %w", errNoConnection, err)
+		}
+
+		// Refuse backends running a contract older than the configured minimum
+		if err := h.registry.CheckVersion(r.Context(), serviceName, conn); err != nil {
+			return err
+		}
+
+		// Skip backends failing health probes or ejected as outliers
+		if err := h.registry.CheckAvailable(serviceName); err != nil {
+			return err
+		}
+
+		if streaming {
+			upstream, first, err = openStream(ctx, conn, fullMethod, request, newResponse)
+			return err
+		}
+		if webSocket {
+			upstream, err = conn.NewStream(ctx, bidiStreamDesc, fullMethod)
+			return err
+		}
+
+		// Long-poll requests hold the connection until the watched field changes
+		if longPolling {
+			pollCtx, stop := context.WithCancel(ctx)
+			defer stop()
+
+			pollStart := time.Now()
+			polled, changed, err := h.longPoll(pollCtx, conn, fullMethod, route, poll, createMessages)
+			if err == nil {
+				response = polled
+				longPollResult = longPollTimeout
+				if changed {
+					longPollResult = longPollChanged
+				}
+			}
+			requestTrace.Record(metrics.StageBackend, "long poll finished", time.Since(pollStart), map[string]string{
+				"service": serviceName,
+				"result":  longPollResult,
+				"code":    status.Code(err).String(),
+			})
+			return err
+		}
 
-	ctx = metadata.NewOutgoingContext(ctx, md)
+		// Long-polls are excluded: their duration is dominated by the client's wait
+		backendStart := time.Now()
+		if hedged(r, route) {
+			err = h.invokeHedged(ctx, r, route, serviceName, conn, fullMethod, request, response, &responseHeader, &responseTrailer)
+		} else {
+			err = h.invoke(ctx, r, serviceName, conn, fullMethod, request, response,
+				grpc.Header(&responseHeader), grpc.Trailer(&responseTrailer))
+		}
+		h.registry.applyDeprioritization(serviceName, responseHeader, responseTrailer)
+		h.metrics.RecordStage(metrics.StageBackend, time.Since(backendStart))
+		requestTrace.Record(metrics.StageBackend, "backend call", time.Since(backendStart), map[string]string{
+			"service":    serviceName,
+			"grpcMethod": fullMethod,
+			"code":       status.Code(err).String(),
+		})
+		return err
+	})
+	h.registry.RecordOutcome(serviceName, err)
 
-	// Invoke gRPC method with proper protobuf messages
-	grpcService, grpcMethod := route.GetGRPCTarget()
-	// Use the full proto package name for the service
-	fullMethod := fmt.Sprintf("/hub_investments.%s/%s", grpcService, grpcMethod)
+	// Shadow traffic: a copy of sampled unary calls goes to the mirror service
+	if route.Mirror != nil && !streaming && !webSocket && !longPolling {
+		h.mirror(r, route, userContext, fullMethod, md, request, response, err, newResponse)
+	}
 
-	// Create proper protobuf request and response messages
-	request, response, err := h.createProtoMessages(grpcService, grpcMethod, body, pathVars, userContext)
 	if err != nil {
-		log.Printf("❌ Failed to create proto messages: %v", err)
-		h.sendError(w, http.StatusInternalServerError, "INTERNAL_ERROR", err.Error())
+		if h.clientAborted(r, route) {
+			h.fail(w, r, route, statusClientClosedRequest, "REQUEST_CANCELLED", "Client closed the request")
+			return
+		}
+		h.backendError(r, route, err)
+
+		switch {
+		case errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrTooManyRequests):
+			requestTrace.Record(metrics.StageBackend, "circuit breaker open", 0, map[string]string{
+				"service": serviceName,
+				"breaker": circuitBreaker.GetState().String(),
+			})
+			slog.WarnContext(r.Context(), "circuit breaker rejected request", "service", serviceName, "state", circuitBreaker.GetState().String())
+			h.metrics.RecordCircuitBreakerTrip()
+			h.metrics.RecordRequest(route.Name, serviceName, time.Since(startTime), false)
+			if h.serveStale(w, r, route, userContext, staleCircuitOpen) {
+				return
+			}
+			h.failWithRetryAfter(w, r, route, http.StatusServiceUnavailable, "CIRCUIT_BREAKER_OPEN",
+				fmt.Sprintf("Service %s is temporarily unavailable (circuit breaker open)", serviceName),
+				circuitBreaker.RetryAfter())
+			return
+		case errors.Is(err, errNoConnection):
+			requestTrace.Record(metrics.StageBackend, "connection refused", 0, map[string]string{
+				"service": serviceName,
+				"error":   err.Error(),
+			})
+			h.metrics.RecordRequest(route.Name, serviceName, time.Since(startTime), false)
+			if h.serveStale(w, r, route, userContext, staleBackendUnavailable) {
+				return
+			}
+			h.fail(w, r, route, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE",
+				fmt.Sprintf("Service %s is unavailable", serviceName))
+			return
+		case errors.Is(err, ErrBackendEjected):
+			requestTrace.Record(metrics.StageBackend, "backend ejected", 0, map[string]string{
+				"service": serviceName,
+				"error":   err.Error(),
+			})
+			h.metrics.RecordRequest(route.Name, serviceName, time.Since(startTime), false)
+			if h.serveStale(w, r, route, userContext, staleBackendUnavailable) {
+				return
+			}
+			h.failWithRetryAfter(w, r, route, http.StatusServiceUnavailable, "SERVICE_UNAVAILABLE",
+				fmt.Sprintf("Service %s is unavailable", serviceName), h.registry.EjectionRetryAfter(serviceName))
+			return
+		case errors.Is(err, ErrIncompatibleBackend):
+			requestTrace.Record(metrics.StageBackend, "incompatible backend", 0, map[string]string{
+				"service": serviceName,
+				"error":   err.Error(),
+			})
+			h.metrics.RecordRequest(route.Name, serviceName, time.Since(startTime), false)
+			h.fail(w, r, route, http.StatusServiceUnavailable, "BACKEND_INCOMPATIBLE",
+				fmt.Sprintf("Service %s is running an incompatible version", serviceName))
+			return
+		}
+
+		// Keep read paths answering from the last good response while the backend is down
+		if staleOnGRPCError(err) && h.serveStale(w, r, route, userContext, staleBackendUnavailable) {
+			slog.ErrorContext(r.Context(), "gRPC call failed", "grpc_method", fullMethod, "error", err)
+			return
+		}
+		if h.fakes != nil && status.Code(err) == codes.Unavailable {
+			requestTrace.Record(metrics.StageMarshal, "served fake response", 0, nil)
+			h.serveFake(w, r, route, method)
+			return
+		}
+		slog.ErrorContext(r.Context(), "gRPC call failed", "grpc_method", fullMethod, "error", err)
+		h.handleGRPCError(w, r, route, err)
 		return
 	}
 
-	err = conn.Invoke(ctx, fullMethod, request, response)
+	if streaming {
+		streamStart := time.Now()
+		err := h.serveStream(w, r, route, streamFormat(r, route), upstream, first, newResponse)
+		requestTrace.Record(metrics.StageBackend, "stream ended", time.Since(streamStart), map[string]string{
+			"service":    serviceName,
+			"grpcMethod": fullMethod,
+			"code":       status.Code(err).String(),
+		})
+		slog.InfoContext(r.Context(), "stream ended", "path", r.URL.Path, "duration_ms", time.Since(startTime).Milliseconds(), "error", err)
+		h.metrics.RecordRequest(route.Name, serviceName, time.Since(startTime), err == nil)
+		return
+	}
 
-	if err != nil {
-		log.Printf("❌ gRPC call failed for %s: %v", fullMethod, err)
-		h.handleGRPCError(w, err)
+	if webSocket {
+		// Client messages are bound like request bodies, with path variables and user ID
+		bind := func(frame []byte) (proto.Message, error) {
+			request, _, err := bindRequest(method, route, frame, pathVars, userID)
+			return request, err
+		}
+		sessionStart := time.Now()
+		err := h.serveWebSocket(w, r, route, upstream, cancel, bind, newResponse)
+		requestTrace.Record(metrics.StageBackend, "websocket closed", time.Since(sessionStart), map[string]string{
+			"service":    serviceName,
+			"grpcMethod": fullMethod,
+			"code":       status.Code(err).String(),
+		})
+		slog.InfoContext(r.Context(), "websocket closed", "path", r.URL.Path, "duration_ms", time.Since(startTime).Milliseconds(), "error", err)
+		h.metrics.RecordRequest(route.Name, serviceName, time.Since(startTime), err == nil)
 		return
 	}
 
 	// Send success response
 	elapsed := time.Since(startTime)
-	log.Printf("✅ Request completed in %v: %s %s", elapsed, r.Method, r.URL.Path)
+	slog.InfoContext(r.Context(), "request completed", "method", r.Method, "path", r.URL.Path, "duration_ms", elapsed.Milliseconds())
 
 	// Record successful request metrics
 	h.metrics.RecordRequest(route.Name, serviceName, elapsed, true)
 
-	// Convert proto response to JSON
-	h.sendProtoJSON(w, http.StatusOK, response)
-}
+	if longPollResult != "" {
+		w.Header().Set("X-Long-Poll", longPollResult)
+	}
 
-// createProtoMessages creates the appropriate protobuf request and response messages
-func (h *ProxyHandler) createProtoMessages(service, method string, body []byte, pathVars map[string]string, userContext *middleware.UserContext) (proto.Message, proto.Message, error) {
-	// Map service.method to proto message types
-	switch fmt.Sprintf("%s.%s", service, method) {
-	case "BalanceService.GetBalance":
-		req := &monolithpb.GetBalanceRequest{}
-		if userContext != nil {
-			req.UserId = userContext.UserID
+	// Let polling clients revalidate against the backend's timestamp instead of refetching
+	if modified := lastModified(responseHeader); !modified.IsZero() && isRead(r) {
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		if notModified(r, modified) {
+			requestTrace.Record(metrics.StageMarshal, "not modified", 0, nil)
+			w.WriteHeader(http.StatusNotModified)
+			return
 		}
-		return req, &monolithpb.GetBalanceResponse{}, nil
+	}
 
-	case "PortfolioService.GetPortfolioSummary":
-		req := &monolithpb.GetPortfolioSummaryRequest{}
-		if userContext != nil {
-			req.UserId = userContext.UserID
+	// Convert proto response to JSON
+	marshalStart := time.Now()
+	var written []byte
+	if h.cacheable(r, route) {
+		if encoded, err := h.encodeJSON(response, route); err != nil {
+			slog.ErrorContext(r.Context(), "failed to marshal proto to JSON", "error", err)
+			h.fail(w, r, route, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to encode response")
+		} else {
+			written = h.sendCacheable(w, r, route, userContext, encoded)
 		}
-		return req, &monolithpb.GetPortfolioSummaryResponse{}, nil
+	} else {
+		written = h.sendProtoJSON(w, r, http.StatusOK, response, route)
+	}
+	h.metrics.RecordStage(metrics.StageMarshal, time.Since(marshalStart))
+	requestTrace.Record(metrics.StageMarshal, "encoded response", time.Since(marshalStart), map[string]string{
+		"responseBytes": strconv.Itoa(len(written)),
+		"stringFields":  strings.Join(route.StringFields, ","),
+	})
 
-	case "OrderService.SubmitOrder":
-		req := &monolithpb.SubmitOrderRequest{}
-		if len(body) > 0 {
-			if err := protojson.Unmarshal(body, req); err != nil {
-				return nil, nil, fmt.Errorf("failed to unmarshal SubmitOrder request: %w", err)
-			}
-		}
-		if userContext != nil {
-			req.UserId = userContext.UserID
-		}
-		return req, &monolithpb.SubmitOrderResponse{}, nil
+	// Remember read responses so they can be served while the backend is drained or down
+	if h.snapshots != nil && written != nil && isRead(r) {
+		h.snapshots.Store(snapshotKey(r, route, userContext), written)
+	}
+}
 
-	case "OrderService.GetOrderDetails":
-		req := &monolithpb.GetOrderDetailsRequest{}
-		if orderId, ok := pathVars["id"]; ok {
-			req.OrderId = orderId
-		}
-		if userContext != nil {
-			req.UserId = userContext.UserID
-		}
-		return req, &monolithpb.GetOrderDetailsResponse{}, nil
+// outgoingMetadata builds the gRPC metadata of a backend call: the forwarded
+// request, the caller's identity, feature flags, path variables and the
+// route's request header rules
+func (h *ProxyHandler) outgoingMetadata(r *http.Request, route *router.Route, upstreamPath string, priority Priority, userContext *middleware.UserContext, pathVars map[string]string, vars router.HeaderVars) (metadata.MD, error) {
+	md := metadata.New(map[string]string{
+		"x-forwarded-method": r.Method,
+		"x-forwarded-path":   upstreamPath,
+		"x-original-uri":     r.RequestURI,
+		PriorityMetadata:     priority.String(),
+	})
 
-	case "OrderService.GetOrderStatus":
-		req := &monolithpb.GetOrderStatusRequest{}
-		if orderId, ok := pathVars["id"]; ok {
-			req.OrderId = orderId
-		}
-		return req, &monolithpb.GetOrderStatusResponse{}, nil
+	// Forward Authorization header to gRPC metadata
+	if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+		md.Set("authorization", authHeader)
+	}
 
-	case "OrderService.CancelOrder":
-		req := &monolithpb.CancelOrderRequest{}
-		if orderId, ok := pathVars["id"]; ok {
-			req.OrderId = orderId
-		}
-		if userContext != nil {
-			req.UserId = userContext.UserID
-		}
-		return req, &monolithpb.CancelOrderResponse{}, nil
+	// Add user context if authenticated
+	if userContext != nil {
+		md.Set("x-user-id", userContext.UserID)
+		md.Set("x-user-email", userContext.Email)
+	}
+	if tenant := middleware.TenantFromContext(r.Context()); tenant != "" {
+		md.Set(TenantMetadata, tenant)
+	}
 
-	case "OrderService.GetOrderHistory":
-		req := &monolithpb.GetOrderHistoryRequest{}
-		if userContext != nil {
-			req.UserId = userContext.UserID
-		}
-		return req, &monolithpb.GetOrderHistoryResponse{}, nil
+	// Add per-user feature flags evaluated by the gateway
+	if flags, ok := features.FromContext(r.Context()); ok {
+		md.Set("x-feature-flags", features.Encode(flags))
+	}
 
-	case "PositionService.GetPositions":
-		req := &monolithpb.GetPositionsRequest{}
-		if userContext != nil {
-			req.UserId = userContext.UserID
+	// Add path variables to metadata
+	for key, value := range pathVars {
+		md.Set(fmt.Sprintf("x-path-%s", key), value)
+	}
+	applyRequestHeaders(md, route.RequestHeaders, vars)
+
+	// Backends trust the gateway's signature rather than the client's token;
+	// minted last so no header rule can put a client credential back
+	if h.internalTokens != nil {
+		err := setInternalToken(md, h.internalTokens, userContext, auth.BackendCall{
+			Service:   route.GetTargetService(),
+			Route:     route.Name,
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			RequestID: logging.RequestID(r.Context()),
+		})
+		if err != nil {
+			return nil, err
 		}
-		return req, &monolithpb.GetPositionsResponse{}, nil
+	}
+	return md, nil
+}
 
-	case "PositionService.GetPositionAggregation":
-		req := &monolithpb.GetPositionAggregationRequest{}
-		if userContext != nil {
-			req.UserId = userContext.UserID
-		}
-		return req, &monolithpb.GetPositionAggregationResponse{}, nil
+// backendError notifies the backend error observer, if any
+func (h *ProxyHandler) backendError(r *http.Request, route *router.Route, err error) {
+	if h.onBackendError != nil {
+		h.onBackendError(r.Context(), route, err)
+	}
+}
 
-	case "MarketDataService.GetMarketData":
-		req := &monolithpb.GetMarketDataRequest{}
-		if symbol, ok := pathVars["symbol"]; ok {
-			req.Symbol = symbol
+// handleDraining answers a request for a draining service: reads get the
+// last known response when available, everything else a 503 maintenance error
+func (h *ProxyHandler) handleDraining(w http.ResponseWriter, r *http.Request, route *router.Route, userContext *middleware.UserContext, drain DrainState) {
+	if isRead(r) && drain.ServeCached && h.snapshots != nil {
+		if body, storedAt, ok := h.snapshots.Load(snapshotKey(r, route, userContext)); ok {
+			slog.InfoContext(r.Context(), "serving maintenance snapshot", "path", r.URL.Path, "age", time.Since(storedAt).Round(time.Second).String())
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Warning", `110 - "Response is Stale"`)
+			w.Header().Set("X-Gateway-Maintenance", "cached")
+			w.Header().Set("Age", fmt.Sprintf("%d", int(time.Since(storedAt).Seconds())))
+			w.WriteHeader(http.StatusOK)
+			w.Write(body)
+			return
 		}
-		return req, &monolithpb.GetMarketDataResponse{}, nil
+	}
 
-	case "MarketDataService.GetAssetDetails":
-		req := &monolithpb.GetAssetDetailsRequest{}
-		if symbol, ok := pathVars["symbol"]; ok {
-			req.Symbol = symbol
-		}
-		return req, &monolithpb.GetAssetDetailsResponse{}, nil
+	message := drain.Message
+	if message == "" {
+		message = fmt.Sprintf("Service %s is undergoing maintenance", drain.Service)
+	}
+	h.failWithRetryAfter(w, r, route, http.StatusServiceUnavailable, "SERVICE_MAINTENANCE", message,
+		time.Duration(drain.RetryAfter)*time.Second)
+}
 
-	case "MarketDataService.GetBatchMarketData":
-		req := &monolithpb.GetBatchMarketDataRequest{}
-		if len(body) > 0 {
-			if err := protojson.Unmarshal(body, req); err != nil {
-				return nil, nil, fmt.Errorf("failed to unmarshal GetBatchMarketData request: %w", err)
-			}
-		}
-		return req, &monolithpb.GetBatchMarketDataResponse{}, nil
+// flagValue returns the value of the route's feature flag for the request,
+// "" for routes without one
+func flagValue(r *http.Request, route *router.Route) string {
+	if route.FeatureFlag == nil {
+		return ""
+	}
+	flags, _ := features.FromContext(r.Context())
+	return flags[route.FeatureFlag.Name]
+}
 
-	default:
-		return nil, nil, fmt.Errorf("unsupported service method: %s.%s", service, method)
+// snapshotKey identifies a GET response per route, URI, tenant and user
+func snapshotKey(r *http.Request, route *router.Route, userContext *middleware.UserContext) string {
+	userID := ""
+	if userContext != nil {
+		userID = userContext.UserID
 	}
+	return route.Name + "|" + r.URL.RequestURI() + "|" + middleware.TenantFromContext(r.Context()) + "|" + userID
+}
+
+// sendProtoJSON sends a protobuf message as JSON and returns the body written.
+// Fields listed in the route's string_fields are emitted as JSON strings.
+func (h *ProxyHandler) sendProtoJSON(w http.ResponseWriter, r *http.Request, statusCode int, msg proto.Message, route *router.Route) []byte {
+	unwrappedJSON, err := h.encodeJSON(msg, route)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "failed to marshal proto to JSON", "error", err)
+		h.fail(w, r, route, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to encode response")
+		return nil
+	}
+
+	writeJSONBody(w, statusCode, unwrappedJSON)
+	return unwrappedJSON
 }
 
-// sendProtoJSON sends a protobuf message as JSON
-func (h *ProxyHandler) sendProtoJSON(w http.ResponseWriter, statusCode int, msg proto.Message) {
+// writeJSONBody sends an encoded JSON body. For HEAD, net/http discards the
+// body but keeps the headers, so clients still see the length of the
+// response a GET would return.
+func writeJSONBody(w http.ResponseWriter, statusCode int, body []byte) {
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
 	w.WriteHeader(statusCode)
+	w.Write(body)
+}
 
-	// Convert proto message to JSON
+// encodeJSON converts a backend response to the JSON sent to clients
+func (h *ProxyHandler) encodeJSON(msg proto.Message, route *router.Route) ([]byte, error) {
 	marshaler := protojson.MarshalOptions{
 		UseProtoNames:   true,
 		EmitUnpopulated: true,
@@ -265,20 +697,21 @@ func (h *ProxyHandler) sendProtoJSON(w http.ResponseWriter, statusCode int, msg
 
 	jsonBytes, err := marshaler.Marshal(msg)
 	if err != nil {
-		log.Printf("❌ Failed to marshal proto to JSON: %v", err)
-		h.sendError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to encode response")
-		return
+		return nil, err
 	}
 
 	// Unwrap api_response wrapper for cleaner API responses
 	unwrappedJSON := h.unwrapAPIResponse(jsonBytes)
-	w.Write(unwrappedJSON)
+
+	// Emit monetary/decimal fields as strings to avoid float precision loss in clients
+	return stringifyFields(unwrappedJSON, route.StringFields), nil
 }
 
 // unwrapAPIResponse removes the api_response wrapper from the JSON response
 func (h *ProxyHandler) unwrapAPIResponse(jsonBytes []byte) []byte {
-	var response map[string]interface{}
-	if err := json.Unmarshal(jsonBytes, &response); err != nil {
+	decoded, err := decodeJSON(jsonBytes)
+	response, ok := decoded.(map[string]interface{})
+	if err != nil || !ok {
 		// If unmarshal fails, return original
 		return jsonBytes
 	}
@@ -314,74 +747,33 @@ func (h *ProxyHandler) unwrapAPIResponse(jsonBytes []byte) []byte {
 	return jsonBytes
 }
 
-// handleGRPCError converts gRPC errors to HTTP errors
-func (h *ProxyHandler) handleGRPCError(w http.ResponseWriter, err error) {
-	// Map gRPC errors to HTTP status codes
-	statusCode := http.StatusInternalServerError
-	errorCode := "INTERNAL_ERROR"
-	message := err.Error()
-
-	// Check for specific gRPC error codes
-	// This is a simplified version - in production, use grpc/status package
-	switch {
-	case contains(message, "NotFound"):
-		statusCode = http.StatusNotFound
-		errorCode = "NOT_FOUND"
-	case contains(message, "AlreadyExists"):
-		statusCode = http.StatusConflict
-		errorCode = "ALREADY_EXISTS"
-	case contains(message, "PermissionDenied"):
-		statusCode = http.StatusForbidden
-		errorCode = "PERMISSION_DENIED"
-	case contains(message, "Unauthenticated"):
-		statusCode = http.StatusUnauthorized
-		errorCode = "UNAUTHENTICATED"
-	case contains(message, "InvalidArgument"):
-		statusCode = http.StatusBadRequest
-		errorCode = "INVALID_ARGUMENT"
-	case contains(message, "Unavailable"):
-		statusCode = http.StatusServiceUnavailable
-		errorCode = "SERVICE_UNAVAILABLE"
-	case contains(message, "DeadlineExceeded"):
-		statusCode = http.StatusGatewayTimeout
-		errorCode = "TIMEOUT"
-	}
-
-	h.sendError(w, statusCode, errorCode, message)
+// fail records the error in the recent errors buffer and sends the error response
+func (h *ProxyHandler) fail(w http.ResponseWriter, r *http.Request, route *router.Route, statusCode int, errorCode, message string) {
+	h.failWithRetryAfter(w, r, route, statusCode, errorCode, message, 0)
 }
 
-// sendJSON sends a JSON response
-func (h *ProxyHandler) sendJSON(w http.ResponseWriter, statusCode int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(statusCode)
-
-	if err := json.NewEncoder(w).Encode(data); err != nil {
-		log.Printf("❌ Failed to encode JSON response: %v", err)
-	}
+// failWithRetryAfter is fail for errors clients should retry later. A positive
+// retryAfter is sent both as the Retry-After header and in the error body.
+func (h *ProxyHandler) failWithRetryAfter(w http.ResponseWriter, r *http.Request, route *router.Route, statusCode int, errorCode, message string, retryAfter time.Duration) {
+	h.failWithDetails(w, r, route, statusCode, errorCode, message, retryAfter, nil)
 }
 
-// sendError sends an error response
-func (h *ProxyHandler) sendError(w http.ResponseWriter, statusCode int, errorCode, message string) {
-	response := map[string]interface{}{
-		"error": message,
-		"code":  errorCode,
+// failWithDetails is failWithRetryAfter with structured error details (e.g. from
+// a gRPC status) added to the error body
+func (h *ProxyHandler) failWithDetails(w http.ResponseWriter, r *http.Request, route *router.Route, statusCode int, errorCode, message string, retryAfter time.Duration, details []map[string]interface{}) {
+	if h.errors != nil {
+		h.errors.Record(errorlog.Entry{
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Route:     route.Name,
+			Status:    statusCode,
+			Code:      errorCode,
+			Backend:   route.GetTargetService(),
+			RequestID: r.Header.Get("X-Request-ID"),
+			Message:   message,
+		})
 	}
 
-	h.sendJSON(w, statusCode, response)
-}
-
-// contains is a simple string contains helper
-func contains(s, substr string) bool {
-	return len(s) >= len(substr) && (s == substr || len(s) > len(substr) &&
-		(s[:len(substr)] == substr || s[len(s)-len(substr):] == substr ||
-			findInString(s, substr)))
-}
-
-func findInString(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
-	}
-	return false
+	httperr.Write(w, r, httperr.Error{Status: statusCode, Code: errorCode, Message: message,
+		Service: route.GetTargetService(), RetryAfter: retryAfter, Details: details})
 }
@@ -0,0 +1,156 @@
+package proxy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"hub-api-gateway/internal/cache"
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/middleware"
+	"hub-api-gateway/internal/router"
+	"hub-api-gateway/internal/trace"
+)
+
+// EnableResponseCache serves GET responses of routes with a cache policy from store
+func (h *ProxyHandler) EnableResponseCache(store cache.Store) {
+	h.responseCache = store
+}
+
+// cacheable reports whether a request may be answered from, and stored in,
+// the response cache. Clients opt out of both with Cache-Control: no-store.
+func (h *ProxyHandler) cacheable(r *http.Request, route *router.Route) bool {
+	return h.responseCache != nil && route.Cache != nil && isRead(r) && !hasCacheDirective(r, "no-store")
+}
+
+// responseCacheKey identifies a cached response per route (and flag
+// variant), path, query (in a canonical order), tenant and user, unless the
+// route's cache is shared by all users of the tenant
+func responseCacheKey(r *http.Request, route *router.Route, userContext *middleware.UserContext) string {
+	userID := ""
+	if userContext != nil && !route.Cache.Shared {
+		userID = userContext.UserID
+	}
+	return route.Name + "|" + flagValue(r, route) + "|" + r.URL.Path + "?" + r.URL.Query().Encode() + "|" +
+		middleware.TenantFromContext(r.Context()) + "|" + userID
+}
+
+// serveCached answers a request from the response cache and reports whether
+// it did. Cache-Control: no-cache makes the gateway revalidate with the
+// backend; the fresh response then replaces the entry.
+func (h *ProxyHandler) serveCached(w http.ResponseWriter, r *http.Request, route *router.Route, userContext *middleware.UserContext) bool {
+	if !h.cacheable(r, route) {
+		return false
+	}
+	if hasCacheDirective(r, "no-cache") {
+		h.metrics.RecordResponseCacheLookup(false)
+		return false
+	}
+
+	lookupStart := time.Now()
+	entry, err := h.responseCache.Get(r.Context(), responseCacheKey(r, route, userContext))
+	if err != nil {
+		if !errors.Is(err, cache.ErrMiss) {
+			slog.WarnContext(r.Context(), "response cache unavailable", "route", route.Name, "error", err)
+		}
+		h.metrics.RecordResponseCacheLookup(false)
+		return false
+	}
+	h.metrics.RecordResponseCacheLookup(true)
+
+	age := time.Since(entry.StoredAt)
+	trace.FromContext(r.Context()).Record(metrics.StageBackend, "served cached response", time.Since(lookupStart), map[string]string{
+		"age": age.Round(time.Second).String(),
+	})
+
+	setCacheHeaders(w, route, entry, "HIT")
+	w.Header().Set("Age", strconv.Itoa(int(age.Seconds())))
+	if etagMatches(r, entry.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	writeJSONBody(w, http.StatusOK, entry.Body)
+	return true
+}
+
+// sendCacheable sends a response of a cached route and stores it. Clients
+// that already hold it (If-None-Match) get a 304. It returns the body.
+func (h *ProxyHandler) sendCacheable(w http.ResponseWriter, r *http.Request, route *router.Route, userContext *middleware.UserContext, body []byte) []byte {
+	now := time.Now()
+	entry := &cache.Entry{
+		Body:      body,
+		ETag:      entityTag(body),
+		StoredAt:  now,
+		ExpiresAt: now.Add(route.Cache.TTLDuration()),
+	}
+
+	// The client may already be gone; the entry is still worth keeping
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), time.Second)
+	defer cancel()
+	if err := h.responseCache.Set(ctx, responseCacheKey(r, route, userContext), entry); err != nil {
+		slog.WarnContext(r.Context(), "failed to store cached response", "route", route.Name, "error", err)
+	}
+
+	setCacheHeaders(w, route, entry, "MISS")
+	if etagMatches(r, entry.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		return body
+	}
+	writeJSONBody(w, http.StatusOK, body)
+	return body
+}
+
+// setCacheHeaders lets clients reuse and revalidate the response for the rest
+// of its TTL. Per-user entries are private to the client.
+func setCacheHeaders(w http.ResponseWriter, route *router.Route, entry *cache.Entry, result string) {
+	visibility := "private"
+	if route.Cache.Shared {
+		visibility = "public"
+	}
+	maxAge := int(time.Until(entry.ExpiresAt).Round(time.Second).Seconds())
+	if maxAge < 0 {
+		maxAge = 0
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", visibility, maxAge))
+	w.Header().Set("ETag", entry.ETag)
+	w.Header().Set("X-Cache", result)
+}
+
+// entityTag returns a strong ETag for a response body
+func entityTag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether If-None-Match lists the ETag (weak comparison,
+// RFC 9110 13.1.2)
+func etagMatches(r *http.Request, etag string) bool {
+	header := r.Header.Get("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// hasCacheDirective reports whether the request's Cache-Control has a directive
+func hasCacheDirective(r *http.Request, directive string) bool {
+	for _, value := range strings.Split(r.Header.Get("Cache-Control"), ",") {
+		if strings.EqualFold(strings.TrimSpace(value), directive) {
+			return true
+		}
+	}
+	return false
+}
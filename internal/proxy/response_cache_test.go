@@ -0,0 +1,68 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hub-api-gateway/internal/cache"
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/middleware"
+	"hub-api-gateway/internal/router"
+)
+
+func TestResponseCache(t *testing.T) {
+	route := &router.Route{Name: "portfolio-summary", Path: "/api/v1/portfolio/summary", Method: "GET",
+		Cache: &router.RouteCache{TTL: "30s"}}
+	h := &ProxyHandler{metrics: metrics.NewMetrics()}
+	h.EnableResponseCache(cache.NewMemoryStore(10))
+	alice := &middleware.UserContext{UserID: "alice"}
+
+	// Query parameter order doesn't matter
+	req := httptest.NewRequest("GET", "/api/v1/portfolio/summary?b=2&a=1", nil)
+	if h.serveCached(httptest.NewRecorder(), req, route, alice) {
+		t.Fatalf("served a response before one was cached")
+	}
+	miss := httptest.NewRecorder()
+	h.sendCacheable(miss, req, route, alice, []byte(`{"total":"100.00"}`))
+	etag := miss.Header().Get("ETag")
+	if miss.Header().Get("X-Cache") != "MISS" || etag == "" || miss.Header().Get("Cache-Control") != "private, max-age=30" {
+		t.Errorf("miss headers = %v", miss.Header())
+	}
+
+	req = httptest.NewRequest("GET", "/api/v1/portfolio/summary?a=1&b=2", nil)
+	hit := httptest.NewRecorder()
+	if !h.serveCached(hit, req, route, alice) {
+		t.Fatalf("expected the cached response")
+	}
+	if hit.Code != http.StatusOK || hit.Body.String() != `{"total":"100.00"}` || hit.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("hit = %d %s %v", hit.Code, hit.Body, hit.Header())
+	}
+
+	// Clients holding the response revalidate with its ETag
+	req.Header.Set("If-None-Match", etag)
+	revalidated := httptest.NewRecorder()
+	if !h.serveCached(revalidated, req, route, alice) || revalidated.Code != http.StatusNotModified || revalidated.Body.Len() != 0 {
+		t.Errorf("revalidation = %d %s, want 304", revalidated.Code, revalidated.Body)
+	}
+
+	// Entries are per user unless the route's cache is shared
+	if h.serveCached(httptest.NewRecorder(), req, route, &middleware.UserContext{UserID: "bob"}) {
+		t.Errorf("served alice's response to bob")
+	}
+	route.Cache.Shared = true
+	if h.serveCached(httptest.NewRecorder(), req, route, &middleware.UserContext{UserID: "bob"}) {
+		t.Errorf("shared entries must not reuse per-user ones")
+	}
+	route.Cache.Shared = false
+
+	// Clients can skip the cache
+	req.Header.Set("Cache-Control", "no-cache")
+	if h.serveCached(httptest.NewRecorder(), req, route, alice) {
+		t.Errorf("served a cached response despite no-cache")
+	}
+
+	if snapshot := h.metrics.GetSnapshot(); snapshot.ResponseCacheHits != 2 || snapshot.ResponseCacheMisses != 4 {
+		t.Errorf("cache lookups = %d hits, %d misses, want 2 and 4", snapshot.ResponseCacheHits, snapshot.ResponseCacheMisses)
+	}
+}
@@ -0,0 +1,161 @@
+package proxy
+
+import (
+	"context"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/trace"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// RetryPolicy controls how failed backend calls are retried. Attempts per
+// service come from ServiceConfig.MaxRetries.
+type RetryPolicy struct {
+	BaseDelay   time.Duration // Backoff before the first retry, doubled per attempt
+	MaxDelay    time.Duration // Upper bound of a single backoff
+	BudgetRatio float64       // Retries earned per request, e.g. 0.2 = at most 20% extra load
+	BudgetBurst int           // Retries that can be spent at once (the budget's capacity)
+}
+
+// retryableCodes are failures where the backend did not process the request
+// (or a repeat is harmless for idempotent methods)
+var retryableCodes = map[codes.Code]bool{
+	codes.Unavailable: true,
+	codes.Aborted:     true,
+}
+
+// idempotentMethods are the HTTP methods whose routes are safe to retry
+var idempotentMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+	http.MethodPut:     true,
+	http.MethodDelete:  true,
+}
+
+// retryBudget caps retries to a share of the service's traffic so retries
+// can't multiply the load on a backend that is already failing
+type retryBudget struct {
+	mu     sync.Mutex
+	tokens float64
+	max    float64
+	ratio  float64
+}
+
+func newRetryBudget(policy RetryPolicy) *retryBudget {
+	burst := float64(policy.BudgetBurst)
+	return &retryBudget{tokens: burst, max: burst, ratio: policy.BudgetRatio}
+}
+
+// deposit credits the budget for a request
+func (b *retryBudget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = minFloat(b.tokens+b.ratio, b.max)
+}
+
+// withdraw spends one retry, reporting false when the budget is exhausted
+func (b *retryBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// SetRetryPolicy enables retries of idempotent routes on transient gRPC errors
+func (h *ProxyHandler) SetRetryPolicy(policy RetryPolicy) {
+	h.retryPolicy = &policy
+}
+
+// budgetFor returns the retry budget of a service
+func (h *ProxyHandler) budgetFor(serviceName string) *retryBudget {
+	if budget, ok := h.retryBudgets.Load(serviceName); ok {
+		return budget.(*retryBudget)
+	}
+	budget, _ := h.retryBudgets.LoadOrStore(serviceName, newRetryBudget(*h.retryPolicy))
+	return budget.(*retryBudget)
+}
+
+// invoke calls the backend, retrying idempotent routes on retryable codes
+// with exponential backoff and full jitter while the retry budget allows
+func (h *ProxyHandler) invoke(ctx context.Context, r *http.Request, serviceName string, conn *grpc.ClientConn, fullMethod string, request, response proto.Message, opts ...grpc.CallOption) error {
+	err := callUpstream(ctx, conn, fullMethod, request, response, opts...)
+	if h.retryPolicy == nil || !idempotentMethods[r.Method] {
+		return err
+	}
+
+	budget := h.budgetFor(serviceName)
+	budget.deposit()
+
+	maxRetries := h.registry.config.Services[serviceName].MaxRetries
+	for attempt := 0; attempt < maxRetries && err != nil && retryableCodes[status.Code(err)]; attempt++ {
+		if !budget.withdraw() {
+			h.metrics.RecordRetryBudgetExhausted()
+			slog.WarnContext(r.Context(), "retry budget exhausted", "service", serviceName, "grpc_method", fullMethod)
+			break
+		}
+
+		delay := h.retryPolicy.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(delay):
+		}
+
+		h.metrics.RecordUpstreamRetry()
+		trace.FromContext(r.Context()).Record(metrics.StageBackend, "retrying backend call", delay, map[string]string{
+			"attempt": strconv.Itoa(attempt + 1),
+			"code":    status.Code(err).String(),
+		})
+		slog.InfoContext(r.Context(), "retrying backend call", "service", serviceName, "grpc_method", fullMethod,
+			"attempt", attempt+1, "code", status.Code(err).String(), "backoff_ms", delay.Milliseconds())
+
+		proto.Reset(response)
+		err = callUpstream(ctx, conn, fullMethod, request, response, opts...)
+	}
+	return err
+}
+
+// callUpstream makes one attempt of a backend call, unless an injected fault
+// fails it first
+func callUpstream(ctx context.Context, conn *grpc.ClientConn, fullMethod string, request, response proto.Message, opts ...grpc.CallOption) error {
+	if err := injectFault(ctx); err != nil {
+		return err
+	}
+	return conn.Invoke(ctx, fullMethod, request, response, opts...)
+}
+
+// backoff returns a random delay up to BaseDelay*2^attempt, capped at MaxDelay
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	ceiling := p.BaseDelay << attempt
+	if ceiling <= 0 || ceiling > p.MaxDelay {
+		ceiling = p.MaxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)) + 1)
+}
@@ -0,0 +1,110 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/metrics"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// flakyBackend fails the first failures calls of any method with code
+func flakyBackend(t *testing.T, failures int, code codes.Code) (*grpc.ClientConn, *int) {
+	listener := bufconn.Listen(1 << 20)
+	calls := 0
+	server := grpc.NewServer(grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+		calls++
+		if err := stream.RecvMsg(&emptypb.Empty{}); err != nil {
+			return err
+		}
+		if calls <= failures {
+			return status.Error(code, "backend restarting")
+		}
+		return stream.SendMsg(&emptypb.Empty{})
+	}))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, &calls
+}
+
+func newRetryHandler(maxRetries int, policy RetryPolicy) *ProxyHandler {
+	registry := NewServiceRegistry(&config.Config{Services: map[string]config.ServiceConfig{
+		"market-data-service": {MaxRetries: maxRetries},
+	}})
+	h := NewProxyHandler(registry, metrics.NewMetrics(), nil)
+	h.SetRetryPolicy(policy)
+	return h
+}
+
+func TestInvoke_RetriesTransientErrors(t *testing.T) {
+	h := newRetryHandler(3, RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, BudgetRatio: 0.2, BudgetBurst: 10})
+	conn, calls := flakyBackend(t, 2, codes.Unavailable)
+
+	req := httptest.NewRequest("GET", "/api/v1/market-data/AAPL", nil)
+	err := h.invoke(context.Background(), req, "market-data-service", conn, "/market.MarketData/GetQuote", &emptypb.Empty{}, &emptypb.Empty{})
+	if err != nil || *calls != 3 {
+		t.Fatalf("invoke() = %v after %d calls, want success after 3", err, *calls)
+	}
+	if retries := h.metrics.GetSnapshot().UpstreamRetries; retries != 2 {
+		t.Errorf("expected 2 recorded retries, got %d", retries)
+	}
+}
+
+func TestInvoke_NoRetry(t *testing.T) {
+	policy := RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, BudgetRatio: 0.2, BudgetBurst: 10}
+
+	tests := []struct {
+		name   string
+		method string
+		code   codes.Code
+	}{
+		{"non-idempotent method", "POST", codes.Unavailable},
+		{"non-retryable code", "GET", codes.InvalidArgument},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newRetryHandler(3, policy)
+			conn, calls := flakyBackend(t, 1, tt.code)
+
+			req := httptest.NewRequest(tt.method, "/api/v1/orders", nil)
+			err := h.invoke(context.Background(), req, "market-data-service", conn, "/orders.OrderService/SubmitOrder", &emptypb.Empty{}, &emptypb.Empty{})
+			if status.Code(err) != tt.code || *calls != 1 {
+				t.Errorf("invoke() = %v after %d calls, want %s after 1", err, *calls, tt.code)
+			}
+		})
+	}
+}
+
+func TestInvoke_RetryBudget(t *testing.T) {
+	h := newRetryHandler(3, RetryPolicy{BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond, BudgetRatio: 0, BudgetBurst: 1})
+	conn, calls := flakyBackend(t, 10, codes.Unavailable)
+
+	req := httptest.NewRequest("GET", "/api/v1/market-data/AAPL", nil)
+	if err := h.invoke(context.Background(), req, "market-data-service", conn, "/market.MarketData/GetQuote", &emptypb.Empty{}, &emptypb.Empty{}); err == nil {
+		t.Fatal("expected failure once the budget is spent")
+	}
+	if *calls != 2 {
+		t.Errorf("expected 1 call + 1 budgeted retry, got %d calls", *calls)
+	}
+	if exhausted := h.metrics.GetSnapshot().RetryBudgetExhausted; exhausted != 1 {
+		t.Errorf("expected 1 budget exhaustion, got %d", exhausted)
+	}
+}
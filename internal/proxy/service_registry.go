@@ -3,56 +3,101 @@ package proxy
 import (
 	"context"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 	"time"
 
 	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/egress"
+	"hub-api-gateway/internal/logging"
+	"hub-api-gateway/internal/router"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
 )
 
 // ServiceRegistry manages gRPC connections to microservices
 type ServiceRegistry struct {
-	connections     map[string]*grpc.ClientConn
+	connections     map[string]*connPool
 	circuitBreakers map[string]*CircuitBreaker
+	draining        map[string]DrainState
+	deprioritized   map[string]Deprioritization
+	versions        map[string]BackendVersion
+	health          map[string]*upstreamHealth
+	versionProbe    VersionProbe
 	config          *config.Config
+	egress          *egress.Policy
+	onBreakerChange func(name string, from, to CircuitState)
 	mu              sync.RWMutex
+	versionMu       sync.Mutex // Serializes version probes
+	healthMu        sync.Mutex // Guards health, updated on every upstream call
 }
 
 // NewServiceRegistry creates a new service registry
 func NewServiceRegistry(cfg *config.Config) *ServiceRegistry {
 	return &ServiceRegistry{
-		connections:     make(map[string]*grpc.ClientConn),
+		connections:     make(map[string]*connPool),
 		circuitBreakers: make(map[string]*CircuitBreaker),
+		draining:        make(map[string]DrainState),
+		deprioritized:   make(map[string]Deprioritization),
+		versions:        make(map[string]BackendVersion),
+		health:          make(map[string]*upstreamHealth),
+		versionProbe:    HealthVersionProbe,
 		config:          cfg,
 	}
 }
 
-// GetConnection returns a gRPC connection for the given service name
-// Creates a new connection if one doesn't exist (lazy loading)
+// SetEgressPolicy restricts the addresses the registry may dial
+func (r *ServiceRegistry) SetEgressPolicy(policy *egress.Policy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.egress = policy
+}
+
+// OnCircuitStateChange registers an observer of every breaker's state changes
+func (r *ServiceRegistry) OnCircuitStateChange(fn func(name string, from, to CircuitState)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.onBreakerChange = fn
+	for _, cb := range r.circuitBreakers {
+		cb.OnStateChange(fn)
+	}
+}
+
+// GetConnection returns a gRPC connection for the given service name, picked
+// round-robin from the service's channels
+// Creates the channels if they don't exist (lazy loading)
 func (r *ServiceRegistry) GetConnection(serviceName string) (*grpc.ClientConn, error) {
+	pool, err := r.connectionPool(serviceName)
+	if err != nil {
+		return nil, err
+	}
+	return pool.pick(), nil
+}
+
+// connectionPool returns the channels of a service, creating them on first use
+func (r *ServiceRegistry) connectionPool(serviceName string) (*connPool, error) {
 	r.mu.RLock()
-	conn, exists := r.connections[serviceName]
+	pool, exists := r.connections[serviceName]
 	r.mu.RUnlock()
 
-	if exists && conn.GetState().String() != "SHUTDOWN" {
-		return conn, nil
+	if exists && !pool.shutdown() {
+		return pool, nil
 	}
 
 	return r.createConnection(serviceName)
 }
 
-// createConnection creates a new gRPC connection to a service
-func (r *ServiceRegistry) createConnection(serviceName string) (*grpc.ClientConn, error) {
+// createConnection creates the gRPC channels of a service
+func (r *ServiceRegistry) createConnection(serviceName string) (*connPool, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	// Double-check if connection was created while waiting for lock
-	if conn, exists := r.connections[serviceName]; exists && conn.GetState().String() != "SHUTDOWN" {
-		return conn, nil
+	if pool, exists := r.connections[serviceName]; exists && !pool.shutdown() {
+		return pool, nil
 	}
 
 	serviceConfig, exists := r.config.Services[serviceName]
@@ -60,7 +105,13 @@ func (r *ServiceRegistry) createConnection(serviceName string) (*grpc.ClientConn
 		return nil, fmt.Errorf("service %s not found in configuration", serviceName)
 	}
 
-	log.Printf("🔌 Creating gRPC connection to %s at %s", serviceName, serviceConfig.Address)
+	size := r.config.Proxy.ConnectionPoolSize
+	if serviceConfig.PoolSize > 0 {
+		size = serviceConfig.PoolSize
+	}
+	size = max(size, 1)
+
+	slog.Info("creating gRPC connection", "service", serviceName, "address", serviceConfig.Address, "channels", size)
 
 	// gRPC dial options
 	opts := []grpc.DialOption{
@@ -74,19 +125,32 @@ func (r *ServiceRegistry) createConnection(serviceName string) (*grpc.ClientConn
 			grpc.MaxCallRecvMsgSize(10*1024*1024), // 10MB
 			grpc.MaxCallSendMsgSize(10*1024*1024), // 10MB
 		),
+		grpc.WithChainUnaryInterceptor(logging.UnaryClientInterceptor()),
+		grpc.WithChainStreamInterceptor(logging.StreamClientInterceptor()),
 	}
 
-	conn, err := grpc.NewClient(serviceConfig.Address, opts...)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create gRPC client for %s: %w", serviceName, err)
+	if r.egress.Enabled() {
+		if err := r.egress.CheckTarget(context.Background(), serviceConfig.Address); err != nil {
+			return nil, fmt.Errorf("service %s: %w", serviceName, err)
+		}
+		opts = append(opts, r.egress.DialOption(serviceConfig.Address))
 	}
 
-	conn.Connect()
+	pool := newConnPool()
+	for range size {
+		conn, err := grpc.NewClient(serviceConfig.Address, opts...)
+		if err != nil {
+			pool.close()
+			return nil, fmt.Errorf("failed to create gRPC client for %s: %w", serviceName, err)
+		}
+		conn.Connect()
+		pool.conns = append(pool.conns, conn)
+	}
 
-	r.connections[serviceName] = conn
-	log.Printf("✅ Connected to %s", serviceName)
+	r.connections[serviceName] = pool
+	slog.Info("connected to service", "service", serviceName)
 
-	return conn, nil
+	return pool, nil
 }
 
 // Close closes all gRPC connections
@@ -94,15 +158,15 @@ func (r *ServiceRegistry) Close() error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	log.Println("🔌 Closing all service connections...")
+	slog.Info("closing all service connections")
 
 	var errors []error
-	for serviceName, conn := range r.connections {
-		if err := conn.Close(); err != nil {
-			log.Printf("⚠️  Error closing connection to %s: %v", serviceName, err)
+	for serviceName, pool := range r.connections {
+		if err := pool.close(); err != nil {
+			slog.Warn("error closing service connection", "service", serviceName, "error", err)
 			errors = append(errors, err)
 		} else {
-			log.Printf("✅ Closed connection to %s", serviceName)
+			slog.Info("closed service connection", "service", serviceName)
 		}
 	}
 
@@ -136,6 +200,25 @@ func (r *ServiceRegistry) HealthCheck(serviceName string) error {
 	return nil
 }
 
+// CheckReady returns an error unless one of the service's channels is READY
+// or IDLE (connects on the next call). Failing channels are asked to
+// reconnect right away.
+func (r *ServiceRegistry) CheckReady(serviceName string) error {
+	pool, err := r.connectionPool(serviceName)
+	if err != nil {
+		return err
+	}
+
+	switch state := pool.pick().GetState(); state {
+	case connectivity.Ready, connectivity.Idle:
+		return nil
+	case connectivity.TransientFailure:
+		return fmt.Errorf("connection to %s is not healthy: %s", serviceName, state)
+	default:
+		return fmt.Errorf("connection to %s is not ready: %s", serviceName, state)
+	}
+}
+
 // GetAllServices returns a list of all registered service names
 func (r *ServiceRegistry) GetAllServices() []string {
 	r.mu.RLock()
@@ -149,40 +232,157 @@ func (r *ServiceRegistry) GetAllServices() []string {
 	return services
 }
 
+// HasService reports whether a service is configured
+func (r *ServiceRegistry) HasService(serviceName string) bool {
+	_, ok := r.config.Services[serviceName]
+	return ok
+}
+
 // GetConnectionState returns the connection state for a service
 func (r *ServiceRegistry) GetConnectionState(serviceName string) (string, error) {
 	r.mu.RLock()
-	conn, exists := r.connections[serviceName]
+	pool, exists := r.connections[serviceName]
 	r.mu.RUnlock()
 
 	if !exists {
 		return "NOT_CONNECTED", nil
 	}
 
-	return conn.GetState().String(), nil
+	return pool.state().String(), nil
+}
+
+// GetChannelStates returns the state of each of a service's channels, nil
+// before the service is first called
+func (r *ServiceRegistry) GetChannelStates(serviceName string) []string {
+	r.mu.RLock()
+	pool, exists := r.connections[serviceName]
+	r.mu.RUnlock()
+
+	if !exists {
+		return nil
+	}
+	return pool.states()
 }
 
 // GetCircuitBreaker returns the circuit breaker for a service
 func (r *ServiceRegistry) GetCircuitBreaker(serviceName string) *CircuitBreaker {
+	return r.circuitBreaker(serviceName, r.serviceBreakerConfig(serviceName))
+}
+
+// RouteCircuitBreaker returns the breaker guarding a route: its own when the
+// route sets circuit_breaker, otherwise its service's. It returns nil when
+// circuit breaking is disabled.
+func (r *ServiceRegistry) RouteCircuitBreaker(route *router.Route) *CircuitBreaker {
+	serviceName := route.GetTargetService()
+	if route.CircuitBreaker == nil || !r.config.Proxy.CircuitBreakerEnabled {
+		return r.ServiceCircuitBreaker(serviceName)
+	}
+
+	cfg := r.serviceBreakerConfig(serviceName)
+	if route.CircuitBreaker.MaxFailures > 0 {
+		cfg.MaxFailures = route.CircuitBreaker.MaxFailures
+	}
+	if timeout := route.CircuitBreaker.ResetTimeoutDuration(); timeout > 0 {
+		cfg.ResetTimeout = timeout
+	}
+	if route.CircuitBreaker.HalfOpenRequests > 0 {
+		cfg.HalfOpenRequests = route.CircuitBreaker.HalfOpenRequests
+	}
+	if route.CircuitBreaker.FailureRate > 0 {
+		cfg.FailureRate = route.CircuitBreaker.FailureRate
+	}
+	if route.CircuitBreaker.MinRequests > 0 {
+		cfg.MinRequests = route.CircuitBreaker.MinRequests
+	}
+	if window := route.CircuitBreaker.WindowDuration(); window > 0 {
+		cfg.Window = window
+	}
+	return r.circuitBreaker(serviceName+"/"+route.Name, cfg)
+}
+
+// ServiceCircuitBreaker returns the breaker of a service, nil when circuit
+// breaking is disabled
+func (r *ServiceRegistry) ServiceCircuitBreaker(serviceName string) *CircuitBreaker {
+	if !r.config.Proxy.CircuitBreakerEnabled {
+		return nil
+	}
+	return r.GetCircuitBreaker(serviceName)
+}
+
+// serviceBreakerConfig merges a service's overrides over the global settings
+func (r *ServiceRegistry) serviceBreakerConfig(serviceName string) CircuitBreakerConfig {
+	// Zero values fall back to NewCircuitBreaker's defaults
+	cfg := CircuitBreakerConfig{
+		MaxFailures:      uint32(max(r.config.Proxy.CircuitBreakerThreshold, 0)),
+		ResetTimeout:     r.config.Proxy.CircuitBreakerTimeout,
+		HalfOpenRequests: uint32(max(r.config.Proxy.CircuitBreakerHalfOpen, 0)),
+		FailureRate:      r.config.Proxy.CircuitBreakerFailureRate,
+		MinRequests:      uint32(max(r.config.Proxy.CircuitBreakerMinRequests, 0)),
+		Window:           r.config.Proxy.CircuitBreakerWindow,
+	}
+
+	if svc, ok := r.config.Services[serviceName]; ok {
+		if svc.BreakerThreshold > 0 {
+			cfg.MaxFailures = uint32(svc.BreakerThreshold)
+		}
+		if svc.BreakerTimeout > 0 {
+			cfg.ResetTimeout = svc.BreakerTimeout
+		}
+		if svc.BreakerHalfOpen > 0 {
+			cfg.HalfOpenRequests = uint32(svc.BreakerHalfOpen)
+		}
+		if svc.BreakerFailureRate > 0 {
+			cfg.FailureRate = svc.BreakerFailureRate
+		}
+	}
+	return cfg
+}
+
+// circuitBreaker returns the named breaker, creating it on first use. A
+// breaker whose settings changed (e.g. after a routes.yaml reload) is replaced.
+func (r *ServiceRegistry) circuitBreaker(name string, cfg CircuitBreakerConfig) *CircuitBreaker {
+	r.mu.RLock()
+	cb, exists := r.circuitBreakers[name]
+	r.mu.RUnlock()
+	if exists && cb.Config() == cfg {
+		return cb
+	}
+
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	if cb, exists := r.circuitBreakers[serviceName]; exists {
+	if cb, exists := r.circuitBreakers[name]; exists && cb.Config() == cfg {
 		return cb
 	}
 
-	// Create new circuit breaker with default config
-	cb := NewCircuitBreaker(serviceName, CircuitBreakerConfig{
-		MaxFailures:      5,
-		ResetTimeout:     30 * time.Second,
-		HalfOpenRequests: 3,
-	})
-	r.circuitBreakers[serviceName] = cb
-	log.Printf("🔌 Created circuit breaker for %s", serviceName)
+	cb = NewCircuitBreaker(name, cfg)
+	cb.OnStateChange(r.onBreakerChange)
+	r.circuitBreakers[name] = cb
+	slog.Info("created circuit breaker", "name", name, "max_failures", cfg.MaxFailures,
+		"reset_timeout", cfg.ResetTimeout.String(), "half_open_requests", cfg.HalfOpenRequests)
 
 	return cb
 }
 
+// CircuitBreakerByName returns a breaker by name: a service's, created on
+// first use, or a route's (<service>/<route>) once it has been used
+func (r *ServiceRegistry) CircuitBreakerByName(name string) (*CircuitBreaker, bool) {
+	if !r.config.Proxy.CircuitBreakerEnabled {
+		return nil, false
+	}
+
+	r.mu.RLock()
+	cb, exists := r.circuitBreakers[name]
+	r.mu.RUnlock()
+	if exists {
+		return cb, true
+	}
+	if _, ok := r.config.Services[name]; ok {
+		return r.GetCircuitBreaker(name), true
+	}
+	return nil, false
+}
+
 // GetAllCircuitBreakers returns all circuit breakers
 func (r *ServiceRegistry) GetAllCircuitBreakers() map[string]*CircuitBreaker {
 	r.mu.RLock()
@@ -0,0 +1,66 @@
+package proxy
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/middleware"
+	"hub-api-gateway/internal/router"
+	"hub-api-gateway/internal/trace"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Reasons reported in X-Gateway-Stale when a stale response is served
+const (
+	staleCircuitOpen        = "circuit-open"
+	staleBackendUnavailable = "backend-unavailable"
+	staleDeprioritized      = "deprioritized"
+)
+
+// staleOnGRPCError reports whether a backend error means the backend is down
+// (as opposed to rejecting the request) and a stale read may stand in for it
+func staleOnGRPCError(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	}
+	return false
+}
+
+// serveStale answers a GET or HEAD on a route with max_stale from the last successful
+// response when it is recent enough. It reports whether a response was written.
+func (h *ProxyHandler) serveStale(w http.ResponseWriter, r *http.Request, route *router.Route, userContext *middleware.UserContext, reason string) bool {
+	maxStale := route.MaxStaleDuration()
+	if maxStale <= 0 || !isRead(r) || h.snapshots == nil {
+		return false
+	}
+
+	body, storedAt, ok := h.snapshots.Load(snapshotKey(r, route, userContext))
+	if !ok {
+		return false
+	}
+	age := time.Since(storedAt)
+	if age > maxStale {
+		return false
+	}
+
+	slog.InfoContext(r.Context(), "serving stale response", "path", r.URL.Path, "reason", reason, "age", age.Round(time.Second).String())
+	trace.FromContext(r.Context()).Record(metrics.StageBackend, "served stale response", 0, map[string]string{
+		"reason": reason,
+		"age":    age.Round(time.Second).String(),
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Add("Warning", `110 - "Response is Stale"`)
+	w.Header().Add("Warning", `111 - "Revalidation Failed"`)
+	w.Header().Set("Age", fmt.Sprintf("%d", int(age.Seconds())))
+	w.Header().Set("X-Gateway-Stale", reason)
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+	return true
+}
@@ -0,0 +1,49 @@
+package proxy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestServeStale(t *testing.T) {
+	route := &router.Route{Name: "portfolio", Path: "/api/v1/portfolio/summary", Method: "GET", MaxStale: "10m"}
+	h := &ProxyHandler{}
+	h.EnableMaintenanceSnapshots(10)
+
+	req := httptest.NewRequest("GET", "/api/v1/portfolio/summary", nil)
+
+	// Nothing cached yet
+	if h.serveStale(httptest.NewRecorder(), req, route, nil, staleBackendUnavailable) {
+		t.Fatalf("expected no stale response without a snapshot")
+	}
+
+	h.snapshots.Store(snapshotKey(req, route, nil), []byte(`{"total":"100.00"}`))
+
+	recorder := httptest.NewRecorder()
+	if !h.serveStale(recorder, req, route, nil, staleCircuitOpen) {
+		t.Fatalf("expected stale response to be served")
+	}
+	if recorder.Code != http.StatusOK || recorder.Body.String() != `{"total":"100.00"}` {
+		t.Errorf("unexpected stale response: %d %s", recorder.Code, recorder.Body)
+	}
+	if recorder.Header().Get("X-Gateway-Stale") != staleCircuitOpen || recorder.Header().Get("Age") == "" || len(recorder.Header().Values("Warning")) != 2 {
+		t.Errorf("expected staleness headers but got %v", recorder.Header())
+	}
+
+	// Routes without max_stale keep failing fast
+	route.MaxStale = ""
+	if h.serveStale(httptest.NewRecorder(), req, route, nil, staleCircuitOpen) {
+		t.Errorf("expected no stale response without max_stale")
+	}
+
+	if !staleOnGRPCError(status.Error(codes.Unavailable, "down")) || staleOnGRPCError(status.Error(codes.NotFound, "missing")) || staleOnGRPCError(errors.New("plain")) {
+		t.Errorf("unexpected stale-on-error classification")
+	}
+}
@@ -0,0 +1,214 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"hub-api-gateway/internal/router"
+	"hub-api-gateway/internal/stream"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// Defaults for streaming routes when EnableStreaming wasn't called
+const (
+	defaultStreamBufferSize = 64
+	defaultStreamPolicy     = stream.PolicyDropOldest
+)
+
+// serverStreamDesc describes the calls made for streaming routes
+var serverStreamDesc = &grpc.StreamDesc{ServerStreams: true}
+
+// streamContentTypes maps stream formats to their Content-Type
+var streamContentTypes = map[string]string{
+	router.StreamSSE:    "text/event-stream",
+	router.StreamNDJSON: "application/x-ndjson",
+}
+
+// EnableStreaming sets how streaming routes buffer messages for each client
+// and how often idle SSE streams get a keep-alive comment (0 disables it)
+func (h *ProxyHandler) EnableStreaming(bufferSize int, policy stream.Policy, heartbeat time.Duration) {
+	h.streamBufferSize = bufferSize
+	h.streamPolicy = policy
+	h.streamHeartbeat = heartbeat
+}
+
+// streamFormat returns the format a streaming route answers in: the one the
+// client asks for in Accept, else the route's
+func streamFormat(r *http.Request, route *router.Route) string {
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, streamContentTypes[router.StreamSSE]):
+		return router.StreamSSE
+	case strings.Contains(accept, streamContentTypes[router.StreamNDJSON]):
+		return router.StreamNDJSON
+	}
+	return route.Stream
+}
+
+// openStream starts a server-streaming call and waits for its first message,
+// so errors the backend reports up front are still answered with an HTTP
+// status. The first message is nil for streams that end without any.
+func openStream(ctx context.Context, conn *grpc.ClientConn, fullMethod string, request proto.Message,
+	newResponse func() proto.Message) (grpc.ClientStream, proto.Message, error) {
+	upstream, err := conn.NewStream(ctx, serverStreamDesc, fullMethod)
+	if err != nil {
+		return nil, nil, err
+	}
+	// On io.EOF the call failed; RecvMsg below returns its status
+	if err := upstream.SendMsg(request); err != nil && err != io.EOF {
+		return nil, nil, err
+	}
+	if err := upstream.CloseSend(); err != nil {
+		return nil, nil, err
+	}
+
+	first := newResponse()
+	if err := upstream.RecvMsg(first); err != nil {
+		if err == io.EOF {
+			return upstream, nil, nil
+		}
+		return nil, nil, err
+	}
+	return upstream, first, nil
+}
+
+// serveStream relays an opened upstream stream to the client until the
+// backend ends it, the client goes away or falls too far behind. Messages are
+// received in their own goroutine and queued in a bounded buffer, so a slow
+// client never holds up the backend. It returns the error the stream ended
+// with, nil for a normal end.
+func (h *ProxyHandler) serveStream(w http.ResponseWriter, r *http.Request, route *router.Route, format string,
+	upstream grpc.ClientStream, first proto.Message, newResponse func() proto.Message) error {
+	w.Header().Set("Content-Type", streamContentTypes[format])
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Keep reverse proxies from buffering the stream
+
+	// Streams outlive the server's WriteTimeout; SERVER_MAX_REQUEST_DURATION still bounds them
+	controller := http.NewResponseController(w)
+	controller.SetWriteDeadline(time.Time{})
+	w.WriteHeader(http.StatusOK)
+	controller.Flush()
+
+	bufferSize, policy := h.streamBufferSize, h.streamPolicy
+	if bufferSize <= 0 {
+		bufferSize = defaultStreamBufferSize
+	}
+	if policy == "" {
+		policy = defaultStreamPolicy
+	}
+	buffer := stream.NewBuffer(bufferSize, policy)
+	buffer.OnDrop(h.metrics.RecordStreamDrop)
+	defer buffer.Close()
+
+	received := make(chan error, 1)
+	if first == nil {
+		received <- nil
+		buffer.Close()
+	} else {
+		go func() {
+			received <- h.receiveStream(upstream, route, first, newResponse, buffer)
+			buffer.Close()
+		}()
+	}
+
+	for {
+		msg, err := h.nextStreamMessage(r.Context(), buffer, w, format, controller)
+		switch {
+		case err == nil:
+			if format == router.StreamSSE {
+				msg = append(append([]byte("data: "), msg...), '\n', '\n')
+			} else {
+				msg = append(msg, '\n')
+			}
+			if _, err := w.Write(msg); err != nil {
+				return nil
+			}
+			controller.Flush()
+		case errors.Is(err, stream.ErrSlowConsumer):
+			slog.WarnContext(r.Context(), "disconnected slow stream consumer", "route", route.Name)
+			writeStreamError(w, format, "SLOW_CONSUMER", "Client fell too far behind the stream")
+			controller.Flush()
+			return err
+		case errors.Is(err, stream.ErrClosed):
+			err := <-received
+			if err != nil {
+				st := status.Convert(err)
+				writeStreamError(w, format, h.grpcErrorMapping(st.Code()).Code, st.Message())
+				controller.Flush()
+			}
+			return err
+		default:
+			// The client went away
+			return nil
+		}
+	}
+}
+
+// receiveStream queues first and every following upstream message in buffer
+// until the stream ends or the buffer is closed
+func (h *ProxyHandler) receiveStream(upstream grpc.ClientStream, route *router.Route, first proto.Message,
+	newResponse func() proto.Message, buffer *stream.Buffer) error {
+	msg := first
+	for {
+		encoded, err := h.encodeJSON(msg, route)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to encode stream message: %v", err)
+		}
+		if !buffer.Push(encoded) {
+			return nil
+		}
+
+		msg = newResponse()
+		if err := upstream.RecvMsg(msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// nextStreamMessage waits for the next queued message, writing SSE keep-alive
+// comments while the stream is idle
+func (h *ProxyHandler) nextStreamMessage(ctx context.Context, buffer *stream.Buffer, w http.ResponseWriter, format string, controller *http.ResponseController) ([]byte, error) {
+	if format != router.StreamSSE || h.streamHeartbeat <= 0 {
+		return buffer.Next(ctx)
+	}
+
+	for {
+		waitCtx, cancel := context.WithTimeout(ctx, h.streamHeartbeat)
+		msg, err := buffer.Next(waitCtx)
+		cancel()
+		if err == nil || ctx.Err() != nil || !errors.Is(err, context.DeadlineExceeded) {
+			return msg, err
+		}
+
+		if _, err := io.WriteString(w, ": keep-alive\n\n"); err != nil {
+			return nil, err
+		}
+		controller.Flush()
+	}
+}
+
+// writeStreamError ends a stream with an error in the {"error","code"} shape
+// of regular responses: an SSE "error" event or a last NDJSON line
+func writeStreamError(w io.Writer, format, code, message string) {
+	body, _ := json.Marshal(map[string]string{"error": message, "code": code})
+	if format == router.StreamSSE {
+		w.Write([]byte("event: error\ndata: "))
+		w.Write(body)
+		w.Write([]byte("\n\n"))
+		return
+	}
+	w.Write(append(body, '\n'))
+}
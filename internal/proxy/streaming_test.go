@@ -0,0 +1,124 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// tickBackend streams ticks quotes for any method, then ends with end
+func tickBackend(t *testing.T, ticks int, end error) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+		if err := stream.RecvMsg(&emptypb.Empty{}); err != nil {
+			return err
+		}
+		for i := 0; i < ticks; i++ {
+			quote, _ := structpb.NewStruct(map[string]interface{}{"symbol": "AAPL", "seq": i})
+			if err := stream.SendMsg(quote); err != nil {
+				return err
+			}
+		}
+		return end
+	}))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func streamQuotes(t *testing.T, conn *grpc.ClientConn, format string) (string, error) {
+	h := NewProxyHandler(nil, metrics.NewMetrics(), nil)
+	route := &router.Route{Name: "stream-quotes", Stream: format}
+	newResponse := func() proto.Message { return &structpb.Struct{} }
+
+	upstream, first, err := openStream(context.Background(), conn, "/market.MarketData/StreamQuotes", &emptypb.Empty{}, newResponse)
+	if err != nil {
+		t.Fatalf("openStream() error = %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/api/v1/market-data/stream", nil)
+	err = h.serveStream(w, r, route, streamFormat(r, route), upstream, first, newResponse)
+	if got := w.Header().Get("Content-Type"); got != streamContentTypes[format] {
+		t.Errorf("Content-Type = %q, want %q", got, streamContentTypes[format])
+	}
+	return w.Body.String(), err
+}
+
+func TestServeStream_SSE(t *testing.T) {
+	body, err := streamQuotes(t, tickBackend(t, 3, nil), router.StreamSSE)
+	if err != nil {
+		t.Fatalf("serveStream() error = %v", err)
+	}
+
+	events := strings.Split(strings.TrimSuffix(body, "\n\n"), "\n\n")
+	if len(events) != 3 {
+		t.Fatalf("got %d events, want 3: %q", len(events), body)
+	}
+	for i, event := range events {
+		var quote map[string]interface{}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(event, "data: ")), &quote); err != nil || quote["seq"] != float64(i) {
+			t.Errorf("event %d = %q, want quote %d", i, event, i)
+		}
+	}
+}
+
+func TestServeStream_NDJSONError(t *testing.T) {
+	body, err := streamQuotes(t, tickBackend(t, 1, status.Error(codes.Unavailable, "feed lost")), router.StreamNDJSON)
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("serveStream() error = %v, want Unavailable", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(body, "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want a quote and an error: %q", len(lines), body)
+	}
+	var last map[string]string
+	if err := json.Unmarshal([]byte(lines[1]), &last); err != nil || last["error"] != "feed lost" || last["code"] == "" {
+		t.Errorf("last line = %q, want the backend error", lines[1])
+	}
+}
+
+func TestOpenStream_UpfrontError(t *testing.T) {
+	conn := tickBackend(t, 0, status.Error(codes.NotFound, "unknown symbol"))
+	_, _, err := openStream(context.Background(), conn, "/market.MarketData/StreamQuotes", &emptypb.Empty{},
+		func() proto.Message { return &structpb.Struct{} })
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("openStream() error = %v, want NotFound before any message", err)
+	}
+}
+
+func TestStreamFormat(t *testing.T) {
+	route := &router.Route{Stream: router.StreamNDJSON}
+	r := httptest.NewRequest("GET", "/api/v1/market-data/stream", nil)
+	if got := streamFormat(r, route); got != router.StreamNDJSON {
+		t.Errorf("streamFormat() = %q, want the route's format", got)
+	}
+	r.Header.Set("Accept", "text/event-stream")
+	if got := streamFormat(r, route); got != router.StreamSSE {
+		t.Errorf("streamFormat() = %q, want sse for EventSource clients", got)
+	}
+}
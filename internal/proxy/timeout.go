@@ -0,0 +1,74 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"hub-api-gateway/internal/router"
+)
+
+// defaultUpstreamTimeout bounds backend calls when neither the route nor its
+// service configures a timeout
+const defaultUpstreamTimeout = 30 * time.Second
+
+// statusClientClosedRequest is the non-standard status logged for requests
+// whose client went away, as nginx does
+const statusClientClosedRequest = 499
+
+// upstreamTimeout returns how long a backend call for route may take: the
+// route's timeout, else the service's, else defaultUpstreamTimeout
+func (h *ProxyHandler) upstreamTimeout(route *router.Route, serviceName string) time.Duration {
+	if timeout := route.TimeoutDuration(); timeout > 0 {
+		return timeout
+	}
+	if timeout := h.registry.config.Services[serviceName].Timeout; timeout > 0 {
+		return timeout
+	}
+	return defaultUpstreamTimeout
+}
+
+// MaxUpstreamDuration returns the longest a backend call for route may
+// legitimately take: its upstream timeout plus, for long-poll routes, the
+// maximum client wait. Streams have no upstream bound and return 0.
+func (h *ProxyHandler) MaxUpstreamDuration(route *router.Route) time.Duration {
+	if route.Stream != "" {
+		return 0
+	}
+	// Branches run concurrently and any upstream (or tenant override) may be
+	// chosen, so the slowest target bounds the request
+	var timeout time.Duration
+	for _, target := range route.Targets() {
+		timeout = max(timeout, h.upstreamTimeout(&target, target.GetTargetService()))
+	}
+	if route.LongPollField != "" {
+		timeout += h.longPollMaxWait
+	}
+	return timeout
+}
+
+// upstreamContext creates the context for a backend call bounded by timeout
+// and by whatever remains of the client request's deadline. It's derived from
+// the request, so a client that disconnects cancels the call. gRPC sends the
+// resulting deadline to the backend as grpc-timeout and propagates the
+// cancellation, so downstream services can stop working on requests nobody
+// is waiting for.
+func upstreamContext(r *http.Request, timeout time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(r.Context(), timeout)
+}
+
+// clientAborted reports whether the client went away before the backend
+// answered, counting the request if so. Such failures say nothing about the
+// backend and only need to be logged. Cancellations with another cause (e.g.
+// the watchdog's) aren't the client's doing.
+func (h *ProxyHandler) clientAborted(r *http.Request, route *router.Route) bool {
+	if r.Context().Err() == nil || !errors.Is(context.Cause(r.Context()), context.Canceled) {
+		return false
+	}
+	h.metrics.RecordClientAborted(route.Name)
+	slog.InfoContext(r.Context(), "client closed request before the backend answered", "route", route.Name,
+		"method", r.Method, "path", r.URL.Path)
+	return true
+}
@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/router"
+)
+
+func TestUpstreamTimeout(t *testing.T) {
+	registry := NewServiceRegistry(&config.Config{Services: map[string]config.ServiceConfig{
+		"market-data-service": {Timeout: 3 * time.Second},
+	}})
+	h := NewProxyHandler(registry, metrics.NewMetrics(), nil)
+
+	tests := []struct {
+		name    string
+		route   router.Route
+		service string
+		want    time.Duration
+	}{
+		{"route timeout", router.Route{Timeout: "60s"}, "market-data-service", 60 * time.Second},
+		{"service timeout", router.Route{}, "market-data-service", 3 * time.Second},
+		{"default", router.Route{}, "report-service", defaultUpstreamTimeout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := h.upstreamTimeout(&tt.route, tt.service); got != tt.want {
+				t.Errorf("upstreamTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpstreamContext_HonorsRequestDeadline(t *testing.T) {
+	reqCtx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	r := httptest.NewRequest("GET", "/api/v1/market-data/AAPL", nil).WithContext(reqCtx)
+
+	ctx, cancelUpstream := upstreamContext(r, time.Minute)
+	defer cancelUpstream()
+
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > 100*time.Millisecond {
+		t.Fatalf("upstream deadline %v should not outlive the request deadline", deadline)
+	}
+
+	ctx, cancelUpstream = upstreamContext(httptest.NewRequest("GET", "/", nil), time.Second)
+	defer cancelUpstream()
+	if deadline, ok := ctx.Deadline(); !ok || time.Until(deadline) > time.Second {
+		t.Errorf("upstream deadline %v should be bounded by the timeout", deadline)
+	}
+}
+
+func TestUpstreamContext_ClientDisconnect(t *testing.T) {
+	h := NewProxyHandler(NewServiceRegistry(&config.Config{}), metrics.NewMetrics(), nil)
+	route := &router.Route{Name: "get-quote"}
+
+	reqCtx, disconnect := context.WithCancel(context.Background())
+	r := httptest.NewRequest("GET", "/api/v1/market-data/AAPL", nil).WithContext(reqCtx)
+	ctx, cancelUpstream := upstreamContext(r, time.Minute)
+	defer cancelUpstream()
+
+	disconnect()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected the upstream call to be cancelled when the client disconnects")
+	}
+	if !h.clientAborted(r, route) || h.metrics.GetSnapshot().ClientAborted != 1 {
+		t.Errorf("expected the request to be counted as aborted by the client")
+	}
+
+	// Cancellations by the gateway itself aren't the client's
+	reqCtx, cancel := context.WithCancelCause(context.Background())
+	cancel(errors.New("request exceeded the watchdog hard limit"))
+	if h.clientAborted(httptest.NewRequest("GET", "/", nil).WithContext(reqCtx), route) {
+		t.Error("expected a watchdog cancellation not to count as a client abort")
+	}
+}
@@ -0,0 +1,282 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+// ErrBackendEjected is returned for backends taken out of rotation by health
+// probes or outlier detection
+var ErrBackendEjected = errors.New("backend ejected")
+
+// Active probe results
+const (
+	ProbeServing     = "SERVING"
+	ProbeNotServing  = "NOT_SERVING"
+	ProbeUnreachable = "UNREACHABLE"
+	ProbeUnsupported = "UNSUPPORTED" // Backend doesn't implement grpc.health.v1
+)
+
+const (
+	probeUnhealthyThreshold = 2 // Consecutive failed probes that eject a backend
+	maxEjectionMultiplier   = 5 // Cap on the ejection time growth for repeat offenders
+)
+
+// ServiceHealth is the health of a backend as seen by the gateway
+type ServiceHealth struct {
+	Service string `json:"service"`
+
+	// Active probing with the standard gRPC health-checking protocol
+	Probe               string     `json:"probe,omitempty"`
+	ProbeError          string     `json:"probe_error,omitempty"`
+	ProbeLatencyMs      float64    `json:"probe_latency_ms,omitempty"`
+	LastProbe           *time.Time `json:"last_probe,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_probe_failures"`
+
+	// Passive outlier detection over the current window
+	Requests     int        `json:"window_requests"`
+	Failures     int        `json:"window_failures"`
+	ErrorRate    float64    `json:"window_error_rate"`
+	Ejections    int        `json:"ejections"`
+	EjectedUntil *time.Time `json:"ejected_until,omitempty"`
+
+	Healthy bool `json:"healthy"`
+}
+
+// upstreamHealth is the mutable health state of a backend
+type upstreamHealth struct {
+	probe               string
+	probeError          string
+	probeLatency        time.Duration
+	lastProbe           time.Time
+	consecutiveFailures int
+
+	windowStart  time.Time
+	requests     int
+	failures     int
+	ejections    int
+	ejectedUntil time.Time
+}
+
+// serviceHealth returns the health state of a service, creating it on first use.
+// The caller holds r.healthMu.
+func (r *ServiceRegistry) serviceHealth(serviceName string) *upstreamHealth {
+	state, ok := r.health[serviceName]
+	if !ok {
+		state = &upstreamHealth{windowStart: time.Now()}
+		r.health[serviceName] = state
+	}
+	return state
+}
+
+// CheckAvailable returns ErrBackendEjected while a service fails its health
+// probes or is ejected as an outlier
+func (r *ServiceRegistry) CheckAvailable(serviceName string) error {
+	r.healthMu.Lock()
+	defer r.healthMu.Unlock()
+
+	state, ok := r.health[serviceName]
+	switch {
+	case !ok:
+		return nil
+	case state.consecutiveFailures >= probeUnhealthyThreshold:
+		return fmt.Errorf("%w: %s failed %d health probes: %s", ErrBackendEjected, serviceName, state.consecutiveFailures, state.probeError)
+	case time.Now().Before(state.ejectedUntil):
+		return fmt.Errorf("%w: %s is an outlier until %s", ErrBackendEjected, serviceName, state.ejectedUntil.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// EjectionRetryAfter returns how long a service stays ejected as an outlier,
+// or the probe interval while it fails health probes
+func (r *ServiceRegistry) EjectionRetryAfter(serviceName string) time.Duration {
+	r.healthMu.Lock()
+	defer r.healthMu.Unlock()
+
+	if state, ok := r.health[serviceName]; ok {
+		if remaining := time.Until(state.ejectedUntil); remaining > 0 {
+			return remaining
+		}
+	}
+	return r.config.Proxy.HealthProbeInterval
+}
+
+// RecordOutcome counts an upstream call towards the service's error rate and
+// ejects the service once the rate reaches OUTLIER_ERROR_RATE over at least
+// OUTLIER_MIN_REQUESTS calls in the window. Calls that never reached the
+// backend, or failed because of the request itself, aren't judged.
+func (r *ServiceRegistry) RecordOutcome(serviceName string, err error) {
+	cfg := r.config.Proxy
+	if !cfg.OutlierDetectionEnabled {
+		return
+	}
+	switch {
+	case errors.Is(err, ErrCircuitOpen), errors.Is(err, ErrTooManyRequests), errors.Is(err, ErrBackendEjected),
+		errors.Is(err, ErrIncompatibleBackend), isCancellation(err):
+		return
+	}
+
+	r.healthMu.Lock()
+	defer r.healthMu.Unlock()
+
+	now := time.Now()
+	state := r.serviceHealth(serviceName)
+	if now.Sub(state.windowStart) >= cfg.OutlierWindow {
+		// A full window without an ejection forgives earlier ones
+		if now.After(state.ejectedUntil.Add(cfg.OutlierWindow)) {
+			state.ejections = 0
+		}
+		state.windowStart, state.requests, state.failures = now, 0, 0
+	}
+	if now.Before(state.ejectedUntil) {
+		return
+	}
+
+	state.requests++
+	if err != nil && isBreakerFailure(err) {
+		state.failures++
+	}
+	if state.requests < cfg.OutlierMinRequests || float64(state.failures)/float64(state.requests) < cfg.OutlierErrorRate {
+		return
+	}
+
+	state.ejections++
+	ejection := cfg.OutlierEjectionTime * time.Duration(min(state.ejections, maxEjectionMultiplier))
+	state.ejectedUntil = now.Add(ejection)
+	slog.Warn("backend ejected as outlier", "service", serviceName, "requests", state.requests,
+		"failures", state.failures, "ejection", ejection.String(), "ejections", state.ejections)
+	state.windowStart, state.requests, state.failures = now, 0, 0
+}
+
+// RunHealthProbes calls the grpc.health.v1 Check RPC on every configured
+// service each HEALTH_PROBE_INTERVAL until ctx is done. Services failing two
+// probes in a row are ejected until a probe succeeds again.
+func (r *ServiceRegistry) RunHealthProbes(ctx context.Context) {
+	interval := r.config.Proxy.HealthProbeInterval
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.probeAll(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeAll probes every configured service concurrently
+func (r *ServiceRegistry) probeAll(ctx context.Context) {
+	var wg sync.WaitGroup
+	for serviceName := range r.config.Services {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.probe(ctx, serviceName)
+		}()
+	}
+	wg.Wait()
+}
+
+// probe checks one service and records the result
+func (r *ServiceRegistry) probe(ctx context.Context, serviceName string) {
+	result, probeErr := ProbeServing, ""
+	start := time.Now()
+
+	conn, err := r.GetConnection(serviceName)
+	if err == nil {
+		probeCtx, cancel := context.WithTimeout(ctx, r.config.Proxy.HealthProbeTimeout)
+		var response *healthpb.HealthCheckResponse
+		response, err = healthpb.NewHealthClient(conn).Check(probeCtx, &healthpb.HealthCheckRequest{})
+		cancel()
+		if err == nil && response.GetStatus() != healthpb.HealthCheckResponse_SERVING {
+			err = fmt.Errorf("backend reported %s", response.GetStatus())
+			result = ProbeNotServing
+		}
+	}
+	switch {
+	case ctx.Err() != nil:
+		return
+	case status.Code(err) == codes.Unimplemented:
+		result, err = ProbeUnsupported, nil
+	case err != nil && result == ProbeServing:
+		result = ProbeUnreachable
+	}
+	if err != nil {
+		probeErr = err.Error()
+	}
+
+	r.healthMu.Lock()
+	state := r.serviceHealth(serviceName)
+	wasEjected := state.consecutiveFailures >= probeUnhealthyThreshold
+	state.probe, state.probeError, state.probeLatency, state.lastProbe = result, probeErr, time.Since(start), time.Now()
+	if err != nil {
+		state.consecutiveFailures++
+	} else {
+		state.consecutiveFailures = 0
+	}
+	ejected := state.consecutiveFailures >= probeUnhealthyThreshold
+	r.healthMu.Unlock()
+
+	switch {
+	case ejected && !wasEjected:
+		slog.Warn("backend failed health probes, ejecting", "service", serviceName, "probe", result, "error", probeErr)
+	case wasEjected && !ejected:
+		slog.Info("backend passed health probe, restoring", "service", serviceName, "probe", result)
+	}
+}
+
+// GetServiceHealth returns the health of every configured service sorted by name
+func (r *ServiceRegistry) GetServiceHealth() []ServiceHealth {
+	names := make([]string, 0, len(r.config.Services))
+	for name := range r.config.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	r.healthMu.Lock()
+	defer r.healthMu.Unlock()
+
+	now := time.Now()
+	services := make([]ServiceHealth, 0, len(names))
+	for _, name := range names {
+		entry := ServiceHealth{Service: name, Healthy: true}
+		if state, ok := r.health[name]; ok {
+			entry.Probe = state.probe
+			entry.ProbeError = state.probeError
+			entry.ProbeLatencyMs = float64(state.probeLatency.Microseconds()) / 1000
+			if !state.lastProbe.IsZero() {
+				lastProbe := state.lastProbe
+				entry.LastProbe = &lastProbe
+			}
+			entry.ConsecutiveFailures = state.consecutiveFailures
+			entry.Requests = state.requests
+			entry.Failures = state.failures
+			entry.Ejections = state.ejections
+			if state.requests > 0 {
+				entry.ErrorRate = float64(state.failures) / float64(state.requests)
+			}
+			if now.Before(state.ejectedUntil) {
+				until := state.ejectedUntil
+				entry.EjectedUntil = &until
+			}
+			entry.Healthy = entry.EjectedUntil == nil && state.consecutiveFailures < probeUnhealthyThreshold
+		}
+		services = append(services, entry)
+	}
+	return services
+}
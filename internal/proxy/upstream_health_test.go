@@ -0,0 +1,91 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/config"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestOutlierEjection(t *testing.T) {
+	registry := NewServiceRegistry(&config.Config{Proxy: config.ProxyConfig{
+		OutlierDetectionEnabled: true,
+		OutlierErrorRate:        0.5,
+		OutlierMinRequests:      4,
+		OutlierWindow:           time.Minute,
+		OutlierEjectionTime:     time.Minute,
+	}})
+
+	// Request errors are answers from a healthy backend; calls that never
+	// reached the backend aren't counted at all
+	for range 4 {
+		registry.RecordOutcome("hub-order-service", status.Error(codes.NotFound, "no such order"))
+		registry.RecordOutcome("hub-order-service", ErrCircuitOpen)
+	}
+	if err := registry.CheckAvailable("hub-order-service"); err != nil {
+		t.Fatalf("ejected on request errors: %v", err)
+	}
+
+	for range 4 {
+		registry.RecordOutcome("hub-order-service", status.Error(codes.Unavailable, "connection refused"))
+	}
+	if err := registry.CheckAvailable("hub-order-service"); !errors.Is(err, ErrBackendEjected) {
+		t.Fatalf("CheckAvailable = %v, want ErrBackendEjected", err)
+	}
+	if retryAfter := registry.EjectionRetryAfter("hub-order-service"); retryAfter <= 0 || retryAfter > time.Minute {
+		t.Errorf("retry after = %v, want up to 1m", retryAfter)
+	}
+	if err := registry.CheckAvailable("hub-user-service"); err != nil {
+		t.Errorf("other services must stay available: %v", err)
+	}
+}
+
+func TestHealthProbe(t *testing.T) {
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(server, healthServer)
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go server.Serve(listener)
+	defer server.Stop()
+
+	registry := NewServiceRegistry(&config.Config{
+		Services: map[string]config.ServiceConfig{"hub-order-service": {Address: listener.Addr().String()}},
+		Proxy:    config.ProxyConfig{HealthProbeInterval: time.Second, HealthProbeTimeout: time.Second},
+	})
+	defer registry.Close()
+	ctx := context.Background()
+
+	registry.probeAll(ctx)
+	if services := registry.GetServiceHealth(); services[0].Probe != ProbeServing || !services[0].Healthy {
+		t.Fatalf("health = %+v, want serving", services[0])
+	}
+
+	// One failed probe is tolerated, the second ejects until a probe passes
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	registry.probeAll(ctx)
+	if err := registry.CheckAvailable("hub-order-service"); err != nil {
+		t.Fatalf("ejected after one failed probe: %v", err)
+	}
+	registry.probeAll(ctx)
+	if err := registry.CheckAvailable("hub-order-service"); !errors.Is(err, ErrBackendEjected) {
+		t.Fatalf("CheckAvailable = %v, want ErrBackendEjected", err)
+	}
+
+	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	registry.probeAll(ctx)
+	if err := registry.CheckAvailable("hub-order-service"); err != nil {
+		t.Errorf("still ejected after a passing probe: %v", err)
+	}
+}
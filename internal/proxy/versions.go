@@ -0,0 +1,222 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// VersionMetadata is the response header (or trailer) backends report their
+// contract version in
+const VersionMetadata = "x-contract-version"
+
+const (
+	versionProbeTimeout    = 2 * time.Second
+	versionRecheckInterval = 30 * time.Second // Catches backends upgraded or rolled back behind the same connection
+)
+
+// ErrIncompatibleBackend is returned for backends older than their configured minimum version
+var ErrIncompatibleBackend = errors.New("backend contract version is incompatible")
+
+// BackendVersion is the detected contract version of a pinned backend
+type BackendVersion struct {
+	Service    string    `json:"service"`
+	Version    string    `json:"version"`
+	MinVersion string    `json:"min_version"`
+	Compatible bool      `json:"compatible"`
+	CheckedAt  time.Time `json:"checked_at"`
+	Error      string    `json:"error,omitempty"`
+
+	conn *grpc.ClientConn // Connection the verdict applies to; a new connection is probed again
+}
+
+// VersionProbe asks a backend for its contract version
+type VersionProbe func(ctx context.Context, conn *grpc.ClientConn) (string, error)
+
+// HealthVersionProbe calls the standard grpc.health.v1 Check RPC and reads the
+// x-contract-version header or trailer. Backends that don't implement health
+// checks can still answer Unimplemented with the header set.
+func HealthVersionProbe(ctx context.Context, conn *grpc.ClientConn) (string, error) {
+	var header, trailer metadata.MD
+	_, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{},
+		grpc.Header(&header), grpc.Trailer(&trailer))
+
+	for _, md := range []metadata.MD{header, trailer} {
+		if values := md.Get(VersionMetadata); len(values) > 0 && values[0] != "" {
+			return values[0], nil
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("backend did not report %s", VersionMetadata)
+}
+
+// SetVersionProbe replaces how backend contract versions are detected
+func (r *ServiceRegistry) SetVersionProbe(probe VersionProbe) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.versionProbe = probe
+}
+
+// CheckVersion verifies a service with a configured minimum version runs a
+// compatible contract before traffic is routed to it. Verdicts are cached per
+// connection and refreshed every 30 seconds. Unreachable backends are not
+// judged; the call itself will fail.
+func (r *ServiceRegistry) CheckVersion(ctx context.Context, serviceName string, conn *grpc.ClientConn) error {
+	minVersion := r.config.Services[serviceName].MinVersion
+	if minVersion == "" {
+		return nil
+	}
+
+	if verdict, ok := r.freshVersion(serviceName, conn); ok {
+		return verdict.err()
+	}
+
+	// One probe at a time; concurrent requests reuse its verdict
+	r.versionMu.Lock()
+	defer r.versionMu.Unlock()
+	if verdict, ok := r.freshVersion(serviceName, conn); ok {
+		return verdict.err()
+	}
+
+	r.mu.RLock()
+	probe := r.versionProbe
+	r.mu.RUnlock()
+
+	probeCtx, cancel := context.WithTimeout(ctx, versionProbeTimeout)
+	version, err := probe(probeCtx, conn)
+	cancel()
+
+	verdict := BackendVersion{Service: serviceName, Version: version, MinVersion: minVersion, CheckedAt: time.Now(), conn: conn}
+	switch {
+	case err != nil && unreachable(err):
+		slog.WarnContext(ctx, "backend version check failed", "service", serviceName, "error", err)
+		return nil
+	case err != nil:
+		verdict.Error = err.Error()
+	default:
+		verdict.Compatible, err = versionAtLeast(version, minVersion)
+		if err != nil {
+			verdict.Error = err.Error()
+		}
+	}
+
+	r.mu.Lock()
+	previous, seen := r.versions[serviceName]
+	r.versions[serviceName] = verdict
+	r.mu.Unlock()
+
+	if !seen || previous.Compatible != verdict.Compatible || previous.Version != verdict.Version {
+		if verdict.Compatible {
+			slog.InfoContext(ctx, "backend version compatible", "service", serviceName, "version", version, "min_version", minVersion)
+		} else {
+			slog.ErrorContext(ctx, "backend version incompatible, refusing to route",
+				"service", serviceName, "version", version, "min_version", minVersion, "error", verdict.Error)
+		}
+	}
+	return verdict.err()
+}
+
+// freshVersion returns the cached verdict for conn unless it is due for a recheck
+func (r *ServiceRegistry) freshVersion(serviceName string, conn *grpc.ClientConn) (BackendVersion, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	verdict, ok := r.versions[serviceName]
+	if !ok || verdict.conn != conn || time.Since(verdict.CheckedAt) > versionRecheckInterval {
+		return BackendVersion{}, false
+	}
+	return verdict, true
+}
+
+// GetBackendVersions returns the detected versions of pinned services
+func (r *ServiceRegistry) GetBackendVersions() map[string]BackendVersion {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	versions := make(map[string]BackendVersion, len(r.versions))
+	for name, verdict := range r.versions {
+		versions[name] = verdict
+	}
+	return versions
+}
+
+// err returns ErrIncompatibleBackend with details for incompatible verdicts
+func (v BackendVersion) err() error {
+	if v.Compatible {
+		return nil
+	}
+	if v.Error != "" {
+		return fmt.Errorf("%w: %s requires %s or newer: %s", ErrIncompatibleBackend, v.Service, v.MinVersion, v.Error)
+	}
+	return fmt.Errorf("%w: %s runs %s, %s or newer is required", ErrIncompatibleBackend, v.Service, v.Version, v.MinVersion)
+}
+
+// unreachable reports probe errors that say nothing about the backend's version
+func unreachable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.Canceled:
+		return true
+	}
+	return false
+}
+
+// ParseVersion parses a dotted version such as "1.4", "v2.0.3" or "1.5.0-rc1"
+// (pre-release and build suffixes are ignored)
+func ParseVersion(version string) ([]int, error) {
+	trimmed := strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if i := strings.IndexAny(trimmed, "-+"); i >= 0 {
+		trimmed = trimmed[:i]
+	}
+	if trimmed == "" {
+		return nil, fmt.Errorf("invalid version %q", version)
+	}
+
+	parts := strings.Split(trimmed, ".")
+	numbers := make([]int, len(parts))
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid version %q", version)
+		}
+		numbers[i] = n
+	}
+	return numbers, nil
+}
+
+// versionAtLeast reports whether version >= minVersion, treating missing components as 0
+func versionAtLeast(version, minVersion string) (bool, error) {
+	have, err := ParseVersion(version)
+	if err != nil {
+		return false, err
+	}
+	want, err := ParseVersion(minVersion)
+	if err != nil {
+		return false, err
+	}
+
+	for i := 0; i < len(have) || i < len(want); i++ {
+		var h, w int
+		if i < len(have) {
+			h = have[i]
+		}
+		if i < len(want) {
+			w = want[i]
+		}
+		if h != w {
+			return h > w, nil
+		}
+	}
+	return true, nil
+}
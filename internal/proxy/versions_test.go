@@ -0,0 +1,87 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"hub-api-gateway/internal/config"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+func TestVersionAtLeast(t *testing.T) {
+	tests := []struct {
+		version, minVersion string
+		want                bool
+	}{
+		{"2.3.0", "2.3", true},
+		{"v2.10", "2.9.5", true},
+		{"2.3.1-rc1", "2.3.1", true},
+		{"2.2.9", "2.3", false},
+		{"1.99", "2", false},
+	}
+	for _, tt := range tests {
+		got, err := versionAtLeast(tt.version, tt.minVersion)
+		if err != nil || got != tt.want {
+			t.Errorf("versionAtLeast(%q, %q) = (%v, %v), want %v", tt.version, tt.minVersion, got, err, tt.want)
+		}
+	}
+
+	if _, err := versionAtLeast("latest", "2.3"); err == nil {
+		t.Error("expected error for non-numeric version")
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	registry := NewServiceRegistry(&config.Config{Services: map[string]config.ServiceConfig{
+		"order-service":       {Address: "localhost:50052", MinVersion: "2.3"},
+		"market-data-service": {Address: "localhost:50054"},
+	}})
+	conn, err := grpc.NewClient("passthrough:///localhost:50052", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+	probes := 0
+	reported, probeErr := "2.2.0", error(nil)
+	registry.SetVersionProbe(func(context.Context, *grpc.ClientConn) (string, error) {
+		probes++
+		return reported, probeErr
+	})
+
+	if err := registry.CheckVersion(ctx, "market-data-service", conn); err != nil || probes != 0 {
+		t.Fatalf("unpinned service: err = %v, probes = %d", err, probes)
+	}
+
+	if err := registry.CheckVersion(ctx, "order-service", conn); !errors.Is(err, ErrIncompatibleBackend) {
+		t.Fatalf("old backend: err = %v, want ErrIncompatibleBackend", err)
+	}
+	if err := registry.CheckVersion(ctx, "order-service", conn); !errors.Is(err, ErrIncompatibleBackend) || probes != 1 {
+		t.Fatalf("cached verdict: err = %v, probes = %d", err, probes)
+	}
+	if v := registry.GetBackendVersions()["order-service"]; v.Version != "2.2.0" || v.Compatible {
+		t.Errorf("unexpected detected version: %+v", v)
+	}
+
+	// A new connection (e.g. after a redeploy) is probed again
+	reconnected, _ := grpc.NewClient("passthrough:///localhost:50052", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer reconnected.Close()
+	reported = "2.4.1"
+	if err := registry.CheckVersion(ctx, "order-service", reconnected); err != nil {
+		t.Fatalf("upgraded backend: err = %v", err)
+	}
+
+	// Unreachable backends are not judged
+	other, _ := grpc.NewClient("passthrough:///localhost:50052", grpc.WithTransportCredentials(insecure.NewCredentials()))
+	defer other.Close()
+	probeErr = status.Error(codes.Unavailable, "connection refused")
+	if err := registry.CheckVersion(ctx, "order-service", other); err != nil {
+		t.Errorf("unreachable backend: err = %v, want nil", err)
+	}
+}
@@ -0,0 +1,84 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+)
+
+// WarmUp dials every configured service and waits until each has a READY
+// channel, giving up when ctx is done. It returns the services that couldn't
+// be reached, so startup can report them instead of the first user request
+// discovering them.
+func (r *ServiceRegistry) WarmUp(ctx context.Context) map[string]error {
+	start := time.Now()
+	var mu sync.Mutex
+	unreachable := make(map[string]error)
+
+	var wg sync.WaitGroup
+	for serviceName := range r.config.Services {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := r.warmUp(ctx, serviceName); err != nil {
+				mu.Lock()
+				unreachable[serviceName] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	slog.Info("backend connections warmed up", "services", len(r.config.Services),
+		"unreachable", len(unreachable), "duration_ms", time.Since(start).Milliseconds())
+	return unreachable
+}
+
+// warmUp waits until one of the service's channels is READY
+func (r *ServiceRegistry) warmUp(ctx context.Context, serviceName string) error {
+	pool, err := r.connectionPool(serviceName)
+	if err != nil {
+		return err
+	}
+
+	ready := make(chan struct{}, len(pool.conns))
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	for _, conn := range pool.conns {
+		go func() {
+			if waitReady(ctx, conn) {
+				ready <- struct{}{}
+			}
+		}()
+	}
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("connection to %s is not ready: %s", serviceName, pool.state())
+	}
+}
+
+// waitReady connects a channel and reports whether it became READY before
+// ctx is done
+func waitReady(ctx context.Context, conn *grpc.ClientConn) bool {
+	conn.Connect()
+	for {
+		state := conn.GetState()
+		switch state {
+		case connectivity.Ready:
+			return true
+		case connectivity.Idle, connectivity.TransientFailure:
+			conn.Connect()
+		}
+		if !conn.WaitForStateChange(ctx, state) {
+			return false
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/config"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestWarmUp(t *testing.T) {
+	registry := NewServiceRegistry(&config.Config{Services: map[string]config.ServiceConfig{
+		"order-service":    {},
+		"position-service": {Address: "127.0.0.1:1"},
+	}})
+	defer registry.Close()
+	backend, _ := flakyBackend(t, 0, codes.OK)
+	registry.connections["order-service"] = newConnPool(backend)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	unreachable := registry.WarmUp(ctx)
+
+	if len(unreachable) != 1 || unreachable["position-service"] == nil {
+		t.Fatalf("expected only position-service to be unreachable but got %v", unreachable)
+	}
+	if err := registry.CheckReady("position-service"); err == nil {
+		t.Error("expected readiness to report the unreachable backend")
+	}
+	if err := registry.CheckReady("order-service"); err != nil {
+		t.Errorf("expected the warmed up backend to be ready but got %v", err)
+	}
+}
@@ -0,0 +1,239 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"hub-api-gateway/internal/router"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// bidiStreamDesc describes the calls made for WebSocket routes
+var bidiStreamDesc = &grpc.StreamDesc{ClientStreams: true, ServerStreams: true}
+
+// WebSocketOptions configures WebSocket routes
+type WebSocketOptions struct {
+	PingInterval    time.Duration // How often the client is pinged
+	PongTimeout     time.Duration // Clients silent (not even a pong) for this long are disconnected
+	WriteTimeout    time.Duration // Deadline for each frame sent to the client
+	MaxMessageBytes int64         // Larger client messages close the connection
+	AllowedOrigins  []string      // Browser origins allowed besides the gateway's own ("*" allows any)
+}
+
+// defaultWebSocketOptions apply to fields left zero
+var defaultWebSocketOptions = WebSocketOptions{
+	PingInterval:    30 * time.Second,
+	PongTimeout:     60 * time.Second,
+	WriteTimeout:    10 * time.Second,
+	MaxMessageBytes: 64 << 10,
+}
+
+// EnableWebSockets sets keep-alive, limits and allowed origins of WebSocket routes
+func (h *ProxyHandler) EnableWebSockets(opts WebSocketOptions) {
+	h.webSocket = opts
+}
+
+// webSocketOptions returns the configured options with defaults filled in
+func (h *ProxyHandler) webSocketOptions() WebSocketOptions {
+	opts := h.webSocket
+	if opts.PingInterval <= 0 {
+		opts.PingInterval = defaultWebSocketOptions.PingInterval
+	}
+	if opts.PongTimeout <= 0 {
+		opts.PongTimeout = defaultWebSocketOptions.PongTimeout
+	}
+	if opts.WriteTimeout <= 0 {
+		opts.WriteTimeout = defaultWebSocketOptions.WriteTimeout
+	}
+	if opts.MaxMessageBytes <= 0 {
+		opts.MaxMessageBytes = defaultWebSocketOptions.MaxMessageBytes
+	}
+	return opts
+}
+
+// checkOrigin accepts clients without an Origin (non-browsers), the gateway's
+// own origin and the allowed ones, so other sites can't open connections
+// with a user's credentials
+func (o WebSocketOptions) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range o.AllowedOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// webSocketConn serializes the data frames written to a client
+type webSocketConn struct {
+	*websocket.Conn
+	writeTimeout time.Duration
+	mu           sync.Mutex
+}
+
+// write sends a JSON text frame
+func (c *webSocketConn) write(data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.SetWriteDeadline(time.Now().Add(c.writeTimeout))
+	return c.WriteMessage(websocket.TextMessage, data)
+}
+
+// writeError sends an error frame in the {"error","code"} shape of regular responses
+func (c *webSocketConn) writeError(code, message string) error {
+	body, _ := json.Marshal(map[string]string{"error": message, "code": code})
+	return c.write(body)
+}
+
+// close starts the closing handshake
+func (c *webSocketConn) close(code int, reason string) {
+	c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(c.writeTimeout))
+}
+
+// serveWebSocket upgrades the request and bridges the connection to an opened
+// bidirectional stream: client text frames are bound to request messages,
+// response messages are sent back as JSON text frames. Either side ending
+// closes the other gracefully: a client close half-closes the stream so the
+// backend can finish, a backend end is sent as a close frame (preceded by an
+// error frame on failure). It returns the error the stream ended with, nil for
+// a normal end or a client that went away.
+func (h *ProxyHandler) serveWebSocket(w http.ResponseWriter, r *http.Request, route *router.Route, upstream grpc.ClientStream,
+	cancel context.CancelFunc, bind func(frame []byte) (proto.Message, error), newResponse func() proto.Message) error {
+	opts := h.webSocketOptions()
+	upgrader := websocket.Upgrader{CheckOrigin: opts.checkOrigin}
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already answered the client
+		return err
+	}
+	defer ws.Close()
+	conn := &webSocketConn{Conn: ws, writeTimeout: opts.WriteTimeout}
+
+	ws.SetReadLimit(opts.MaxMessageBytes)
+	ws.SetReadDeadline(time.Now().Add(opts.PongTimeout))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(opts.PongTimeout))
+	})
+
+	clientDone := make(chan error, 1)
+	go func() {
+		clientDone <- forwardWebSocket(conn, upstream, bind, opts.PongTimeout)
+	}()
+	backendDone := make(chan error, 1)
+	go func() {
+		backendDone <- h.relayWebSocket(conn, upstream, route, newResponse, cancel)
+	}()
+
+	ping := time.NewTicker(opts.PingInterval)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-ping.C:
+			if err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(opts.WriteTimeout)); err != nil {
+				return nil
+			}
+		case err := <-clientDone:
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				// The client went away
+				return nil
+			}
+			// Let the backend finish its stream after the client's last message
+			upstream.CloseSend()
+			select {
+			case err := <-backendDone:
+				return err
+			case <-time.After(opts.WriteTimeout):
+				return nil
+			}
+		case err := <-backendDone:
+			h.closeWebSocket(conn, r, err)
+			select {
+			case <-clientDone:
+			case <-time.After(opts.WriteTimeout):
+			}
+			return err
+		}
+	}
+}
+
+// forwardWebSocket sends client messages to the backend until the client
+// closes the connection or goes away. Invalid messages get an error frame.
+func forwardWebSocket(conn *webSocketConn, upstream grpc.ClientStream, bind func([]byte) (proto.Message, error), pongTimeout time.Duration) error {
+	for {
+		_, frame, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		conn.SetReadDeadline(time.Now().Add(pongTimeout))
+
+		request, err := bind(frame)
+		if err != nil {
+			conn.writeError("INVALID_REQUEST", err.Error())
+			continue
+		}
+		// Sends fail once the stream has ended; relayWebSocket reports how
+		upstream.SendMsg(request)
+	}
+}
+
+// relayWebSocket sends backend messages to the client until the stream ends.
+// A client that can't be written to cancels the call.
+func (h *ProxyHandler) relayWebSocket(conn *webSocketConn, upstream grpc.ClientStream, route *router.Route,
+	newResponse func() proto.Message, cancel context.CancelFunc) error {
+	for {
+		msg := newResponse()
+		if err := upstream.RecvMsg(msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		encoded, err := h.encodeJSON(msg, route)
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to encode stream message: %v", err)
+		}
+		if err := conn.write(encoded); err != nil {
+			cancel()
+			return nil
+		}
+	}
+}
+
+// closeWebSocket closes the connection once the backend ended the stream.
+// Failures are sent as an error frame first; client errors close with policy
+// violation (1008), backend errors with internal error (1011), and the
+// request deadline with going away (1001) so clients reconnect.
+func (h *ProxyHandler) closeWebSocket(conn *webSocketConn, r *http.Request, err error) {
+	switch {
+	case err == nil:
+		conn.close(websocket.CloseNormalClosure, "")
+	case r.Context().Err() != nil:
+		conn.close(websocket.CloseGoingAway, "session time limit reached")
+	default:
+		st := status.Convert(err)
+		mapping := h.grpcErrorMapping(st.Code())
+		conn.writeError(mapping.Code, st.Message())
+		if mapping.Status < http.StatusInternalServerError {
+			conn.close(websocket.ClosePolicyViolation, mapping.Code)
+		} else {
+			conn.close(websocket.CloseInternalServerErr, mapping.Code)
+		}
+	}
+}
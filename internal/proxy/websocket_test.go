@@ -0,0 +1,164 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/router"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// echoBackend echoes every message of a bidi stream until the client
+// half-closes it, or fails with end after the first message when set
+func echoBackend(t *testing.T, end error) *grpc.ClientConn {
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+		for {
+			msg := &structpb.Struct{}
+			if err := stream.RecvMsg(msg); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+			if end != nil {
+				return end
+			}
+			echo, _ := structpb.NewStruct(map[string]interface{}{"echo": msg.AsMap()["order_id"]})
+			if err := stream.SendMsg(echo); err != nil {
+				return err
+			}
+		}
+	}))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// dialOrderUpdates serves an order updates WebSocket route bridged to conn and
+// dials it; the server's result is sent on the returned channel
+func dialOrderUpdates(t *testing.T, conn *grpc.ClientConn) (*websocket.Conn, <-chan error) {
+	h := NewProxyHandler(nil, metrics.NewMetrics(), nil)
+	route := &router.Route{Name: "order-updates", Stream: router.StreamWebSocket}
+	bind := func(frame []byte) (proto.Message, error) {
+		msg := &structpb.Struct{}
+		return msg, protojson.Unmarshal(frame, msg)
+	}
+
+	served := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		upstream, err := conn.NewStream(ctx, bidiStreamDesc, "/order.OrderService/OrderUpdates")
+		if err != nil {
+			served <- err
+			return
+		}
+		served <- h.serveWebSocket(w, r, route, upstream, cancel, bind,
+			func() proto.Message { return &structpb.Struct{} })
+	}))
+	t.Cleanup(server.Close)
+
+	client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	return client, served
+}
+
+func readFrame(t *testing.T, client *websocket.Conn) map[string]interface{} {
+	t.Helper()
+	var frame map[string]interface{}
+	if err := client.ReadJSON(&frame); err != nil {
+		t.Fatalf("ReadJSON() error = %v", err)
+	}
+	return frame
+}
+
+func TestServeWebSocket_ClientClose(t *testing.T) {
+	client, served := dialOrderUpdates(t, echoBackend(t, nil))
+
+	client.WriteMessage(websocket.TextMessage, []byte(`{"order_id": "o-1"}`))
+	if frame := readFrame(t, client); frame["echo"] != "o-1" {
+		t.Errorf("frame = %v, want the echoed order", frame)
+	}
+
+	client.WriteMessage(websocket.TextMessage, []byte(`not json`))
+	if frame := readFrame(t, client); frame["code"] != "INVALID_REQUEST" {
+		t.Errorf("frame = %v, want an INVALID_REQUEST error", frame)
+	}
+
+	// The bridge survives invalid messages
+	client.WriteMessage(websocket.TextMessage, []byte(`{"order_id": "o-2"}`))
+	if frame := readFrame(t, client); frame["echo"] != "o-2" {
+		t.Errorf("frame = %v, want the echoed order", frame)
+	}
+
+	client.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+	if err := <-served; err != nil {
+		t.Errorf("serveWebSocket() error = %v, want a clean end after the client closed", err)
+	}
+}
+
+func TestServeWebSocket_BackendError(t *testing.T) {
+	client, served := dialOrderUpdates(t, echoBackend(t, status.Error(codes.PermissionDenied, "not your order")))
+
+	client.WriteMessage(websocket.TextMessage, []byte(`{"order_id": "o-1"}`))
+	if frame := readFrame(t, client); frame["error"] != "not your order" || frame["code"] == "" {
+		t.Errorf("frame = %v, want the backend error", frame)
+	}
+
+	_, _, err := client.ReadMessage()
+	var closeErr *websocket.CloseError
+	if !errors.As(err, &closeErr) || closeErr.Code != websocket.ClosePolicyViolation {
+		t.Errorf("ReadMessage() error = %v, want a policy violation close", err)
+	}
+	if err := <-served; status.Code(err) != codes.PermissionDenied {
+		t.Errorf("serveWebSocket() error = %v, want PermissionDenied", err)
+	}
+}
+
+func TestWebSocketCheckOrigin(t *testing.T) {
+	opts := WebSocketOptions{AllowedOrigins: []string{"https://app.example.com"}}
+	for origin, want := range map[string]bool{
+		"":                         true, // Not a browser
+		"https://gateway.example":  true, // Same origin
+		"https://app.example.com":  true,
+		"https://evil.example.com": false,
+	} {
+		r := httptest.NewRequest("GET", "https://gateway.example/api/v1/orders/updates", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+		if got := opts.checkOrigin(r); got != want {
+			t.Errorf("checkOrigin(%q) = %v, want %v", origin, got, want)
+		}
+	}
+}
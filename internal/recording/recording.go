@@ -0,0 +1,211 @@
+// Package recording captures sanitized request/response pairs of selected
+// routes, so they can be replayed against another environment (see
+// cmd/replay) to check that a migrated backend answers like the original.
+//
+// Routes opt in with `record: true`. Credentials are dropped from headers and
+// query parameters and sensitive JSON fields are redacted before anything is
+// stored; replays supply their own credentials.
+package recording
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"hub-api-gateway/internal/respwriter"
+	"hub-api-gateway/internal/router"
+)
+
+// Redacted replaces the values of sensitive body fields
+const Redacted = "[REDACTED]"
+
+// sensitiveHeaders are never recorded
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+	"X-Admin-Token":       true,
+	"X-Reconnect-Ticket":  true,
+	"X-Gateway-Trace":     true,
+	"Proxy-Authorization": true,
+}
+
+// sensitiveQuery are query parameters that carry credentials
+var sensitiveQuery = map[string]bool{
+	"ticket":       true,
+	"token":        true,
+	"access_token": true,
+	"api_key":      true,
+}
+
+// Exchange is one recorded request and the response the gateway gave
+type Exchange struct {
+	ID             string            `json:"id"`
+	Route          string            `json:"route"`
+	RecordedAt     time.Time         `json:"recordedAt"`
+	Method         string            `json:"method"`
+	Path           string            `json:"path"`
+	Query          string            `json:"query,omitempty"`
+	Headers        map[string]string `json:"headers,omitempty"`
+	Body           json.RawMessage   `json:"body,omitempty"`
+	Status         int               `json:"status"`
+	ResponseHeader map[string]string `json:"responseHeaders,omitempty"`
+	ResponseBody   json.RawMessage   `json:"responseBody,omitempty"`
+	DurationMs     float64           `json:"durationMs"`
+	Truncated      bool              `json:"truncated,omitempty"` // A body was too large or not JSON and wasn't recorded
+}
+
+// Recorder records the exchanges of routes with record enabled
+type Recorder struct {
+	store        Store
+	percent      float64
+	maxBodyBytes int
+	redact       map[string]bool
+}
+
+// NewRecorder creates a recorder storing a percentage of exchanges, with
+// bodies up to maxBodyBytes and the given JSON fields redacted
+func NewRecorder(store Store, percent float64, maxBodyBytes int, redactFields []string) *Recorder {
+	redact := make(map[string]bool, len(redactFields))
+	for _, field := range redactFields {
+		redact[strings.ToLower(field)] = true
+	}
+	return &Recorder{
+		store:        store,
+		percent:      percent,
+		maxBodyBytes: maxBodyBytes,
+		redact:       redact,
+	}
+}
+
+// Middleware records sampled exchanges of the route. Streaming routes aren't
+// recorded.
+func (rec *Recorder) Middleware(route *router.Route, next http.Handler) http.Handler {
+	if !route.Record || route.Stream != "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rand.Float64()*100 >= rec.percent {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		exchange := &Exchange{
+			ID:         newID(),
+			Route:      route.Name,
+			RecordedAt: time.Now(),
+			Method:     r.Method,
+			Path:       r.URL.Path,
+			Query:      sanitizeQuery(r.URL.Query()),
+			Headers:    sanitizeHeaders(r.Header),
+		}
+
+		if r.Body != nil && r.Body != http.NoBody {
+			body, err := io.ReadAll(r.Body)
+			r.Body.Close()
+			if err != nil {
+				http.Error(w, "failed to read request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+			exchange.Body, exchange.Truncated = rec.sanitizeBody(body)
+		}
+
+		capture := respwriter.New(w)
+		capture.CaptureBody(rec.maxBodyBytes)
+		next.ServeHTTP(capture, r)
+
+		exchange.Status = capture.Status()
+		exchange.DurationMs = float64(time.Since(exchange.RecordedAt).Microseconds()) / 1000
+		exchange.ResponseHeader = sanitizeHeaders(w.Header())
+		truncated := true
+		if body, complete := capture.Body(); complete {
+			exchange.ResponseBody, truncated = rec.sanitizeBody(body)
+		}
+		exchange.Truncated = exchange.Truncated || truncated
+
+		// The request context may already be cancelled; storing must not depend on it
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := rec.store.Append(ctx, exchange); err != nil {
+			slog.WarnContext(r.Context(), "failed to record exchange", "route", route.Name, "error", err)
+		}
+	})
+}
+
+// sanitizeBody returns a JSON body with sensitive fields redacted; bodies too
+// large or not JSON aren't kept
+func (rec *Recorder) sanitizeBody(body []byte) (json.RawMessage, bool) {
+	if len(body) == 0 {
+		return nil, false
+	}
+	if len(body) > rec.maxBodyBytes {
+		return nil, true
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil, true
+	}
+	sanitized, err := json.Marshal(rec.redactValue(value))
+	if err != nil {
+		return nil, true
+	}
+	return sanitized, false
+}
+
+// redactValue replaces the values of sensitive fields at any depth
+func (rec *Recorder) redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if rec.redact[strings.ToLower(key)] {
+				v[key] = Redacted
+				continue
+			}
+			v[key] = rec.redactValue(field)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = rec.redactValue(item)
+		}
+	}
+	return value
+}
+
+// sanitizeHeaders copies headers without credentials or hop-by-hop noise
+func sanitizeHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			continue
+		}
+		headers[name] = strings.Join(values, ", ")
+	}
+	return headers
+}
+
+// sanitizeQuery encodes the query parameters without credentials
+func sanitizeQuery(values url.Values) string {
+	for name := range values {
+		if sensitiveQuery[strings.ToLower(name)] {
+			values.Del(name)
+		}
+	}
+	return values.Encode()
+}
+
+// newID generates a random exchange ID
+func newID() string {
+	return fmt.Sprintf("%016x", rand.Uint64())
+}
@@ -0,0 +1,59 @@
+package recording
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"hub-api-gateway/internal/router"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "recordings.jsonl"))
+	recorder := NewRecorder(store, 100, 1024, []string{"password"})
+	route := &router.Route{Name: "create-order", Record: true}
+
+	handler := recorder.Middleware(route, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"order_id":"42","status":"PENDING","created_at":"2026-01-01"}`))
+	}))
+	req := httptest.NewRequest("POST", "/api/v1/orders?token=abc&source=app", strings.NewReader(`{"symbol":"PETR4","password":"hunter2"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("Content-Type", "application/json")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	exchanges, err := store.Load(context.Background())
+	if err != nil || len(exchanges) != 1 {
+		t.Fatalf("expected one recorded exchange but got %d: %v", len(exchanges), err)
+	}
+	exchange := exchanges[0]
+	if _, ok := exchange.Headers["Authorization"]; ok || exchange.Query != "source=app" {
+		t.Errorf("expected credentials to be dropped but got %v %q", exchange.Headers, exchange.Query)
+	}
+	if string(exchange.Body) != `{"password":"[REDACTED]","symbol":"PETR4"}` || exchange.Status != http.StatusCreated {
+		t.Errorf("unexpected recorded request %s -> %d", exchange.Body, exchange.Status)
+	}
+
+	// The target answers with a different status and timestamp
+	var authorization string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"order_id":"42","status":"FILLED","created_at":"2026-02-02"}`))
+	}))
+	defer target.Close()
+
+	header := http.Header{"Authorization": {"Bearer replay"}}
+	status, body, err := Replay(context.Background(), target.Client(), target.URL, exchange, header)
+	if err != nil || authorization != "Bearer replay" {
+		t.Fatalf("replay failed (%v) or sent %q", err, authorization)
+	}
+	diffs := Compare(exchange, status, body, []string{"created_at"})
+	if len(diffs) != 1 || diffs[0] != `$.status: recorded "PENDING", got "FILLED"` {
+		t.Errorf("unexpected diffs %v", diffs)
+	}
+}
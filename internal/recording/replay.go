@@ -0,0 +1,144 @@
+package recording
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// maxDiffs caps the differences reported per exchange
+const maxDiffs = 20
+
+// Replay re-sends a recorded request to the target base URL with the extra
+// headers (e.g. the replay's own Authorization) and returns the response
+func Replay(ctx context.Context, client *http.Client, target string, exchange Exchange, header http.Header) (int, []byte, error) {
+	url := strings.TrimSuffix(target, "/") + exchange.Path
+	if exchange.Query != "" {
+		url += "?" + exchange.Query
+	}
+
+	var body io.Reader
+	if len(exchange.Body) > 0 {
+		body = bytes.NewReader(exchange.Body)
+	}
+	req, err := http.NewRequestWithContext(ctx, exchange.Method, url, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	for name, value := range exchange.Headers {
+		// Let the client negotiate its own framing and encoding
+		switch http.CanonicalHeaderKey(name) {
+		case "Content-Length", "Accept-Encoding", "Connection", "X-Request-Id":
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	return resp.StatusCode, respBody, err
+}
+
+// Compare lists how a replayed response differs from the recorded one.
+// Fields named in ignore (at any depth) and fields redacted when recording
+// aren't compared; neither are bodies that weren't recorded.
+func Compare(exchange Exchange, status int, body []byte, ignore []string) []string {
+	var diffs []string
+	if status != exchange.Status {
+		diffs = append(diffs, fmt.Sprintf("status: recorded %d, got %d", exchange.Status, status))
+	}
+	if len(exchange.ResponseBody) == 0 {
+		return diffs
+	}
+
+	var recorded, replayed interface{}
+	if err := json.Unmarshal(exchange.ResponseBody, &recorded); err != nil {
+		return append(diffs, fmt.Sprintf("recorded body isn't JSON: %v", err))
+	}
+	if err := json.Unmarshal(body, &replayed); err != nil {
+		return append(diffs, fmt.Sprintf("body isn't JSON: %v", err))
+	}
+
+	skip := make(map[string]bool, len(ignore))
+	for _, field := range ignore {
+		skip[strings.ToLower(field)] = true
+	}
+	return compareValues("$", recorded, replayed, skip, diffs)
+}
+
+// compareValues walks both JSON values and appends their differences
+func compareValues(path string, recorded, replayed interface{}, skip map[string]bool, diffs []string) []string {
+	if len(diffs) >= maxDiffs || recorded == Redacted {
+		return diffs
+	}
+
+	switch r := recorded.(type) {
+	case map[string]interface{}:
+		got, ok := replayed.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := make([]string, 0, len(r)+len(got))
+		for key := range r {
+			keys = append(keys, key)
+		}
+		for key := range got {
+			if _, ok := r[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if skip[strings.ToLower(key)] {
+				continue
+			}
+			recordedField, inRecorded := r[key]
+			replayedField, inReplayed := got[key]
+			switch {
+			case !inReplayed:
+				diffs = append(diffs, fmt.Sprintf("%s.%s: missing", path, key))
+			case !inRecorded:
+				diffs = append(diffs, fmt.Sprintf("%s.%s: unexpected", path, key))
+			default:
+				diffs = compareValues(path+"."+key, recordedField, replayedField, skip, diffs)
+			}
+		}
+		return diffs
+	case []interface{}:
+		got, ok := replayed.([]interface{})
+		if !ok {
+			break
+		}
+		if len(r) != len(got) {
+			return append(diffs, fmt.Sprintf("%s: recorded %d items, got %d", path, len(r), len(got)))
+		}
+		for i := range r {
+			diffs = compareValues(fmt.Sprintf("%s[%d]", path, i), r[i], got[i], skip, diffs)
+		}
+		return diffs
+	}
+
+	if !reflect.DeepEqual(recorded, replayed) {
+		diffs = append(diffs, fmt.Sprintf("%s: recorded %s, got %s", path, encode(recorded), encode(replayed)))
+	}
+	return diffs
+}
+
+// encode renders a JSON value for a diff
+func encode(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
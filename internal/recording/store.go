@@ -0,0 +1,117 @@
+package recording
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Store keeps recorded exchanges in the order they were recorded
+type Store interface {
+	Append(ctx context.Context, exchange *Exchange) error
+	Load(ctx context.Context) ([]Exchange, error)
+}
+
+// FileStore appends exchanges to a JSON Lines file
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileStore creates a store appending to the file at path
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+// Append writes the exchange as one line
+func (s *FileStore) Append(_ context.Context, exchange *Exchange) error {
+	data, err := json.Marshal(exchange)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Load reads every exchange in the file
+func (s *FileStore) Load(_ context.Context) ([]Exchange, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var exchanges []Exchange
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64<<10), 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var exchange Exchange
+		if err := json.Unmarshal(scanner.Bytes(), &exchange); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", s.path, line, err)
+		}
+		exchanges = append(exchanges, exchange)
+	}
+	return exchanges, scanner.Err()
+}
+
+// RedisStore keeps the most recent exchanges in a Redis list, shared by all
+// gateway instances
+type RedisStore struct {
+	client     *redis.Client
+	key        string
+	maxEntries int64
+}
+
+// NewRedisStore creates a store keeping up to maxEntries exchanges under key
+func NewRedisStore(client *redis.Client, key string, maxEntries int) *RedisStore {
+	return &RedisStore{client: client, key: key, maxEntries: int64(maxEntries)}
+}
+
+// Append pushes the exchange, dropping the oldest beyond the limit
+func (s *RedisStore) Append(ctx context.Context, exchange *Exchange) error {
+	data, err := json.Marshal(exchange)
+	if err != nil {
+		return err
+	}
+	pipe := s.client.TxPipeline()
+	pipe.RPush(ctx, s.key, data)
+	pipe.LTrim(ctx, s.key, -s.maxEntries, -1)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// Load reads every exchange in the list
+func (s *RedisStore) Load(ctx context.Context) ([]Exchange, error) {
+	values, err := s.client.LRange(ctx, s.key, 0, -1).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	exchanges := make([]Exchange, 0, len(values))
+	for _, value := range values {
+		var exchange Exchange
+		if err := json.Unmarshal([]byte(value), &exchange); err != nil {
+			return nil, err
+		}
+		exchanges = append(exchanges, exchange)
+	}
+	return exchanges, nil
+}
@@ -0,0 +1,131 @@
+// Package respwriter observes HTTP responses as middleware writes them,
+// without hiding the optional interfaces of the writer underneath: flushing
+// for streams, hijacking for WebSocket upgrades and http.ResponseController.
+package respwriter
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"net/http"
+)
+
+// Recorder passes a response through, capturing its status and, when asked,
+// a copy of its body
+type Recorder struct {
+	http.ResponseWriter
+	status   int
+	hijacked bool
+	onHeader func(status int)
+	onHijack func()
+
+	body     *bytes.Buffer // nil unless CaptureBody was called
+	limit    int
+	overflow bool
+}
+
+// New wraps w
+func New(w http.ResponseWriter) *Recorder {
+	return &Recorder{ResponseWriter: w}
+}
+
+// CaptureBody keeps a copy of the body while it is at most limit bytes
+func (r *Recorder) CaptureBody(limit int) {
+	r.body, r.limit = &bytes.Buffer{}, limit
+}
+
+// OnHeader runs fn once, just before the status and headers are sent, so fn
+// can still change the headers
+func (r *Recorder) OnHeader(fn func(status int)) {
+	r.onHeader = fn
+}
+
+// OnHijack runs fn once the connection is taken over, e.g. by a WebSocket
+// upgrade; the response is over as far as HTTP middleware is concerned
+func (r *Recorder) OnHijack(fn func()) {
+	r.onHijack = fn
+}
+
+// record notes the status the first time headers are sent
+func (r *Recorder) record(status int) {
+	if r.status != 0 {
+		return
+	}
+	r.status = status
+	if r.onHeader != nil {
+		r.onHeader(status)
+	}
+}
+
+// WriteHeader records the first status written
+func (r *Recorder) WriteHeader(status int) {
+	r.record(status)
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Write records an implicit 200 and copies the body when capturing
+func (r *Recorder) Write(b []byte) (int, error) {
+	r.record(http.StatusOK)
+	if r.body != nil && !r.overflow {
+		if r.body.Len()+len(b) > r.limit {
+			r.overflow = true
+			r.body.Reset()
+		} else {
+			r.body.Write(b)
+		}
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Flush supports streaming responses
+func (r *Recorder) Flush() {
+	r.record(http.StatusOK)
+	http.NewResponseController(r.ResponseWriter).Flush()
+}
+
+// Hijack supports WebSocket upgrades
+func (r *Recorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(r.ResponseWriter).Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	r.record(http.StatusSwitchingProtocols)
+	r.hijacked = true
+	if r.onHijack != nil {
+		r.onHijack()
+	}
+	return conn, rw, nil
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (r *Recorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Status returns the status sent to the client: 200 when the handler wrote
+// nothing, as net/http sends then
+func (r *Recorder) Status() int {
+	if r.status == 0 {
+		return http.StatusOK
+	}
+	return r.status
+}
+
+// Written reports whether the handler sent anything
+func (r *Recorder) Written() bool {
+	return r.status != 0
+}
+
+// Hijacked reports whether the connection was taken over
+func (r *Recorder) Hijacked() bool {
+	return r.hijacked
+}
+
+// Body returns the captured body and whether it is complete: false when it
+// outgrew the capture limit
+func (r *Recorder) Body() ([]byte, bool) {
+	if r.body == nil {
+		return nil, false
+	}
+	return r.body.Bytes(), !r.overflow
+}
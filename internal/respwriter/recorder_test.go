@@ -0,0 +1,52 @@
+package respwriter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestRecorder(t *testing.T) {
+	// The body copy stops at the limit without cutting the response short
+	w := httptest.NewRecorder()
+	recorder := New(w)
+	recorder.CaptureBody(4)
+	recorder.Write([]byte("abc"))
+	if body, complete := recorder.Body(); !complete || string(body) != "abc" {
+		t.Errorf("expected the complete body but got %q (complete=%v)", body, complete)
+	}
+	recorder.Write([]byte("de"))
+	if _, complete := recorder.Body(); complete {
+		t.Error("expected the body to be incomplete past the limit")
+	}
+	if w.Body.String() != "abcde" || recorder.Status() != http.StatusOK {
+		t.Errorf("expected the response to pass through but got %d %q", recorder.Status(), w.Body.String())
+	}
+
+	// WebSocket upgrades reach the connection underneath
+	upgraded := make(chan *Recorder, 1)
+	var upgrader websocket.Upgrader
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := New(w)
+		conn, err := upgrader.Upgrade(recorder, r, nil)
+		if err != nil {
+			t.Errorf("expected the upgrade to succeed: %v", err)
+			return
+		}
+		defer conn.Close()
+		upgraded <- recorder
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("expected the dial to succeed: %v", err)
+	}
+	defer conn.Close()
+	if recorder := <-upgraded; !recorder.Hijacked() || recorder.Status() != http.StatusSwitchingProtocols {
+		t.Errorf("expected a hijacked 101 but got %d", recorder.Status())
+	}
+}
@@ -0,0 +1,105 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RouteTypeAggregate routes call several RPCs concurrently and merge their
+// responses into one JSON object, e.g. a mobile dashboard
+const RouteTypeAggregate = "aggregate"
+
+// AggregateErrorsField holds the errors of failed optional branches
+const AggregateErrorsField = "errors"
+
+// AggregateBranch is one RPC of an aggregate route. Its response is placed
+// under Name in the merged response.
+type AggregateBranch struct {
+	Name         string   `yaml:"name" json:"name"`
+	Service      string   `yaml:"service" json:"service"`
+	GRPCService  string   `yaml:"grpc_service" json:"grpc_service"`
+	GRPCMethod   string   `yaml:"grpc_method" json:"grpc_method"`
+	Timeout      string   `yaml:"timeout,omitempty" json:"timeout,omitempty"`             // Defaults to the route's, then the service's timeout
+	Required     bool     `yaml:"required,omitempty" json:"required,omitempty"`           // Fail the whole response when this branch fails
+	StringFields []string `yaml:"string_fields,omitempty" json:"string_fields,omitempty"` // As the route's string_fields, within this branch
+}
+
+// IsAggregate reports whether the route fans out to branches
+func (r *Route) IsAggregate() bool {
+	return r.Type == RouteTypeAggregate
+}
+
+// Targets returns the routes actually sent to backends: the route itself,
+// one route per upstream, or one route per branch of an aggregate route.
+// Branch routes are named <route>.<branch> and keep the route's auth, tags
+// and header rules. Tenant overrides naming another backend and feature
+// flag variants add their targets.
+func (r *Route) Targets() []Route {
+	targets := append(r.ownTargets(), r.tenantTargets()...)
+	return append(targets, r.variantTargets()...)
+}
+
+// ownTargets returns the targets of the route itself, without overrides
+func (r *Route) ownTargets() []Route {
+	if len(r.Upstreams) > 0 {
+		targets := make([]Route, len(r.Upstreams))
+		for i, upstream := range r.Upstreams {
+			targets[i] = r.upstreamRoute(upstream)
+		}
+		return targets
+	}
+	if !r.IsAggregate() {
+		return []Route{*r}
+	}
+
+	targets := make([]Route, len(r.Branches))
+	for i, branch := range r.Branches {
+		target := *r
+		target.Type = ""
+		target.Branches = nil
+		target.Name = r.Name + "." + branch.Name
+		target.Service = branch.Service
+		target.GRPCService = branch.GRPCService
+		target.GRPCMethod = branch.GRPCMethod
+		if branch.Timeout != "" {
+			target.Timeout = branch.Timeout
+		}
+		target.StringFields = branch.StringFields
+		targets[i] = target
+	}
+	return targets
+}
+
+// validateAggregate checks an aggregate route's branches
+func (r *Route) validateAggregate() error {
+	if r.Method != "" && !strings.EqualFold(r.Method, http.MethodGet) {
+		return fmt.Errorf("route %s: aggregate routes only support GET", r.Name)
+	}
+	if r.Stream != "" || r.LongPollField != "" || r.MaxStale != "" || r.Cache != nil || r.CircuitBreaker != nil || len(r.Upstreams) > 0 {
+		return fmt.Errorf("route %s: aggregate routes can't use stream, long_poll_field, max_stale, cache, circuit_breaker or upstreams", r.Name)
+	}
+	if len(r.Branches) < 2 {
+		return fmt.Errorf("route %s: aggregate routes need at least two branches", r.Name)
+	}
+
+	seen := make(map[string]bool, len(r.Branches))
+	for _, branch := range r.Branches {
+		switch {
+		case branch.Name == "" || branch.Name == AggregateErrorsField:
+			return fmt.Errorf("route %s: branch name is required and can't be %q", r.Name, AggregateErrorsField)
+		case seen[branch.Name]:
+			return fmt.Errorf("route %s: duplicate branch %s", r.Name, branch.Name)
+		case branch.Service == "" || branch.GRPCService == "" || branch.GRPCMethod == "":
+			return fmt.Errorf("route %s: branch %s needs service, grpc_service and grpc_method", r.Name, branch.Name)
+		}
+		seen[branch.Name] = true
+		if branch.Timeout != "" {
+			if timeout, err := time.ParseDuration(branch.Timeout); err != nil || timeout <= 0 {
+				return fmt.Errorf("route %s: branch %s timeout must be a positive duration, got %q", r.Name, branch.Name, branch.Timeout)
+			}
+		}
+	}
+	return nil
+}
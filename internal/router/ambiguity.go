@@ -0,0 +1,81 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ambiguity is a pair of routes that can match the same request with equal
+// priority and specificity, so which one wins depends only on file order
+type Ambiguity struct {
+	First  string
+	Second string
+	Method string
+	Path   string // Example of the overlap: the first route's path
+}
+
+// Error describes the ambiguity
+func (a Ambiguity) Error() string {
+	method := a.Method
+	if method == "" {
+		method = "*"
+	}
+	return fmt.Sprintf("routes %s and %s both match %s %s with equal priority (set priority on one of them)",
+		a.First, a.Second, method, a.Path)
+}
+
+// DetectAmbiguities reports every pair of routes with overlapping methods and
+// path patterns whose priority and specificity are equal
+func DetectAmbiguities(routes []Route) []Ambiguity {
+	var ambiguities []Ambiguity
+
+	for i := range routes {
+		for j := i + 1; j < len(routes); j++ {
+			a, b := &routes[i], &routes[j]
+			if a.Priority != b.Priority || specificity(a) != specificity(b) {
+				continue
+			}
+			if !methodsOverlap(a.Method, b.Method) || !patternsOverlap(splitPattern(a.Path), splitPattern(b.Path)) || predicatesDisjoint(a, b) {
+				continue
+			}
+
+			method := a.Method
+			if method == "" {
+				method = b.Method
+			}
+			ambiguities = append(ambiguities, Ambiguity{First: a.Name, Second: b.Name, Method: strings.ToUpper(method), Path: a.Path})
+		}
+	}
+
+	return ambiguities
+}
+
+// methodsOverlap returns whether two route methods can match the same request
+// (an empty method matches any)
+func methodsOverlap(a, b string) bool {
+	return a == "" || b == "" || strings.EqualFold(a, b)
+}
+
+// splitPattern splits a path pattern into segments
+func splitPattern(path string) []string {
+	return strings.Split(strings.Trim(path, "/"), "/")
+}
+
+// patternsOverlap returns whether some path matches both segment patterns.
+// Segments containing {vars} match any single segment and segments containing
+// * match any remainder, which errs on the side of reporting an overlap.
+func patternsOverlap(a, b []string) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return len(a) == len(b) || (len(a) > 0 && strings.Contains(a[0], "*")) || (len(b) > 0 && strings.Contains(b[0], "*"))
+	}
+
+	if strings.Contains(a[0], "*") || strings.Contains(b[0], "*") {
+		return true
+	}
+
+	if !strings.Contains(a[0], "{") && !strings.Contains(b[0], "{") && a[0] != b[0] {
+		return false
+	}
+
+	return patternsOverlap(a[1:], b[1:])
+}
@@ -0,0 +1,66 @@
+package router
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+// RouteFault injects failures into a share of a route's backend calls, to
+// rehearse retries and circuit breaking. Only honoured when fault injection
+// is enabled (never in production).
+type RouteFault struct {
+	Percent float64 `yaml:"percent" json:"percent"`                   // Share of backend calls affected, 0-100
+	Delay   string  `yaml:"delay,omitempty" json:"delay,omitempty"`   // Added before the call, e.g. 500ms
+	Jitter  string  `yaml:"jitter,omitempty" json:"jitter,omitempty"` // Random extra delay up to this long
+	Code    string  `yaml:"code,omitempty" json:"code,omitempty"`     // Aborts the call with this gRPC code, e.g. UNAVAILABLE
+}
+
+// DelayDuration returns the parsed fixed delay
+func (f *RouteFault) DelayDuration() time.Duration {
+	delay, _ := time.ParseDuration(f.Delay)
+	return delay
+}
+
+// JitterDuration returns the parsed delay jitter
+func (f *RouteFault) JitterDuration() time.Duration {
+	jitter, _ := time.ParseDuration(f.Jitter)
+	return jitter
+}
+
+// GRPCCode returns the code calls are aborted with, by its Go or canonical
+// name ("DeadlineExceeded" or "DEADLINE_EXCEEDED"); false when calls aren't
+// aborted
+func (f *RouteFault) GRPCCode() (codes.Code, bool) {
+	normalized := strings.ToLower(strings.ReplaceAll(f.Code, "_", ""))
+	for code := codes.Canceled; code <= codes.Unauthenticated; code++ {
+		if strings.ToLower(code.String()) == normalized {
+			return code, true
+		}
+	}
+	return codes.OK, false
+}
+
+// Validate checks the fault's share, delays and code
+func (f *RouteFault) Validate() error {
+	if f.Percent < 0 || f.Percent > 100 {
+		return fmt.Errorf("fault.percent must be in [0, 100], got %v", f.Percent)
+	}
+	for field, value := range map[string]string{"delay": f.Delay, "jitter": f.Jitter} {
+		if value == "" {
+			continue
+		}
+		if duration, err := time.ParseDuration(value); err != nil || duration < 0 {
+			return fmt.Errorf("fault.%s must be a non-negative duration, got %q", field, value)
+		}
+	}
+	if _, ok := f.GRPCCode(); f.Code != "" && !ok {
+		return fmt.Errorf("fault.code must be a non-OK gRPC code, got %q", f.Code)
+	}
+	if f.Delay == "" && f.Jitter == "" && f.Code == "" {
+		return fmt.Errorf("fault needs a delay, jitter or code")
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package router
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// RouteFeatureFlag gates a route behind a feature flag: users the flag is
+// off for get 404 as if the route didn't exist. Other flag values may name a
+// variant served to their users instead. Flags are evaluated per user, so
+// gated routes require authentication.
+type RouteFeatureFlag struct {
+	Name     string                   `yaml:"name" json:"name"`
+	Variants map[string]*RouteVariant `yaml:"variants,omitempty" json:"variants,omitempty"` // Flag value -> variant
+}
+
+// RouteVariant overrides a route's backend for the users of one flag value.
+// Unset fields keep the route's.
+type RouteVariant struct {
+	Service     string `yaml:"service,omitempty" json:"service,omitempty"`
+	GRPCService string `yaml:"grpc_service,omitempty" json:"grpc_service,omitempty"`
+	GRPCMethod  string `yaml:"grpc_method,omitempty" json:"grpc_method,omitempty"`
+}
+
+// FlagOff is the value of feature flags that are off
+const FlagOff = "false"
+
+// ForVariant returns the route as served to users the route's flag has value
+// for. A service override replaces the route's upstreams; values without a
+// variant get the route as is.
+func (r *Route) ForVariant(value string) *Route {
+	if r.FeatureFlag == nil {
+		return r
+	}
+	variant, ok := r.FeatureFlag.Variants[value]
+	if !ok || variant == nil {
+		return r
+	}
+
+	target := *r
+	if variant.Service != "" {
+		target.Service = variant.Service
+		target.Upstreams = nil
+	}
+	if variant.GRPCService != "" {
+		target.GRPCService = variant.GRPCService
+	}
+	if variant.GRPCMethod != "" {
+		target.GRPCMethod = variant.GRPCMethod
+	}
+	return &target
+}
+
+// variantTargets returns the targets of the feature flag's variants, in
+// value order
+func (r *Route) variantTargets() []Route {
+	if r.FeatureFlag == nil {
+		return nil
+	}
+	var targets []Route
+	for _, value := range slices.Sorted(maps.Keys(r.FeatureFlag.Variants)) {
+		if variant := r.ForVariant(value); variant != r {
+			targets = append(targets, variant.ownTargets()...)
+		}
+	}
+	return targets
+}
+
+// validateFeatureFlag checks the route's feature flag and variants
+func (r *Route) validateFeatureFlag() error {
+	if r.FeatureFlag == nil {
+		return nil
+	}
+	if r.FeatureFlag.Name == "" {
+		return fmt.Errorf("route %s: feature_flag.name is required", r.Name)
+	}
+	if !r.AuthRequired {
+		return fmt.Errorf("route %s: feature_flag requires auth_required", r.Name)
+	}
+	if len(r.FeatureFlag.Variants) > 0 && r.IsAggregate() {
+		return fmt.Errorf("route %s: feature_flag variants are not supported on aggregate routes", r.Name)
+	}
+	for value, variant := range r.FeatureFlag.Variants {
+		if value == "" || value == FlagOff {
+			return fmt.Errorf("route %s: feature_flag variant %q can't be served, the route is off for it", r.Name, value)
+		}
+		if variant == nil || (variant.Service == "" && variant.GRPCService == "" && variant.GRPCMethod == "") {
+			return fmt.Errorf("route %s: feature_flag variant %s overrides nothing", r.Name, value)
+		}
+		if len(r.Upstreams) > 0 && variant.Service == "" {
+			return fmt.Errorf("route %s: feature_flag variant %s overrides the RPC of a route with upstreams without a service", r.Name, value)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+)
+
+// HeaderRules transforms the headers of a route's requests or responses.
+// Removals apply first, then sets, then adds. Values may be templates over
+// HeaderVars, e.g. "{{.UserID}}".
+type HeaderRules struct {
+	Add    map[string]string `yaml:"add,omitempty" json:"add,omitempty"`       // Appended to existing values
+	Set    map[string]string `yaml:"set,omitempty" json:"set,omitempty"`       // Replace existing values
+	Remove []string          `yaml:"remove,omitempty" json:"remove,omitempty"` // Dropped
+}
+
+// HeaderVars are the values header templates can reference
+type HeaderVars struct {
+	UserID    string
+	Email     string
+	Roles     string // Comma-separated
+	Route     string
+	Service   string
+	Method    string
+	Path      string
+	ClientIP  string
+	RequestID string
+	PathVars  map[string]string // e.g. {{.PathVars.id}}
+	Header    http.Header       // Request headers, e.g. {{.Header.Get "X-Tenant"}}
+}
+
+// headerName matches RFC 9110 field names
+var headerName = regexp.MustCompile("^[A-Za-z0-9!#$%&'*+.^_`|~-]+$")
+
+// headerTemplates caches parsed templates by source
+var headerTemplates sync.Map // string -> *template.Template
+
+// RenderHeader expands a header value template
+func RenderHeader(value string, vars HeaderVars) (string, error) {
+	if !strings.Contains(value, "{{") {
+		return value, nil
+	}
+
+	tmpl, err := parseHeaderTemplate(value)
+	if err != nil {
+		return "", err
+	}
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, vars); err != nil {
+		return "", err
+	}
+	// Header values can't span lines
+	return strings.NewReplacer("\r", "", "\n", "").Replace(rendered.String()), nil
+}
+
+// parseHeaderTemplate returns the cached template for value
+func parseHeaderTemplate(value string) (*template.Template, error) {
+	if cached, ok := headerTemplates.Load(value); ok {
+		return cached.(*template.Template), nil
+	}
+	tmpl, err := template.New("header").Option("missingkey=zero").Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	headerTemplates.Store(value, tmpl)
+	return tmpl, nil
+}
+
+// validate checks header names and that every template renders
+func (h *HeaderRules) validate(section string, reserved func(name string) bool) error {
+	names := append([]string{}, h.Remove...)
+	for name := range h.Set {
+		names = append(names, name)
+	}
+	for name := range h.Add {
+		names = append(names, name)
+	}
+	for _, name := range names {
+		if !headerName.MatchString(name) {
+			return fmt.Errorf("%s: invalid header name %q", section, name)
+		}
+		if reserved(name) {
+			return fmt.Errorf("%s: header %s can't be changed", section, name)
+		}
+	}
+
+	probe := HeaderVars{PathVars: map[string]string{}, Header: http.Header{}}
+	for _, values := range []map[string]string{h.Set, h.Add} {
+		for name, value := range values {
+			if _, err := RenderHeader(value, probe); err != nil {
+				return fmt.Errorf("%s: invalid template for %s: %w", section, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// reservedRequestHeader reports whether a gRPC metadata key is owned by gRPC
+func reservedRequestHeader(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasPrefix(name, "grpc-") || name == "te" || name == "content-type" || name == "user-agent"
+}
+
+// reservedResponseHeader reports whether a response header is owned by the gateway's framing
+func reservedResponseHeader(name string) bool {
+	name = http.CanonicalHeaderKey(name)
+	return name == "Content-Length" || name == "Transfer-Encoding" || name == "Connection"
+}
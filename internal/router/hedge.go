@@ -0,0 +1,29 @@
+package router
+
+import (
+	"fmt"
+	"time"
+)
+
+// RouteHedge sends a second attempt of a slow read to another backend
+// replica; whichever answers first is used. Only GET routes can be hedged,
+// since both attempts may reach a backend.
+type RouteHedge struct {
+	Delay string `yaml:"delay,omitempty" json:"delay,omitempty"` // Wait before hedging, e.g. 50ms; default: the route's recent p95 latency
+}
+
+// DelayDuration returns the parsed fixed delay (0 follows the route's p95)
+func (h *RouteHedge) DelayDuration() time.Duration {
+	delay, _ := time.ParseDuration(h.Delay)
+	return delay
+}
+
+// Validate checks the hedging settings
+func (h *RouteHedge) Validate() error {
+	if h.Delay != "" {
+		if delay, err := time.ParseDuration(h.Delay); err != nil || delay <= 0 {
+			return fmt.Errorf("hedge.delay must be a positive duration, got %q", h.Delay)
+		}
+	}
+	return nil
+}
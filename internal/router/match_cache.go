@@ -0,0 +1,99 @@
+package router
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+)
+
+// RouteMatch is a matched route with the path variables extracted from the request.
+// Cached matches are shared between requests, so PathVars must not be modified.
+type RouteMatch struct {
+	Route    *Route
+	PathVars map[string]string
+}
+
+// matchCache is an LRU cache of (method, path) -> route match used to skip
+// regex evaluation for hot paths. A cache instance belongs to one route table
+// and is discarded when routes are replaced.
+type matchCache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List // front = most recently used
+}
+
+// matchEntry is a cached match
+type matchEntry struct {
+	key   string
+	match RouteMatch
+}
+
+// newMatchCache creates a match cache holding up to capacity entries
+func newMatchCache(capacity int) *matchCache {
+	return &matchCache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element, capacity),
+		order:    list.New(),
+	}
+}
+
+// matchKey normalizes the method so GET and get share an entry
+func matchKey(path, method string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+// get returns the cached match and marks it as recently used
+func (c *matchCache) get(key string) (RouteMatch, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return RouteMatch{}, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*matchEntry).match, true
+}
+
+// put stores a match, evicting the least recently used entry when full
+func (c *matchCache) put(key string, match RouteMatch) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*matchEntry).match = match
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&matchEntry{key: key, match: match})
+
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*matchEntry).key)
+	}
+}
+
+// len returns the number of cached matches
+func (c *matchCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// pathVarsKey is the context key for path variables extracted during routing
+type pathVarsKey struct{}
+
+// WithPathVars stores the path variables of a route match in the context
+func WithPathVars(ctx context.Context, vars map[string]string) context.Context {
+	return context.WithValue(ctx, pathVarsKey{}, vars)
+}
+
+// PathVarsFromContext returns path variables stored by WithPathVars
+func PathVarsFromContext(ctx context.Context) (map[string]string, bool) {
+	vars, ok := ctx.Value(pathVarsKey{}).(map[string]string)
+	return vars, ok
+}
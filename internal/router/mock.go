@@ -0,0 +1,52 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// RouteMock is a canned response served instead of calling the backend while
+// the gateway runs in mock mode, so clients can be built before the RPC exists
+type RouteMock struct {
+	Status  int               `yaml:"status,omitempty" json:"status,omitempty"`   // Defaults to 200
+	Body    interface{}       `yaml:"body,omitempty" json:"body,omitempty"`       // Sent as JSON, e.g. a YAML mapping
+	Latency string            `yaml:"latency,omitempty" json:"latency,omitempty"` // Simulated backend latency, e.g. 150ms
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"` // Extra response headers
+}
+
+// StatusCode returns the mock's HTTP status
+func (m *RouteMock) StatusCode() int {
+	if m.Status == 0 {
+		return http.StatusOK
+	}
+	return m.Status
+}
+
+// LatencyDuration returns the parsed simulated latency
+func (m *RouteMock) LatencyDuration() time.Duration {
+	latency, _ := time.ParseDuration(m.Latency)
+	return latency
+}
+
+// JSON returns the mock's body encoded as JSON
+func (m *RouteMock) JSON() ([]byte, error) {
+	return json.Marshal(m.Body)
+}
+
+// Validate checks the mock's status, latency and body
+func (m *RouteMock) Validate() error {
+	if m.Status != 0 && (m.Status < 200 || m.Status > 599) {
+		return fmt.Errorf("mock.status must be an HTTP status in [200, 599], got %d", m.Status)
+	}
+	if m.Latency != "" {
+		if latency, err := time.ParseDuration(m.Latency); err != nil || latency < 0 {
+			return fmt.Errorf("mock.latency must be a non-negative duration, got %q", m.Latency)
+		}
+	}
+	if _, err := m.JSON(); err != nil {
+		return fmt.Errorf("mock.body can't be encoded as JSON: %w", err)
+	}
+	return nil
+}
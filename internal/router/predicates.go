@@ -0,0 +1,112 @@
+package router
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// AnyValue as a header or query matcher value only requires the parameter to be present
+const AnyValue = "*"
+
+// hasPredicates reports whether the route matches on more than path and method
+func (r *Route) hasPredicates() bool {
+	return r.Host != "" || len(r.Headers) > 0 || len(r.Query) > 0
+}
+
+// predicateCount is the number of host, header and query matchers
+func (r *Route) predicateCount() int {
+	count := len(r.Headers) + len(r.Query)
+	if r.Host != "" {
+		count++
+	}
+	return count
+}
+
+// MatchesPredicates checks the route's host, header and query matchers
+func (r *Route) MatchesPredicates(req *http.Request) bool {
+	if r.Host != "" && !hostMatches(r.Host, req.Host) {
+		return false
+	}
+	for name, want := range r.Headers {
+		values := req.Header.Values(name)
+		if len(values) == 0 || (want != AnyValue && values[0] != want) {
+			return false
+		}
+	}
+	if len(r.Query) > 0 {
+		query := req.URL.Query()
+		for name, want := range r.Query {
+			if !query.Has(name) || (want != AnyValue && query.Get(name) != want) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// hostMatches compares a request host, without its port, to an exact host or
+// a *.example.com wildcard matching any subdomain
+func hostMatches(pattern, host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	pattern = strings.ToLower(pattern)
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+	return host == pattern
+}
+
+// predicatesDisjoint reports whether no request can satisfy the matchers of
+// both routes: they require different exact hosts, or different values of
+// the same header or query parameter
+func predicatesDisjoint(a, b *Route) bool {
+	if a.Host != "" && b.Host != "" && !strings.Contains(a.Host+b.Host, "*") && !strings.EqualFold(a.Host, b.Host) {
+		return true
+	}
+	for name, value := range a.Headers {
+		if other, ok := headerValue(b.Headers, name); ok && value != AnyValue && other != AnyValue && value != other {
+			return true
+		}
+	}
+	for name, value := range a.Query {
+		if other, ok := b.Query[name]; ok && value != AnyValue && other != AnyValue && value != other {
+			return true
+		}
+	}
+	return false
+}
+
+// headerValue looks up a header matcher by case-insensitive name
+func headerValue(headers map[string]string, name string) (string, bool) {
+	for candidate, value := range headers {
+		if strings.EqualFold(candidate, name) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// validatePredicates checks the route's host, header and query matchers
+func (r *Route) validatePredicates() error {
+	if r.Host != "" {
+		host := strings.TrimPrefix(r.Host, "*.")
+		if host == "" || strings.ContainsAny(host, "*/: ") {
+			return fmt.Errorf("route %s: host must be a hostname or *.domain, got %q", r.Name, r.Host)
+		}
+	}
+	for name := range r.Headers {
+		if !headerName.MatchString(name) {
+			return fmt.Errorf("route %s: invalid header matcher name %q", r.Name, name)
+		}
+	}
+	for name := range r.Query {
+		if name == "" {
+			return fmt.Errorf("route %s: query matcher names must not be empty", r.Name)
+		}
+	}
+	return nil
+}
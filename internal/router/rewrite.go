@@ -0,0 +1,80 @@
+package router
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// RouteRewrite maps the request path onto the path the backend sees. Named
+// groups of the pattern also bind like path variables, e.g.
+// (?P<order_id>[0-9]+) sets the order_id request field.
+type RouteRewrite struct {
+	Pattern     string `yaml:"pattern" json:"pattern"`         // Regular expression matched against the (stripped) path
+	Replacement string `yaml:"replacement" json:"replacement"` // Expansion with $1 or ${name} references
+}
+
+// compileRewrite compiles the rewrite pattern
+func (r *Route) compileRewrite() error {
+	if r.Rewrite == nil {
+		return nil
+	}
+	regex, err := regexp.Compile(r.Rewrite.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid rewrite pattern %s: %w", r.Rewrite.Pattern, err)
+	}
+	r.rewriteRegex = regex
+	return nil
+}
+
+// RewritePath returns the path to forward to the backend and the values of
+// the rewrite pattern's named groups. Paths the pattern doesn't match are only
+// stripped of the prefix.
+func (r *Route) RewritePath(path string) (string, map[string]string) {
+	if r.StripPrefix != "" {
+		path = strings.TrimPrefix(path, strings.TrimSuffix(r.StripPrefix, "/"))
+		if !strings.HasPrefix(path, "/") {
+			path = "/" + path
+		}
+	}
+	if r.rewriteRegex == nil {
+		return path, nil
+	}
+
+	match := r.rewriteRegex.FindStringSubmatchIndex(path)
+	if match == nil {
+		return path, nil
+	}
+
+	var variables map[string]string
+	for i, name := range r.rewriteRegex.SubexpNames() {
+		if name == "" || match[2*i] < 0 {
+			continue
+		}
+		if variables == nil {
+			variables = make(map[string]string)
+		}
+		variables[name] = path[match[2*i]:match[2*i+1]]
+	}
+
+	rewritten := r.rewriteRegex.ExpandString(nil, r.Rewrite.Replacement, path, match)
+	return path[:match[0]] + string(rewritten) + path[match[1]:], variables
+}
+
+// RewriteVariables returns the named groups of the rewrite pattern
+func (r *Route) RewriteVariables() []string {
+	if r.Rewrite == nil {
+		return nil
+	}
+	regex, err := regexp.Compile(r.Rewrite.Pattern)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, name := range regex.SubexpNames() {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
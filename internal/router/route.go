@@ -4,35 +4,173 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 )
 
 // Route represents a single routing rule
 type Route struct {
-	Name         string           `yaml:"name"`
-	Path         string           `yaml:"path"`
-	Method       string           `yaml:"method"`
-	Service      string           `yaml:"service"`
-	GRPCService  string           `yaml:"grpc_service"`
-	GRPCMethod   string           `yaml:"grpc_method"`
-	AuthRequired bool             `yaml:"auth_required"`
-	RateLimit    *RateLimitConfig `yaml:"rate_limit,omitempty"`
-	Timeout      string           `yaml:"timeout,omitempty"`
-	Description  string           `yaml:"description,omitempty"`
+	Name             string            `yaml:"name" json:"name"`
+	Path             string            `yaml:"path" json:"path"`
+	Method           string            `yaml:"method" json:"method"`
+	Service          string            `yaml:"service" json:"service"`
+	GRPCService      string            `yaml:"grpc_service" json:"grpc_service"`
+	GRPCMethod       string            `yaml:"grpc_method" json:"grpc_method"`
+	AuthRequired     bool              `yaml:"auth_required" json:"auth_required"`
+	AuthProvider     string            `yaml:"auth_provider,omitempty" json:"auth_provider,omitempty"` // user-service, jwt, oidc, introspection, api-key
+	RateLimit        *RateLimitConfig  `yaml:"rate_limit,omitempty" json:"rate_limit,omitempty"`
+	Timeout          string            `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	Description      string            `yaml:"description,omitempty" json:"description,omitempty"`
+	Tags             map[string]string `yaml:"tags,omitempty" json:"tags,omitempty"`                           // e.g. domain: orders, tier: critical
+	StringFields     []string          `yaml:"string_fields,omitempty" json:"string_fields,omitempty"`         // Response fields emitted as JSON strings, e.g. positions.*.market_value
+	RequiredFields   []string          `yaml:"required_fields,omitempty" json:"required_fields,omitempty"`     // Request fields that must be set, e.g. symbol or order.quantity
+	LongPollField    string            `yaml:"long_poll_field,omitempty" json:"long_poll_field,omitempty"`     // Response field watched by ?wait= long-polling, e.g. status
+	ReplayProtection bool              `yaml:"replay_protection,omitempty" json:"replay_protection,omitempty"` // Require fresh X-Timestamp and unique X-Nonce
+	RevocationCheck  bool              `yaml:"revocation_check,omitempty" json:"revocation_check,omitempty"`   // Validate bearer tokens with the User Service on every request, so revoked tokens are refused at once
+	Audit            string            `yaml:"audit,omitempty" json:"audit,omitempty"`                         // Records every request in the audit log as this action, e.g. order.submit
+	RequiredRoles    []string          `yaml:"required_roles,omitempty" json:"required_roles,omitempty"`       // The user needs at least one of these roles
+	RequiredScopes   []string          `yaml:"required_scopes,omitempty" json:"required_scopes,omitempty"`     // The user needs every one of these scopes
+	Priority         int               `yaml:"priority,omitempty" json:"priority,omitempty"`                   // Higher wins over calculated specificity (default 0)
+	PathFields       map[string]string `yaml:"path_fields,omitempty" json:"path_fields,omitempty"`             // Path variable -> request field when names differ, e.g. id: order_id
+	MaxStale         string            `yaml:"max_stale,omitempty" json:"max_stale,omitempty"`                 // GET only: serve the last response up to this old when the backend fails, e.g. 10m
+	CORS             *RouteCORS        `yaml:"cors,omitempty" json:"cors,omitempty"`                           // Overrides the global CORS origins for this route
+	CircuitBreaker   *RouteBreaker     `yaml:"circuit_breaker,omitempty" json:"circuit_breaker,omitempty"`     // Gives the route its own breaker instead of the service's
+	Stream           string            `yaml:"stream,omitempty" json:"stream,omitempty"`                       // Relays a server-streaming RPC as "sse" or "ndjson", or bridges a bidi one to a WebSocket
+	Cache            *RouteCache       `yaml:"cache,omitempty" json:"cache,omitempty"`                         // GET only: serves responses from the response cache for a TTL
+	MaxBodySize      int64             `yaml:"max_body_size,omitempty" json:"max_body_size,omitempty"`         // Request body limit in bytes, replacing MAX_BODY_SIZE
+	RequestHeaders   *HeaderRules      `yaml:"request_headers,omitempty" json:"request_headers,omitempty"`     // Transforms the metadata sent to the backend
+	ResponseHeaders  *HeaderRules      `yaml:"response_headers,omitempty" json:"response_headers,omitempty"`   // Transforms the headers sent to the client
+	StripPrefix      string            `yaml:"strip_prefix,omitempty" json:"strip_prefix,omitempty"`           // Removed from the path forwarded to the backend, e.g. /api/v1/legacy
+	Rewrite          *RouteRewrite     `yaml:"rewrite,omitempty" json:"rewrite,omitempty"`                     // Rewrites the forwarded path after strip_prefix
+	Type             string            `yaml:"type,omitempty" json:"type,omitempty"`                           // "aggregate" fans out to branches instead of one RPC
+	Branches         []AggregateBranch `yaml:"branches,omitempty" json:"branches,omitempty"`                   // RPCs of an aggregate route
+	Mirror           *RouteMirror      `yaml:"mirror,omitempty" json:"mirror,omitempty"`                       // Copies a share of the traffic to a second service
+	Hedge            *RouteHedge       `yaml:"hedge,omitempty" json:"hedge,omitempty"`                         // GET only: races a second attempt against a slow first one
+	Fault            *RouteFault       `yaml:"fault,omitempty" json:"fault,omitempty"`                         // Injects delays or errors into backend calls (chaos testing)
+	Mock             *RouteMock        `yaml:"mock,omitempty" json:"mock,omitempty"`                           // Canned response served in mock mode
+	Record           bool              `yaml:"record,omitempty" json:"record,omitempty"`                       // Records sanitized request/response pairs when recording is enabled
+	Upstreams        []RouteUpstream   `yaml:"upstreams,omitempty" json:"upstreams,omitempty"`                 // Splits the traffic between services by weight
+	Compress         *bool             `yaml:"compress,omitempty" json:"compress,omitempty"`                   // Set false to never compress the route's responses
+	Host             string            `yaml:"host,omitempty" json:"host,omitempty"`                           // Only matches this Host, e.g. acme.hub.com or *.hub.com
+	Headers          map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`                     // Only matches requests with these header values ("*" = present)
+	Query            map[string]string `yaml:"query,omitempty" json:"query,omitempty"`                         // Only matches requests with these query parameter values ("*" = present)
+
+	// Per-tenant overrides of the backend, timeout and rate limit, by tenant ID
+	Tenants map[string]*RouteTenant `yaml:"tenants,omitempty" json:"tenants,omitempty"`
+
+	// Feature flag the route is dark-launched behind, and its variants
+	FeatureFlag *RouteFeatureFlag `yaml:"feature_flag,omitempty" json:"feature_flag,omitempty"`
 
 	// Compiled regex for path matching (used internally)
 	pathRegex *regexp.Regexp
 	pathVars  []string // Variable names extracted from path (e.g., ["id", "symbol"])
+
+	// Compiled rewrite pattern (used internally)
+	rewriteRegex *regexp.Regexp
 }
 
+// pathVarNames matches {name} path variables
+var pathVarNames = regexp.MustCompile(`\{([^}]*)\}`)
+
+// Response formats of streaming routes
+const (
+	StreamSSE       = "sse"       // Server-Sent Events (text/event-stream)
+	StreamNDJSON    = "ndjson"    // Newline-delimited JSON (application/x-ndjson)
+	StreamWebSocket = "websocket" // JSON text frames both ways over a bidirectional stream
+)
+
 // RateLimitConfig defines rate limiting parameters
 type RateLimitConfig struct {
-	Requests int    `yaml:"requests"`
-	Per      string `yaml:"per"` // "second", "minute", "hour"
+	Requests int    `yaml:"requests" json:"requests"`
+	Per      string `yaml:"per" json:"per"` // "second", "minute", "hour"
+}
+
+// RouteCORS overrides the global CORS policy for a route. Routes serving the
+// same frontend can share one definition with a YAML anchor.
+type RouteCORS struct {
+	AllowedOrigins   []string `yaml:"allowed_origins" json:"allowed_origins"`                         // Replaces CORS_ALLOWED_ORIGINS
+	AllowCredentials *bool    `yaml:"allow_credentials,omitempty" json:"allow_credentials,omitempty"` // Defaults to CORS_ALLOW_CREDENTIALS
+}
+
+// RouteBreaker configures a circuit breaker dedicated to one route, so a
+// failing method doesn't open the breaker for the rest of its service.
+// Unset fields inherit the service's settings.
+type RouteBreaker struct {
+	MaxFailures      uint32 `yaml:"max_failures,omitempty" json:"max_failures,omitempty"`             // Consecutive failures that open the breaker
+	ResetTimeout     string `yaml:"reset_timeout,omitempty" json:"reset_timeout,omitempty"`           // How long it stays open before probing, e.g. 10s
+	HalfOpenRequests uint32 `yaml:"half_open_requests,omitempty" json:"half_open_requests,omitempty"` // Successful probes needed to close it
+
+	// Opens the breaker once this share of calls in a window failed (0..1),
+	// instead of counting consecutive failures
+	FailureRate float64 `yaml:"failure_rate,omitempty" json:"failure_rate,omitempty"`
+	MinRequests uint32  `yaml:"min_requests,omitempty" json:"min_requests,omitempty"` // Calls in the window before the rate is judged
+	Window      string  `yaml:"window,omitempty" json:"window,omitempty"`             // How long calls are counted, e.g. 1m
+}
+
+// ResetTimeoutDuration returns the parsed reset timeout (0 inherits the service's)
+func (b *RouteBreaker) ResetTimeoutDuration() time.Duration {
+	timeout, _ := time.ParseDuration(b.ResetTimeout)
+	return timeout
+}
+
+// WindowDuration returns the parsed failure-rate window (0 inherits the service's)
+func (b *RouteBreaker) WindowDuration() time.Duration {
+	window, _ := time.ParseDuration(b.Window)
+	return window
+}
+
+// Validate checks the breaker settings
+func (b *RouteBreaker) Validate() error {
+	if b.ResetTimeout != "" {
+		if timeout, err := time.ParseDuration(b.ResetTimeout); err != nil || timeout <= 0 {
+			return fmt.Errorf("circuit_breaker.reset_timeout must be a positive duration, got %q", b.ResetTimeout)
+		}
+	}
+	if b.FailureRate < 0 || b.FailureRate > 1 {
+		return fmt.Errorf("circuit_breaker.failure_rate must be between 0 and 1, got %v", b.FailureRate)
+	}
+	if b.FailureRate > 0 && b.MaxFailures > 0 {
+		return fmt.Errorf("circuit_breaker.failure_rate and circuit_breaker.max_failures are exclusive")
+	}
+	if b.Window != "" {
+		if window, err := time.ParseDuration(b.Window); err != nil || window <= 0 {
+			return fmt.Errorf("circuit_breaker.window must be a positive duration, got %q", b.Window)
+		}
+	}
+	return nil
+}
+
+// RouteCache caches a route's responses. Entries are per user unless shared,
+// which is only safe for responses that don't depend on the caller (e.g.
+// market quotes).
+type RouteCache struct {
+	TTL    string `yaml:"ttl" json:"ttl"`                           // How long a response is served from the cache, e.g. 30s
+	Shared bool   `yaml:"shared,omitempty" json:"shared,omitempty"` // One entry for all users instead of one per user
+}
+
+// RouteMirror sends a copy of a share of a route's requests to another
+// service (e.g. a new build) in the background and compares its responses
+// with the primary's. Clients only ever get the primary response.
+type RouteMirror struct {
+	Service string  `yaml:"service" json:"service"`                     // Receives the copies; must serve the same gRPC method
+	Percent float64 `yaml:"percent" json:"percent"`                     // Share of requests mirrored, 0-100
+	Timeout string  `yaml:"timeout,omitempty" json:"timeout,omitempty"` // Defaults to the mirror service's timeout
+}
+
+// TimeoutDuration returns the parsed mirror timeout (0 uses the service's)
+func (m *RouteMirror) TimeoutDuration() time.Duration {
+	timeout, _ := time.ParseDuration(m.Timeout)
+	return timeout
+}
+
+// TTLDuration returns the parsed cache TTL
+func (c *RouteCache) TTLDuration() time.Duration {
+	ttl, _ := time.ParseDuration(c.TTL)
+	return ttl
 }
 
 // RouteConfig holds all routes
 type RouteConfig struct {
-	Routes []Route `yaml:"routes"`
+	Routes []Route `yaml:"routes" json:"routes"`
 }
 
 // CompilePathPattern compiles the path pattern into a regex for matching
@@ -73,7 +211,7 @@ func (r *Route) CompilePathPattern() error {
 		return fmt.Errorf("failed to compile path pattern %s: %w", pattern, err)
 	}
 
-	return nil
+	return r.compileRewrite()
 }
 
 // Matches checks if the route matches the given path and method
@@ -110,6 +248,43 @@ func (r *Route) ExtractPathVariables(path string) map[string]string {
 	return variables
 }
 
+// PathVariables returns the variable names in the route's path pattern
+func (r *Route) PathVariables() []string {
+	var names []string
+	for _, match := range pathVarNames.FindAllStringSubmatch(r.Path, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// PathFieldFor returns the request field a path variable binds to
+func (r *Route) PathFieldFor(variable string) string {
+	if field, ok := r.PathFields[variable]; ok {
+		return field
+	}
+	return variable
+}
+
+// TimeoutDuration returns the route's upstream timeout (0 when the route
+// defers to its service's timeout)
+func (r *Route) TimeoutDuration() time.Duration {
+	if r.Timeout == "" {
+		return 0
+	}
+	timeout, _ := time.ParseDuration(r.Timeout)
+	return timeout
+}
+
+// MaxStaleDuration returns how old a cached response may be to be served when
+// the backend fails (0 when serve-stale-on-error is disabled)
+func (r *Route) MaxStaleDuration() time.Duration {
+	if r.MaxStale == "" {
+		return 0
+	}
+	maxStale, _ := time.ParseDuration(r.MaxStale)
+	return maxStale
+}
+
 // GetTargetService returns the service name for this route
 func (r *Route) GetTargetService() string {
 	return r.Service
@@ -125,11 +300,24 @@ func (r *Route) RequiresAuth() bool {
 	return r.AuthRequired
 }
 
+// HasTag returns whether the route carries the given tag key/value pair
+func (r *Route) HasTag(key, value string) bool {
+	v, ok := r.Tags[key]
+	return ok && v == value
+}
+
 // String returns a string representation of the route
 func (r *Route) String() string {
 	auth := "public"
 	if r.AuthRequired {
 		auth = "protected"
 	}
+	if r.IsAggregate() {
+		branches := make([]string, len(r.Branches))
+		for i, branch := range r.Branches {
+			branches[i] = branch.GRPCService + "." + branch.GRPCMethod
+		}
+		return fmt.Sprintf("%s %s -> aggregate(%s) (%s)", r.Method, r.Path, strings.Join(branches, ", "), auth)
+	}
 	return fmt.Sprintf("%s %s -> %s.%s (%s)", r.Method, r.Path, r.GRPCService, r.GRPCMethod, auth)
 }
@@ -1,6 +1,9 @@
 package router
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -237,3 +240,121 @@ func TestRoute_GetGRPCTarget(t *testing.T) {
 		t.Errorf("expected method SubmitOrder but got %s", method)
 	}
 }
+
+func TestRoute_RewritePath(t *testing.T) {
+	tests := []struct {
+		name      string
+		route     Route
+		path      string
+		expected  string
+		variables map[string]string
+	}{
+		{
+			name:     "strip prefix",
+			route:    Route{Path: "/api/v1/legacy/*", StripPrefix: "/api/v1/legacy/"},
+			path:     "/api/v1/legacy/orders/42",
+			expected: "/orders/42",
+		},
+		{
+			name:     "strip whole path",
+			route:    Route{Path: "/api/v1/legacy", StripPrefix: "/api/v1/legacy"},
+			path:     "/api/v1/legacy",
+			expected: "/",
+		},
+		{
+			name: "rewrite with named group",
+			route: Route{Path: "/api/v1/legacy/orders/{id}", StripPrefix: "/api/v1/legacy",
+				Rewrite: &RouteRewrite{Pattern: `^/orders/(?P<order_id>[0-9]+)$`, Replacement: "/v2/orders/${order_id}/details"}},
+			path:      "/api/v1/legacy/orders/42",
+			expected:  "/v2/orders/42/details",
+			variables: map[string]string{"order_id": "42"},
+		},
+		{
+			name:     "rewrite not matching",
+			route:    Route{Path: "/api/v1/*", Rewrite: &RouteRewrite{Pattern: `^/api/v1/orders/([0-9]+)$`, Replacement: "/orders/$1"}},
+			path:     "/api/v1/positions",
+			expected: "/api/v1/positions",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.route.CompilePathPattern(); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			path, variables := tt.route.RewritePath(tt.path)
+			if path != tt.expected {
+				t.Errorf("expected %s but got %s", tt.expected, path)
+			}
+			if len(variables) != len(tt.variables) || variables["order_id"] != tt.variables["order_id"] {
+				t.Errorf("expected variables %v but got %v", tt.variables, variables)
+			}
+		})
+	}
+}
+
+func TestRoute_SelectUpstream(t *testing.T) {
+	route := &Route{
+		Name:        "get-order",
+		GRPCService: "OrderService",
+		GRPCMethod:  "GetOrderDetails",
+		Upstreams: []RouteUpstream{
+			{Service: "hub-monolith", Weight: 90},
+			{Service: "order-service", GRPCService: "orders.OrderService", Weight: 10, Headers: map[string]string{"X-Canary": "true"}, Cookies: map[string]string{"canary": "1"}},
+		},
+	}
+
+	// Header and cookie overrides win over the weights
+	req := httptest.NewRequest("GET", "/api/v1/orders/1", nil)
+	req.Header.Set("X-Canary", "true")
+	if target := route.SelectUpstream(req, "user-1"); target.Service != "order-service" || target.GRPCService != "orders.OrderService" || target.GRPCMethod != "GetOrderDetails" {
+		t.Errorf("expected the canary upstream for X-Canary but got %s %s.%s", target.Service, target.GRPCService, target.GRPCMethod)
+	}
+	req = httptest.NewRequest("GET", "/api/v1/orders/1", nil)
+	req.AddCookie(&http.Cookie{Name: "canary", Value: "1"})
+	if target := route.SelectUpstream(req, ""); target.Service != "order-service" {
+		t.Errorf("expected the canary upstream for the cookie but got %s", target.Service)
+	}
+
+	// The split follows the weights and sticks per user
+	req = httptest.NewRequest("GET", "/api/v1/orders/1", nil)
+	counts := map[string]int{}
+	for i := 0; i < 2000; i++ {
+		counts[route.SelectUpstream(req, fmt.Sprintf("user-%d", i)).Service]++
+	}
+	if counts["order-service"] < 100 || counts["order-service"] > 300 {
+		t.Errorf("expected about 10%% canary traffic but got %v", counts)
+	}
+	first := route.SelectUpstream(req, "user-42").Service
+	for i := 0; i < 10; i++ {
+		if route.SelectUpstream(req, "user-42").Service != first {
+			t.Fatalf("expected user-42 to stick to %s", first)
+		}
+	}
+}
+
+func TestRoute_ForTenant(t *testing.T) {
+	route := &Route{
+		Name:        "list-orders",
+		Service:     "hub-monolith",
+		GRPCService: "OrderService",
+		GRPCMethod:  "ListOrders",
+		Tenants: map[string]*RouteTenant{
+			"acme":   {Service: "acme-orders", Timeout: "20s"},
+			"globex": {RateLimit: &RateLimitConfig{Requests: 10, Per: "minute"}},
+		},
+	}
+
+	if target := route.ForTenant("acme"); target.Service != "acme-orders" || target.GRPCMethod != "ListOrders" || target.Timeout != "20s" {
+		t.Errorf("unexpected acme route: %s %s %s", target.Service, target.GRPCMethod, target.Timeout)
+	}
+	if target := route.ForTenant("initech"); target != route {
+		t.Errorf("expected tenants without overrides to get the route as is")
+	}
+
+	// Only overrides sending requests elsewhere add targets
+	targets := route.Targets()
+	if len(targets) != 2 || targets[1].Service != "acme-orders" {
+		t.Errorf("expected the route and acme's override as targets but got %+v", targets)
+	}
+}
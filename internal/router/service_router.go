@@ -1,23 +1,56 @@
 package router
 
 import (
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
 	"os"
+	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
 
 	"gopkg.in/yaml.v3"
 )
 
 // ServiceRouter manages route matching and service discovery
 type ServiceRouter struct {
-	routes []Route
-	config *RouteConfig
+	mu         sync.RWMutex
+	routes     []Route
+	config     *RouteConfig
+	configPath string
+	listeners  []func(routes []Route)
+	checks     []func(route *Route) error
+
+	// Reject (rather than warn about) ambiguous route tables
+	strictAmbiguity bool
+
+	// Optional LRU of route matches, rebuilt on every route table swap
+	cache         *matchCache
+	cacheSize     int
+	observeLookup func(hit bool)
 }
 
 // NewServiceRouter creates a new service router from configuration file
 func NewServiceRouter(configPath string) (*ServiceRouter, error) {
+	routes, err := LoadRoutes(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	router := &ServiceRouter{configPath: configPath}
+	if err := router.ReplaceRoutes(routes); err != nil {
+		return nil, err
+	}
+
+	slog.Info("loaded routes", "routes", len(router.routes), "path", configPath)
+	return router, nil
+}
+
+// LoadRoutes reads the routes of a routes file without checking them
+func LoadRoutes(configPath string) ([]Route, error) {
 	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read routes config: %w", err)
@@ -27,32 +60,172 @@ func NewServiceRouter(configPath string) (*ServiceRouter, error) {
 	if err := yaml.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse routes config: %w", err)
 	}
+	return config.Routes, nil
+}
+
+// NewServiceRouterFromRoutes creates a service router from an in-memory route
+// table (e.g. one delivered in a config bundle)
+func NewServiceRouterFromRoutes(routes []Route) (*ServiceRouter, error) {
+	router := &ServiceRouter{}
+	if err := router.ReplaceRoutes(routes); err != nil {
+		return nil, err
+	}
+	return router, nil
+}
 
-	router := &ServiceRouter{
-		config: &config,
-		routes: config.Routes,
+// ReplaceRoutes atomically swaps the route table for the given routes.
+// Patterns are compiled and sorted before the swap so in-flight lookups
+// never observe a partially built table.
+func (r *ServiceRouter) ReplaceRoutes(routes []Route) error {
+	if errs := r.CheckRoutes(routes); len(errs) > 0 {
+		return errors.Join(errs...)
 	}
 
+	compiled := make([]Route, len(routes))
+	copy(compiled, routes)
+
 	// Compile all path patterns
-	for i := range router.routes {
-		if err := router.routes[i].CompilePathPattern(); err != nil {
-			return nil, fmt.Errorf("failed to compile route %s: %w", router.routes[i].Name, err)
+	for i := range compiled {
+		compiled[i].pathRegex = nil
+		compiled[i].pathVars = nil
+		compiled[i].rewriteRegex = nil
+		if err := compiled[i].CompilePathPattern(); err != nil {
+			return fmt.Errorf("failed to compile route %s: %w", compiled[i].Name, err)
 		}
 	}
 
-	// Sort routes by specificity (most specific first)
+	// In strict mode CheckRoutes has already rejected ambiguous tables
+	for _, ambiguity := range DetectAmbiguities(compiled) {
+		slog.Warn("ambiguous routes", "first", ambiguity.First, "second", ambiguity.Second, "detail", ambiguity.Error())
+	}
+
+	// Sort routes by explicit priority, then specificity (most specific first)
 	// Exact matches > Path parameters > Wildcards
-	sort.Slice(router.routes, func(i, j int) bool {
-		return router.calculateSpecificity(&router.routes[i]) > router.calculateSpecificity(&router.routes[j])
+	sort.SliceStable(compiled, func(i, j int) bool {
+		if compiled[i].Priority != compiled[j].Priority {
+			return compiled[i].Priority > compiled[j].Priority
+		}
+		return specificity(&compiled[i]) > specificity(&compiled[j])
 	})
 
-	log.Printf("✅ Loaded %d routes from %s", len(router.routes), configPath)
-	return router, nil
+	r.mu.Lock()
+	r.routes = compiled
+	r.config = &RouteConfig{Routes: compiled}
+	if r.cacheSize > 0 {
+		// Cached matches point into the previous table, so start from an empty cache
+		r.cache = newMatchCache(r.cacheSize)
+	}
+	listeners := append([]func([]Route){}, r.listeners...)
+	r.mu.Unlock()
+
+	for _, listener := range listeners {
+		listener(compiled)
+	}
+
+	return nil
+}
+
+// AddRouteCheck installs a check every route must pass before a route table
+// is accepted (e.g. the egress policy rejecting unknown services)
+func (r *ServiceRouter) AddRouteCheck(check func(route *Route) error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checks = append(r.checks, check)
+}
+
+// SetStrictAmbiguity makes route tables with ambiguous routes fail to load
+// instead of only logging a warning
+func (r *ServiceRouter) SetStrictAmbiguity(strict bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.strictAmbiguity = strict
 }
 
-// calculateSpecificity returns a score for route specificity
+// CheckRoutes validates routes, runs the route checks (and, in strict mode,
+// ambiguity detection) against them and returns every violation
+func (r *ServiceRouter) CheckRoutes(routes []Route) []error {
+	r.mu.RLock()
+	checks := r.checks
+	strict := r.strictAmbiguity
+	r.mu.RUnlock()
+
+	errs := ValidateRoutes(routes)
+	for i := range routes {
+		for _, check := range checks {
+			if err := check(&routes[i]); err != nil {
+				errs = append(errs, fmt.Errorf("route %s: %w", routes[i].Name, err))
+				break
+			}
+		}
+	}
+
+	if strict {
+		for _, ambiguity := range DetectAmbiguities(routes) {
+			errs = append(errs, ambiguity)
+		}
+	}
+	return errs
+}
+
+// OnRoutesChanged registers a callback invoked after every route table swap
+func (r *ServiceRouter) OnRoutesChanged(listener func(routes []Route)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, listener)
+}
+
+// ConfigPath returns the path of the routes file the router was loaded from
+func (r *ServiceRouter) ConfigPath() string {
+	return r.configPath
+}
+
+// ErrNoConfigPath is returned by SaveConfig for routers not loaded from a file
+var ErrNoConfigPath = errors.New("router was not loaded from a routes file")
+
+// SaveConfig writes the active route table back to the routes file. The file
+// is replaced atomically so a crash never leaves a truncated routes.yaml.
+// Comments and ordering of the original file are not preserved.
+func (r *ServiceRouter) SaveConfig() error {
+	if r.configPath == "" {
+		return ErrNoConfigPath
+	}
+
+	data, err := yaml.Marshal(RouteConfig{Routes: r.GetRoutes()})
+	if err != nil {
+		return fmt.Errorf("failed to marshal routes: %w", err)
+	}
+
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(r.configPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.configPath), ".routes-*.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary routes file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write routes: %w", err)
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to set routes file mode: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write routes: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), r.configPath); err != nil {
+		return fmt.Errorf("failed to replace routes file: %w", err)
+	}
+	return nil
+}
+
+// specificity returns a score for route specificity
 // Higher score = more specific route (should be matched first)
-func (r *ServiceRouter) calculateSpecificity(route *Route) int {
+func specificity(route *Route) int {
 	score := 0
 
 	// Exact paths (no variables or wildcards) get highest priority
@@ -70,39 +243,170 @@ func (r *ServiceRouter) calculateSpecificity(route *Route) int {
 		score += 100
 	}
 
-	// Longer paths are more specific
-	score += len(route.Path)
+	// Longer paths are more specific (variable names don't count, so
+	// /orders/{id} and /orders/{ref} rank equally)
+	score += len(pathVarNames.ReplaceAllString(route.Path, "{}"))
 
 	// Routes with specific methods are more specific
 	if route.Method != "" {
 		score += 50
 	}
 
+	// Host, header and query matchers narrow a route further
+	score += 10 * route.predicateCount()
+
 	return score
 }
 
+// EnableMatchCache caches up to size route matches keyed by method and path.
+// observe, if set, is called with the outcome of every cache lookup.
+func (r *ServiceRouter) EnableMatchCache(size int, observe func(hit bool)) {
+	if size <= 0 {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cacheSize = size
+	r.cache = newMatchCache(size)
+	r.observeLookup = observe
+}
+
+// MatchCacheSize returns the number of cached route matches
+func (r *ServiceRouter) MatchCacheSize() int {
+	r.mu.RLock()
+	cache := r.cache
+	r.mu.RUnlock()
+
+	if cache == nil {
+		return 0
+	}
+	return cache.len()
+}
+
 // FindRoute finds a matching route for the given path and method
 func (r *ServiceRouter) FindRoute(path, method string) (*Route, error) {
-	for i := range r.routes {
-		route := &r.routes[i]
-		if route.Matches(path, method) {
-			log.Printf("📍 Route matched: %s %s -> %s", method, path, route.Name)
-			return route, nil
+	match, err := r.Match(path, method)
+	if err != nil {
+		return nil, err
+	}
+	return match.Route, nil
+}
+
+// ErrRouteNotFound is returned by Match when no route matches the path
+var ErrRouteNotFound = errors.New("no route found")
+
+// MethodNotAllowedError is returned by Match when routes exist for the path
+// but none accepts the request method
+type MethodNotAllowedError struct {
+	Method  string
+	Path    string
+	Allowed []string
+}
+
+// Error describes the rejected method
+func (e *MethodNotAllowedError) Error() string {
+	return fmt.Sprintf("Method %s not allowed for %s", e.Method, e.Path)
+}
+
+// Match finds the route for the given path and method and extracts its path
+// variables, serving repeated lookups from the match cache when enabled.
+// HEAD requests fall back to the GET route when no route accepts HEAD itself.
+// Host, header and query matchers are ignored; use MatchRequest to apply them.
+func (r *ServiceRouter) Match(path, method string) (RouteMatch, error) {
+	return r.match(path, method, nil)
+}
+
+// MatchRequest is Match for a request, also applying the routes' host,
+// header and query matchers
+func (r *ServiceRouter) MatchRequest(req *http.Request) (RouteMatch, error) {
+	return r.match(req.URL.Path, req.Method, req)
+}
+
+// match implements Match and MatchRequest; a nil req ignores predicates
+func (r *ServiceRouter) match(path, method string, req *http.Request) (RouteMatch, error) {
+	r.mu.RLock()
+	routes := r.routes
+	cache := r.cache
+	observe := r.observeLookup
+	r.mu.RUnlock()
+
+	key := matchKey(path, method)
+	if cache != nil {
+		match, hit := cache.get(key)
+		if observe != nil {
+			observe(hit)
+		}
+		if hit {
+			return match, nil
 		}
 	}
 
-	return nil, fmt.Errorf("no route found for %s %s", method, path)
+	route := findRoute(routes, path, method, req)
+	if route == nil && strings.EqualFold(method, http.MethodHead) {
+		route = findRoute(routes, path, http.MethodGet, req)
+	}
+	if route != nil {
+		slog.Debug("route matched", "method", method, "path", path, "route", route.Name)
+		match := RouteMatch{Route: route, PathVars: route.ExtractPathVariables(path)}
+		// Only cache paths whose match can't depend on the rest of the request
+		if cache != nil && !predicatesApply(routes, path) {
+			cache.put(key, match)
+		}
+		return match, nil
+	}
+
+	// Distinguish an unknown path from a known path with the wrong method
+	var allowed []string
+	for i := range routes {
+		if routes[i].pathRegex != nil && routes[i].pathRegex.MatchString(path) && routes[i].Method != "" &&
+			!slices.Contains(allowed, strings.ToUpper(routes[i].Method)) {
+			allowed = append(allowed, strings.ToUpper(routes[i].Method))
+		}
+	}
+	if slices.Contains(allowed, http.MethodGet) && !slices.Contains(allowed, http.MethodHead) {
+		allowed = append(allowed, http.MethodHead)
+	}
+	if len(allowed) > 0 {
+		return RouteMatch{}, &MethodNotAllowedError{Method: method, Path: path, Allowed: allowed}
+	}
+
+	return RouteMatch{}, fmt.Errorf("%w for %s %s", ErrRouteNotFound, method, path)
+}
+
+// findRoute returns the first route matching path and method, and the
+// request's host, headers and query unless req is nil
+func findRoute(routes []Route, path, method string, req *http.Request) *Route {
+	for i := range routes {
+		if routes[i].Matches(path, method) && (req == nil || routes[i].MatchesPredicates(req)) {
+			return &routes[i]
+		}
+	}
+	return nil
+}
+
+// predicatesApply reports whether a route with host, header or query
+// matchers matches the path
+func predicatesApply(routes []Route, path string) bool {
+	for i := range routes {
+		if routes[i].hasPredicates() && routes[i].pathRegex != nil && routes[i].pathRegex.MatchString(path) {
+			return true
+		}
+	}
+	return false
 }
 
 // GetRoutes returns all configured routes
 func (r *ServiceRouter) GetRoutes() []Route {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	return r.routes
 }
 
 // GetRoutesByService returns all routes for a specific service
 func (r *ServiceRouter) GetRoutesByService(serviceName string) []Route {
 	var routes []Route
-	for _, route := range r.routes {
+	for _, route := range r.GetRoutes() {
 		if route.Service == serviceName {
 			routes = append(routes, route)
 		}
@@ -110,10 +414,21 @@ func (r *ServiceRouter) GetRoutesByService(serviceName string) []Route {
 	return routes
 }
 
+// GetRoutesByTag returns all routes carrying the given tag key/value pair
+func (r *ServiceRouter) GetRoutesByTag(key, value string) []Route {
+	var routes []Route
+	for _, route := range r.GetRoutes() {
+		if route.HasTag(key, value) {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
 // GetProtectedRoutes returns all routes that require authentication
 func (r *ServiceRouter) GetProtectedRoutes() []Route {
 	var routes []Route
-	for _, route := range r.routes {
+	for _, route := range r.GetRoutes() {
 		if route.AuthRequired {
 			routes = append(routes, route)
 		}
@@ -124,7 +439,7 @@ func (r *ServiceRouter) GetProtectedRoutes() []Route {
 // GetPublicRoutes returns all routes that don't require authentication
 func (r *ServiceRouter) GetPublicRoutes() []Route {
 	var routes []Route
-	for _, route := range r.routes {
+	for _, route := range r.GetRoutes() {
 		if !route.AuthRequired {
 			routes = append(routes, route)
 		}
@@ -134,29 +449,18 @@ func (r *ServiceRouter) GetPublicRoutes() []Route {
 
 // ListRoutes logs all configured routes for debugging
 func (r *ServiceRouter) ListRoutes() {
-	log.Println("📋 Configured Routes:")
-	log.Println("=====================================================")
-
-	serviceRoutes := make(map[string][]Route)
-	for _, route := range r.routes {
-		serviceRoutes[route.Service] = append(serviceRoutes[route.Service], route)
-	}
-
-	for serviceName, routes := range serviceRoutes {
-		log.Printf("\n🔹 %s:", serviceName)
-		for _, route := range routes {
-			auth := "🔓 public"
-			if route.AuthRequired {
-				auth = "🔒 protected"
-			}
-			log.Printf("  %s %s -> %s.%s (%s)",
-				route.Method, route.Path, route.GRPCService, route.GRPCMethod, auth)
-		}
+	allRoutes := r.GetRoutes()
+	for _, route := range allRoutes {
+		slog.Info("configured route",
+			"service", route.Service,
+			"method", route.Method,
+			"path", route.Path,
+			"grpc_method", route.GRPCService+"."+route.GRPCMethod,
+			"auth_required", route.AuthRequired)
 	}
 
-	log.Println("\n=====================================================")
-	log.Printf("Total: %d routes (%d protected, %d public)\n",
-		len(r.routes),
-		len(r.GetProtectedRoutes()),
-		len(r.GetPublicRoutes()))
+	slog.Info("configured routes",
+		"total", len(allRoutes),
+		"protected", len(r.GetProtectedRoutes()),
+		"public", len(r.GetPublicRoutes()))
 }
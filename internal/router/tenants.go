@@ -0,0 +1,93 @@
+package router
+
+import (
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"time"
+)
+
+// TenantIDPattern matches valid tenant IDs, e.g. acme or acme-eu
+var TenantIDPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+// RouteTenant overrides a route for the requests of one tenant, e.g. to send
+// a tenant with a dedicated deployment to its own service. Unset fields keep
+// the route's.
+type RouteTenant struct {
+	Service     string           `yaml:"service,omitempty" json:"service,omitempty"`
+	GRPCService string           `yaml:"grpc_service,omitempty" json:"grpc_service,omitempty"`
+	GRPCMethod  string           `yaml:"grpc_method,omitempty" json:"grpc_method,omitempty"`
+	Timeout     string           `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	RateLimit   *RateLimitConfig `yaml:"rate_limit,omitempty" json:"rate_limit,omitempty"`
+}
+
+// ForTenant returns the route as served to tenant. A service override
+// replaces the route's upstreams; routes without an override for tenant are
+// returned as is.
+func (r *Route) ForTenant(tenant string) *Route {
+	override, ok := r.Tenants[tenant]
+	if !ok || override == nil {
+		return r
+	}
+
+	target := *r
+	if override.Service != "" {
+		target.Service = override.Service
+		target.Upstreams = nil
+	}
+	if override.GRPCService != "" {
+		target.GRPCService = override.GRPCService
+	}
+	if override.GRPCMethod != "" {
+		target.GRPCMethod = override.GRPCMethod
+	}
+	if override.Timeout != "" {
+		target.Timeout = override.Timeout
+	}
+	if override.RateLimit != nil {
+		target.RateLimit = override.RateLimit
+	}
+	return &target
+}
+
+// tenantTargets returns the targets of tenant overrides that send requests
+// to another backend or RPC, in tenant order
+func (r *Route) tenantTargets() []Route {
+	var targets []Route
+	for _, tenant := range slices.Sorted(maps.Keys(r.Tenants)) {
+		override := r.Tenants[tenant]
+		if override == nil || (override.Service == "" && override.GRPCService == "" && override.GRPCMethod == "" && override.Timeout == "") {
+			continue
+		}
+		targets = append(targets, r.ForTenant(tenant).ownTargets()...)
+	}
+	return targets
+}
+
+// validateTenants checks the route's per-tenant overrides
+func (r *Route) validateTenants() error {
+	if len(r.Tenants) > 0 && r.IsAggregate() {
+		return fmt.Errorf("route %s: tenants overrides are not supported on aggregate routes", r.Name)
+	}
+	for tenant, override := range r.Tenants {
+		if !TenantIDPattern.MatchString(tenant) {
+			return fmt.Errorf("route %s: invalid tenant ID %q", r.Name, tenant)
+		}
+		if override == nil {
+			continue
+		}
+		if len(r.Upstreams) > 0 && override.Service == "" && (override.GRPCService != "" || override.GRPCMethod != "") {
+			return fmt.Errorf("route %s: tenant %s overrides the RPC of a route with upstreams without a service", r.Name, tenant)
+		}
+		if override.Timeout != "" {
+			if timeout, err := time.ParseDuration(override.Timeout); err != nil || timeout <= 0 {
+				return fmt.Errorf("route %s: tenant %s timeout must be a positive duration, got %q", r.Name, tenant, override.Timeout)
+			}
+		}
+		if override.RateLimit != nil && (override.RateLimit.Requests <= 0 || !validRateLimitPeriods[override.RateLimit.Per]) {
+			return fmt.Errorf("route %s: tenant %s rate_limit needs positive requests per second, minute or hour", r.Name, tenant)
+		}
+	}
+	return nil
+}
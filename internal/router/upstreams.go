@@ -0,0 +1,131 @@
+package router
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+)
+
+// RouteUpstream is one of several services sharing a route's traffic, e.g.
+// the monolith and the microservice replacing it. Requests carrying all of
+// its Headers, or all of its Cookies, always go to it; the rest are split by
+// Weight.
+type RouteUpstream struct {
+	Service     string            `yaml:"service" json:"service"`
+	GRPCService string            `yaml:"grpc_service,omitempty" json:"grpc_service,omitempty"` // Defaults to the route's
+	GRPCMethod  string            `yaml:"grpc_method,omitempty" json:"grpc_method,omitempty"`   // Defaults to the route's
+	Weight      int               `yaml:"weight" json:"weight"`                                 // Relative share of traffic; 0 only takes overridden requests
+	Headers     map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`           // e.g. X-Canary: "true"
+	Cookies     map[string]string `yaml:"cookies,omitempty" json:"cookies,omitempty"`           // e.g. canary: "1"
+}
+
+// overrides reports whether the request asks for this upstream explicitly
+func (u *RouteUpstream) overrides(r *http.Request) bool {
+	if len(u.Headers) > 0 {
+		matched := true
+		for name, value := range u.Headers {
+			if r.Header.Get(name) != value {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+	if len(u.Cookies) > 0 {
+		for name, value := range u.Cookies {
+			cookie, err := r.Cookie(name)
+			if err != nil || cookie.Value != value {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// upstreamRoute returns the route as sent to one of its upstreams
+func (r *Route) upstreamRoute(upstream RouteUpstream) Route {
+	target := *r
+	target.Upstreams = nil
+	target.Service = upstream.Service
+	if upstream.GRPCService != "" {
+		target.GRPCService = upstream.GRPCService
+	}
+	if upstream.GRPCMethod != "" {
+		target.GRPCMethod = upstream.GRPCMethod
+	}
+	return target
+}
+
+// SelectUpstream picks the upstream serving a request of a route with
+// upstreams and returns the route as sent to it. Requests overriding an upstream get it; otherwise the split is by weight,
+// sticky per key (e.g. the user ID) so a user keeps seeing one version, or
+// random when key is empty. Routes without upstreams are returned as is.
+func (r *Route) SelectUpstream(req *http.Request, key string) *Route {
+	if len(r.Upstreams) == 0 {
+		return r
+	}
+
+	for _, upstream := range r.Upstreams {
+		if upstream.overrides(req) {
+			target := r.upstreamRoute(upstream)
+			return &target
+		}
+	}
+
+	total := 0
+	for _, upstream := range r.Upstreams {
+		total += upstream.Weight
+	}
+	var point int
+	if key != "" {
+		hash := fnv.New32a()
+		hash.Write([]byte(r.Name + "\x00" + key))
+		point = int(hash.Sum32() % uint32(total))
+	} else {
+		point = rand.Intn(total)
+	}
+
+	for _, upstream := range r.Upstreams {
+		if point < upstream.Weight {
+			target := r.upstreamRoute(upstream)
+			return &target
+		}
+		point -= upstream.Weight
+	}
+	return r
+}
+
+// validateUpstreams checks a route's weighted upstreams
+func (r *Route) validateUpstreams() error {
+	if len(r.Upstreams) < 2 {
+		return fmt.Errorf("route %s: upstreams needs at least two services", r.Name)
+	}
+	if r.Service != "" {
+		return fmt.Errorf("route %s: set service on each upstream, not on the route", r.Name)
+	}
+
+	total := 0
+	seen := make(map[string]bool, len(r.Upstreams))
+	for _, upstream := range r.Upstreams {
+		switch {
+		case upstream.Service == "":
+			return fmt.Errorf("route %s: every upstream needs a service", r.Name)
+		case seen[upstream.Service]:
+			return fmt.Errorf("route %s: duplicate upstream %s", r.Name, upstream.Service)
+		case upstream.Weight < 0:
+			return fmt.Errorf("route %s: upstream %s weight must not be negative", r.Name, upstream.Service)
+		case (upstream.GRPCService == "" && r.GRPCService == "") || (upstream.GRPCMethod == "" && r.GRPCMethod == ""):
+			return fmt.Errorf("route %s: upstream %s needs grpc_service and grpc_method, on itself or the route", r.Name, upstream.Service)
+		}
+		seen[upstream.Service] = true
+		total += upstream.Weight
+	}
+	if total == 0 {
+		return fmt.Errorf("route %s: upstream weights must not all be 0", r.Name)
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// validMethods lists the HTTP methods a route may declare
+var validMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodHead:    true,
+	http.MethodOptions: true,
+}
+
+// validRateLimitPeriods lists the accepted rate_limit.per values
+var validRateLimitPeriods = map[string]bool{
+	"second": true,
+	"minute": true,
+	"hour":   true,
+}
+
+// auditActionPattern matches audit action names, e.g. order.submit
+var auditActionPattern = regexp.MustCompile(`^[a-z0-9_-]+(\.[a-z0-9_-]+)+$`)
+
+// Validate checks that a route definition is complete and well-formed
+func (r *Route) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("route name is required")
+	}
+	if !strings.HasPrefix(r.Path, "/") {
+		return fmt.Errorf("route %s: path must start with /", r.Name)
+	}
+	if r.Method != "" && !validMethods[strings.ToUpper(r.Method)] {
+		return fmt.Errorf("route %s: unsupported method %s", r.Name, r.Method)
+	}
+	switch {
+	case r.IsAggregate():
+		if err := r.validateAggregate(); err != nil {
+			return err
+		}
+	case r.Type != "":
+		return fmt.Errorf("route %s: type must be empty or %s, got %q", r.Name, RouteTypeAggregate, r.Type)
+	case len(r.Branches) > 0:
+		return fmt.Errorf("route %s: branches require type %s", r.Name, RouteTypeAggregate)
+	case len(r.Upstreams) > 0:
+		if err := r.validateUpstreams(); err != nil {
+			return err
+		}
+	case r.Service == "":
+		return fmt.Errorf("route %s: service is required", r.Name)
+	case r.GRPCService == "" || r.GRPCMethod == "":
+		return fmt.Errorf("route %s: grpc_service and grpc_method are required", r.Name)
+	}
+	if r.Timeout != "" {
+		timeout, err := time.ParseDuration(r.Timeout)
+		if err != nil {
+			return fmt.Errorf("route %s: invalid timeout %q: %w", r.Name, r.Timeout, err)
+		}
+		if timeout <= 0 {
+			return fmt.Errorf("route %s: timeout must be positive, got %q", r.Name, r.Timeout)
+		}
+	}
+	if r.MaxStale != "" {
+		maxStale, err := time.ParseDuration(r.MaxStale)
+		if err != nil || maxStale <= 0 {
+			return fmt.Errorf("route %s: max_stale must be a positive duration, got %q", r.Name, r.MaxStale)
+		}
+		if r.Method != "" && !strings.EqualFold(r.Method, http.MethodGet) {
+			return fmt.Errorf("route %s: max_stale is only supported on GET routes", r.Name)
+		}
+	}
+	switch r.Stream {
+	case "", StreamSSE, StreamNDJSON:
+	case StreamWebSocket:
+		if r.Method != "" && !strings.EqualFold(r.Method, http.MethodGet) {
+			return fmt.Errorf("route %s: stream %s is only supported on GET routes", r.Name, StreamWebSocket)
+		}
+	default:
+		return fmt.Errorf("route %s: stream must be %s, %s or %s, got %q", r.Name, StreamSSE, StreamNDJSON, StreamWebSocket, r.Stream)
+	}
+	if r.Stream != "" && (r.LongPollField != "" || r.MaxStale != "") {
+		return fmt.Errorf("route %s: stream cannot be combined with long_poll_field or max_stale", r.Name)
+	}
+	if r.Cache != nil {
+		if ttl, err := time.ParseDuration(r.Cache.TTL); err != nil || ttl <= 0 {
+			return fmt.Errorf("route %s: cache.ttl must be a positive duration, got %q", r.Name, r.Cache.TTL)
+		}
+		if r.Method != "" && !strings.EqualFold(r.Method, http.MethodGet) {
+			return fmt.Errorf("route %s: cache is only supported on GET routes", r.Name)
+		}
+		if r.Stream != "" || r.LongPollField != "" {
+			return fmt.Errorf("route %s: cache cannot be combined with stream or long_poll_field", r.Name)
+		}
+	}
+	if r.Mirror != nil {
+		switch {
+		case r.Mirror.Service == "" || r.Mirror.Service == r.Service:
+			return fmt.Errorf("route %s: mirror.service is required and must differ from service", r.Name)
+		case r.Mirror.Percent <= 0 || r.Mirror.Percent > 100:
+			return fmt.Errorf("route %s: mirror.percent must be in (0, 100], got %v", r.Name, r.Mirror.Percent)
+		case r.Stream != "" || r.LongPollField != "" || r.IsAggregate():
+			return fmt.Errorf("route %s: mirror can't be combined with stream, long_poll_field or aggregate routes", r.Name)
+		}
+		if r.Mirror.Timeout != "" {
+			if timeout, err := time.ParseDuration(r.Mirror.Timeout); err != nil || timeout <= 0 {
+				return fmt.Errorf("route %s: mirror.timeout must be a positive duration, got %q", r.Name, r.Mirror.Timeout)
+			}
+		}
+	}
+	if r.Hedge != nil {
+		if err := r.Hedge.Validate(); err != nil {
+			return fmt.Errorf("route %s: %w", r.Name, err)
+		}
+		if r.Method != "" && !strings.EqualFold(r.Method, http.MethodGet) {
+			return fmt.Errorf("route %s: hedge is only supported on GET routes", r.Name)
+		}
+		if r.Stream != "" || r.LongPollField != "" || r.IsAggregate() {
+			return fmt.Errorf("route %s: hedge can't be combined with stream, long_poll_field or aggregate routes", r.Name)
+		}
+	}
+	if r.Mock != nil {
+		if err := r.Mock.Validate(); err != nil {
+			return fmt.Errorf("route %s: %w", r.Name, err)
+		}
+	}
+	if r.Fault != nil {
+		if err := r.Fault.Validate(); err != nil {
+			return fmt.Errorf("route %s: %w", r.Name, err)
+		}
+	}
+	if err := r.validatePredicates(); err != nil {
+		return err
+	}
+	if r.MaxBodySize < 0 {
+		return fmt.Errorf("route %s: max_body_size must not be negative", r.Name)
+	}
+	if len(r.RequiredRoles) > 0 || len(r.RequiredScopes) > 0 {
+		if !r.AuthRequired {
+			return fmt.Errorf("route %s: required_roles and required_scopes require auth_required", r.Name)
+		}
+		for _, grant := range append(append([]string{}, r.RequiredRoles...), r.RequiredScopes...) {
+			if strings.TrimSpace(grant) == "" {
+				return fmt.Errorf("route %s: required_roles and required_scopes must not contain empty values", r.Name)
+			}
+		}
+	}
+	if r.RevocationCheck {
+		if !r.AuthRequired {
+			return fmt.Errorf("route %s: revocation_check requires auth_required", r.Name)
+		}
+		if r.AuthProvider != "" && r.AuthProvider != "user-service" {
+			return fmt.Errorf("route %s: revocation_check needs the user-service provider, got auth_provider %s", r.Name, r.AuthProvider)
+		}
+	}
+	if r.Audit != "" && !auditActionPattern.MatchString(r.Audit) {
+		return fmt.Errorf("route %s: audit must be a dotted action name like order.submit, got %q", r.Name, r.Audit)
+	}
+	if r.CircuitBreaker != nil {
+		if err := r.CircuitBreaker.Validate(); err != nil {
+			return fmt.Errorf("route %s: %w", r.Name, err)
+		}
+	}
+	if r.CORS != nil {
+		if len(r.CORS.AllowedOrigins) == 0 {
+			return fmt.Errorf("route %s: cors.allowed_origins must not be empty", r.Name)
+		}
+		for _, origin := range r.CORS.AllowedOrigins {
+			if origin == "*" {
+				if r.CORS.AllowCredentials != nil && *r.CORS.AllowCredentials {
+					return fmt.Errorf("route %s: cors.allow_credentials can't be combined with a * origin", r.Name)
+				}
+				continue
+			}
+			if !strings.Contains(origin, "://") {
+				return fmt.Errorf("route %s: cors origin %q must include a scheme, e.g. https://app.example.com", r.Name, origin)
+			}
+		}
+	}
+	if r.RateLimit != nil {
+		if r.RateLimit.Requests <= 0 {
+			return fmt.Errorf("route %s: rate_limit.requests must be positive", r.Name)
+		}
+		if !validRateLimitPeriods[r.RateLimit.Per] {
+			return fmt.Errorf("route %s: rate_limit.per must be second, minute or hour", r.Name)
+		}
+	}
+	if err := r.validateTenants(); err != nil {
+		return err
+	}
+	if err := r.validateFeatureFlag(); err != nil {
+		return err
+	}
+
+	if r.RequestHeaders != nil {
+		if err := r.RequestHeaders.validate("request_headers", reservedRequestHeader); err != nil {
+			return fmt.Errorf("route %s: %w", r.Name, err)
+		}
+	}
+	if r.ResponseHeaders != nil {
+		if err := r.ResponseHeaders.validate("response_headers", reservedResponseHeader); err != nil {
+			return fmt.Errorf("route %s: %w", r.Name, err)
+		}
+	}
+
+	for _, field := range r.StringFields {
+		for _, segment := range strings.Split(field, ".") {
+			if segment == "" {
+				return fmt.Errorf("route %s: invalid string_fields path %q", r.Name, field)
+			}
+		}
+	}
+	for _, field := range r.RequiredFields {
+		for _, segment := range strings.Split(field, ".") {
+			if segment == "" || segment == "*" {
+				return fmt.Errorf("route %s: invalid required_fields path %q", r.Name, field)
+			}
+		}
+	}
+
+	if r.StripPrefix != "" {
+		if !strings.HasPrefix(r.StripPrefix, "/") {
+			return fmt.Errorf("route %s: strip_prefix must start with /", r.Name)
+		}
+		if !strings.HasPrefix(r.Path, strings.TrimSuffix(r.StripPrefix, "/")) {
+			return fmt.Errorf("route %s: path %s doesn't start with strip_prefix %s", r.Name, r.Path, r.StripPrefix)
+		}
+	}
+	if r.Rewrite != nil {
+		if r.Rewrite.Pattern == "" {
+			return fmt.Errorf("route %s: rewrite.pattern is required", r.Name)
+		}
+		pathVariables := make(map[string]bool)
+		for _, variable := range r.PathVariables() {
+			pathVariables[variable] = true
+		}
+		for _, variable := range r.RewriteVariables() {
+			if pathVariables[variable] {
+				return fmt.Errorf("route %s: rewrite group %s shadows the path variable of the same name", r.Name, variable)
+			}
+		}
+	}
+
+	probe := Route{Path: r.Path, Rewrite: r.Rewrite}
+	if err := probe.CompilePathPattern(); err != nil {
+		return fmt.Errorf("route %s: %w", r.Name, err)
+	}
+
+	return nil
+}
+
+// ValidateRoutes validates every route and checks names are unique.
+// All problems are reported, not just the first one.
+func ValidateRoutes(routes []Route) []error {
+	var errs []error
+	seen := make(map[string]bool, len(routes))
+
+	for i := range routes {
+		if err := routes[i].Validate(); err != nil {
+			errs = append(errs, err)
+		}
+		if routes[i].Name != "" {
+			if seen[routes[i].Name] {
+				errs = append(errs, fmt.Errorf("duplicate route name: %s", routes[i].Name))
+			}
+			seen[routes[i].Name] = true
+		}
+	}
+
+	return errs
+}
+
+// RouteDiff describes the changes between two route tables, keyed by route name
+type RouteDiff struct {
+	Added     []string `json:"added"`
+	Removed   []string `json:"removed"`
+	Modified  []string `json:"modified"`
+	Unchanged int      `json:"unchanged"`
+}
+
+// HasChanges returns whether the diff contains any change
+func (d RouteDiff) HasChanges() bool {
+	return len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Modified) > 0
+}
+
+// DiffRoutes compares the current route table with a proposed one
+func DiffRoutes(current, proposed []Route) RouteDiff {
+	diff := RouteDiff{
+		Added:    []string{},
+		Removed:  []string{},
+		Modified: []string{},
+	}
+
+	currentByName := make(map[string]Route, len(current))
+	for _, route := range current {
+		currentByName[route.Name] = route
+	}
+
+	proposedNames := make(map[string]bool, len(proposed))
+	for _, route := range proposed {
+		proposedNames[route.Name] = true
+
+		existing, ok := currentByName[route.Name]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, route.Name)
+		case !sameDefinition(existing, route):
+			diff.Modified = append(diff.Modified, route.Name)
+		default:
+			diff.Unchanged++
+		}
+	}
+
+	for _, route := range current {
+		if !proposedNames[route.Name] {
+			diff.Removed = append(diff.Removed, route.Name)
+		}
+	}
+
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Modified)
+	return diff
+}
+
+// sameDefinition compares the configured fields of two routes, ignoring compiled state
+func sameDefinition(a, b Route) bool {
+	a.pathRegex, a.pathVars, a.rewriteRegex = nil, nil, nil
+	b.pathRegex, b.pathVars, b.rewriteRegex = nil, nil, nil
+	return reflect.DeepEqual(a, b)
+}
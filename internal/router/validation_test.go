@@ -0,0 +1,342 @@
+package router
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func validRoute(name string) Route {
+	return Route{
+		Name:        name,
+		Path:        "/api/v1/" + name,
+		Method:      "GET",
+		Service:     "hub-monolith",
+		GRPCService: "OrderService",
+		GRPCMethod:  "GetOrderDetails",
+	}
+}
+
+func TestValidateRoutes(t *testing.T) {
+	missingService := validRoute("missing-service")
+	missingService.Service = ""
+
+	badTimeout := validRoute("bad-timeout")
+	badTimeout.Timeout = "soon"
+
+	badMethod := validRoute("bad-method")
+	badMethod.Method = "FETCH"
+
+	badPath := validRoute("bad-path")
+	badPath.Path = "api/v1/orders"
+
+	credentials := true
+	credentialedWildcard := validRoute("credentialed-wildcard")
+	credentialedWildcard.CORS = &RouteCORS{AllowedOrigins: []string{"*"}, AllowCredentials: &credentials}
+
+	partnerCORS := validRoute("partner-cors")
+	partnerCORS.CORS = &RouteCORS{AllowedOrigins: []string{"https://*.partners.hub.com"}, AllowCredentials: &credentials}
+
+	headerRules := validRoute("header-rules")
+	headerRules.RequestHeaders = &HeaderRules{Set: map[string]string{"X-Forwarded-User": "{{.UserID}}"}, Remove: []string{"x-user-email"}}
+	headerRules.ResponseHeaders = &HeaderRules{Add: map[string]string{"X-Order": "{{.PathVars.id}}"}}
+
+	unknownHeaderVar := validRoute("unknown-header-var")
+	unknownHeaderVar.RequestHeaders = &HeaderRules{Set: map[string]string{"X-Tenant": "{{.Tenant}}"}}
+
+	reservedHeader := validRoute("reserved-header")
+	reservedHeader.RequestHeaders = &HeaderRules{Set: map[string]string{"grpc-timeout": "1S"}}
+
+	dashboard := Route{Name: "dashboard", Path: "/api/v1/dashboard", Method: "GET", Type: RouteTypeAggregate, Branches: []AggregateBranch{
+		{Name: "balance", Service: "hub-monolith", GRPCService: "BalanceService", GRPCMethod: "GetBalance", Required: true},
+		{Name: "positions", Service: "hub-monolith", GRPCService: "PositionService", GRPCMethod: "GetPositions", Timeout: "500ms"},
+	}}
+
+	badHost := validRoute("bad-host")
+	badHost.Host = "*.hub.com:8080"
+
+	singleBranch := dashboard
+	singleBranch.Name = "single-branch"
+	singleBranch.Branches = dashboard.Branches[:1]
+
+	tests := []struct {
+		name     string
+		routes   []Route
+		expected int
+	}{
+		{name: "valid routes", routes: []Route{validRoute("a"), validRoute("b")}, expected: 0},
+		{name: "duplicate names", routes: []Route{validRoute("a"), validRoute("a")}, expected: 1},
+		{name: "missing service", routes: []Route{missingService}, expected: 1},
+		{name: "invalid timeout", routes: []Route{badTimeout}, expected: 1},
+		{name: "invalid method", routes: []Route{badMethod}, expected: 1},
+		{name: "relative path", routes: []Route{badPath}, expected: 1},
+		{name: "cors credentials with wildcard origin", routes: []Route{credentialedWildcard}, expected: 1},
+		{name: "route cors override", routes: []Route{partnerCORS}, expected: 0},
+		{name: "header rules", routes: []Route{headerRules}, expected: 0},
+		{name: "unknown header template variable", routes: []Route{unknownHeaderVar}, expected: 1},
+		{name: "reserved request header", routes: []Route{reservedHeader}, expected: 1},
+		{name: "aggregate route", routes: []Route{dashboard}, expected: 0},
+		{name: "aggregate route with one branch", routes: []Route{singleBranch}, expected: 1},
+		{name: "host matcher with port", routes: []Route{badHost}, expected: 1},
+		{name: "all problems reported", routes: []Route{missingService, badTimeout, badMethod}, expected: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := ValidateRoutes(tt.routes)
+			if len(errs) != tt.expected {
+				t.Errorf("expected %d errors but got %d: %v", tt.expected, len(errs), errs)
+			}
+		})
+	}
+}
+
+func TestDiffRoutes(t *testing.T) {
+	modified := validRoute("b")
+	modified.Timeout = "5s"
+
+	current := []Route{validRoute("a"), validRoute("b"), validRoute("c")}
+	proposed := []Route{validRoute("a"), modified, validRoute("d")}
+
+	// Compiled state must not count as a modification
+	if err := current[0].CompilePathPattern(); err != nil {
+		t.Fatalf("failed to compile pattern: %v", err)
+	}
+
+	diff := DiffRoutes(current, proposed)
+
+	if len(diff.Added) != 1 || diff.Added[0] != "d" {
+		t.Errorf("expected added [d] but got %v", diff.Added)
+	}
+	if len(diff.Removed) != 1 || diff.Removed[0] != "c" {
+		t.Errorf("expected removed [c] but got %v", diff.Removed)
+	}
+	if len(diff.Modified) != 1 || diff.Modified[0] != "b" {
+		t.Errorf("expected modified [b] but got %v", diff.Modified)
+	}
+	if diff.Unchanged != 1 {
+		t.Errorf("expected 1 unchanged route but got %d", diff.Unchanged)
+	}
+	if !diff.HasChanges() {
+		t.Errorf("expected diff to report changes")
+	}
+}
+
+func TestServiceRouter_MatchCache(t *testing.T) {
+	r := &ServiceRouter{}
+	if err := r.ReplaceRoutes([]Route{
+		{Name: "quote", Path: "/api/v1/market-data/{symbol}", Method: "GET", Service: "market-data-service", GRPCService: "OrderService", GRPCMethod: "GetOrderDetails"},
+	}); err != nil {
+		t.Fatalf("failed to load routes: %v", err)
+	}
+
+	var hits, misses int
+	r.EnableMatchCache(2, func(hit bool) {
+		if hit {
+			hits++
+		} else {
+			misses++
+		}
+	})
+
+	for i := 0; i < 3; i++ {
+		match, err := r.Match("/api/v1/market-data/PETR4", "GET")
+		if err != nil {
+			t.Fatalf("expected match: %v", err)
+		}
+		if match.Route.Name != "quote" || match.PathVars["symbol"] != "PETR4" {
+			t.Errorf("unexpected match: %+v", match)
+		}
+	}
+	if hits != 2 || misses != 1 {
+		t.Errorf("expected 2 hits and 1 miss but got %d/%d", hits, misses)
+	}
+
+	// Capacity is bounded
+	r.Match("/api/v1/market-data/VALE3", "GET")
+	r.Match("/api/v1/market-data/ITUB4", "GET")
+	if size := r.MatchCacheSize(); size != 2 {
+		t.Errorf("expected cache bounded to 2 entries but got %d", size)
+	}
+
+	// Reloading routes invalidates cached matches
+	if err := r.ReplaceRoutes([]Route{
+		{Name: "quote-v2", Path: "/api/v1/market-data/{symbol}", Method: "GET", Service: "market-data-service", GRPCService: "OrderService", GRPCMethod: "GetOrderDetails"},
+	}); err != nil {
+		t.Fatalf("failed to reload routes: %v", err)
+	}
+	if size := r.MatchCacheSize(); size != 0 {
+		t.Errorf("expected empty cache after reload but got %d", size)
+	}
+	match, _ := r.Match("/api/v1/market-data/ITUB4", "GET")
+	if match.Route == nil || match.Route.Name != "quote-v2" {
+		t.Errorf("expected reloaded route but got %+v", match.Route)
+	}
+}
+
+func TestServiceRouter_MatchRequest(t *testing.T) {
+	routes := []Route{
+		{Name: "orders", Path: "/api/v1/orders", Method: "GET", Service: "order-service", GRPCService: "OrderService", GRPCMethod: "GetOrderDetails"},
+		{Name: "orders-v2", Path: "/api/v1/orders", Method: "GET", Service: "order-service-v2", GRPCService: "OrderService", GRPCMethod: "GetOrderDetails", Headers: map[string]string{"X-API-Version": "2"}},
+		{Name: "orders-v3", Path: "/api/v1/orders", Method: "GET", Service: "order-service-v3", GRPCService: "OrderService", GRPCMethod: "GetOrderDetails", Headers: map[string]string{"X-API-Version": "3"}},
+		{Name: "orders-acme", Path: "/api/v1/orders", Method: "GET", Service: "acme-orders", GRPCService: "OrderService", GRPCMethod: "GetOrderDetails", Host: "*.acme.com", Query: map[string]string{"beta": "*"}},
+	}
+	if ambiguities := DetectAmbiguities(routes); len(ambiguities) != 0 {
+		t.Errorf("expected different header values not to be ambiguous but got %v", ambiguities)
+	}
+
+	r := &ServiceRouter{}
+	if err := r.ReplaceRoutes(routes); err != nil {
+		t.Fatalf("failed to load routes: %v", err)
+	}
+	r.EnableMatchCache(10, nil)
+
+	tests := []struct {
+		host, target, version string
+		expected              string
+	}{
+		{"api.hub.com", "/api/v1/orders", "", "orders"},
+		{"api.hub.com", "/api/v1/orders", "2", "orders-v2"},
+		{"api.hub.com", "/api/v1/orders", "3", "orders-v3"},
+		{"api.hub.com", "/api/v1/orders", "4", "orders"},
+		{"eu.acme.com:8443", "/api/v1/orders?beta", "", "orders-acme"},
+		{"eu.acme.com", "/api/v1/orders", "", "orders"},
+		{"acme.com", "/api/v1/orders?beta=1", "", "orders"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.target, nil)
+		req.Host = tt.host
+		if tt.version != "" {
+			req.Header.Set("X-API-Version", tt.version)
+		}
+		match, err := r.MatchRequest(req)
+		if err != nil || match.Route.Name != tt.expected {
+			t.Errorf("%s%s (version %q): expected %s but got %+v, %v", tt.host, tt.target, tt.version, tt.expected, match.Route, err)
+		}
+	}
+	if size := r.MatchCacheSize(); size != 0 {
+		t.Errorf("expected predicated paths not to be cached but got %d entries", size)
+	}
+}
+
+func TestDetectAmbiguities(t *testing.T) {
+	routes := []Route{
+		{Name: "order-by-id", Path: "/api/v1/orders/{id}", Method: "GET", Service: "order-service", GRPCService: "OrderService", GRPCMethod: "GetOrderDetails"},
+		{Name: "order-by-ref", Path: "/api/v1/orders/{ref}", Method: "GET", Service: "order-service", GRPCService: "OrderService", GRPCMethod: "GetOrderDetails"},
+		{Name: "order-cancel", Path: "/api/v1/orders/{id}", Method: "DELETE", Service: "order-service", GRPCService: "OrderService", GRPCMethod: "GetOrderDetails"},
+		{Name: "position", Path: "/api/v1/positions/{id}", Method: "GET", Service: "order-service", GRPCService: "OrderService", GRPCMethod: "GetOrderDetails"},
+	}
+
+	ambiguities := DetectAmbiguities(routes)
+	if len(ambiguities) != 1 || ambiguities[0].First != "order-by-id" || ambiguities[0].Second != "order-by-ref" {
+		t.Fatalf("expected order-by-id/order-by-ref ambiguity but got %v", ambiguities)
+	}
+
+	// An explicit priority resolves the ambiguity and wins the match
+	routes[1].Priority = 10
+	if ambiguities := DetectAmbiguities(routes); len(ambiguities) != 0 {
+		t.Errorf("expected priority to resolve ambiguity but got %v", ambiguities)
+	}
+
+	r := &ServiceRouter{}
+	if err := r.ReplaceRoutes(routes); err != nil {
+		t.Fatalf("failed to load routes: %v", err)
+	}
+	if route, _ := r.FindRoute("/api/v1/orders/42", "GET"); route == nil || route.Name != "order-by-ref" {
+		t.Errorf("expected prioritized route to match but got %+v", route)
+	}
+
+	// Strict mode rejects ambiguous tables
+	routes[1].Priority = 0
+	r.SetStrictAmbiguity(true)
+	if err := r.ReplaceRoutes(routes); err == nil {
+		t.Errorf("expected strict mode to reject ambiguous routes")
+	}
+}
+
+func TestServiceRouter_ShippedRoutesAndMethodNotAllowed(t *testing.T) {
+	r, err := NewServiceRouter("../../config/routes.yaml")
+	if err != nil {
+		t.Fatalf("failed to load shipped routes: %v", err)
+	}
+
+	routes := r.GetRoutes()
+	if errs := ValidateRoutes(routes); len(errs) > 0 {
+		t.Errorf("shipped routes are invalid: %v", errs)
+	}
+	if ambiguities := DetectAmbiguities(routes); len(ambiguities) > 0 {
+		t.Errorf("shipped routes are ambiguous: %v", ambiguities)
+	}
+
+	match, err := r.Match("/api/v1/orders/history", "GET")
+	if err != nil || match.Route.Name != "get-order-history" {
+		t.Errorf("expected exact path to win over /orders/{id}, got %+v (%v)", match.Route, err)
+	}
+
+	_, err = r.Match("/api/v1/orders", "DELETE")
+	methodErr, ok := err.(*MethodNotAllowedError)
+	if !ok || len(methodErr.Allowed) != 1 || methodErr.Allowed[0] != "POST" {
+		t.Errorf("expected method not allowed with Allow: POST, got %v", err)
+	}
+
+	match, err = r.Match("/api/v1/orders/history", "HEAD")
+	if err != nil || match.Route.Name != "get-order-history" {
+		t.Errorf("expected HEAD to fall back to the GET route, got %+v (%v)", match.Route, err)
+	}
+
+	if _, err := r.Match("/api/v1/unknown", "GET"); !errors.Is(err, ErrRouteNotFound) {
+		t.Errorf("expected ErrRouteNotFound, got %v", err)
+	}
+}
+
+func TestServiceRouter_SaveConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.yaml")
+	if err := os.WriteFile(path, []byte("routes:\n  - name: a\n    path: /api/v1/a\n    method: GET\n    service: hub-monolith\n    grpc_service: OrderService\n    grpc_method: GetOrderDetails\n"), 0o600); err != nil {
+		t.Fatalf("failed to write routes file: %v", err)
+	}
+
+	r, err := NewServiceRouter(path)
+	if err != nil {
+		t.Fatalf("failed to load routes: %v", err)
+	}
+	if err := r.ReplaceRoutes(append(r.GetRoutes(), validRoute("b"))); err != nil {
+		t.Fatalf("failed to add route: %v", err)
+	}
+	if err := r.SaveConfig(); err != nil {
+		t.Fatalf("failed to save routes: %v", err)
+	}
+
+	reloaded, err := NewServiceRouter(path)
+	if err != nil {
+		t.Fatalf("failed to reload saved routes: %v", err)
+	}
+	if routes := reloaded.GetRoutes(); len(routes) != 2 {
+		t.Errorf("expected 2 persisted routes but got %v", routes)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("expected file mode to be preserved but got %v", info.Mode())
+	}
+
+	inMemory, _ := NewServiceRouterFromRoutes([]Route{validRoute("a")})
+	if err := inMemory.SaveConfig(); !errors.Is(err, ErrNoConfigPath) {
+		t.Errorf("expected ErrNoConfigPath but got %v", err)
+	}
+}
+
+func TestNewServiceRouter_RejectsInvalidRoutes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "routes.yaml")
+	routes := "routes:\n" +
+		"  - {name: a, path: /api/v1/a, method: GET, service: hub-monolith, grpc_service: OrderService, grpc_method: GetOrderDetails}\n" +
+		"  - {name: a, path: /api/v1/b, method: GET, service: hub-monolith, grpc_service: OrderService, grpc_method: GetOrderDetails, timeout: banana}\n"
+	if err := os.WriteFile(path, []byte(routes), 0o600); err != nil {
+		t.Fatalf("failed to write routes file: %v", err)
+	}
+
+	_, err := NewServiceRouter(path)
+	if err == nil || !strings.Contains(err.Error(), "duplicate route name: a") || !strings.Contains(err.Error(), "invalid timeout") {
+		t.Errorf("expected the duplicate name and bad timeout to be reported but got %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package sdkgen
+
+import (
+	"fmt"
+	"html/template"
+	"log/slog"
+	"net/http"
+
+	"hub-api-gateway/internal/httperr"
+	"hub-api-gateway/internal/router"
+)
+
+// swaggerUIPage loads Swagger UI from assetsURL and points it at the spec
+var swaggerUIPage = template.Must(template.New("docs").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Hub API Gateway</title>
+  <link rel="stylesheet" href="{{.Assets}}/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="{{.Assets}}/swagger-ui-bundle.js"></script>
+  <script>
+    SwaggerUIBundle({ url: {{.Spec}}, dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`))
+
+// Handler serves the OpenAPI document of the live route table and a Swagger
+// UI page for it, so the contract follows route reloads
+type Handler struct {
+	routes    func() []router.Route
+	resolve   MethodResolver
+	assetsURL string
+	specPath  string
+}
+
+// NewHandler creates a handler documenting the routes returned by routes.
+// assetsURL is where swagger-ui-dist is served from; specPath is the path of
+// the OpenAPI document the UI loads.
+func NewHandler(routes func() []router.Route, resolve MethodResolver, assetsURL, specPath string) *Handler {
+	return &Handler{routes: routes, resolve: resolve, assetsURL: assetsURL, specPath: specPath}
+}
+
+// HandleSpec serves GET /openapi.json
+func (h *Handler) HandleSpec(w http.ResponseWriter, r *http.Request) {
+	api, err := Build(h.routes(), h.resolve)
+	if err != nil {
+		slog.Error("failed to build OpenAPI document", "error", err)
+		httperr.Send(w, r, http.StatusInternalServerError, "OPENAPI_UNAVAILABLE", fmt.Sprintf("Failed to build OpenAPI document: %v", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	w.Write(OpenAPI(api, "the gateway's route table"))
+}
+
+// HandleDocs serves the Swagger UI page under GET /docs
+func (h *Handler) HandleDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	swaggerUIPage.Execute(w, struct{ Assets, Spec string }{h.assetsURL, h.specPath})
+}
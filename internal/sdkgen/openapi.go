@@ -0,0 +1,200 @@
+package sdkgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// openAPIVersion is the OpenAPI version generated; 3.0 keeps Swagger UI and
+// most code generators happy
+const openAPIVersion = "3.0.3"
+
+// errorSchema is the component describing the gateway's error body
+const errorSchema = "Error"
+
+// OpenAPI renders the API as an OpenAPI 3 document in JSON
+func OpenAPI(api *API, source string) []byte {
+	messages := make(map[string]Message, len(api.Messages))
+	schemas := map[string]any{
+		errorSchema: map[string]any{
+			"type":     "object",
+			"required": []string{"error"},
+			"properties": map[string]any{
+				"error": map[string]any{
+					"type":     "object",
+					"required": []string{"code", "message", "timestamp"},
+					"properties": map[string]any{
+						"code":      map[string]any{"type": "string"},
+						"message":   map[string]any{"type": "string"},
+						"requestId": map[string]any{"type": "string"},
+						"timestamp": map[string]any{"type": "string", "format": "date-time"},
+						"details": map[string]any{
+							"type":  "array",
+							"items": map[string]any{"type": "object", "additionalProperties": true},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, message := range api.Messages {
+		messages[message.Name] = message
+		schemas[message.Name] = messageSchema(message, nil)
+	}
+	for _, enum := range api.Enums {
+		schemas[enum.Name] = map[string]any{"type": "string", "enum": enum.Values}
+	}
+
+	paths := make(map[string]map[string]any)
+	for _, op := range api.Operations {
+		if paths[op.Path] == nil {
+			paths[op.Path] = make(map[string]any)
+		}
+		paths[op.Path][strings.ToLower(op.Method)] = openAPIOperation(op, messages)
+	}
+
+	description := fmt.Sprintf("Generated from %s.", source)
+	if len(api.Skipped) > 0 {
+		description += " Not described:\n\n- " + strings.Join(api.Skipped, "\n- ")
+	}
+
+	document := map[string]any{
+		"openapi": openAPIVersion,
+		"info": map[string]any{
+			"title":       "Hub API Gateway",
+			"version":     "v1",
+			"description": description,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"},
+			},
+		},
+	}
+
+	// Only maps, slices and strings: encoding can't fail
+	out, _ := json.MarshalIndent(document, "", "  ")
+	return append(out, '\n')
+}
+
+// openAPIOperation renders an operation
+func openAPIOperation(op Operation, messages map[string]Message) map[string]any {
+	summary := op.Description
+	if summary == "" {
+		summary = op.Name
+	}
+	operation := map[string]any{
+		"operationId": camelCase(op.Name),
+		"summary":     summary,
+	}
+
+	if len(op.PathParams) > 0 {
+		var parameters []any
+		for _, param := range op.PathParams {
+			parameters = append(parameters, map[string]any{
+				"name":     param,
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]any{"type": "string"},
+			})
+		}
+		operation["parameters"] = parameters
+	}
+
+	if op.Request != "" {
+		var schema any = schemaRef(op.Request)
+		if len(op.OmitFields) > 0 {
+			schema = messageSchema(messages[op.Request], op.OmitFields)
+		}
+		operation["requestBody"] = map[string]any{
+			"required": true,
+			"content":  map[string]any{"application/json": map[string]any{"schema": schema}},
+		}
+	}
+
+	response := typeSchema(op.Response)
+	if len(op.ResponseOmit) > 0 && op.Response.Kind == KindMessage {
+		response = messageSchema(messages[op.Response.Ref], op.ResponseOmit)
+	}
+	if op.ResponseNullable {
+		response = nullable(response)
+	}
+	operation["responses"] = map[string]any{
+		"200": map[string]any{
+			"description": "OK",
+			"content":     map[string]any{"application/json": map[string]any{"schema": response}},
+		},
+		"default": map[string]any{
+			"description": "Error",
+			"content":     map[string]any{"application/json": map[string]any{"schema": schemaRef(errorSchema)}},
+		},
+	}
+
+	if op.AuthRequired {
+		operation["security"] = []any{map[string]any{"bearerAuth": []string{}}}
+	}
+	return operation
+}
+
+// messageSchema renders a message as an object schema, without the omitted fields
+func messageSchema(message Message, omit []string) map[string]any {
+	properties := make(map[string]any, len(message.Fields))
+	for _, field := range message.Fields {
+		if slices.Contains(omit, field.Name) {
+			continue
+		}
+		schema := typeSchema(field.Type)
+		if field.Nullable {
+			schema = nullable(schema)
+		}
+		properties[field.Name] = schema
+	}
+	return map[string]any{
+		"type":        "object",
+		"description": message.FullName,
+		"properties":  properties,
+	}
+}
+
+// typeSchema renders a type as a schema
+func typeSchema(t Type) map[string]any {
+	switch t.Kind {
+	case KindString:
+		return map[string]any{"type": "string"}
+	case KindNumber:
+		return map[string]any{"type": "number"}
+	case KindBool:
+		return map[string]any{"type": "boolean"}
+	case KindInt64:
+		return map[string]any{"type": "string", "format": "int64"}
+	case KindBytes:
+		return map[string]any{"type": "string", "format": "byte"}
+	case KindEnum, KindMessage:
+		return schemaRef(t.Ref)
+	case KindList:
+		return map[string]any{"type": "array", "items": typeSchema(*t.Elem)}
+	case KindMap:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(*t.Elem)}
+	default:
+		return map[string]any{}
+	}
+}
+
+// nullable marks a schema as accepting null; references can't carry
+// siblings in OpenAPI 3.0, so they are wrapped in allOf
+func nullable(schema map[string]any) map[string]any {
+	if _, ok := schema["$ref"]; ok {
+		return map[string]any{"allOf": []any{schema}, "nullable": true}
+	}
+	schema["nullable"] = true
+	return schema
+}
+
+// schemaRef references a component schema
+func schemaRef(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
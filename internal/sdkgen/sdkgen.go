@@ -0,0 +1,411 @@
+// Package sdkgen builds a typed description of the gateway's public surface
+// from the route table and the backends' proto descriptors, and renders it as
+// client SDKs and OpenAPI documents. Types follow what the gateway actually sends: protojson with
+// proto field names, api_response unwrapped, string_fields as strings.
+package sdkgen
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"hub-api-gateway/internal/router"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// apiResponseField is the status wrapper the gateway strips from successful responses
+const apiResponseField = "api_response"
+
+// userIDField is filled by the gateway from the caller's credentials on authenticated routes
+const userIDField = "user_id"
+
+// MethodResolver resolves a route to its gRPC method
+type MethodResolver func(route *router.Route) (protoreflect.MethodDescriptor, error)
+
+// Kind is the JSON shape of a type
+type Kind int
+
+const (
+	KindString Kind = iota
+	KindNumber
+	KindBool
+	KindInt64 // 64-bit integers, sent as JSON strings
+	KindBytes // base64 strings
+	KindEnum
+	KindMessage
+	KindList
+	KindMap  // JSON object with string keys
+	KindJSON // arbitrary JSON (google.protobuf.Struct, Value, Any)
+)
+
+// Type describes a field or response type
+type Type struct {
+	Kind Kind
+	Ref  string // Message or enum name for KindMessage and KindEnum
+	Elem *Type  // Element type for KindList and KindMap
+}
+
+// Field is a message field as it appears in JSON
+type Field struct {
+	Name     string
+	Type     Type
+	Nullable bool // Unset messages and optional scalars are sent as null
+}
+
+// Message is a proto message rendered as a JSON object type
+type Message struct {
+	Name     string
+	FullName string
+	Fields   []Field
+}
+
+// Enum is a proto enum, sent as the value name
+type Enum struct {
+	Name   string
+	Values []string
+}
+
+// Operation is one route exposed as a client method
+type Operation struct {
+	Name         string // Route name, e.g. get-order-details
+	Method       string
+	Path         string
+	Description  string
+	AuthRequired bool
+	PathParams   []string
+
+	// Request body type and the fields the gateway fills itself; Request is
+	// empty for methods that carry no body
+	Request    string
+	OmitFields []string
+
+	// Response is the type of a successful response body
+	Response         Type
+	ResponseNullable bool     // The unwrapped field is a message that may be null
+	ResponseOmit     []string // Fields removed from Response when it is a message
+}
+
+// API is the client surface of the gateway
+type API struct {
+	Operations []Operation
+	Messages   []Message
+	Enums      []Enum
+	Skipped    []string // Routes that can't be expressed as a client method, with the reason
+}
+
+// builder collects the messages and enums reachable from the routes
+type builder struct {
+	api      *API
+	names    map[protoreflect.FullName]string
+	messages map[protoreflect.FullName]int // Index into api.Messages
+	enums    map[protoreflect.FullName]bool
+	taken    map[string]protoreflect.FullName
+}
+
+// Build describes every route of the table. Routes whose method can't be
+// resolved fail the build, as they would fail to load in the gateway.
+func Build(routes []router.Route, resolve MethodResolver) (*API, error) {
+	b := &builder{
+		api:      &API{},
+		names:    make(map[protoreflect.FullName]string),
+		messages: make(map[protoreflect.FullName]int),
+		enums:    make(map[protoreflect.FullName]bool),
+		taken:    make(map[string]protoreflect.FullName),
+	}
+
+	sorted := make([]router.Route, len(routes))
+	copy(sorted, routes)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	for i := range sorted {
+		route := &sorted[i]
+		if strings.Contains(route.Path, "*") {
+			b.api.Skipped = append(b.api.Skipped, fmt.Sprintf("%s: wildcard paths have no fixed client method", route.Name))
+			continue
+		}
+		if route.Stream != "" {
+			b.api.Skipped = append(b.api.Skipped, fmt.Sprintf("%s: streaming routes are consumed with EventSource or fetch", route.Name))
+			continue
+		}
+		if route.IsAggregate() {
+			b.api.Skipped = append(b.api.Skipped, fmt.Sprintf("%s: aggregate responses merge several methods", route.Name))
+			continue
+		}
+		if route.FeatureFlag != nil {
+			b.api.Skipped = append(b.api.Skipped, fmt.Sprintf("%s: dark-launched behind feature flag %s", route.Name, route.FeatureFlag.Name))
+			continue
+		}
+
+		// Weighted upstreams share a contract; the first one describes it
+		method, err := resolve(&route.Targets()[0])
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", route.Name, err)
+		}
+
+		op, err := b.operation(route, method)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", route.Name, err)
+		}
+		b.api.Operations = append(b.api.Operations, op)
+	}
+
+	sort.Slice(b.api.Messages, func(i, j int) bool { return b.api.Messages[i].Name < b.api.Messages[j].Name })
+	sort.Slice(b.api.Enums, func(i, j int) bool { return b.api.Enums[i].Name < b.api.Enums[j].Name })
+	return b.api, nil
+}
+
+// operation describes one route
+func (b *builder) operation(route *router.Route, method protoreflect.MethodDescriptor) (Operation, error) {
+	op := Operation{
+		Name:         route.Name,
+		Method:       strings.ToUpper(route.Method),
+		Path:         route.Path,
+		Description:  route.Description,
+		AuthRequired: route.RequiresAuth(),
+		PathParams:   route.PathVariables(),
+	}
+	if op.Method == "" {
+		op.Method = http.MethodPost // Routes without a method accept any; POST carries a body
+	}
+
+	if op.Method != http.MethodGet && op.Method != http.MethodHead {
+		op.Request = b.message(method.Input())
+		for _, variable := range op.PathParams {
+			op.OmitFields = append(op.OmitFields, route.PathFieldFor(variable))
+		}
+		if op.AuthRequired && method.Input().Fields().ByName(userIDField) != nil {
+			op.OmitFields = append(op.OmitFields, userIDField)
+		}
+	}
+
+	// Mirror the gateway's unwrapping: api_response is dropped and a single
+	// remaining field replaces the whole body
+	output := method.Output()
+	op.Response = Type{Kind: KindMessage, Ref: b.message(output)}
+	if output.Fields().ByName(apiResponseField) != nil {
+		op.ResponseOmit = []string{apiResponseField}
+		if output.Fields().Len() == 2 {
+			for _, field := range b.api.Messages[b.messages[output.FullName()]].Fields {
+				if field.Name != apiResponseField {
+					op.Response = field.Type
+					op.ResponseNullable = field.Nullable
+					op.ResponseOmit = nil
+				}
+			}
+		}
+	}
+
+	for _, path := range route.StringFields {
+		if err := b.stringify(op.Response, strings.Split(path, ".")); err != nil {
+			return Operation{}, fmt.Errorf("string_fields %s: %w", path, err)
+		}
+	}
+	return op, nil
+}
+
+// stringify retypes the numeric field at path as a string. Messages are
+// shared, so the field is a string for every route using the message.
+func (b *builder) stringify(t Type, path []string) error {
+	if len(path) == 0 {
+		return nil
+	}
+
+	segment := path[0]
+	if segment == "*" {
+		if t.Kind != KindList && t.Kind != KindMap {
+			return fmt.Errorf("* used on a field that is not a list or map")
+		}
+		return b.stringify(*t.Elem, path[1:])
+	}
+	if t.Kind != KindMessage {
+		return fmt.Errorf("%s is not a message field", segment)
+	}
+
+	for _, message := range b.api.Messages {
+		if message.Name != t.Ref {
+			continue
+		}
+		// Fields shares its backing array with b.api.Messages, so retype sticks
+		for i := range message.Fields {
+			field := &message.Fields[i]
+			if field.Name != segment {
+				continue
+			}
+			if len(path) > 1 {
+				return b.stringify(field.Type, path[1:])
+			}
+			retype(&field.Type)
+			return nil
+		}
+		return fmt.Errorf("field %s not found in %s", segment, message.FullName)
+	}
+	return fmt.Errorf("unknown message %s", t.Ref)
+}
+
+// retype turns numbers (or lists and maps of numbers) into strings
+func retype(t *Type) {
+	switch t.Kind {
+	case KindNumber, KindInt64:
+		t.Kind = KindString
+	case KindList, KindMap:
+		retype(t.Elem)
+	}
+}
+
+// message registers a message and everything it references, returning its name
+func (b *builder) message(descriptor protoreflect.MessageDescriptor) string {
+	if name, ok := b.names[descriptor.FullName()]; ok {
+		return name
+	}
+
+	name := b.typeName(descriptor.FullName(), descriptor.ParentFile().Package())
+	b.api.Messages = append(b.api.Messages, Message{Name: name, FullName: string(descriptor.FullName())})
+	index := len(b.api.Messages) - 1
+	b.messages[descriptor.FullName()] = index
+
+	fields := descriptor.Fields()
+	var described []Field
+	for i := 0; i < fields.Len(); i++ {
+		field := fields.Get(i)
+		described = append(described, Field{
+			Name:     string(field.Name()),
+			Type:     b.fieldType(field),
+			Nullable: field.HasPresence() && !field.IsList() && !field.IsMap(),
+		})
+	}
+
+	// Set after recursion, which may have grown the slice
+	b.api.Messages[index].Fields = described
+	return name
+}
+
+// fieldType maps a field to its JSON type
+func (b *builder) fieldType(field protoreflect.FieldDescriptor) Type {
+	if field.IsMap() {
+		value := b.fieldType(field.MapValue())
+		return Type{Kind: KindMap, Elem: &value}
+	}
+
+	t := b.scalarType(field)
+	if field.IsList() {
+		return Type{Kind: KindList, Elem: &t}
+	}
+	return t
+}
+
+// scalarType maps a singular field to its JSON type
+func (b *builder) scalarType(field protoreflect.FieldDescriptor) Type {
+	switch field.Kind() {
+	case protoreflect.StringKind:
+		return Type{Kind: KindString}
+	case protoreflect.BoolKind:
+		return Type{Kind: KindBool}
+	case protoreflect.BytesKind:
+		return Type{Kind: KindBytes}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return Type{Kind: KindInt64}
+	case protoreflect.EnumKind:
+		return Type{Kind: KindEnum, Ref: b.enum(field.Enum())}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		if t, ok := wellKnownTypes[field.Message().FullName()]; ok {
+			return t
+		}
+		if elem := wrapperElem(field); elem != nil {
+			return *elem
+		}
+		return Type{Kind: KindMessage, Ref: b.message(field.Message())}
+	default:
+		return Type{Kind: KindNumber}
+	}
+}
+
+// wellKnownTypes have a dedicated JSON form in protojson
+var wellKnownTypes = map[protoreflect.FullName]Type{
+	"google.protobuf.Timestamp": {Kind: KindString},
+	"google.protobuf.Duration":  {Kind: KindString},
+	"google.protobuf.FieldMask": {Kind: KindString},
+	"google.protobuf.Struct":    {Kind: KindJSON},
+	"google.protobuf.Value":     {Kind: KindJSON},
+	"google.protobuf.ListValue": {Kind: KindJSON},
+	"google.protobuf.Any":       {Kind: KindJSON},
+}
+
+// wrapperElem returns the JSON type of a google.protobuf wrapper field, or nil
+func wrapperElem(field protoreflect.FieldDescriptor) *Type {
+	if field.Message() == nil || field.IsList() || field.IsMap() {
+		return nil
+	}
+
+	var t Type
+	switch field.Message().FullName() {
+	case "google.protobuf.StringValue":
+		t = Type{Kind: KindString}
+	case "google.protobuf.BoolValue":
+		t = Type{Kind: KindBool}
+	case "google.protobuf.BytesValue":
+		t = Type{Kind: KindBytes}
+	case "google.protobuf.Int64Value", "google.protobuf.UInt64Value":
+		t = Type{Kind: KindInt64}
+	case "google.protobuf.Int32Value", "google.protobuf.UInt32Value",
+		"google.protobuf.FloatValue", "google.protobuf.DoubleValue":
+		t = Type{Kind: KindNumber}
+	default:
+		return nil
+	}
+	return &t
+}
+
+// enum registers an enum, returning its name
+func (b *builder) enum(descriptor protoreflect.EnumDescriptor) string {
+	name := b.typeName(descriptor.FullName(), descriptor.ParentFile().Package())
+	if b.enums[descriptor.FullName()] {
+		return name
+	}
+	b.enums[descriptor.FullName()] = true
+
+	enum := Enum{Name: name}
+	values := descriptor.Values()
+	for i := 0; i < values.Len(); i++ {
+		enum.Values = append(enum.Values, string(values.Get(i).Name()))
+	}
+	b.api.Enums = append(b.api.Enums, enum)
+	return name
+}
+
+// typeName names a type after its package-relative name (Order.Item becomes
+// Order_Item), qualifying it with the package when two packages collide
+func (b *builder) typeName(fullName, pkg protoreflect.FullName) string {
+	if name, ok := b.names[fullName]; ok {
+		return name
+	}
+
+	relative := strings.TrimPrefix(string(fullName), string(pkg)+".")
+	name := strings.ReplaceAll(relative, ".", "_")
+	if owner, ok := b.taken[name]; ok && owner != fullName {
+		name = pascalCase(string(pkg)) + "_" + name
+	}
+	b.taken[name] = fullName
+	b.names[fullName] = name
+	return name
+}
+
+// pascalCase joins words separated by -, _, . or spaces, e.g. hub_investments -> HubInvestments
+func pascalCase(s string) string {
+	words := strings.FieldsFunc(s, func(r rune) bool { return r == '-' || r == '_' || r == '.' || r == ' ' })
+	for i, word := range words {
+		words[i] = strings.ToUpper(word[:1]) + word[1:]
+	}
+	return strings.Join(words, "")
+}
+
+// camelCase is pascalCase with a lowercase first letter, e.g. get-order-details -> getOrderDetails
+func camelCase(s string) string {
+	pascal := pascalCase(s)
+	if pascal == "" {
+		return pascal
+	}
+	return strings.ToLower(pascal[:1]) + pascal[1:]
+}
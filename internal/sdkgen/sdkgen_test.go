@@ -0,0 +1,137 @@
+package sdkgen
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"hub-api-gateway/internal/proxy"
+	"hub-api-gateway/internal/router"
+)
+
+var testRoutes = []router.Route{
+	{
+		Name: "submit-order", Path: "/api/v1/orders", Method: "POST", Service: "hub-monolith",
+		GRPCService: "OrderService", GRPCMethod: "SubmitOrder", AuthRequired: true,
+	},
+	{
+		Name: "get-order-details", Path: "/api/v1/orders/{id}", Method: "GET", Service: "hub-monolith",
+		GRPCService: "OrderService", GRPCMethod: "GetOrderDetails", AuthRequired: true,
+		PathFields: map[string]string{"id": "order_id"}, StringFields: []string{"estimated_value"},
+	},
+	{
+		Name: "static-assets", Path: "/static/*", Method: "GET", Service: "hub-monolith",
+		GRPCService: "OrderService", GRPCMethod: "GetOrderDetails",
+	},
+}
+
+func TestBuild(t *testing.T) {
+	api, err := Build(testRoutes, proxy.NewDescriptorRegistry().FindMethod)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	if len(api.Operations) != 2 || len(api.Skipped) != 1 {
+		t.Fatalf("expected 2 operations and 1 skipped route, got %d and %v", len(api.Operations), api.Skipped)
+	}
+
+	details := api.Operations[0]
+	if details.Name != "get-order-details" || details.Request != "" {
+		t.Errorf("GET operation should have no body, got %+v", details)
+	}
+	if details.Response.Kind != KindMessage || details.Response.Ref != "OrderDetails" || !details.ResponseNullable {
+		t.Errorf("expected the response unwrapped to a nullable OrderDetails, got %+v", details.Response)
+	}
+
+	submit := api.Operations[1]
+	if strings.Join(submit.OmitFields, ",") != "user_id" {
+		t.Errorf("expected user_id to be filled by the gateway, got %v", submit.OmitFields)
+	}
+	if strings.Join(submit.ResponseOmit, ",") != "api_response" {
+		t.Errorf("expected api_response omitted from the response, got %v", submit.ResponseOmit)
+	}
+
+	for _, message := range api.Messages {
+		if message.Name != "OrderDetails" {
+			continue
+		}
+		for _, field := range message.Fields {
+			if field.Name == "estimated_value" && field.Type.Kind != KindString {
+				t.Errorf("string_fields should type estimated_value as a string, got %v", field.Type.Kind)
+			}
+		}
+	}
+}
+
+func TestBuild_UnknownMethod(t *testing.T) {
+	routes := []router.Route{{Name: "missing", Path: "/api/v1/missing", Method: "GET", GRPCService: "OrderService", GRPCMethod: "Missing"}}
+	if _, err := Build(routes, proxy.NewDescriptorRegistry().FindMethod); err == nil {
+		t.Fatal("expected an error for a route the gateway would reject")
+	}
+}
+
+func TestOpenAPI(t *testing.T) {
+	api, err := Build(testRoutes, proxy.NewDescriptorRegistry().FindMethod)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+
+	var doc struct {
+		OpenAPI string `json:"openapi"`
+		Paths   map[string]map[string]struct {
+			OperationID string           `json:"operationId"`
+			Parameters  []map[string]any `json:"parameters"`
+			RequestBody struct {
+				Content map[string]struct {
+					Schema struct {
+						Properties map[string]any `json:"properties"`
+					} `json:"schema"`
+				} `json:"content"`
+			} `json:"requestBody"`
+			Security []map[string][]string `json:"security"`
+		} `json:"paths"`
+		Components struct {
+			Schemas map[string]map[string]any `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(OpenAPI(api, "routes.yaml"), &doc); err != nil {
+		t.Fatalf("generated document is not valid JSON: %v", err)
+	}
+
+	if doc.OpenAPI != openAPIVersion || len(doc.Paths) != 2 {
+		t.Fatalf("expected two paths in an OpenAPI %s document, got %s with %d", openAPIVersion, doc.OpenAPI, len(doc.Paths))
+	}
+	details := doc.Paths["/api/v1/orders/{id}"]["get"]
+	if details.OperationID != "getOrderDetails" || len(details.Parameters) != 1 || details.Parameters[0]["name"] != "id" || len(details.Security) != 1 {
+		t.Errorf("unexpected get-order-details operation %+v", details)
+	}
+	body := doc.Paths["/api/v1/orders"]["post"].RequestBody.Content["application/json"].Schema.Properties
+	if _, ok := body["user_id"]; ok || len(body) == 0 {
+		t.Errorf("expected request body without user_id, got %v", body)
+	}
+	if doc.Components.Schemas["OrderDetails"] == nil || doc.Components.Schemas[errorSchema] == nil {
+		t.Errorf("expected message and error schemas, got %d schemas", len(doc.Components.Schemas))
+	}
+}
+
+func TestTypeScript(t *testing.T) {
+	api, err := Build(testRoutes, proxy.NewDescriptorRegistry().FindMethod)
+	if err != nil {
+		t.Fatalf("Build() error = %v", err)
+	}
+	out := string(TypeScript(api, "routes.yaml"))
+
+	for _, want := range []string{
+		"getOrderDetails(id: string, init?: RequestOptions): Promise<OrderDetails | null>",
+		"`/api/v1/orders/${encodeURIComponent(id)}`",
+		`submitOrder(body: Partial<Omit<SubmitOrderRequest, "user_id">>, init?: RequestOptions): Promise<Omit<SubmitOrderResponse, "api_response">>`,
+		"  estimated_value: string;",
+		"  price: number | null;",
+		"export class GatewayError extends Error",
+		"// Skipped static-assets",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in generated client", want)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package sdkgen
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// pathParam matches {name} path variables
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// typescriptRuntime is the fixed part of the TypeScript client: error types,
+// options and the request helper every generated method goes through
+const typescriptRuntime = `/** Error body sent by the gateway; problem+json fields are set when enabled */
+export interface GatewayErrorBody {
+  error?: string | { code: string; message: string; requestId?: string; timestamp?: string; details?: Array<Record<string, unknown>> };
+  code?: string;
+  retry_after_seconds?: number;
+  details?: Array<Record<string, unknown>>;
+  type?: string;
+  title?: string;
+  status?: number;
+  detail?: string;
+}
+
+/** Thrown for every non-2xx response */
+export class GatewayError extends Error {
+  constructor(
+    readonly status: number,
+    readonly code: string,
+    message: string,
+    readonly requestId?: string,
+    readonly retryAfterSeconds?: number,
+    readonly body?: GatewayErrorBody,
+  ) {
+    super(message);
+    this.name = "GatewayError";
+  }
+}
+
+export interface ClientOptions {
+  /** Gateway base URL, e.g. https://api.example.com */
+  baseUrl: string;
+  /** Supplies the bearer token for authenticated routes; defaults to the token from login() */
+  token?: () => string | undefined | Promise<string | undefined>;
+  /** Headers sent with every request */
+  headers?: Record<string, string>;
+  /** Set to "include" when the gateway issues session cookies on another origin */
+  credentials?: RequestCredentials;
+  fetch?: typeof fetch;
+}
+
+export interface RequestOptions {
+  signal?: AbortSignal;
+  headers?: Record<string, string>;
+}
+
+export interface LoginResponse {
+  /** Absent when the gateway issues session cookies; csrfToken is set instead */
+  token?: string;
+  csrfToken?: string;
+  expiresIn: number;
+  userId: string;
+  email: string;
+}
+
+export class GatewayClient {
+  private token?: string;
+  private csrfToken?: string;
+
+  constructor(private readonly options: ClientOptions) {}
+
+  /**
+   * Exchanges credentials for a token used by every authenticated call. After
+   * repeated failures the gateway answers CHALLENGE_REQUIRED; solve the challenge
+   * described in the error details and retry with its token.
+   */
+  async login(email: string, password: string, init?: RequestOptions, challengeToken?: string): Promise<LoginResponse> {
+    const response = await this.request<LoginResponse>("POST", "/api/v1/auth/login", false, { email, password, challengeToken }, init);
+    this.token = response.token;
+    this.csrfToken = response.csrfToken;
+    return response;
+  }
+
+  /** Forgets the token obtained by login() */
+  logout(): void {
+    this.token = undefined;
+    this.csrfToken = undefined;
+  }
+/* methods */
+  private async request<T>(method: string, path: string, auth: boolean, body?: unknown, init?: RequestOptions): Promise<T> {
+    const headers: Record<string, string> = { Accept: "application/json", ...this.options.headers, ...init?.headers };
+    if (body !== undefined) {
+      headers["Content-Type"] = "application/json";
+    }
+    if (auth) {
+      const token = this.options.token ? await this.options.token() : this.token;
+      if (token) {
+        headers["Authorization"] = "Bearer " + token;
+      }
+    }
+    if (this.csrfToken && method !== "GET" && method !== "HEAD") {
+      headers["X-CSRF-Token"] = this.csrfToken;
+    }
+
+    const doFetch = this.options.fetch ?? fetch;
+    const response = await doFetch(this.options.baseUrl.replace(/\/$/, "") + path, {
+      method,
+      headers,
+      body: body === undefined ? undefined : JSON.stringify(body),
+      credentials: this.options.credentials,
+      signal: init?.signal,
+    });
+
+    const text = await response.text();
+    const parsed = text ? JSON.parse(text) : undefined;
+    if (!response.ok) {
+      throw toGatewayError(response, parsed);
+    }
+    return parsed as T;
+  }
+}
+
+function toGatewayError(response: Response, body: GatewayErrorBody | undefined): GatewayError {
+  const requestId = response.headers.get("X-Request-ID") ?? undefined;
+  const retryAfter = Number(response.headers.get("Retry-After")) || body?.retry_after_seconds;
+  if (body && typeof body.error === "object") {
+    return new GatewayError(response.status, body.error.code, body.error.message, body.error.requestId ?? requestId, retryAfter, body);
+  }
+  const code = body?.code ?? "HTTP_" + response.status;
+  const message = (typeof body?.error === "string" ? body.error : body?.detail) ?? response.statusText;
+  return new GatewayError(response.status, code, message, requestId, retryAfter, body);
+}
+`
+
+// TypeScript renders the API as a single dependency-free TypeScript module
+func TypeScript(api *API, source string) []byte {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("// Code generated by gensdk from %s. DO NOT EDIT.\n\n", source))
+	for _, skipped := range api.Skipped {
+		sb.WriteString(fmt.Sprintf("// Skipped %s\n", skipped))
+	}
+	if len(api.Skipped) > 0 {
+		sb.WriteString("\n")
+	}
+
+	for _, enum := range api.Enums {
+		values := make([]string, len(enum.Values))
+		for i, value := range enum.Values {
+			values[i] = strconv.Quote(value)
+		}
+		sb.WriteString(fmt.Sprintf("export type %s = %s;\n\n", enum.Name, strings.Join(values, " | ")))
+	}
+
+	for _, message := range api.Messages {
+		sb.WriteString(fmt.Sprintf("/** %s */\n", message.FullName))
+		sb.WriteString(fmt.Sprintf("export interface %s {\n", message.Name))
+		for _, field := range message.Fields {
+			t := tsType(field.Type)
+			if field.Nullable {
+				t += " | null"
+			}
+			sb.WriteString(fmt.Sprintf("  %s: %s;\n", field.Name, t))
+		}
+		sb.WriteString("}\n\n")
+	}
+
+	var methods strings.Builder
+	for _, op := range api.Operations {
+		methods.WriteString("\n")
+		methods.WriteString(tsMethod(op))
+	}
+	sb.WriteString(strings.Replace(typescriptRuntime, "/* methods */\n", methods.String()+"\n", 1))
+	return []byte(sb.String())
+}
+
+// tsMethod renders the client method for an operation
+func tsMethod(op Operation) string {
+	var params []string
+	for _, param := range op.PathParams {
+		params = append(params, camelCase(param)+": string")
+	}
+
+	body := "undefined"
+	if op.Request != "" {
+		request := op.Request
+		if len(op.OmitFields) > 0 {
+			request = fmt.Sprintf("Omit<%s, %s>", request, tsUnion(op.OmitFields))
+		}
+		params = append(params, "body: Partial<"+request+">")
+		body = "body"
+	}
+	params = append(params, "init?: RequestOptions")
+
+	response := tsType(op.Response)
+	if len(op.ResponseOmit) > 0 {
+		response = fmt.Sprintf("Omit<%s, %s>", response, tsUnion(op.ResponseOmit))
+	}
+	if op.ResponseNullable {
+		response += " | null"
+	}
+
+	path := pathParam.ReplaceAllStringFunc(op.Path, func(match string) string {
+		return "${encodeURIComponent(" + camelCase(match[1:len(match)-1]) + ")}"
+	})
+
+	var sb strings.Builder
+	doc := op.Description
+	if doc == "" {
+		doc = op.Name
+	}
+	sb.WriteString(fmt.Sprintf("  /** %s (%s %s) */\n", doc, op.Method, op.Path))
+	sb.WriteString(fmt.Sprintf("  %s(%s): Promise<%s> {\n", camelCase(op.Name), strings.Join(params, ", "), response))
+	sb.WriteString(fmt.Sprintf("    return this.request(%q, `%s`, %t, %s, init);\n", op.Method, path, op.AuthRequired, body))
+	sb.WriteString("  }\n")
+	return sb.String()
+}
+
+// tsType renders a type reference
+func tsType(t Type) string {
+	switch t.Kind {
+	case KindString, KindInt64, KindBytes:
+		return "string"
+	case KindNumber:
+		return "number"
+	case KindBool:
+		return "boolean"
+	case KindEnum, KindMessage:
+		return t.Ref
+	case KindList:
+		return "Array<" + tsType(*t.Elem) + ">"
+	case KindMap:
+		return "Record<string, " + tsType(*t.Elem) + ">"
+	default:
+		return "unknown"
+	}
+}
+
+// tsUnion renders names as a union of string literals
+func tsUnion(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = strconv.Quote(name)
+	}
+	return strings.Join(quoted, " | ")
+}
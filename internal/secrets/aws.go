@@ -0,0 +1,162 @@
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// AWSCredentials are the access keys requests to AWS are signed with
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Set for temporary credentials
+}
+
+// AWSSecretsManager reads secrets from AWS Secrets Manager. A ref is the
+// secret's name or ARN; a secret holding JSON key/value pairs needs #key to
+// pick one.
+type AWSSecretsManager struct {
+	region      string
+	endpoint    string
+	credentials AWSCredentials
+	client      *http.Client
+}
+
+// NewAWSSecretsManager creates an AWS Secrets Manager provider for region.
+// endpoint overrides https://secretsmanager.<region>.amazonaws.com, e.g. for a
+// VPC endpoint.
+func NewAWSSecretsManager(region, endpoint string, credentials AWSCredentials, timeout time.Duration) *AWSSecretsManager {
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", region)
+	}
+	return &AWSSecretsManager{
+		region:      region,
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+		credentials: credentials,
+		client:      &http.Client{Timeout: timeout},
+	}
+}
+
+// Name identifies the provider in logs
+func (a *AWSSecretsManager) Name() string {
+	return "aws"
+}
+
+// Fetch reads the current version of the secret ref points to
+func (a *AWSSecretsManager) Fetch(ctx context.Context, ref string) (string, error) {
+	id, key, _ := strings.Cut(ref, "#")
+	body, err := json.Marshal(map[string]string{"SecretId": id})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	a.sign(req, body, time.Now())
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach secrets manager: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var failure struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		json.NewDecoder(io.LimitReader(resp.Body, 4096)).Decode(&failure)
+		if strings.HasSuffix(failure.Type, "ResourceNotFoundException") {
+			return "", ErrNotFound
+		}
+		return "", fmt.Errorf("secrets manager returned %s: %s %s", resp.Status, failure.Type, failure.Message)
+	}
+
+	var secret struct {
+		SecretString *string `json:"SecretString"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("invalid secrets manager response: %w", err)
+	}
+	if secret.SecretString == nil {
+		return "", fmt.Errorf("binary secrets are not supported")
+	}
+	if key == "" {
+		return *secret.SecretString, nil
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(*secret.SecretString), &fields); err != nil {
+		return "", fmt.Errorf("secret is not JSON, drop #%s: %w", key, err)
+	}
+	return pickField(fields, key)
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to req
+func (a *AWSSecretsManager) sign(req *http.Request, body []byte, now time.Time) {
+	timestamp := now.UTC().Format("20060102T150405Z")
+	date := timestamp[:8]
+	req.Header.Set("X-Amz-Date", timestamp)
+	if a.credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", a.credentials.SessionToken)
+	}
+
+	// Every header set above is signed, in alphabetical order
+	names := []string{"content-type", "host", "x-amz-date"}
+	if a.credentials.SessionToken != "" {
+		names = append(names, "x-amz-security-token")
+	}
+	names = append(names, "x-amz-target")
+
+	var headers strings.Builder
+	for _, name := range names {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		headers.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{req.Method, path, req.URL.RawQuery, headers.String(), signedHeaders, hashHex(body)}, "\n")
+
+	scope := date + "/" + a.region + "/secretsmanager/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", timestamp, scope, hashHex([]byte(canonicalRequest))}, "\n")
+
+	key := []byte("AWS4" + a.credentials.SecretAccessKey)
+	for _, part := range []string{date, a.region, "secretsmanager", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		a.credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
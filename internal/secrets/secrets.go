@@ -0,0 +1,194 @@
+// Package secrets fetches gateway settings such as JWT_SECRET, REDIS_PASSWORD
+// and the TLS key pair from a secrets manager (HashiCorp Vault or AWS Secrets
+// Manager) instead of the environment, and refetches them periodically so
+// rotated secrets apply without a restart.
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Provider fetches secrets from a secrets manager
+type Provider interface {
+	// Name identifies the provider in logs
+	Name() string
+	// Fetch returns the secret ref points to: the secret's path or name,
+	// optionally followed by #field to pick one field of a secret holding
+	// several
+	Fetch(ctx context.Context, ref string) (string, error)
+}
+
+// ErrNotFound is returned when a secret or one of its fields doesn't exist
+var ErrNotFound = errors.New("secret not found")
+
+// rotation runs fn when any of names changes
+type rotation struct {
+	names []string
+	fn    func() error
+}
+
+// Store holds the secrets of the settings mapped to a secrets manager
+type Store struct {
+	provider Provider
+	refs     map[string]string // Setting name -> secret reference
+	timeout  time.Duration
+
+	mu        sync.RWMutex
+	values    map[string]string
+	rotations []rotation
+}
+
+// NewStore creates a store for refs (setting name -> secret reference); call
+// Load before using it
+func NewStore(provider Provider, refs map[string]string, timeout time.Duration) *Store {
+	return &Store{
+		provider: provider,
+		refs:     refs,
+		timeout:  timeout,
+		values:   make(map[string]string),
+	}
+}
+
+// Load fetches every secret, failing if any can't be fetched
+func (s *Store) Load(ctx context.Context) error {
+	values, errs := s.fetch(ctx)
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values = values
+	return nil
+}
+
+// fetch fetches every secret; values holds those fetched successfully
+func (s *Store) fetch(ctx context.Context) (map[string]string, []error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	values := make(map[string]string, len(s.refs))
+	var errs []error
+	for _, name := range slices.Sorted(maps.Keys(s.refs)) {
+		value, err := s.provider.Fetch(ctx, s.refs[name])
+		if err == nil && value == "" {
+			err = fmt.Errorf("secret is empty")
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s from %s %s: %w", name, s.provider.Name(), s.refs[name], err))
+			continue
+		}
+		values[name] = value
+	}
+	return values, errs
+}
+
+// Has reports whether setting name comes from the secrets manager
+func (s *Store) Has(name string) bool {
+	_, ok := s.refs[name]
+	return ok
+}
+
+// Get returns the current secret of setting name
+func (s *Store) Get(name string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.values[name]
+}
+
+// Apply exports the secrets as environment variables so the regular
+// configuration loader picks them up
+func (s *Store) Apply() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for name, value := range s.values {
+		if err := os.Setenv(name, value); err != nil {
+			return fmt.Errorf("failed to apply secret %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// OnRotate runs fn after a refresh changed any of names. Changed secrets
+// nothing rotates are logged as needing a restart.
+func (s *Store) OnRotate(names []string, fn func() error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rotations = append(s.rotations, rotation{names: names, fn: fn})
+}
+
+// Run refreshes the secrets every interval until ctx is done
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Refresh(ctx)
+		}
+	}
+}
+
+// Refresh fetches the secrets again and rotates those that changed. A secret
+// that fails to fetch keeps its current value.
+func (s *Store) Refresh(ctx context.Context) {
+	values, errs := s.fetch(ctx)
+	for _, err := range errs {
+		slog.WarnContext(ctx, "secret refresh failed, keeping the current secret", "error", err)
+	}
+
+	s.mu.Lock()
+	changed := make(map[string]bool)
+	for name, value := range values {
+		if s.values[name] != value {
+			s.values[name] = value
+			changed[name] = true
+		}
+	}
+	rotations := slices.Clone(s.rotations)
+	s.mu.Unlock()
+	if len(changed) == 0 {
+		return
+	}
+
+	rotated := make(map[string]bool)
+	for _, rotation := range rotations {
+		if !slices.ContainsFunc(rotation.names, func(name string) bool { return changed[name] }) {
+			continue
+		}
+		for _, name := range rotation.names {
+			rotated[name] = true
+		}
+		if err := rotation.fn(); err != nil {
+			slog.ErrorContext(ctx, "failed to apply rotated secret", "secrets", rotation.names, "error", err)
+		}
+	}
+
+	var applied, restart []string
+	for name := range changed {
+		if rotated[name] {
+			applied = append(applied, name)
+		} else {
+			restart = append(restart, name)
+		}
+	}
+	slices.Sort(applied)
+	slices.Sort(restart)
+	if len(applied) > 0 {
+		slog.InfoContext(ctx, "secrets rotated", "provider", s.provider.Name(), "secrets", applied)
+	}
+	if len(restart) > 0 {
+		slog.WarnContext(ctx, "secrets changed, restart to apply them", "provider", s.provider.Name(), "secrets", restart)
+	}
+}
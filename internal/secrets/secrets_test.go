@@ -0,0 +1,63 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestStore_Vault(t *testing.T) {
+	jwtSecret := "first-secret"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/gateway": // KV version 2
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{
+				"data":     map[string]any{"jwt_secret": jwtSecret, "redis_password": "hunter2"},
+				"metadata": map[string]any{"version": 1},
+			}})
+		case "/v1/kv/redis": // KV version 1
+			json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"password": "swordfish"}})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	vault := NewVault(server.URL, "root", "", time.Second)
+	store := NewStore(vault, map[string]string{
+		"JWT_SECRET":     "secret/data/gateway#jwt_secret",
+		"REDIS_PASSWORD": "kv/redis",
+	}, time.Second)
+	if err := store.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if store.Get("JWT_SECRET") != "first-secret" || store.Get("REDIS_PASSWORD") != "swordfish" {
+		t.Errorf("got JWT_SECRET=%q REDIS_PASSWORD=%q", store.Get("JWT_SECRET"), store.Get("REDIS_PASSWORD"))
+	}
+	if _, err := vault.Fetch(context.Background(), "secret/data/gateway"); err == nil {
+		t.Error("expected an error when a secret with several fields doesn't name one")
+	}
+
+	// A refresh hands the rotated secret to its rotation
+	var rotated string
+	store.OnRotate([]string{"JWT_SECRET"}, func() error {
+		rotated = store.Get("JWT_SECRET")
+		return nil
+	})
+	store.Refresh(context.Background())
+	if rotated != "" {
+		t.Errorf("unchanged secret was rotated to %q", rotated)
+	}
+	jwtSecret = "second-secret"
+	store.Refresh(context.Background())
+	if rotated != "second-secret" {
+		t.Errorf("expected the secret to rotate to second-secret but got %q", rotated)
+	}
+}
@@ -0,0 +1,104 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Vault reads secrets from a HashiCorp Vault KV secrets engine, version 1 or
+// 2. A ref is the API path of the secret without /v1, e.g.
+// secret/data/gateway#jwt_secret; the field may be left out when the secret
+// has a single one.
+type Vault struct {
+	address   string
+	token     string
+	namespace string
+	client    *http.Client
+}
+
+// NewVault creates a Vault provider authenticating with token
+func NewVault(address, token, namespace string, timeout time.Duration) *Vault {
+	return &Vault{
+		address:   strings.TrimSuffix(address, "/"),
+		token:     token,
+		namespace: namespace,
+		client:    &http.Client{Timeout: timeout},
+	}
+}
+
+// Name identifies the provider in logs
+func (v *Vault) Name() string {
+	return "vault"
+}
+
+// Fetch reads the secret ref points to
+func (v *Vault) Fetch(ctx context.Context, ref string) (string, error) {
+	path, field, _ := strings.Cut(ref, "#")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.address+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	if v.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.namespace)
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return "", ErrNotFound
+	default:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("vault returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var secret struct {
+		Data map[string]any `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&secret); err != nil {
+		return "", fmt.Errorf("invalid vault response: %w", err)
+	}
+
+	// KV version 2 nests the fields under data.data, next to data.metadata
+	fields := secret.Data
+	if nested, ok := fields["data"].(map[string]any); ok {
+		if _, versioned := fields["metadata"]; versioned {
+			fields = nested
+		}
+	}
+	return pickField(fields, field)
+}
+
+// pickField returns field of a secret holding several, or its only field
+// when field is empty
+func pickField(fields map[string]any, field string) (string, error) {
+	if field == "" {
+		if len(fields) != 1 {
+			return "", fmt.Errorf("secret has %d fields, name one with #field", len(fields))
+		}
+		for name := range fields {
+			field = name
+		}
+	}
+
+	value, ok := fields[field]
+	if !ok {
+		return "", fmt.Errorf("field %s: %w", field, ErrNotFound)
+	}
+	text, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("field %s is not a string", field)
+	}
+	return text, nil
+}
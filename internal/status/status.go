@@ -0,0 +1,203 @@
+// Package status computes the public, customer-facing health rollup served at
+// GET /status. It reports one level per product area and never exposes service
+// names, addresses or internal error details.
+package status
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"hub-api-gateway/internal/config"
+	"hub-api-gateway/internal/metrics"
+	"hub-api-gateway/internal/proxy"
+)
+
+// Level is the public status of a product area
+type Level string
+
+const (
+	Operational Level = "operational"
+	Degraded    Level = "degraded"
+	Outage      Level = "outage"
+)
+
+// severity orders levels so the worst one can be selected
+func (l Level) severity() int {
+	switch l {
+	case Outage:
+		return 2
+	case Degraded:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// worst returns the more severe of two levels
+func worst(a, b Level) Level {
+	if b.severity() > a.severity() {
+		return b
+	}
+	return a
+}
+
+// AreaStatus is the status of a single product area
+type AreaStatus struct {
+	Name   string `json:"name"`
+	Status Level  `json:"status"`
+}
+
+// Rollup is the response of GET /status
+type Rollup struct {
+	Status    Level        `json:"status"`
+	Areas     []AreaStatus `json:"areas"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// signals are the health inputs for a single backend service
+type signals struct {
+	connection   string
+	breaker      string
+	draining     bool
+	requests     uint64 // requests since the previous evaluation
+	failures     uint64 // failures since the previous evaluation
+	minRequests  uint64
+	degradedRate int // error percentage at which the service is degraded
+	outageRate   int // error percentage at which the service is in outage
+}
+
+// level derives the public level of a service from its signals
+func (s signals) level() Level {
+	switch s.connection {
+	case "TRANSIENT_FAILURE", "SHUTDOWN":
+		return Outage
+	}
+
+	level := Operational
+	switch s.breaker {
+	case "OPEN":
+		return Outage
+	case "HALF_OPEN":
+		level = Degraded
+	}
+
+	if s.draining {
+		level = worst(level, Degraded)
+	}
+
+	if s.requests > 0 && s.requests >= s.minRequests {
+		errorPercent := int(s.failures * 100 / s.requests)
+		switch {
+		case errorPercent >= s.outageRate:
+			level = Outage
+		case errorPercent >= s.degradedRate:
+			level = worst(level, Degraded)
+		}
+	}
+
+	return level
+}
+
+// Handler serves the cached status rollup
+type Handler struct {
+	config   config.StatusConfig
+	registry *proxy.ServiceRegistry
+	metrics  *metrics.Metrics
+
+	mu       sync.Mutex
+	cached   *Rollup
+	previous map[string]metrics.ServiceSnapshot
+}
+
+// NewHandler creates a new status rollup handler
+func NewHandler(cfg config.StatusConfig, registry *proxy.ServiceRegistry, m *metrics.Metrics) *Handler {
+	return &Handler{
+		config:   cfg,
+		registry: registry,
+		metrics:  m,
+		previous: make(map[string]metrics.ServiceSnapshot),
+	}
+}
+
+// Handle serves GET /status
+func (h *Handler) Handle(w http.ResponseWriter, r *http.Request) {
+	rollup := h.Current()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(h.config.CacheTTL.Seconds())))
+	w.WriteHeader(http.StatusOK)
+
+	if err := json.NewEncoder(w).Encode(rollup); err != nil {
+		slog.ErrorContext(r.Context(), "failed to encode status rollup", "error", err)
+	}
+}
+
+// Current returns the cached rollup, recomputing it once the cache has expired
+func (h *Handler) Current() Rollup {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cached != nil && time.Since(h.cached.UpdatedAt) < h.config.CacheTTL {
+		return *h.cached
+	}
+
+	rollup := h.compute()
+	h.cached = &rollup
+	return rollup
+}
+
+// compute evaluates every configured service and rolls the levels up per area.
+// Error rates are measured over the interval since the previous computation.
+func (h *Handler) compute() Rollup {
+	snapshot := h.metrics.GetSnapshot()
+	breakers := h.registry.GetAllCircuitBreakers()
+
+	areas := make(map[string]Level)
+	for service, area := range h.config.ProductAreas {
+		s := signals{
+			minRequests:  uint64(h.config.MinRequests),
+			degradedRate: h.config.DegradedErrorPercent,
+			outageRate:   h.config.OutageErrorPercent,
+		}
+
+		s.connection, _ = h.registry.GetConnectionState(service)
+		if cb, ok := breakers[service]; ok {
+			s.breaker = cb.GetState().String()
+		}
+		_, s.draining = h.registry.GetDrainState(service)
+
+		current := snapshot.Services[service]
+		previous := h.previous[service]
+		if current.Requests >= previous.Requests {
+			s.requests = current.Requests - previous.Requests
+			s.failures = current.Failures - previous.Failures
+		}
+		h.previous[service] = current
+
+		level, seen := areas[area]
+		if !seen {
+			level = Operational
+		}
+		areas[area] = worst(level, s.level())
+	}
+
+	rollup := Rollup{
+		Status:    Operational,
+		Areas:     make([]AreaStatus, 0, len(areas)),
+		UpdatedAt: time.Now(),
+	}
+	for name, level := range areas {
+		rollup.Areas = append(rollup.Areas, AreaStatus{Name: name, Status: level})
+		rollup.Status = worst(rollup.Status, level)
+	}
+	sort.Slice(rollup.Areas, func(i, j int) bool {
+		return rollup.Areas[i].Name < rollup.Areas[j].Name
+	})
+
+	return rollup
+}
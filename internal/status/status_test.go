@@ -0,0 +1,38 @@
+package status
+
+import "testing"
+
+func TestSignalsLevel(t *testing.T) {
+	base := signals{minRequests: 20, degradedRate: 5, outageRate: 50}
+
+	tests := []struct {
+		name   string
+		modify func(s *signals)
+		want   Level
+	}{
+		{"idle service", func(s *signals) {}, Operational},
+		{"breaker open", func(s *signals) { s.breaker = "OPEN" }, Outage},
+		{"breaker half open", func(s *signals) { s.breaker = "HALF_OPEN" }, Degraded},
+		{"connection failure", func(s *signals) { s.connection = "TRANSIENT_FAILURE" }, Outage},
+		{"draining", func(s *signals) { s.draining = true }, Degraded},
+		{"elevated errors", func(s *signals) { s.requests, s.failures = 100, 10 }, Degraded},
+		{"failing requests", func(s *signals) { s.requests, s.failures = 100, 60 }, Outage},
+		{"too few requests", func(s *signals) { s.requests, s.failures = 5, 5 }, Operational},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := base
+			tt.modify(&s)
+			if got := s.level(); got != tt.want {
+				t.Errorf("expected %s but got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestWorst(t *testing.T) {
+	if worst(Operational, Degraded) != Degraded || worst(Outage, Degraded) != Outage {
+		t.Errorf("worst should select the most severe level")
+	}
+}
@@ -0,0 +1,262 @@
+// Package stream fans a shared upstream stream (e.g. market data) out to many
+// clients through bounded per-client send buffers, so a slow consumer such as
+// a mobile client on 3G loses messages or its connection instead of
+// back-pressuring the upstream and every other client.
+package stream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// Policy decides what happens when a client's buffer reaches its high-water mark
+type Policy string
+
+const (
+	// PolicyDropOldest discards the oldest queued message to make room (quotes are superseded anyway)
+	PolicyDropOldest Policy = "drop_oldest"
+	// PolicyDisconnect closes the client's stream; it reconnects and resynchronizes
+	PolicyDisconnect Policy = "disconnect"
+)
+
+// Drop reasons passed to the OnDrop callback
+const (
+	DropOldest   = "drop_oldest"
+	DropConsumer = "disconnect"
+)
+
+// ErrSlowConsumer is returned by Next after a client was disconnected for falling behind
+var ErrSlowConsumer = errors.New("slow consumer disconnected")
+
+// ErrClosed is returned by Next after the stream ended
+var ErrClosed = errors.New("stream closed")
+
+// ParsePolicy validates a slow-consumer policy name
+func ParsePolicy(name string) (Policy, error) {
+	switch Policy(name) {
+	case PolicyDropOldest, PolicyDisconnect:
+		return Policy(name), nil
+	}
+	return "", fmt.Errorf("unknown slow consumer policy %q (use drop_oldest or disconnect)", name)
+}
+
+// Buffer is a bounded send queue for one client. Push never blocks.
+type Buffer struct {
+	highWater int
+	policy    Policy
+	onDrop    func(reason string)
+
+	mu     sync.Mutex
+	queue  [][]byte
+	notify chan struct{}
+	err    error // Set once the buffer is closed
+
+	dropped atomic.Uint64
+}
+
+// NewBuffer creates a buffer holding up to highWater messages
+func NewBuffer(highWater int, policy Policy) *Buffer {
+	if highWater < 1 {
+		highWater = 1
+	}
+	return &Buffer{
+		highWater: highWater,
+		policy:    policy,
+		notify:    make(chan struct{}, 1),
+	}
+}
+
+// OnDrop registers a callback invoked for every dropped message or disconnected client
+func (b *Buffer) OnDrop(fn func(reason string)) {
+	b.onDrop = fn
+}
+
+// Push queues a message, applying the policy when the buffer is full. It
+// reports false once the buffer is closed.
+func (b *Buffer) Push(msg []byte) bool {
+	b.mu.Lock()
+	if b.err != nil {
+		b.mu.Unlock()
+		return false
+	}
+
+	reason := ""
+	if len(b.queue) >= b.highWater {
+		if b.policy == PolicyDisconnect {
+			b.closeLocked(ErrSlowConsumer)
+			b.mu.Unlock()
+			b.drop(DropConsumer)
+			return false
+		}
+		b.queue[0] = nil
+		b.queue = b.queue[1:]
+		reason = DropOldest
+	}
+	b.queue = append(b.queue, msg)
+	b.mu.Unlock()
+
+	if reason != "" {
+		b.drop(reason)
+	}
+	b.signal()
+	return true
+}
+
+// Next returns the oldest queued message, waiting until one arrives, ctx is
+// done or the buffer is closed. Queued messages are still delivered after a
+// normal close, but not after a slow-consumer disconnect.
+func (b *Buffer) Next(ctx context.Context) ([]byte, error) {
+	for {
+		b.mu.Lock()
+		if b.err == ErrSlowConsumer {
+			b.mu.Unlock()
+			return nil, ErrSlowConsumer
+		}
+		if len(b.queue) > 0 {
+			msg := b.queue[0]
+			b.queue[0] = nil
+			b.queue = b.queue[1:]
+			b.mu.Unlock()
+			return msg, nil
+		}
+		err := b.err
+		b.mu.Unlock()
+		if err != nil {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-b.notify:
+		}
+	}
+}
+
+// Close ends the stream; Next returns ErrClosed once the queue is drained
+func (b *Buffer) Close() {
+	b.mu.Lock()
+	b.closeLocked(ErrClosed)
+	b.mu.Unlock()
+}
+
+// Len returns the number of queued messages
+func (b *Buffer) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.queue)
+}
+
+// Dropped returns the number of messages dropped for this client
+func (b *Buffer) Dropped() uint64 {
+	return b.dropped.Load()
+}
+
+func (b *Buffer) closeLocked(err error) {
+	if b.err == nil {
+		b.err = err
+		if err == ErrSlowConsumer {
+			b.queue = nil
+		}
+		b.signal()
+	}
+}
+
+func (b *Buffer) signal() {
+	select {
+	case b.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (b *Buffer) drop(reason string) {
+	b.dropped.Add(1)
+	if b.onDrop != nil {
+		b.onDrop(reason)
+	}
+}
+
+// Broadcaster publishes upstream messages to every subscribed client buffer
+type Broadcaster struct {
+	highWater int
+	policy    Policy
+	onDrop    func(reason string)
+
+	mu          sync.RWMutex
+	subscribers map[*Buffer]struct{}
+}
+
+// NewBroadcaster creates a broadcaster whose subscribers buffer up to highWater messages
+func NewBroadcaster(highWater int, policy Policy) *Broadcaster {
+	return &Broadcaster{
+		highWater:   highWater,
+		policy:      policy,
+		subscribers: make(map[*Buffer]struct{}),
+	}
+}
+
+// OnDrop registers a callback invoked for every message dropped or client disconnected
+func (b *Broadcaster) OnDrop(fn func(reason string)) {
+	b.onDrop = fn
+}
+
+// Subscribe adds a client; call Unsubscribe when it goes away
+func (b *Broadcaster) Subscribe() *Buffer {
+	buffer := NewBuffer(b.highWater, b.policy)
+	buffer.OnDrop(b.onDrop)
+
+	b.mu.Lock()
+	b.subscribers[buffer] = struct{}{}
+	b.mu.Unlock()
+	return buffer
+}
+
+// Unsubscribe removes a client and closes its buffer
+func (b *Broadcaster) Unsubscribe(buffer *Buffer) {
+	b.mu.Lock()
+	delete(b.subscribers, buffer)
+	b.mu.Unlock()
+	buffer.Close()
+}
+
+// Publish queues msg for every subscriber without waiting on any of them;
+// subscribers disconnected as slow consumers are removed
+func (b *Broadcaster) Publish(msg []byte) {
+	var slow []*Buffer
+
+	b.mu.RLock()
+	for buffer := range b.subscribers {
+		if !buffer.Push(msg) {
+			slow = append(slow, buffer)
+		}
+	}
+	b.mu.RUnlock()
+
+	if len(slow) > 0 {
+		b.mu.Lock()
+		for _, buffer := range slow {
+			delete(b.subscribers, buffer)
+		}
+		b.mu.Unlock()
+	}
+}
+
+// Subscribers returns the number of connected clients
+func (b *Broadcaster) Subscribers() int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers)
+}
+
+// Close ends the stream for every subscriber
+func (b *Broadcaster) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for buffer := range b.subscribers {
+		buffer.Close()
+	}
+	b.subscribers = make(map[*Buffer]struct{})
+}
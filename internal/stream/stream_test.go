@@ -0,0 +1,87 @@
+package stream
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBuffer_DropOldest(t *testing.T) {
+	buffer := NewBuffer(2, PolicyDropOldest)
+	var reasons []string
+	buffer.OnDrop(func(reason string) { reasons = append(reasons, reason) })
+
+	for _, msg := range []string{"quote-1", "quote-2", "quote-3"} {
+		if !buffer.Push([]byte(msg)) {
+			t.Fatalf("Push(%s) = false, want true", msg)
+		}
+	}
+
+	ctx := context.Background()
+	for _, want := range []string{"quote-2", "quote-3"} {
+		msg, err := buffer.Next(ctx)
+		if err != nil || string(msg) != want {
+			t.Fatalf("Next() = (%s, %v), want %s", msg, err, want)
+		}
+	}
+	if buffer.Dropped() != 1 || len(reasons) != 1 || reasons[0] != DropOldest {
+		t.Errorf("expected one drop_oldest, got %d drops %v", buffer.Dropped(), reasons)
+	}
+}
+
+func TestBuffer_Disconnect(t *testing.T) {
+	buffer := NewBuffer(1, PolicyDisconnect)
+
+	buffer.Push([]byte("quote-1"))
+	if buffer.Push([]byte("quote-2")) {
+		t.Fatal("Push() past the high-water mark should disconnect")
+	}
+	if _, err := buffer.Next(context.Background()); !errors.Is(err, ErrSlowConsumer) {
+		t.Errorf("Next() error = %v, want ErrSlowConsumer", err)
+	}
+}
+
+func TestBroadcaster_SlowConsumerDoesNotBlock(t *testing.T) {
+	broadcaster := NewBroadcaster(4, PolicyDisconnect)
+	fast := broadcaster.Subscribe()
+	slow := broadcaster.Subscribe()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 10; i++ {
+			broadcaster.Publish([]byte("tick"))
+			if _, err := fast.Next(context.Background()); err != nil {
+				t.Errorf("fast consumer Next() error = %v", err)
+				return
+			}
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("publishing blocked on the slow consumer")
+	}
+
+	if broadcaster.Subscribers() != 1 {
+		t.Errorf("slow consumer should be removed, %d subscribers left", broadcaster.Subscribers())
+	}
+	if _, err := slow.Next(context.Background()); !errors.Is(err, ErrSlowConsumer) {
+		t.Errorf("slow consumer Next() error = %v, want ErrSlowConsumer", err)
+	}
+}
+
+func TestBuffer_CloseDrainsQueue(t *testing.T) {
+	buffer := NewBuffer(4, PolicyDropOldest)
+	buffer.Push([]byte("last"))
+	buffer.Close()
+
+	if msg, err := buffer.Next(context.Background()); err != nil || string(msg) != "last" {
+		t.Fatalf("Next() = (%s, %v), want queued message", msg, err)
+	}
+	if _, err := buffer.Next(context.Background()); !errors.Is(err, ErrClosed) {
+		t.Errorf("Next() error = %v, want ErrClosed", err)
+	}
+}
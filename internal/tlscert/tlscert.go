@@ -0,0 +1,198 @@
+// Package tlscert serves the gateway's HTTPS certificate from files that are
+// reloaded when they change, so renewed certificates (e.g. from certbot or
+// cert-manager) apply without a restart.
+package tlscert
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reloader holds the current certificate of a cert/key file pair
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	modTimes [2]time.Time
+}
+
+// NewReloader loads the certificate, failing if the files are unusable
+func NewReloader(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// NewPEMReloader loads the certificate from a PEM-encoded key pair, e.g. one
+// kept in a secrets manager. SetPEM replaces it; there are no files to Run on.
+func NewPEMReloader(certPEM, keyPEM []byte) (*Reloader, error) {
+	r := &Reloader{}
+	if _, err := r.SetPEM(certPEM, keyPEM); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// SetPEM replaces the certificate with a PEM-encoded key pair and reports
+// whether it changed. A broken pair leaves the current certificate in place.
+func (r *Reloader) SetPEM(certPEM, keyPEM []byte) (bool, error) {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return false, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cert != nil && bytes.Equal(r.cert.Certificate[0], cert.Certificate[0]) {
+		return false, nil
+	}
+	r.cert = &cert
+	return true, nil
+}
+
+// GetCertificate returns the current certificate, for tls.Config
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Reload loads the files again if either changed since the last load and
+// reports whether the certificate was replaced. A broken pair leaves the
+// current certificate in place.
+func (r *Reloader) Reload() (bool, error) {
+	modTimes, err := r.stat()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	unchanged := r.cert != nil && modTimes == r.modTimes
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert, r.modTimes = &cert, modTimes
+	return true, nil
+}
+
+// stat returns the modification times of the cert and key files
+func (r *Reloader) stat() ([2]time.Time, error) {
+	var modTimes [2]time.Time
+	for i, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return modTimes, fmt.Errorf("failed to read TLS certificate: %w", err)
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// Run checks the files for changes every interval until ctx is done
+func (r *Reloader) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := r.Reload()
+			switch {
+			case err != nil:
+				slog.Error("TLS certificate reload failed, keeping the current certificate", "cert_file", r.certFile, "error", err)
+			case reloaded:
+				slog.Info("TLS certificate reloaded", "cert_file", r.certFile, "expires", r.expiry().Format(time.RFC3339))
+			}
+		}
+	}
+}
+
+// expiry returns when the current certificate expires
+func (r *Reloader) expiry() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.cert == nil || r.cert.Leaf == nil {
+		return time.Time{}
+	}
+	return r.cert.Leaf.NotAfter
+}
+
+// ParseMinVersion parses a minimum TLS version ("1.2" or "1.3")
+func ParseMinVersion(version string) (uint16, error) {
+	switch version {
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported TLS version %q, use 1.2 or 1.3", version)
+	}
+}
+
+// ParseCipherSuites maps IANA cipher suite names to IDs. Only suites Go
+// considers secure are accepted; an empty list keeps Go's defaults. TLS 1.3
+// suites aren't configurable and are always enabled.
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	secure := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		secure[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		id, ok := secure[strings.TrimSpace(name)]
+		if !ok {
+			return nil, fmt.Errorf("unknown or insecure TLS cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// RedirectHandler sends plain HTTP requests to the same URL over HTTPS on
+// httpsPort. Reads get a 301; other methods a 308 so clients resend the body.
+func RedirectHandler(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+
+		status := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			status = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+	})
+}
@@ -0,0 +1,110 @@
+package tlscert
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCertificate writes a self-signed certificate for name to the files
+func writeCertificate(t *testing.T, certFile, keyFile, name string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+}
+
+func TestReloaderPicksUpRenewedCertificate(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	writeCertificate(t, certFile, keyFile, "old.hub.local")
+
+	reloader, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded, err := reloader.Reload(); reloaded || err != nil {
+		t.Errorf("Reload of unchanged files = %v, %v", reloaded, err)
+	}
+
+	// A half-written renewal keeps the current certificate
+	os.WriteFile(certFile, []byte("not a certificate"), 0o600)
+	os.Chtimes(certFile, time.Now().Add(time.Minute), time.Now().Add(time.Minute))
+	if _, err := reloader.Reload(); err == nil {
+		t.Errorf("expected an error for a broken certificate")
+	}
+	if cert, _ := reloader.GetCertificate(nil); cert.Leaf.Subject.CommonName != "old.hub.local" {
+		t.Errorf("certificate = %s, want the previous one", cert.Leaf.Subject.CommonName)
+	}
+
+	writeCertificate(t, certFile, keyFile, "new.hub.local")
+	os.Chtimes(certFile, time.Now().Add(2*time.Minute), time.Now().Add(2*time.Minute))
+	if reloaded, err := reloader.Reload(); !reloaded || err != nil {
+		t.Fatalf("Reload = %v, %v, want the renewed certificate", reloaded, err)
+	}
+	if cert, _ := reloader.GetCertificate(nil); cert.Leaf.Subject.CommonName != "new.hub.local" {
+		t.Errorf("certificate = %s, want new.hub.local", cert.Leaf.Subject.CommonName)
+	}
+}
+
+func TestParseSettings(t *testing.T) {
+	if version, err := ParseMinVersion("1.3"); err != nil || version != tls.VersionTLS13 {
+		t.Errorf("ParseMinVersion(1.3) = %x, %v", version, err)
+	}
+	if _, err := ParseMinVersion("1.0"); err == nil {
+		t.Errorf("TLS 1.0 must be rejected")
+	}
+
+	suites, err := ParseCipherSuites([]string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"})
+	if err != nil || len(suites) != 1 || suites[0] != tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 {
+		t.Errorf("ParseCipherSuites = %v, %v", suites, err)
+	}
+	if _, err := ParseCipherSuites([]string{"TLS_RSA_WITH_RC4_128_SHA"}); err == nil {
+		t.Errorf("insecure cipher suites must be rejected")
+	}
+}
+
+func TestRedirectHandler(t *testing.T) {
+	handler := RedirectHandler("8443")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "http://api.hub.local:8080/api/v1/orders?page=2", nil))
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "https://api.hub.local:8443/api/v1/orders?page=2" {
+		t.Errorf("GET redirect = %d %s", rec.Code, rec.Header().Get("Location"))
+	}
+
+	rec = httptest.NewRecorder()
+	RedirectHandler("443").ServeHTTP(rec, httptest.NewRequest("POST", "http://api.hub.local/api/v1/orders", nil))
+	if rec.Code != http.StatusPermanentRedirect || rec.Header().Get("Location") != "https://api.hub.local/api/v1/orders" {
+		t.Errorf("POST redirect = %d %s", rec.Code, rec.Header().Get("Location"))
+	}
+}
@@ -0,0 +1,123 @@
+package trace
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"hub-api-gateway/internal/logging"
+	"hub-api-gateway/internal/respwriter"
+)
+
+// Header flags a request for tracing; its value must be the trace token
+const Header = "X-Gateway-Trace"
+
+// redacted replaces credential values in stored traces
+const redacted = "[REDACTED]"
+
+// sensitiveHeaders are never stored in a trace
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+	"X-Admin-Token":       true,
+	"X-Reconnect-Ticket":  true,
+	"X-Gateway-Trace":     true,
+	"Proxy-Authorization": true,
+}
+
+// sensitiveQuery are query parameters that carry credentials
+var sensitiveQuery = map[string]bool{
+	"ticket":       true,
+	"token":        true,
+	"access_token": true,
+	"api_key":      true,
+}
+
+// Recorder traces requests flagged with the trace token
+type Recorder struct {
+	token string
+	store Store
+}
+
+// NewRecorder creates a recorder; requests are traced only when they carry
+// X-Gateway-Trace: <token>
+func NewRecorder(token string, store Store) *Recorder {
+	return &Recorder{token: token, store: store}
+}
+
+// Middleware starts a trace for flagged requests and saves it once the
+// response has been written
+func (rec *Recorder) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flag := r.Header.Get(Header)
+		if flag == "" || rec.token == "" || subtle.ConstantTimeCompare([]byte(flag), []byte(rec.token)) != 1 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		requestID := r.Header.Get("X-Request-ID")
+		if requestID == "" {
+			requestID = logging.NewRequestID()
+			r.Header.Set("X-Request-ID", requestID)
+		}
+		w.Header().Set("X-Request-ID", requestID)
+
+		t := &Trace{
+			RequestID: requestID,
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Query:     sanitizeQuery(r),
+			Headers:   sanitizeHeaders(r.Header),
+			StartedAt: time.Now(),
+		}
+
+		recorder := respwriter.New(w)
+		next.ServeHTTP(recorder, r.WithContext(WithTrace(r.Context(), t)))
+		t.finish(recorder.Status())
+
+		// The request context may already be cancelled; saving must not depend on it
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := rec.store.Save(ctx, t); err != nil {
+			slog.WarnContext(r.Context(), "failed to save trace", "trace_id", requestID, "error", err)
+			return
+		}
+		slog.InfoContext(r.Context(), "recorded trace", "trace_id", requestID, "method", r.Method, "path", r.URL.Path, "status", recorder.Status())
+	})
+}
+
+// sanitizeHeaders copies request headers without credentials
+func sanitizeHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			headers[name] = redacted
+			continue
+		}
+		headers[name] = strings.Join(values, ", ")
+	}
+	return headers
+}
+
+// sanitizeQuery copies query parameters without credentials
+func sanitizeQuery(r *http.Request) map[string]string {
+	values := r.URL.Query()
+	if len(values) == 0 {
+		return nil
+	}
+
+	query := make(map[string]string, len(values))
+	for name, value := range values {
+		if sensitiveQuery[strings.ToLower(name)] {
+			query[name] = redacted
+			continue
+		}
+		query[name] = strings.Join(value, ", ")
+	}
+	return query
+}
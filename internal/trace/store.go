@@ -0,0 +1,121 @@
+package trace
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNotFound is returned when no trace exists for a request ID
+var ErrNotFound = errors.New("trace not found")
+
+// Store persists finished traces
+type Store interface {
+	Save(ctx context.Context, t *Trace) error
+	Get(ctx context.Context, requestID string) (*Trace, error)
+}
+
+// RedisStore shares traces across gateway instances, so a trace can be
+// retrieved from any instance regardless of which one served the request
+type RedisStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+// NewRedisStore creates a Redis-backed trace store keeping traces for ttl
+func NewRedisStore(client *redis.Client, ttl time.Duration) *RedisStore {
+	return &RedisStore{client: client, ttl: ttl}
+}
+
+// Save stores the trace as JSON
+func (s *RedisStore) Save(ctx context.Context, t *Trace) error {
+	data, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+	return s.client.Set(ctx, "request_trace:"+t.RequestID, data, s.ttl).Err()
+}
+
+// Get loads a trace
+func (s *RedisStore) Get(ctx context.Context, requestID string) (*Trace, error) {
+	data, err := s.client.Get(ctx, "request_trace:"+requestID).Bytes()
+	if err == redis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var t Trace
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// MemoryStore keeps the most recent traces in process memory (single instance only)
+type MemoryStore struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	entries  map[string]*list.Element
+	order    *list.List // front = newest
+}
+
+// memoryEntry is a stored trace with its expiry
+type memoryEntry struct {
+	trace     *Trace
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates an in-memory store holding up to capacity traces for ttl
+func NewMemoryStore(capacity int, ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Save stores the trace, evicting the oldest one when full
+func (s *MemoryStore) Save(_ context.Context, t *Trace) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.entries[t.RequestID]; ok {
+		s.order.Remove(element)
+	}
+	s.entries[t.RequestID] = s.order.PushFront(&memoryEntry{trace: t, expiresAt: time.Now().Add(s.ttl)})
+
+	for s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*memoryEntry).trace.RequestID)
+	}
+	return nil
+}
+
+// Get returns a trace that hasn't expired
+func (s *MemoryStore) Get(_ context.Context, requestID string) (*Trace, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	element, ok := s.entries[requestID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	entry := element.Value.(*memoryEntry)
+	if time.Now().After(entry.expiresAt) {
+		s.order.Remove(element)
+		delete(s.entries, requestID)
+		return nil, ErrNotFound
+	}
+	return entry.trace, nil
+}
@@ -0,0 +1,95 @@
+// Package trace records the full journey of individual requests on demand, so
+// support engineers can look up exactly what happened to a customer's request.
+//
+// Internal tools flag a request by sending X-Gateway-Trace with the configured
+// trace token. The gateway then records routing, auth, backend calls, timings
+// and the response status, sanitizes credentials, and stores the trace under
+// the request ID for retrieval via /admin/traces/{requestId}.
+package trace
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Event is one step of a traced request
+type Event struct {
+	OffsetMs   float64           `json:"offsetMs"` // Since the request started
+	Stage      string            `json:"stage"`
+	Message    string            `json:"message"`
+	DurationMs float64           `json:"durationMs,omitempty"`
+	Details    map[string]string `json:"details,omitempty"`
+}
+
+// Trace is the recorded journey of a single request
+type Trace struct {
+	RequestID  string            `json:"requestId"`
+	Method     string            `json:"method"`
+	Path       string            `json:"path"`
+	Query      map[string]string `json:"query,omitempty"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Route      string            `json:"route,omitempty"`
+	StartedAt  time.Time         `json:"startedAt"`
+	DurationMs float64           `json:"durationMs"`
+	Status     int               `json:"status"`
+	Events     []Event           `json:"events"`
+
+	mu sync.Mutex
+}
+
+// Record appends an event. It is safe to call on a nil trace, so call sites
+// don't need to check whether the request is being traced.
+func (t *Trace) Record(stage, message string, duration time.Duration, details map[string]string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Events = append(t.Events, Event{
+		OffsetMs:   milliseconds(time.Since(t.StartedAt)),
+		Stage:      stage,
+		Message:    message,
+		DurationMs: milliseconds(duration),
+		Details:    details,
+	})
+}
+
+// SetRoute records the matched route name
+func (t *Trace) SetRoute(name string) {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Route = name
+}
+
+// finish stamps the final status and total duration
+func (t *Trace) finish(status int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Status = status
+	t.DurationMs = milliseconds(time.Since(t.StartedAt))
+}
+
+// milliseconds converts a duration to fractional milliseconds
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// traceKey is the context key for the active trace
+type traceKey struct{}
+
+// WithTrace stores a trace in the context
+func WithTrace(ctx context.Context, t *Trace) context.Context {
+	return context.WithValue(ctx, traceKey{}, t)
+}
+
+// FromContext returns the request's trace, or nil when it isn't traced
+func FromContext(ctx context.Context) *Trace {
+	t, _ := ctx.Value(traceKey{}).(*Trace)
+	return t
+}
@@ -0,0 +1,77 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRecorder_TracesFlaggedRequests(t *testing.T) {
+	store := NewMemoryStore(10, time.Hour)
+	recorder := NewRecorder("support-token", store)
+
+	handler := recorder.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestTrace := FromContext(r.Context())
+		requestTrace.SetRoute("get-balance")
+		requestTrace.Record("backend", "backend call", 15*time.Millisecond, map[string]string{"code": "OK"})
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	// Requests without the exact token are not traced
+	for _, flag := range []string{"", "wrong-token"} {
+		req := httptest.NewRequest("GET", "/api/v1/balance", nil)
+		req.Header.Set("X-Request-ID", "untraced-"+flag)
+		if flag != "" {
+			req.Header.Set(Header, flag)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		if _, err := store.Get(context.Background(), "untraced-"+flag); !errors.Is(err, ErrNotFound) {
+			t.Errorf("expected no trace for flag %q but got %v", flag, err)
+		}
+	}
+
+	req := httptest.NewRequest("GET", "/api/v1/balance?ticket=secret&page=2", nil)
+	req.Header.Set("X-Request-ID", "req-123")
+	req.Header.Set(Header, "support-token")
+	req.Header.Set("Authorization", "Bearer secret")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	saved, err := store.Get(context.Background(), "req-123")
+	if err != nil {
+		t.Fatalf("expected trace to be saved: %v", err)
+	}
+	if saved.Route != "get-balance" || saved.Status != http.StatusTeapot || len(saved.Events) != 1 {
+		t.Errorf("unexpected trace: %+v", saved)
+	}
+	if saved.Headers["Authorization"] != redacted || saved.Headers[Header] != redacted {
+		t.Errorf("expected credentials to be redacted but got %v", saved.Headers)
+	}
+	if saved.Query["ticket"] != redacted || saved.Query["page"] != "2" {
+		t.Errorf("expected sanitized query but got %v", saved.Query)
+	}
+}
+
+func TestMemoryStore_EvictsOldestAndExpired(t *testing.T) {
+	store := NewMemoryStore(2, time.Hour)
+	ctx := context.Background()
+	for _, id := range []string{"a", "b", "c"} {
+		store.Save(ctx, &Trace{RequestID: id})
+	}
+
+	if _, err := store.Get(ctx, "a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected oldest trace to be evicted but got %v", err)
+	}
+	if _, err := store.Get(ctx, "c"); err != nil {
+		t.Errorf("expected newest trace to be kept: %v", err)
+	}
+
+	expiring := NewMemoryStore(2, time.Nanosecond)
+	expiring.Save(ctx, &Trace{RequestID: "old"})
+	time.Sleep(time.Millisecond)
+	if _, err := expiring.Get(ctx, "old"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected expired trace to be gone but got %v", err)
+	}
+}
@@ -0,0 +1,281 @@
+// Package usage tracks when each route was last matched, so routes nobody
+// calls anymore can be found and pruned from the route file safely.
+package usage
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"hub-api-gateway/internal/router"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys shared by all gateway instances
+const (
+	lastSeenKey = "route_usage:last_seen" // Hash of route -> unix seconds
+	requestsKey = "route_usage:requests"  // Hash of route -> requests matched
+	sinceKey    = "route_usage:since"     // Unix seconds tracking started
+)
+
+// Route statuses in reports
+const (
+	StatusActive       = "active"        // Matched within the unused threshold
+	StatusUnused       = "unused"        // Last matched before the threshold
+	StatusNeverMatched = "never_matched" // Not matched since tracking started
+)
+
+// keepLatestScript stores each route's last-seen time unless another
+// instance already stored a later one
+var keepLatestScript = redis.NewScript(`
+for i = 1, #ARGV, 2 do
+  local current = tonumber(redis.call('HGET', KEYS[1], ARGV[i]) or '0')
+  if tonumber(ARGV[i + 1]) > current then
+    redis.call('HSET', KEYS[1], ARGV[i], ARGV[i + 1])
+  end
+end
+return 1
+`)
+
+// Tracker records route matches. With Redis, usage is shared across
+// instances and survives restarts; otherwise it covers this process only.
+type Tracker struct {
+	client *redis.Client
+
+	mu       sync.Mutex
+	since    time.Time
+	lastSeen map[string]time.Time
+	requests map[string]uint64
+
+	// Matches not yet written to Redis
+	pendingSeen     map[string]time.Time
+	pendingRequests map[string]uint64
+}
+
+// NewTracker creates a tracker, persisting usage to client when not nil
+func NewTracker(client *redis.Client) *Tracker {
+	return &Tracker{
+		client:          client,
+		since:           time.Now(),
+		lastSeen:        make(map[string]time.Time),
+		requests:        make(map[string]uint64),
+		pendingSeen:     make(map[string]time.Time),
+		pendingRequests: make(map[string]uint64),
+	}
+}
+
+// Load reads the usage recorded so far by every instance
+func (t *Tracker) Load(ctx context.Context) error {
+	if t.client == nil {
+		return nil
+	}
+
+	t.client.SetNX(ctx, sinceKey, time.Now().Unix(), 0)
+	since, err := t.client.Get(ctx, sinceKey).Int64()
+	if err != nil {
+		return err
+	}
+	lastSeen, err := t.client.HGetAll(ctx, lastSeenKey).Result()
+	if err != nil {
+		return err
+	}
+	requests, err := t.client.HGetAll(ctx, requestsKey).Result()
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.since = time.Unix(since, 0)
+	for route, value := range lastSeen {
+		if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+			t.lastSeen[route] = time.Unix(seconds, 0)
+		}
+	}
+	for route, value := range requests {
+		if count, err := strconv.ParseUint(value, 10, 64); err == nil {
+			t.requests[route] = count
+		}
+	}
+	return nil
+}
+
+// Record notes that a request matched the route
+func (t *Tracker) Record(route string) {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastSeen[route] = now
+	t.requests[route]++
+	if t.client != nil {
+		t.pendingSeen[route] = now
+		t.pendingRequests[route]++
+	}
+}
+
+// Flush writes matches recorded since the last flush to Redis
+func (t *Tracker) Flush(ctx context.Context) error {
+	if t.client == nil {
+		return nil
+	}
+
+	t.mu.Lock()
+	seen, requests := t.pendingSeen, t.pendingRequests
+	t.pendingSeen, t.pendingRequests = make(map[string]time.Time), make(map[string]uint64)
+	t.mu.Unlock()
+	if len(seen) == 0 {
+		return nil
+	}
+
+	args := make([]interface{}, 0, 2*len(seen))
+	for route, at := range seen {
+		args = append(args, route, at.Unix())
+	}
+	err := keepLatestScript.Run(ctx, t.client, []string{lastSeenKey}, args...).Err()
+	if err == nil {
+		_, err = t.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for route, count := range requests {
+				pipe.HIncrBy(ctx, requestsKey, route, int64(count))
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		// Keep the matches for the next flush
+		t.mu.Lock()
+		for route, at := range seen {
+			if at.After(t.pendingSeen[route]) {
+				t.pendingSeen[route] = at
+			}
+		}
+		for route, count := range requests {
+			t.pendingRequests[route] += count
+		}
+		t.mu.Unlock()
+	}
+	return err
+}
+
+// Run flushes matches to Redis every interval until ctx is done. Flush once
+// more on shutdown to keep the last ones.
+func (t *Tracker) Run(ctx context.Context, interval time.Duration) {
+	if t.client == nil {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.Flush(ctx); err != nil {
+				slog.Warn("failed to flush route usage", "error", err)
+			}
+		}
+	}
+}
+
+// RouteUsage is a route's entry in a usage report
+type RouteUsage struct {
+	Name     string     `json:"name"`
+	Method   string     `json:"method"`
+	Path     string     `json:"path"`
+	Service  string     `json:"service"`
+	Status   string     `json:"status"`
+	LastSeen *time.Time `json:"lastSeen,omitempty"`
+	Requests uint64     `json:"requests"`
+}
+
+// Report describes how recently every route was used
+type Report struct {
+	GeneratedAt   time.Time    `json:"generatedAt"`
+	TrackingSince time.Time    `json:"trackingSince"`
+	UnusedAfter   string       `json:"unusedAfter"`
+	Routes        []RouteUsage `json:"routes"`
+
+	// Routes safe to consider for pruning: unused ones, and never matched ones
+	// once tracking has run for longer than the threshold
+	PruneCandidates []string `json:"pruneCandidates"`
+}
+
+// Report builds a usage report of routes, calling those not matched for
+// unusedAfter unused. Routes are listed least recently used first.
+func (t *Tracker) Report(routes []router.Route, unusedAfter time.Duration) Report {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	report := Report{
+		GeneratedAt:     now,
+		TrackingSince:   t.since,
+		UnusedAfter:     unusedAfter.String(),
+		Routes:          make([]RouteUsage, 0, len(routes)),
+		PruneCandidates: []string{},
+	}
+	conclusive := now.Sub(t.since) >= unusedAfter
+
+	for _, route := range routes {
+		entry := RouteUsage{
+			Name:     route.Name,
+			Method:   route.Method,
+			Path:     route.Path,
+			Service:  route.Service,
+			Requests: t.requests[route.Name],
+		}
+		lastSeen, seen := t.lastSeen[route.Name]
+		switch {
+		case !seen:
+			entry.Status = StatusNeverMatched
+			if conclusive {
+				report.PruneCandidates = append(report.PruneCandidates, route.Name)
+			}
+		case now.Sub(lastSeen) >= unusedAfter:
+			entry.Status = StatusUnused
+			entry.LastSeen = &lastSeen
+			report.PruneCandidates = append(report.PruneCandidates, route.Name)
+		default:
+			entry.Status = StatusActive
+			entry.LastSeen = &lastSeen
+		}
+		report.Routes = append(report.Routes, entry)
+	}
+
+	sort.SliceStable(report.Routes, func(i, j int) bool {
+		a, b := report.Routes[i].LastSeen, report.Routes[j].LastSeen
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		return a.Before(*b)
+	})
+	return report
+}
+
+// RunReports logs the prune candidates among routes every interval until ctx is done
+func (t *Tracker) RunReports(ctx context.Context, interval, unusedAfter time.Duration, routes func() []router.Route) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report := t.Report(routes(), unusedAfter)
+			if len(report.PruneCandidates) == 0 {
+				slog.Info("all routes in use", "routes", len(report.Routes), "unused_after", report.UnusedAfter)
+				continue
+			}
+			slog.Warn("unused routes found", "count", len(report.PruneCandidates), "routes", report.PruneCandidates,
+				"unused_after", report.UnusedAfter, "tracking_since", report.TrackingSince.Format(time.RFC3339))
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package usage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"hub-api-gateway/internal/router"
+)
+
+func TestTrackerReport(t *testing.T) {
+	routes := []router.Route{
+		{Name: "get-quote", Method: "GET", Path: "/api/v1/market-data/{symbol}"},
+		{Name: "legacy-export", Method: "GET", Path: "/api/v1/export"},
+		{Name: "get-orders", Method: "GET", Path: "/api/v1/orders"},
+	}
+
+	tracker := NewTracker(nil)
+	tracker.Record("get-quote")
+	tracker.Record("get-quote")
+	tracker.Record("get-orders")
+	tracker.lastSeen["get-orders"] = time.Now().Add(-45 * 24 * time.Hour)
+
+	// Tracking hasn't run for 30 days: never matched routes aren't conclusive yet
+	report := tracker.Report(routes, 30*24*time.Hour)
+	statuses := make(map[string]string)
+	for _, route := range report.Routes {
+		statuses[route.Name] = route.Status
+	}
+	want := map[string]string{"get-quote": StatusActive, "legacy-export": StatusNeverMatched, "get-orders": StatusUnused}
+	if !reflect.DeepEqual(statuses, want) {
+		t.Errorf("statuses = %v, want %v", statuses, want)
+	}
+	if !reflect.DeepEqual(report.PruneCandidates, []string{"get-orders"}) {
+		t.Errorf("prune candidates = %v, want [get-orders]", report.PruneCandidates)
+	}
+	if report.Routes[0].Name != "legacy-export" || report.Routes[2].Name != "get-quote" || report.Routes[2].Requests != 2 {
+		t.Errorf("routes = %+v, want least recently used first", report.Routes)
+	}
+
+	tracker.since = time.Now().Add(-60 * 24 * time.Hour)
+	report = tracker.Report(routes, 30*24*time.Hour)
+	if !reflect.DeepEqual(report.PruneCandidates, []string{"legacy-export", "get-orders"}) {
+		t.Errorf("prune candidates = %v, want [legacy-export get-orders]", report.PruneCandidates)
+	}
+}
@@ -0,0 +1,159 @@
+// Package watchdog cancels requests that outlive an absolute hard limit. A
+// request still running well past its route's timeout usually means a stuck
+// goroutine (a backend call ignoring its deadline, a lock never released), so
+// besides cancelling it the watchdog logs a goroutine dump to find where.
+package watchdog
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// ErrStuck is the cancellation cause of requests cancelled by the watchdog
+var ErrStuck = errors.New("request exceeded the watchdog hard limit")
+
+// Options configures a Watchdog
+type Options struct {
+	Interval     time.Duration // How often in-flight requests are checked
+	DumpInterval time.Duration // Minimum time between goroutine dumps
+	DumpMaxBytes int           // Goroutine dumps are truncated to this size
+}
+
+// Watchdog tracks in-flight requests and cancels those past their hard limit
+type Watchdog struct {
+	opts    Options
+	onStuck func(routeName string)
+
+	mu       sync.Mutex
+	nextID   uint64
+	active   map[uint64]*request
+	lastDump time.Time
+}
+
+// request is an in-flight request tracked by the watchdog
+type request struct {
+	ctx     context.Context
+	route   string
+	method  string
+	path    string
+	started time.Time
+	limit   time.Duration
+	cancel  context.CancelCauseFunc
+}
+
+// New creates a watchdog; call Run to start checking requests
+func New(opts Options) *Watchdog {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	if opts.DumpMaxBytes <= 0 {
+		opts.DumpMaxBytes = 64 << 10
+	}
+	return &Watchdog{
+		opts:   opts,
+		active: make(map[uint64]*request),
+	}
+}
+
+// OnStuck registers a callback invoked for every cancelled request
+func (w *Watchdog) OnStuck(fn func(routeName string)) {
+	w.onStuck = fn
+}
+
+// Middleware tracks requests to a route and cancels their context once they
+// run longer than limit. A non-positive limit disables tracking.
+func (w *Watchdog) Middleware(routeName string, limit time.Duration, next http.Handler) http.Handler {
+	if limit <= 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithCancelCause(r.Context())
+		defer cancel(nil)
+
+		id := w.track(&request{
+			ctx:     r.Context(),
+			route:   routeName,
+			method:  r.Method,
+			path:    r.URL.Path,
+			started: time.Now(),
+			limit:   limit,
+			cancel:  cancel,
+		})
+		defer w.untrack(id)
+
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+// Run checks in-flight requests every interval until ctx is done
+func (w *Watchdog) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.opts.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			w.sweep(now)
+		}
+	}
+}
+
+func (w *Watchdog) track(req *request) uint64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.nextID++
+	w.active[w.nextID] = req
+	return w.nextID
+}
+
+func (w *Watchdog) untrack(id uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.active, id)
+}
+
+// sweep cancels requests past their limit. Each is reported once; the
+// goroutine dump is taken before cancelling so it shows where they are stuck.
+func (w *Watchdog) sweep(now time.Time) {
+	w.mu.Lock()
+	var stuck []*request
+	for id, req := range w.active {
+		if now.Sub(req.started) > req.limit {
+			stuck = append(stuck, req)
+			delete(w.active, id)
+		}
+	}
+	dump := len(stuck) > 0 && now.Sub(w.lastDump) >= w.opts.DumpInterval
+	if dump {
+		w.lastDump = now
+	}
+	w.mu.Unlock()
+
+	if dump {
+		slog.Error("goroutine dump for stuck requests", "stuck", len(stuck),
+			"goroutines", runtime.NumGoroutine(), "stacks", goroutineDump(w.opts.DumpMaxBytes))
+	}
+
+	for _, req := range stuck {
+		req.cancel(ErrStuck)
+		slog.ErrorContext(req.ctx, "cancelled stuck request", "route", req.route, "method", req.method, "path", req.path,
+			"elapsed", now.Sub(req.started).Round(time.Millisecond).String(), "limit", req.limit.String())
+		if w.onStuck != nil {
+			w.onStuck(req.route)
+		}
+	}
+}
+
+// goroutineDump returns the stacks of all goroutines, truncated to maxBytes
+func goroutineDump(maxBytes int) string {
+	buf := make([]byte, maxBytes)
+	return string(buf[:runtime.Stack(buf, true)])
+}
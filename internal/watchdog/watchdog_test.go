@@ -0,0 +1,67 @@
+package watchdog
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWatchdogCancelsStuckRequests(t *testing.T) {
+	w := New(Options{Interval: time.Hour})
+	var stuckRoutes []string
+	w.OnStuck(func(route string) { stuckRoutes = append(stuckRoutes, route) })
+
+	entered := make(chan struct{})
+	cause := make(chan error, 1)
+	handler := w.Middleware("get-quote", time.Second, http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-r.Context().Done()
+		cause <- context.Cause(r.Context())
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/market-data/AAPL", nil))
+		close(done)
+	}()
+	<-entered
+
+	w.sweep(time.Now())
+	select {
+	case <-done:
+		t.Fatal("request cancelled before its hard limit")
+	default:
+	}
+
+	w.sweep(time.Now().Add(2 * time.Second))
+	if err := <-cause; !errors.Is(err, ErrStuck) {
+		t.Errorf("cancellation cause = %v, want ErrStuck", err)
+	}
+	<-done
+
+	if len(stuckRoutes) != 1 || stuckRoutes[0] != "get-quote" {
+		t.Errorf("stuck callbacks = %v, want [get-quote]", stuckRoutes)
+	}
+	if len(w.active) != 0 {
+		t.Errorf("%d requests still tracked", len(w.active))
+	}
+
+	// Reported requests are not reported again
+	w.sweep(time.Now().Add(time.Minute))
+	if len(stuckRoutes) != 1 {
+		t.Errorf("stuck request reported %d times", len(stuckRoutes))
+	}
+}
+
+func TestWatchdogUntracksFinishedRequests(t *testing.T) {
+	w := New(Options{})
+	handler := w.Middleware("get-quote", time.Second, http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/market-data/AAPL", nil))
+
+	if len(w.active) != 0 {
+		t.Errorf("%d requests still tracked after finishing", len(w.active))
+	}
+}